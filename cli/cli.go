@@ -38,6 +38,7 @@ func setupCommands(cfg *cmd.Config) *cobra.Command {
 	mc := master.NewMasterClient(cfg.MasterAddr, false)
 	mc.SetTimeout(cfg.Timeout)
 	mc.SetClientIDKey(cfg.ClientIDKey)
+	mc.SetAPIToken(cfg.APIToken)
 	cfsRootCmd := cmd.NewRootCmd(mc)
 	completionCmd := &cobra.Command{
 		Use:   "completion",
@@ -0,0 +1,130 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cubefs/cubefs/proto"
+	sdk "github.com/cubefs/cubefs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdAPITokenUse   = "apitoken [COMMAND]"
+	cmdAPITokenShort = "Manage the tokens of the master admin APIs"
+)
+
+func newAPITokenCmd(client *sdk.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdAPITokenUse,
+		Short: cmdAPITokenShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newAPITokenCreateCmd(client),
+		newAPITokenDeleteCmd(client),
+		newAPITokenListCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdAPITokenCreateShort = "Create an API token of the role"
+	cmdAPITokenDeleteShort = "Delete an API token"
+	cmdAPITokenListShort   = "List API tokens"
+)
+
+func newAPITokenCreateCmd(client *sdk.MasterClient) *cobra.Command {
+	var optDescription string
+	cmd := &cobra.Command{
+		Use:   CliOpCreate + " [NAME] [ROLE]",
+		Short: cmdAPITokenCreateShort,
+		Long: fmt.Sprintf("Create an API token of the role [%v, %v, %v, %v], the token is only shown once",
+			proto.APITokenRoleReadOnly, proto.APITokenRoleVolumeAdmin, proto.APITokenRoleNodeOperator, proto.APITokenRoleClusterAdmin),
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				info *proto.APITokenInfo
+				err  error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if info, err = client.AdminAPI().CreateAPIToken(args[0], args[1], optDescription); err != nil {
+				return
+			}
+			stdout("API token [%v] of role [%v] has been created: %v\n", info.Name, info.Role, info.Token)
+		},
+	}
+	cmd.Flags().StringVar(&optDescription, "description", "", "Specify the description of the token")
+	return cmd
+}
+
+func newAPITokenDeleteCmd(client *sdk.MasterClient) *cobra.Command {
+	var optYes bool
+	cmd := &cobra.Command{
+		Use:   CliOpDelete + " [NAME]",
+		Short: cmdAPITokenDeleteShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			if !optYes {
+				stdout("Delete API token [%v] (yes/no)[no]:", args[0])
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					stdout("Abort by user.\n")
+					return
+				}
+			}
+			if err = client.AdminAPI().DeleteAPIToken(args[0]); err != nil {
+				return
+			}
+			stdout("API token [%v] has been deleted.\n", args[0])
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newAPITokenListCmd(client *sdk.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdAPITokenListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				tokens []*proto.APITokenInfo
+				err    error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if tokens, err = client.AdminAPI().ListAPITokens(); err != nil {
+				return
+			}
+			tablePattern := "%-24v    %-14v    %-20v    %v\n"
+			stdout(tablePattern, "NAME", "ROLE", "CREATE TIME", "DESCRIPTION")
+			for _, token := range tokens {
+				stdout(tablePattern, token.Name, token.Role, token.CreateTime, token.Description)
+			}
+		},
+	}
+	return cmd
+}
@@ -48,6 +48,7 @@ type Config struct {
 	MasterAddr  []string `json:"masterAddr"`
 	Timeout     uint16   `json:"timeout"`
 	ClientIDKey string   `json:"clientIDKey"`
+	APIToken    string   `json:"apiToken"`
 }
 
 func newConfigCmd() *cobra.Command {
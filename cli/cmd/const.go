@@ -60,6 +60,7 @@ const (
 	CliOpDataNodeOp                   = "datanodeop"
 	CliOpVolOp                        = "volop"
 	CliOpToLeader                     = "to-leader"
+	CliOpSplit                        = "split"
 
 	CliOpSetDecommissionLimit    = "set-decommission-limit"
 	CliOpQueryDecommissionStatus = "query-decommission-status"
@@ -136,6 +137,7 @@ const (
 	CliFlagEnableQuota                  = "enableQuota"
 	CliFlagDeleteLockTime               = "delete-lock-time"
 	CliFlagClientIDKey                  = "clientIDKey"
+	CliFlagSplitKey                     = "split-key"
 	CliFlagMarkDiskBrokenThreshold      = "markBrokenDiskThreshold"
 	CliFlagForce                        = "force"
 	CliFlagEnableCrossZone              = "cross-zone"
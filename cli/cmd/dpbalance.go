@@ -0,0 +1,160 @@
+// Copyright 2025 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdDataBalanceUse   = "dp-balance [COMMAND]"
+	cmdDataBalanceShort = "balance disk usage ratio in data node"
+)
+
+func newDataBalanceCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdDataBalanceUse,
+		Short: cmdDataBalanceShort,
+	}
+	cmd.AddCommand(
+		newCreateDataBalanceTaskCmd(client),
+		newShowDataBalanceTaskCmd(client),
+		newRunDataBalanceTaskCmd(client),
+		newStopDataBalanceTaskCmd(client),
+		newDeleteDataBalanceTaskCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdCreateDataBalanceTaskShort = "Create data partition balance task"
+	cmdShowDataBalanceTaskShort   = "Show data partition balance task"
+	cmdRunDataBalanceTaskShort    = "Run data partition balance task"
+	cmdStopDataBalanceTaskShort   = "Stop data partition balance task"
+	cmdDeleteDataBalanceTaskShort = "Delete data partition balance task"
+
+	defaultDataBalanceParallel = 5
+)
+
+func newCreateDataBalanceTaskCmd(client *master.MasterClient) *cobra.Command {
+	var parallel int
+	cmd := &cobra.Command{
+		Use:   cmdCreateBalanceTask,
+		Short: cmdCreateDataBalanceTaskShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			var task *proto.DataClusterPlan
+			if task, err = client.AdminAPI().CreateDataNodeBalanceTask(parallel); err != nil {
+				return
+			}
+			out, err := json.MarshalIndent(task, "", "    ")
+			if err != nil {
+				stdout("marshal task failed: %s", err.Error())
+				return
+			}
+			stdout("%s", string(out))
+		},
+	}
+	cmd.Flags().IntVar(&parallel, "parallel", defaultDataBalanceParallel, "Specify the data partitions migrated at the same time")
+	return cmd
+}
+
+func newShowDataBalanceTaskCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdShowBalanceTask,
+		Short: cmdShowDataBalanceTaskShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			var task *proto.DataClusterPlan
+			if task, err = client.AdminAPI().GetDataNodeBalanceTask(); err != nil {
+				return
+			}
+			out, err := json.MarshalIndent(task, "", "    ")
+			if err != nil {
+				stdout("marshal task failed: %s", err.Error())
+				return
+			}
+			stdout("%s", string(out))
+		},
+	}
+	return cmd
+}
+
+func newRunDataBalanceTaskCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdRunBalanceTask,
+		Short: cmdRunDataBalanceTaskShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			var result string
+			if result, err = client.AdminAPI().RunDataNodeBalanceTask(); err != nil {
+				return
+			}
+			stdout("%s", result)
+		},
+	}
+	return cmd
+}
+
+func newStopDataBalanceTaskCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdStopBalanceTask,
+		Short: cmdStopDataBalanceTaskShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			var result string
+			if result, err = client.AdminAPI().StopDataNodeBalanceTask(); err != nil {
+				return
+			}
+			stdout("%s", result)
+		},
+	}
+	return cmd
+}
+
+func newDeleteDataBalanceTaskCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdDeleteBalanceTask,
+		Short: cmdDeleteDataBalanceTaskShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			var result string
+			if result, err = client.AdminAPI().DeleteDataNodeBalanceTask(); err != nil {
+				return
+			}
+			stdout("%s", result)
+		},
+	}
+	return cmd
+}
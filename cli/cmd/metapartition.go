@@ -39,6 +39,7 @@ func newMetaPartitionCmd(client *master.MasterClient) *cobra.Command {
 		newMetaPartitionDecommissionCmd(client),
 		newMetaPartitionReplicateCmd(client),
 		newMetaPartitionDeleteReplicaCmd(client),
+		newMetaPartitionSplitCmd(client),
 	)
 	return cmd
 }
@@ -49,6 +50,7 @@ const (
 	cmdMetaPartitionDecommissionShort  = "Decommission a replication of the meta partition to a new address"
 	cmdMetaPartitionReplicateShort     = "Add a replication of the meta partition on a new address"
 	cmdMetaPartitionDeleteReplicaShort = "Delete a replication of the meta partition on a fixed address"
+	cmdMetaPartitionSplitShort         = "Split the meta partition at an inode boundary, the upper inodes move to a new meta partition"
 )
 
 func newMetaPartitionGetCmd(client *master.MasterClient) *cobra.Command {
@@ -360,3 +362,31 @@ func newMetaPartitionDeleteReplicaCmd(client *master.MasterClient) *cobra.Comman
 	cmd.Flags().StringVar(&clientIDKey, CliFlagClientIDKey, client.ClientIDKey(), CliUsageClientIDKey)
 	return cmd
 }
+
+func newMetaPartitionSplitCmd(client *master.MasterClient) *cobra.Command {
+	var splitKey uint64
+	cmd := &cobra.Command{
+		Use:   CliOpSplit + " [META PARTITION ID]",
+		Short: cmdMetaPartitionSplitShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				result      string
+			)
+			defer func() {
+				errout(err)
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if result, err = client.AdminAPI().SplitMetaPartition(partitionID, splitKey); err != nil {
+				return
+			}
+			stdout("%v\n", result)
+		},
+	}
+	cmd.Flags().Uint64Var(&splitKey, CliFlagSplitKey, 0, "The first inode moved to the new meta partition, the median inode by default")
+	return cmd
+}
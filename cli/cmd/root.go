@@ -83,6 +83,9 @@ func NewRootCmd(client *master.MasterClient) *CubeFSCmd {
 		newFlashNodeCmd(client),
 		newFlashGroupCmd(client),
 		newBalanceCmd(client),
+		newDataBalanceCmd(client),
+		newAPITokenCmd(client),
+		newTLSCmd(client),
 	)
 	return cmd
 }
@@ -0,0 +1,136 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/cubefs/cubefs/proto"
+	sdk "github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/util/tlsutil"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdTLSUse   = "tls [COMMAND]"
+	cmdTLSShort = "Manage the TLS trust distributed to the nodes"
+)
+
+func newTLSCmd(client *sdk.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdTLSUse,
+		Short: cmdTLSShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newTLSSetCmd(client),
+		newTLSInfoCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdTLSSetShort  = "Set the TLS mode and the CA bundle of the cluster"
+	cmdTLSInfoShort = "Show the TLS mode and the CA bundle of the cluster"
+)
+
+func newTLSSetCmd(client *sdk.MasterClient) *cobra.Command {
+	var optCAFile string
+	var optKeepCA bool
+	cmd := &cobra.Command{
+		Use:   CliOpSet + " [MODE]",
+		Short: cmdTLSSetShort,
+		Long: fmt.Sprintf(`Set the TLS mode [%v, %v, %v, %v] of the cluster, the mode only takes effect on the nodes
+whose local mode is weaker and local CA file verifies the master, the empty mode "" keeps the local config. Upgrade the cluster by the modes one after another, after the certificates
+are deployed to all the nodes and the clients:
+  %v: accept both TLS and plaintext, dial plaintext
+  %v: accept both TLS and plaintext, dial TLS and fall back to plaintext
+  %v: accept and dial TLS only`,
+			tlsutil.ModeDisable, tlsutil.ModePermissive, tlsutil.ModePrefer, tlsutil.ModeStrict,
+			tlsutil.ModePermissive, tlsutil.ModePrefer, tlsutil.ModeStrict),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			info := &proto.TLSTrustInfo{TLSMode: args[0]}
+			if !tlsutil.IsValidMode(info.TLSMode) {
+				err = fmt.Errorf("invalid tls mode: %v", info.TLSMode)
+				return
+			}
+			if optCAFile != "" {
+				var data []byte
+				if data, err = os.ReadFile(optCAFile); err != nil {
+					return
+				}
+				if !x509.NewCertPool().AppendCertsFromPEM(data) {
+					err = fmt.Errorf("no certificate found in CA file: %v", optCAFile)
+					return
+				}
+				info.TLSCAPem = string(data)
+			} else if optKeepCA {
+				var old *proto.TLSTrustInfo
+				if old, err = client.AdminAPI().GetTLSTrust(); err != nil {
+					return
+				}
+				info.TLSCAPem = old.TLSCAPem
+			}
+			if err = client.AdminAPI().SetTLSTrust(info); err != nil {
+				return
+			}
+			stdout("TLS mode of the cluster is set to [%v]\n", info.TLSMode)
+		},
+	}
+	cmd.Flags().StringVar(&optCAFile, "ca-file", "", "Specify the PEM file of the CA bundle distributed to the nodes")
+	cmd.Flags().BoolVar(&optKeepCA, "keep-ca", true, "Keep the CA bundle of the cluster if no CA file specified")
+	return cmd
+}
+
+func newTLSInfoCmd(client *sdk.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpInfo,
+		Short: cmdTLSInfoShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				info *proto.TLSTrustInfo
+				err  error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if info, err = client.AdminAPI().GetTLSTrust(); err != nil {
+				return
+			}
+			stdout("TLS mode : %v\n", info.TLSMode)
+			rest := []byte(info.TLSCAPem)
+			for {
+				var block *pem.Block
+				if block, rest = pem.Decode(rest); block == nil {
+					break
+				}
+				cert, e := x509.ParseCertificate(block.Bytes)
+				if e != nil {
+					continue
+				}
+				stdout("CA       : %v (expires %v)\n", cert.Subject, cert.NotAfter.Format(proto.TimeFormat))
+			}
+		},
+	}
+	return cmd
+}
@@ -60,6 +60,7 @@ import (
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/stat"
 	sysutil "github.com/cubefs/cubefs/util/sys"
+	"github.com/cubefs/cubefs/util/tlsutil"
 	"github.com/cubefs/cubefs/util/ump"
 	"github.com/jacobsa/daemonize"
 	_ "go.uber.org/automaxprocs"
@@ -428,6 +429,13 @@ func main() {
 	}
 	stat.ClearStat()
 
+	if err = tlsutil.Init(cfg); err != nil {
+		err = errors.NewErrorf("Init tls fail: %v\n", err)
+		fmt.Println(err)
+		daemonize.SignalOutcome(err)
+		os.Exit(1)
+	}
+
 	if opt.EnableAudit {
 		_, err = auditlog.InitAuditWithPrefix(opt.Logpath, LoggerPrefix, int64(auditlog.DefaultAuditLogSize),
 			auditlog.NewAuditPrefix(opt.Master, opt.Volname, opt.SubDir, opt.MountPoint))
@@ -47,6 +47,7 @@ import (
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
 	sysutil "github.com/cubefs/cubefs/util/sys"
+	"github.com/cubefs/cubefs/util/tlsutil"
 	"github.com/cubefs/cubefs/util/ump"
 	"github.com/jacobsa/daemonize"
 )
@@ -333,6 +334,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = tlsutil.Init(cfg); err != nil {
+		log.LogFlush()
+		err = errors.NewErrorf("Fatal: failed to init tls - %v", err)
+		syslog.Println(err)
+		daemonize.SignalOutcome(err)
+		os.Exit(1)
+	}
+
 	if profPort != "" {
 		go func() {
 			mainMux := http.NewServeMux()
@@ -742,7 +742,7 @@ func (dp *DataPartition) getLeaderPartitionNormalExtentInfo() (extentInfos []*st
 
 // Get the partition size from the leader.
 func (dp *DataPartition) getLeaderPartitionSize(maxExtentID uint64) (logicSize, realUsedSize uint64, err error) {
-	var conn *net.TCPConn
+	var conn net.Conn
 
 	p := NewPacketToGetPartitionSize(dp.partitionID)
 	p.ExtentID = maxExtentID
@@ -782,7 +782,7 @@ func (dp *DataPartition) getLeaderPartitionSize(maxExtentID uint64) (logicSize,
 }
 
 func (dp *DataPartition) getMaxExtentIDAndPartitionSize(target string) (maxExtentID, PartitionSize uint64, err error) {
-	var conn *net.TCPConn
+	var conn net.Conn
 	p := NewPacketToGetMaxExtentIDAndPartitionSIze(dp.partitionID)
 
 	conn, err = gConnPool.GetConnect(target) // get remote connect
@@ -843,7 +843,7 @@ func (dp *DataPartition) broadcastMinAppliedID(minAppliedID uint64) (err error)
 		}
 
 		p := NewPacketToBroadcastMinAppliedID(dp.partitionID, minAppliedID)
-		var conn *net.TCPConn
+		var conn net.Conn
 		conn, err = gConnPool.GetConnect(targetReplica)
 		if err != nil {
 			return
@@ -901,7 +901,7 @@ func (dp *DataPartition) getAllReplicaAppliedID() (allAppliedID []uint64, replyN
 
 // Get target members' applied id
 func (dp *DataPartition) getRemoteAppliedID(target string, p *repl.Packet) (appliedID uint64, err error) {
-	var conn *net.TCPConn
+	var conn net.Conn
 	start := time.Now().UnixNano()
 	defer func() {
 		if err != nil {
@@ -44,6 +44,7 @@ import (
 	"github.com/cubefs/cubefs/util/loadutil"
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/strutil"
+	"github.com/cubefs/cubefs/util/tlsutil"
 
 	"github.com/cubefs/cubefs/depends/xtaci/smux"
 )
@@ -83,6 +84,7 @@ const (
 	ConfigKeyPort          = "port"            // int
 	ConfigKeyMasterAddr    = "masterAddr"      // array
 	ConfigKeyZone          = "zoneName"        // string
+	ConfigKeyLabels        = "topologyLabels"  // object, such as {"rack": "r1"}
 	ConfigKeyDisks         = "disks"           // array
 	ConfigKeyRaftDir       = "raftDir"         // string
 	ConfigKeyRaftHeartbeat = "raftHeartbeat"   // string
@@ -166,6 +168,7 @@ type DataNode struct {
 	space                              *SpaceManager
 	port                               string
 	zoneName                           string
+	topologyLabels                     map[string]string
 	clusterID                          string
 	bindIp                             bool
 	localServerAddr                    string
@@ -444,6 +447,8 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	poolSize := cfg.GetInt64(proto.CfgHttpPoolSize)
 	syslog.Printf("parseConfig: http pool size %d", poolSize)
 	MasterClient.SetTransport(proto.GetHttpTransporter(&proto.HttpCfg{PoolSize: int(poolSize)}))
+	// the node operator token to register the node and report the task responses
+	MasterClient.SetAPIToken(cfg.GetString(proto.MasterAPIToken))
 
 	if err = MasterClient.Start(); err != nil {
 		return err
@@ -453,6 +458,9 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	if s.zoneName == "" {
 		s.zoneName = DefaultZoneName
 	}
+	if s.topologyLabels, err = proto.ParseTopologyLabels(cfg.GetValue(ConfigKeyLabels)); err != nil {
+		return err
+	}
 	s.metricsDegrade = cfg.GetInt64(CfgMetricsDegrade)
 
 	s.serviceIDKey = cfg.GetString(ConfigServiceIDKey)
@@ -978,7 +986,7 @@ func (s *DataNode) startTCPService() (err error) {
 	if s.bindIp {
 		addr = fmt.Sprintf("%s:%v", LocalIP, s.port)
 	}
-	l, err := net.Listen(NetworkProtocol, addr)
+	l, err := tlsutil.Listen(NetworkProtocol, addr)
 	log.LogDebugf("action[startTCPService] listen %v address(%v).", NetworkProtocol, addr)
 	if err != nil {
 		log.LogError("failed to listen, err:", err)
@@ -1016,9 +1024,10 @@ func (s *DataNode) stopTCPService() (err error) {
 func (s *DataNode) serveConn(conn net.Conn) {
 	space := s.space
 	space.Stats().AddConnection()
-	c, _ := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
+	if c, ok := tlsutil.TCPConn(conn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
 	packetProcessor := repl.NewReplProtocol(conn, s.Prepare, s.OperatePacket, s.Post)
 	packetProcessor.ServerConn()
 	space.Stats().RemoveConnection()
@@ -1034,7 +1043,7 @@ func (s *DataNode) startSmuxService(cfg *config.Config) (err error) {
 	log.LogInfof("SmuxListenAddr: (%v)", addr)
 
 	// server
-	l, err := net.Listen(NetworkProtocol, addr)
+	l, err := tlsutil.Listen(NetworkProtocol, addr)
 	log.LogDebugf("action[startSmuxService] listen %v address(%v).", NetworkProtocol, addr)
 	if err != nil {
 		log.LogError("failed to listen smux addr, err:", err)
@@ -1072,14 +1081,15 @@ func (s *DataNode) stopSmuxService() (err error) {
 func (s *DataNode) serveSmuxConn(conn net.Conn) {
 	space := s.space
 	space.Stats().AddConnection()
-	c, _ := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
+	if c, ok := tlsutil.TCPConn(conn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
 	var sess *smux.Session
 	var err error
 	sess, err = smux.Server(conn, s.smuxServerConfig)
 	if err != nil {
-		log.LogErrorf("action[serveSmuxConn] failed to serve smux connection, addr(%v), err(%v)", c.RemoteAddr(), err)
+		log.LogErrorf("action[serveSmuxConn] failed to serve smux connection, addr(%v), err(%v)", conn.RemoteAddr(), err)
 		return
 	}
 	defer func() {
@@ -1181,7 +1191,7 @@ func (s *DataNode) initConnPool() {
 		}
 		s.putRepairConnFunc = func(conn net.Conn, forceClose bool) {
 			log.LogDebugf("[dataNode.putRepairConnFunc] put tcp conn, addr(%v), forceClose(%v)", conn.RemoteAddr().String(), forceClose)
-			gConnPool.PutConnect(conn.(*net.TCPConn), forceClose)
+			gConnPool.PutConnect(conn, forceClose)
 		}
 	}
 }
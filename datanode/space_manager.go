@@ -742,6 +742,7 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 	stat.Unlock()
 
 	response.ZoneName = s.zoneName
+	response.Labels = s.topologyLabels
 	response.ReceivedForbidWriteOpOfProtoVer0 = s.nodeForbidWriteOpOfProtoVer0
 	response.PartitionReports = make([]*proto.DataPartitionReport, 0)
 	space := s.space
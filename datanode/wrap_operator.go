@@ -41,6 +41,7 @@ import (
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/strutil"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 var ErrForbiddenDataPartition = errors.New("the data partition is forbidden")
@@ -189,7 +190,7 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 	case proto.OpDeleteDataPartition:
 		s.handlePacketToDeleteDataPartition(p)
 	case proto.OpDataNodeHeartbeat:
-		s.handleHeartbeatPacket(p)
+		s.handleHeartbeatPacket(p, c)
 	case proto.OpGetAppliedId:
 		s.handlePacketToGetAppliedID(p)
 	case proto.OpDecommissionDataPartition:
@@ -540,7 +541,7 @@ func (s *DataNode) checkDecommissionDisks(decommissionDisks []string) {
 }
 
 // Handle OpHeartbeat packet.
-func (s *DataNode) handleHeartbeatPacket(p *repl.Packet) {
+func (s *DataNode) handleHeartbeatPacket(p *repl.Packet, c net.Conn) {
 	var err error
 	task := &proto.AdminTask{}
 	err = json.Unmarshal(p.Data, task)
@@ -571,6 +572,9 @@ func (s *DataNode) handleHeartbeatPacket(p *repl.Packet) {
 					log.LogWarnf("action[handleHeartbeatPacket] change GOGC, old(%v) new(%v)", oldGOGC, request.DataNodeGOGC)
 				}
 			}
+			if err := tlsutil.SetTrustFrom(c, request.TLSMode, request.TLSCAPem); err != nil {
+				log.LogErrorf("action[handleHeartbeatPacket] apply tls trust failed, tlsMode(%v) err(%v)", request.TLSMode, err)
+			}
 			if s.diskQosEnableFromMaster != request.EnableDiskQos {
 				log.LogWarnf("action[handleHeartbeatPacket] master command disk qos enable change to [%v], local conf enable [%v]",
 					request.EnableDiskQos,
@@ -1728,7 +1732,7 @@ func (s *DataNode) handlePacketToDataPartitionTryToLeader(p *repl.Packet) {
 
 func (s *DataNode) forwardToRaftLeader(dp *DataPartition, p *repl.Packet, force bool) (ok bool, err error) {
 	var (
-		conn       *net.TCPConn
+		conn       net.Conn
 		leaderAddr string
 	)
 
@@ -41,7 +41,7 @@ func newHeartbeatTransport(raftServer *RaftServer, config *TransportConfig) (*he
 		err      error
 	)
 
-	if listener, err = net.Listen("tcp", config.HeartbeatAddr); err != nil {
+	if listener, err = util.Listen(config.HeartbeatAddr); err != nil {
 		return nil, err
 	}
 	t := &heartbeatTransport{
@@ -45,7 +45,7 @@ func newReplicateTransport(raftServer *RaftServer, config *TransportConfig) (*re
 		err      error
 	)
 
-	if listener, err = net.Listen("tcp", config.ReplicateAddr); err != nil {
+	if listener, err = util.Listen(config.ReplicateAddr); err != nil {
 		return nil, err
 	}
 	t := &replicateTransport{
@@ -26,15 +26,45 @@ type ConnTimeout struct {
 	writeTime time.Duration
 }
 
+// Dial and Listen create the connections of the transports, they are replaced to secure the transports.
+var (
+	Dial = func(addr string, timeout time.Duration) (net.Conn, error) {
+		return net.DialTimeout("tcp", addr, timeout)
+	}
+	Listen = func(addr string) (net.Listener, error) {
+		return net.Listen("tcp", addr)
+	}
+)
+
+// tcpConn returns the TCP connection under the connection wrapped by TLS.
+func tcpConn(conn net.Conn) *net.TCPConn {
+	for {
+		switch c := conn.(type) {
+		case *net.TCPConn:
+			return c
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return nil
+		}
+	}
+}
+
+func setTCPOptions(conn net.Conn) {
+	if c := tcpConn(conn); c != nil {
+		c.SetNoDelay(true)
+		c.SetLinger(0)
+		c.SetKeepAlive(true)
+	}
+}
+
 func DialTimeout(addr string, connTime time.Duration) (*ConnTimeout, error) {
-	conn, err := net.DialTimeout("tcp", addr, connTime)
+	conn, err := Dial(addr, connTime)
 	if err != nil {
 		return nil, err
 	}
 
-	conn.(*net.TCPConn).SetNoDelay(true)
-	conn.(*net.TCPConn).SetLinger(0)
-	conn.(*net.TCPConn).SetKeepAlive(true)
+	setTCPOptions(conn)
 	return &ConnTimeout{conn: conn, addr: addr}, nil
 }
 
@@ -43,9 +73,7 @@ func NewConnTimeout(conn net.Conn) *ConnTimeout {
 		return nil
 	}
 
-	conn.(*net.TCPConn).SetNoDelay(true)
-	conn.(*net.TCPConn).SetLinger(0)
-	conn.(*net.TCPConn).SetKeepAlive(true)
+	setTCPOptions(conn)
 	return &ConnTimeout{conn: conn, addr: conn.RemoteAddr().String()}
 }
 
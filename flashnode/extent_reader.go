@@ -100,7 +100,7 @@ func extentReadWithRetry(reqPacket *proto.Packet, source *proto.DataSource, afte
 }
 
 func readFromDataPartition(addr string, reqPacket *proto.Packet, afterReadFunc cachengine.ReadExtentAfter, timeout int) (readBytes int, err error) {
-	var conn *net.TCPConn
+	var conn net.Conn
 	var why string
 	defer func() {
 		extentReaderConnPool.PutConnect(conn, err != nil)
@@ -128,7 +128,7 @@ func readFromDataPartition(addr string, reqPacket *proto.Packet, afterReadFunc c
 	return
 }
 
-func getReadReply(conn *net.TCPConn, reqPacket *proto.Packet, afterReadFunc cachengine.ReadExtentAfter, timeout int) (readBytes int, err error) {
+func getReadReply(conn net.Conn, reqPacket *proto.Packet, afterReadFunc cachengine.ReadExtentAfter, timeout int) (readBytes int, err error) {
 	buf := bytespool.Alloc(int(reqPacket.Size))
 	defer bytespool.Free(buf)
 
@@ -474,6 +474,8 @@ func (f *FlashNode) parseConfig(cfg *config.Config) (err error) {
 	masters := cfg.GetStringSlice(proto.MasterAddr)
 	f.masters = masters
 	f.mc = master.NewMasterClient(masters, false)
+	// the node operator token to register the node and report the task responses
+	f.mc.SetAPIToken(cfg.GetString(proto.MasterAPIToken))
 	if len(f.mc.Nodes()) == 0 {
 		return errors.New("master addresses is empty")
 	}
@@ -33,6 +33,7 @@ import (
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/stat"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 func (f *FlashNode) preHandle(conn net.Conn, p *proto.Packet) error {
@@ -104,6 +105,9 @@ func (f *FlashNode) opFlashNodeHeartbeat(conn net.Conn, p *proto.Packet) (err er
 	decode.UseNumber()
 	if err = decode.Decode(adminTask); err == nil {
 		f.SetTimeout(req.FlashNodeHandleReadTimeout, req.FlashNodeReadDataNodeTimeout)
+		if e := tlsutil.SetTrustFrom(conn, req.TLSMode, req.TLSCAPem); e != nil {
+			log.LogErrorf("action[opFlashNodeHeartbeat] apply tls trust failed, tlsMode(%v) err(%v)", req.TLSMode, e)
+		}
 	} else {
 		log.LogWarnf("decode HeartBeatRequest error: %s", err.Error())
 		resp.Status = proto.TaskFailed
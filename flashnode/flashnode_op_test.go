@@ -35,7 +35,7 @@ const (
 	blockSize        = 1024
 )
 
-func newTCPConn(t *testing.T) *net.TCPConn {
+func newTCPConn(t *testing.T) net.Conn {
 	tcpAddr, err := net.ResolveTCPAddr("tcp", flashServer.localAddr)
 	require.NoError(t, err)
 	conn, err := net.DialTCP("tcp", nil, tcpAddr)
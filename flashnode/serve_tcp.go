@@ -23,6 +23,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 // NewServer creates a new flash node instance.
@@ -32,7 +33,7 @@ func NewServer() *FlashNode {
 
 // StartTcpService binds and listens to the specified port.
 func (f *FlashNode) startTcpServer() (err error) {
-	f.tcpListener, err = net.Listen("tcp", ":"+f.listen)
+	f.tcpListener, err = tlsutil.Listen("tcp", ":"+f.listen)
 	if err != nil {
 		return
 	}
@@ -75,13 +76,14 @@ func (f *FlashNode) stopServer() {
 
 func (f *FlashNode) serveConn(conn net.Conn) {
 	defer conn.Close()
-	c := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
+	if c, ok := tlsutil.TCPConn(conn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
 
 	remoteAddr := conn.RemoteAddr().String()
 	for {
-		c.SetReadDeadline(time.Now().Add(time.Second * _tcpServerTimeoutSec))
+		conn.SetReadDeadline(time.Now().Add(time.Second * _tcpServerTimeoutSec))
 		select {
 		case <-f.stopCh:
 			return
@@ -89,7 +91,7 @@ func (f *FlashNode) serveConn(conn net.Conn) {
 		}
 
 		p := proto.NewPacketReqID()
-		if err := p.ReadFromConn(c, proto.NoReadDeadlineTime); err != nil {
+		if err := p.ReadFromConn(conn, proto.NoReadDeadlineTime); err != nil {
 			if err != io.EOF {
 				log.LogWarn("flashnode read from remote", err.Error())
 			}
@@ -0,0 +1,523 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/auditlog"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	batchItemsPerRound   = 1000
+	batchReportFlushSize = 4 << 20 // 4MB
+	batchTaskSucceeded   = "succeeded"
+	batchTaskFailed      = "failed"
+)
+
+var errBatchStopped = errors.New("batch task is stopped")
+
+// batchS3Client is the subset of the S3 API used by the batch task, which is served by the objectnodes.
+type batchS3Client interface {
+	GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error)
+	PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	ListObjectsV2Pages(input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool) error
+	PutObjectTagging(input *s3.PutObjectTaggingInput) (*s3.PutObjectTaggingOutput, error)
+	DeleteObjectTagging(input *s3.DeleteObjectTaggingInput) (*s3.DeleteObjectTaggingOutput, error)
+	CopyObject(input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error)
+	DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+	PutObjectAcl(input *s3.PutObjectAclInput) (*s3.PutObjectAclOutput, error)
+}
+
+type batchItem struct {
+	Bucket    string
+	Key       string
+	VersionId string
+}
+
+// BatchWorker executes one shard of the batch job. The objects are operated in rounds, and the
+// checkpoint is committed once the report of the round is written, so that the shard is resumed
+// from the checkpoint by any lcnode. The operations are idempotent to be redone after the checkpoint.
+type BatchWorker struct {
+	ID       string
+	job      *proto.BatchJob
+	client   batchS3Client
+	routines int
+	stopC    chan struct{}
+	stopOnce sync.Once
+
+	mutex     sync.RWMutex
+	committed proto.BatchJobTask // reported to the master
+
+	pending proto.BatchJobTask // including the rounds not reported yet
+	report  *bytes.Buffer
+	writer  *csv.Writer
+}
+
+func NewBatchWorker(task *proto.BatchTask, client batchS3Client, routines int) *BatchWorker {
+	report := new(bytes.Buffer)
+	return &BatchWorker{
+		ID:        task.Id,
+		job:       task.Job,
+		client:    client,
+		routines:  routines,
+		stopC:     make(chan struct{}),
+		committed: *task.Task,
+		pending:   *task.Task,
+		report:    report,
+		writer:    csv.NewWriter(report),
+	}
+}
+
+func (w *BatchWorker) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopC)
+	})
+}
+
+func (w *BatchWorker) stopped() bool {
+	select {
+	case <-w.stopC:
+		return true
+	default:
+		return false
+	}
+}
+
+// Checkpoint returns the committed progress and checkpoint of the shard.
+func (w *BatchWorker) Checkpoint() *proto.BatchJobTask {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	task := w.committed
+	return &task
+}
+
+func (w *BatchWorker) Run() (err error) {
+	if w.job.Manifest.IsPrefix() {
+		err = w.runPrefix()
+	} else {
+		err = w.runManifest()
+	}
+	if err != nil {
+		return
+	}
+	return w.commit(true)
+}
+
+func parseManifestRecord(record, fields []string) (item *batchItem, err error) {
+	if len(record) < len(fields) {
+		return nil, fmt.Errorf("expect %v fields, got %v", len(fields), len(record))
+	}
+	item = &batchItem{}
+	for i, field := range fields {
+		switch field {
+		case proto.BatchManifestFieldBucket:
+			item.Bucket = record[i]
+		case proto.BatchManifestFieldKey:
+			// the keys in the manifest are url-encoded
+			if item.Key, err = url.QueryUnescape(record[i]); err != nil {
+				return nil, err
+			}
+		case proto.BatchManifestFieldVersionId:
+			item.VersionId = record[i]
+		}
+	}
+	if item.Bucket == "" || item.Key == "" {
+		return nil, fmt.Errorf("empty bucket or key")
+	}
+	return
+}
+
+// runManifest operates the objects in the lines of the CSV manifest which belong to the shard,
+// i.e. the line number modulo the shards is the shard.
+func (w *BatchWorker) runManifest() (err error) {
+	m := w.job.Manifest
+	output, err := w.client.GetObject(&s3.GetObjectInput{Bucket: aws.String(m.Bucket), Key: aws.String(m.Key)})
+	if err != nil {
+		return fmt.Errorf("get manifest %v/%v: %v", m.Bucket, m.Key, err)
+	}
+	defer output.Body.Close()
+	if m.ETag != "" && strings.Trim(aws.StringValue(output.ETag), `"`) != strings.Trim(m.ETag, `"`) {
+		return fmt.Errorf("manifest %v/%v is modified: etag(%v) expect(%v)", m.Bucket, m.Key,
+			aws.StringValue(output.ETag), m.ETag)
+	}
+	fields := m.Fields
+	if len(fields) == 0 {
+		fields = []string{proto.BatchManifestFieldBucket, proto.BatchManifestFieldKey}
+	}
+
+	reader := csv.NewReader(output.Body)
+	reader.FieldsPerRecord = -1
+	items := make([]*batchItem, 0, batchItemsPerRound)
+	line := int64(-1)
+	for {
+		var record []string
+		if record, err = reader.Read(); err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("read manifest line %v: %v", line, err)
+		}
+		if line < w.pending.NextLine || line%int64(w.job.Shards) != int64(w.pending.Shard) {
+			continue
+		}
+		var item *batchItem
+		if item, err = parseManifestRecord(record, fields); err != nil {
+			return fmt.Errorf("invalid manifest line %v: %v", line, err)
+		}
+		items = append(items, item)
+		if len(items) < batchItemsPerRound {
+			continue
+		}
+		next := line + 1
+		if err = w.process(items, func(t *proto.BatchJobTask) { t.NextLine = next }); err != nil {
+			return
+		}
+		items = items[:0]
+		if w.stopped() {
+			return errBatchStopped
+		}
+	}
+	next := line + 1
+	return w.process(items, func(t *proto.BatchJobTask) {
+		if next > t.NextLine {
+			t.NextLine = next
+		}
+	})
+}
+
+// runPrefix operates the objects under the prefix of the shard after the marker.
+func (w *BatchWorker) runPrefix() (err error) {
+	m := w.job.Manifest
+	if w.pending.Shard >= len(m.Prefixes) {
+		return fmt.Errorf("shard %v out of prefixes %v", w.pending.Shard, len(m.Prefixes))
+	}
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(m.Bucket),
+		Prefix: aws.String(m.Prefixes[w.pending.Shard]),
+	}
+	if w.pending.Marker != "" {
+		input.StartAfter = aws.String(w.pending.Marker)
+	}
+	op := w.job.Operation
+	var processErr error
+	err = w.client.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, last bool) bool {
+		items := make([]*batchItem, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			key := aws.StringValue(object.Key)
+			if op.Type == proto.BatchOpPutObjectCopy && op.TargetPrefix != "" &&
+				(op.TargetBucket == "" || op.TargetBucket == m.Bucket) && strings.HasPrefix(key, op.TargetPrefix) {
+				// skip the copies made by the job itself
+				continue
+			}
+			items = append(items, &batchItem{Bucket: m.Bucket, Key: key})
+		}
+		if len(page.Contents) > 0 {
+			marker := aws.StringValue(page.Contents[len(page.Contents)-1].Key)
+			if processErr = w.process(items, func(t *proto.BatchJobTask) { t.Marker = marker }); processErr != nil {
+				return false
+			}
+		}
+		if w.stopped() {
+			processErr = errBatchStopped
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("list objects %v/%v: %v", m.Bucket, aws.StringValue(input.Prefix), err)
+	}
+	return processErr
+}
+
+// process operates the objects of the round concurrently, and commits the checkpoint if the
+// report is written.
+func (w *BatchWorker) process(items []*batchItem, checkpoint func(t *proto.BatchJobTask)) error {
+	results := make([]error, len(items))
+	limit := make(chan struct{}, w.routines)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, item *batchItem) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			results[i] = w.execute(item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	for i, item := range items {
+		w.pending.Progress.Total++
+		if results[i] == nil {
+			w.pending.Progress.Succeeded++
+		} else {
+			w.pending.Progress.Failed++
+			log.LogWarnf("batch task(%v) %v %v/%v err: %v", w.ID, w.job.Operation.Type, item.Bucket, item.Key, results[i])
+		}
+		w.addReport(item, results[i])
+	}
+	checkpoint(&w.pending)
+	return w.commit(false)
+}
+
+func (w *BatchWorker) execute(item *batchItem) (err error) {
+	op := w.job.Operation
+	var versionId *string
+	if item.VersionId != "" {
+		versionId = aws.String(item.VersionId)
+	}
+	switch op.Type {
+	case proto.BatchOpPutObjectTagging:
+		if len(op.Tags) == 0 {
+			_, err = w.client.DeleteObjectTagging(&s3.DeleteObjectTaggingInput{
+				Bucket: aws.String(item.Bucket), Key: aws.String(item.Key), VersionId: versionId,
+			})
+			return
+		}
+		tagSet := make([]*s3.Tag, 0, len(op.Tags))
+		for _, tag := range op.Tags {
+			tagSet = append(tagSet, &s3.Tag{Key: aws.String(tag.Key), Value: aws.String(tag.Value)})
+		}
+		_, err = w.client.PutObjectTagging(&s3.PutObjectTaggingInput{
+			Bucket: aws.String(item.Bucket), Key: aws.String(item.Key), VersionId: versionId,
+			Tagging: &s3.Tagging{TagSet: tagSet},
+		})
+	case proto.BatchOpPutObjectCopy:
+		target := op.TargetBucket
+		if target == "" {
+			target = item.Bucket
+		}
+		source := url.QueryEscape(item.Bucket + "/" + item.Key)
+		if item.VersionId != "" {
+			source += "?versionId=" + item.VersionId
+		}
+		input := &s3.CopyObjectInput{
+			Bucket:            aws.String(target),
+			Key:               aws.String(op.TargetPrefix + item.Key),
+			CopySource:        aws.String(source),
+			MetadataDirective: aws.String(s3.MetadataDirectiveCopy),
+			TaggingDirective:  aws.String(s3.TaggingDirectiveCopy),
+		}
+		if op.StorageClass != "" {
+			input.StorageClass = aws.String(op.StorageClass)
+		}
+		_, err = w.client.CopyObject(input)
+	case proto.BatchOpDeleteObject:
+		_, err = w.client.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(item.Bucket), Key: aws.String(item.Key), VersionId: versionId,
+		})
+	case proto.BatchOpPutObjectAcl:
+		_, err = w.client.PutObjectAcl(&s3.PutObjectAclInput{
+			Bucket: aws.String(item.Bucket), Key: aws.String(item.Key), VersionId: versionId,
+			ACL: aws.String(op.CannedACL),
+		})
+	default:
+		err = fmt.Errorf("unknown batch operation %v", op.Type)
+	}
+	return
+}
+
+// addReport adds the result of the object to the report in the format of
+// Bucket,Key,VersionId,TaskStatus,ErrorCode,HTTPStatusCode,ResultMessage.
+func (w *BatchWorker) addReport(item *batchItem, err error) {
+	report := w.job.Report
+	if report == nil || !report.Enabled || (err == nil && report.Scope == proto.BatchReportFailedTasksOnly) {
+		return
+	}
+	status, code, statusCode, message := batchTaskSucceeded, "", http.StatusOK, "Successful"
+	if err != nil {
+		status, code, statusCode, message = batchTaskFailed, "InternalError", http.StatusInternalServerError, err.Error()
+		if e, ok := err.(awserr.RequestFailure); ok {
+			code, statusCode, message = e.Code(), e.StatusCode(), e.Message()
+		}
+	}
+	_ = w.writer.Write([]string{
+		item.Bucket, url.QueryEscape(item.Key), item.VersionId, status, code, strconv.Itoa(statusCode), message,
+	})
+}
+
+// commit writes the report and commits the checkpoint. The report is buffered until it's large
+// enough unless it's the final commit, and the checkpoint is not committed before the report.
+func (w *BatchWorker) commit(final bool) error {
+	w.writer.Flush()
+	if w.report.Len() > 0 {
+		if !final && w.report.Len() < batchReportFlushSize {
+			return nil
+		}
+		report := w.job.Report
+		key := proto.BatchReportKey(report.Prefix, w.job.JobId, w.pending.Shard, w.pending.ReportSeq)
+		if _, err := w.client.PutObject(&s3.PutObjectInput{
+			Bucket:      aws.String(report.Bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(w.report.Bytes()),
+			ContentType: aws.String("text/csv"),
+		}); err != nil {
+			return fmt.Errorf("put report %v/%v: %v", report.Bucket, key, err)
+		}
+		w.pending.ReportSeq++
+		w.report.Reset()
+	}
+	w.mutex.Lock()
+	w.committed = w.pending
+	w.mutex.Unlock()
+	return nil
+}
+
+func (l *LcNode) newBatchClient(job *proto.BatchJob) (batchS3Client, error) {
+	if l.s3Endpoint == "" {
+		return nil, fmt.Errorf("%v is not configured", configS3EndpointStr)
+	}
+	userInfo, err := l.mc.UserAPI().GetAKInfo(job.AccessKey)
+	if err != nil {
+		return nil, fmt.Errorf("get user of access key %v: %v", job.AccessKey, err)
+	}
+	return l.newS3Client(userInfo)
+}
+
+// newS3Client returns the client of the objectnodes with the credentials of the user.
+func (l *LcNode) newS3Client(userInfo *proto.UserInfo) (*s3.S3, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:         aws.String(l.s3Endpoint),
+		Region:           aws.String(l.clusterID),
+		Credentials:      credentials.NewStaticCredentials(userInfo.AccessKey, userInfo.SecretKey, ""),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(sess), nil
+}
+
+func (l *LcNode) startBatch(adminTask *proto.AdminTask) {
+	request := adminTask.Request.(*proto.LcNodeBatchTaskRequest)
+	resp := &proto.LcNodeBatchTaskResponse{LcNode: l.localServerAddr}
+	adminTask.Response = resp
+	task := request.Task
+	if task == nil || task.Job == nil || task.Task == nil {
+		resp.Status = proto.TaskFailed
+		resp.Result = "batch task is empty"
+		return
+	}
+	resp.ID = task.Id
+	resp.Task = task.Task
+	log.LogInfof("startBatch: batch task(%v) received, checkpoint(%+v)", task.Id, task.Task)
+
+	client, err := l.newBatchClient(task.Job)
+	if err != nil {
+		log.LogErrorf("startBatch: batch task(%v) err: %v", task.Id, err)
+		resp.Status = proto.TaskFailed
+		resp.Result = err.Error()
+		return
+	}
+	w := NewBatchWorker(task, client, batchRoutineNumPerTask)
+	l.batchMutex.Lock()
+	if _, ok := l.batchWorkers[w.ID]; ok {
+		l.batchMutex.Unlock()
+		// the task is dispatched again before the heartbeat, the running one reports the result
+		log.LogWarnf("startBatch: batch task(%v) is already running", task.Id)
+		return
+	}
+	l.batchWorkers[w.ID] = w
+	l.batchMutex.Unlock()
+	defer func() {
+		l.batchMutex.Lock()
+		delete(l.batchWorkers, w.ID)
+		l.batchMutex.Unlock()
+	}()
+
+	start := time.Now()
+	err = w.Run()
+	resp.Task = w.Checkpoint()
+	switch {
+	case err == errBatchStopped:
+		resp.Status = proto.TaskSucceeds
+		resp.Stopped = true
+		err = nil
+	case err != nil:
+		log.LogErrorf("startBatch: batch task(%v) err: %v", task.Id, err)
+		resp.Status = proto.TaskFailed
+		resp.Result = err.Error()
+	default:
+		resp.Status = proto.TaskSucceeds
+	}
+	auditlog.LogMasterOp("LcBatch", fmt.Sprintf("task(%v), from master(%v), stopped(%v), progress(%+v), %v",
+		task.Id, request.MasterAddr, resp.Stopped, resp.Task.Progress, time.Since(start).String()), err)
+}
+
+func (l *LcNode) batchTasks() map[string]*proto.LcNodeBatchTaskResponse {
+	l.batchMutex.Lock()
+	defer l.batchMutex.Unlock()
+	tasks := make(map[string]*proto.LcNodeBatchTaskResponse, len(l.batchWorkers))
+	for id, w := range l.batchWorkers {
+		tasks[id] = &proto.LcNodeBatchTaskResponse{
+			ID:     id,
+			LcNode: l.localServerAddr,
+			Task:   w.Checkpoint(),
+		}
+	}
+	return tasks
+}
+
+func (l *LcNode) stopBatchWorkers() {
+	l.batchMutex.Lock()
+	defer l.batchMutex.Unlock()
+	for _, w := range l.batchWorkers {
+		w.Stop()
+	}
+}
+
+func (l *LcNode) httpServiceStopBatchTask(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("httpServiceStopBatchTask ParseForm failed: %v", err), http.StatusBadRequest)
+		return
+	}
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "invalid task id", http.StatusBadRequest)
+		return
+	}
+	log.LogInfof("receive httpServiceStopBatchTask id: %v", id)
+
+	l.batchMutex.Lock()
+	worker, ok := l.batchWorkers[id]
+	l.batchMutex.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("task id(%v) not exist", id), http.StatusNotFound)
+		return
+	}
+	worker.Stop()
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,302 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+type MockBatchS3Client struct {
+	sync.Mutex
+	objects map[string][]byte // bucket/key --> data
+	tags    map[string][]*s3.Tag
+	denied  map[string]bool // bucket/key --> access denied
+}
+
+func NewMockBatchS3Client() *MockBatchS3Client {
+	return &MockBatchS3Client{
+		objects: make(map[string][]byte),
+		tags:    make(map[string][]*s3.Tag),
+		denied:  make(map[string]bool),
+	}
+}
+
+func (m *MockBatchS3Client) check(bucket, key *string) (string, error) {
+	path := aws.StringValue(bucket) + "/" + aws.StringValue(key)
+	if m.denied[path] {
+		return path, awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), http.StatusForbidden, "")
+	}
+	if _, ok := m.objects[path]; !ok {
+		return path, awserr.NewRequestFailure(awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil), http.StatusNotFound, "")
+	}
+	return path, nil
+}
+
+func (m *MockBatchS3Client) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	path, err := m.check(input.Bucket, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &s3.GetObjectOutput{
+		Body: io.NopCloser(bytes.NewReader(m.objects[path])),
+		ETag: aws.String(fmt.Sprintf(`"%x"`, len(m.objects[path]))),
+	}, nil
+}
+
+func (m *MockBatchS3Client) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	data, _ := io.ReadAll(input.Body)
+	m.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *MockBatchS3Client) ListObjectsV2Pages(input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool) error {
+	prefix := aws.StringValue(input.Bucket) + "/" + aws.StringValue(input.Prefix)
+	after := aws.StringValue(input.Bucket) + "/" + aws.StringValue(input.StartAfter)
+	for {
+		m.Lock()
+		paths := make([]string, 0)
+		for path := range m.objects {
+			if strings.HasPrefix(path, prefix) && path > after {
+				paths = append(paths, path)
+			}
+		}
+		m.Unlock()
+		sort.Strings(paths)
+		if len(paths) > 2 {
+			paths = paths[:2]
+		}
+		page := &s3.ListObjectsV2Output{}
+		for _, path := range paths {
+			key := strings.SplitN(path, "/", 2)[1]
+			page.Contents = append(page.Contents, &s3.Object{Key: aws.String(key)})
+			after = path
+		}
+		if !fn(page, len(paths) < 2) || len(paths) < 2 {
+			return nil
+		}
+	}
+}
+
+func (m *MockBatchS3Client) PutObjectTagging(input *s3.PutObjectTaggingInput) (*s3.PutObjectTaggingOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	path, err := m.check(input.Bucket, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	m.tags[path] = input.Tagging.TagSet
+	return &s3.PutObjectTaggingOutput{}, nil
+}
+
+func (m *MockBatchS3Client) DeleteObjectTagging(input *s3.DeleteObjectTaggingInput) (*s3.DeleteObjectTaggingOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	path, err := m.check(input.Bucket, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	delete(m.tags, path)
+	return &s3.DeleteObjectTaggingOutput{}, nil
+}
+
+func (m *MockBatchS3Client) CopyObject(input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	source, err := url.QueryUnescape(aws.StringValue(input.CopySource))
+	if err != nil {
+		return nil, err
+	}
+	data, ok := m.objects[source]
+	if !ok {
+		return nil, awserr.NewRequestFailure(awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil), http.StatusNotFound, "")
+	}
+	m.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)] = data
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (m *MockBatchS3Client) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	path := aws.StringValue(input.Bucket) + "/" + aws.StringValue(input.Key)
+	if m.denied[path] {
+		return nil, awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), http.StatusForbidden, "")
+	}
+	delete(m.objects, path)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (m *MockBatchS3Client) PutObjectAcl(input *s3.PutObjectAclInput) (*s3.PutObjectAclOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	_, err := m.check(input.Bucket, input.Key)
+	return &s3.PutObjectAclOutput{}, err
+}
+
+func (m *MockBatchS3Client) has(path string) bool {
+	m.Lock()
+	defer m.Unlock()
+	_, ok := m.objects[path]
+	return ok
+}
+
+func newTestBatchTask(job *proto.BatchJob, shard int) *proto.BatchTask {
+	return &proto.BatchTask{
+		Id:   proto.BatchTaskId(job.JobId, shard),
+		Job:  job,
+		Task: &proto.BatchJobTask{Shard: shard},
+	}
+}
+
+func TestBatchWorkerManifest(t *testing.T) {
+	client := NewMockBatchS3Client()
+	manifest := new(bytes.Buffer)
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("photos/%d a+b.jpg", i)
+		client.objects["bucket/"+key] = []byte("data")
+		fmt.Fprintf(manifest, "bucket,%s\n", url.QueryEscape(key))
+	}
+	client.objects["manifests/manifest.csv"] = manifest.Bytes()
+	client.denied["bucket/photos/3 a+b.jpg"] = true
+
+	job := &proto.BatchJob{
+		JobId:     "job1",
+		Operation: &proto.BatchOperation{Type: proto.BatchOpDeleteObject},
+		Manifest:  &proto.BatchManifest{Bucket: "manifests", Key: "manifest.csv"},
+		Report:    &proto.BatchReport{Enabled: true, Bucket: "reports", Prefix: "batch/", Scope: proto.BatchReportFailedTasksOnly},
+		Shards:    2,
+	}
+	// each shard operates the lines of its own
+	for shard := 0; shard < 2; shard++ {
+		w := NewBatchWorker(newTestBatchTask(job, shard), client, 4)
+		require.NoError(t, w.Run())
+		checkpoint := w.Checkpoint()
+		require.Equal(t, int64(10), checkpoint.NextLine)
+		require.Equal(t, int64(5), checkpoint.Progress.Total)
+	}
+	for i := 0; i < 10; i++ {
+		require.Equal(t, i == 3, client.has(fmt.Sprintf("bucket/photos/%d a+b.jpg", i)))
+	}
+
+	// only the failed object is reported by the shard of it
+	require.False(t, client.has("reports/"+proto.BatchReportKey("batch/", "job1", 0, 0)))
+	data := client.objects["reports/"+proto.BatchReportKey("batch/", "job1", 1, 0)]
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{
+		"bucket", url.QueryEscape("photos/3 a+b.jpg"), "", batchTaskFailed, "AccessDenied", "403", "Access Denied",
+	}}, records)
+
+	// the modified manifest fails the task
+	job.Manifest.ETag = "etag"
+	w := NewBatchWorker(newTestBatchTask(job, 0), client, 4)
+	require.Error(t, w.Run())
+}
+
+func TestBatchWorkerCheckpoint(t *testing.T) {
+	client := NewMockBatchS3Client()
+	manifest := new(bytes.Buffer)
+	for i := 0; i < 5; i++ {
+		client.objects[fmt.Sprintf("bucket/%d", i)] = []byte("data")
+		fmt.Fprintf(manifest, "bucket,%d,\n", i)
+	}
+	client.objects["manifests/manifest.csv"] = manifest.Bytes()
+
+	job := &proto.BatchJob{
+		JobId: "job1",
+		Operation: &proto.BatchOperation{
+			Type: proto.BatchOpPutObjectTagging,
+			Tags: []proto.BatchTag{{Key: "project", Value: "blue"}},
+		},
+		Manifest: &proto.BatchManifest{
+			Bucket: "manifests",
+			Key:    "manifest.csv",
+			Fields: []string{proto.BatchManifestFieldBucket, proto.BatchManifestFieldKey, proto.BatchManifestFieldVersionId},
+		},
+		Report: &proto.BatchReport{Enabled: true, Bucket: "reports", Scope: proto.BatchReportAllTasks},
+		Shards: 1,
+	}
+	// the shard is resumed from the checkpoint, the report sequence continues
+	task := newTestBatchTask(job, 0)
+	task.Task.NextLine = 3
+	task.Task.ReportSeq = 1
+	task.Task.Progress = proto.BatchJobProgress{Total: 3, Succeeded: 3}
+	w := NewBatchWorker(task, client, 4)
+	require.NoError(t, w.Run())
+	require.Len(t, client.tags, 2)
+	require.Contains(t, client.tags, "bucket/3")
+	require.Contains(t, client.tags, "bucket/4")
+
+	checkpoint := w.Checkpoint()
+	require.Equal(t, proto.BatchJobProgress{Total: 5, Succeeded: 5}, checkpoint.Progress)
+	require.Equal(t, 2, checkpoint.ReportSeq)
+	data := client.objects["reports/"+proto.BatchReportKey("", "job1", 0, 1)]
+	require.Equal(t, 2, bytes.Count(data, []byte(batchTaskSucceeded)))
+}
+
+func TestBatchWorkerPrefix(t *testing.T) {
+	client := NewMockBatchS3Client()
+	for i := 0; i < 5; i++ {
+		client.objects[fmt.Sprintf("bucket/logs/%d", i)] = []byte("data")
+	}
+	client.objects["bucket/other/0"] = []byte("data")
+
+	// the copies under the scanned prefix are not copied again
+	job := &proto.BatchJob{
+		JobId:     "job1",
+		Operation: &proto.BatchOperation{Type: proto.BatchOpPutObjectCopy, TargetPrefix: "logs/copy/"},
+		Manifest:  &proto.BatchManifest{Bucket: "bucket", Prefixes: []string{"logs/"}},
+		Report:    &proto.BatchReport{},
+		Shards:    1,
+	}
+	w := NewBatchWorker(newTestBatchTask(job, 0), client, 2)
+	require.NoError(t, w.Run())
+	for i := 0; i < 5; i++ {
+		require.True(t, client.has(fmt.Sprintf("bucket/logs/copy/logs/%d", i)))
+	}
+	require.False(t, client.has("bucket/logs/copy/other/0"))
+	require.Equal(t, int64(5), w.Checkpoint().Progress.Succeeded)
+	require.Equal(t, "logs/copy/logs/4", w.Checkpoint().Marker)
+
+	// the stopped worker keeps the checkpoint of the pages done
+	client = NewMockBatchS3Client()
+	for i := 0; i < 5; i++ {
+		client.objects[fmt.Sprintf("bucket/logs/%d", i)] = []byte("data")
+	}
+	job.Operation = &proto.BatchOperation{Type: proto.BatchOpDeleteObject}
+	w = NewBatchWorker(newTestBatchTask(job, 0), client, 2)
+	w.Stop()
+	require.Equal(t, errBatchStopped, w.Run())
+	require.Equal(t, "logs/1", w.Checkpoint().Marker)
+	require.Len(t, client.objects, 3)
+}
@@ -16,6 +16,7 @@ package lcnode
 
 import (
 	"regexp"
+	"time"
 
 	"github.com/cubefs/cubefs/proto"
 	"golang.org/x/time/rate"
@@ -24,6 +25,7 @@ import (
 const (
 	configListen                       = proto.ListenPort
 	configMasterAddr                   = proto.MasterAddr
+	configMasterAPIToken               = proto.MasterAPIToken
 	configSimpleQueueInitCapacityStr   = "simpleQueueInitCapacity"
 	configScanCheckIntervalStr         = "scanCheckInterval"
 	configLcScanRoutineNumPerTaskStr   = "lcScanRoutineNumPerTask"
@@ -32,6 +34,9 @@ const (
 	configLcNodeTaskCountLimit         = "lcNodeTaskCountLimit"
 	configDelayDelMinute               = "delayDelMinute"
 	configUseCreateTime                = "useCreateTime"
+	configRestoreRoutineNumStr         = "restoreRoutineNum"
+	configS3EndpointStr                = "s3Endpoint"
+	configBatchRoutineNumPerTaskStr    = "batchRoutineNumPerTask"
 )
 
 // Default of configuration value
@@ -51,6 +56,10 @@ const (
 	defaultDelayDelMinute            = 1440           // default retention min(1 day) of old eks after migration
 	MaxSizePutOnce                   = int64(1) << 23 // 8MB
 	DirTrashSkip                     = ".Trash"
+	defaultRestoreRoutineNum         = 10
+	defaultRestorerIdleTimeout       = 10 * time.Minute // close the clients of the volume without restore tasks
+	restoreFileMode                  = 0o644
+	defaultBatchRoutineNumPerTask    = 20
 
 	defaultAllocRetryInterval       = 100
 	defaultWriteRetryInterval       = 100
@@ -70,4 +79,6 @@ var (
 	maxDirChanNum             = 1000000
 	delayDelMinute            uint64
 	useCreateTime             bool
+	restoreRoutineNum         int
+	batchRoutineNumPerTask    int
 )
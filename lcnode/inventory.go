@@ -0,0 +1,290 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	inventoryFileSize        = 64 << 20 // 64MB
+	inventoryManifestVersion = "2016-11-30"
+	inventoryDateFormat      = "2006-01-02T15-04Z"
+)
+
+// inventoryS3Client is the subset of the S3 API used by the inventory, which is served by the objectnodes.
+type inventoryS3Client interface {
+	PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error)
+}
+
+type inventoryObject struct {
+	Key          string
+	Size         uint64
+	ModifyTime   time.Time
+	ETag         string
+	StorageClass string
+	Tags         map[string]string
+}
+
+// InventoryManifest lists the data files of one inventory, it's written once all the files are written.
+type InventoryManifest struct {
+	SourceBucket      string                  `json:"sourceBucket"`
+	DestinationBucket string                  `json:"destinationBucket"`
+	Version           string                  `json:"version"`
+	CreationTimestamp string                  `json:"creationTimestamp"` // unix milliseconds
+	FileFormat        string                  `json:"fileFormat"`
+	FileSchema        string                  `json:"fileSchema"`
+	Files             []InventoryManifestFile `json:"files"`
+}
+
+type InventoryManifestFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5checksum string `json:"MD5checksum"`
+}
+
+// InventoryWriter writes the objects listed by the scanner into the data files in the destination
+// bucket. The files are written under "<prefix>/<source bucket>/<inventory id>/", and the manifest
+// of the inventory is written under the directory of the date after all the files are written.
+type InventoryWriter struct {
+	sync.Mutex
+	bucket   string
+	conf     *proto.InventoryConfiguration
+	client   inventoryS3Client
+	stat     *proto.LcNodeRuleTaskStatistics
+	now      time.Time
+	fileSize int
+
+	buf    *bytes.Buffer
+	writer *csv.Writer
+	files  []InventoryManifestFile
+	err    error // the inventory fails once any file fails to be written
+}
+
+func NewInventoryWriter(bucket string, conf *proto.InventoryConfiguration, client inventoryS3Client,
+	stat *proto.LcNodeRuleTaskStatistics, now time.Time,
+) *InventoryWriter {
+	buf := new(bytes.Buffer)
+	return &InventoryWriter{
+		bucket:   bucket,
+		conf:     conf,
+		client:   client,
+		stat:     stat,
+		now:      now.UTC(),
+		fileSize: inventoryFileSize,
+		buf:      buf,
+		writer:   csv.NewWriter(buf),
+		files:    make([]InventoryManifestFile, 0),
+	}
+}
+
+// Configuration returns the configuration of the inventory, or nil if the writer is nil.
+func (w *InventoryWriter) Configuration() *proto.InventoryConfiguration {
+	if w == nil {
+		return nil
+	}
+	return w.conf
+}
+
+func (w *InventoryWriter) basePath() string {
+	return path.Join(w.conf.Destination.Prefix, w.bucket, w.conf.Id)
+}
+
+func (w *InventoryWriter) dataKey(seq int) string {
+	ext := ".csv"
+	if w.conf.Destination.Format == proto.InventoryFormatJSONL {
+		ext = ".jsonl"
+	}
+	return fmt.Sprintf("%s/data/%s-%d%s", w.basePath(), w.now.Format(inventoryDateFormat), seq, ext)
+}
+
+func (w *InventoryWriter) manifestKey(name string) string {
+	return fmt.Sprintf("%s/%s/%s", w.basePath(), w.now.Format(inventoryDateFormat), name)
+}
+
+func encodeInventoryTags(tags map[string]string) string {
+	values := url.Values{}
+	for k, v := range tags {
+		values.Set(k, v)
+	}
+	return values.Encode()
+}
+
+// Add adds the object to the inventory, the data file is written once it's large enough.
+func (w *InventoryWriter) Add(obj *inventoryObject) error {
+	w.Lock()
+	defer w.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.conf.Destination.Format == proto.InventoryFormatJSONL {
+		record := map[string]interface{}{"Bucket": w.bucket, "Key": obj.Key}
+		for _, f := range w.conf.Fields {
+			switch f {
+			case proto.InventoryFieldSize:
+				record[f] = obj.Size
+			case proto.InventoryFieldLastModifiedDate:
+				record[f] = obj.ModifyTime.UTC().Format(time.RFC3339)
+			case proto.InventoryFieldETag:
+				record[f] = obj.ETag
+			case proto.InventoryFieldStorageClass:
+				record[f] = obj.StorageClass
+			case proto.InventoryFieldTags:
+				record[f] = obj.Tags
+			}
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		w.buf.Write(data)
+		w.buf.WriteByte('\n')
+	} else {
+		row := []string{w.bucket, obj.Key}
+		for _, f := range w.conf.Schema()[2:] {
+			switch f {
+			case proto.InventoryFieldSize:
+				row = append(row, strconv.FormatUint(obj.Size, 10))
+			case proto.InventoryFieldLastModifiedDate:
+				row = append(row, obj.ModifyTime.UTC().Format(time.RFC3339))
+			case proto.InventoryFieldETag:
+				row = append(row, obj.ETag)
+			case proto.InventoryFieldStorageClass:
+				row = append(row, obj.StorageClass)
+			case proto.InventoryFieldTags:
+				row = append(row, encodeInventoryTags(obj.Tags))
+			}
+		}
+		if err := w.writer.Write(row); err != nil {
+			return err
+		}
+		w.writer.Flush()
+	}
+
+	if w.buf.Len() >= w.fileSize {
+		w.err = w.flush()
+	}
+	return w.err
+}
+
+// flush writes the buffered objects into a data file, the caller should hold the lock.
+func (w *InventoryWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	contentType := "text/csv"
+	if w.conf.Destination.Format == proto.InventoryFormatJSONL {
+		contentType = "application/x-ndjson"
+	}
+	key := w.dataKey(len(w.files))
+	sum := md5.Sum(w.buf.Bytes())
+	if _, err := w.client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(w.conf.Destination.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(w.buf.Bytes()),
+		ContentType: aws.String(contentType),
+	}); err != nil {
+		return fmt.Errorf("put inventory file %v/%v: %v", w.conf.Destination.Bucket, key, err)
+	}
+	w.files = append(w.files, InventoryManifestFile{Key: key, Size: int64(w.buf.Len()), MD5checksum: hex.EncodeToString(sum[:])})
+	atomic.AddInt64(&w.stat.InventoryFileNum, 1)
+	log.LogInfof("inventory: bucket(%v) id(%v) file(%v/%v) written", w.bucket, w.conf.Id, w.conf.Destination.Bucket, key)
+	w.buf.Reset()
+	return nil
+}
+
+// Finish writes the rest of the objects and the manifest, together with the checksum of the manifest.
+func (w *InventoryWriter) Finish() error {
+	w.Lock()
+	defer w.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if w.err = w.flush(); w.err != nil {
+		return w.err
+	}
+
+	files := make([]InventoryManifestFile, len(w.files))
+	copy(files, w.files)
+	sort.Slice(files, func(i, j int) bool { return files[i].Key < files[j].Key })
+	manifest := &InventoryManifest{
+		SourceBucket:      w.bucket,
+		DestinationBucket: w.conf.Destination.Bucket,
+		Version:           inventoryManifestVersion,
+		CreationTimestamp: strconv.FormatInt(w.now.UnixNano()/int64(time.Millisecond), 10),
+		FileFormat:        w.conf.Destination.Format,
+		FileSchema:        strings.Join(w.conf.Schema(), ", "),
+		Files:             files,
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		w.err = err
+		return err
+	}
+	sum := md5.Sum(data)
+	for _, f := range []struct {
+		name        string
+		body        []byte
+		contentType string
+	}{
+		{"manifest.json", data, "application/json"},
+		{"manifest.checksum", []byte(hex.EncodeToString(sum[:])), "text/plain"},
+	} {
+		key := w.manifestKey(f.name)
+		if _, err = w.client.PutObject(&s3.PutObjectInput{
+			Bucket:      aws.String(w.conf.Destination.Bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(f.body),
+			ContentType: aws.String(f.contentType),
+		}); err != nil {
+			w.err = fmt.Errorf("put inventory manifest %v/%v: %v", w.conf.Destination.Bucket, key, err)
+			return w.err
+		}
+	}
+	log.LogInfof("inventory: bucket(%v) id(%v) finished, files(%v)", w.bucket, w.conf.Id, len(files))
+	return nil
+}
+
+// newInventoryClient returns the client with the credentials of the owner of the source bucket,
+// which is the owner of the destination bucket as well.
+func (l *LcNode) newInventoryClient(owner string) (inventoryS3Client, error) {
+	if l.s3Endpoint == "" {
+		return nil, fmt.Errorf("%v is not configured", configS3EndpointStr)
+	}
+	userInfo, err := l.mc.UserAPI().GetUserInfo(owner)
+	if err != nil {
+		return nil, fmt.Errorf("get user %v: %v", owner, err)
+	}
+	return l.newS3Client(userInfo)
+}
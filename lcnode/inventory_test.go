@@ -0,0 +1,135 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func newTestInventoryConf(format string, fields ...string) *proto.InventoryConfiguration {
+	return &proto.InventoryConfiguration{
+		Id:      "inv",
+		Enabled: true,
+		Destination: &proto.InventoryDestination{
+			Bucket: "dest",
+			Prefix: "reports",
+			Format: format,
+		},
+		Fields:                 fields,
+		Frequency:              proto.InventoryFrequencyDaily,
+		IncludedObjectVersions: proto.InventoryVersionsCurrent,
+	}
+}
+
+func TestInventoryWriterCSV(t *testing.T) {
+	client := NewMockBatchS3Client()
+	stat := &proto.LcNodeRuleTaskStatistics{}
+	now := time.Date(2023, 6, 1, 8, 30, 0, 0, time.UTC)
+	conf := newTestInventoryConf(proto.InventoryFormatCSV, proto.InventoryFieldTags, proto.InventoryFieldSize, proto.InventoryFieldETag)
+	w := NewInventoryWriter("src", conf, client, stat, now)
+	w.fileSize = 16
+
+	require.NoError(t, w.Add(&inventoryObject{Key: "a,b", Size: 10, ETag: "e1", Tags: map[string]string{"k": "v"}}))
+	require.NoError(t, w.Add(&inventoryObject{Key: "c", Size: 20, ETag: "e2"}))
+	require.NoError(t, w.Finish())
+	require.Equal(t, int64(2), stat.InventoryFileNum)
+
+	// the columns are in the canonical order regardless of the order of the fields
+	data := string(client.objects["dest/reports/src/inv/data/2023-06-01T08-30Z-0.csv"])
+	require.Equal(t, "src,\"a,b\",10,e1,k=v\n", data)
+	data = string(client.objects["dest/reports/src/inv/data/2023-06-01T08-30Z-1.csv"])
+	require.Equal(t, "src,c,20,e2,\n", data)
+
+	manifestData := client.objects["dest/reports/src/inv/2023-06-01T08-30Z/manifest.json"]
+	manifest := &InventoryManifest{}
+	require.NoError(t, json.Unmarshal(manifestData, manifest))
+	require.Equal(t, "src", manifest.SourceBucket)
+	require.Equal(t, "dest", manifest.DestinationBucket)
+	require.Equal(t, "Bucket, Key, Size, ETag, Tags", manifest.FileSchema)
+	require.Len(t, manifest.Files, 2)
+	require.True(t, strings.HasSuffix(manifest.Files[0].Key, "-0.csv"))
+	sum := md5.Sum(manifestData)
+	require.Equal(t, hex.EncodeToString(sum[:]), string(client.objects["dest/reports/src/inv/2023-06-01T08-30Z/manifest.checksum"]))
+}
+
+func TestInventoryWriterJSONL(t *testing.T) {
+	client := NewMockBatchS3Client()
+	stat := &proto.LcNodeRuleTaskStatistics{}
+	now := time.Date(2023, 6, 1, 8, 30, 0, 0, time.UTC)
+	conf := newTestInventoryConf(proto.InventoryFormatJSONL, proto.InventoryFieldSize, proto.InventoryFieldLastModifiedDate)
+	w := NewInventoryWriter("src", conf, client, stat, now)
+
+	require.NoError(t, w.Add(&inventoryObject{Key: "a", Size: 10, ModifyTime: now}))
+	require.NoError(t, w.Add(&inventoryObject{Key: "b", Size: 20, ModifyTime: now}))
+	require.NoError(t, w.Finish())
+	require.Equal(t, int64(1), stat.InventoryFileNum)
+
+	data := string(client.objects["dest/reports/src/inv/data/2023-06-01T08-30Z-0.jsonl"])
+	lines := strings.Split(strings.TrimSpace(data), "\n")
+	require.Len(t, lines, 2)
+	record := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	require.Equal(t, "b", record["Key"])
+	require.Equal(t, float64(20), record[proto.InventoryFieldSize])
+	require.Equal(t, "2023-06-01T08:30:00Z", record[proto.InventoryFieldLastModifiedDate])
+	require.NotContains(t, record, proto.InventoryFieldETag)
+}
+
+func TestLcScannerInventory(t *testing.T) {
+	client := NewMockBatchS3Client()
+	mw := &MockLcMetaWrapper{
+		MockMetaWrapper: NewMockMetaWrapper(),
+		xattrs: map[uint64]map[string]string{
+			1: {proto.XAttrKeyTagging: "type=tmp", proto.XAttrKeyETag: "etag1:1685600000"},
+		},
+	}
+	conf := newTestInventoryConf(proto.InventoryFormatCSV, proto.InventoryFieldSize, proto.InventoryFieldETag, proto.InventoryFieldTags)
+	scanner := &LcScanner{
+		ID:          "test_vol:inventory:inv",
+		Volume:      "test_vol",
+		mw:          mw,
+		rule:        &proto.Rule{ID: conf.Id, Status: proto.RuleEnabled, Filter: &proto.Filter{}},
+		currentStat: &proto.LcNodeRuleTaskStatistics{},
+		limiter:     rate.NewLimiter(defaultLcScanLimitPerSecond, defaultLcScanLimitBurst),
+		now:         time.Now(),
+		stopC:       make(chan bool),
+	}
+	scanner.inventory = NewInventoryWriter(scanner.Volume, conf, client, scanner.currentStat, scanner.now)
+
+	// the objects are listed but never expired
+	scanner.handleFile(&proto.ScanDentry{Inode: 1, Path: "logs/1"})
+	scanner.handleFile(&proto.ScanDentry{Inode: 2, Path: "logs/2"})
+	require.Equal(t, int64(2), scanner.currentStat.InventoryObjectNum)
+	require.Equal(t, int64(0), scanner.currentStat.TotalFileExpiredNum)
+	require.Equal(t, int64(0), scanner.currentStat.ErrorInventoryNum)
+	require.NoError(t, scanner.inventory.Finish())
+
+	var data string
+	for key, value := range client.objects {
+		if strings.HasSuffix(key, ".csv") {
+			data = string(value)
+		}
+	}
+	require.Equal(t, "test_vol,logs/1,100,etag1,type=tmp\ntest_vol,logs/2,200,,\n", data)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/auditlog"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 func (l *LcNode) opMasterHeartbeat(conn net.Conn, p *proto.Packet, remoteAddr string) (err error) {
@@ -51,6 +52,9 @@ func (l *LcNode) opMasterHeartbeat(conn net.Conn, p *proto.Packet, remoteAddr st
 			resp.Result = fmt.Sprintf("lcnode(%v) heartbeat decode err(%v)", l.localServerAddr, err.Error())
 			goto end
 		}
+		if e := tlsutil.SetTrustFrom(conn, req.TLSMode, req.TLSCAPem); e != nil {
+			log.LogErrorf("action[opMasterHeartbeat] apply tls trust failed, tlsMode(%v) err(%v)", req.TLSMode, e)
+		}
 
 		l.scannerMutex.RLock()
 		for _, scanner := range l.lcScanners {
@@ -61,6 +65,7 @@ func (l *LcNode) opMasterHeartbeat(conn net.Conn, p *proto.Packet, remoteAddr st
 				Volume:    scanner.Volume,
 				RcvStop:   scanner.receiveStop,
 				Rule:      scanner.rule,
+				Inventory: scanner.inventory.Configuration(),
 				LcNodeRuleTaskStatistics: proto.LcNodeRuleTaskStatistics{
 					TotalFileScannedNum:      atomic.LoadInt64(&scanner.currentStat.TotalFileScannedNum),
 					TotalFileExpiredNum:      atomic.LoadInt64(&scanner.currentStat.TotalFileExpiredNum),
@@ -75,6 +80,13 @@ func (l *LcNode) opMasterHeartbeat(conn net.Conn, p *proto.Packet, remoteAddr st
 					ErrorMToHddNum:           atomic.LoadInt64(&scanner.currentStat.ErrorMToHddNum),
 					ErrorMToBlobstoreNum:     atomic.LoadInt64(&scanner.currentStat.ErrorMToBlobstoreNum),
 					ErrorReadDirNum:          atomic.LoadInt64(&scanner.currentStat.ErrorReadDirNum),
+					ExpiredDeleteMarkerNum:   atomic.LoadInt64(&scanner.currentStat.ExpiredDeleteMarkerNum),
+					AbortedMultipartNum:      atomic.LoadInt64(&scanner.currentStat.AbortedMultipartNum),
+					ErrorDeleteMarkerNum:     atomic.LoadInt64(&scanner.currentStat.ErrorDeleteMarkerNum),
+					ErrorAbortMultipartNum:   atomic.LoadInt64(&scanner.currentStat.ErrorAbortMultipartNum),
+					InventoryObjectNum:       atomic.LoadInt64(&scanner.currentStat.InventoryObjectNum),
+					InventoryFileNum:         atomic.LoadInt64(&scanner.currentStat.InventoryFileNum),
+					ErrorInventoryNum:        atomic.LoadInt64(&scanner.currentStat.ErrorInventoryNum),
 				},
 			}
 			resp.LcScanningTasks[scanner.ID] = result
@@ -97,6 +109,7 @@ func (l *LcNode) opMasterHeartbeat(conn net.Conn, p *proto.Packet, remoteAddr st
 		}
 		l.scannerMutex.RUnlock()
 
+		resp.BatchTasks = l.batchTasks()
 		resp.LcTaskCountLimit = lcNodeTaskCountLimit
 		resp.Status = proto.TaskSucceeds
 
@@ -189,6 +202,70 @@ func (l *LcNode) opSnapshotVerDel(conn net.Conn, p *proto.Packet) (err error) {
 	return
 }
 
+func (l *LcNode) opRestore(conn net.Conn, p *proto.Packet) (err error) {
+	data := p.Data
+
+	responseAckOKToMaster(conn, p)
+
+	go func() {
+		var (
+			req       = &proto.LcNodeRestoreTaskRequest{}
+			resp      = &proto.LcNodeRestoreTaskResponse{}
+			adminTask = &proto.AdminTask{
+				Request: req,
+			}
+		)
+
+		decoder := json.NewDecoder(bytes.NewBuffer(data))
+		decoder.UseNumber()
+		if err = decoder.Decode(adminTask); err != nil {
+			resp.LcNode = l.localServerAddr
+			resp.Status = proto.TaskFailed
+			resp.Result = err.Error()
+			adminTask.Response = resp
+			l.respondToMaster(adminTask)
+			return
+		}
+
+		l.startRestore(adminTask)
+		l.respondToMaster(adminTask)
+	}()
+
+	return
+}
+
+func (l *LcNode) opBatch(conn net.Conn, p *proto.Packet) (err error) {
+	data := p.Data
+
+	responseAckOKToMaster(conn, p)
+
+	go func() {
+		var (
+			req       = &proto.LcNodeBatchTaskRequest{}
+			resp      = &proto.LcNodeBatchTaskResponse{}
+			adminTask = &proto.AdminTask{
+				Request: req,
+			}
+		)
+
+		decoder := json.NewDecoder(bytes.NewBuffer(data))
+		decoder.UseNumber()
+		if err = decoder.Decode(adminTask); err != nil {
+			resp.LcNode = l.localServerAddr
+			resp.Status = proto.TaskFailed
+			resp.Result = err.Error()
+			adminTask.Response = resp
+			l.respondToMaster(adminTask)
+			return
+		}
+
+		l.startBatch(adminTask)
+		l.respondToMaster(adminTask)
+	}()
+
+	return
+}
+
 func responseAckOKToMaster(conn net.Conn, p *proto.Packet) {
 	go func() {
 		p.PacketOkReply()
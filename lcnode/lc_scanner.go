@@ -16,7 +16,9 @@ package lcnode
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path"
 	"strings"
@@ -58,6 +60,11 @@ type LcScanner struct {
 	receiveStop   bool
 	receiveStopC  chan bool
 	stopC         chan bool
+	// multipartC is closed once the incomplete multipart uploads are aborted, it is nil
+	// if the rule has no AbortIncompleteMultipartUpload.
+	multipartC chan struct{}
+	// inventory lists the objects instead of applying the rule, it is nil for the lifecycle task.
+	inventory *InventoryWriter
 }
 
 func NewS3Scanner(adminTask *proto.AdminTask, l *LcNode) (*LcScanner, error) {
@@ -136,6 +143,14 @@ func NewS3Scanner(adminTask *proto.AdminTask, l *LcNode) (*LcScanner, error) {
 			scanner.Volume, volumeInfo.Status)
 		return nil, proto.ErrVolNotExists
 	}
+	if scanTask.Inventory != nil {
+		var client inventoryS3Client
+		if client, err = l.newInventoryClient(volumeInfo.Owner); err != nil {
+			log.LogErrorf("NewS3Scanner: new inventory client err: %v, id: %v", err, scanner.ID)
+			return nil, err
+		}
+		scanner.inventory = NewInventoryWriter(scanner.Volume, scanTask.Inventory, client, scanner.currentStat, scanner.now)
+	}
 	extentConfig := &stream.ExtentConfig{
 		Volume:                      scanner.Volume,
 		Masters:                     l.masters,
@@ -195,6 +210,7 @@ func (l *LcNode) startLcScan(adminTask *proto.AdminTask) (err error) {
 		resp.ID = request.Task.Id
 		resp.Volume = request.Task.VolName
 		resp.Rule = request.Task.Rule
+		resp.Inventory = request.Task.Inventory
 		resp.LcNode = l.localServerAddr
 		resp.Status = proto.TaskFailed
 		resp.Done = true
@@ -214,6 +230,14 @@ func (l *LcNode) startLcScan(adminTask *proto.AdminTask) (err error) {
 func (s *LcScanner) Start() (err error) {
 	response := s.adminTask.Response.(*proto.LcNodeRuleTaskResponse)
 	parentId, prefixDirs, err := s.FindPrefixInode()
+	scanDirs := true
+	if err == syscall.ENOENT && s.rule.AbortIncompleteMultipartUpload != nil {
+		// no object matches the prefix, while the directories of the incomplete
+		// multipart uploads are made on completion
+		log.LogInfof("startScan: volume(%v), rule id(%v), prefix not found, only abort multipart uploads",
+			s.Volume, s.rule.ID)
+		scanDirs, err = false, nil
+	}
 	if err != nil {
 		log.LogErrorf("startScan err(%v): volume(%v), rule id(%v), scanning done!",
 			err, s.Volume, s.rule.ID)
@@ -222,6 +246,7 @@ func (s *LcScanner) Start() (err error) {
 		response.StartTime = &s.now
 		response.Volume = s.Volume
 		response.Rule = s.rule
+		response.Inventory = s.inventory.Configuration()
 		response.Status = proto.TaskFailed
 		response.Done = true
 		response.StartErr = err.Error()
@@ -235,6 +260,10 @@ func (s *LcScanner) Start() (err error) {
 
 	go s.handleFileChan()
 	go s.handleDirChan()
+	if s.rule.AbortIncompleteMultipartUpload != nil {
+		s.multipartC = make(chan struct{})
+		go s.abortIncompleteMultiparts()
+	}
 
 	var currentPath string
 	if len(prefixDirs) > 0 {
@@ -248,7 +277,9 @@ func (s *LcScanner) Start() (err error) {
 	}
 	response.StartTime = &s.now
 
-	s.firstIn(firstDentry)
+	if scanDirs {
+		s.firstIn(firstDentry)
+	}
 
 	go s.checkScanning()
 
@@ -400,8 +431,21 @@ func (s *LcScanner) handleFile(dentry *proto.ScanDentry) {
 		return
 	}
 
-	if info != nil && info.Size < s.rule.MinSize() {
-		log.LogInfof("handleFile: %+v, minSize(%d) size(%v) no need to process", dentry, s.rule.MinSize(), info.Size)
+	if s.inventory != nil {
+		if info != nil {
+			s.listInventory(dentry, info)
+		}
+		return
+	}
+
+	if info != nil && proto.IsStorageClassBlobStore(info.StorageClass) {
+		if _, e := expireRestoredCopy(s.mw, dentry.Inode, dentry.Path, s.now, false); e != nil {
+			log.LogWarnf("handleFile expireRestoredCopy err: %v, dentry: %+v", e, dentry)
+		}
+	}
+
+	if info != nil && !s.rule.MatchSize(info.Size) {
+		log.LogInfof("handleFile: %+v, size(%v) not matched by the filter, no need to process", dentry, info.Size)
 		return
 	}
 
@@ -415,6 +459,10 @@ func (s *LcScanner) handleFile(dentry *proto.ScanDentry) {
 		log.LogInfof("handleFile: %+v, ctime(%v), atime(%v), is not expired", dentry, info.CreateTime, info.AccessTime)
 		return
 	}
+	if len(s.rule.Tags()) > 0 && !s.matchTags(dentry) {
+		log.LogInfof("handleFile: %+v, tags not matched by the filter, no need to process", dentry)
+		return
+	}
 
 	atomic.AddInt64(&s.currentStat.TotalFileExpiredNum, 1)
 	log.LogInfof("handleFile: %+v, ctime(%v), atime(%v), is expired", dentry, info.CreateTime, info.AccessTime)
@@ -427,11 +475,22 @@ func (s *LcScanner) handleFile(dentry *proto.ScanDentry) {
 	switch op {
 	case proto.OpTypeDelete:
 		_, err = s.mw.DeleteWithCond_ll(dentry.ParentId, dentry.Inode, dentry.Name, os.FileMode(dentry.Type).IsDir(), dentry.Path)
+		if err == meta.ErrObjectLocked {
+			// the object under retention or legal hold is not expired
+			atomic.AddInt64(&s.currentStat.ExpiredSkipNum, 1)
+			log.LogInfof("delete DeleteWithCond_ll skip locked object: dentry: %+v", dentry)
+			return
+		}
 		if err != nil {
 			atomic.AddInt64(&s.currentStat.ErrorDeleteNum, 1)
 			log.LogWarnf("delete DeleteWithCond_ll err: %v, dentry: %+v", err, dentry)
 			return
 		}
+		if proto.IsStorageClassBlobStore(dentry.StorageClass) {
+			if _, e := expireRestoredCopy(s.mw, dentry.Inode, dentry.Path, s.now, true); e != nil {
+				log.LogWarnf("delete expireRestoredCopy err: %v, dentry: %+v", e, dentry)
+			}
+		}
 		if err = s.mw.Evict(dentry.Inode, dentry.Path); err != nil {
 			log.LogWarnf("delete Evict err: %v, dentry: %+v", err, dentry)
 		}
@@ -513,6 +572,197 @@ func (s *LcScanner) handleFile(dentry *proto.ScanDentry) {
 	}
 }
 
+// listInventory adds the object to the inventory with the fields of the configuration.
+func (s *LcScanner) listInventory(dentry *proto.ScanDentry, info *proto.InodeInfo) {
+	conf := s.inventory.Configuration()
+	obj := &inventoryObject{
+		Key:          dentry.Path,
+		Size:         info.Size,
+		ModifyTime:   info.ModifyTime,
+		StorageClass: proto.StorageClassString(info.StorageClass),
+	}
+	var err error
+	if conf.HasField(proto.InventoryFieldETag) {
+		var xattr *proto.XAttrInfo
+		if xattr, err = s.mw.XAttrGet_ll(dentry.Inode, proto.XAttrKeyETag); err != nil {
+			atomic.AddInt64(&s.currentStat.ErrorInventoryNum, 1)
+			log.LogWarnf("listInventory XAttrGet_ll err: %v, dentry: %+v", err, dentry)
+			return
+		}
+		// the ETag is followed by the modify time when it's computed
+		obj.ETag = strings.SplitN(string(xattr.Get(proto.XAttrKeyETag)), ":", 2)[0]
+	}
+	if conf.HasField(proto.InventoryFieldTags) {
+		if obj.Tags, err = s.objectTags(dentry); err != nil {
+			atomic.AddInt64(&s.currentStat.ErrorInventoryNum, 1)
+			log.LogWarnf("listInventory objectTags err: %v, dentry: %+v", err, dentry)
+			return
+		}
+	}
+	if err = s.inventory.Add(obj); err != nil {
+		atomic.AddInt64(&s.currentStat.ErrorInventoryNum, 1)
+		log.LogErrorf("listInventory add err: %v, dentry: %+v", err, dentry)
+		return
+	}
+	atomic.AddInt64(&s.currentStat.InventoryObjectNum, 1)
+}
+
+// objectTags returns the tags of the object, which are kept in the xattr of the object by the objectnode.
+func (s *LcScanner) objectTags(dentry *proto.ScanDentry) (map[string]string, error) {
+	info, err := s.mw.XAttrGet_ll(dentry.Inode, proto.XAttrKeyTagging)
+	if err != nil {
+		return nil, err
+	}
+	values, err := url.ParseQuery(string(info.Get(proto.XAttrKeyTagging)))
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(values))
+	for key := range values {
+		tags[key] = values.Get(key)
+	}
+	return tags, nil
+}
+
+// matchTags checks whether the object has all the tags of the rule.
+func (s *LcScanner) matchTags(dentry *proto.ScanDentry) bool {
+	tags, err := s.objectTags(dentry)
+	if err != nil {
+		log.LogWarnf("matchTags err: %v, dentry: %+v", err, dentry)
+		return false
+	}
+	return s.rule.MatchTags(tags)
+}
+
+// removeExpiredDeleteMarkers removes the delete markers in the directory which are the only
+// versions left of the object keys. The versions of the keys are kept in the xattrs of the
+// directory by the objectnode.
+func (s *LcScanner) removeExpiredDeleteMarkers(dir *proto.ScanDentry) {
+	keys, err := s.mw.XAttrsList_ll(dir.Inode)
+	if err != nil {
+		if err != syscall.ENOENT {
+			atomic.AddInt64(&s.currentStat.ErrorDeleteMarkerNum, 1)
+			log.LogErrorf("removeExpiredDeleteMarkers XAttrsList_ll err(%v), dentry(%v)", err, dir)
+		}
+		return
+	}
+
+	prefix := s.rule.GetPrefix()
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, proto.XAttrKeyVersions)
+		if name == key || name == "" {
+			continue
+		}
+		path := strings.TrimPrefix(dir.Path+pathSep+name, pathSep)
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		start := time.Now()
+		var (
+			expired bool
+			raw     []byte
+		)
+		if expired, raw, err = s.deleteMarkerExpired(dir.Inode, name, key); err != nil {
+			atomic.AddInt64(&s.currentStat.ErrorDeleteMarkerNum, 1)
+			log.LogWarnf("removeExpiredDeleteMarkers check err: %v, dentry: %+v, name: %v", err, dir, name)
+			continue
+		}
+		if !expired {
+			continue
+		}
+		// the delete markers are removed only if no version is added since they are checked
+		err = s.mw.XAttrCompareAndSet_ll(dir.Inode, key, raw, nil)
+		if err == syscall.ESTALE {
+			log.LogInfof("removeExpiredDeleteMarkers versions changed, dentry: %+v, name: %v", dir, name)
+			continue
+		}
+		if err != nil {
+			atomic.AddInt64(&s.currentStat.ErrorDeleteMarkerNum, 1)
+			log.LogWarnf("removeExpiredDeleteMarkers XAttrCompareAndSet_ll err: %v, dentry: %+v, name: %v", err, dir, name)
+		} else {
+			atomic.AddInt64(&s.currentStat.ExpiredDeleteMarkerNum, 1)
+		}
+		auditlog.LogLcNodeOp(proto.OpTypeDeleteMarker, s.Volume, name, path, dir.Inode, 0, 0, 0, false, 0, 0,
+			time.Since(start).Milliseconds(), err)
+	}
+}
+
+// deleteMarkerExpired checks whether the versions of the key are only the delete markers, and returns
+// the versions checked.
+func (s *LcScanner) deleteMarkerExpired(parentId uint64, name, key string) (expired bool, raw []byte, err error) {
+	var info *proto.XAttrInfo
+	if info, err = s.mw.XAttrGet_ll(parentId, key); err != nil {
+		return
+	}
+	var versions []*proto.ObjectVersion
+	if raw = info.Get(key); len(raw) > 0 {
+		if err = json.Unmarshal(raw, &versions); err != nil {
+			return
+		}
+	}
+	if len(versions) == 0 {
+		return
+	}
+	for _, version := range versions {
+		if !version.DeleteMarker {
+			return
+		}
+	}
+	// the delete markers are non-current if the object exists
+	if _, _, err = s.mw.Lookup_ll(parentId, name); err != syscall.ENOENT {
+		return
+	}
+	return true, raw, nil
+}
+
+// abortIncompleteMultiparts aborts the multipart uploads which are not completed within the days
+// after initiation, and releases the uploaded parts as AbortMultipartUpload does.
+func (s *LcScanner) abortIncompleteMultiparts() {
+	defer close(s.multipartC)
+
+	prefix := s.rule.GetPrefix()
+	days := s.rule.AbortIncompleteMultipartUpload.DaysAfterInitiation
+	infos, err := s.mw.BatchGetExpiredMultipart(prefix, days)
+	if err != nil {
+		if err != syscall.ENOENT {
+			atomic.AddInt64(&s.currentStat.ErrorAbortMultipartNum, 1)
+			log.LogErrorf("abortIncompleteMultiparts BatchGetExpiredMultipart err(%v), id(%v)", err, s.ID)
+		}
+		return
+	}
+
+	for _, info := range infos {
+		select {
+		case <-s.stopC:
+			log.LogInfof("receive stop, stop abortIncompleteMultiparts %v", s.ID)
+			return
+		default:
+		}
+
+		s.limiter.Wait(context.Background())
+		start := time.Now()
+		// the session is removed first, so that the parts are not released if it is completed
+		if err = s.mw.RemoveMultipart_ll(info.Path, info.MultipartId); err != nil {
+			atomic.AddInt64(&s.currentStat.ErrorAbortMultipartNum, 1)
+			log.LogWarnf("abortIncompleteMultiparts RemoveMultipart_ll err: %v, info: %+v", err, info)
+		} else {
+			for _, inode := range info.Inodes {
+				if _, e := s.mw.InodeUnlink_ll(inode, info.Path); e != nil {
+					log.LogWarnf("abortIncompleteMultiparts InodeUnlink_ll err: %v, inode: %v, info: %+v", e, inode, info)
+					continue
+				}
+				if e := s.mw.Evict(inode, info.Path); e != nil {
+					log.LogWarnf("abortIncompleteMultiparts Evict err: %v, inode: %v, info: %+v", e, inode, info)
+				}
+			}
+			atomic.AddInt64(&s.currentStat.AbortedMultipartNum, 1)
+		}
+		auditlog.LogLcNodeOp(proto.OpTypeAbortMultipart, s.Volume, info.MultipartId, info.Path, 0, 0, 0, 0, false, 0, 0,
+			time.Since(start).Milliseconds(), err)
+	}
+}
+
 func isSkipErr(err error) bool {
 	if strings.Contains(err.Error(), "statusLeaseOccupiedByOthers") {
 		return true
@@ -606,6 +856,10 @@ func (s *LcScanner) handleDirLimitDepthFirst(dentry *proto.ScanDentry) {
 		return
 	}
 
+	if s.rule.Expiration.ExpiresDeleteMarkers() {
+		s.removeExpiredDeleteMarkers(dentry)
+	}
+
 	marker := ""
 	done := false
 	for !done {
@@ -684,6 +938,10 @@ func (s *LcScanner) handleDirLimitBreadthFirst(dentry *proto.ScanDentry) {
 		return
 	}
 
+	if s.rule.Expiration.ExpiresDeleteMarkers() {
+		s.removeExpiredDeleteMarkers(dentry)
+	}
+
 	marker := ""
 	done := false
 	for !done {
@@ -767,6 +1025,7 @@ func (s *LcScanner) checkScanning() {
 			response.Volume = s.Volume
 			response.RcvStop = s.receiveStop
 			response.Rule = s.rule
+			response.Inventory = s.inventory.Configuration()
 			response.ExpiredDeleteNum = s.currentStat.ExpiredDeleteNum
 			response.ExpiredMToHddNum = s.currentStat.ExpiredMToHddNum
 			response.ExpiredMToBlobstoreNum = s.currentStat.ExpiredMToBlobstoreNum
@@ -780,6 +1039,13 @@ func (s *LcScanner) checkScanning() {
 			response.ErrorMToHddNum = s.currentStat.ErrorMToHddNum
 			response.ErrorMToBlobstoreNum = s.currentStat.ErrorMToBlobstoreNum
 			response.ErrorReadDirNum = s.currentStat.ErrorReadDirNum
+			response.ExpiredDeleteMarkerNum = s.currentStat.ExpiredDeleteMarkerNum
+			response.AbortedMultipartNum = s.currentStat.AbortedMultipartNum
+			response.ErrorDeleteMarkerNum = s.currentStat.ErrorDeleteMarkerNum
+			response.ErrorAbortMultipartNum = s.currentStat.ErrorAbortMultipartNum
+			response.InventoryObjectNum = s.currentStat.InventoryObjectNum
+			response.InventoryFileNum = s.currentStat.InventoryFileNum
+			response.ErrorInventoryNum = s.currentStat.ErrorInventoryNum
 			log.LogInfof("receive receiveStopC response(%+v)", response)
 
 			s.lcnode.scannerMutex.Lock()
@@ -793,15 +1059,28 @@ func (s *LcScanner) checkScanning() {
 			if s.DoneScanning() {
 				log.LogInfof("checkScanning completed for task(%v)", s.adminTask)
 				taskCheckTimer.Stop()
+				var status uint8 = proto.TaskSucceeds
+				var inventoryErr string
+				if s.inventory != nil {
+					// the inventory without the manifest is incomplete
+					if err := s.inventory.Finish(); err != nil {
+						log.LogErrorf("checkScanning finish inventory err(%v), id(%v)", err, s.ID)
+						atomic.AddInt64(&s.currentStat.ErrorInventoryNum, 1)
+						status = proto.TaskFailed
+						inventoryErr = err.Error()
+					}
+				}
 				t := time.Now()
 				response := s.adminTask.Response.(*proto.LcNodeRuleTaskResponse)
 				response.EndTime = &t
-				response.Status = proto.TaskSucceeds
+				response.Status = status
+				response.StartErr = inventoryErr
 				response.Done = true
 				response.ID = s.ID
 				response.LcNode = s.lcnode.localServerAddr
 				response.Volume = s.Volume
 				response.Rule = s.rule
+				response.Inventory = s.inventory.Configuration()
 				response.ExpiredDeleteNum = s.currentStat.ExpiredDeleteNum
 				response.ExpiredMToHddNum = s.currentStat.ExpiredMToHddNum
 				response.ExpiredMToBlobstoreNum = s.currentStat.ExpiredMToBlobstoreNum
@@ -815,6 +1094,13 @@ func (s *LcScanner) checkScanning() {
 				response.ErrorMToHddNum = s.currentStat.ErrorMToHddNum
 				response.ErrorMToBlobstoreNum = s.currentStat.ErrorMToBlobstoreNum
 				response.ErrorReadDirNum = s.currentStat.ErrorReadDirNum
+				response.ExpiredDeleteMarkerNum = s.currentStat.ExpiredDeleteMarkerNum
+				response.AbortedMultipartNum = s.currentStat.AbortedMultipartNum
+				response.ErrorDeleteMarkerNum = s.currentStat.ErrorDeleteMarkerNum
+				response.ErrorAbortMultipartNum = s.currentStat.ErrorAbortMultipartNum
+				response.InventoryObjectNum = s.currentStat.InventoryObjectNum
+				response.InventoryFileNum = s.currentStat.InventoryFileNum
+				response.ErrorInventoryNum = s.currentStat.ErrorInventoryNum
 				log.LogInfof("checkScanning completed response(%+v)", response)
 
 				s.lcnode.scannerMutex.Lock()
@@ -836,12 +1122,28 @@ func (s *LcScanner) checkScanning() {
 func (s *LcScanner) DoneScanning() bool {
 	log.LogInfof("dirChan.Len(%v) fileChan.Len(%v) fileRPool.RunningNum(%v) dirRPool.RunningNum(%v)",
 		s.dirChan.Len(), len(s.fileChan), s.fileRPool.RunningNum(), s.dirRPool.RunningNum())
-	return s.dirChan.Len() == 0 && len(s.fileChan) == 0 && s.fileRPool.RunningNum() == 0 && s.dirRPool.RunningNum() == 0
+	return s.dirChan.Len() == 0 && len(s.fileChan) == 0 && s.fileRPool.RunningNum() == 0 && s.dirRPool.RunningNum() == 0 &&
+		s.doneAbortingMultiparts()
+}
+
+func (s *LcScanner) doneAbortingMultiparts() bool {
+	if s.multipartC == nil {
+		return true
+	}
+	select {
+	case <-s.multipartC:
+		return true
+	default:
+		return false
+	}
 }
 
 func (s *LcScanner) Stop() {
 	start := time.Now()
 	close(s.stopC)
+	if s.multipartC != nil {
+		<-s.multipartC
+	}
 	s.clearFileChan() // clear fileChan avoid blocking dirRPool
 	s.fileRPool.WaitAndClose()
 	s.dirRPool.WaitAndClose()
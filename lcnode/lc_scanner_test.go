@@ -15,6 +15,7 @@
 package lcnode
 
 import (
+	"syscall"
 	"testing"
 	"time"
 
@@ -111,3 +112,159 @@ func TestLcScanner(t *testing.T) {
 	res = expired(inode, now, &days, nil)
 	require.False(t, res)
 }
+
+type MockLcMetaWrapper struct {
+	*MockMetaWrapper
+	xattrs     map[uint64]map[string]string
+	dentries   map[string]bool
+	multiparts []*proto.ExpiredMultipartInfo
+	removed    []string
+	unlinked   []uint64
+	// racing are the xattrs modified concurrently once they are got
+	racing map[string]string
+}
+
+func (m *MockLcMetaWrapper) Lookup_ll(parentID uint64, name string) (uint64, uint32, error) {
+	if m.dentries[name] {
+		return 1, 0, nil
+	}
+	return 0, 0, syscall.ENOENT
+}
+
+func (m *MockLcMetaWrapper) XAttrsList_ll(inode uint64) ([]string, error) {
+	keys := make([]string, 0)
+	for key := range m.xattrs[inode] {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m *MockLcMetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error) {
+	info := &proto.XAttrInfo{Inode: inode, XAttrs: make(map[string]string)}
+	if value, ok := m.xattrs[inode][name]; ok {
+		info.XAttrs[name] = value
+	}
+	if value, ok := m.racing[name]; ok {
+		m.xattrs[inode][name] = value
+	}
+	return info, nil
+}
+
+func (m *MockLcMetaWrapper) XAttrCompareAndSet_ll(inode uint64, name string, expected, value []byte) error {
+	if m.xattrs[inode][name] != string(expected) {
+		return syscall.ESTALE
+	}
+	if len(value) == 0 {
+		delete(m.xattrs[inode], name)
+	} else {
+		m.xattrs[inode][name] = string(value)
+	}
+	return nil
+}
+
+func (m *MockLcMetaWrapper) XAttrDel_ll(inode uint64, name string) error {
+	delete(m.xattrs[inode], name)
+	return nil
+}
+
+func (m *MockLcMetaWrapper) BatchGetExpiredMultipart(prefix string, days int) ([]*proto.ExpiredMultipartInfo, error) {
+	if len(m.multiparts) == 0 {
+		return nil, syscall.ENOENT
+	}
+	return m.multiparts, nil
+}
+
+func (m *MockLcMetaWrapper) RemoveMultipart_ll(path, multipartID string) error {
+	if multipartID == "completed" {
+		return syscall.ENOENT
+	}
+	m.removed = append(m.removed, multipartID)
+	return nil
+}
+
+func (m *MockLcMetaWrapper) InodeUnlink_ll(inode uint64, fullPath string) (*proto.InodeInfo, error) {
+	m.unlinked = append(m.unlinked, inode)
+	return nil, nil
+}
+
+func TestLcScannerFilters(t *testing.T) {
+	days := 1
+	mw := &MockLcMetaWrapper{
+		MockMetaWrapper: NewMockMetaWrapper(),
+		xattrs: map[uint64]map[string]string{
+			1: {proto.XAttrKeyTagging: "type=tmp&owner=app"},
+			2: {proto.XAttrKeyTagging: "type=log"},
+			10: {
+				proto.XAttrKeyVersions + "a": `[{"vid":"v2","dm":true,"mt":2},{"vid":"v1","dm":true,"mt":1}]`,
+				proto.XAttrKeyVersions + "b": `[{"vid":"v2","dm":true,"mt":2},{"vid":"v1","ino":3,"mt":1}]`,
+				proto.XAttrKeyVersions + "c": `[{"vid":"v1","dm":true,"mt":1}]`,
+				proto.XAttrKeyVersions + "d": `[{"vid":"v1","dm":true,"mt":1}]`,
+				proto.XAttrKeyTagging:        "k=v",
+			},
+		},
+		dentries: map[string]bool{"c": true},
+		multiparts: []*proto.ExpiredMultipartInfo{
+			{Path: "uploads/a", MultipartId: "m1", Inodes: []uint64{11, 12}},
+			{Path: "uploads/b", MultipartId: "completed", Inodes: []uint64{13}},
+		},
+	}
+	scanner := &LcScanner{
+		ID:     "test_id",
+		Volume: "test_vol",
+		mw:     mw,
+		rule: &proto.Rule{
+			Filter: &proto.Filter{
+				And: &proto.FilterAnd{
+					Prefix: "logs/",
+					Tags:   []proto.LcTag{{Key: "type", Value: "tmp"}},
+				},
+			},
+			Transitions: []*proto.Transition{
+				{StorageClass: proto.OpTypeStorageClassHDD, Days: &days},
+			},
+		},
+		currentStat: &proto.LcNodeRuleTaskStatistics{},
+		limiter:     rate.NewLimiter(defaultLcScanLimitPerSecond, defaultLcScanLimitBurst),
+		now:         time.Now(),
+		stopC:       make(chan bool),
+	}
+
+	// tags
+	require.True(t, scanner.matchTags(&proto.ScanDentry{Inode: 1}))
+	require.False(t, scanner.matchTags(&proto.ScanDentry{Inode: 2}))
+	scanner.handleFile(&proto.ScanDentry{Inode: 2, Path: "logs/2"})
+	require.Equal(t, int64(1), scanner.currentStat.TotalFileScannedNum)
+	require.Equal(t, int64(0), scanner.currentStat.TotalFileExpiredNum)
+
+	// the version added to the key d concurrently is kept
+	scanner.rule.Filter.And.Prefix = "logs/d"
+	mw.racing = map[string]string{proto.XAttrKeyVersions + "d": `[{"vid":"v2","ino":4,"mt":2},{"vid":"v1","dm":true,"mt":1}]`}
+	scanner.removeExpiredDeleteMarkers(&proto.ScanDentry{Inode: 10, Path: "logs"})
+	require.Equal(t, int64(0), scanner.currentStat.ExpiredDeleteMarkerNum)
+	require.Equal(t, int64(0), scanner.currentStat.ErrorDeleteMarkerNum)
+	require.Equal(t, mw.racing[proto.XAttrKeyVersions+"d"], mw.xattrs[10][proto.XAttrKeyVersions+"d"])
+	mw.racing = nil
+
+	// only the delete markers of the key a are expired
+	scanner.rule.Filter.And.Prefix = "logs/a"
+	scanner.removeExpiredDeleteMarkers(&proto.ScanDentry{Inode: 10, Path: "logs"})
+	require.Equal(t, int64(1), scanner.currentStat.ExpiredDeleteMarkerNum)
+	require.Len(t, mw.xattrs[10], 4)
+	require.NotContains(t, mw.xattrs[10], proto.XAttrKeyVersions+"a")
+	scanner.rule.Filter.And.Prefix = "logs/"
+	scanner.removeExpiredDeleteMarkers(&proto.ScanDentry{Inode: 10, Path: "logs"})
+	require.Equal(t, int64(1), scanner.currentStat.ExpiredDeleteMarkerNum)
+	require.Len(t, mw.xattrs[10], 4)
+	require.Equal(t, int64(0), scanner.currentStat.ErrorDeleteMarkerNum)
+
+	// the parts of the completed upload are not released
+	scanner.rule.AbortIncompleteMultipartUpload = &proto.AbortIncompleteMultipartUpload{DaysAfterInitiation: 7}
+	scanner.multipartC = make(chan struct{})
+	require.False(t, scanner.doneAbortingMultiparts())
+	scanner.abortIncompleteMultiparts()
+	require.True(t, scanner.doneAbortingMultiparts())
+	require.Equal(t, []string{"m1"}, mw.removed)
+	require.Equal(t, []uint64{11, 12}, mw.unlinked)
+	require.Equal(t, int64(1), scanner.currentStat.AbortedMultipartNum)
+	require.Equal(t, int64(1), scanner.currentStat.ErrorAbortMultipartNum)
+}
@@ -84,3 +84,7 @@ func (m *MockEbsClient) Put(ctx context.Context, volName string, f io.Reader, si
 func (m *MockEbsClient) Get(ctx context.Context, volName string, offset uint64, size uint64, oek proto.ObjExtentKey) (body io.ReadCloser, err error) {
 	return
 }
+
+func (m *MockEbsClient) Read(ctx context.Context, volName string, buf []byte, offset uint64, size uint64, oek proto.ObjExtentKey) (readN int, err error) {
+	return copy(buf, m.data[oek.FileOffset+offset:oek.FileOffset+offset+size]), nil
+}
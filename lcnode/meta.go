@@ -26,5 +26,15 @@ type MetaWrapper interface {
 	UpdateExtentKeyAfterMigration(inode uint64, storageType uint32, extentKeys []proto.ObjExtentKey, leaseExpireTime uint64, delayDelMinute uint64, fullPath string) error
 	DeleteMigrationExtentKey(inode uint64, fullPath string) error
 	ReadDirLimit_ll(parentID uint64, from string, limit uint64) ([]proto.Dentry, error)
+	InodeCreate_ll(parentID uint64, mode, uid, gid uint32, target []byte, quotaIds []uint64, fullPath string) (*proto.InodeInfo, error)
+	InodeUnlink_ll(inode uint64, fullPath string) (*proto.InodeInfo, error)
+	GetObjExtents(inode uint64) (gen uint64, size uint64, extents []proto.ExtentKey, objExtents []proto.ObjExtentKey, err error)
+	XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error)
+	XAttrSet_ll(inode uint64, name, value []byte) error
+	XAttrDel_ll(inode uint64, name string) error
+	XAttrCompareAndSet_ll(inode uint64, name string, expected, value []byte) error
+	XAttrsList_ll(inode uint64) ([]string, error)
+	BatchGetExpiredMultipart(prefix string, days int) (expiredIds []*proto.ExpiredMultipartInfo, err error)
+	RemoveMultipart_ll(path, multipartID string) (err error)
 	Close() error
 }
@@ -16,6 +16,7 @@ package lcnode
 
 import (
 	"os"
+	"syscall"
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
@@ -126,6 +127,46 @@ func (*MockMetaWrapper) ReadDirLimit_ll(parentID uint64, from string, limit uint
 	}, nil
 }
 
+func (*MockMetaWrapper) InodeCreate_ll(parentID uint64, mode, uid, gid uint32, target []byte, quotaIds []uint64, fullPath string) (*proto.InodeInfo, error) {
+	return nil, nil
+}
+
+func (*MockMetaWrapper) InodeUnlink_ll(inode uint64, fullPath string) (*proto.InodeInfo, error) {
+	return nil, nil
+}
+
+func (*MockMetaWrapper) GetObjExtents(inode uint64) (gen uint64, size uint64, extents []proto.ExtentKey, objExtents []proto.ObjExtentKey, err error) {
+	return
+}
+
+func (*MockMetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error) {
+	return &proto.XAttrInfo{Inode: inode}, nil
+}
+
+func (*MockMetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
+	return nil
+}
+
+func (*MockMetaWrapper) XAttrDel_ll(inode uint64, name string) error {
+	return nil
+}
+
+func (*MockMetaWrapper) XAttrCompareAndSet_ll(inode uint64, name string, expected, value []byte) error {
+	return nil
+}
+
+func (*MockMetaWrapper) XAttrsList_ll(inode uint64) ([]string, error) {
+	return nil, nil
+}
+
+func (*MockMetaWrapper) BatchGetExpiredMultipart(prefix string, days int) ([]*proto.ExpiredMultipartInfo, error) {
+	return nil, syscall.ENOENT
+}
+
+func (*MockMetaWrapper) RemoveMultipart_ll(path, multipartID string) error {
+	return nil
+}
+
 func (*MockMetaWrapper) Close() error {
 	return nil
 }
@@ -0,0 +1,406 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/blobstore"
+	"github.com/cubefs/cubefs/sdk/data/stream"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/auditlog"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Restorer restores the objects of one volume in the blobstore storage class, the data of the
+// object is copied to a temporary inode in the replica tier. It is shared by the restore tasks
+// of the volume, and closed once it is idle for a while.
+type Restorer struct {
+	volume     string
+	mw         MetaWrapper
+	ec         ExtentApi
+	ebsClient  EbsApi
+	running    int
+	lastActive time.Time
+}
+
+func NewRestorer(volume string, l *LcNode) (*Restorer, error) {
+	var err error
+	metaConfig := &meta.MetaConfig{
+		Volume:               volume,
+		Masters:              l.masters,
+		Authenticate:         false,
+		ValidateOwner:        false,
+		InnerReq:             true,
+		MetaSendTimeout:      600,
+		DisableTrashByClient: true,
+	}
+	var metaWrapper *meta.MetaWrapper
+	if metaWrapper, err = meta.NewMetaWrapper(metaConfig); err != nil {
+		log.LogErrorf("NewRestorer: NewMetaWrapper err: %v", err)
+		return nil, err
+	}
+
+	ebsConfig := access.Config{
+		ConnMode: access.NoLimitConnMode,
+		Consul: access.ConsulConfig{
+			Address: l.ebsAddr,
+		},
+		MaxSizePutOnce: MaxSizePutOnce,
+		Logger: &access.Logger{
+			Filename: path.Join(l.logDir, "ebs.log"),
+		},
+	}
+	var ebsClient *blobstore.BlobStoreClient
+	if ebsClient, err = blobstore.NewEbsClient(ebsConfig); err != nil {
+		log.LogErrorf("NewRestorer: NewEbsClient err: %v, volume: %v", err, volume)
+		metaWrapper.Close()
+		return nil, err
+	}
+
+	var volumeInfo *proto.SimpleVolView
+	if volumeInfo, err = l.mc.AdminAPI().GetVolumeSimpleInfo(volume); err != nil {
+		log.LogErrorf("NewRestorer: get volume info from master failed: volume(%v) err(%v)", volume, err)
+		metaWrapper.Close()
+		return nil, err
+	}
+	extentConfig := &stream.ExtentConfig{
+		Volume:                      volume,
+		Masters:                     l.masters,
+		FollowerRead:                false,
+		OnAppendExtentKey:           metaWrapper.AppendExtentKey,
+		OnSplitExtentKey:            metaWrapper.SplitExtentKey,
+		OnGetExtents:                metaWrapper.GetExtents,
+		OnTruncate:                  metaWrapper.Truncate,
+		OnRenewalForbiddenMigration: metaWrapper.RenewalForbiddenMigration,
+		VolStorageClass:             volumeInfo.VolStorageClass,
+		VolAllowedStorageClass:      volumeInfo.AllowedStorageClass,
+		OnForbiddenMigration:        metaWrapper.ForbiddenMigration,
+		InnerReq:                    true,
+		MetaWrapper:                 metaWrapper,
+	}
+	var extentClient *stream.ExtentClient
+	if extentClient, err = stream.NewExtentClient(extentConfig); err != nil {
+		log.LogErrorf("NewRestorer: NewExtentClient err: %v", err)
+		metaWrapper.Close()
+		return nil, err
+	}
+
+	return &Restorer{
+		volume:    volume,
+		mw:        metaWrapper,
+		ec:        extentClient,
+		ebsClient: ebsClient,
+	}, nil
+}
+
+func (r *Restorer) Close() {
+	r.mw.Close()
+	r.ec.Close()
+}
+
+// restore copies the data of the object to the replica tier, and marks the restore completed.
+// The task is skipped if the restore is not ongoing, e.g. the object has been overwritten. The
+// status is changed only if it is not changed since it is read, so the task dispatched again
+// by the master does not leak the copy of the task still running.
+func (r *Restorer) restore(task *proto.RestoreTask) (copyInode uint64, expiryDate int64, err error) {
+	var info *proto.InodeInfo
+	if info, err = r.mw.InodeGet_ll(task.Inode); err != nil {
+		return
+	}
+	if !proto.IsStorageClassBlobStore(info.StorageClass) {
+		err = fmt.Errorf("inode(%v) storage class(%v) is not blobstore", task.Inode, proto.StorageClassString(info.StorageClass))
+		return
+	}
+	var (
+		status *proto.RestoreStatus
+		raw    []byte
+	)
+	if status, raw, err = getRestoreStatus(r.mw, task.Inode); err != nil {
+		return
+	}
+	if status == nil || !status.Ongoing {
+		log.LogInfof("restore: skip inode(%v) path(%v), restore is not ongoing: %+v", task.Inode, task.Path, status)
+		if status.Restored() {
+			expiryDate = status.ExpiryDate
+		}
+		return
+	}
+	defer func() {
+		// clear the status so that the object can be restored again
+		if err != nil {
+			if delErr := r.mw.XAttrCompareAndSet_ll(task.Inode, proto.XAttrKeyRestore, raw, nil); delErr != nil {
+				log.LogWarnf("restore: clear restore status fail, inode(%v) err: %v", task.Inode, delErr)
+			}
+		}
+	}()
+
+	var copyInfo *proto.InodeInfo
+	if copyInfo, err = r.mw.InodeCreate_ll(0, restoreFileMode, 0, 0, nil, nil, task.Path); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			deleteRestoredCopy(r.mw, copyInfo.Inode, task.Path)
+		}
+	}()
+	if !proto.IsStorageClassReplica(copyInfo.StorageClass) {
+		err = fmt.Errorf("storage class(%v) of the restored copy is not replica", proto.StorageClassString(copyInfo.StorageClass))
+		return
+	}
+	if err = r.copyData(info, copyInfo, task.Path); err != nil {
+		return
+	}
+
+	// the object may be deleted or overwritten, or restored by another task during the restore
+	status.Ongoing = false
+	status.ExpiryDate = proto.RestoreExpiryDate(time.Now(), status.Days).Unix()
+	status.CopyInode = copyInfo.Inode
+	status.CopyStorageClass = copyInfo.StorageClass
+	if err = setRestoreStatus(r.mw, task.Inode, raw, status); err != nil {
+		if err == syscall.ESTALE {
+			// the copy restored by another task is to be expired
+			if status, _, e := getRestoreStatus(r.mw, task.Inode); e == nil && status.Restored() {
+				expiryDate = status.ExpiryDate
+			}
+			err = fmt.Errorf("restore of inode(%v) is canceled", task.Inode)
+		}
+		return
+	}
+	log.LogInfof("restore: inode(%v) path(%v) is restored to inode(%v), status: %+v", task.Inode, task.Path, copyInfo.Inode, status)
+	return copyInfo.Inode, status.ExpiryDate, nil
+}
+
+// expire deletes the restored copy of the object if it is expired, and returns the expiry date
+// of the copy left, which is zero if the object is deleted or has no restored copy.
+func (r *Restorer) expire(task *proto.RestoreTask) (expiryDate int64, err error) {
+	if _, err = expireRestoredCopy(r.mw, task.Inode, task.Path, time.Now(), false); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	var status *proto.RestoreStatus
+	if status, _, err = getRestoreStatus(r.mw, task.Inode); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	if status.Restored() {
+		expiryDate = status.ExpiryDate
+	}
+	return
+}
+
+func (r *Restorer) copyData(src, dst *proto.InodeInfo, fullPath string) (err error) {
+	var objExtents []proto.ObjExtentKey
+	if _, _, _, objExtents, err = r.mw.GetObjExtents(src.Inode); err != nil {
+		return
+	}
+	if err = r.ec.OpenStream(dst.Inode, false, false, fullPath); err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := r.ec.CloseStream(dst.Inode); closeErr != nil {
+			log.LogWarnf("restore: CloseStream fail, inode(%v) err: %v", dst.Inode, closeErr)
+		}
+	}()
+
+	var (
+		ctx     = context.Background()
+		buf     = make([]byte, 2*util.BlockSize)
+		readN   int
+		written uint64
+	)
+	for _, oek := range objExtents {
+		for offset := uint64(0); offset < oek.Size; offset += uint64(readN) {
+			size := oek.Size - offset
+			if size > uint64(len(buf)) {
+				size = uint64(len(buf))
+			}
+			if readN, err = r.ebsClient.Read(ctx, r.volume, buf[:size], offset, size, oek); err != nil {
+				return fmt.Errorf("read blobstore err(%v)", err)
+			}
+			if _, err = r.ec.Write(dst.Inode, int(oek.FileOffset+offset), buf[:readN], 0, nil, dst.StorageClass, false); err != nil {
+				return fmt.Errorf("write replica err(%v)", err)
+			}
+			written += uint64(readN)
+		}
+	}
+	if err = r.ec.Flush(dst.Inode); err != nil {
+		return fmt.Errorf("write replica flush err(%v)", err)
+	}
+	if written != src.Size {
+		return fmt.Errorf("restored size(%v) is inconsistent with inode(%v) size(%v)", written, src.Inode, src.Size)
+	}
+	return
+}
+
+// getRestoreStatus returns the restore status of the object, and the raw status to compare
+// when it is changed.
+func getRestoreStatus(mw MetaWrapper, inode uint64) (status *proto.RestoreStatus, raw []byte, err error) {
+	var info *proto.XAttrInfo
+	if info, err = mw.XAttrGet_ll(inode, proto.XAttrKeyRestore); err != nil {
+		return
+	}
+	if raw = info.Get(proto.XAttrKeyRestore); len(raw) == 0 {
+		return
+	}
+	status = &proto.RestoreStatus{}
+	if err = json.Unmarshal(raw, status); err != nil {
+		return nil, nil, err
+	}
+	return
+}
+
+// setRestoreStatus sets the restore status of the object if the raw status is not changed,
+// otherwise syscall.ESTALE is returned.
+func setRestoreStatus(mw MetaWrapper, inode uint64, expected []byte, status *proto.RestoreStatus) error {
+	raw, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return mw.XAttrCompareAndSet_ll(inode, proto.XAttrKeyRestore, expected, raw)
+}
+
+func deleteRestoredCopy(mw MetaWrapper, inode uint64, fullPath string) {
+	if _, err := mw.InodeUnlink_ll(inode, fullPath); err != nil {
+		log.LogWarnf("deleteRestoredCopy: unlink inode(%v) path(%v) err: %v", inode, fullPath, err)
+		return
+	}
+	if err := mw.Evict(inode, fullPath); err != nil {
+		log.LogWarnf("deleteRestoredCopy: evict inode(%v) path(%v) err: %v", inode, fullPath, err)
+	}
+}
+
+// expireRestoredCopy deletes the restored copy of the object if it is expired, or the object is
+// deleted. The copy is kept if the expiry date is extended meanwhile.
+func expireRestoredCopy(mw MetaWrapper, inode uint64, fullPath string, now time.Time, deleted bool) (expired bool, err error) {
+	var (
+		status *proto.RestoreStatus
+		raw    []byte
+	)
+	if status, raw, err = getRestoreStatus(mw, inode); err != nil || !status.Restored() {
+		return
+	}
+	if !deleted && !status.Expired(now) {
+		return
+	}
+	if !deleted {
+		if err = mw.XAttrCompareAndSet_ll(inode, proto.XAttrKeyRestore, raw, nil); err != nil {
+			if err == syscall.ESTALE {
+				log.LogInfof("expireRestoredCopy: inode(%v) path(%v) restore status changed", inode, fullPath)
+				err = nil
+			}
+			return
+		}
+	}
+	deleteRestoredCopy(mw, status.CopyInode, fullPath)
+	log.LogInfof("expireRestoredCopy: inode(%v) path(%v) restored copy(%v) is deleted, deleted(%v)",
+		inode, fullPath, status.CopyInode, deleted)
+	return true, nil
+}
+
+func (l *LcNode) startRestore(adminTask *proto.AdminTask) {
+	request := adminTask.Request.(*proto.LcNodeRestoreTaskRequest)
+	resp := &proto.LcNodeRestoreTaskResponse{LcNode: l.localServerAddr}
+	adminTask.Response = resp
+	task := request.Task
+	if task == nil {
+		resp.Status = proto.TaskFailed
+		resp.Result = "restore task is empty"
+		return
+	}
+	resp.ID = task.Id
+	resp.Volume = task.VolName
+	resp.Inode = task.Inode
+	resp.Expire = task.Expire
+	log.LogInfof("startRestore: restore task(%+v) received!", task)
+
+	l.restoreLimit <- struct{}{}
+	defer func() {
+		<-l.restoreLimit
+	}()
+
+	start := time.Now()
+	r, err := l.getRestorer(task.VolName)
+	if err == nil {
+		if task.Expire {
+			resp.ExpiryDate, err = r.expire(task)
+		} else {
+			resp.CopyInode, resp.ExpiryDate, err = r.restore(task)
+		}
+		l.putRestorer(r)
+	}
+	if err != nil {
+		log.LogErrorf("startRestore: restore task(%+v) err: %v", task, err)
+		resp.Status = proto.TaskFailed
+		resp.Result = err.Error()
+	} else {
+		resp.Status = proto.TaskSucceeds
+	}
+	auditlog.LogMasterOp("LcRestore", fmt.Sprintf("task(%+v), from master(%v), copy inode(%v), %v",
+		task, request.MasterAddr, resp.CopyInode, time.Since(start).String()), err)
+}
+
+func (l *LcNode) getRestorer(volume string) (r *Restorer, err error) {
+	l.restoreMutex.Lock()
+	defer l.restoreMutex.Unlock()
+	if r = l.restorers[volume]; r == nil {
+		if r, err = NewRestorer(volume, l); err != nil {
+			return
+		}
+		l.restorers[volume] = r
+	}
+	r.running++
+	return
+}
+
+func (l *LcNode) putRestorer(r *Restorer) {
+	l.restoreMutex.Lock()
+	r.running--
+	r.lastActive = time.Now()
+	l.restoreMutex.Unlock()
+}
+
+func (l *LcNode) closeIdleRestorers() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stopC:
+			return
+		case <-ticker.C:
+			l.restoreMutex.Lock()
+			for volume, r := range l.restorers {
+				if r.running == 0 && time.Since(r.lastActive) > defaultRestorerIdleTimeout {
+					r.Close()
+					delete(l.restorers, volume)
+					log.LogInfof("closeIdleRestorers: restorer of volume(%v) is closed", volume)
+				}
+			}
+			l.restoreMutex.Unlock()
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"encoding/json"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRestoreMetaWrapper struct {
+	*MockMetaWrapper
+	inodes     map[uint64]*proto.InodeInfo
+	objExtents map[uint64][]proto.ObjExtentKey
+	xattrs     map[uint64]map[string]string
+	unlinked   []uint64
+	nextInode  uint64
+	// afterGet is called once the xattr is got, e.g. to change it concurrently
+	afterGet func()
+}
+
+func NewMockRestoreMetaWrapper() *MockRestoreMetaWrapper {
+	return &MockRestoreMetaWrapper{
+		MockMetaWrapper: NewMockMetaWrapper(),
+		inodes:          make(map[uint64]*proto.InodeInfo),
+		objExtents:      make(map[uint64][]proto.ObjExtentKey),
+		xattrs:          make(map[uint64]map[string]string),
+		nextInode:       100,
+	}
+}
+
+func (m *MockRestoreMetaWrapper) InodeGet_ll(inode uint64) (*proto.InodeInfo, error) {
+	if info, ok := m.inodes[inode]; ok {
+		return info, nil
+	}
+	return nil, syscall.ENOENT
+}
+
+func (m *MockRestoreMetaWrapper) InodeCreate_ll(parentID uint64, mode, uid, gid uint32, target []byte, quotaIds []uint64, fullPath string) (*proto.InodeInfo, error) {
+	m.nextInode++
+	info := &proto.InodeInfo{Inode: m.nextInode, Mode: mode, StorageClass: proto.StorageClass_Replica_HDD}
+	m.inodes[info.Inode] = info
+	return info, nil
+}
+
+func (m *MockRestoreMetaWrapper) InodeUnlink_ll(inode uint64, fullPath string) (*proto.InodeInfo, error) {
+	m.unlinked = append(m.unlinked, inode)
+	delete(m.inodes, inode)
+	return nil, nil
+}
+
+func (m *MockRestoreMetaWrapper) GetObjExtents(inode uint64) (gen uint64, size uint64, extents []proto.ExtentKey, objExtents []proto.ObjExtentKey, err error) {
+	return 0, m.inodes[inode].Size, nil, m.objExtents[inode], nil
+}
+
+func (m *MockRestoreMetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error) {
+	info := &proto.XAttrInfo{Inode: inode, XAttrs: make(map[string]string)}
+	if value, ok := m.xattrs[inode][name]; ok {
+		info.XAttrs[name] = value
+	}
+	if afterGet := m.afterGet; afterGet != nil {
+		m.afterGet = nil
+		afterGet()
+	}
+	return info, nil
+}
+
+func (m *MockRestoreMetaWrapper) XAttrCompareAndSet_ll(inode uint64, name string, expected, value []byte) error {
+	if m.xattrs[inode][name] != string(expected) {
+		return syscall.ESTALE
+	}
+	if len(value) == 0 {
+		return m.XAttrDel_ll(inode, name)
+	}
+	return m.XAttrSet_ll(inode, []byte(name), value)
+}
+
+func (m *MockRestoreMetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
+	if m.xattrs[inode] == nil {
+		m.xattrs[inode] = make(map[string]string)
+	}
+	m.xattrs[inode][string(name)] = string(value)
+	return nil
+}
+
+func (m *MockRestoreMetaWrapper) XAttrDel_ll(inode uint64, name string) error {
+	delete(m.xattrs[inode], name)
+	return nil
+}
+
+func TestRestorer(t *testing.T) {
+	data := []byte("hello restore")
+	mw := NewMockRestoreMetaWrapper()
+	mw.inodes[10] = &proto.InodeInfo{Inode: 10, Size: uint64(len(data)), StorageClass: proto.StorageClass_BlobStore}
+	mw.objExtents[10] = []proto.ObjExtentKey{{FileOffset: 0, Size: 5}, {FileOffset: 5, Size: uint64(len(data)) - 5}}
+	mw.inodes[11] = &proto.InodeInfo{Inode: 11, Size: uint64(len(data)), StorageClass: proto.StorageClass_Replica_SSD}
+	ec := NewMockExtentClient()
+	r := &Restorer{
+		volume:    "test_vol",
+		mw:        mw,
+		ec:        ec,
+		ebsClient: &MockEbsClient{data: data},
+	}
+	task := &proto.RestoreTask{Id: "test_id", VolName: "test_vol", Path: "a/b", Inode: 10, Days: 2}
+
+	// the restore is not requested
+	copyInode, expiryDate, err := r.restore(task)
+	require.NoError(t, err)
+	require.Zero(t, copyInode)
+	require.Zero(t, expiryDate)
+	require.Len(t, mw.inodes, 2)
+
+	// the object is not in the blobstore storage class
+	_, _, err = r.restore(&proto.RestoreTask{Inode: 11, Days: 2})
+	require.Error(t, err)
+
+	start := time.Now()
+	require.NoError(t, setRestoreStatus(mw, 10, nil, &proto.RestoreStatus{Ongoing: true, RequestTime: start.Unix(), Days: 2}))
+	copyInode, expiryDate, err = r.restore(task)
+	require.NoError(t, err)
+	require.NotZero(t, copyInode)
+	require.Equal(t, data[5:], ec.data)
+	status, _, err := getRestoreStatus(mw, 10)
+	require.NoError(t, err)
+	require.Equal(t, status.ExpiryDate, expiryDate)
+	require.True(t, status.Restored())
+	require.Equal(t, copyInode, status.CopyInode)
+	require.Equal(t, uint32(proto.StorageClass_Replica_HDD), status.CopyStorageClass)
+	require.Equal(t, proto.RestoreExpiryDate(start, 2).Unix(), status.ExpiryDate)
+
+	// the restored copy is not expired before the expiry date
+	expireTask := &proto.RestoreTask{Id: "test_id", VolName: "test_vol", Path: "a/b", Inode: 10, Expire: true}
+	expiryDate, err = r.expire(expireTask)
+	require.NoError(t, err)
+	require.Equal(t, status.ExpiryDate, expiryDate)
+	expired, err := expireRestoredCopy(mw, 10, task.Path, start, false)
+	require.NoError(t, err)
+	require.False(t, expired)
+
+	// the restored copy is kept if the expiry date is extended concurrently
+	extended := *status
+	extended.ExpiryDate++
+	raw, _ := json.Marshal(&extended)
+	mw.afterGet = func() { mw.xattrs[10][proto.XAttrKeyRestore] = string(raw) }
+	expired, err = expireRestoredCopy(mw, 10, task.Path, time.Unix(status.ExpiryDate, 0), false)
+	require.NoError(t, err)
+	require.False(t, expired)
+	require.Empty(t, mw.unlinked)
+
+	// the restored copy is expired after the expiry date
+	expired, err = expireRestoredCopy(mw, 10, task.Path, time.Unix(extended.ExpiryDate, 0), false)
+	require.NoError(t, err)
+	require.True(t, expired)
+	require.Equal(t, []uint64{copyInode}, mw.unlinked)
+	status, _, err = getRestoreStatus(mw, 10)
+	require.NoError(t, err)
+	require.Nil(t, status)
+	expiryDate, err = r.expire(expireTask)
+	require.NoError(t, err)
+	require.Zero(t, expiryDate)
+
+	// the copy is deleted but the status is kept if the object is restored by another task
+	ongoing := &proto.RestoreStatus{Ongoing: true, RequestTime: start.Unix(), Days: 1}
+	require.NoError(t, setRestoreStatus(mw, 10, nil, ongoing))
+	restored := &proto.RestoreStatus{RequestTime: start.Unix(), Days: 1, ExpiryDate: start.Unix(), CopyInode: 99}
+	raw, _ = json.Marshal(restored)
+	mw.afterGet = func() { mw.xattrs[10][proto.XAttrKeyRestore] = string(raw) }
+	_, expiryDate, err = r.restore(task)
+	require.Error(t, err)
+	require.Equal(t, restored.ExpiryDate, expiryDate)
+	require.Len(t, mw.unlinked, 2)
+	status, _, err = getRestoreStatus(mw, 10)
+	require.NoError(t, err)
+	require.Equal(t, restored, status)
+
+	// the status is cleared if the restore fails
+	mw.inodes[10].Size++
+	require.NoError(t, mw.XAttrDel_ll(10, proto.XAttrKeyRestore))
+	require.NoError(t, setRestoreStatus(mw, 10, nil, ongoing))
+	_, _, err = r.restore(task)
+	require.Error(t, err)
+	require.Len(t, mw.unlinked, 3)
+	status, _, err = getRestoreStatus(mw, 10)
+	require.NoError(t, err)
+	require.Nil(t, status)
+
+	// the restore status is gone with the object
+	delete(mw.inodes, 10)
+	delete(mw.xattrs, 10)
+	expiryDate, err = r.expire(expireTask)
+	require.NoError(t, err)
+	require.Zero(t, expiryDate)
+}
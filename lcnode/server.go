@@ -35,6 +35,7 @@ import (
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/tlsutil"
 	"github.com/gorilla/mux"
 	"golang.org/x/time/rate"
 )
@@ -55,12 +56,20 @@ type LcNode struct {
 	control          common.Control
 	lcScanners       map[string]*LcScanner
 	snapshotScanners map[string]*SnapshotScanner
+	restoreMutex     sync.Mutex
+	restoreLimit     chan struct{}
+	restorers        map[string]*Restorer
+	s3Endpoint       string
+	batchMutex       sync.Mutex
+	batchWorkers     map[string]*BatchWorker
 }
 
 func NewServer() *LcNode {
 	return &LcNode{
 		lcScanners:       make(map[string]*LcScanner),
 		snapshotScanners: make(map[string]*SnapshotScanner),
+		restorers:        make(map[string]*Restorer),
+		batchWorkers:     make(map[string]*BatchWorker),
 	}
 }
 
@@ -93,6 +102,7 @@ func doStart(s common.Server, cfg *config.Config) (err error) {
 	exporter.RegistConsul(l.clusterID, ModuleName, cfg)
 
 	go l.checkRegister()
+	go l.closeIdleRestorers()
 	if err = l.startServer(); err != nil {
 		return
 	}
@@ -142,6 +152,7 @@ func (l *LcNode) parseConfig(cfg *config.Config) (err error) {
 	log.LogWarnf("loadConfig: setup config: %v(%v)", configMasterAddr, strings.Join(masters, ","))
 	l.masters = masters
 	l.mc = master.NewMasterClient(masters, false)
+	l.mc.SetAPIToken(cfg.GetString(configMasterAPIToken))
 
 	// parse scanCheckInterval
 	scanCheckInterval = cfg.GetInt64(configScanCheckIntervalStr)
@@ -202,6 +213,25 @@ func (l *LcNode) parseConfig(cfg *config.Config) (err error) {
 	useCreateTime = cfg.GetBool(configUseCreateTime)
 	log.LogWarnf("loadConfig: setup config: %v(%v)", configUseCreateTime, useCreateTime)
 
+	// parse restoreRoutineNum
+	restoreRoutineNum = cfg.GetInt(configRestoreRoutineNumStr)
+	if restoreRoutineNum <= 0 || restoreRoutineNum > maxLcScanRoutineNumPerTask {
+		restoreRoutineNum = defaultRestoreRoutineNum
+	}
+	l.restoreLimit = make(chan struct{}, restoreRoutineNum)
+	log.LogWarnf("loadConfig: setup config: %v(%v)", configRestoreRoutineNumStr, restoreRoutineNum)
+
+	// parse s3Endpoint, the objectnode which the s3 batch tasks are sent to
+	l.s3Endpoint = cfg.GetString(configS3EndpointStr)
+	log.LogWarnf("loadConfig: setup config: %v(%v)", configS3EndpointStr, l.s3Endpoint)
+
+	// parse batchRoutineNumPerTask
+	batchRoutineNumPerTask = cfg.GetInt(configBatchRoutineNumPerTaskStr)
+	if batchRoutineNumPerTask <= 0 || batchRoutineNumPerTask > maxLcScanRoutineNumPerTask {
+		batchRoutineNumPerTask = defaultBatchRoutineNumPerTask
+	}
+	log.LogWarnf("loadConfig: setup config: %v(%v)", configBatchRoutineNumPerTaskStr, batchRoutineNumPerTask)
+
 	stream.SetExentRetryArgs(defaultAllocRetryInterval, defaultWriteRetryInterval, defaultExtenthandlerMaxRetryMin, true)
 
 	return
@@ -258,6 +288,7 @@ func (l *LcNode) checkRegister() {
 		if time.Since(l.lastHeartbeat) > time.Minute*10 {
 			log.LogWarnf("lcnode might be deregistered from master, stop scanners...")
 			l.stopScanners()
+			l.stopBatchWorkers()
 			log.LogWarnf("lcnode might be deregistered from master, retry registering...")
 			l.register()
 			l.lastHeartbeat = time.Now()
@@ -269,7 +300,7 @@ func (l *LcNode) checkRegister() {
 func (l *LcNode) startServer() (err error) {
 	log.LogInfo("Start: startServer")
 	addr := fmt.Sprintf(":%v", l.listen)
-	listener, err := net.Listen("tcp", addr)
+	listener, err := tlsutil.Listen("tcp", addr)
 	log.LogInfof("action[startServer] listen tcp address(%v).", addr)
 	if err != nil {
 		log.LogErrorf("action[startServer] failed to listen, err: %v", err)
@@ -297,9 +328,10 @@ func (l *LcNode) startServer() (err error) {
 
 func (l *LcNode) serveConn(conn net.Conn, stopC chan bool) {
 	defer conn.Close()
-	c := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
+	if c, ok := tlsutil.TCPConn(conn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
 	remoteAddr := conn.RemoteAddr().String()
 	for {
 		select {
@@ -329,6 +361,10 @@ func (l *LcNode) handlePacket(conn net.Conn, p *proto.Packet, remoteAddr string)
 		err = l.opLcScan(conn, p)
 	case proto.OpLcNodeSnapshotVerDel:
 		err = l.opSnapshotVerDel(conn, p)
+	case proto.OpLcNodeRestore:
+		err = l.opRestore(conn, p)
+	case proto.OpLcNodeBatch:
+		err = l.opBatch(conn, p)
 	default:
 		err = fmt.Errorf("%s unknown Opcode: %d, reqId: %d", remoteAddr,
 			p.Opcode, p.GetReqID())
@@ -373,6 +409,9 @@ func (l *LcNode) httpServiceStart() {
 	router.NewRoute().Methods(http.MethodGet).
 		Path("/getFile").
 		HandlerFunc(l.httpServiceGetFile)
+	router.NewRoute().Methods(http.MethodGet).
+		Path("/stopBatchTask").
+		HandlerFunc(l.httpServiceStopBatchTask)
 
 	addr := fmt.Sprintf(":%v", l.httpListen)
 	server := &http.Server{
@@ -32,6 +32,7 @@ import (
 	"github.com/cubefs/cubefs/util/compressor"
 	"github.com/cubefs/cubefs/util/cryptoutil"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 var (
@@ -423,6 +424,7 @@ type updateVolReq struct {
 	txOpLimit                int
 	zoneName                 string
 	description              string
+	spreadLabel              string
 	dpSelectorName           string
 	dpSelectorParm           string
 	replicaNum               int
@@ -463,6 +465,10 @@ func parseVolUpdateReq(r *http.Request, vol *Vol, req *updateVolReq) (err error)
 
 	req.authKey = extractStr(r, volAuthKey)
 	req.description = extractStrWithDefault(r, descriptionKey, vol.description)
+	req.spreadLabel = extractStrWithDefault(r, spreadLabelKey, vol.spreadLabel)
+	if req.spreadLabel != "" && !proto.ValidTopologyLabel(req.spreadLabel) {
+		return fmt.Errorf("invalid %v: %v", spreadLabelKey, req.spreadLabel)
+	}
 	req.zoneName = extractStrWithDefault(r, zoneNameKey, vol.zoneName)
 	if req.crossZone, err = extractBoolWithDefault(r, crossZoneKey, vol.crossZone); err != nil {
 		return
@@ -741,6 +747,7 @@ type createVolReq struct {
 	domainId                uint64
 	zoneName                string
 	description             string
+	spreadLabel             string
 	volType                 int
 	enablePosixAcl          bool
 	DpReadOnlyWhenVolFull   bool
@@ -930,6 +937,10 @@ func parseRequestToCreateVol(r *http.Request, req *createVolReq) (err error) {
 
 	req.zoneName = extractStr(r, zoneNameKey)
 	req.description = extractStr(r, descriptionKey)
+	req.spreadLabel = extractStr(r, spreadLabelKey)
+	if req.spreadLabel != "" && !proto.ValidTopologyLabel(req.spreadLabel) {
+		return fmt.Errorf("invalid %v: %v", spreadLabelKey, req.spreadLabel)
+	}
 
 	req.domainId, err = extractUint64WithDefault(r, domainIdKey, 0)
 	if err != nil {
@@ -1360,6 +1371,22 @@ func parseAndExtractDataNodeGOGC(r *http.Request) (dataNodeGOGC int, err error)
 	return
 }
 
+func parseTLSTrust(r *http.Request) (info *proto.TLSTrustInfo, err error) {
+	var body []byte
+	if body, err = io.ReadAll(r.Body); err != nil {
+		return
+	}
+	info = &proto.TLSTrustInfo{}
+	if err = json.Unmarshal(body, info); err != nil {
+		return
+	}
+	if !tlsutil.IsValidMode(info.TLSMode) {
+		err = fmt.Errorf("tlsMode must be one of [%v, %v, %v, %v] or empty", tlsutil.ModeDisable,
+			tlsutil.ModePermissive, tlsutil.ModePrefer, tlsutil.ModeStrict)
+	}
+	return
+}
+
 func parseAndExtractFileStatsThresholds(r *http.Request) (thresholds []uint64, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -1630,6 +1657,17 @@ func parseAndExtractSetNodeInfoParams(r *http.Request) (params map[string]interf
 		params[autoDpMetaRepairKey] = val
 	}
 
+	if value = r.FormValue(spreadRepairKey); value != "" {
+		noParams = false
+		val := false
+		val, err = strconv.ParseBool(value)
+		if err != nil {
+			err = unmatchedKey(spreadRepairKey)
+			return
+		}
+		params[spreadRepairKey] = val
+	}
+
 	if value = r.FormValue(autoDpMetaRepairParallelCntKey); value != "" {
 		noParams = false
 		val := int64(0)
@@ -252,6 +252,39 @@ func (m *Server) setDataNodeGOGC(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set dataNodeGOGC to %v successfully", dataNodeGOGC)))
 }
 
+func (m *Server) setTLSTrust(w http.ResponseWriter, r *http.Request) {
+	var (
+		info *proto.TLSTrustInfo
+		err  error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSetTLSTrust))
+	defer func() {
+		doStatAndMetric(proto.AdminSetTLSTrust, metric, err, nil)
+		if info != nil {
+			AuditLog(r, proto.AdminSetTLSTrust, fmt.Sprintf("set tlsMode to %v, tlsCAPem length %v", info.TLSMode, len(info.TLSCAPem)), err)
+		}
+	}()
+
+	if info, err = parseTLSTrust(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.setTLSTrust(info.TLSMode, info.TLSCAPem); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set tlsMode to %v successfully", info.TLSMode)))
+}
+
+func (m *Server) getTLSTrust(w http.ResponseWriter, r *http.Request) {
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminGetTLSTrust))
+	defer func() {
+		doStatAndMetric(proto.AdminGetTLSTrust, metric, nil, nil)
+	}()
+
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.getTLSTrust()))
+}
+
 // Turn on or off the automatic allocation of the data partitions.
 // If DisableAutoAllocate == off, then we WILL NOT automatically allocate new data partitions for the volume when:
 //  1. the used space is below the max capacity,
@@ -946,6 +979,7 @@ func (m *Server) getCluster(w http.ResponseWriter, r *http.Request) {
 		MarkDiskBrokenThreshold:                m.cluster.getMarkDiskBrokenThreshold(),
 		EnableAutoDpMetaRepair:                 m.cluster.getEnableAutoDpMetaRepair(),
 		AutoDpMetaRepairParallelCnt:            m.cluster.GetAutoDpMetaRepairParallelCnt(),
+		EnableSpreadRepair:                     m.cluster.getEnableSpreadRepair(),
 		EnableAutoDecommission:                 m.cluster.AutoDecommissionDiskIsEnabled(),
 		AutoDecommissionDiskInterval:           m.cluster.GetAutoDecommissionDiskInterval().String(),
 		DecommissionLimit:                      atomic.LoadUint64(&m.cluster.DecommissionLimit),
@@ -2038,6 +2072,50 @@ func (m *Server) changeMetaPartitionLeader(w http.ResponseWriter, r *http.Reques
 	_ = sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
 }
 
+func (m *Server) splitMetaPartition(w http.ResponseWriter, r *http.Request) {
+	var (
+		mp          *MetaPartition
+		nextMp      *MetaPartition
+		vol         *Vol
+		partitionID uint64
+		splitKey    uint64
+		err         error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSplitMetaPartition))
+	defer func() {
+		doStatAndMetric(proto.AdminSplitMetaPartition, metric, err, nil)
+		AuditLog(r, proto.AdminSplitMetaPartition, fmt.Sprintf("split meta partition %v at inode %v", partitionID, splitKey), err)
+	}()
+
+	if partitionID, _, err = parseRequestToGetDataPartition(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if splitKey, err = extractUint64WithDefault(r, splitInodeKey, 0); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if mp, err = m.cluster.getMetaPartitionByID(partitionID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrMetaPartitionNotExists))
+		return
+	}
+	if vol, err = m.cluster.getVol(mp.volName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	if nextMp, err = vol.splitMetaPartitionAt(m.cluster, mp, splitKey); err != nil {
+		log.LogErrorf("splitMetaPartition.err %v", err)
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if nextMp == nil {
+		_ = sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("meta partition %v split at inode %v is committed", partitionID, splitKey)))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("meta partition %v is split at inode %v, the inodes move to meta partition %v",
+		partitionID, nextMp.Start, nextMp.PartitionID)))
+}
+
 // balance the leader meta partition in metaNodes which can select all cluster some zones or noteSet
 func (m *Server) balanceMetaPartitionLeader(w http.ResponseWriter, r *http.Request) {
 	var (
@@ -2597,6 +2675,7 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	newArgs.zoneName = req.zoneName
 	newArgs.crossZone = req.crossZone
 	newArgs.description = req.description
+	newArgs.spreadLabel = req.spreadLabel
 	newArgs.capacity = req.capacity
 	newArgs.deleteLockTime = req.deleteLockTime
 	newArgs.followerRead = req.followerRead
@@ -3163,6 +3242,7 @@ func newSimpleView(vol *Vol) (view *proto.SimpleVolView) {
 		CreateTime:              time.Unix(vol.createTime, 0).Format(proto.TimeFormat),
 		DeleteLockTime:          vol.DeleteLockTime,
 		Description:             vol.description,
+		SpreadLabel:             vol.spreadLabel,
 		DpSelectorName:          vol.dpSelectorName,
 		DpSelectorParm:          vol.dpSelectorParm,
 		DpReadOnlyWhenVolFull:   vol.DpReadOnlyWhenVolFull,
@@ -3326,6 +3406,7 @@ func (m *Server) getDataNode(w http.ResponseWriter, r *http.Request) {
 		AvailableSpace:                        dataNode.AvailableSpace,
 		ID:                                    dataNode.ID,
 		ZoneName:                              dataNode.ZoneName,
+		Labels:                                dataNode.GetLabels(),
 		Addr:                                  dataNode.Addr,
 		RaftHeartbeatPort:                     dataNode.HeartbeatPort,
 		RaftReplicaPort:                       dataNode.ReplicaPort,
@@ -3806,6 +3887,15 @@ func (m *Server) setNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if val, ok := params[spreadRepairKey]; ok {
+		if spreadRepair, ok := val.(bool); ok {
+			if err = m.cluster.setEnableSpreadRepair(spreadRepair); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
+
 	if val, ok := params[autoDpMetaRepairParallelCntKey]; ok {
 		if cnt, ok := val.(int); ok {
 			if err = m.cluster.setAutoDpMetaRepairParallelCnt(cnt); err != nil {
@@ -5262,6 +5352,7 @@ func (m *Server) getMetaNode(w http.ResponseWriter, r *http.Request) {
 		IsActive:                  metaNode.IsActive,
 		IsWriteAble:               metaNode.IsWriteAble(),
 		ZoneName:                  metaNode.ZoneName,
+		Labels:                    metaNode.GetLabels(),
 		MaxMemAvailWeight:         metaNode.MaxMemAvailWeight,
 		Total:                     metaNode.Total,
 		Used:                      metaNode.Used,
@@ -6733,6 +6824,15 @@ func (m *Server) queryDecommissionToken(w http.ResponseWriter, r *http.Request)
 	sendOkReply(w, r, newSuccessHTTPReply(stats))
 }
 
+func (m *Server) getSpreadViolations(w http.ResponseWriter, r *http.Request) {
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminGetSpreadViolations))
+	defer func() {
+		doStatAndMetric(proto.AdminGetSpreadViolations, metric, nil, nil)
+	}()
+
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.getSpreadViolations()))
+}
+
 func (m *Server) queryDecommissionLimit(w http.ResponseWriter, r *http.Request) {
 	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminQueryDecommissionLimit))
 	defer func() {
@@ -7945,6 +8045,263 @@ func (m *Server) DelBucketLifecycle(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
+func (m *Server) SetBucketInventory(w http.ResponseWriter, r *http.Request) {
+	var (
+		bytes []byte
+		err   error
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.SetBucketInventory))
+	defer func() {
+		doStatAndMetric(proto.SetBucketInventory, metric, err, nil)
+	}()
+
+	if bytes, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	req := proto.BucketInventory{}
+	if err = json.Unmarshal(bytes, &req); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(req.VolName); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	if err = m.validBucketInventory(&req); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	err = m.cluster.SetBucketInventory(&req)
+	AuditLog(r, "SetBucketInventory", fmt.Sprintf("BucketInventory(%v)", string(bytes)), err)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set vol[%v] inventory successfully", req.VolName)))
+}
+
+func (m *Server) validBucketInventory(inventory *proto.BucketInventory) error {
+	if len(inventory.Configurations) > proto.MaxInventoryConfigurations {
+		return proto.InventoryErrTooMany
+	}
+	ids := make(map[string]bool, len(inventory.Configurations))
+	for _, conf := range inventory.Configurations {
+		if ids[conf.Id] {
+			return proto.InventoryErrInvalidId
+		}
+		ids[conf.Id] = true
+		if err := conf.Validate(); err != nil {
+			return err
+		}
+		if _, err := m.cluster.getVol(conf.Destination.Bucket); err != nil {
+			return fmt.Errorf("destination bucket %v: %v", conf.Destination.Bucket, err)
+		}
+	}
+	return nil
+}
+
+func (m *Server) GetBucketInventory(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		name      string
+		inventory *proto.BucketInventory
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.GetBucketInventory))
+	defer func() {
+		doStatAndMetric(proto.GetBucketInventory, metric, err, nil)
+	}()
+
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	if inventory = m.cluster.GetBucketInventory(name); inventory == nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrNoSuchInventoryConfiguration))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(inventory))
+}
+
+func (m *Server) DelBucketInventory(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		name string
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.DeleteBucketInventory))
+	defer func() {
+		doStatAndMetric(proto.DeleteBucketInventory, metric, err, nil)
+	}()
+
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	err = m.cluster.DelBucketInventory(name)
+	AuditLog(r, "DelBucketInventory", fmt.Sprintf("vol(%v)", name), err)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error()})
+		return
+	}
+	msg := fmt.Sprintf("delete vol[%v] inventory successfully", name)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) restoreObject(w http.ResponseWriter, r *http.Request) {
+	var (
+		bytes []byte
+		err   error
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.RestoreObject))
+	defer func() {
+		doStatAndMetric(proto.RestoreObject, metric, err, nil)
+	}()
+
+	if bytes, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	req := proto.RestoreTask{}
+	if err = json.Unmarshal(bytes, &req); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if req.Inode == 0 || req.Days <= 0 {
+		err = fmt.Errorf("invalid restore task: inode(%v) days(%v)", req.Inode, req.Days)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(req.VolName); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+
+	err = m.cluster.restoreObject(&req)
+	AuditLog(r, "RestoreObject", fmt.Sprintf("RestoreTask(%v)", string(bytes)), err)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("restore task[%v] is dispatched", req.Id)))
+}
+
+func (m *Server) createBatchJob(w http.ResponseWriter, r *http.Request) {
+	var (
+		bytes []byte
+		job   *proto.BatchJob
+		err   error
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.BatchJobCreate))
+	defer func() {
+		doStatAndMetric(proto.BatchJobCreate, metric, err, nil)
+	}()
+
+	if bytes, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	req := &proto.BatchJob{}
+	if err = json.Unmarshal(bytes, req); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = req.Validate(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	job, err = m.cluster.batchMgr.createJob(req)
+	AuditLog(r, "CreateBatchJob", fmt.Sprintf("BatchJob(%v)", string(bytes)), err)
+	if err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(job))
+}
+
+func (m *Server) getBatchJob(w http.ResponseWriter, r *http.Request) {
+	var (
+		job *proto.BatchJob
+		err error
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.BatchJobGet))
+	defer func() {
+		doStatAndMetric(proto.BatchJobGet, metric, err, nil)
+	}()
+
+	id := extractStr(r, idKey)
+	if id == "" {
+		err = keyNotFound(idKey)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if job, err = m.cluster.batchMgr.getJob(id, extractStr(r, volOwnerKey)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(job))
+}
+
+func (m *Server) listBatchJobs(w http.ResponseWriter, r *http.Request) {
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.BatchJobList))
+	defer func() {
+		doStatAndMetric(proto.BatchJobList, metric, nil, nil)
+	}()
+
+	var statuses []string
+	if status := extractStr(r, statusKey); status != "" {
+		statuses = strings.Split(status, ",")
+	}
+	jobs := m.cluster.batchMgr.listJobs(extractStr(r, volOwnerKey), statuses)
+	sendOkReply(w, r, newSuccessHTTPReply(jobs))
+}
+
+func (m *Server) updateBatchJobStatus(w http.ResponseWriter, r *http.Request) {
+	var (
+		bytes []byte
+		job   *proto.BatchJob
+		err   error
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.BatchJobUpdateStatus))
+	defer func() {
+		doStatAndMetric(proto.BatchJobUpdateStatus, metric, err, nil)
+	}()
+
+	if bytes, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	req := &proto.BatchJobStatusRequest{}
+	if err = json.Unmarshal(bytes, req); err != nil || req.JobId == "" {
+		err = fmt.Errorf("invalid batch job status request: %v", string(bytes))
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	job, err = m.cluster.batchMgr.updateStatus(req)
+	AuditLog(r, "UpdateBatchJobStatus", fmt.Sprintf("request(%v)", string(bytes)), err)
+	if err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(job))
+}
+
 func (m *Server) adminLcNode(w http.ResponseWriter, r *http.Request) {
 	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminLcNode))
 	defer func() {
@@ -8028,14 +8385,9 @@ func (m *Server) S3QosSet(w http.ResponseWriter, r *http.Request) {
 
 	// set s3 qos quota
 	if param.Quota != 0 {
-		if strings.ToLower(param.Uid) == proto.DefaultUid {
-			param.Uid = proto.DefaultUid
-		}
-		param.Api = strings.ToLower(param.Api)
 		metadata := new(RaftCmd)
 		metadata.Op = opSyncS3QosSet
-		key := param.Api + keySeparator + param.Uid + keySeparator + param.Type
-		metadata.K = S3QoSPrefix + key
+		metadata.K = s3QosParamKey(param)
 		metadata.V = []byte(strconv.FormatUint(param.Quota, 10))
 
 		// raft sync
@@ -8074,8 +8426,10 @@ func (m *Server) S3QosGet(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	apiLimitConf := make(map[string]*proto.UserLimitConf)
+	bucketLimitConf := make(map[string]*proto.BucketLimitConf)
 	s3QosResponse := proto.S3QoSResponse{
-		ApiLimitConf: apiLimitConf,
+		ApiLimitConf:    apiLimitConf,
+		BucketLimitConf: bucketLimitConf,
 	}
 	// memory cache
 	m.cluster.S3ApiQosQuota.Range(func(key, value interface{}) bool {
@@ -8090,6 +8444,23 @@ func (m *Server) S3QosGet(w http.ResponseWriter, r *http.Request) {
 			s3QosResponse.Nodes = v
 			return true
 		}
+		if bucket, isBucket := parseS3QoSBucket(uid); isBucket {
+			if _, ok := bucketLimitConf[api]; !ok {
+				bucketLimitConf[api] = &proto.BucketLimitConf{
+					BandWidthQuota: make(map[string]uint64),
+					QPSQuota:       make(map[string]uint64),
+				}
+			}
+			switch limitType {
+			case proto.FlowLimit:
+				bucketLimitConf[api].BandWidthQuota[bucket] = v
+			case proto.QPSLimit:
+				bucketLimitConf[api].QPSQuota[bucket] = v
+			default:
+				// do nothing
+			}
+			return true
+		}
 		if _, ok := apiLimitConf[api]; !ok {
 			bandWidthQuota := make(map[string]uint64)
 			qpsQuota := make(map[string]uint64)
@@ -8139,14 +8510,9 @@ func (m *Server) S3QosDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if strings.ToLower(param.Uid) == proto.DefaultUid {
-		param.Uid = proto.DefaultUid
-	}
-	param.Api = strings.ToLower(param.Api)
 	metadata := new(RaftCmd)
 	metadata.Op = opSyncS3QosDelete
-	key := param.Api + keySeparator + param.Uid + keySeparator + param.Type
-	metadata.K = S3QoSPrefix + key
+	metadata.K = s3QosParamKey(param)
 
 	// raft sync
 	if err = m.cluster.submit(metadata); err != nil {
@@ -8160,23 +8526,81 @@ func (m *Server) S3QosDelete(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply("success"))
 }
 
+// S3QosLease leases the shares of the quotas to the ObjectNode by the demands reported by it.
+func (m *Server) S3QosLease(w http.ResponseWriter, r *http.Request) {
+	var (
+		param = &proto.S3QoSLeaseRequest{}
+		err   error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.S3QoSLease))
+	defer func() {
+		doStatAndMetric(proto.S3QoSLease, metric, err, nil)
+	}()
+
+	var body []byte
+	if body, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, param); err != nil || param.Node == "" {
+		log.LogErrorf("[S3QosLease] parse fail err [%v]", err)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: "s3 qos lease param err"})
+		return
+	}
+
+	resp := m.cluster.s3QosLeases.lease(param, m.cluster.s3QosQuota, time.Now())
+	log.LogDebugf("[S3QosLease] node [%v] demands [%v] shares [%v]", param.Node, param.Demands, resp.Shares)
+	sendOkReply(w, r, newSuccessHTTPReply(resp))
+}
+
 func parseS3QoSKey(key string) (api, uid, limitType, nodes string, err error) {
 	s3qosInfo := strings.TrimPrefix(key, S3QoSPrefix)
 	strs := strings.Split(s3qosInfo, keySeparator)
 	if len(strs) == 3 {
 		return strs[0], strs[1], strs[2], "", nil
 	}
+	// the bucket is returned as the uid with the bucket scope, see parseS3QoSBucket
+	if len(strs) == 4 && strs[1] == proto.S3QoSScopeBucket {
+		return strs[0], strs[1] + keySeparator + strs[2], strs[3], "", nil
+	}
 	if len(strs) == 1 && strs[0] == proto.S3Nodes {
 		return "", "", "", strs[0], nil
 	}
 	return "", "", "", "", errors.New("unexpected key")
 }
 
+func parseS3QoSBucket(uid string) (bucket string, ok bool) {
+	if !strings.HasPrefix(uid, proto.S3QoSScopeBucket+keySeparator) {
+		return "", false
+	}
+	return strings.TrimPrefix(uid, proto.S3QoSScopeBucket+keySeparator), true
+}
+
+// s3QosParamKey returns the key of the quota in the store, the quota of the bucket is
+// keyed by the bucket scope and the bucket name instead of the uid.
+func s3QosParamKey(param *proto.S3QosRequest) string {
+	api := strings.ToLower(param.Api)
+	if param.Bucket != "" {
+		return s3QosBucketKey(api, param.Bucket, param.Type)
+	}
+	uid := param.Uid
+	if strings.ToLower(uid) == proto.DefaultUid {
+		uid = proto.DefaultUid
+	}
+	return s3QosUidKey(api, uid, param.Type)
+}
+
 func isS3QosConfigValid(param *proto.S3QosRequest) bool {
 	if param.Type != proto.FlowLimit && param.Type != proto.QPSLimit && param.Type != proto.ConcurrentLimit {
 		return false
 	}
 
+	// the concurrency of buckets is not limited
+	if param.Bucket != "" && (param.Uid != "" || param.Type == proto.ConcurrentLimit ||
+		strings.Contains(param.Bucket, keySeparator)) {
+		return false
+	}
+
 	if proto.IsS3PutApi(param.Api) {
 		return false
 	}
@@ -503,6 +503,107 @@ func (m *Server) deleteMetaNodeBalancePlan(w http.ResponseWriter, r *http.Reques
 	sendOkReply(w, r, newSuccessHTTPReply("Delete balance plan task successfully."))
 }
 
+func (m *Server) createDataNodeBalancePlan(w http.ResponseWriter, r *http.Request) {
+	var err error
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.CreateDataNodeBalanceTask))
+	defer func() {
+		doStatAndMetric(proto.CreateDataNodeBalanceTask, metric, err, nil)
+	}()
+
+	var parallel int
+	if parallel, err = extractUintWithDefault(r, parallelKey, defaultDataBalanceParallel); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if parallel == 0 || parallel > maxDataBalanceParallel {
+		err = fmt.Errorf("parallel should be in [1, %d]", maxDataBalanceParallel)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	var plan *proto.DataClusterPlan
+	plan, err = m.cluster.CreateDataPartitionBalanceTask(parallel)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error(), Data: plan})
+		return
+	}
+
+	AuditLog(r, "createDataBalancePlan", "create data partition balance task", nil)
+
+	sendOkReply(w, r, newSuccessHTTPReply(plan))
+}
+
+func (m *Server) getDataNodeBalancePlan(w http.ResponseWriter, r *http.Request) {
+	var err error
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.GetDataNodeBalanceTask))
+	defer func() {
+		doStatAndMetric(proto.GetDataNodeBalanceTask, metric, err, nil)
+	}()
+
+	var plan *proto.DataClusterPlan
+	plan, err = m.cluster.loadDataBalanceTask()
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error(), Data: plan})
+		return
+	}
+
+	sendOkReply(w, r, newSuccessHTTPReply(plan))
+}
+
+func (m *Server) runDataNodeBalancePlan(w http.ResponseWriter, r *http.Request) {
+	var err error
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.RunDataNodeBalanceTask))
+	defer func() {
+		doStatAndMetric(proto.RunDataNodeBalanceTask, metric, err, nil)
+	}()
+
+	err = m.cluster.RunDataPartitionBalanceTask()
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error()})
+		return
+	}
+
+	AuditLog(r, "runDataBalancePlan", "start to run data partition balance task", nil)
+
+	sendOkReply(w, r, newSuccessHTTPReply("Start running data balance task successfully."))
+}
+
+func (m *Server) stopDataNodeBalancePlan(w http.ResponseWriter, r *http.Request) {
+	var err error
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.StopDataNodeBalanceTask))
+	defer func() {
+		doStatAndMetric(proto.StopDataNodeBalanceTask, metric, err, nil)
+	}()
+
+	err = m.cluster.StopDataPartitionBalanceTask()
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error()})
+		return
+	}
+
+	AuditLog(r, "stopDataBalancePlan", "stop data partition balance task", nil)
+
+	sendOkReply(w, r, newSuccessHTTPReply("Stop data balance task successfully."))
+}
+
+func (m *Server) deleteDataNodeBalancePlan(w http.ResponseWriter, r *http.Request) {
+	var err error
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.DeleteDataNodeBalanceTask))
+	defer func() {
+		doStatAndMetric(proto.DeleteDataNodeBalanceTask, metric, err, nil)
+	}()
+
+	err = m.cluster.DeleteDataPartitionBalanceTask()
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error()})
+		return
+	}
+
+	AuditLog(r, "deleteDataBalancePlan", "Remove data partition balance task", nil)
+
+	sendOkReply(w, r, newSuccessHTTPReply("Delete data balance plan task successfully."))
+}
+
 func (m *Server) offlineMetaNode(w http.ResponseWriter, r *http.Request) {
 	var (
 		rstMsg      string
@@ -37,6 +37,7 @@ import (
 	"github.com/cubefs/cubefs/util/compressor"
 	"github.com/cubefs/cubefs/util/config"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/tlsutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -1420,6 +1421,120 @@ func TestListUser(t *testing.T) {
 	process(reqURL, t)
 }
 
+func TestUserRole(t *testing.T) {
+	trustPolicy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"sts:AssumeRole"}]}`
+	permissionPolicy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`
+	param := &proto.RoleParam{RoleName: "reader", OwnerID: testUserID, TrustPolicy: trustPolicy, PermissionPolicy: permissionPolicy}
+	data, err := json.Marshal(param)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	post(fmt.Sprintf("%v%v", hostAddr, proto.UserRoleCreate), data, t)
+	roleInfo, err := server.user.getRole(testUserID, "reader")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if roleInfo.MaxSessionDuration != proto.RoleDefaultSessionDuration {
+		t.Errorf("expect max session duration[%v], real[%v]", proto.RoleDefaultSessionDuration, roleInfo.MaxSessionDuration)
+		return
+	}
+	version := roleInfo.Version
+	if _, err = server.user.createRole(param); err != proto.ErrDuplicateRole {
+		t.Errorf("expect err ErrDuplicateRole, but err is %v", err)
+		return
+	}
+	if _, err = server.user.createRole(&proto.RoleParam{RoleName: "bad", OwnerID: testUserID, TrustPolicy: "{", PermissionPolicy: permissionPolicy}); err != proto.ErrInvalidRole {
+		t.Errorf("expect err ErrInvalidRole, but err is %v", err)
+		return
+	}
+
+	param = &proto.RoleParam{RoleName: "reader", OwnerID: testUserID, MaxSessionDuration: 7200}
+	if data, err = json.Marshal(param); err != nil {
+		t.Error(err)
+		return
+	}
+	post(fmt.Sprintf("%v%v", hostAddr, proto.UserRoleUpdate), data, t)
+	if roleInfo, err = server.user.getRole(testUserID, "reader"); err != nil {
+		t.Error(err)
+		return
+	}
+	if roleInfo.MaxSessionDuration != 7200 || roleInfo.TrustPolicy != trustPolicy || roleInfo.Version == version {
+		t.Errorf("unexpected role after update: %+v", roleInfo)
+		return
+	}
+
+	process(fmt.Sprintf("%v%v?user=%v&role=%v", hostAddr, proto.UserRoleGetInfo, testUserID, "reader"), t)
+	process(fmt.Sprintf("%v%v?user=%v", hostAddr, proto.UserRoleList, testUserID), t)
+	// the roles of all the users are never listed at once
+	if reply := processNoCheck(fmt.Sprintf("%v%v", hostAddr, proto.UserRoleList), t); reply.Code != proto.ErrCodeParamError {
+		t.Errorf("expect code[%v], real[%v]", proto.ErrCodeParamError, reply.Code)
+		return
+	}
+	process(fmt.Sprintf("%v%v?user=%v&role=%v", hostAddr, proto.UserRoleDelete, testUserID, "reader"), t)
+	if _, err = server.user.getRole(testUserID, "reader"); err != proto.ErrRoleNotExists {
+		t.Errorf("expect err ErrRoleNotExists, but err is %v", err)
+		return
+	}
+}
+
+func TestAPIToken(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?name=%v&role=%v", hostAddr, proto.AdminAPITokenCreate, "reader", proto.APITokenRoleReadOnly)
+	reply := process(reqURL, t)
+	data, err := json.Marshal(reply.Data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	info := &proto.APITokenInfo{}
+	if err = json.Unmarshal(data, info); err != nil {
+		t.Error(err)
+		return
+	}
+	if info.Token == "" || info.Role != proto.APITokenRoleReadOnly {
+		t.Errorf("unexpected api token: %+v", info)
+		return
+	}
+	if tokens := server.user.getAPITokens(); len(tokens) != 1 || tokens[0].Token != "" {
+		t.Errorf("unexpected api tokens: %+v", tokens)
+		return
+	}
+	process(fmt.Sprintf("%v%v", hostAddr, proto.AdminAPITokenList), t)
+	process(fmt.Sprintf("%v%v?name=%v", hostAddr, proto.AdminAPITokenDelete, "reader"), t)
+	if err = server.user.deleteAPIToken("reader"); err != proto.ErrAPITokenNotExists {
+		t.Errorf("expect err ErrAPITokenNotExists, but err is %v", err)
+		return
+	}
+}
+
+func TestTLSTrust(t *testing.T) {
+	defer server.cluster.setTLSTrust("", "")
+	post := func(info *proto.TLSTrustInfo) *proto.HTTPReply {
+		data, err := json.Marshal(info)
+		require.NoError(t, err)
+		resp, err := http.Post(fmt.Sprintf("%v%v", hostAddr, proto.AdminSetTLSTrust), "application/json", bytes.NewBuffer(data))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		reply := &proto.HTTPReply{}
+		require.NoError(t, json.Unmarshal(body, reply))
+		return reply
+	}
+	require.Equal(t, proto.ErrCodeParamError, post(&proto.TLSTrustInfo{TLSMode: "tls"}).Code)
+	require.NotEqual(t, proto.ErrCodeSuccess, post(&proto.TLSTrustInfo{TLSMode: tlsutil.ModePermissive, TLSCAPem: "invalid"}).Code)
+	require.Equal(t, proto.ErrCodeSuccess, post(&proto.TLSTrustInfo{TLSMode: tlsutil.ModePermissive}).Code)
+	require.Equal(t, tlsutil.ModePermissive, tlsutil.Mode())
+
+	reply := process(fmt.Sprintf("%v%v", hostAddr, proto.AdminGetTLSTrust), t)
+	data, err := json.Marshal(reply.Data)
+	require.NoError(t, err)
+	info := &proto.TLSTrustInfo{}
+	require.NoError(t, json.Unmarshal(data, info))
+	require.Equal(t, tlsutil.ModePermissive, info.TLSMode)
+}
+
 func TestDeleteUser(t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?user=%v", hostAddr, proto.UserDelete, testUserID)
 	process(reqURL, t)
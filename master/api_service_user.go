@@ -324,6 +324,204 @@ func (m *Server) getUsersOfVol(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(users))
 }
 
+func (m *Server) createUserRole(w http.ResponseWriter, r *http.Request) {
+	var (
+		roleInfo *proto.RoleInfo
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserRoleCreate))
+	defer func() {
+		doStatAndMetric(proto.UserRoleCreate, metric, err, nil)
+	}()
+
+	var bytes []byte
+	if bytes, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	param := proto.RoleParam{}
+	if err = json.Unmarshal(bytes, &param); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if roleInfo, err = m.user.createRole(&param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	AuditLog(r, "createUserRole", fmt.Sprintf("create role: %v", roleInfo), nil)
+	_ = sendOkReply(w, r, newSuccessHTTPReply(roleInfo))
+}
+
+func (m *Server) updateUserRole(w http.ResponseWriter, r *http.Request) {
+	var (
+		roleInfo *proto.RoleInfo
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserRoleUpdate))
+	defer func() {
+		doStatAndMetric(proto.UserRoleUpdate, metric, err, nil)
+	}()
+
+	var bytes []byte
+	if bytes, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	param := proto.RoleParam{}
+	if err = json.Unmarshal(bytes, &param); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if roleInfo, err = m.user.updateRole(&param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	AuditLog(r, "updateUserRole", fmt.Sprintf("update role: %v", roleInfo), nil)
+	_ = sendOkReply(w, r, newSuccessHTTPReply(roleInfo))
+}
+
+func (m *Server) deleteUserRole(w http.ResponseWriter, r *http.Request) {
+	var (
+		userID   string
+		roleName string
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserRoleDelete))
+	defer func() {
+		doStatAndMetric(proto.UserRoleDelete, metric, err, nil)
+	}()
+
+	if userID, roleName, err = parseUserRole(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.user.deleteRole(userID, roleName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("delete role[%v] of user[%v] successfully", roleName, userID)
+	log.LogWarn(msg)
+	AuditLog(r, "deleteUserRole", msg, nil)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) getUserRole(w http.ResponseWriter, r *http.Request) {
+	var (
+		userID   string
+		roleName string
+		roleInfo *proto.RoleInfo
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserRoleGetInfo))
+	defer func() {
+		doStatAndMetric(proto.UserRoleGetInfo, metric, err, nil)
+	}()
+
+	if userID, roleName, err = parseUserRole(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if roleInfo, err = m.user.getRole(userID, roleName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(roleInfo))
+}
+
+func (m *Server) getUserRoles(w http.ResponseWriter, r *http.Request) {
+	var err error
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserRoleList))
+	defer func() {
+		doStatAndMetric(proto.UserRoleList, metric, err, nil)
+	}()
+
+	var userID string
+	if userID, err = parseUser(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	roles := m.user.getRolesOfOwner(userID)
+	sendOkReply(w, r, newSuccessHTTPReply(roles))
+}
+
+func (m *Server) createAPIToken(w http.ResponseWriter, r *http.Request) {
+	var (
+		info *proto.APITokenInfo
+		err  error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminAPITokenCreate))
+	defer func() {
+		doStatAndMetric(proto.AdminAPITokenCreate, metric, err, nil)
+	}()
+
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	name, role := r.FormValue(nameKey), r.FormValue(roleNameKey)
+	if name == "" || role == "" {
+		err = keyNotFound(nameKey + "/" + roleNameKey)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if info, err = m.user.createAPIToken(name, role, r.FormValue(descriptionKey)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	AuditLog(r, "createAPIToken", fmt.Sprintf("create api token[%v] role[%v]", name, role), nil)
+	_ = sendOkReply(w, r, newSuccessHTTPReply(info))
+}
+
+func (m *Server) deleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		err  error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminAPITokenDelete))
+	defer func() {
+		doStatAndMetric(proto.AdminAPITokenDelete, metric, err, nil)
+	}()
+
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if name = r.FormValue(nameKey); name == "" {
+		err = keyNotFound(nameKey)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.user.deleteAPIToken(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("delete api token[%v] successfully", name)
+	log.LogWarn(msg)
+	AuditLog(r, "deleteAPIToken", msg, nil)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) listAPITokens(w http.ResponseWriter, r *http.Request) {
+	var err error
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminAPITokenList))
+	defer func() {
+		doStatAndMetric(proto.AdminAPITokenList, metric, err, nil)
+	}()
+
+	sendOkReply(w, r, newSuccessHTTPReply(m.user.getAPITokens()))
+}
+
+func parseUserRole(r *http.Request) (userID, roleName string, err error) {
+	if userID, err = parseUser(r); err != nil {
+		return
+	}
+	if roleName = r.FormValue(roleNameKey); roleName == "" {
+		err = keyNotFound(roleNameKey)
+		return
+	}
+	return
+}
+
 func parseUser(r *http.Request) (userID string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -0,0 +1,443 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	apiTokenLength        = 40
+	bootstrapAPITokenName = "bootstrap"
+)
+
+var apiTokenNameRegexp = regexp.MustCompile(`^[\w.@-]{1,64}$`)
+
+// apiToken is the API token persisted by the raft, only the digest of the token is kept.
+type apiToken struct {
+	Name        string `json:"name"`
+	Role        string `json:"role"`
+	Digest      string `json:"digest"`
+	Description string `json:"description"`
+	CreateTime  string `json:"create_time"`
+}
+
+func (t *apiToken) toInfo() *proto.APITokenInfo {
+	return &proto.APITokenInfo{Name: t.Name, Role: t.Role, Description: t.Description, CreateTime: t.CreateTime}
+}
+
+func apiTokenDigest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (u *User) createAPIToken(name, role, description string) (info *proto.APITokenInfo, err error) {
+	if !apiTokenNameRegexp.MatchString(name) || name == bootstrapAPITokenName || !proto.IsValidAPITokenRole(role) {
+		err = proto.ErrInvalidAPIToken
+		return
+	}
+
+	u.apiTokenMutex.Lock()
+	defer u.apiTokenMutex.Unlock()
+	if _, exist := u.apiTokenStore.Load(name); exist {
+		err = proto.ErrDuplicateAPIToken
+		return
+	}
+	token := util.RandomString(apiTokenLength, util.Numeric|util.LowerLetter|util.UpperLetter)
+	t := &apiToken{
+		Name: name, Role: role, Digest: apiTokenDigest(token), Description: description,
+		CreateTime: time.Unix(time.Now().Unix(), 0).Format(proto.TimeFormat),
+	}
+	if err = u.syncAddAPIToken(t); err != nil {
+		return
+	}
+	u.apiTokenStore.Store(name, t)
+	info = t.toInfo()
+	info.Token = token
+	log.LogInfof("action[createAPIToken], name: %v, role: %v", name, role)
+	return
+}
+
+func (u *User) deleteAPIToken(name string) (err error) {
+	u.apiTokenMutex.Lock()
+	defer u.apiTokenMutex.Unlock()
+	value, exist := u.apiTokenStore.Load(name)
+	if !exist {
+		err = proto.ErrAPITokenNotExists
+		return
+	}
+	if err = u.syncDeleteAPIToken(value.(*apiToken)); err != nil {
+		return
+	}
+	u.apiTokenStore.Delete(name)
+	log.LogInfof("action[deleteAPIToken], name: %v", name)
+	return
+}
+
+func (u *User) getAPITokens() (tokens []*proto.APITokenInfo) {
+	tokens = make([]*proto.APITokenInfo, 0)
+	u.apiTokenStore.Range(func(key, value interface{}) bool {
+		tokens = append(tokens, value.(*apiToken).toInfo())
+		return true
+	})
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Name < tokens[j].Name })
+	return
+}
+
+// getAPITokenByToken returns the API token whose digest matches the token.
+func (u *User) getAPITokenByToken(token string) (t *apiToken, err error) {
+	digest := apiTokenDigest(token)
+	u.apiTokenStore.Range(func(key, value interface{}) bool {
+		if v := value.(*apiToken); subtle.ConstantTimeCompare([]byte(v.Digest), []byte(digest)) == 1 {
+			t = v
+			return false
+		}
+		return true
+	})
+	if t == nil {
+		err = proto.ErrInvalidAPIToken
+	}
+	return
+}
+
+func (u *User) clearAPITokenStore() {
+	u.apiTokenStore.Range(func(key, value interface{}) bool {
+		u.apiTokenStore.Delete(key)
+		return true
+	})
+}
+
+// apiTokenExempt marks the APIs which require no API token.
+const apiTokenExempt = "exempt"
+
+// apiTokenPathRoles are the roles required by all the APIs registered, the APIs out of the table require the
+// cluster admin. The new APIs must be added to the table, which is checked against the routes by the tests.
+var apiTokenPathRoles = map[string]string{
+	// the anonymous reads of the clients, which carry no API token, the volumes are authenticated by
+	// the owner authKeys if required
+	"/metrics":                    apiTokenExempt,
+	proto.AdminGetIP:              apiTokenExempt,
+	proto.AdminGetMonitorPushAddr: apiTokenExempt,
+	proto.AdminGetVol:             apiTokenExempt,
+	proto.ClientFlashGroups:       apiTokenExempt,
+	proto.ClientMetaPartitions:    apiTokenExempt,
+	proto.ClientDataPartitions:    apiTokenExempt,
+	proto.ClientVol:               apiTokenExempt,
+	proto.ClientVolStat:           apiTokenExempt,
+	proto.AdminGetDataPartition:   apiTokenExempt,
+	proto.ClientMetaPartition:     apiTokenExempt,
+	proto.AdminGetVersionInfo:     apiTokenExempt,
+	proto.AdminGetAllVersionInfo:  apiTokenExempt,
+	proto.QuotaList:               apiTokenExempt,
+	proto.AdminGetVolVer:          apiTokenExempt,
+	proto.AdminListVols:           apiTokenExempt,
+	// the clients report their traffic and get their limits, no state is persisted
+	proto.QosUpload: apiTokenExempt,
+
+	// the reads of the cluster, the nodes and the volumes
+	proto.AdminGetClusterDataNodes:                         proto.APITokenRoleReadOnly,
+	proto.AdminGetClusterMetaNodes:                         proto.APITokenRoleReadOnly,
+	proto.AdminGetApiQpsLimit:                              proto.APITokenRoleReadOnly,
+	proto.AdminGetCluster:                                  proto.APITokenRoleReadOnly,
+	proto.AdminGetClusterUuid:                              proto.APITokenRoleReadOnly,
+	proto.AdminGetClusterValue:                             proto.APITokenRoleReadOnly,
+	proto.AdminGetConfig:                                   proto.APITokenRoleReadOnly,
+	proto.AdminGetDiscardDp:                                proto.APITokenRoleReadOnly,
+	proto.AdminGetAllNodeSetGrpInfo:                        proto.APITokenRoleReadOnly,
+	proto.AdminGetNodeSetGrpInfo:                           proto.APITokenRoleReadOnly,
+	proto.AdminGetFileStats:                                proto.APITokenRoleReadOnly,
+	proto.AdminGetIsDomainOn:                               proto.APITokenRoleReadOnly,
+	proto.AdminGetMasterApiList:                            proto.APITokenRoleReadOnly,
+	proto.AdminGetNodeInfo:                                 proto.APITokenRoleReadOnly,
+	proto.AdminGetOpLog:                                    proto.APITokenRoleReadOnly,
+	proto.AdminGetSpreadViolations:                         proto.APITokenRoleReadOnly,
+	proto.AdminGetUpgradeCompatibleSettings:                proto.APITokenRoleReadOnly,
+	proto.AdminQueryAutoDecommissionDisk:                   proto.APITokenRoleReadOnly,
+	proto.AdminQueryDataNodeDecommissionInfoStat:           proto.APITokenRoleReadOnly,
+	proto.AdminQueryDecommissionFailedDisk:                 proto.APITokenRoleReadOnly,
+	proto.AdminQueryDecommissionFirstHostDiskParallelLimit: proto.APITokenRoleReadOnly,
+	proto.AdminQueryDecommissionFirstHostParallelInfo:      proto.APITokenRoleReadOnly,
+	proto.AdminQueryDecommissionFirstHostParallelLimit:     proto.APITokenRoleReadOnly,
+	proto.AdminQueryDecommissionLimit:                      proto.APITokenRoleReadOnly,
+	proto.AdminQueryDecommissionToken:                      proto.APITokenRoleReadOnly,
+	proto.AdminQueryDiskBrokenThreshold:                    proto.APITokenRoleReadOnly,
+	proto.AdminQueryDiskDecommissionInfoStat:               proto.APITokenRoleReadOnly,
+	proto.AdminGetTLSTrust:                                 proto.APITokenRoleReadOnly,
+	proto.ClientDiskDataPartitions:                         proto.APITokenRoleReadOnly,
+	proto.AdminClusterStat:                                 proto.APITokenRoleReadOnly,
+	proto.GetDataNode:                                      proto.APITokenRoleReadOnly,
+	proto.GetDataNodeBalanceTask:                           proto.APITokenRoleReadOnly,
+	proto.QueryDataNodeDecoFailedDps:                       proto.APITokenRoleReadOnly,
+	proto.QueryDataNodeDecoProgress:                        proto.APITokenRoleReadOnly,
+	proto.QueryDecommissionSuccessDisk:                     proto.APITokenRoleReadOnly,
+	proto.QueryDisableDisk:                                 proto.APITokenRoleReadOnly,
+	proto.AdminDiagnoseDataPartition:                       proto.APITokenRoleReadOnly,
+	proto.AdminQueryDataPartitionDecommissionStatus:        proto.APITokenRoleReadOnly,
+	proto.QueryDiskDetail:                                  proto.APITokenRoleReadOnly,
+	proto.QueryAllDecommissionDisk:                         proto.APITokenRoleReadOnly,
+	proto.QueryBackupDirectories:                           proto.APITokenRoleReadOnly,
+	proto.QueryBadDiskRecoverProgress:                      proto.APITokenRoleReadOnly,
+	proto.QueryBadDisks:                                    proto.APITokenRoleReadOnly,
+	proto.QueryDecommissionDiskDecoFailedDps:               proto.APITokenRoleReadOnly,
+	proto.QueryDiskDecoProgress:                            proto.APITokenRoleReadOnly,
+	proto.QueryDisks:                                       proto.APITokenRoleReadOnly,
+	proto.AdminFlashGroupGet:                               proto.APITokenRoleReadOnly,
+	proto.AdminFlashGroupList:                              proto.APITokenRoleReadOnly,
+	proto.FlashNodeGet:                                     proto.APITokenRoleReadOnly,
+	proto.FlashNodeList:                                    proto.APITokenRoleReadOnly,
+	proto.RaftStatus:                                       proto.APITokenRoleReadOnly,
+	proto.GetAllClients:                                    proto.APITokenRoleReadOnly,
+	proto.AdminGetInvalidNodes:                             proto.APITokenRoleReadOnly,
+	proto.GetMetaNode:                                      proto.APITokenRoleReadOnly,
+	proto.GetMetaNodeBalanceTask:                           proto.APITokenRoleReadOnly,
+	proto.AdminDiagnoseMetaPartition:                       proto.APITokenRoleReadOnly,
+	proto.AdminMetaPartitionEmptyStatus:                    proto.APITokenRoleReadOnly,
+	proto.AdminMetaPartitionGetCleanTask:                   proto.APITokenRoleReadOnly,
+	proto.GetNodeSet:                                       proto.APITokenRoleReadOnly,
+	proto.GetAllNodeSets:                                   proto.APITokenRoleReadOnly,
+	proto.QosGetClientsLimitInfo:                           proto.APITokenRoleReadOnly,
+	proto.QosGetStatus:                                     proto.APITokenRoleReadOnly,
+	proto.QosGetZoneLimitInfo:                              proto.APITokenRoleReadOnly,
+	proto.QuotaGet:                                         proto.APITokenRoleReadOnly,
+	proto.QuotaListAll:                                     proto.APITokenRoleReadOnly,
+	proto.BatchJobGet:                                      proto.APITokenRoleReadOnly,
+	proto.BatchJobList:                                     proto.APITokenRoleReadOnly,
+	proto.GetBucketInventory:                               proto.APITokenRoleReadOnly,
+	proto.GetBucketLifecycle:                               proto.APITokenRoleReadOnly,
+	proto.S3QoSGet:                                         proto.APITokenRoleReadOnly,
+	proto.GetTopologyView:                                  proto.APITokenRoleReadOnly,
+	proto.UsersOfVol:                                       proto.APITokenRoleReadOnly,
+	proto.GetAllZones:                                      proto.APITokenRoleReadOnly,
+
+	// the changes of the volumes and their S3 configs
+	proto.AdminACL:                       proto.APITokenRoleVolumeAdmin,
+	proto.AdminCreateVol:                 proto.APITokenRoleVolumeAdmin,
+	proto.AdminUid:                       proto.APITokenRoleVolumeAdmin,
+	proto.AdminCreateDataPartition:       proto.APITokenRoleVolumeAdmin,
+	proto.AdminCreateMetaPartition:       proto.APITokenRoleVolumeAdmin,
+	proto.AdminCreateVersion:             proto.APITokenRoleVolumeAdmin,
+	proto.AdminDelVersion:                proto.APITokenRoleVolumeAdmin,
+	proto.QuotaCreate:                    proto.APITokenRoleVolumeAdmin,
+	proto.QuotaDelete:                    proto.APITokenRoleVolumeAdmin,
+	proto.QuotaUpdate:                    proto.APITokenRoleVolumeAdmin,
+	proto.BatchJobCreate:                 proto.APITokenRoleVolumeAdmin,
+	proto.BatchJobUpdateStatus:           proto.APITokenRoleVolumeAdmin,
+	proto.DeleteBucketInventory:          proto.APITokenRoleVolumeAdmin,
+	proto.DeleteBucketLifecycle:          proto.APITokenRoleVolumeAdmin,
+	proto.S3QoSDelete:                    proto.APITokenRoleVolumeAdmin,
+	proto.S3QoSLease:                     proto.APITokenRoleVolumeAdmin,
+	proto.S3QoSSet:                       proto.APITokenRoleVolumeAdmin,
+	proto.SetBucketInventory:             proto.APITokenRoleVolumeAdmin,
+	proto.SetBucketLifecycle:             proto.APITokenRoleVolumeAdmin,
+	proto.AdminSetVerStrategy:            proto.APITokenRoleVolumeAdmin,
+	proto.AdminVolAddAllowedStorageClass: proto.APITokenRoleVolumeAdmin,
+	proto.AdminVolEnableAuditLog:         proto.APITokenRoleVolumeAdmin,
+	proto.AdminDeleteVol:                 proto.APITokenRoleVolumeAdmin,
+	proto.AdminVolExpand:                 proto.APITokenRoleVolumeAdmin,
+	proto.AdminVolForbidden:              proto.APITokenRoleVolumeAdmin,
+	proto.AdminVolSetDpRepairBlockSize:   proto.APITokenRoleVolumeAdmin,
+	proto.AdminSetTrashInterval:          proto.APITokenRoleVolumeAdmin,
+	proto.AdminVolShrink:                 proto.APITokenRoleVolumeAdmin,
+	proto.AdminUpdateVol:                 proto.APITokenRoleVolumeAdmin,
+
+	// the changes of the nodes, the disks and the partitions, the nodes register themselves, report
+	// the task responses and push the data partitions to the followers with the node operator token
+	proto.AdminAbortDecommissionDisk:                        proto.APITokenRoleNodeOperator,
+	proto.AdminEnableAutoDecommissionDisk:                   proto.APITokenRoleNodeOperator,
+	proto.AdminResetDataPartitionRestoreStatus:              proto.APITokenRoleNodeOperator,
+	proto.AdminSetDiskBrokenThreshold:                       proto.APITokenRoleNodeOperator,
+	proto.AdminSetDpDiscard:                                 proto.APITokenRoleNodeOperator,
+	proto.AdminSetDpRdOnly:                                  proto.APITokenRoleNodeOperator,
+	proto.AdminSetNodeRdOnly:                                proto.APITokenRoleNodeOperator,
+	proto.AdminUpdateDecommissionDiskLimit:                  proto.APITokenRoleNodeOperator,
+	proto.AdminUpdateDecommissionFirstHostDiskParallelLimit: proto.APITokenRoleNodeOperator,
+	proto.AdminUpdateDecommissionFirstHostParallelLimit:     proto.APITokenRoleNodeOperator,
+	proto.AdminUpdateDecommissionLimit:                      proto.APITokenRoleNodeOperator,
+	proto.AdminUpdateNodeSetCapcity:                         proto.APITokenRoleNodeOperator,
+	proto.AdminUpdateNodeSetId:                              proto.APITokenRoleNodeOperator,
+	proto.AdminUpdateNodeSetNodeSelector:                    proto.APITokenRoleNodeOperator,
+	proto.AdminUpdateZoneExcludeRatio:                       proto.APITokenRoleNodeOperator,
+	proto.AddDataNode:                                       proto.APITokenRoleNodeOperator,
+	proto.CancelDecommissionDataNode:                        proto.APITokenRoleNodeOperator,
+	proto.CreateDataNodeBalanceTask:                         proto.APITokenRoleNodeOperator,
+	proto.DecommissionDataNode:                              proto.APITokenRoleNodeOperator,
+	proto.DeleteDataNodeBalanceTask:                         proto.APITokenRoleNodeOperator,
+	proto.MigrateDataNode:                                   proto.APITokenRoleNodeOperator,
+	proto.PauseDecommissionDataNode:                         proto.APITokenRoleNodeOperator,
+	proto.ResetDecommissionDataNodeStatus:                   proto.APITokenRoleNodeOperator,
+	proto.GetDataNodeTaskResponse:                           proto.APITokenRoleNodeOperator,
+	proto.RunDataNodeBalanceTask:                            proto.APITokenRoleNodeOperator,
+	proto.SetDpCntLimit:                                     proto.APITokenRoleNodeOperator,
+	proto.StopDataNodeBalanceTask:                           proto.APITokenRoleNodeOperator,
+	proto.AdminUpdateDataNode:                               proto.APITokenRoleNodeOperator,
+	proto.AdminSetDataNodeGOGC:                              proto.APITokenRoleNodeOperator,
+	proto.AdminDataPartitionChangeLeader:                    proto.APITokenRoleNodeOperator,
+	proto.AdminCheckReplicaMeta:                             proto.APITokenRoleNodeOperator,
+	proto.AdminDecommissionDataPartition:                    proto.APITokenRoleNodeOperator,
+	proto.AdminLoadDataPartition:                            proto.APITokenRoleNodeOperator,
+	proto.AdminRecoverBackupDataReplica:                     proto.APITokenRoleNodeOperator,
+	proto.AdminRecoverReplicaMeta:                           proto.APITokenRoleNodeOperator,
+	proto.AdminResetDataPartitionDecommissionStatus:         proto.APITokenRoleNodeOperator,
+	proto.AdminPutDataPartitions:                            proto.APITokenRoleNodeOperator,
+	proto.AdminAddDataReplica:                               proto.APITokenRoleNodeOperator,
+	proto.AdminDeleteDataReplica:                            proto.APITokenRoleNodeOperator,
+	proto.CancelDecommissionDisk:                            proto.APITokenRoleNodeOperator,
+	proto.DecommissionDisk:                                  proto.APITokenRoleNodeOperator,
+	proto.DeleteBackupDirectories:                           proto.APITokenRoleNodeOperator,
+	proto.DeleteDecommissionDiskRecord:                      proto.APITokenRoleNodeOperator,
+	proto.DeleteLostDisk:                                    proto.APITokenRoleNodeOperator,
+	proto.PauseDecommissionDisk:                             proto.APITokenRoleNodeOperator,
+	proto.RecommissionDisk:                                  proto.APITokenRoleNodeOperator,
+	proto.RecoverBadDisk:                                    proto.APITokenRoleNodeOperator,
+	proto.ReloadDisk:                                        proto.APITokenRoleNodeOperator,
+	proto.RestoreStoppedAutoDecommissionDisk:                proto.APITokenRoleNodeOperator,
+	proto.AdminFlashGroupNodeAdd:                            proto.APITokenRoleNodeOperator,
+	proto.AdminFlashGroupCreate:                             proto.APITokenRoleNodeOperator,
+	proto.AdminFlashGroupRemove:                             proto.APITokenRoleNodeOperator,
+	proto.AdminFlashGroupNodeRemove:                         proto.APITokenRoleNodeOperator,
+	proto.AdminFlashGroupSet:                                proto.APITokenRoleNodeOperator,
+	proto.AdminFlashGroupTurn:                               proto.APITokenRoleNodeOperator,
+	proto.FlashNodeSetWriteIOLimits:                         proto.APITokenRoleNodeOperator,
+	proto.FlashNodeAdd:                                      proto.APITokenRoleNodeOperator,
+	proto.CreateFlashNodeManualTask:                         proto.APITokenRoleNodeOperator,
+	proto.AdminFlashManualTask:                              proto.APITokenRoleNodeOperator,
+	proto.FlashNodeRemove:                                   proto.APITokenRoleNodeOperator,
+	proto.FlashNodeRemoveAllInactive:                        proto.APITokenRoleNodeOperator,
+	proto.GetFlashNodeTaskResponse:                          proto.APITokenRoleNodeOperator,
+	proto.FlashNodeSet:                                      proto.APITokenRoleNodeOperator,
+	proto.FlashNodeSetReadIOLimits:                          proto.APITokenRoleNodeOperator,
+	proto.AddLcNode:                                         proto.APITokenRoleNodeOperator,
+	proto.GetLcNodeTaskResponse:                             proto.APITokenRoleNodeOperator,
+	proto.RestoreObject:                                     proto.APITokenRoleNodeOperator,
+	proto.AddMetaNode:                                       proto.APITokenRoleNodeOperator,
+	proto.CreateMetaNodeBalanceTask:                         proto.APITokenRoleNodeOperator,
+	proto.DecommissionMetaNode:                              proto.APITokenRoleNodeOperator,
+	proto.DeleteMetaNodeBalanceTask:                         proto.APITokenRoleNodeOperator,
+	proto.MigrateMetaNode:                                   proto.APITokenRoleNodeOperator,
+	proto.MigrateMetaPartition:                              proto.APITokenRoleNodeOperator,
+	proto.OfflineMetaNode:                                   proto.APITokenRoleNodeOperator,
+	proto.GetMetaNodeTaskResponse:                           proto.APITokenRoleNodeOperator,
+	proto.RunMetaNodeBalanceTask:                            proto.APITokenRoleNodeOperator,
+	proto.SetMpCntLimit:                                     proto.APITokenRoleNodeOperator,
+	proto.StopMetaNodeBalanceTask:                           proto.APITokenRoleNodeOperator,
+	proto.AdminUpdateMetaNode:                               proto.APITokenRoleNodeOperator,
+	proto.AdminSetMetaNodeGOGC:                              proto.APITokenRoleNodeOperator,
+	proto.AdminBalanceMetaPartitionLeader:                   proto.APITokenRoleNodeOperator,
+	proto.AdminChangeMetaPartitionLeader:                    proto.APITokenRoleNodeOperator,
+	proto.AdminMetaPartitionCleanEmpty:                      proto.APITokenRoleNodeOperator,
+	proto.AdminDecommissionMetaPartition:                    proto.APITokenRoleNodeOperator,
+	proto.AdminMetaPartitionFreezeEmpty:                     proto.APITokenRoleNodeOperator,
+	proto.AdminLoadMetaPartition:                            proto.APITokenRoleNodeOperator,
+	proto.AdminMetaPartitionRemoveBackup:                    proto.APITokenRoleNodeOperator,
+	proto.AdminSplitMetaPartition:                           proto.APITokenRoleNodeOperator,
+	proto.AdminAddMetaReplica:                               proto.APITokenRoleNodeOperator,
+	proto.AdminDeleteMetaReplica:                            proto.APITokenRoleNodeOperator,
+	proto.UpdateNodeSet:                                     proto.APITokenRoleNodeOperator,
+	proto.AdminSetMetaNodeThreshold:                         proto.APITokenRoleNodeOperator,
+	proto.UpdateZone:                                        proto.APITokenRoleNodeOperator,
+
+	// the changes of the cluster and the masters, the users, the access keys and the roles which carry
+	// the secret keys and the policies, and the API tokens
+	proto.AdminAPITokenCreate:                proto.APITokenRoleClusterAdmin,
+	proto.AdminAPITokenDelete:                proto.APITokenRoleClusterAdmin,
+	proto.AdminAPITokenList:                  proto.APITokenRoleClusterAdmin,
+	proto.AdminGenerateClusterUuid:           proto.APITokenRoleClusterAdmin,
+	proto.AdminLcNode:                        proto.APITokenRoleClusterAdmin,
+	proto.AdminRemoveApiQpsLimit:             proto.APITokenRoleClusterAdmin,
+	proto.AdminSetApiQpsLimit:                proto.APITokenRoleClusterAdmin,
+	proto.AdminSetClusterInfo:                proto.APITokenRoleClusterAdmin,
+	proto.AdminSetClusterUuidEnable:          proto.APITokenRoleClusterAdmin,
+	proto.AdminSetConfig:                     proto.APITokenRoleClusterAdmin,
+	proto.AdminSetFileStats:                  proto.APITokenRoleClusterAdmin,
+	proto.AdminSetNodeInfo:                   proto.APITokenRoleClusterAdmin,
+	proto.AdminSetTLSTrust:                   proto.APITokenRoleClusterAdmin,
+	proto.AdminUpdateDomainDataUseRatio:      proto.APITokenRoleClusterAdmin,
+	proto.AdminClusterAPI:                    proto.APITokenRoleClusterAdmin,
+	proto.AdminUserAPI:                       proto.APITokenRoleClusterAdmin,
+	proto.AdminClusterForbidMpDecommission:   proto.APITokenRoleClusterAdmin,
+	proto.AdminClusterFreeze:                 proto.APITokenRoleClusterAdmin,
+	proto.AdminSetCheckDataReplicasEnable:    proto.APITokenRoleClusterAdmin,
+	proto.AdminChangeMasterLeader:            proto.APITokenRoleClusterAdmin,
+	proto.AdminOpFollowerPartitionsRead:      proto.APITokenRoleClusterAdmin,
+	proto.QosUpdateMasterLimit:               proto.APITokenRoleClusterAdmin,
+	proto.QosUpdate:                          proto.APITokenRoleClusterAdmin,
+	proto.QosUpdateClientParam:               proto.APITokenRoleClusterAdmin,
+	proto.QosUpdateMagnify:                   proto.APITokenRoleClusterAdmin,
+	proto.QosUpdateZoneLimit:                 proto.APITokenRoleClusterAdmin,
+	proto.AddRaftNode:                        proto.APITokenRoleClusterAdmin,
+	proto.RemoveRaftNode:                     proto.APITokenRoleClusterAdmin,
+	proto.UserGetAKInfo:                      proto.APITokenRoleClusterAdmin,
+	proto.UserCreate:                         proto.APITokenRoleClusterAdmin,
+	proto.UserDelete:                         proto.APITokenRoleClusterAdmin,
+	proto.UserDeleteVolPolicy:                proto.APITokenRoleClusterAdmin,
+	proto.UserGetInfo:                        proto.APITokenRoleClusterAdmin,
+	proto.UserList:                           proto.APITokenRoleClusterAdmin,
+	proto.UserRemovePolicy:                   proto.APITokenRoleClusterAdmin,
+	proto.UserRoleCreate:                     proto.APITokenRoleClusterAdmin,
+	proto.UserRoleDelete:                     proto.APITokenRoleClusterAdmin,
+	proto.UserRoleGetInfo:                    proto.APITokenRoleClusterAdmin,
+	proto.UserRoleList:                       proto.APITokenRoleClusterAdmin,
+	proto.UserRoleUpdate:                     proto.APITokenRoleClusterAdmin,
+	proto.UserTransferVol:                    proto.APITokenRoleClusterAdmin,
+	proto.UserUpdate:                         proto.APITokenRoleClusterAdmin,
+	proto.UserUpdatePolicy:                   proto.APITokenRoleClusterAdmin,
+	proto.AdminSetMasterVolDeletionDelayTime: proto.APITokenRoleClusterAdmin,
+}
+
+// apiTokenRoleOf returns the role required by the API of the path, the exempt APIs require no token.
+func apiTokenRoleOf(path string) (role string, exempt bool) {
+	role, ok := apiTokenPathRoles[path]
+	if !ok {
+		return proto.APITokenRoleClusterAdmin, false
+	}
+	if role == apiTokenExempt {
+		return "", true
+	}
+	return role, false
+}
+
+// checkAPIToken checks the API token of the request is granted the role required, the bootstrap token
+// from the config acts as the cluster admin to create the first tokens.
+func (m *Server) checkAPIToken(r *http.Request, required string) (name string, err error) {
+	token := r.Header.Get(proto.HeaderAPIToken)
+	if token == "" {
+		err = proto.ErrInvalidAPIToken
+		return
+	}
+	role := proto.APITokenRoleClusterAdmin
+	name = bootstrapAPITokenName
+	if m.bootstrapAPIToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(m.bootstrapAPIToken)) != 1 {
+		var t *apiToken
+		if t, err = m.user.getAPITokenByToken(token); err != nil {
+			name = ""
+			return
+		}
+		name, role = t.Name, t.Role
+	}
+	if !proto.APITokenRoleAllows(role, required) {
+		err = proto.ErrAPITokenDenied
+	}
+	return
+}
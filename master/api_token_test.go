@@ -0,0 +1,132 @@
+package master
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/gorilla/mux"
+)
+
+func TestAPITokenRoleOf(t *testing.T) {
+	cases := []struct {
+		path   string
+		role   string
+		exempt bool
+	}{
+		{proto.ClientDataPartitions, "", true},
+		{proto.ClientVol, "", true},
+		{proto.AdminGetVol, "", true},
+		{proto.AdminListVols, "", true},
+		{proto.QosUpload, "", true},
+		{proto.AdminGetCluster, proto.APITokenRoleReadOnly, false},
+		{proto.GetDataNode, proto.APITokenRoleReadOnly, false},
+		{proto.ClientDiskDataPartitions, proto.APITokenRoleReadOnly, false},
+		{proto.AddDataNode, proto.APITokenRoleNodeOperator, false},
+		{proto.AddMetaNode, proto.APITokenRoleNodeOperator, false},
+		{proto.AddLcNode, proto.APITokenRoleNodeOperator, false},
+		{proto.FlashNodeAdd, proto.APITokenRoleNodeOperator, false},
+		{proto.GetDataNodeTaskResponse, proto.APITokenRoleNodeOperator, false},
+		{proto.GetFlashNodeTaskResponse, proto.APITokenRoleNodeOperator, false},
+		{proto.AdminPutDataPartitions, proto.APITokenRoleNodeOperator, false},
+		{"/client/unknown", proto.APITokenRoleClusterAdmin, false},
+		{proto.S3QoSLease, proto.APITokenRoleVolumeAdmin, false},
+		{proto.GetTopologyView, proto.APITokenRoleReadOnly, false},
+		{proto.QueryDisableDisk, proto.APITokenRoleReadOnly, false},
+		{proto.AdminGetInvalidNodes, proto.APITokenRoleReadOnly, false},
+		{proto.AdminCreateVol, proto.APITokenRoleVolumeAdmin, false},
+		{proto.AdminDeleteVol, proto.APITokenRoleVolumeAdmin, false},
+		{proto.QuotaCreate, proto.APITokenRoleVolumeAdmin, false},
+		{proto.DecommissionDataNode, proto.APITokenRoleNodeOperator, false},
+		{proto.DecommissionMetaNode, proto.APITokenRoleNodeOperator, false},
+		{proto.AdminDecommissionMetaPartition, proto.APITokenRoleNodeOperator, false},
+		{proto.AdminSetNodeRdOnly, proto.APITokenRoleNodeOperator, false},
+		{proto.AdminClusterFreeze, proto.APITokenRoleClusterAdmin, false},
+		{proto.AddRaftNode, proto.APITokenRoleClusterAdmin, false},
+		{proto.UserCreate, proto.APITokenRoleClusterAdmin, false},
+		{proto.UserList, proto.APITokenRoleClusterAdmin, false},
+		{proto.UserGetInfo, proto.APITokenRoleClusterAdmin, false},
+		{proto.UserGetAKInfo, proto.APITokenRoleClusterAdmin, false},
+		{proto.UserRoleGetInfo, proto.APITokenRoleClusterAdmin, false},
+		{proto.UserRoleList, proto.APITokenRoleClusterAdmin, false},
+		{"/user/unknown/list", proto.APITokenRoleClusterAdmin, false},
+		{proto.AdminACL, proto.APITokenRoleVolumeAdmin, false},
+		{proto.AdminUid, proto.APITokenRoleVolumeAdmin, false},
+		{proto.FlashNodeSet, proto.APITokenRoleNodeOperator, false},
+		{proto.AdminAPITokenCreate, proto.APITokenRoleClusterAdmin, false},
+		{proto.AdminAPITokenList, proto.APITokenRoleClusterAdmin, false},
+		{proto.AdminSetTLSTrust, proto.APITokenRoleClusterAdmin, false},
+		{proto.AdminGetTLSTrust, proto.APITokenRoleReadOnly, false},
+		{"/admin/unknown", proto.APITokenRoleClusterAdmin, false},
+	}
+	for _, c := range cases {
+		role, exempt := apiTokenRoleOf(c.path)
+		if role != c.role || exempt != c.exempt {
+			t.Errorf("path[%v] expect role[%v] exempt[%v], real role[%v] exempt[%v]", c.path, c.role, c.exempt, role, exempt)
+		}
+	}
+}
+
+func TestAPITokenRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	server.registerAPIRoutes(router)
+	registered := make(map[string]bool)
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		registered[path] = true
+		if _, ok := apiTokenPathRoles[path]; !ok {
+			t.Errorf("route[%v] path[%v] is not in apiTokenPathRoles", route.GetName(), path)
+		}
+		return nil
+	})
+	for path := range apiTokenPathRoles {
+		// the metrics are registered by the exporter
+		if !registered[path] && path != "/metrics" {
+			t.Errorf("path[%v] of apiTokenPathRoles is not registered", path)
+		}
+	}
+}
+
+func TestCheckAPIToken(t *testing.T) {
+	info, err := server.user.createAPIToken("operator", proto.APITokenRoleNodeOperator, "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.user.deleteAPIToken(info.Name)
+	if _, err = server.user.createAPIToken("operator", proto.APITokenRoleReadOnly, ""); err != proto.ErrDuplicateAPIToken {
+		t.Errorf("expect err ErrDuplicateAPIToken, but err is %v", err)
+		return
+	}
+	if _, err = server.user.createAPIToken("admin", "root", ""); err != proto.ErrInvalidAPIToken {
+		t.Errorf("expect err ErrInvalidAPIToken, but err is %v", err)
+		return
+	}
+
+	m := &Server{user: server.user, bootstrapAPIToken: "bootstrapToken"}
+	check := func(token, required string, expect error) {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		if token != "" {
+			r.Header.Set(proto.HeaderAPIToken, token)
+		}
+		if _, err := m.checkAPIToken(r, required); err != expect {
+			t.Errorf("token[%v] required role[%v] expect err[%v], but err is %v", token, required, expect, err)
+		}
+	}
+	check("", proto.APITokenRoleReadOnly, proto.ErrInvalidAPIToken)
+	check("invalidToken", proto.APITokenRoleReadOnly, proto.ErrInvalidAPIToken)
+	check(info.Token, proto.APITokenRoleReadOnly, nil)
+	check(info.Token, proto.APITokenRoleNodeOperator, nil)
+	check(info.Token, proto.APITokenRoleVolumeAdmin, proto.ErrAPITokenDenied)
+	check(info.Token, proto.APITokenRoleClusterAdmin, proto.ErrAPITokenDenied)
+	check("bootstrapToken", proto.APITokenRoleClusterAdmin, nil)
+
+	if err = server.user.deleteAPIToken(info.Name); err != nil {
+		t.Error(err)
+		return
+	}
+	check(info.Token, proto.APITokenRoleReadOnly, proto.ErrInvalidAPIToken)
+}
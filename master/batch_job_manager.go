@@ -0,0 +1,547 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/auditlog"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/google/uuid"
+)
+
+const (
+	batchJobCheckInterval        = 10 * time.Second
+	batchTaskTimeout             = 5 * time.Minute
+	batchJobRetention            = 30 * 24 * time.Hour
+	defaultBatchTaskLimitPerNode = 2
+)
+
+// batchJobManager schedules the shards of the S3 batch jobs to the lcnodes. The progress and the
+// checkpoint of each shard are reported by the heartbeat of the lcnode and persisted, so that the
+// shard is resumed from the checkpoint once the job is resumed, or the lcnode is lost.
+type batchJobManager struct {
+	sync.RWMutex
+	jobs             map[string]*proto.BatchJob
+	exitCh           chan struct{}
+	taskLimitPerNode int
+
+	persist     func(job *proto.BatchJob) error
+	remove      func(job *proto.BatchJob) error
+	activeNodes func() []string
+	dispatch    func(node string, task *proto.BatchTask) error
+	stop        func(node, id string)
+}
+
+func newBatchJobManager(c *Cluster) *batchJobManager {
+	log.LogInfof("action[newBatchJobManager] construct")
+	mgr := &batchJobManager{
+		jobs:             make(map[string]*proto.BatchJob),
+		exitCh:           make(chan struct{}),
+		taskLimitPerNode: defaultBatchTaskLimitPerNode,
+	}
+	if c != nil {
+		mgr.persist = c.syncAddBatchJob
+		mgr.remove = c.syncDeleteBatchJob
+		mgr.activeNodes = c.activeLcNodes
+		mgr.dispatch = c.dispatchBatchTask
+		mgr.stop = c.stopBatchTask
+	}
+	return mgr
+}
+
+func (mgr *batchJobManager) load(job *proto.BatchJob) {
+	mgr.Lock()
+	mgr.jobs[job.JobId] = job
+	mgr.Unlock()
+}
+
+// createJob creates the job, or returns the job created by the same request token of the owner.
+func (mgr *batchJobManager) createJob(job *proto.BatchJob) (*proto.BatchJob, error) {
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+	mgr.Lock()
+	defer mgr.Unlock()
+	if job.ClientRequestToken != "" {
+		for _, j := range mgr.jobs {
+			if j.Owner == job.Owner && j.ClientRequestToken == job.ClientRequestToken {
+				return j.Copy(), nil
+			}
+		}
+	}
+
+	job.JobId = uuid.New().String()
+	job.CreationTime = time.Now().Unix()
+	job.TerminationTime = 0
+	if job.Status != proto.BatchJobSuspended {
+		job.Status = proto.BatchJobReady
+	}
+	if job.Manifest.IsPrefix() {
+		// each prefix is scanned by one shard
+		job.Shards = len(job.Manifest.Prefixes)
+	} else {
+		// the lines of the manifest are spread over the lcnodes
+		job.Shards = len(mgr.activeNodes())
+		if job.Shards < 1 {
+			job.Shards = 1
+		} else if job.Shards > proto.BatchJobMaxShards {
+			job.Shards = proto.BatchJobMaxShards
+		}
+	}
+	job.Tasks = make([]*proto.BatchJobTask, 0, job.Shards)
+	for i := 0; i < job.Shards; i++ {
+		job.Tasks = append(job.Tasks, &proto.BatchJobTask{Shard: i})
+	}
+	if err := mgr.persist(job); err != nil {
+		return nil, err
+	}
+	mgr.jobs[job.JobId] = job
+	log.LogInfof("action[createBatchJob] job(%v) owner(%v) operation(%v) shards(%v) status(%v)",
+		job.JobId, job.Owner, job.Operation.Type, job.Shards, job.Status)
+	return job.Copy(), nil
+}
+
+// getJob returns the job of the owner, or the job of any owner if the owner is empty.
+func (mgr *batchJobManager) getJob(id, owner string) (*proto.BatchJob, error) {
+	mgr.RLock()
+	defer mgr.RUnlock()
+	job, ok := mgr.jobs[id]
+	if !ok || (owner != "" && job.Owner != owner) {
+		return nil, proto.ErrBatchJobNotExists
+	}
+	return job.Copy(), nil
+}
+
+// listJobs returns the jobs of the owner in the statuses, the latest first.
+func (mgr *batchJobManager) listJobs(owner string, statuses []string) []*proto.BatchJob {
+	mgr.RLock()
+	jobs := make([]*proto.BatchJob, 0)
+	for _, job := range mgr.jobs {
+		if owner != "" && job.Owner != owner {
+			continue
+		}
+		if len(statuses) > 0 && !contains(statuses, job.Status) {
+			continue
+		}
+		jobs = append(jobs, job.Copy())
+	}
+	mgr.RUnlock()
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].CreationTime != jobs[j].CreationTime {
+			return jobs[i].CreationTime > jobs[j].CreationTime
+		}
+		return jobs[i].JobId < jobs[j].JobId
+	})
+	return jobs
+}
+
+// updateStatus cancels, suspends or resumes the job. The running shards of the cancelled or
+// suspended job are stopped, and the shards of the resumed job are started from the checkpoints.
+func (mgr *batchJobManager) updateStatus(req *proto.BatchJobStatusRequest) (*proto.BatchJob, error) {
+	mgr.Lock()
+	job, ok := mgr.jobs[req.JobId]
+	if !ok || (req.Owner != "" && job.Owner != req.Owner) {
+		mgr.Unlock()
+		return nil, proto.ErrBatchJobNotExists
+	}
+	if !job.CanTransit(req.Status) {
+		mgr.Unlock()
+		return nil, proto.ErrInvalidBatchJobStatus
+	}
+
+	updated := job.Copy()
+	running := make(map[string]string) // task id --> lcnode
+	for _, task := range updated.Tasks {
+		switch req.Status {
+		case proto.BatchJobCancelled, proto.BatchJobSuspended:
+			if task.LcNode != "" {
+				running[proto.BatchTaskId(job.JobId, task.Shard)] = task.LcNode
+				task.LcNode = ""
+			}
+		case proto.BatchJobReady:
+			if task.Result != "" {
+				// retry the failed shard from its checkpoint
+				task.Done, task.Result = false, ""
+			}
+		}
+	}
+	updated.Status = req.Status
+	updated.StatusUpdateReason = req.Reason
+	if req.Status == proto.BatchJobCancelled {
+		updated.TerminationTime = time.Now().Unix()
+	} else {
+		updated.TerminationTime = 0
+	}
+	if err := mgr.persist(updated); err != nil {
+		mgr.Unlock()
+		return nil, err
+	}
+	mgr.jobs[job.JobId] = updated
+	mgr.Unlock()
+
+	for id, node := range running {
+		go mgr.stop(node, id)
+	}
+	log.LogInfof("action[updateBatchJobStatus] job(%v) status(%v) -> (%v) reason(%v)",
+		job.JobId, job.Status, req.Status, req.Reason)
+	return updated.Copy(), nil
+}
+
+// task returns the job and the task of the id, the caller should hold the lock.
+func (mgr *batchJobManager) task(id string) (*proto.BatchJob, *proto.BatchJobTask) {
+	jobId, shard, err := proto.ParseBatchTaskId(id)
+	if err != nil {
+		return nil, nil
+	}
+	job, ok := mgr.jobs[jobId]
+	if !ok || shard < 0 || shard >= len(job.Tasks) {
+		return nil, nil
+	}
+	return job, job.Tasks[shard]
+}
+
+func updateBatchCheckpoint(task, progress *proto.BatchJobTask) {
+	if progress == nil {
+		return
+	}
+	task.NextLine = progress.NextLine
+	task.Marker = progress.Marker
+	task.ReportSeq = progress.ReportSeq
+	task.Progress = progress.Progress
+}
+
+// handleHeartbeat updates the checkpoints of the shards running on the lcnode, and stops the
+// shards which are not expected to run on it, e.g. the job is cancelled or the shard is redispatched.
+func (mgr *batchJobManager) handleHeartbeat(node string, tasks map[string]*proto.LcNodeBatchTaskResponse) {
+	if len(tasks) == 0 {
+		return
+	}
+	now := time.Now().Unix()
+	updated := make([]*proto.BatchJob, 0)
+	stopped := make([]string, 0)
+	mgr.Lock()
+	changed := make(map[string]bool)
+	for id, resp := range tasks {
+		job, task := mgr.task(id)
+		if job == nil || job.Status != proto.BatchJobActive || task.Done || task.LcNode != node {
+			stopped = append(stopped, id)
+			continue
+		}
+		updateBatchCheckpoint(task, resp.Task)
+		task.UpdateTime = now
+		if !changed[job.JobId] {
+			changed[job.JobId] = true
+			updated = append(updated, job.Copy())
+		}
+	}
+	mgr.Unlock()
+
+	for _, job := range updated {
+		if err := mgr.persist(job); err != nil {
+			log.LogWarnf("action[handleBatchHeartbeat] persist job(%v) err(%v)", job.JobId, err)
+		}
+	}
+	for _, id := range stopped {
+		log.LogInfof("action[handleBatchHeartbeat] stop unexpected task(%v) on lcnode(%v)", id, node)
+		go mgr.stop(node, id)
+	}
+}
+
+// handleTaskResp handles the result of the shard, the job is finished once all the shards are done.
+func (mgr *batchJobManager) handleTaskResp(node string, resp *proto.LcNodeBatchTaskResponse) error {
+	mgr.Lock()
+	job, task := mgr.task(resp.ID)
+	if job == nil {
+		mgr.Unlock()
+		return fmt.Errorf("batch task(%v) not exists", resp.ID)
+	}
+	if task.LcNode != node && (job.Status == proto.BatchJobActive || job.Status == proto.BatchJobReady) {
+		// the shard is redispatched to another lcnode
+		mgr.Unlock()
+		log.LogWarnf("action[handleBatchTaskResp] task(%v) is not running on lcnode(%v)", resp.ID, node)
+		return nil
+	}
+	if task.Done {
+		mgr.Unlock()
+		return nil
+	}
+	updateBatchCheckpoint(task, resp.Task)
+	task.LcNode = ""
+	task.UpdateTime = time.Now().Unix()
+	if !resp.Stopped {
+		switch resp.Status {
+		case proto.TaskSucceeds:
+			task.Done = true
+		case proto.TaskFailed:
+			task.Done, task.Result = true, resp.Result
+		}
+	}
+	checkBatchJobDone(job)
+	updated := job.Copy()
+	mgr.Unlock()
+
+	log.LogInfof("action[handleBatchTaskResp] lcnode(%v) task(%v) status(%v) stopped(%v) job(%v) status(%v)",
+		node, resp.ID, resp.Status, resp.Stopped, updated.JobId, updated.Status)
+	return mgr.persist(updated)
+}
+
+func checkBatchJobDone(job *proto.BatchJob) {
+	if job.Status != proto.BatchJobActive {
+		return
+	}
+	failed := false
+	for _, task := range job.Tasks {
+		if !task.Done {
+			return
+		}
+		if task.Result != "" {
+			failed = true
+		}
+	}
+	if failed {
+		// the failed job can be resumed by the owner
+		job.Status = proto.BatchJobFailed
+		return
+	}
+	job.Status = proto.BatchJobComplete
+	job.TerminationTime = time.Now().Unix()
+}
+
+// schedule dispatches the pending shards to the lcnodes by the priority of the jobs, and redispatches
+// the shards whose lcnode doesn't report in time from their checkpoints.
+func (mgr *batchJobManager) schedule(now time.Time) {
+	nodes := mgr.activeNodes()
+	type dispatchTask struct {
+		node string
+		task *proto.BatchTask
+	}
+	dispatched := make([]dispatchTask, 0)
+	updated := make([]*proto.BatchJob, 0)
+	removed := make([]*proto.BatchJob, 0)
+
+	mgr.Lock()
+	working := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		working[node] = 0
+	}
+	jobs := make([]*proto.BatchJob, 0)
+	for id, job := range mgr.jobs {
+		if job.Terminated() && now.Sub(time.Unix(job.TerminationTime, 0)) > batchJobRetention {
+			delete(mgr.jobs, id)
+			removed = append(removed, job)
+			continue
+		}
+		if job.Status != proto.BatchJobReady && job.Status != proto.BatchJobActive {
+			continue
+		}
+		jobs = append(jobs, job)
+		for _, task := range job.Tasks {
+			if task.LcNode == "" {
+				continue
+			}
+			if now.Unix()-task.UpdateTime > int64(batchTaskTimeout/time.Second) {
+				log.LogWarnf("action[scheduleBatchJob] task(%v) on lcnode(%v) timeout, redispatch from checkpoint",
+					proto.BatchTaskId(job.JobId, task.Shard), task.LcNode)
+				task.LcNode = ""
+				continue
+			}
+			working[task.LcNode]++
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Priority != jobs[j].Priority {
+			return jobs[i].Priority > jobs[j].Priority
+		}
+		return jobs[i].CreationTime < jobs[j].CreationTime
+	})
+
+	for _, job := range jobs {
+		changed := false
+		for _, task := range job.Tasks {
+			if task.Done || task.LcNode != "" {
+				continue
+			}
+			node := ""
+			for _, n := range nodes {
+				if working[n] < mgr.taskLimitPerNode && (node == "" || working[n] < working[node]) {
+					node = n
+				}
+			}
+			if node == "" {
+				break
+			}
+			working[node]++
+			task.LcNode = node
+			task.UpdateTime = now.Unix()
+			job.Status = proto.BatchJobActive
+			changed = true
+
+			t := *task
+			j := *job
+			j.Tasks = nil
+			dispatched = append(dispatched, dispatchTask{
+				node: node,
+				task: &proto.BatchTask{Id: proto.BatchTaskId(job.JobId, task.Shard), Job: &j, Task: &t},
+			})
+		}
+		if changed {
+			updated = append(updated, job.Copy())
+		}
+	}
+	mgr.Unlock()
+
+	for _, job := range removed {
+		log.LogInfof("action[scheduleBatchJob] remove expired job(%v) status(%v)", job.JobId, job.Status)
+		if err := mgr.remove(job); err != nil {
+			log.LogWarnf("action[scheduleBatchJob] remove job(%v) err(%v)", job.JobId, err)
+		}
+	}
+	for _, job := range updated {
+		if err := mgr.persist(job); err != nil {
+			log.LogWarnf("action[scheduleBatchJob] persist job(%v) err(%v)", job.JobId, err)
+		}
+	}
+	for _, d := range dispatched {
+		// the task which fails to be dispatched is redispatched once timeout
+		if err := mgr.dispatch(d.node, d.task); err != nil {
+			log.LogWarnf("action[scheduleBatchJob] dispatch task(%v) to lcnode(%v) err(%v)", d.task.Id, d.node, err)
+			continue
+		}
+		log.LogInfof("action[scheduleBatchJob] dispatch task(%v) to lcnode(%v) checkpoint(%v/%v)",
+			d.task.Id, d.node, d.task.Task.NextLine, d.task.Task.Marker)
+	}
+}
+
+func (mgr *batchJobManager) startBatchJobHandleLeaderChange() {
+	go mgr.process()
+}
+
+func (mgr *batchJobManager) process() {
+	log.LogInfo("batchJobManager process start")
+	ticker := time.NewTicker(batchJobCheckInterval)
+	defer func() {
+		ticker.Stop()
+		log.LogInfo("batchJobManager process stop")
+	}()
+	for {
+		select {
+		case <-mgr.exitCh:
+			log.LogInfo("exitCh notified, batchJobManager process exit")
+			return
+		case <-ticker.C:
+			mgr.schedule(time.Now())
+		}
+	}
+}
+
+func (c *Cluster) activeLcNodes() []string {
+	nodes := make([]string, 0)
+	c.lcNodes.Range(func(addr, value interface{}) bool {
+		node := value.(*LcNode)
+		node.RLock()
+		if node.IsActive {
+			nodes = append(nodes, node.Addr)
+		}
+		node.RUnlock()
+		return true
+	})
+	sort.Strings(nodes)
+	return nodes
+}
+
+func (c *Cluster) dispatchBatchTask(addr string, task *proto.BatchTask) error {
+	node, err := c.lcNode(addr)
+	if err != nil {
+		return err
+	}
+	c.addLcNodeTasks([]*proto.AdminTask{node.createBatchTask(c.masterAddr(), task)})
+	return nil
+}
+
+func (c *Cluster) stopBatchTask(node, id string) {
+	url := getLcNodeUrl(node, "stopBatchTask", id)
+	if url == "" {
+		return
+	}
+	cli := &http.Client{Timeout: 10 * time.Second}
+	resp, err := cli.Get(url)
+	if err != nil {
+		log.LogWarnf("action[stopBatchTask] task(%v) on lcnode(%v) err(%v)", id, node, err)
+		return
+	}
+	_ = resp.Body.Close()
+	log.LogInfof("action[stopBatchTask] task(%v) on lcnode(%v) status(%v)", id, node, resp.StatusCode)
+}
+
+func (c *Cluster) handleLcNodeBatchResp(nodeAddr string, resp *proto.LcNodeBatchTaskResponse) (err error) {
+	switch resp.Status {
+	case proto.TaskFailed:
+		log.LogWarnf("action[handleLcNodeBatchResp] lcNode[%v] batch task failed, resp(%+v)", nodeAddr, resp)
+		auditlog.LogMasterOp("HandleLcNodeBatchResp", fmt.Sprintf("batch task failed: %+v", resp), nil)
+	case proto.TaskSucceeds:
+		log.LogInfof("action[handleLcNodeBatchResp] lcNode[%v] batch task completed, resp(%+v)", nodeAddr, resp)
+		auditlog.LogMasterOp("HandleLcNodeBatchResp", fmt.Sprintf("batch task completed: %+v", resp), nil)
+	default:
+		log.LogInfof("action[handleLcNodeBatchResp] lcNode[%v] batch task received, resp(%+v)", nodeAddr, resp)
+		return
+	}
+	return c.batchMgr.handleTaskResp(nodeAddr, resp)
+}
+
+// key=#bj#jobId
+func (c *Cluster) syncAddBatchJob(job *proto.BatchJob) (err error) {
+	return c.syncPutBatchJob(opSyncAddBatchJob, job)
+}
+
+func (c *Cluster) syncDeleteBatchJob(job *proto.BatchJob) (err error) {
+	return c.syncPutBatchJob(opSyncDeleteBatchJob, job)
+}
+
+func (c *Cluster) syncPutBatchJob(opType uint32, job *proto.BatchJob) (err error) {
+	metadata := new(RaftCmd)
+	metadata.Op = opType
+	metadata.K = batchJobPrefix + job.JobId
+	metadata.V, err = json.Marshal(job)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	return c.submit(metadata)
+}
+
+func (c *Cluster) loadBatchJobs() (err error) {
+	result, err := c.fsm.store.SeekForPrefix([]byte(batchJobPrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadBatchJobs],err:%v", err.Error())
+		return err
+	}
+	for _, value := range result {
+		job := &proto.BatchJob{}
+		if err = json.Unmarshal(value, job); err != nil {
+			err = fmt.Errorf("action[loadBatchJobs],value:%v,unmarshal err:%v", string(value), err)
+			return
+		}
+		c.batchMgr.load(job)
+		log.LogInfof("action[loadBatchJobs],job[%v] status[%v]", job.JobId, job.Status)
+	}
+	return
+}
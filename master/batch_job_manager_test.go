@@ -0,0 +1,294 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+type mockBatchCluster struct {
+	sync.Mutex
+	nodes      []string
+	persisted  map[string]*proto.BatchJob
+	dispatched map[string]string // task id --> lcnode
+	stopped    map[string]string // task id --> lcnode
+}
+
+func newMockBatchJobManager(nodes ...string) (*batchJobManager, *mockBatchCluster) {
+	mc := &mockBatchCluster{
+		nodes:      nodes,
+		persisted:  make(map[string]*proto.BatchJob),
+		dispatched: make(map[string]string),
+		stopped:    make(map[string]string),
+	}
+	mgr := newBatchJobManager(nil)
+	mgr.persist = func(job *proto.BatchJob) error {
+		mc.Lock()
+		mc.persisted[job.JobId] = job.Copy()
+		mc.Unlock()
+		return nil
+	}
+	mgr.remove = func(job *proto.BatchJob) error {
+		mc.Lock()
+		delete(mc.persisted, job.JobId)
+		mc.Unlock()
+		return nil
+	}
+	mgr.activeNodes = func() []string { return mc.nodes }
+	mgr.dispatch = func(node string, task *proto.BatchTask) error {
+		mc.Lock()
+		mc.dispatched[task.Id] = node
+		mc.Unlock()
+		return nil
+	}
+	mgr.stop = func(node, id string) {
+		mc.Lock()
+		mc.stopped[id] = node
+		mc.Unlock()
+	}
+	return mgr, mc
+}
+
+func (mc *mockBatchCluster) stoppedCount() int {
+	mc.Lock()
+	defer mc.Unlock()
+	return len(mc.stopped)
+}
+
+func newTestBatchJob(owner, token string, priority int) *proto.BatchJob {
+	return &proto.BatchJob{
+		Owner:              owner,
+		AccessKey:          owner + "-ak",
+		ClientRequestToken: token,
+		Priority:           priority,
+		Operation:          &proto.BatchOperation{Type: proto.BatchOpDeleteObject},
+		Manifest:           &proto.BatchManifest{Bucket: "manifests", Key: "manifest.csv"},
+		Report:             &proto.BatchReport{},
+	}
+}
+
+func waitBatchStopped(t *testing.T, mc *mockBatchCluster, count int) {
+	for i := 0; i < 100 && mc.stoppedCount() < count; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := mc.stoppedCount(); n != count {
+		t.Fatalf("stopped tasks expected %v, got %v", count, n)
+	}
+}
+
+func TestBatchJobCreate(t *testing.T) {
+	mgr, mc := newMockBatchJobManager("node1:17510", "node2:17510")
+	job, err := mgr.createJob(newTestBatchJob("alice", "token1", 10))
+	if err != nil {
+		t.Fatalf("create job err %v", err)
+	}
+	if job.Status != proto.BatchJobReady || job.Shards != 2 || len(job.Tasks) != 2 {
+		t.Errorf("unexpected job %+v", job)
+	}
+	if _, ok := mc.persisted[job.JobId]; !ok {
+		t.Errorf("job %v is not persisted", job.JobId)
+	}
+
+	// the job created by the same token is returned
+	again, err := mgr.createJob(newTestBatchJob("alice", "token1", 10))
+	if err != nil || again.JobId != job.JobId {
+		t.Errorf("create job by the same token expected %v, got %v err %v", job.JobId, again, err)
+	}
+	other, err := mgr.createJob(newTestBatchJob("bob", "token1", 10))
+	if err != nil || other.JobId == job.JobId {
+		t.Errorf("create job of the other owner expected new job, got %v err %v", other, err)
+	}
+
+	// the prefix manifest is scanned by one shard for each prefix
+	prefixJob := newTestBatchJob("alice", "", 0)
+	prefixJob.Manifest = &proto.BatchManifest{Bucket: "photos", Prefixes: []string{"2022/", "2023/", "2024/"}}
+	prefixJob.Status = proto.BatchJobSuspended
+	if prefixJob, err = mgr.createJob(prefixJob); err != nil {
+		t.Fatalf("create job err %v", err)
+	}
+	if prefixJob.Shards != 3 || prefixJob.Status != proto.BatchJobSuspended {
+		t.Errorf("unexpected job %+v", prefixJob)
+	}
+
+	invalid := newTestBatchJob("alice", "", 0)
+	invalid.Operation.Type = "S3InitiateRestoreObject"
+	if _, err = mgr.createJob(invalid); err == nil {
+		t.Errorf("create job with invalid operation expected err")
+	}
+
+	if _, err = mgr.getJob(job.JobId, "bob"); err != proto.ErrBatchJobNotExists {
+		t.Errorf("get job of the other owner expected ErrBatchJobNotExists, got %v", err)
+	}
+	if jobs := mgr.listJobs("alice", []string{proto.BatchJobReady}); len(jobs) != 1 || jobs[0].JobId != job.JobId {
+		t.Errorf("list ready jobs of alice expected %v, got %v", job.JobId, jobs)
+	}
+	if jobs := mgr.listJobs("", nil); len(jobs) != 3 {
+		t.Errorf("list all jobs expected 3, got %v", len(jobs))
+	}
+}
+
+func TestBatchJobSchedule(t *testing.T) {
+	mgr, mc := newMockBatchJobManager("node1:17510", "node2:17510")
+	mgr.taskLimitPerNode = 1
+	low, _ := mgr.createJob(newTestBatchJob("alice", "low", 1))
+	high, _ := mgr.createJob(newTestBatchJob("alice", "high", 100))
+
+	// the shards of the job with the higher priority are dispatched first
+	now := time.Now()
+	mgr.schedule(now)
+	if len(mc.dispatched) != 2 {
+		t.Fatalf("dispatched tasks expected 2, got %v", mc.dispatched)
+	}
+	for shard := 0; shard < 2; shard++ {
+		if _, ok := mc.dispatched[proto.BatchTaskId(high.JobId, shard)]; !ok {
+			t.Errorf("task %v of the job with the higher priority is not dispatched", shard)
+		}
+	}
+	if job, _ := mgr.getJob(high.JobId, ""); job.Status != proto.BatchJobActive {
+		t.Errorf("job status expected Active, got %v", job.Status)
+	}
+	if job, _ := mgr.getJob(low.JobId, ""); job.Status != proto.BatchJobReady {
+		t.Errorf("job status expected Ready, got %v", job.Status)
+	}
+
+	// the checkpoint is updated by the heartbeat, and the unknown task is stopped
+	id := proto.BatchTaskId(high.JobId, 0)
+	node := mc.dispatched[id]
+	mgr.handleHeartbeat(node, map[string]*proto.LcNodeBatchTaskResponse{
+		id: {ID: id, Task: &proto.BatchJobTask{
+			Shard: 0, NextLine: 100, ReportSeq: 1,
+			Progress: proto.BatchJobProgress{Total: 50, Succeeded: 49, Failed: 1},
+		}},
+		"unknown:0": {ID: "unknown:0"},
+	})
+	if job, _ := mgr.getJob(high.JobId, ""); job.Tasks[0].NextLine != 100 || job.Progress().Total != 50 {
+		t.Errorf("checkpoint is not updated by the heartbeat: %+v", job.Tasks[0])
+	}
+	if p := mc.persisted[high.JobId]; p.Tasks[0].NextLine != 100 {
+		t.Errorf("checkpoint is not persisted: %+v", p.Tasks[0])
+	}
+	waitBatchStopped(t, mc, 1)
+
+	// the shard not reported in time is redispatched from the checkpoint
+	mc.dispatched = make(map[string]string)
+	mgr.schedule(now.Add(batchTaskTimeout + time.Minute))
+	if len(mc.dispatched) != 2 {
+		t.Errorf("redispatched tasks expected 2, got %v", mc.dispatched)
+	}
+	job, _ := mgr.getJob(high.JobId, "")
+	if job.Tasks[0].NextLine != 100 {
+		t.Errorf("redispatched task expected to keep the checkpoint, got %+v", job.Tasks[0])
+	}
+
+	// the job is complete once all the shards are done
+	for _, task := range job.Tasks {
+		resp := &proto.LcNodeBatchTaskResponse{
+			ID:     proto.BatchTaskId(high.JobId, task.Shard),
+			Task:   &proto.BatchJobTask{Shard: task.Shard, NextLine: 200},
+			Status: proto.TaskSucceeds,
+		}
+		if err := mgr.handleTaskResp(task.LcNode, resp); err != nil {
+			t.Fatalf("handle task resp err %v", err)
+		}
+	}
+	if job, _ = mgr.getJob(high.JobId, ""); job.Status != proto.BatchJobComplete || job.TerminationTime == 0 {
+		t.Errorf("job status expected Complete, got %v", job.Status)
+	}
+
+	// the shards of the other job are dispatched to the idle lcnodes
+	mc.dispatched = make(map[string]string)
+	mgr.schedule(time.Now())
+	if len(mc.dispatched) != 2 {
+		t.Errorf("dispatched tasks expected 2, got %v", mc.dispatched)
+	}
+}
+
+func TestBatchJobUpdateStatus(t *testing.T) {
+	mgr, mc := newMockBatchJobManager("node1:17510")
+	job, _ := mgr.createJob(newTestBatchJob("alice", "", 0))
+	mgr.schedule(time.Now())
+	id := proto.BatchTaskId(job.JobId, 0)
+
+	// suspend the job and stop the running shard
+	req := &proto.BatchJobStatusRequest{JobId: job.JobId, Owner: "alice", Status: proto.BatchJobSuspended}
+	if _, err := mgr.updateStatus(req); err != nil {
+		t.Fatalf("suspend job err %v", err)
+	}
+	waitBatchStopped(t, mc, 1)
+	// the checkpoint of the stopped shard is kept
+	if err := mgr.handleTaskResp("node1:17510", &proto.LcNodeBatchTaskResponse{
+		ID: id, Task: &proto.BatchJobTask{NextLine: 10}, Stopped: true,
+	}); err != nil {
+		t.Fatalf("handle task resp err %v", err)
+	}
+	job, _ = mgr.getJob(job.JobId, "")
+	if job.Tasks[0].NextLine != 10 || job.Tasks[0].Done || job.Tasks[0].LcNode != "" {
+		t.Errorf("unexpected task of the suspended job %+v", job.Tasks[0])
+	}
+	mc.dispatched = make(map[string]string)
+	mgr.schedule(time.Now())
+	if len(mc.dispatched) != 0 {
+		t.Errorf("the suspended job is dispatched: %v", mc.dispatched)
+	}
+
+	// resume the job, the shard fails and the failed job is resumed again
+	req.Status = proto.BatchJobReady
+	if _, err := mgr.updateStatus(req); err != nil {
+		t.Fatalf("resume job err %v", err)
+	}
+	mgr.schedule(time.Now())
+	if mc.dispatched[id] != "node1:17510" {
+		t.Fatalf("the resumed job is not dispatched: %v", mc.dispatched)
+	}
+	_ = mgr.handleTaskResp("node1:17510", &proto.LcNodeBatchTaskResponse{
+		ID: id, Task: &proto.BatchJobTask{NextLine: 20}, Status: proto.TaskFailed, Result: "access denied",
+	})
+	if job, _ = mgr.getJob(job.JobId, ""); job.Status != proto.BatchJobFailed || job.Tasks[0].Result == "" {
+		t.Errorf("job status expected Failed, got %v", job.Status)
+	}
+	if _, err := mgr.updateStatus(req); err != nil {
+		t.Fatalf("resume failed job err %v", err)
+	}
+	if job, _ = mgr.getJob(job.JobId, ""); job.Tasks[0].Done || job.Tasks[0].Result != "" || job.Tasks[0].NextLine != 20 {
+		t.Errorf("unexpected task of the resumed job %+v", job.Tasks[0])
+	}
+
+	// cancel the job, which can't be resumed
+	req.Status = proto.BatchJobCancelled
+	if _, err := mgr.updateStatus(req); err != nil {
+		t.Fatalf("cancel job err %v", err)
+	}
+	req.Status = proto.BatchJobReady
+	if _, err := mgr.updateStatus(req); err != proto.ErrInvalidBatchJobStatus {
+		t.Errorf("resume cancelled job expected ErrInvalidBatchJobStatus, got %v", err)
+	}
+	req.Owner = "bob"
+	if _, err := mgr.updateStatus(req); err != proto.ErrBatchJobNotExists {
+		t.Errorf("update job of the other owner expected ErrBatchJobNotExists, got %v", err)
+	}
+
+	// the terminated job is removed after the retention
+	mgr.schedule(time.Now().Add(batchJobRetention + time.Hour))
+	if _, err := mgr.getJob(job.JobId, ""); err != proto.ErrBatchJobNotExists {
+		t.Errorf("expired job expected to be removed, got %v", err)
+	}
+	if _, ok := mc.persisted[job.JobId]; ok {
+		t.Errorf("expired job expected to be removed from the store")
+	}
+}
@@ -41,6 +41,7 @@ import (
 	"github.com/cubefs/cubefs/util/config"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 var (
@@ -108,6 +109,8 @@ type ClusterDecommission struct {
 	DecommissionDisks                      sync.Map
 	DataNodeToDecommissionRepairDpMap      sync.Map
 	NoSamePeerDps                          sync.Map
+	SpreadViolationDps                     sync.Map
+	SpreadViolationMps                     sync.Map
 	DecommissionFirstHostDiskParallelLimit uint64
 	DecommissionLimit                      uint64
 	AutoDecommissionDiskMux                sync.Mutex
@@ -120,6 +123,7 @@ type ClusterDecommission struct {
 	EnableAutoDecommissionDisk  atomicutil.Bool
 	AutoDecommissionInterval    atomicutil.Int64
 	AutoDpMetaRepairParallelCnt atomicutil.Uint32
+	EnableSpreadRepair          atomicutil.Bool
 	server                      *Server
 }
 
@@ -167,6 +171,7 @@ type Cluster struct {
 	dataMediaTypeVaild      bool
 
 	S3ApiQosQuota  *sync.Map // (api,uid,limtType) -> limitQuota
+	s3QosLeases    *s3QosLeaseMgr
 	QosAcceptLimit *rate.Limiter
 	apiLimiter     *ApiLimiter
 
@@ -183,7 +188,9 @@ type Cluster struct {
 	Cleaning    bool
 	mu          sync.Mutex
 	PlanRun     bool
+	DataPlanRun bool
 	flashManMgr *flashManualTaskManager
+	batchMgr    *batchJobManager
 }
 
 type cTask struct {
@@ -476,6 +483,7 @@ func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition
 	c.snapshotMgr = newSnapshotManager()
 	c.snapshotMgr.cluster = c
 	c.S3ApiQosQuota = new(sync.Map)
+	c.s3QosLeases = newS3QosLeaseMgr()
 	c.MarkDiskBrokenThreshold.Store(defaultMarkDiskBrokenThreshold)
 	c.EnableAutoDpMetaRepair.Store(defaultEnableDpMetaRepair)
 	c.AutoDecommissionInterval.Store(int64(defaultAutoDecommissionDiskInterval))
@@ -483,7 +491,9 @@ func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition
 	c.flashNodeTopo = newFlashNodeTopology()
 	c.cleanTask = make(map[string]*CleanTask)
 	c.PlanRun = false
+	c.DataPlanRun = false
 	c.flashManMgr = newFlashManualTaskManager(c)
+	c.batchMgr = newBatchJobManager(c)
 	return
 }
 
@@ -507,6 +517,7 @@ func (c *Cluster) scheduleTask() {
 	c.scheduleToCheckDecommissionDisk()
 	c.scheduleToLcScan()
 	c.scheduleToSnapshotDelVerScan()
+	c.scheduleToCheckRestoreRecords()
 	c.scheduleToBadDisk()
 	c.scheduleToCheckVolUid()
 	c.scheduleToCheckDataReplicaMeta()
@@ -515,6 +526,7 @@ func (c *Cluster) scheduleTask() {
 	c.scheduleToUpdateFlashGroupSlots()
 	c.scheduleToCheckDataPartitionRepairingStatus()
 	c.scheduleToCheckDataPartitionDecommissionDiskRetryMap()
+	c.scheduleToCheckSpreadConstraint()
 }
 
 func (c *Cluster) masterAddr() (addr string) {
@@ -910,6 +922,7 @@ func (c *Cluster) checkDataNodeHeartbeat() {
 		log.LogDebugf("checkDataNodeHeartbeat createHeartbeatTask for data node %v task %v %v", node.Addr,
 			task.RequestID, id.String())
 		hbReq := task.Request.(*proto.HeartBeatRequest)
+		hbReq.TLSTrustInfo = c.getTLSTrust()
 		c.volMutex.RLock()
 		defer c.volMutex.RUnlock()
 		for _, vol := range c.vols {
@@ -948,6 +961,7 @@ func (c *Cluster) checkMetaNodeHeartbeat() {
 		node.checkHeartbeat()
 		task := node.createHeartbeatTask(c.masterAddr(), c.fileStatsEnable, c.fileStatsThresholds, c.cfg.forbidWriteOpOfProtoVer0, c.cfg.metaNodeGOGC, c.RaftPartitionCanUsingDifferentPortEnabled())
 		hbReq := task.Request.(*proto.HeartBeatRequest)
+		hbReq.TLSTrustInfo = c.getTLSTrust()
 
 		c.volMutex.RLock()
 		defer c.volMutex.RUnlock()
@@ -1005,6 +1019,7 @@ func (c *Cluster) checkLcNodeHeartbeat() {
 			return true
 		}
 		task := node.createHeartbeatTask(c.masterAddr())
+		task.Request.(*proto.HeartBeatRequest).TLSTrustInfo = c.getTLSTrust()
 		tasks = append(tasks, task)
 		return true
 	})
@@ -1938,13 +1953,16 @@ func (c *Cluster) createDataPartition(volName string, mediaType uint32) (dp *Dat
 	} else {
 		zoneNum := c.decideZoneNum(vol, mediaType) // zoneNum scope [1,3]
 		if targetHosts, targetPeers, err = c.getHostFromNormalZone(TypeDataPartition, nil, nil, nil,
-			int(dpReplicaNum), zoneNum, zoneName, mediaType); err != nil {
+			int(dpReplicaNum), zoneNum, zoneName, mediaType, newSpreadConstraint(vol.spreadLabel)); err != nil {
 			goto errHandler
 		}
 	}
 	if err = c.checkMultipleReplicasOnSameMachine(targetHosts); err != nil {
 		goto errHandler
 	}
+	if err = c.checkSpreadConstraint(volName, TypeDataPartition, targetHosts); err != nil {
+		goto errHandler
+	}
 
 	if partitionID, err = c.idAlloc.allocateDataPartitionID(); err != nil {
 		goto errHandler
@@ -2145,7 +2163,7 @@ func (c *Cluster) decideZoneNum(vol *Vol, mediaType uint32) (zoneNum int) {
 }
 
 func (c *Cluster) chooseZone2Plus1(rsMgr *rsManager, zones []*Zone, excludeNodeSets []uint64, excludeHosts []string,
-	nodeType uint32, replicaNum int) (hosts []string, peers []proto.Peer, err error,
+	nodeType uint32, replicaNum int, spread *spreadConstraint) (hosts []string, peers []proto.Peer, err error,
 ) {
 	if replicaNum < 2 || replicaNum > defaultReplicaNum {
 		return nil, nil, fmt.Errorf("action[chooseZone2Plus1] replicaNum [%v]", replicaNum)
@@ -2167,7 +2185,7 @@ func (c *Cluster) chooseZone2Plus1(rsMgr *rsManager, zones []*Zone, excludeNodeS
 
 	num := 1
 	for _, zone := range zoneList {
-		selectedHosts, selectedPeers, e := zone.getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, num)
+		selectedHosts, selectedPeers, e := zone.getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, num, spread)
 		if e != nil {
 			log.LogErrorf("action[chooseZone2Plus1] getAvailNodeHosts error: [%v]", e)
 			return nil, nil, e
@@ -2186,7 +2204,7 @@ func (c *Cluster) chooseZone2Plus1(rsMgr *rsManager, zones []*Zone, excludeNodeS
 }
 
 func (c *Cluster) chooseZoneNormal(zones []*Zone, excludeNodeSets []uint64, excludeHosts []string,
-	nodeType uint32, replicaNum int,
+	nodeType uint32, replicaNum int, spread *spreadConstraint,
 ) (hosts []string, peers []proto.Peer, err error) {
 	log.LogInfof("action[chooseZoneNormal] zones[%s] nodeType[%d] replicaNum[%d]", printZonesName(zones), nodeType, replicaNum)
 
@@ -2198,7 +2216,7 @@ func (c *Cluster) chooseZoneNormal(zones []*Zone, excludeNodeSets []uint64, excl
 		for j := 0; j < len(zones); j++ {
 			zone := zones[c.lastZoneIdxForNode]
 			c.lastZoneIdxForNode = (c.lastZoneIdxForNode + 1) % len(zones)
-			selectedHosts, selectedPeers, err := zone.getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, 1)
+			selectedHosts, selectedPeers, err := zone.getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, 1, spread)
 			if err != nil {
 				// no zone available
 				if j == len(zones)-1 {
@@ -2238,10 +2256,14 @@ func (c *Cluster) getSpecificZoneList(specifiedZone string) (zones []*Zone, err
 	return
 }
 
+// getHostFromNormalZone selects the hosts in the normal zones, the spread constraint, which may be nil,
+// keeps the hosts in the failure domains not taken by the other replicas.
 func (c *Cluster) getHostFromNormalZone(nodeType uint32, excludeZones []string, excludeNodeSets []uint64,
 	excludeHosts []string, replicaNum int, zoneNumNeed int,
-	specifiedZoneName string, dataMediaType uint32) (hosts []string, peers []proto.Peer, err error,
+	specifiedZoneName string, dataMediaType uint32, spread *spreadConstraint) (hosts []string, peers []proto.Peer, err error,
 ) {
+	// the domains taken by the hosts selected are never returned to the caller
+	spread = spread.clone()
 	log.LogInfof("[getHostFromNormalZone] dataMediaType(%v) nodeType(%v) replicaNum(%v) zoneNumNeed(%v) specifiedZoneName(%v)",
 		proto.MediaTypeString(nodeType), nodeType, replicaNum, zoneNumNeed, specifiedZoneName)
 
@@ -2270,7 +2292,7 @@ func (c *Cluster) getHostFromNormalZone(nodeType uint32, excludeZones []string,
 
 	if len(zonesQualified) == 1 {
 		log.LogInfof("action[getHostFromNormalZone] zones [%v]", zonesQualified[0].name)
-		if hosts, peers, err = zonesQualified[0].getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, replicaNum); err != nil {
+		if hosts, peers, err = zonesQualified[0].getAvailNodeHosts(nodeType, excludeNodeSets, excludeHosts, replicaNum, spread); err != nil {
 			log.LogErrorf("action[getHostFromNormalZone] err[%v]", err)
 			return
 		}
@@ -2282,11 +2304,11 @@ func (c *Cluster) getHostFromNormalZone(nodeType uint32, excludeZones []string,
 	}
 	// The upper process tries to go and get the dedicated zones, and the latter tries to choose the right zones as possible.
 	if c.cfg.DefaultNormalZoneCnt == defaultNormalCrossZoneCnt && len(zonesQualified) >= defaultNormalCrossZoneCnt || replicaNum == 1 {
-		if hosts, peers, err = c.chooseZoneNormal(zonesQualified, excludeNodeSets, excludeHosts, nodeType, replicaNum); err != nil {
+		if hosts, peers, err = c.chooseZoneNormal(zonesQualified, excludeNodeSets, excludeHosts, nodeType, replicaNum, spread); err != nil {
 			return
 		}
 	} else {
-		if hosts, peers, err = c.chooseZone2Plus1(rsMgr, zonesQualified, excludeNodeSets, excludeHosts, nodeType, replicaNum); err != nil {
+		if hosts, peers, err = c.chooseZone2Plus1(rsMgr, zonesQualified, excludeNodeSets, excludeHosts, nodeType, replicaNum, spread); err != nil {
 			return
 		}
 	}
@@ -2839,6 +2861,8 @@ ERR:
 // 6. persistent the new host list
 func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataPartition, raftForce bool, errMsg string) (err error) {
 	var (
+		spread          *spreadConstraint
+		spreadHosts     []string
 		targetHosts     []string
 		finalHosts      []string
 		newAddr         string
@@ -2909,13 +2933,22 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 		goto errHandler
 	}
 
+	// the replicas kept take their failure domains, the new one is placed in the others
+	dp.RLock()
+	spreadHosts = keptHosts(dp.Hosts, srcAddr)
+	dp.RUnlock()
+	spread = c.newSpreadConstraint(dp.VolName, TypeDataPartition, spreadHosts)
+
 	if targetAddr != "" {
 		targetHosts = []string{targetAddr}
 		if err = c.checkDataNodesMediaTypeForMigrate(dataNode, targetAddr); err != nil {
 			log.LogErrorf("[migrateDataPartition] check mediaType err: %v", err.Error())
 			goto errHandler
 		}
-	} else if targetHosts, _, err = ns.getAvailDataNodeHosts(dp.Hosts, 1); err != nil {
+		if err = c.checkSpreadTarget(dp.VolName, TypeDataPartition, spreadHosts, targetAddr); err != nil {
+			goto errHandler
+		}
+	} else if targetHosts, _, err = ns.getAvailDataNodeHosts(dp.Hosts, 1, spread); err != nil {
 		if _, ok := c.vols[dp.VolName]; !ok {
 			log.LogWarnf("clusterID[%v] partitionID:%v  on node:%v offline failed,PersistenceHosts:[%v]",
 				c.Name, dp.PartitionID, srcAddr, dp.Hosts)
@@ -2928,10 +2961,10 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 		}
 		// select data nodes from the other node set in same zone
 		excludeNodeSets = append(excludeNodeSets, ns.ID)
-		if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets, dp.Hosts, 1); err != nil {
+		if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets, dp.Hosts, 1, spread); err != nil {
 			// select data nodes from the other zone
 			zones = dp.getLiveZones(srcAddr)
-			if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, zones, excludeNodeSets, dp.Hosts, 1, 1, "", dp.MediaType); err != nil {
+			if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, zones, excludeNodeSets, dp.Hosts, 1, 1, "", dp.MediaType, spread); err != nil {
 				goto errHandler
 			}
 		}
@@ -4078,6 +4111,7 @@ func (c *Cluster) doCreateVol(req *createVolReq) (vol *Vol, err error) {
 		CreateTime:              createTime,
 		DeleteLockTime:          req.deleteLockTime,
 		Description:             req.description,
+		SpreadLabel:             req.spreadLabel,
 		EnablePosixAcl:          req.enablePosixAcl,
 		EnableQuota:             req.enableQuota,
 		EnableTransaction:       req.enableTransaction,
@@ -4452,6 +4486,27 @@ func (c *Cluster) setMetaNodeGOGC(metaNodeGOGC int) (err error) {
 	return
 }
 
+// setTLSTrust distributes the TLS mode and the CA bundle to the nodes and the clients, and applies them to the master.
+func (c *Cluster) setTLSTrust(mode, caPem string) (err error) {
+	if err = tlsutil.SetTrust(mode, caPem); err != nil {
+		return
+	}
+	oldMode, oldCAPem := c.cfg.tlsMode, c.cfg.tlsCAPem
+	c.cfg.tlsMode, c.cfg.tlsCAPem = mode, caPem
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setTLSTrust] err[%v]", err)
+		c.cfg.tlsMode, c.cfg.tlsCAPem = oldMode, oldCAPem
+		tlsutil.SetTrust(oldMode, oldCAPem)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (c *Cluster) getTLSTrust() proto.TLSTrustInfo {
+	return proto.TLSTrustInfo{TLSMode: c.cfg.tlsMode, TLSCAPem: c.cfg.tlsCAPem}
+}
+
 func (c *Cluster) setDataNodeGOGC(dataNodeGOGC int) (err error) {
 	oldDataNodeGOGC := c.cfg.dataNodeGOGC
 	c.cfg.dataNodeGOGC = dataNodeGOGC
@@ -4669,6 +4724,23 @@ func (c *Cluster) getEnableAutoDpMetaRepair() (v bool) {
 	return
 }
 
+func (c *Cluster) setEnableSpreadRepair(val bool) (err error) {
+	oldVal := c.EnableSpreadRepair.Load()
+	c.EnableSpreadRepair.Store(val)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("[setEnableSpreadRepair] failed to set enable spread repair, err(%v)", err)
+		c.EnableSpreadRepair.Store(oldVal)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (c *Cluster) getEnableSpreadRepair() (v bool) {
+	v = c.EnableSpreadRepair.Load()
+	return
+}
+
 func (c *Cluster) getDataPartitionTimeoutSec() (val int64) {
 	val = atomic.LoadInt64(&c.cfg.DataPartitionTimeOutSec)
 	if val == 0 {
@@ -5959,6 +6031,41 @@ func (c *Cluster) DelBucketLifecycle(VolName string) error {
 	return nil
 }
 
+func (c *Cluster) SetBucketInventory(req *proto.BucketInventory) error {
+	inventory := &proto.BucketInventory{
+		VolName:        req.VolName,
+		Configurations: req.Configurations,
+	}
+	if err := c.syncPutBucketInventory(inventory); err != nil {
+		err = fmt.Errorf("action[SetBucketInventory],clusterID[%v] vol:%v err:%v ", c.Name, inventory.VolName, err.Error())
+		log.LogError(errors.Stack(err))
+		Warn(c.Name, err.Error())
+		return err
+	}
+	c.lcMgr.SetBucketInventory(inventory)
+	log.LogInfof("action[SetBucketInventory],clusterID[%v] vol:%v configurations:%v", c.Name, inventory.VolName, len(inventory.Configurations))
+	return nil
+}
+
+func (c *Cluster) GetBucketInventory(VolName string) *proto.BucketInventory {
+	return c.lcMgr.GetBucketInventory(VolName)
+}
+
+func (c *Cluster) DelBucketInventory(VolName string) error {
+	inventory := &proto.BucketInventory{
+		VolName: VolName,
+	}
+	if err := c.syncDeleteBucketInventory(inventory); err != nil {
+		err = fmt.Errorf("action[DelBucketInventory],clusterID[%v] vol:%v err:%v ", c.Name, VolName, err.Error())
+		log.LogError(errors.Stack(err))
+		Warn(c.Name, err.Error())
+		return err
+	}
+	c.lcMgr.DelBucketInventory(VolName)
+	log.LogInfof("action[DelBucketInventory],clusterID[%v] vol:%v", c.Name, VolName)
+	return nil
+}
+
 func (c *Cluster) addDecommissionDiskToNodeset(dd *DecommissionDisk) (err error) {
 	var (
 		node *DataNode
@@ -1321,13 +1321,21 @@ func (c *Cluster) scheduleStartBalanceTask() {
 		for {
 			select {
 			case <-ticker.C:
-				if c.partition == nil || !c.partition.IsRaftLeader() || c.PlanRun {
+				if c.partition == nil || !c.partition.IsRaftLeader() {
 					continue
 				}
 
-				err := c.RestartMetaPartitionBalanceTask()
-				if err != nil && err != proto.ErrNoMpMigratePlan {
-					log.LogErrorf("RestartMetaPartitionBalanceTask err: %s", err.Error())
+				if !c.PlanRun {
+					err := c.RestartMetaPartitionBalanceTask()
+					if err != nil && err != proto.ErrNoMpMigratePlan {
+						log.LogErrorf("RestartMetaPartitionBalanceTask err: %s", err.Error())
+					}
+				}
+				if !c.DataPlanRun {
+					err := c.RestartDataPartitionBalanceTask()
+					if err != nil && err != proto.ErrNoDpMigratePlan {
+						log.LogErrorf("RestartDataPartitionBalanceTask err: %s", err.Error())
+					}
 				}
 			case <-c.stopc:
 				return
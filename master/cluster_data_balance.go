@@ -0,0 +1,518 @@
+// Copyright 2025 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/auditlog"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The data partition balance task moves the data partitions from the data nodes and the disks with
+// high usage to the data nodes with low usage in the same zone, such as the data nodes newly added.
+
+type dataBalanceCandidate struct {
+	dp   *DataPartition
+	size uint64
+	disk string
+}
+
+func DataNodeBalanceRecord(dataNode *DataNode) *proto.DataNodeBalanceInfo {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+
+	record := &proto.DataNodeBalanceInfo{
+		ID:        dataNode.ID,
+		Addr:      dataNode.Addr,
+		ZoneName:  dataNode.ZoneName,
+		NodeSetID: dataNode.NodeSetID,
+		Total:     dataNode.Total,
+		Used:      dataNode.Used,
+		Disks:     make([]*proto.DiskBalanceInfo, 0, len(dataNode.DiskStats)),
+	}
+	if record.Total > 0 {
+		record.Ratio = float64(record.Used) / float64(record.Total)
+	}
+	record.Estimate = record.Ratio
+	for _, disk := range dataNode.DiskStats {
+		if disk.Total == 0 {
+			continue
+		}
+		record.Disks = append(record.Disks, &proto.DiskBalanceInfo{
+			Path:  disk.DiskPath,
+			Total: disk.Total,
+			Used:  disk.Used,
+			Ratio: float64(disk.Used) / float64(disk.Total),
+		})
+	}
+	return record
+}
+
+// CreateDataPartitionMigratePlan creates the plan to balance the usage of the data nodes in each zone.
+func (c *Cluster) CreateDataPartitionMigratePlan(parallel int) (*proto.DataClusterPlan, error) {
+	plan := &proto.DataClusterPlan{
+		Nodes:    make([]*proto.DataNodeBalanceInfo, 0),
+		Plan:     make([]*proto.DataBalancePlan, 0),
+		Parallel: parallel,
+		Status:   PlanTaskInit,
+	}
+
+	zones := make(map[string][]*proto.DataNodeBalanceInfo)
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode, ok := value.(*DataNode)
+		if !ok {
+			return true
+		}
+		if !dataNode.isActive || dataNode.ToBeOffline || dataNode.Total == 0 {
+			return true
+		}
+		record := DataNodeBalanceRecord(dataNode)
+		zones[record.ZoneName] = append(zones[record.ZoneName], record)
+		plan.Nodes = append(plan.Nodes, record)
+		return true
+	})
+
+	zoneNames := make([]string, 0, len(zones))
+	for zoneName := range zones {
+		zoneNames = append(zoneNames, zoneName)
+	}
+	sort.Strings(zoneNames)
+	planned := make(map[uint64]struct{})
+	for _, zoneName := range zoneNames {
+		c.AddZoneIntoDataBalancePlan(plan, zones[zoneName], planned)
+	}
+	plan.Total = len(plan.Plan)
+
+	return plan, nil
+}
+
+// AddZoneIntoDataBalancePlan moves the data partitions from the nodes or the disks with the usage ratio over the
+// average of the zone by the gap, to the nodes with the usage ratio under the average.
+func (c *Cluster) AddZoneIntoDataBalancePlan(plan *proto.DataClusterPlan, nodes []*proto.DataNodeBalanceInfo, planned map[uint64]struct{}) {
+	var total, used uint64
+	for _, node := range nodes {
+		total += node.Total
+		used += node.Used
+	}
+	if total == 0 || len(nodes) < 2 {
+		return
+	}
+	average := float64(used) / float64(total)
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Ratio > nodes[j].Ratio
+	})
+	for _, src := range nodes {
+		if len(plan.Plan) >= defaultDataBalancePlanLimit {
+			log.LogWarnf("AddZoneIntoDataBalancePlan: the plan reaches the limit %d", defaultDataBalancePlanLimit)
+			return
+		}
+		if !dataNodeOverLoad(src, average) {
+			continue
+		}
+		c.AddDataNodeIntoDataBalancePlan(plan, src, nodes, average, planned)
+	}
+}
+
+func dataNodeOverLoad(node *proto.DataNodeBalanceInfo, average float64) bool {
+	if node.Estimate > average+defaultDataBalanceRatioGap {
+		return true
+	}
+	for _, disk := range node.Disks {
+		if disk.Ratio > average+defaultDataBalanceRatioGap {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cluster) AddDataNodeIntoDataBalancePlan(plan *proto.DataClusterPlan, src *proto.DataNodeBalanceInfo,
+	nodes []*proto.DataNodeBalanceInfo, average float64, planned map[uint64]struct{},
+) {
+	disks := make(map[string]*proto.DiskBalanceInfo, len(src.Disks))
+	for _, disk := range src.Disks {
+		disks[disk.Path] = disk
+	}
+
+	candidates := make([]*dataBalanceCandidate, 0)
+	for _, dp := range c.getAllDataPartitionByDataNode(src.Addr) {
+		if _, ok := planned[dp.PartitionID]; ok {
+			continue
+		}
+		if !proto.IsNormalDp(dp.PartitionType) || dp.isSpecialReplicaCnt() || dp.isRecover || dp.IsDecommissionRunning() {
+			continue
+		}
+		dp.RLock()
+		replica, err := dp.getReplica(src.Addr)
+		if err != nil || len(dp.Hosts) != int(dp.ReplicaNum) {
+			dp.RUnlock()
+			continue
+		}
+		candidates = append(candidates, &dataBalanceCandidate{dp: dp, size: replica.Used, disk: replica.DiskPath})
+		dp.RUnlock()
+	}
+	// move the partitions on the disks with high usage first, and the large ones first to reduce the moves.
+	diskRatio := func(path string) float64 {
+		if disk, ok := disks[path]; ok {
+			return disk.Ratio
+		}
+		return 0
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if ri, rj := diskRatio(candidates[i].disk), diskRatio(candidates[j].disk); ri != rj {
+			return ri > rj
+		}
+		return candidates[i].size > candidates[j].size
+	})
+
+	for _, candidate := range candidates {
+		if len(plan.Plan) >= defaultDataBalancePlanLimit || !dataNodeOverLoad(src, average) {
+			return
+		}
+		if candidate.size == 0 {
+			continue
+		}
+		disk := disks[candidate.disk]
+		if src.Estimate <= average+defaultDataBalanceRatioGap && (disk == nil || disk.Ratio <= average+defaultDataBalanceRatioGap) {
+			// only the partitions on the disks over loaded are moved
+			continue
+		}
+		dst := c.findDataBalanceDestination(candidate, nodes, average)
+		if dst == nil {
+			continue
+		}
+
+		src.Estimate -= float64(candidate.size) / float64(src.Total)
+		if disk != nil && disk.Used >= candidate.size {
+			disk.Used -= candidate.size
+			disk.Ratio = float64(disk.Used) / float64(disk.Total)
+		}
+		dst.Estimate += float64(candidate.size) / float64(dst.Total)
+		planned[candidate.dp.PartitionID] = struct{}{}
+		plan.Plan = append(plan.Plan, &proto.DataBalancePlan{
+			ID:           candidate.dp.PartitionID,
+			VolName:      candidate.dp.VolName,
+			Size:         candidate.size,
+			Source:       src.Addr,
+			SrcDisk:      candidate.disk,
+			SrcNodeSetId: src.NodeSetID,
+			Destination:  dst.Addr,
+			DstNodeSetId: dst.NodeSetID,
+			ZoneName:     src.ZoneName,
+			Status:       PlanTaskInit,
+		})
+	}
+}
+
+// findDataBalanceDestination returns the node with the lowest usage ratio, which is still under the average
+// of the zone after the partition is moved in.
+func (c *Cluster) findDataBalanceDestination(candidate *dataBalanceCandidate, nodes []*proto.DataNodeBalanceInfo,
+	average float64,
+) (dst *proto.DataNodeBalanceInfo) {
+	candidate.dp.RLock()
+	hosts := make([]string, len(candidate.dp.Hosts))
+	copy(hosts, candidate.dp.Hosts)
+	candidate.dp.RUnlock()
+
+	for _, node := range nodes {
+		if contains(hosts, node.Addr) {
+			continue
+		}
+		if node.Estimate+float64(candidate.size)/float64(node.Total) > average {
+			continue
+		}
+		if dst != nil && dst.Estimate <= node.Estimate {
+			continue
+		}
+		dataNode, err := c.dataNode(node.Addr)
+		if err != nil || !dataNode.canAllocDp() {
+			continue
+		}
+		if err = c.checkSpreadTarget(candidate.dp.VolName, TypeDataPartition, hosts, node.Addr); err != nil {
+			continue
+		}
+		dst = node
+	}
+	return
+}
+
+func (c *Cluster) RunDataPartitionBalanceTask() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.DataPlanRun {
+		return nil
+	}
+
+	plan, err := c.loadDataBalanceTask()
+	if err != nil {
+		log.LogErrorf("loadDataBalanceTask err: %s", err.Error())
+		return fmt.Errorf("can't find data partition balance task in raft storage: %s", err.Error())
+	}
+	if plan.Status == PlanTaskDone {
+		return fmt.Errorf("data partition balance task is done")
+	}
+
+	plan.Status = PlanTaskRun
+	err = c.syncUpdateDataBalanceTask(plan)
+	if err != nil {
+		log.LogErrorf("syncUpdateDataBalanceTask err: %s", err.Error())
+		return err
+	}
+
+	c.DataPlanRun = true
+	go c.DoDataPartitionBalanceTask(plan)
+
+	return nil
+}
+
+// DoDataPartitionBalanceTask migrates the data partitions in the plan, no more than plan.Parallel at the same time.
+func (c *Cluster) DoDataPartitionBalanceTask(plan *proto.DataClusterPlan) {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+	)
+
+	parallel := plan.Parallel
+	if parallel <= 0 {
+		parallel = defaultDataBalanceParallel
+	}
+	limitCh := make(chan struct{}, parallel)
+
+	for _, dpPlan := range plan.Plan {
+		if dpPlan.Status == PlanTaskDone || dpPlan.Status == PlanTaskError {
+			continue
+		}
+		limitCh <- struct{}{}
+		if !c.DataPlanRun || c.partition == nil || !c.partition.IsRaftLeader() {
+			<-limitCh
+			break
+		}
+
+		mu.Lock()
+		dpPlan.Status = PlanTaskRun
+		c.updateDataBalanceTask(plan)
+		mu.Unlock()
+
+		wg.Add(1)
+		go func(dpPlan *proto.DataBalancePlan) {
+			defer func() {
+				<-limitCh
+				wg.Done()
+			}()
+
+			err := c.migrateDataBalancePlan(dpPlan)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.LogErrorf("DoDataPartitionBalanceTask: migrate dp(%d) from %s to %s err: %s",
+					dpPlan.ID, dpPlan.Source, dpPlan.Destination, err.Error())
+				failed++
+				dpPlan.Status = PlanTaskError
+				dpPlan.Msg = err.Error()
+			} else {
+				dpPlan.Status = PlanTaskDone
+				plan.DoneNum++
+			}
+			c.updateDataBalanceTask(plan)
+		}(dpPlan)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	defer func() {
+		c.DataPlanRun = false
+	}()
+
+	if c.partition == nil || !c.partition.IsRaftLeader() {
+		plan.Msg = "master leader is changed"
+		return
+	}
+	if !c.DataPlanRun {
+		plan.Status = PlanTaskStop
+		plan.Msg = "migrate plan is stopped"
+		c.updateDataBalanceTask(plan)
+		return
+	}
+
+	plan.Status = PlanTaskDone
+	if failed > 0 {
+		plan.Msg = fmt.Sprintf("%d data partitions failed to migrate", failed)
+	}
+	plan.Expire = time.Now().Add(defaultPlanExpireHours * time.Hour)
+	c.updateDataBalanceTask(plan)
+}
+
+func (c *Cluster) updateDataBalanceTask(plan *proto.DataClusterPlan) {
+	if err := c.syncUpdateDataBalanceTask(plan); err != nil {
+		log.LogErrorf("syncUpdateDataBalanceTask error: %s", err.Error())
+		plan.Msg = err.Error()
+	}
+}
+
+func (c *Cluster) migrateDataBalancePlan(dpPlan *proto.DataBalancePlan) (err error) {
+	dp, err := c.getDataPartitionByID(dpPlan.ID)
+	if err != nil {
+		return
+	}
+
+	dp.RLock()
+	sourceChanged := !dp.hasHost(dpPlan.Source) || dp.hasHost(dpPlan.Destination)
+	dp.RUnlock()
+	if sourceChanged {
+		return fmt.Errorf("skip rebalance data partition(%d) because hosts changed", dpPlan.ID)
+	}
+	if dp.isRecover || dp.IsDecommissionRunning() {
+		return fmt.Errorf("skip rebalance data partition(%d) because it is recovering or decommissioning", dpPlan.ID)
+	}
+
+	dataNode, err := c.dataNode(dpPlan.Destination)
+	if err != nil {
+		return
+	}
+	if !dataNode.canAllocDp() {
+		return fmt.Errorf("destination %s can't alloc data partition", dpPlan.Destination)
+	}
+
+	log.LogDebugf("Start to migrate data partition(%d) from %s to %s", dpPlan.ID, dpPlan.Source, dpPlan.Destination)
+	if err = c.migrateDataPartition(dpPlan.Source, dpPlan.Destination, dp, false, "data partition balance"); err != nil {
+		return
+	}
+	rstMsg := fmt.Sprintf("migrate data partition(%d) from %s to %s", dpPlan.ID, dpPlan.Source, dpPlan.Destination)
+	auditlog.LogMasterOp("migrateDataPartition", rstMsg, nil)
+
+	return c.WaitForDataPartitionMigrateDone(dp)
+}
+
+func (c *Cluster) WaitForDataPartitionMigrateDone(dp *DataPartition) error {
+	ticker := time.NewTicker(time.Duration(defaultDataBalanceCheckInterval) * time.Second)
+	defer ticker.Stop()
+
+	timeout := time.After(defaultDataBalanceRecoverTimeout)
+	for {
+		select {
+		case <-ticker.C:
+			if !dp.isRecover {
+				return nil
+			}
+			if !c.DataPlanRun {
+				// the replica keeps recovering even though the task is stopped
+				return nil
+			}
+		case <-timeout:
+			return fmt.Errorf("Waiting for data partition(%d) recovering timeout", dp.PartitionID)
+		case <-c.stopc:
+			return fmt.Errorf("cluster is stopping")
+		}
+	}
+}
+
+func (c *Cluster) StopDataPartitionBalanceTask() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.DataPlanRun {
+		return fmt.Errorf("Data balance task is not running")
+	}
+
+	c.DataPlanRun = false
+	return nil
+}
+
+func (c *Cluster) RestartDataPartitionBalanceTask() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.DataPlanRun {
+		return nil
+	}
+
+	plan, err := c.loadDataBalanceTask()
+	if err != nil {
+		if err != proto.ErrNoDpMigratePlan {
+			log.LogErrorf("loadDataBalanceTask err: %s", err.Error())
+		}
+		return err
+	}
+
+	if plan.Status == PlanTaskDone {
+		if plan.Expire.Before(time.Now()) {
+			if err = c.syncDeleteDataBalanceTask(); err != nil {
+				log.LogErrorf("syncDeleteDataBalanceTask err: %s", err.Error())
+				return err
+			}
+		}
+		return nil
+	}
+
+	if plan.Status != PlanTaskRun {
+		// No start the plan task if the status is not running.
+		return nil
+	}
+
+	c.DataPlanRun = true
+	go c.DoDataPartitionBalanceTask(plan)
+
+	return nil
+}
+
+func (c *Cluster) DeleteDataPartitionBalanceTask() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.DataPlanRun {
+		return fmt.Errorf("Please stop the running task before deleting it.")
+	}
+
+	err := c.syncDeleteDataBalanceTask()
+	if err != nil {
+		log.LogErrorf("syncDeleteDataBalanceTask err: %s", err.Error())
+	}
+
+	return err
+}
+
+func (c *Cluster) CreateDataPartitionBalanceTask(parallel int) (*proto.DataClusterPlan, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Only store one plan
+	plan, err := c.loadDataBalanceTask()
+	if err == nil && plan != nil {
+		return plan, fmt.Errorf("There is a data partition task plan already. Please remove it before create a new one.")
+	}
+
+	if plan, err = c.CreateDataPartitionMigratePlan(parallel); err != nil {
+		return plan, err
+	}
+	if plan.Total <= 0 {
+		return plan, fmt.Errorf("Not find data node that needs partition rebalance.")
+	}
+	plan.Type = ManualPlan
+
+	if err = c.syncAddDataBalanceTask(plan); err != nil {
+		return plan, err
+	}
+
+	log.LogInfof("Create data partition migrating plan, total %d", plan.Total)
+	return plan, nil
+}
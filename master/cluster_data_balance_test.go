@@ -0,0 +1,106 @@
+package master
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+)
+
+func newDataBalanceTestCluster(used ...uint64) *Cluster {
+	c := &Cluster{vols: make(map[string]*Vol)}
+	for i, u := range used {
+		dataNode := &DataNode{
+			ID:             uint64(i + 1),
+			Addr:           fmt.Sprintf("192.168.1.%d:17310", i+1),
+			ZoneName:       "testZone",
+			NodeSetID:      1,
+			Total:          1000 * util.GB,
+			Used:           u * util.GB,
+			AvailableSpace: (1000 - u) * util.GB,
+			isActive:       true,
+		}
+		c.dataNodes.Store(dataNode.Addr, dataNode)
+	}
+	return c
+}
+
+func addDataBalanceTestPartition(c *Cluster, id uint64, size uint64, hosts ...string) {
+	vol, ok := c.vols["testVol"]
+	if !ok {
+		vol = &Vol{Name: "testVol", Status: proto.VolStatusNormal, dataPartitions: newDataPartitionMap("testVol")}
+		c.vols[vol.Name] = vol
+	}
+	dp := newDataPartition(id, uint8(len(hosts)), vol.Name, 1, proto.PartitionTypeNormal, proto.MediaType_HDD)
+	dp.Hosts = hosts
+	for _, host := range hosts {
+		dp.Replicas = append(dp.Replicas, &DataReplica{DataReplica: proto.DataReplica{Addr: host, Used: size * util.GB, DiskPath: "/disk1"}})
+	}
+	vol.dataPartitions.put(dp)
+}
+
+func TestCreateDataPartitionMigratePlan(t *testing.T) {
+	// three full nodes and a new one, the average usage ratio is 0.6
+	c := newDataBalanceTestCluster(800, 800, 800, 0)
+	hosts := []string{"192.168.1.1:17310", "192.168.1.2:17310", "192.168.1.3:17310"}
+	for id := uint64(1); id <= 8; id++ {
+		addDataBalanceTestPartition(c, id, 100, hosts...)
+	}
+
+	plan, err := c.CreateDataPartitionMigratePlan(2)
+	if err != nil {
+		t.Fatalf("CreateDataPartitionMigratePlan err: %v", err)
+	}
+	if plan.Parallel != 2 || len(plan.Nodes) != 4 {
+		t.Errorf("Expected parallel 2 and 4 nodes, but got %d and %d", plan.Parallel, len(plan.Nodes))
+	}
+	// each full node moves one partition out to get the usage ratio under 0.7
+	if plan.Total != 3 || len(plan.Plan) != 3 {
+		t.Fatalf("Expected 3 partitions to migrate, but got %d", plan.Total)
+	}
+	sources := make(map[string]struct{})
+	partitions := make(map[uint64]struct{})
+	for _, dpPlan := range plan.Plan {
+		if dpPlan.Destination != "192.168.1.4:17310" {
+			t.Errorf("Expected destination is the new node, but got %s", dpPlan.Destination)
+		}
+		if dpPlan.Status != PlanTaskInit || dpPlan.Size != 100*util.GB {
+			t.Errorf("Unexpected plan %v", dpPlan)
+		}
+		sources[dpPlan.Source] = struct{}{}
+		partitions[dpPlan.ID] = struct{}{}
+	}
+	if len(sources) != 3 || len(partitions) != 3 {
+		t.Errorf("Expected 3 distinct sources and partitions, but got %v and %v", sources, partitions)
+	}
+}
+
+func TestCreateDataPartitionMigratePlanBalanced(t *testing.T) {
+	c := newDataBalanceTestCluster(600, 550, 500)
+	addDataBalanceTestPartition(c, 1, 100, "192.168.1.1:17310", "192.168.1.2:17310", "192.168.1.3:17310")
+
+	plan, err := c.CreateDataPartitionMigratePlan(defaultDataBalanceParallel)
+	if err != nil {
+		t.Fatalf("CreateDataPartitionMigratePlan err: %v", err)
+	}
+	if plan.Total != 0 {
+		t.Errorf("Expected no partition to migrate, but got %d", plan.Total)
+	}
+}
+
+func TestDataNodeOverLoad(t *testing.T) {
+	node := &proto.DataNodeBalanceInfo{Estimate: 0.65}
+	if dataNodeOverLoad(node, 0.6) {
+		t.Errorf("Expected node under the gap is not over load")
+	}
+	node.Disks = []*proto.DiskBalanceInfo{{Path: "/disk1", Ratio: 0.95}}
+	if !dataNodeOverLoad(node, 0.6) {
+		t.Errorf("Expected node with the disk over the gap is over load")
+	}
+	node.Disks = nil
+	node.Estimate = 0.75
+	if !dataNodeOverLoad(node, 0.6) {
+		t.Errorf("Expected node over the gap is over load")
+	}
+}
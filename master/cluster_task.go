@@ -105,6 +105,7 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 		finalHosts      []string
 		oldHosts        []string
 		zones           []string
+		spread          *spreadConstraint
 	)
 
 	log.LogWarnf("action[migrateMetaPartition],volName[%v], migrate from src[%s] to target[%s],partitionID[%v] begin",
@@ -142,11 +143,17 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 		goto errHandler
 	}
 
+	// the replicas kept take their failure domains, the new one is placed in the others
+	spread = c.newSpreadConstraint(mp.volName, TypeMetaPartition, keptHosts(oldHosts, srcAddr))
+
 	if targetAddr != "" {
 		newPeers = []proto.Peer{{
 			Addr: targetAddr,
 		}}
-	} else if _, newPeers, err = ns.getAvailMetaNodeHosts(oldHosts, 1); err != nil {
+		if err = c.checkSpreadTarget(mp.volName, TypeMetaPartition, keptHosts(oldHosts, srcAddr), targetAddr); err != nil {
+			goto errHandler
+		}
+	} else if _, newPeers, err = ns.getAvailMetaNodeHosts(oldHosts, 1, spread); err != nil {
 		if _, ok := c.vols[mp.volName]; !ok {
 			log.LogWarnf("[migrateMetaPartition] clusterID[%v] partitionID:%v  on node:[%v]",
 				c.Name, mp.PartitionID, mp.Hosts)
@@ -159,7 +166,7 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 		}
 		// choose a meta node in other node set in the same zone
 		excludeNodeSets = append(excludeNodeSets, ns.ID)
-		if _, newPeers, err = zone.getAvailNodeHosts(TypeMetaPartition, excludeNodeSets, oldHosts, 1); err != nil {
+		if _, newPeers, err = zone.getAvailNodeHosts(TypeMetaPartition, excludeNodeSets, oldHosts, 1, spread); err != nil {
 			zones = mp.getLiveZones(srcAddr)
 			var excludeZone []string
 			if len(zones) == 0 {
@@ -168,7 +175,7 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 				excludeZone = append(excludeZone, zones[0])
 			}
 			// choose a meta node in other zone
-			if _, newPeers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, excludeNodeSets, oldHosts, 1, 1, "", proto.MediaType_Unspecified); err != nil {
+			if _, newPeers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, excludeNodeSets, oldHosts, 1, 1, "", proto.MediaType_Unspecified, spread); err != nil {
 				goto errHandler
 			}
 		}
@@ -1217,7 +1224,7 @@ func (c *Cluster) updateInodeIDUpperBound(mp *MetaPartition, mr *proto.MetaParti
 	}
 
 	maxPartitionID := vol.maxMetaPartitionID()
-	if mr.PartitionID < maxPartitionID {
+	if mr.PartitionID != maxPartitionID {
 		return
 	}
 	var end uint64
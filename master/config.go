@@ -204,6 +204,9 @@ type clusterConfig struct {
 	metaNodeGOGC int
 	dataNodeGOGC int
 
+	tlsMode  string // the TLS mode distributed to the nodes and the clients, empty to keep their local config
+	tlsCAPem string // the CA bundle distributed to the nodes and the clients
+
 	metaNodeMemHighPer float64
 	metaNodeMemLowPer  float64
 	metaNodeMemMidPer  float64
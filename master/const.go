@@ -28,7 +28,10 @@ const (
 	diskPathKey             = "disk"
 	nameKey                 = "name"
 	idKey                   = "id"
+	statusKey               = "status"
 	countKey                = "count"
+	parallelKey             = "parallel"
+	splitInodeKey           = "splitKey"
 	enableKey               = "enable"
 	thresholdKey            = "threshold"
 	volDeletionDelayTimeKey = "volDeletionDelayTime"
@@ -66,6 +69,7 @@ const (
 	crossZoneKey                           = "crossZone"
 	normalZonesFirstKey                    = "normalZonesFirst"
 	userKey                                = "user"
+	roleNameKey                            = "role"
 	nodeDeleteBatchCountKey                = "batchCount"
 	nodeMarkDeleteRateKey                  = "markDeleteRate"
 	nodeDeleteWorkerSleepMs                = "deleteWorkerSleepMs"
@@ -78,6 +82,7 @@ const (
 	maxMpCntLimitKey                       = "maxMpCntLimit"
 	clusterCreateTimeKey                   = "clusterCreateTime"
 	descriptionKey                         = "description"
+	spreadLabelKey                         = "spreadLabel"
 	dpSelectorNameKey                      = "dpSelectorName"
 	dpSelectorParmKey                      = "dpSelectorParm"
 	nodeTypeKey                            = "nodeType"
@@ -138,6 +143,7 @@ const (
 	autoDecommissionDiskIntervalKey        = "autoDecommissionDiskInterval"
 	autoDpMetaRepairKey                    = "autoDpMetaRepair"
 	autoDpMetaRepairParallelCntKey         = "autoDpMetaRepairParallelCnt"
+	spreadRepairKey                        = "spreadRepair"
 	dpTimeoutKey                           = "dpTimeout"
 	mpTimeoutKey                           = "mpTimeout"
 	ShowAll                                = "showAll"
@@ -246,6 +252,14 @@ const (
 	metaNodeReserveMemorySize                     = 3 * 1024 * 1024 * 1024
 	metaNodeMemoryRatio                           = 2
 	defaultPlanExpireHours                        = 72
+	defaultDataBalanceParallel                    = 5
+	maxDataBalanceParallel                        = 50
+	defaultDataBalanceRatioGap                    = 0.1 // the usage ratio over the average of the zone to balance
+	defaultDataBalancePlanLimit                   = 1000
+	defaultDataBalanceCheckInterval               = 5
+	defaultDataBalanceRecoverTimeout              = 24 * time.Hour
+	defaultRestoreTaskTimeout                     = 6 * time.Hour // dispatch the restore task again if no response
+	defaultIntervalToCheckRestoreRecords          = time.Minute
 	defaultGOGCLowerLimit                         = 30
 	defaultGOGCUpperLimit                         = 100
 	lowPriorityDecommissionWeight                 = 2
@@ -335,6 +349,9 @@ const (
 	opSyncAddLcResult    uint32 = 0x3a
 	opSyncDeleteLcResult uint32 = 0x3b
 
+	opSyncPutRestoreRecord    uint32 = 0x3c
+	opSyncDeleteRestoreRecord uint32 = 0x3d
+
 	opSyncAllocQuotaID uint32 = 0x40
 	opSyncSetQuota     uint32 = 0x41
 	opSyncDeleteQuota  uint32 = 0x42
@@ -355,6 +372,22 @@ const (
 
 	opSyncAddFlashManualTask    uint32 = 0x72
 	opSyncDeleteFlashManualTask uint32 = 0x73
+
+	opSyncAddRoleInfo    uint32 = 0x74
+	opSyncDeleteRoleInfo uint32 = 0x75
+	opSyncUpdateRoleInfo uint32 = 0x76
+
+	opSyncAddBatchJob    uint32 = 0x77
+	opSyncDeleteBatchJob uint32 = 0x78
+
+	opSyncPutInventory    uint32 = 0x79
+	opSyncDeleteInventory uint32 = 0x7A
+
+	opSyncAddDataBalanceTask    uint32 = 0x7B
+	opSyncUpdateDataBalanceTask uint32 = 0x7C
+
+	opSyncAddAPIToken    uint32 = 0x7D
+	opSyncDeleteAPIToken uint32 = 0x7E
 )
 
 func init() {
@@ -390,6 +423,11 @@ func init() {
 		opSyncAddVolUser,
 		opSyncDeleteVolUser,
 		opSyncUpdateVolUser,
+		opSyncAddRoleInfo,
+		opSyncDeleteRoleInfo,
+		opSyncUpdateRoleInfo,
+		opSyncAddAPIToken,
+		opSyncDeleteAPIToken,
 		opSyncNodeSetGrp,
 		opSyncDataPartitionsView,
 		opSyncExclueDomain,
@@ -425,6 +463,15 @@ func init() {
 
 		opSyncS3QosSet,
 		opSyncS3QosDelete,
+
+		opSyncAddBatchJob,
+		opSyncDeleteBatchJob,
+
+		opSyncPutInventory,
+		opSyncDeleteInventory,
+
+		opSyncPutRestoreRecord,
+		opSyncDeleteRestoreRecord,
 	} {
 		if _, in := set[op]; in {
 			panic(op)
@@ -450,6 +497,7 @@ const (
 	lcConfigurationAcronym = "lc"
 	lcTaskAcronym          = "lct"
 	lcResultAcronym        = "lcr"
+	restoreRecordAcronym   = "rst"
 	S3QoS                  = "s3qos"
 	maxDataPartitionIDKey  = keySeparator + "max_dp_id"
 	maxMetaPartitionIDKey  = keySeparator + "max_mp_id"
@@ -475,15 +523,20 @@ const (
 	akAcronym        = "ak"
 	userAcronym      = "user"
 	volUserAcronym   = "voluser"
+	roleAcronym      = "role"
+	apiTokenAcronym  = "apitoken"
 	akPrefix         = keySeparator + akAcronym + keySeparator
 	userPrefix       = keySeparator + userAcronym + keySeparator
 	volUserPrefix    = keySeparator + volUserAcronym + keySeparator
+	rolePrefix       = keySeparator + roleAcronym + keySeparator
+	apiTokenPrefix   = keySeparator + apiTokenAcronym + keySeparator
 	volWarnUsedRatio = 0.9
 	quotaPrefix      = keySeparator + "quota" + keySeparator
 	lcNodePrefix     = keySeparator + lcNodeAcronym + keySeparator
 	lcConfPrefix     = keySeparator + lcConfigurationAcronym + keySeparator
 	lcTaskPrefix     = keySeparator + lcTaskAcronym + keySeparator
 	lcResultPrefix   = keySeparator + lcResultAcronym + keySeparator
+	restorePrefix    = keySeparator + restoreRecordAcronym + keySeparator
 	S3QoSPrefix      = keySeparator + S3QoS + keySeparator
 
 	DecommissionDiskAcronym = "dd"
@@ -492,8 +545,11 @@ const (
 	flashNodePrefix       = keySeparator + "fn" + keySeparator
 	flashGroupPrefix      = keySeparator + "fg" + keySeparator
 	flashManualTaskPrefix = keySeparator + "flt" + keySeparator
+	batchJobPrefix        = keySeparator + "bj" + keySeparator
+	inventoryPrefix       = keySeparator + "inv" + keySeparator
 
-	balanceTaskKey = keySeparator + "balanceTask"
+	balanceTaskKey     = keySeparator + "balanceTask"
+	dataBalanceTaskKey = keySeparator + "dataBalanceTask"
 )
 
 // selector enum
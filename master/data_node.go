@@ -38,7 +38,8 @@ type DataNode struct {
 	Used                               uint64 `json:"UsedWeight"`
 	AvailableSpace                     uint64
 	ID                                 uint64
-	ZoneName                           string `json:"Zone"`
+	ZoneName                           string            `json:"Zone"`
+	Labels                             map[string]string // topology labels reported by the heartbeat
 	Addr                               string
 	HeartbeatPort                      string `json:"HeartbeatPort"`
 	ReplicaPort                        string `json:"ReplicaPort"`
@@ -223,6 +224,7 @@ func (dataNode *DataNode) updateNodeMetric(c *Cluster, resp *proto.DataNodeHeart
 		dataNode.LastUpdateTime = time.Now()
 	}
 	dataNode.ZoneName = resp.ZoneName
+	dataNode.Labels = resp.Labels
 	dataNode.DataPartitionCount = resp.CreatedPartitionCnt
 	dataNode.DataPartitionReports = resp.PartitionReports
 	dataNode.TotalPartitionSize = resp.TotalPartitionSize
@@ -459,6 +461,12 @@ func (dataNode *DataNode) GetZoneName() string {
 	return dataNode.ZoneName
 }
 
+func (dataNode *DataNode) GetLabels() map[string]string {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	return dataNode.Labels
+}
+
 // SelectNodeForWrite implements "SelectNodeForWrite" in the Node interface
 func (dataNode *DataNode) SelectNodeForWrite() {
 	dataNode.Lock()
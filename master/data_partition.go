@@ -1327,6 +1327,10 @@ func (partition *DataPartition) MarkDecommissionStatus(srcAddr, dstAddr, srcDisk
 			log.LogErrorf("[MarkDecommissionStatus] check mediaType err: %v", err.Error())
 			return
 		}
+		if err = c.checkSpreadTarget(partition.VolName, TypeDataPartition, keptHosts(partition.Hosts, srcAddr), dstAddr); err != nil {
+			log.LogErrorf("[MarkDecommissionStatus] partitionID: %v check spread err: %v", partition.PartitionID, err.Error())
+			return
+		}
 	}
 
 	if dstNodeSetID != 0 {
@@ -2255,8 +2259,10 @@ func (partition *DataPartition) TryAcquireDecommissionToken(c *Cluster) bool {
 		}
 		log.LogDebugf("action[TryAcquireDecommissionToken]dp %v excludeHosts %v",
 			partition.PartitionID, excludeHosts)
+		// the replicas kept take their failure domains, the new one is placed in the others
+		spread := c.newSpreadConstraint(partition.VolName, TypeDataPartition, keptHosts(partition.Hosts, partition.DecommissionSrcAddr))
 		// data nodes in a nodeset has the same mediaType
-		targetHosts, _, err = ns.getAvailDataNodeHosts(excludeHosts, 1)
+		targetHosts, _, err = ns.getAvailDataNodeHosts(excludeHosts, 1, spread)
 		if err != nil {
 			if partition.DecommissionDstNodeSet != 0 {
 				log.LogWarnf("action[TryAcquireDecommissionToken] dp %v choose from given dst nodeset %v failed:%v",
@@ -2279,12 +2285,12 @@ func (partition *DataPartition) TryAcquireDecommissionToken(c *Cluster) bool {
 			}
 			excludeNodeSets = append(excludeNodeSets, ns.ID)
 			// data nodes in a zone has the same mediaType
-			if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets, excludeHosts, 1); err != nil {
+			if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets, excludeHosts, 1, spread); err != nil {
 				log.LogWarnf("action[TryAcquireDecommissionToken] dp %v choose from other nodeset failed:%v",
 					partition.PartitionID, err.Error())
 				// select data nodes from the other zone
 				zones = partition.getLiveZones(partition.DecommissionSrcAddr)
-				if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, zones, excludeNodeSets, excludeHosts, 1, 1, "", partition.MediaType); err != nil {
+				if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, zones, excludeNodeSets, excludeHosts, 1, 1, "", partition.MediaType, spread); err != nil {
 					log.LogWarnf("action[TryAcquireDecommissionToken] dp %v choose from other zone failed:%v",
 						partition.PartitionID, err.Error())
 					goto errHandler
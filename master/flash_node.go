@@ -196,6 +196,7 @@ func (c *Cluster) checkFlashNodeHeartbeat() {
 		node := flashNode.(*FlashNode)
 		node.checkLiveliness()
 		task := node.createHeartbeatTask(c.masterAddr(), c.cfg.flashNodeHandleReadTimeout, c.cfg.flashNodeReadDataNodeTimeout)
+		task.Request.(*proto.HeartBeatRequest).TLSTrustInfo = c.getTLSTrust()
 		tasks = append(tasks, task)
 		return true
 	})
@@ -41,6 +41,9 @@ func (m *Server) startHTTPService(modulename string, cfg *config.Config) {
 	if m.cluster.authenticate {
 		m.registerAuthenticationMiddleware(router)
 	}
+	if m.enableAPIToken {
+		m.registerAPITokenMiddleware(router)
+	}
 	exporter.InitWithRouter(modulename, cfg, router, m.port)
 	addr := fmt.Sprintf(":%s", m.port)
 	if m.bindIp {
@@ -225,6 +228,11 @@ var AuthenticationUri2MsgTypeMap = map[string]proto.MsgType{
 	proto.UserRemovePolicy:    proto.MsgMasterUserRemovePolicyReq,
 	proto.UserDeleteVolPolicy: proto.MsgMasterUserDeleteVolPolicyReq,
 	proto.UserTransferVol:     proto.MsgMasterUserTransferVolReq,
+	proto.UserRoleCreate:      proto.MsgMasterUserRoleCreateReq,
+	proto.UserRoleUpdate:      proto.MsgMasterUserRoleUpdateReq,
+	proto.UserRoleDelete:      proto.MsgMasterUserRoleDeleteReq,
+	proto.UserRoleGetInfo:     proto.MsgMasterUserRoleGetReq,
+	proto.UserRoleList:        proto.MsgMasterUserRoleListReq,
 
 	// Master API zone management
 	proto.UpdateZone: proto.MsgMasterUpdateZoneReq,
@@ -251,6 +259,31 @@ func (m *Server) registerAuthenticationMiddleware(router *mux.Router) {
 	router.Use(authenticationInterceptor)
 }
 
+// registerAPITokenMiddleware checks the API token of the requests is granted the role required by the API,
+// the requests are checked by the leader after they are proxied, except the ones read from the followers.
+func (m *Server) registerAPITokenMiddleware(router *mux.Router) {
+	apiTokenInterceptor := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				required, exempt := apiTokenRoleOf(r.URL.Path)
+				if exempt {
+					next.ServeHTTP(w, r)
+					return
+				}
+				name, err := m.checkAPIToken(r, required)
+				AuditLog(r, "checkAPIToken", fmt.Sprintf("token[%v] path[%v] required role[%v]", name, r.URL.Path, required), err)
+				if err != nil {
+					log.LogWarnf("action[apiTokenInterceptor] remote[%v] token[%v] path[%v] required role[%v], err[%v]",
+						r.RemoteAddr, name, r.URL.Path, required, err)
+					sendErrReply(w, r, newErrHTTPReply(err))
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+	}
+	router.Use(apiTokenInterceptor)
+}
+
 func (m *Server) registerAPIRoutes(router *mux.Router) {
 	// graphql api for cluster
 	cs := &ClusterService{user: m.user, cluster: m.cluster, conf: m.config, leaderInfo: m.leaderInfo}
@@ -362,6 +395,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminQueryDecommissionToken).
 		HandlerFunc(m.queryDecommissionToken)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetSpreadViolations).
+		HandlerFunc(m.getSpreadViolations)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetFileStats).
 		HandlerFunc(m.setFileStats)
@@ -496,9 +532,38 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.DeleteBucketLifecycle).
 		HandlerFunc(m.DelBucketLifecycle)
+
+	// S3 inventory configuration APIS
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.SetBucketInventory).
+		HandlerFunc(m.SetBucketInventory)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.GetBucketInventory).
+		HandlerFunc(m.GetBucketInventory)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.DeleteBucketInventory).
+		HandlerFunc(m.DelBucketInventory)
+
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AddLcNode).
 		HandlerFunc(m.addLcNode)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.RestoreObject).
+		HandlerFunc(m.restoreObject)
+
+	// APIs for S3 batch operations
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.BatchJobCreate).
+		HandlerFunc(m.createBatchJob)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.BatchJobGet).
+		HandlerFunc(m.getBatchJob)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.BatchJobList).
+		HandlerFunc(m.listBatchJobs)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.BatchJobUpdateStatus).
+		HandlerFunc(m.updateBatchJobStatus)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminLcNode).
 		HandlerFunc(m.adminLcNode)
@@ -527,6 +592,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminBalanceMetaPartitionLeader).
 		HandlerFunc(m.balanceMetaPartitionLeader)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSplitMetaPartition).
+		HandlerFunc(m.splitMetaPartition)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.ClientMetaPartitions).
 		HandlerFunc(m.getMetaPartitions)
@@ -602,6 +670,21 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.DeleteMetaNodeBalanceTask).
 		HandlerFunc(m.deleteMetaNodeBalancePlan)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.CreateDataNodeBalanceTask).
+		HandlerFunc(m.createDataNodeBalancePlan)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.GetDataNodeBalanceTask).
+		HandlerFunc(m.getDataNodeBalancePlan)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.RunDataNodeBalanceTask).
+		HandlerFunc(m.runDataNodeBalancePlan)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.StopDataNodeBalanceTask).
+		HandlerFunc(m.stopDataNodeBalancePlan)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.DeleteDataNodeBalanceTask).
+		HandlerFunc(m.deleteDataNodeBalancePlan)
 
 	// data partition management APIs
 	router.NewRoute().Methods(http.MethodGet).
@@ -675,6 +758,12 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetDataNodeGOGC).
 		HandlerFunc(m.setDataNodeGOGC)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.AdminSetTLSTrust).
+		HandlerFunc(m.setTLSTrust)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetTLSTrust).
+		HandlerFunc(m.getTLSTrust)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminAddDataReplica).
 		HandlerFunc(m.addDataReplica)
@@ -871,6 +960,32 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.UsersOfVol).
 		HandlerFunc(m.getUsersOfVol)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.UserRoleCreate).
+		HandlerFunc(m.createUserRole)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.UserRoleUpdate).
+		HandlerFunc(m.updateUserRole)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserRoleDelete).
+		HandlerFunc(m.deleteUserRole)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.UserRoleGetInfo).
+		HandlerFunc(m.getUserRole)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.UserRoleList).
+		HandlerFunc(m.getUserRoles)
+
+	// API token management APIs
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminAPITokenCreate).
+		HandlerFunc(m.createAPIToken)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminAPITokenDelete).
+		HandlerFunc(m.deleteAPIToken)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminAPITokenList).
+		HandlerFunc(m.listAPITokens)
 
 	// zone management APIs
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
@@ -922,6 +1037,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodDelete, http.MethodPost).
 		Path(proto.S3QoSDelete).
 		HandlerFunc(m.S3QosDelete)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.S3QoSLease).
+		HandlerFunc(m.S3QosLease)
 
 	// APIs for FlashNode
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).Path(proto.FlashNodeAdd).HandlerFunc(m.addFlashNode)
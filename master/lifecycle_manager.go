@@ -33,20 +33,25 @@ type lifecycleManager struct {
 	startTime        *time.Time // start or stop scan must wait 12s when master leader change
 	cluster          *Cluster
 	lcConfigurations map[string]*proto.LcConfiguration
+	inventories      map[string]*proto.BucketInventory
 	lcNodeStatus     *lcNodeStatus
 	lcRuleTaskStatus *lcRuleTaskStatus
 	idleLcNodeCh     chan string
 	exitCh           chan struct{}
+	restoreMutex     sync.Mutex
+	restoreRecords   map[string]*restoreRecord // key is volName#inode
 }
 
 func newLifecycleManager() *lifecycleManager {
 	log.LogInfof("action[newLifecycleManager] construct")
 	lcMgr := &lifecycleManager{
 		lcConfigurations: make(map[string]*proto.LcConfiguration),
+		inventories:      make(map[string]*proto.BucketInventory),
 		lcNodeStatus:     newLcNodeStatus(),
 		lcRuleTaskStatus: newLcRuleTaskStatus(),
 		idleLcNodeCh:     make(chan string),
 		exitCh:           make(chan struct{}),
+		restoreRecords:   make(map[string]*restoreRecord),
 	}
 	return lcMgr
 }
@@ -62,6 +67,10 @@ func exist(task *proto.RuleTask, doing []*proto.LcNodeRuleTaskResponse, todo []*
 			log.LogInfof("startLcScan: exist doing task: %v, skip this task: %v", d, task)
 			return true
 		}
+		// the inventory only reads the objects, which doesn't conflict with other tasks
+		if task.Inventory != nil || d.Inventory != nil {
+			continue
+		}
 		if task.VolName == d.Volume && task.Rule.GetPrefix() == d.Rule.GetPrefix() {
 			log.LogInfof("startLcScan: exist doing task: %v, skip this task: %v", d, task)
 			return true
@@ -80,6 +89,9 @@ func exist(task *proto.RuleTask, doing []*proto.LcNodeRuleTaskResponse, todo []*
 			log.LogInfof("startLcScan: exist todo task: %v, skip this task: %v", t, task)
 			return true
 		}
+		if task.Inventory != nil || t.Inventory != nil {
+			continue
+		}
 		if task.VolName == t.VolName && task.Rule.GetPrefix() == t.Rule.GetPrefix() {
 			log.LogInfof("startLcScan: exist todo task: %v, skip this task: %v", t, task)
 			return true
@@ -219,7 +231,7 @@ func (lcMgr *lifecycleManager) startLcScan(vol, rid string) (success bool, msg s
 	return
 }
 
-// generate all tasks or vol tasks
+// generate all tasks or vol tasks, including the tasks of the inventories
 func (lcMgr *lifecycleManager) genEnabledRuleTasks(vol string) []*proto.RuleTask {
 	lcMgr.RLock()
 	defer lcMgr.RUnlock()
@@ -233,6 +245,13 @@ func (lcMgr *lifecycleManager) genEnabledRuleTasks(vol string) []*proto.RuleTask
 			tasks = append(tasks, ts...)
 		}
 	}
+	now := time.Now()
+	for _, v := range lcMgr.inventories {
+		if vol != "" && v.VolName != vol {
+			continue
+		}
+		tasks = append(tasks, v.GenEnabledInventoryTasks(now)...)
+	}
 	return tasks
 }
 
@@ -249,6 +268,9 @@ func (lcMgr *lifecycleManager) genRuleTask(vol, taskId string) *proto.RuleTask {
 			}
 		}
 	}
+	if inventory := lcMgr.inventories[vol]; inventory != nil {
+		return inventory.GenInventoryTask(taskId)
+	}
 	return nil
 }
 
@@ -394,20 +416,26 @@ func doRequestStopLcScan(cli *http.Client, d *proto.LcNodeRuleTaskResponse) erro
 }
 
 func getLcStopUrl(node, id string) (url string) {
+	return getLcNodeUrl(node, "stopScanner", id)
+}
+
+// getLcNodeUrl returns the url of the api of the lcnode, whose http service listens on the port
+// next to the port of the lcnode.
+func getLcNodeUrl(node, api, id string) (url string) {
 	s := strings.Split(node, ":")
 	if len(s) != 2 {
-		log.LogErrorf("getLcStopUrl id: %v invalid LcNode addr: %v", id, node)
+		log.LogErrorf("getLcNodeUrl id: %v invalid LcNode addr: %v", id, node)
 		return
 	}
 	ip := s[0]
 	port := s[1]
 	portInt, err := strconv.Atoi(port)
 	if err != nil {
-		log.LogErrorf("getLcStopUrl id: %v port: %v err: %v", id, port, err)
+		log.LogErrorf("getLcNodeUrl id: %v port: %v err: %v", id, port, err)
 		return
 	}
-	url = fmt.Sprintf("http://%v:%v/stopScanner?id=%v", ip, portInt+1, id)
-	log.LogInfof("getLcStopUrl: %v", url)
+	url = fmt.Sprintf("http://%v:%v/%v?id=%v", ip, portInt+1, api, id)
+	log.LogInfof("getLcNodeUrl: %v", url)
 	return
 }
 
@@ -541,7 +569,7 @@ func (lcMgr *lifecycleManager) process() {
 			adminTask := node.createLcScanTask(lcMgr.cluster.masterAddr(), task)
 			lcMgr.cluster.addLcNodeTasks([]*proto.AdminTask{adminTask})
 			t := time.Now()
-			lcMgr.lcRuleTaskStatus.AddResult(&proto.LcNodeRuleTaskResponse{ID: task.Id, LcNode: nodeAddr, UpdateTime: &t, Volume: task.VolName, Rule: task.Rule})
+			lcMgr.lcRuleTaskStatus.AddResult(&proto.LcNodeRuleTaskResponse{ID: task.Id, LcNode: nodeAddr, UpdateTime: &t, Volume: task.VolName, Rule: task.Rule, Inventory: task.Inventory})
 			log.LogInfof("add lifecycle scan task(%v) to lcnode(%v)", *task, nodeAddr)
 		}
 	}
@@ -589,6 +617,27 @@ func (lcMgr *lifecycleManager) DelS3BucketLifecycle(VolName string) {
 	delete(lcMgr.lcConfigurations, VolName)
 }
 
+func (lcMgr *lifecycleManager) SetBucketInventory(inventory *proto.BucketInventory) {
+	lcMgr.Lock()
+	defer lcMgr.Unlock()
+
+	lcMgr.inventories[inventory.VolName] = inventory
+}
+
+func (lcMgr *lifecycleManager) GetBucketInventory(VolName string) *proto.BucketInventory {
+	lcMgr.RLock()
+	defer lcMgr.RUnlock()
+
+	return lcMgr.inventories[VolName]
+}
+
+func (lcMgr *lifecycleManager) DelBucketInventory(VolName string) {
+	lcMgr.Lock()
+	defer lcMgr.Unlock()
+
+	delete(lcMgr.inventories, VolName)
+}
+
 //-----------------------------------------------
 
 type OpLcNode interface {
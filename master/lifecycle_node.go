@@ -88,3 +88,24 @@ func (lcNode *LcNode) createSnapshotVerDelTask(masterAddr string, sTask *proto.S
 	task = proto.NewAdminTaskEx(proto.OpLcNodeSnapshotVerDel, lcNode.Addr, request, request.Task.Id)
 	return
 }
+
+func (lcNode *LcNode) createRestoreTask(masterAddr string, rTask *proto.RestoreTask) (task *proto.AdminTask) {
+	request := &proto.LcNodeRestoreTaskRequest{
+		MasterAddr: masterAddr,
+		LcNodeAddr: lcNode.Addr,
+		Task:       rTask,
+	}
+	// the task may be dispatched again to the same lcnode
+	task = proto.NewAdminTaskEx(proto.OpLcNodeRestore, lcNode.Addr, request, fmt.Sprintf("%v_%v", rTask.Id, time.Now().UnixNano()))
+	return
+}
+
+func (lcNode *LcNode) createBatchTask(masterAddr string, bTask *proto.BatchTask) (task *proto.AdminTask) {
+	request := &proto.LcNodeBatchTaskRequest{
+		MasterAddr: masterAddr,
+		LcNodeAddr: lcNode.Addr,
+		Task:       bTask,
+	}
+	task = proto.NewAdminTaskEx(proto.OpLcNodeBatch, lcNode.Addr, request, bTask.Id)
+	return
+}
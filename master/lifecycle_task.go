@@ -16,6 +16,8 @@ package master
 
 import (
 	"fmt"
+	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
@@ -52,6 +54,12 @@ func (c *Cluster) handleLcNodeTaskResponse(nodeAddr string, task *proto.AdminTas
 	case proto.OpLcNodeSnapshotVerDel:
 		response := task.Response.(*proto.SnapshotVerDelTaskResponse)
 		err = c.handleLcNodeSnapshotScanResp(task.OperatorAddr, response)
+	case proto.OpLcNodeRestore:
+		response := task.Response.(*proto.LcNodeRestoreTaskResponse)
+		err = c.handleLcNodeRestoreResp(task.OperatorAddr, response)
+	case proto.OpLcNodeBatch:
+		response := task.Response.(*proto.LcNodeBatchTaskResponse)
+		err = c.handleLcNodeBatchResp(task.OperatorAddr, response)
 	default:
 		err = fmt.Errorf(fmt.Sprintf("lc unknown operate code %v", task.OpCode))
 		goto errHandler
@@ -135,6 +143,9 @@ func (c *Cluster) handleLcNodeHeartbeatResp(nodeAddr string, resp *proto.LcNodeH
 		}
 	}
 
+	// handle BatchTasks
+	c.batchMgr.handleHeartbeat(nodeAddr, resp.BatchTasks)
+
 	log.LogInfof("action[handleLcNodeHeartbeatResp], lcNode[%v], heartbeat success", nodeAddr)
 	return
 }
@@ -203,3 +214,140 @@ func (c *Cluster) handleLcNodeSnapshotScanResp(nodeAddr string, resp *proto.Snap
 
 	return
 }
+
+// restoreRecord is the restore task of the object persisted by the master. The task is dispatched
+// again if no response is received after the timeout, and the record is kept after the object is
+// restored to expire the restored copy on its expiry date.
+type restoreRecord struct {
+	Task         *proto.RestoreTask
+	LcNode       string // the lcnode running the task, empty if no task is running
+	DispatchTime int64  // unix seconds
+	ExpiryDate   int64  // unix seconds, the expiry date of the restored copy once restored
+}
+
+func restoreRecordKey(volName string, inode uint64) string {
+	return volName + keySeparator + strconv.FormatUint(inode, 10)
+}
+
+func (c *Cluster) handleLcNodeRestoreResp(nodeAddr string, resp *proto.LcNodeRestoreTaskResponse) (err error) {
+	switch resp.Status {
+	case proto.TaskFailed:
+		log.LogWarnf("action[handleLcNodeRestoreResp] lcNode[%v] restore failed, resp(%+v)", nodeAddr, resp)
+		auditlog.LogMasterOp("HandleLcNodeRestoreResp", fmt.Sprintf("restore failed: %+v", resp), nil)
+	case proto.TaskSucceeds:
+		log.LogInfof("action[handleLcNodeRestoreResp] lcNode[%v] restore completed, resp(%+v)", nodeAddr, resp)
+		auditlog.LogMasterOp("HandleLcNodeRestoreResp", fmt.Sprintf("restore completed: %+v", resp), nil)
+	default:
+		log.LogInfof("action[handleLcNodeRestoreResp] lcNode[%v] restore received, resp(%+v)", nodeAddr, resp)
+		return
+	}
+
+	c.lcMgr.restoreMutex.Lock()
+	defer c.lcMgr.restoreMutex.Unlock()
+	record := c.lcMgr.restoreRecords[restoreRecordKey(resp.Volume, resp.Inode)]
+	if record == nil || record.Task.Id != resp.ID || record.Task.Expire != resp.Expire {
+		log.LogInfof("action[handleLcNodeRestoreResp] lcNode[%v] outdated resp(%+v)", nodeAddr, resp)
+		return
+	}
+	updated := *record
+	updated.LcNode = ""
+	switch {
+	case resp.Status == proto.TaskFailed && resp.Expire:
+		// the expire task is dispatched again on the next check
+	case resp.ExpiryDate == 0:
+		// the restore failed and the status is cleared, or there is no restored copy any longer
+		if err = c.syncDeleteRestoreRecord(record); err != nil {
+			log.LogWarnf("action[handleLcNodeRestoreResp] syncDeleteRestoreRecord %+v err(%v)", record.Task, err)
+			return
+		}
+		delete(c.lcMgr.restoreRecords, restoreRecordKey(resp.Volume, resp.Inode))
+		return
+	default:
+		updated.ExpiryDate = resp.ExpiryDate
+	}
+	if err = c.syncPutRestoreRecord(&updated); err != nil {
+		log.LogWarnf("action[handleLcNodeRestoreResp] syncPutRestoreRecord %+v err(%v)", record.Task, err)
+		return
+	}
+	*record = updated
+	return
+}
+
+// restoreObject persists the restore task, and dispatches it to a random active lcnode. The task
+// replaces the record of the former restore of the object, whose status is replaced as well.
+func (c *Cluster) restoreObject(task *proto.RestoreTask) (err error) {
+	record := &restoreRecord{Task: task}
+	c.lcMgr.restoreMutex.Lock()
+	defer c.lcMgr.restoreMutex.Unlock()
+	if err = c.dispatchRestoreTask(record, time.Now()); err != nil {
+		return
+	}
+	c.lcMgr.restoreRecords[restoreRecordKey(task.VolName, task.Inode)] = record
+	return
+}
+
+// dispatchRestoreTask persists the record with the lcnode selected before dispatching the task,
+// the restore task is dispatched if the object is not restored yet, otherwise the expire task.
+func (c *Cluster) dispatchRestoreTask(record *restoreRecord, now time.Time) (err error) {
+	nodes := make([]*LcNode, 0)
+	c.lcNodes.Range(func(addr, value interface{}) bool {
+		node := value.(*LcNode)
+		node.RLock()
+		if node.IsActive {
+			nodes = append(nodes, node)
+		}
+		node.RUnlock()
+		return true
+	})
+	if len(nodes) == 0 {
+		return fmt.Errorf("no active lcnode")
+	}
+	node := nodes[rand.Intn(len(nodes))]
+
+	updated := *record
+	task := *record.Task
+	task.Expire = record.ExpiryDate > 0
+	updated.Task = &task
+	updated.LcNode = node.Addr
+	updated.DispatchTime = now.Unix()
+	if err = c.syncPutRestoreRecord(&updated); err != nil {
+		return
+	}
+	*record = updated
+	c.addLcNodeTasks([]*proto.AdminTask{node.createRestoreTask(c.masterAddr(), &task)})
+	log.LogInfof("action[dispatchRestoreTask] add restore task(%+v) to lcnode(%v)", task, node.Addr)
+	return
+}
+
+// checkRestoreRecords dispatches the restore tasks without response after the timeout, and the
+// expire tasks of the restored copies after the expiry date.
+func (c *Cluster) checkRestoreRecords(now time.Time) {
+	c.lcMgr.restoreMutex.Lock()
+	defer c.lcMgr.restoreMutex.Unlock()
+	for key, record := range c.lcMgr.restoreRecords {
+		if record.LcNode != "" && now.Unix()-record.DispatchTime < int64(defaultRestoreTaskTimeout.Seconds()) {
+			continue
+		}
+		if record.LcNode == "" && record.ExpiryDate > 0 && now.Unix() < record.ExpiryDate {
+			continue
+		}
+		if record.LcNode != "" {
+			log.LogWarnf("action[checkRestoreRecords] restore task(%+v) of lcnode(%v) timeout", record.Task, record.LcNode)
+		}
+		if err := c.dispatchRestoreTask(record, now); err != nil {
+			log.LogWarnf("action[checkRestoreRecords] dispatch restore task(%v) err(%v)", key, err)
+			return
+		}
+	}
+}
+
+func (c *Cluster) scheduleToCheckRestoreRecords() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.checkRestoreRecords(time.Now())
+			}
+			time.Sleep(defaultIntervalToCheckRestoreRecords)
+		}
+	}()
+}
@@ -80,6 +80,7 @@ func (m *Server) handleLeaderChange(leader uint64) {
 		m.cluster.checkLcNodeHeartbeat()
 		m.cluster.lcMgr.startLcScanHandleLeaderChange()
 		m.cluster.flashManMgr.startFlashScanHandleLeaderChange()
+		m.cluster.batchMgr.startBatchJobHandleLeaderChange()
 		m.cluster.followerReadManager.reSet()
 	} else {
 		Warn(m.clusterName, fmt.Sprintf("clusterID[%v] leader is changed to %v",
@@ -94,6 +95,10 @@ func (m *Server) handleLeaderChange(leader uint64) {
 			close(m.cluster.flashManMgr.exitCh)
 			m.cluster.flashManMgr = newFlashManualTaskManager(m.cluster)
 		}
+		if m.cluster.batchMgr != nil {
+			close(m.cluster.batchMgr.exitCh)
+			m.cluster.batchMgr = newBatchJobManager(m.cluster)
+		}
 		m.metaReady = false
 		m.cluster.metaReady = false
 		if WarnMetrics != nil {
@@ -222,6 +227,7 @@ func (m *Server) loadMetadata() {
 	if err = m.cluster.loadMetaPartitions(); err != nil {
 		panic(err)
 	}
+	m.cluster.resumeSplitMetaPartitions()
 
 	if err = m.cluster.loadDataPartitions(); err != nil {
 		panic(err)
@@ -243,6 +249,12 @@ func (m *Server) loadMetadata() {
 	if err = m.user.loadVolUsers(); err != nil {
 		panic(err)
 	}
+	if err = m.user.loadRoleStore(); err != nil {
+		panic(err)
+	}
+	if err = m.user.loadAPITokenStore(); err != nil {
+		panic(err)
+	}
 	log.LogInfo("action[loadUserInfo] end")
 
 	log.LogInfo("action[refreshUser] begin")
@@ -269,6 +281,12 @@ func (m *Server) loadMetadata() {
 	}
 	log.LogInfo("action[loadLcConfs] end")
 
+	log.LogInfo("action[loadBucketInventories] begin")
+	if err = m.cluster.loadBucketInventories(); err != nil {
+		panic(err)
+	}
+	log.LogInfo("action[loadBucketInventories] end")
+
 	log.LogInfo("action[loadLcTasks] begin")
 	if err = m.cluster.loadLcTasks(); err != nil {
 		panic(err)
@@ -281,6 +299,12 @@ func (m *Server) loadMetadata() {
 	}
 	log.LogInfo("action[loadLcResults] end")
 
+	log.LogInfo("action[loadRestoreRecords] begin")
+	if err = m.cluster.loadRestoreRecords(); err != nil {
+		panic(err)
+	}
+	log.LogInfo("action[loadRestoreRecords] end")
+
 	log.LogInfo("action[loadLcNodes] begin")
 	if err = m.cluster.loadLcNodes(); err != nil {
 		panic(err)
@@ -293,6 +317,12 @@ func (m *Server) loadMetadata() {
 	}
 	log.LogInfo("action[loadFlashManualTasks] end")
 
+	log.LogInfo("action[loadBatchJobs] begin")
+	if err = m.cluster.loadBatchJobs(); err != nil {
+		panic(err)
+	}
+	log.LogInfo("action[loadBatchJobs] end")
+
 	log.LogInfo("action[loadS3QoSInfo] begin")
 	if err = m.cluster.loadS3ApiQosInfo(); err != nil {
 		panic(err)
@@ -324,12 +354,16 @@ func (m *Server) clearMetadata() {
 
 	m.cluster.DataNodeToDecommissionRepairDpMap = sync.Map{}
 	m.cluster.NoSamePeerDps = sync.Map{}
+	m.cluster.SpreadViolationDps = sync.Map{}
+	m.cluster.SpreadViolationMps = sync.Map{}
 
 	if m.user != nil {
 		// leader change event may be before m.user initialization
 		m.user.clearUserStore()
 		m.user.clearAKStore()
 		m.user.clearVolUsers()
+		m.user.clearRoleStore()
+		m.user.clearAPITokenStore()
 	}
 
 	m.cluster.t = newTopology()
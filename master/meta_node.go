@@ -33,6 +33,7 @@ type MetaNode struct {
 	IsActive                         bool
 	Sender                           *AdminTaskManager `graphql:"-"`
 	ZoneName                         string            `json:"Zone"`
+	Labels                           map[string]string // topology labels reported by the heartbeat
 	MaxMemAvailWeight                uint64            `json:"MaxMemAvailWeight"`
 	Total                            uint64            `json:"TotalWeight"`
 	Used                             uint64            `json:"UsedWeight"`
@@ -129,6 +130,12 @@ func (metaNode *MetaNode) GetZoneName() string {
 	return metaNode.ZoneName
 }
 
+func (metaNode *MetaNode) GetLabels() map[string]string {
+	metaNode.RLock()
+	defer metaNode.RUnlock()
+	return metaNode.Labels
+}
+
 // SelectNodeForWrite implements the Node interface
 func (metaNode *MetaNode) SelectNodeForWrite() {
 	metaNode.Lock()
@@ -175,6 +182,7 @@ func (metaNode *MetaNode) updateMetric(resp *proto.MetaNodeHeartbeatResponse, th
 		metaNode.MaxMemAvailWeight = uint64(left)
 	}
 	metaNode.ZoneName = resp.ZoneName
+	metaNode.Labels = resp.Labels
 	metaNode.Threshold = threshold
 	metaNode.NodeMemTotal = resp.NodeMemTotal
 	metaNode.NodeMemUsed = resp.NodeMemUsed
@@ -86,6 +86,10 @@ type MetaPartition struct {
 	sync.RWMutex
 
 	LastDelReplicaTime int64
+
+	// the split in progress, the inodes not less than the split key are moving to the new partition
+	SplitKey         uint64
+	SplitPartitionID uint64
 }
 
 func newMetaReplica(start, end uint64, metaNode *MetaNode) (mr *MetaReplica) {
@@ -228,7 +232,7 @@ func (mp *MetaPartition) dataSize() uint64 {
 }
 
 func (mp *MetaPartition) checkEnd(c *Cluster, maxPartitionID uint64) {
-	if mp.PartitionID < maxPartitionID {
+	if mp.PartitionID != maxPartitionID {
 		return
 	}
 	vol, err := c.getVol(mp.volName)
@@ -362,7 +366,7 @@ func (mp *MetaPartition) checkStatus(clusterID string, writeLog bool, replicaNum
 		}
 	}
 
-	if mp.PartitionID >= maxPartitionID && mp.Status == proto.ReadOnly && !forbiddenVol {
+	if mp.PartitionID == maxPartitionID && mp.Status == proto.ReadOnly && !forbiddenVol {
 		mp.Status = proto.ReadWrite
 	}
 
@@ -742,6 +746,22 @@ func (mp *MetaPartition) createTaskToRemoveRaftMember(removePeer proto.Peer) (t
 	return
 }
 
+// createTaskToSeedReplica creates the replica seeded by the upper range of the splitting partition on the host.
+func (mp *MetaPartition) createTaskToSeedReplica(host string, splitFrom uint64) (t *proto.AdminTask) {
+	req := &proto.CreateMetaPartitionRequest{
+		Start:       mp.Start,
+		End:         mp.End,
+		PartitionID: mp.PartitionID,
+		Members:     mp.Peers,
+		VolName:     mp.volName,
+		VerSeq:      mp.VerSeq,
+		SplitFrom:   splitFrom,
+	}
+	t = proto.NewAdminTask(proto.OpCreateMetaPartition, host, req)
+	resetMetaPartitionTaskID(t, mp.PartitionID)
+	return
+}
+
 func resetMetaPartitionTaskID(t *proto.AdminTask, partitionID uint64) {
 	t.ID = fmt.Sprintf("%v_pid[%v]", t.ID, partitionID)
 	t.PartitionID = partitionID
@@ -835,6 +855,17 @@ func (mr *MetaReplica) createTaskToFreezeReplica(partitionID uint64, freeze bool
 	return
 }
 
+func (mr *MetaReplica) createTaskToSplitReplica(partitionID, splitKey uint64, phase string) (t *proto.AdminTask) {
+	req := &proto.SplitMetaPartitionRequest{
+		PartitionID: partitionID,
+		SplitKey:    splitKey,
+		Phase:       phase,
+	}
+	t = proto.NewAdminTask(proto.OpSplitMetaPartition, mr.Addr, req)
+	resetMetaPartitionTaskID(t, partitionID)
+	return
+}
+
 func (mr *MetaReplica) createTaskToBackupReplica(partitionID uint64) (t *proto.AdminTask) {
 	req := &proto.BackupMetaPartitionRequest{
 		PartitionID: partitionID,
@@ -0,0 +1,307 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// splitMetaPartitionAt splits a hot meta partition at an inode boundary, the inodes not less than
+// the split key, the dentries under them and their extended attributes move to a new meta partition.
+// A zero split key lets the leader of the partition pick its median inode.
+//
+//  1. the prepare freezes the upper range on every replica by the raft log of the partition
+//  2. the new partition is created on the same hosts and seeded by the frozen range
+//  3. the new range of the partition and the new partition are committed in one raft command
+//  4. the commit purges the moved items from the partition and resumes the writes
+//
+// The split in progress is persisted with the partition, the new leader of the master resumes the commit
+// of the split committed by the master, or aborts the one not committed yet.
+func (vol *Vol) splitMetaPartitionAt(c *Cluster, mp *MetaPartition, splitKey uint64) (nextMp *MetaPartition, err error) {
+	if vol.Forbidden {
+		err = errors.NewErrorf("volume %v is forbidden", vol.Name)
+		return
+	}
+
+	vol.createMpMutex.Lock()
+	defer vol.createMpMutex.Unlock()
+
+	if mp.SplitKey != 0 {
+		err = fmt.Errorf("mp[%v] is splitting at[%v] to mp[%v]", mp.PartitionID, mp.SplitKey, mp.SplitPartitionID)
+		return
+	}
+
+	if nextMp, err = vol.doSplitMetaPartitionAt(c, mp, splitKey); err != nil {
+		return
+	}
+	vol.addMetaPartition(nextMp)
+	vol.updateViewCache(c)
+
+	mp.Lock()
+	commitErr := c.commitSplitMetaPartition(mp)
+	mp.Unlock()
+	if commitErr != nil {
+		log.LogErrorf("action[splitMetaPartitionAt] mp[%v] commit split at[%v] err[%v]", mp.PartitionID, nextMp.Start, commitErr)
+		go c.resumeSplitMetaPartition(mp)
+	}
+	log.LogWarnf("action[splitMetaPartitionAt] mp[%v] range[%v,%v], next mp[%v] range[%v,%v]",
+		mp.PartitionID, mp.Start, mp.End, nextMp.PartitionID, nextMp.Start, nextMp.End)
+	return
+}
+
+func (vol *Vol) doSplitMetaPartitionAt(c *Cluster, mp *MetaPartition, splitKey uint64) (nextMp *MetaPartition, err error) {
+	mp.Lock()
+	defer mp.Unlock()
+
+	if splitKey != 0 && (splitKey <= mp.Start || splitKey > mp.End) {
+		err = fmt.Errorf("split key[%v] out of the range[%v,%v] of mp[%v]", splitKey, mp.Start, mp.End, mp.PartitionID)
+		return
+	}
+	if mp.IsRecover {
+		err = fmt.Errorf("mp[%v] is recovering", mp.PartitionID)
+		return
+	}
+
+	resp, err := c.syncSplitMetaReplica(mp, splitKey, proto.MetaPartitionSplitPrepare)
+	if err != nil {
+		return
+	}
+	splitKey = resp.SplitKey
+	log.LogWarnf("action[splitMetaPartitionAt] mp[%v] range[%v,%v] split at[%v], inodes[%v] dentries[%v] to move",
+		mp.PartitionID, mp.Start, mp.End, splitKey, resp.InodeCount, resp.DentryCount)
+
+	// persist the split before creating the new partition, so that it is aborted by the new leader of the master
+	partitionID, err := c.idAlloc.allocateMetaPartitionID()
+	if err == nil {
+		mp.SplitKey, mp.SplitPartitionID = splitKey, partitionID
+		if err = c.syncUpdateMetaPartition(mp); err != nil {
+			mp.SplitKey, mp.SplitPartitionID = 0, 0
+		}
+	}
+	if err != nil {
+		if _, abortErr := c.syncSplitMetaReplica(mp, splitKey, proto.MetaPartitionSplitAbort); abortErr != nil {
+			log.LogErrorf("action[splitMetaPartitionAt] mp[%v] abort split at[%v] err[%v]", mp.PartitionID, splitKey, abortErr)
+		}
+		return nil, errors.NewError(err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if abortErr := c.abortSplitMetaPartition(mp); abortErr != nil {
+			log.LogErrorf("action[splitMetaPartitionAt] mp[%v] abort split at[%v] err[%v]", mp.PartitionID, splitKey, abortErr)
+			go c.resumeSplitMetaPartition(mp)
+		}
+	}()
+
+	if nextMp, err = vol.doCreateSplitMetaPartition(c, mp, partitionID, splitKey); err != nil {
+		return
+	}
+
+	oldEnd := mp.End
+	mp.End = splitKey - 1
+	cmdMap := make(map[string]*RaftCmd)
+	updateMpRaftCmd, err := c.buildMetaPartitionRaftCmd(opSyncUpdateMetaPartition, mp)
+	if err == nil {
+		cmdMap[updateMpRaftCmd.K] = updateMpRaftCmd
+		var addMpRaftCmd *RaftCmd
+		if addMpRaftCmd, err = c.buildMetaPartitionRaftCmd(opSyncAddMetaPartition, nextMp); err == nil {
+			cmdMap[addMpRaftCmd.K] = addMpRaftCmd
+			err = c.syncBatchCommitCmd(cmdMap)
+		}
+	}
+	if err != nil {
+		mp.End = oldEnd
+		return nil, errors.NewError(err)
+	}
+
+	if mp.MaxInodeID > mp.End {
+		mp.MaxInodeID = mp.End
+	}
+	mp.updateInodeIDRangeForAllReplicas()
+	return
+}
+
+// doCreateSplitMetaPartition creates the partition of the upper range on the hosts of the splitting partition.
+func (vol *Vol) doCreateSplitMetaPartition(c *Cluster, src *MetaPartition, partitionID, start uint64) (mp *MetaPartition, err error) {
+	var wg sync.WaitGroup
+	mp = newMetaPartition(partitionID, start, src.End, src.ReplicaNum, vol.Name, vol.ID, vol.VersionMgr.getLatestVer())
+	mp.setHosts(src.Hosts)
+	mp.setPeers(src.Peers)
+
+	errChannel := make(chan error, len(mp.Hosts))
+	for _, host := range mp.Hosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			metaNode, err := c.metaNode(host)
+			if err != nil {
+				errChannel <- err
+				return
+			}
+			if _, err = metaNode.Sender.syncSendAdminTask(mp.createTaskToSeedReplica(host, src.PartitionID)); err != nil {
+				log.LogErrorf("doCreateSplitMetaPartition: create mp to metanode failed, mp %d, err %s", mp.PartitionID, err.Error())
+				errChannel <- err
+				return
+			}
+			mp.Lock()
+			defer mp.Unlock()
+			if err = mp.afterCreation(host, c); err != nil {
+				errChannel <- err
+			}
+		}(host)
+	}
+	wg.Wait()
+
+	select {
+	case err = <-errChannel:
+		return nil, errors.NewError(err)
+	default:
+		mp.Status = proto.ReadWrite
+	}
+	log.LogInfof("action[doCreateSplitMetaPartition] success,volName[%v],partition[%v],start[%v],end[%v]", vol.Name, partitionID, mp.Start, mp.End)
+	return
+}
+
+// deleteSplitReplicas deletes the replicas of the new partition of the split never committed.
+func (c *Cluster) deleteSplitReplicas(mp *MetaPartition) {
+	tasks := make([]*proto.AdminTask, 0, len(mp.Hosts))
+	for _, host := range mp.Hosts {
+		mr := &MetaReplica{Addr: host}
+		tasks = append(tasks, mr.createTaskToDeleteReplica(mp.SplitPartitionID))
+	}
+	c.addMetaNodeTasks(tasks)
+}
+
+// commitSplitMetaPartition resumes the writes of the partition whose split is committed by the master,
+// the moved items are purged once the commit is applied.
+func (c *Cluster) commitSplitMetaPartition(mp *MetaPartition) (err error) {
+	if _, err = c.syncSplitMetaReplica(mp, mp.SplitKey, proto.MetaPartitionSplitCommit); err != nil {
+		return
+	}
+	return c.clearSplitMetaPartition(mp)
+}
+
+// abortSplitMetaPartition resumes the writes of the partition whose split is not committed by the master,
+// and deletes the replicas of the new partition.
+func (c *Cluster) abortSplitMetaPartition(mp *MetaPartition) (err error) {
+	if _, err = c.syncSplitMetaReplica(mp, mp.SplitKey, proto.MetaPartitionSplitAbort); err != nil {
+		return
+	}
+	c.deleteSplitReplicas(mp)
+	return c.clearSplitMetaPartition(mp)
+}
+
+func (c *Cluster) clearSplitMetaPartition(mp *MetaPartition) (err error) {
+	splitKey, partitionID := mp.SplitKey, mp.SplitPartitionID
+	mp.SplitKey, mp.SplitPartitionID = 0, 0
+	if err = c.syncUpdateMetaPartition(mp); err != nil {
+		mp.SplitKey, mp.SplitPartitionID = splitKey, partitionID
+	}
+	return
+}
+
+// isSplitCommitted returns if the split in progress of the partition is committed by the master.
+func (mp *MetaPartition) isSplitCommitted() bool {
+	return mp.SplitKey != 0 && mp.End == mp.SplitKey-1
+}
+
+// resumeSplitMetaPartitions resumes the splits in progress after the master becomes the leader.
+func (c *Cluster) resumeSplitMetaPartitions() {
+	for _, vol := range c.allVols() {
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			if mp.SplitKey != 0 {
+				go c.resumeSplitMetaPartition(mp)
+			}
+		}
+	}
+}
+
+func (c *Cluster) syncSplitMetaReplica(mp *MetaPartition, splitKey uint64, phase string) (resp *proto.SplitMetaPartitionResponse, err error) {
+	mr, err := mp.getMetaReplicaLeader()
+	if err != nil {
+		return
+	}
+	metaNode, err := c.metaNode(mr.Addr)
+	if err != nil {
+		return
+	}
+	packet, err := metaNode.Sender.syncSendAdminTask(mr.createTaskToSplitReplica(mp.PartitionID, splitKey, phase))
+	if err != nil {
+		log.LogErrorf("action[syncSplitMetaReplica] mp[%v] %v split at[%v] err[%v]", mp.PartitionID, phase, splitKey, err)
+		return
+	}
+	resp = &proto.SplitMetaPartitionResponse{}
+	if err = json.Unmarshal(packet.Data, resp); err != nil {
+		return
+	}
+	return
+}
+
+// resumeSplitMetaPartition commits or aborts the split in progress of the partition until it succeeds,
+// it quits once the master is not the leader, the new leader resumes the split when it loads the metadata.
+func (c *Cluster) resumeSplitMetaPartition(mp *MetaPartition) {
+	ticker := time.NewTicker(defaultIntervalToCheck * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopc:
+			return
+		case <-ticker.C:
+			if !c.partition.IsRaftLeader() {
+				return
+			}
+			if done, err := c.doResumeSplitMetaPartition(mp); done {
+				return
+			} else if err != nil {
+				Warn(c.Name, fmt.Sprintf("action[resumeSplitMetaPartition] clusterID[%v] mp[%v] split at[%v] not resumed, err[%v]",
+					c.Name, mp.PartitionID, mp.SplitKey, err))
+			}
+		}
+	}
+}
+
+func (c *Cluster) doResumeSplitMetaPartition(mp *MetaPartition) (done bool, err error) {
+	vol, err := c.getVol(mp.volName)
+	if err != nil {
+		// the volume is deleted with the partition
+		return true, err
+	}
+	vol.createMpMutex.Lock()
+	defer vol.createMpMutex.Unlock()
+	mp.Lock()
+	defer mp.Unlock()
+	if mp.SplitKey == 0 {
+		return true, nil
+	}
+	splitKey, committed := mp.SplitKey, mp.isSplitCommitted()
+	if committed {
+		err = c.commitSplitMetaPartition(mp)
+	} else {
+		err = c.abortSplitMetaPartition(mp)
+	}
+	if err != nil {
+		return
+	}
+	log.LogWarnf("action[resumeSplitMetaPartition] mp[%v] split at[%v] resumed, committed[%v]", mp.PartitionID, splitKey, committed)
+	return true, nil
+}
@@ -0,0 +1,54 @@
+package master
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+func TestMaxMetaPartitionIDAfterSplit(t *testing.T) {
+	vol := &Vol{Name: "testVol", MetaPartitions: make(map[uint64]*MetaPartition)}
+	// mp 3 is split from mp 1, the rear partition is still mp 2
+	vol.MetaPartitions[1] = newMetaPartition(1, 1, 49, 3, vol.Name, 1, 0)
+	vol.MetaPartitions[2] = newMetaPartition(2, 101, defaultMaxMetaPartitionInodeID, 3, vol.Name, 1, 0)
+	vol.MetaPartitions[3] = newMetaPartition(3, 50, 100, 3, vol.Name, 1, 0)
+	if id := vol.maxMetaPartitionID(); id != 2 {
+		t.Errorf("Expected the rear meta partition 2, but got %v", id)
+	}
+}
+
+func TestSplitMetaPartitionAtOutOfRange(t *testing.T) {
+	vol := &Vol{Name: "testVol", MetaPartitions: make(map[uint64]*MetaPartition)}
+	mp := newMetaPartition(1, 1, 100, 3, vol.Name, 1, 0)
+	mp.Status = proto.ReadWrite
+	vol.MetaPartitions[mp.PartitionID] = mp
+	for _, key := range []uint64{1, 102} {
+		if _, err := vol.splitMetaPartitionAt(nil, mp, key); err == nil {
+			t.Errorf("Expected split at %v out of the range [1,100] fails", key)
+		}
+	}
+	if mp.End != 100 {
+		t.Errorf("Expected the range is not changed, but end is %v", mp.End)
+	}
+}
+
+func TestSplitMetaPartitionInProgress(t *testing.T) {
+	vol := &Vol{Name: "testVol", MetaPartitions: make(map[uint64]*MetaPartition)}
+	mp := newMetaPartition(1, 1, 100, 3, vol.Name, 1, 0)
+	mp.SplitKey, mp.SplitPartitionID = 51, 2
+	vol.MetaPartitions[mp.PartitionID] = mp
+
+	if mpv := newMetaPartitionValue(mp); mpv.SplitKey != 51 || mpv.SplitPartitionID != 2 {
+		t.Errorf("Expected the split in progress is persisted, but got %v,%v", mpv.SplitKey, mpv.SplitPartitionID)
+	}
+	if mp.isSplitCommitted() {
+		t.Errorf("Expected the split is not committed before the range is shrunk")
+	}
+	mp.End = 50
+	if !mp.isSplitCommitted() {
+		t.Errorf("Expected the split is committed after the range is shrunk")
+	}
+	if _, err := vol.splitMetaPartitionAt(nil, mp, 20); err == nil {
+		t.Errorf("Expected another split fails while one is in progress")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/cubefs/cubefs/depends/tiglabs/raft"
@@ -27,6 +28,7 @@ import (
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/stat"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 const (
@@ -85,6 +87,7 @@ func (mf *MetadataFsm) registerRaftUserCmdApplyHandler(handler raftUserCmdApplyH
 
 func (mf *MetadataFsm) restore() {
 	mf.restoreApplied()
+	mf.restoreTLSTrust()
 }
 
 func (mf *MetadataFsm) restoreApplied() {
@@ -105,6 +108,33 @@ func (mf *MetadataFsm) restoreApplied() {
 	log.LogInfof("[restoreApplied] apply index(%v)", applied)
 }
 
+func (mf *MetadataFsm) restoreTLSTrust() {
+	result, err := mf.store.SeekForPrefix([]byte(clusterPrefix))
+	if err != nil {
+		log.LogErrorf("[restoreTLSTrust] seek cluster value failed, err(%v)", err)
+		return
+	}
+	for key, value := range result {
+		mf.applyTLSTrust(key, value)
+	}
+}
+
+// applyTLSTrust applies the TLS trust in the cluster value on every master rather than the leader only,
+// the raft traffic between the masters is secured by it as well.
+func (mf *MetadataFsm) applyTLSTrust(key string, value []byte) {
+	if !strings.HasPrefix(key, clusterPrefix) {
+		return
+	}
+	cv := &clusterValue{}
+	if err := json.Unmarshal(value, cv); err != nil {
+		log.LogErrorf("[applyTLSTrust] unmarshal cluster value failed, err(%v)", err)
+		return
+	}
+	if err := tlsutil.SetTrust(cv.TLSMode, cv.TLSCAPem); err != nil {
+		log.LogErrorf("[applyTLSTrust] apply tls trust failed, tlsMode(%v) err(%v)", cv.TLSMode, err)
+	}
+}
+
 // Apply implements the interface of raft.StateMachine
 func (mf *MetadataFsm) Apply(command []byte, index uint64) (resp interface{}, err error) {
 	mf.raftLk.Lock()
@@ -141,8 +171,9 @@ func (mf *MetadataFsm) Apply(command []byte, index uint64) (resp interface{}, er
 		for cmdK, cmd := range nestedCmdMap {
 			switch cmd.Op {
 			case opSyncDeleteDataNode, opSyncDeleteMetaNode, opSyncDeleteVol, opSyncDeleteDataPartition, opSyncDeleteMetaPartition,
-				opSyncDeleteUserInfo, opSyncDeleteAKUser, opSyncDeleteVolUser, opSyncDeleteQuota, opSyncDeleteLcNode,
-				opSyncDeleteLcConf, opSyncDeleteLcTask, opSyncDeleteLcResult, opSyncS3QosDelete, opSyncDeleteDecommissionDisk:
+				opSyncDeleteUserInfo, opSyncDeleteAKUser, opSyncDeleteVolUser, opSyncDeleteRoleInfo, opSyncDeleteQuota, opSyncDeleteLcNode,
+				opSyncDeleteLcConf, opSyncDeleteLcTask, opSyncDeleteLcResult, opSyncS3QosDelete, opSyncDeleteDecommissionDisk,
+				opSyncDeleteBatchJob, opSyncDeleteInventory, opSyncDeleteAPIToken, opSyncDeleteRestoreRecord:
 				deleteSet[cmdK] = util.Null{}
 			// NOTE: opSyncPutFollowerApiLimiterInfo, opSyncPutApiLimiterInfo need special handle?
 			default:
@@ -154,9 +185,10 @@ func (mf *MetadataFsm) Apply(command []byte, index uint64) (resp interface{}, er
 
 	switch cmd.Op {
 	case opSyncDeleteDataNode, opSyncDeleteMetaNode, opSyncDeleteVol, opSyncDeleteDataPartition, opSyncDeleteMetaPartition,
-		opSyncDeleteUserInfo, opSyncDeleteAKUser, opSyncDeleteVolUser, opSyncDeleteQuota, opSyncDeleteLcNode,
+		opSyncDeleteUserInfo, opSyncDeleteAKUser, opSyncDeleteVolUser, opSyncDeleteRoleInfo, opSyncDeleteQuota, opSyncDeleteLcNode,
 		opSyncDeleteLcConf, opSyncDeleteLcTask, opSyncDeleteLcResult, opSyncS3QosDelete, opSyncDeleteDecommissionDisk,
-		opSyncDeleteFlashNode, opSyncDeleteFlashGroup, opSyncDeleteFlashManualTask:
+		opSyncDeleteFlashNode, opSyncDeleteFlashGroup, opSyncDeleteFlashManualTask, opSyncDeleteBatchJob,
+		opSyncDeleteInventory, opSyncDeleteAPIToken, opSyncDeleteRestoreRecord:
 		if err = mf.delKeyAndPutIndex(cmd.K, cmdMap); err != nil {
 			panic(err)
 		}
@@ -174,6 +206,9 @@ func (mf *MetadataFsm) Apply(command []byte, index uint64) (resp interface{}, er
 			panic(err)
 		}
 	}
+	for k, v := range cmdMap {
+		mf.applyTLSTrust(k, v)
+	}
 	log.LogDebugf("action[Apply],persist index[%v]", string(cmdMap[applied]))
 	mf.applied = index
 
@@ -72,6 +72,7 @@ type clusterValue struct {
 	MarkDiskBrokenThreshold                float64
 	EnableAutoDpMetaRepair                 bool
 	AutoDpMetaRepairParallelCnt            uint32
+	EnableSpreadRepair                     bool
 	DataPartitionTimeoutSec                int64
 	MetaPartitionTimeoutSec                int64
 	ForbidWriteOpOfProtoVer0               bool
@@ -82,6 +83,8 @@ type clusterValue struct {
 	AutoMpMigrate                          bool
 	FlashNodeHandleReadTimeout             int
 	FlashNodeReadDataNodeTimeout           int
+	TLSMode                                string
+	TLSCAPem                               string
 }
 
 func newClusterValue(c *Cluster) (cv *clusterValue) {
@@ -123,6 +126,7 @@ func newClusterValue(c *Cluster) (cv *clusterValue) {
 		MarkDiskBrokenThreshold:                c.getMarkDiskBrokenThreshold(),
 		EnableAutoDpMetaRepair:                 c.getEnableAutoDpMetaRepair(),
 		AutoDpMetaRepairParallelCnt:            c.AutoDpMetaRepairParallelCnt.Load(),
+		EnableSpreadRepair:                     c.getEnableSpreadRepair(),
 		DataPartitionTimeoutSec:                c.getDataPartitionTimeoutSec(),
 		MetaPartitionTimeoutSec:                c.getMetaPartitionTimeoutSec(),
 		ForbidWriteOpOfProtoVer0:               c.cfg.forbidWriteOpOfProtoVer0,
@@ -133,6 +137,8 @@ func newClusterValue(c *Cluster) (cv *clusterValue) {
 		AutoMpMigrate:                          c.cfg.AutoMpMigrate,
 		FlashNodeHandleReadTimeout:             c.cfg.flashNodeHandleReadTimeout,
 		FlashNodeReadDataNodeTimeout:           c.cfg.flashNodeReadDataNodeTimeout,
+		TLSMode:                                c.cfg.tlsMode,
+		TLSCAPem:                               c.cfg.tlsCAPem,
 	}
 	return cv
 }
@@ -151,6 +157,8 @@ type metaPartitionValue struct {
 	IsRecover          bool
 	Freeze             int8
 	LastDelReplicaTime int64
+	SplitKey           uint64
+	SplitPartitionID   uint64
 }
 
 func newMetaPartitionValue(mp *MetaPartition) (mpv *metaPartitionValue) {
@@ -168,6 +176,8 @@ func newMetaPartitionValue(mp *MetaPartition) (mpv *metaPartitionValue) {
 		IsRecover:          mp.IsRecover,
 		Freeze:             mp.Freeze,
 		LastDelReplicaTime: mp.LastDelReplicaTime,
+		SplitKey:           mp.SplitKey,
+		SplitPartitionID:   mp.SplitPartitionID,
 	}
 	return
 }
@@ -349,6 +359,7 @@ type volValue struct {
 	DeleteLockTime     int64
 	LeaderRetryTimeOut int64
 	Description        string
+	SpreadLabel        string
 	DpSelectorName     string
 	DpSelectorParm     string
 	DefaultPriority    bool
@@ -433,6 +444,7 @@ func newVolValue(vol *Vol) (vv *volValue) {
 		CreateTime:              vol.createTime,
 		DeleteLockTime:          vol.DeleteLockTime,
 		Description:             vol.description,
+		SpreadLabel:             vol.spreadLabel,
 		DpSelectorName:          vol.dpSelectorName,
 		DpSelectorParm:          vol.dpSelectorParm,
 		DefaultPriority:         vol.defaultPriority,
@@ -682,6 +694,10 @@ func (m *RaftCmd) setOpType() {
 		m.Op = opSyncAddAKUser
 	case volUserAcronym:
 		m.Op = opSyncAddVolUser
+	case roleAcronym:
+		m.Op = opSyncAddRoleInfo
+	case apiTokenAcronym:
+		m.Op = opSyncAddAPIToken
 	case lcNodeAcronym:
 		m.Op = opSyncAddLcNode
 	case lcConfigurationAcronym:
@@ -690,6 +706,8 @@ func (m *RaftCmd) setOpType() {
 		m.Op = opSyncAddLcTask
 	case lcResultAcronym:
 		m.Op = opSyncAddLcResult
+	case restoreRecordAcronym:
+		m.Op = opSyncPutRestoreRecord
 	default:
 		log.LogWarnf("action[setOpType] unknown opCode[%v]", keyArr[1])
 	}
@@ -1392,6 +1410,7 @@ func (c *Cluster) loadClusterValue() (err error) {
 		c.updateMarkDiskBrokenThreshold(cv.MarkDiskBrokenThreshold)
 		c.updateEnableAutoDpMetaRepair(cv.EnableAutoDpMetaRepair)
 		c.updateAutoDpMetaRepairParallelCnt(cv.AutoDpMetaRepairParallelCnt)
+		c.EnableSpreadRepair.Store(cv.EnableSpreadRepair)
 		c.updateDataPartitionTimeoutSec(cv.DataPartitionTimeoutSec)
 		c.cfg.raftPartitionAlreadyUseDifferentPort.Store(cv.RaftPartitionAlreadyUseDifferentPort)
 		c.updateMetaPartitionTimeoutSec(cv.MetaPartitionTimeoutSec)
@@ -1421,6 +1440,9 @@ func (c *Cluster) loadClusterValue() (err error) {
 		c.cfg.flashNodeReadDataNodeTimeout = cv.FlashNodeReadDataNodeTimeout
 		log.LogInfof("action[loadClusterValue] flashNodeHandleReadTimeout %v(ms), flashNodeReadDataNodeTimeout%v(ms)",
 			cv.FlashNodeHandleReadTimeout, cv.FlashNodeReadDataNodeTimeout)
+
+		c.cfg.tlsMode = cv.TLSMode
+		c.cfg.tlsCAPem = cv.TLSCAPem
 	}
 
 	return
@@ -1849,6 +1871,8 @@ func (c *Cluster) loadMetaPartitions() (err error) {
 		mp.IsRecover = mpv.IsRecover
 		mp.Freeze = mpv.Freeze
 		mp.LastDelReplicaTime = mpv.LastDelReplicaTime
+		mp.SplitKey = mpv.SplitKey
+		mp.SplitPartitionID = mpv.SplitPartitionID
 		vol.addMetaPartition(mp)
 		c.addBadMetaParitionIdMap(mp)
 		log.LogInfof("action[loadMetaPartitions],vol[%v],mp[%v]", vol.Name, mp.PartitionID)
@@ -2196,6 +2220,45 @@ func (c *Cluster) loadLcConfs() (err error) {
 	return
 }
 
+// key=#inv#volName
+func (c *Cluster) syncPutBucketInventory(inventory *proto.BucketInventory) (err error) {
+	return c.syncPutBucketInventoryInfo(opSyncPutInventory, inventory)
+}
+
+func (c *Cluster) syncDeleteBucketInventory(inventory *proto.BucketInventory) (err error) {
+	return c.syncPutBucketInventoryInfo(opSyncDeleteInventory, inventory)
+}
+
+func (c *Cluster) syncPutBucketInventoryInfo(opType uint32, inventory *proto.BucketInventory) (err error) {
+	metadata := new(RaftCmd)
+	metadata.Op = opType
+	metadata.K = inventoryPrefix + inventory.VolName
+	metadata.V, err = json.Marshal(inventory)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	return c.submit(metadata)
+}
+
+func (c *Cluster) loadBucketInventories() (err error) {
+	result, err := c.fsm.store.SeekForPrefix([]byte(inventoryPrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadBucketInventories],err:%v", err.Error())
+		return err
+	}
+
+	for _, value := range result {
+		inventory := &proto.BucketInventory{}
+		if err = json.Unmarshal(value, inventory); err != nil {
+			err = fmt.Errorf("action[loadBucketInventories],value:%v,unmarshal err:%v", string(value), err)
+			return
+		}
+		c.lcMgr.SetBucketInventory(inventory)
+		log.LogInfof("action[loadBucketInventories],vol[%v]", inventory.VolName)
+	}
+	return
+}
+
 func (c *Cluster) syncAddLcTask(lcTask *proto.RuleTask) (err error) {
 	return c.syncPutLcTaskInfo(opSyncAddLcTask, lcTask)
 }
@@ -2234,6 +2297,49 @@ func (c *Cluster) loadLcTasks() (err error) {
 	return
 }
 
+// key=#rst#volName#inode
+func (c *Cluster) syncPutRestoreRecord(record *restoreRecord) (err error) {
+	return c.syncPutRestoreRecordInfo(opSyncPutRestoreRecord, record)
+}
+
+func (c *Cluster) syncDeleteRestoreRecord(record *restoreRecord) (err error) {
+	return c.syncPutRestoreRecordInfo(opSyncDeleteRestoreRecord, record)
+}
+
+func (c *Cluster) syncPutRestoreRecordInfo(opType uint32, record *restoreRecord) (err error) {
+	metadata := new(RaftCmd)
+	metadata.Op = opType
+	metadata.K = restorePrefix + restoreRecordKey(record.Task.VolName, record.Task.Inode)
+	metadata.V, err = json.Marshal(record)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	return c.submit(metadata)
+}
+
+func (c *Cluster) loadRestoreRecords() (err error) {
+	result, err := c.fsm.store.SeekForPrefix([]byte(restorePrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadRestoreRecords],err:%v", err.Error())
+		return err
+	}
+
+	records := make(map[string]*restoreRecord, len(result))
+	for _, value := range result {
+		record := &restoreRecord{}
+		if err = json.Unmarshal(value, record); err != nil {
+			err = fmt.Errorf("action[loadRestoreRecords],value:%v,unmarshal err:%v", string(value), err)
+			return
+		}
+		records[restoreRecordKey(record.Task.VolName, record.Task.Inode)] = record
+		log.LogInfof("action[loadRestoreRecords], task[%+v]", record.Task)
+	}
+	c.lcMgr.restoreMutex.Lock()
+	c.lcMgr.restoreRecords = records
+	c.lcMgr.restoreMutex.Unlock()
+	return
+}
+
 func (c *Cluster) syncAddLcResult(lcResult *proto.LcNodeRuleTaskResponse) (err error) {
 	return c.syncPutLcResultInfo(opSyncAddLcResult, lcResult)
 }
@@ -2328,6 +2434,52 @@ func (c *Cluster) syncDeleteBalanceTask() error {
 	return err
 }
 
+// key=#dataBalanceTask,value=json.Marshal(DataClusterPlan)
+func (c *Cluster) syncAddDataBalanceTask(task *proto.DataClusterPlan) (err error) {
+	return c.putDataBalanceTaskInfo(opSyncAddDataBalanceTask, task)
+}
+
+func (c *Cluster) syncUpdateDataBalanceTask(task *proto.DataClusterPlan) (err error) {
+	return c.putDataBalanceTaskInfo(opSyncUpdateDataBalanceTask, task)
+}
+
+func (c *Cluster) putDataBalanceTaskInfo(opType uint32, task *proto.DataClusterPlan) (err error) {
+	balanceTask := new(RaftCmd)
+	balanceTask.Op = opType
+	balanceTask.K = dataBalanceTaskKey
+	if balanceTask.V, err = json.Marshal(task); err != nil {
+		return fmt.Errorf("data balance task op(%d) encode err: %s", opType, err.Error())
+	}
+	return c.submit(balanceTask)
+}
+
+func (c *Cluster) loadDataBalanceTask() (*proto.DataClusterPlan, error) {
+	result, err := c.fsm.store.GetByKey([]byte(dataBalanceTaskKey))
+	if err != nil {
+		return nil, fmt.Errorf("loadDataBalanceTask GetByKey err: %s", err.Error())
+	}
+
+	if len(result) == 0 {
+		return nil, proto.ErrNoDpMigratePlan
+	}
+
+	task := new(proto.DataClusterPlan)
+	if err = json.Unmarshal(result, task); err != nil {
+		return nil, fmt.Errorf("loadDataBalanceTask decode json err: %s", err.Error())
+	}
+
+	return task, nil
+}
+
+func (c *Cluster) syncDeleteDataBalanceTask() error {
+	err := c.fsm.store.DelByKey([]byte(dataBalanceTaskKey), true)
+	if err != nil {
+		log.LogErrorf("DelByKey err: %s", err.Error())
+	}
+
+	return err
+}
+
 func (c *Cluster) loadFlashManualTasks() (err error) {
 	result, err := c.fsm.store.SeekForPrefix([]byte(flashManualTaskPrefix))
 	if err != nil {
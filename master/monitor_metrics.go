@@ -55,6 +55,7 @@ const (
 	MetricDiskLost                         = "disk_lost"
 	MetricDpUnableDecommissionCount        = "dp_unable_decommission_count"
 	MetricDpNoSamePeer                     = "dp_no_same_peer"
+	MetricPartitionSpreadViolation         = "partition_spread_violation"
 	MetricBadDiskDecommissionTimeOverLimit = "bad_disk_decommission_time_over_limit"
 	MetricDataNodesInactive                = "dataNodes_inactive"
 	MetricInactiveDataNodeInfo             = "inactive_dataNodes_info"
@@ -130,6 +131,7 @@ type monitorMetrics struct {
 	diskLost                         *exporter.GaugeVec
 	dpUnableDecommissionCount        *exporter.Gauge
 	dpNoSamePeer                     *exporter.GaugeVec
+	partitionSpreadViolation         *exporter.GaugeVec
 	badDiskDecommissionTimeOverLimit *exporter.GaugeVec
 	dataNodesNotWritable             *exporter.Gauge    // TODO: remove in the future
 	dataNodesAllocable               *exporter.Gauge    // TODO: remove in the future
@@ -518,6 +520,7 @@ func (mm *monitorMetrics) start() {
 	mm.diskLost = exporter.NewGaugeVec(MetricDiskLost, "", []string{"addr", "path"})
 	mm.dpUnableDecommissionCount = exporter.NewGauge(MetricDpUnableDecommissionCount)
 	mm.dpNoSamePeer = exporter.NewGaugeVec(MetricDpNoSamePeer, "", []string{"dpId"})
+	mm.partitionSpreadViolation = exporter.NewGaugeVec(MetricPartitionSpreadViolation, "", []string{"vol", "type", "partitionId"})
 	mm.badDiskDecommissionTimeOverLimit = exporter.NewGaugeVec(MetricBadDiskDecommissionTimeOverLimit, "", []string{"addr", "path", "firstReportTime"})
 	mm.nodeStat = exporter.NewGaugeVec(MetricNodeStat, "", []string{"type", "addr", "stat", "zone", "set", "media", "writable", "alloc"})
 	mm.dataNodesInactive = exporter.NewGauge(MetricDataNodesInactive)
@@ -621,6 +624,7 @@ func (mm *monitorMetrics) doStat() {
 	mm.setFlashNodesDiskErrorMetric()
 	mm.setDpUnableDecommissionMetric()
 	mm.setDpNoSamePeerMetric()
+	mm.setPartitionSpreadViolationMetric()
 	mm.setBadDiskDecommissionTimeOverLimit()
 	mm.setNotWritableDataNodesCount()
 	mm.setNotWritableMetaNodesCount()
@@ -976,6 +980,15 @@ func (mm *monitorMetrics) setDpNoSamePeerMetric() {
 	})
 }
 
+func (mm *monitorMetrics) setPartitionSpreadViolationMetric() {
+	mm.partitionSpreadViolation.Reset()
+
+	for _, violation := range mm.cluster.getSpreadViolations() {
+		idStr := strconv.FormatUint(violation.PartitionID, 10)
+		mm.partitionSpreadViolation.SetWithLabelValues(1, violation.VolName, violation.PartitionType, idStr)
+	}
+}
+
 func (mm *monitorMetrics) setBadDiskDecommissionTimeOverLimit() {
 	mm.badDiskDecommissionTimeOverLimit.Reset()
 
@@ -1190,12 +1203,17 @@ func (mm *monitorMetrics) deleteS3LcVolMetric(id string) {
 	mm.lcVolExpired.DeleteLabelValues(id, "hdd")
 	mm.lcVolExpired.DeleteLabelValues(id, "blobstore")
 	mm.lcVolExpired.DeleteLabelValues(id, "skip")
+	mm.lcVolExpired.DeleteLabelValues(id, "deletemarker")
+	mm.lcVolExpired.DeleteLabelValues(id, "multipart")
 	mm.lcVolMigrateBytes.DeleteLabelValues(id, "hdd")
 	mm.lcVolMigrateBytes.DeleteLabelValues(id, "blobstore")
 	mm.lcVolError.DeleteLabelValues(id, "delete")
 	mm.lcVolError.DeleteLabelValues(id, "hdd")
 	mm.lcVolError.DeleteLabelValues(id, "blobstore")
 	mm.lcVolError.DeleteLabelValues(id, "readdir")
+	mm.lcVolError.DeleteLabelValues(id, "deletemarker")
+	mm.lcVolError.DeleteLabelValues(id, "multipart")
+	mm.lcVolError.DeleteLabelValues(id, "inventory")
 }
 
 func (mm *monitorMetrics) setLcMetrics() {
@@ -1220,12 +1238,17 @@ func (mm *monitorMetrics) setLcMetrics() {
 		mm.lcVolExpired.SetWithLabelValues(float64(stat.ExpiredMToHddNum), id, "hdd")
 		mm.lcVolExpired.SetWithLabelValues(float64(stat.ExpiredMToBlobstoreNum), id, "blobstore")
 		mm.lcVolExpired.SetWithLabelValues(float64(stat.ExpiredSkipNum), id, "skip")
+		mm.lcVolExpired.SetWithLabelValues(float64(stat.ExpiredDeleteMarkerNum), id, "deletemarker")
+		mm.lcVolExpired.SetWithLabelValues(float64(stat.AbortedMultipartNum), id, "multipart")
 		mm.lcVolMigrateBytes.SetWithLabelValues(float64(stat.ExpiredMToHddBytes), id, "hdd")
 		mm.lcVolMigrateBytes.SetWithLabelValues(float64(stat.ExpiredMToBlobstoreBytes), id, "blobstore")
 		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorDeleteNum), id, "delete")
 		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorMToHddNum), id, "hdd")
 		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorMToBlobstoreNum), id, "blobstore")
 		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorReadDirNum), id, "readdir")
+		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorDeleteMarkerNum), id, "deletemarker")
+		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorAbortMultipartNum), id, "multipart")
+		mm.lcVolError.SetWithLabelValues(float64(stat.ErrorInventoryNum), id, "inventory")
 	}
 }
 
@@ -1366,6 +1389,7 @@ func (mm *monitorMetrics) resetAllLeaderMetrics() {
 	mm.diskLost.Reset()
 	mm.dpUnableDecommissionCount.Set(0)
 	mm.dpNoSamePeer.Reset()
+	mm.partitionSpreadViolation.Reset()
 	mm.badDiskDecommissionTimeOverLimit.Reset()
 	mm.diskDecommissionSuccess.Reset()
 	mm.dataNodesInactive.Set(0)
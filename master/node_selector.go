@@ -51,7 +51,9 @@ func (ns *nodeSet) getNodes(nodeType NodeType) *sync.Map {
 
 type NodeSelector interface {
 	GetName() string
-	Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error)
+	// Select selects the nodes for the replicas, the spread constraint is updated with the
+	// failure domains of the nodes selected.
+	Select(ns *nodeSet, excludeHosts []string, replicaNum int, spread *spreadConstraint) (newHosts []string, peers []proto.Peer, err error)
 }
 
 type weightedNode struct {
@@ -78,6 +80,7 @@ type Node interface {
 	GetStorageInfo() string
 	IsOffline() bool
 	GetZoneName() string
+	GetLabels() map[string]string
 }
 
 // SortedWeightedNodes defines an array sorted by carry
@@ -212,7 +215,7 @@ func (s *CarryWeightNodeSelector) selectNodeForWrite(node Node) {
 	s.carry[node.GetID()] -= 1.0
 }
 
-func (s *CarryWeightNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+func (s *CarryWeightNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int, spread *spreadConstraint) (newHosts []string, peers []proto.Peer, err error) {
 	nodes := ns.getNodes(s.nodeType)
 	total := s.getTotalMax(nodes)
 	// prepare carry for every nodes
@@ -236,9 +239,24 @@ func (s *CarryWeightNodeSelector) Select(ns *nodeSet, excludeHosts []string, rep
 	s.setNodeCarry(weightedNodes, count, replicaNum)
 	// sort nodes by weight
 	sort.Sort(weightedNodes)
-	// pick first N nodes
-	for i := 0; i < replicaNum; i++ {
+	// pick first N nodes in distinct failure domains
+	selected := make([]Node, 0, replicaNum)
+	picked := spread.clone()
+	for i := 0; i < len(weightedNodes) && len(selected) < replicaNum; i++ {
 		node := weightedNodes[i].Ptr
+		if !picked.allow(node) {
+			continue
+		}
+		picked.take(node)
+		selected = append(selected, node)
+	}
+	if len(selected) < replicaNum {
+		err = fmt.Errorf("action[%s NodeSelector-Select] no enough writable hosts for spread constraint %v,replicaNum: %d MatchNodeCount:%d",
+			s.GetName(), spread, replicaNum, len(selected))
+		return
+	}
+	spread.merge(picked)
+	for _, node := range selected {
 		s.selectNodeForWrite(node)
 		orderHosts = append(orderHosts, node.GetAddr())
 		peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr(), ReplicaPort: node.GetReplicaPort(), HeartbeatPort: node.GetHeartbeatPort()}
@@ -272,7 +290,7 @@ func (s *AvailableSpaceFirstNodeSelector) GetName() string {
 	return AvailableSpaceFirstNodeSelectorName
 }
 
-func (s *AvailableSpaceFirstNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+func (s *AvailableSpaceFirstNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int, spread *spreadConstraint) (newHosts []string, peers []proto.Peer, err error) {
 	newHosts = make([]string, 0)
 	peers = make([]proto.Peer, 0)
 	// if replica == 0, return
@@ -316,13 +334,19 @@ func (s *AvailableSpaceFirstNodeSelector) Select(ns *nodeSet, excludeHosts []str
 	//	2) When create 5 replicas dp, we will select IP1:17310, IP2:17310, IP3:17310,IP1:18310, IP2:18310
 	//	inner loop be executed twice, first loop select out IP1:17310, IP2:17310, IP3:17310
 	//	because nodes with distinct ip can’t satisfy the replica requirement, need second loop , and select out IP1:18310, IP2:18310
-	excludedNodes := make([]Node, 0)
+	selected := make([]Node, 0, replicaNum)
+	picked := spread.clone()
 	distinctIpSet := make(map[string]struct{})
 	// outer loop: select until we get replica number of nodes
-	for len(orderHosts) < replicaNum {
+	for len(selected) < replicaNum && len(sortedNodes) > 0 {
+		excludedNodes := make([]Node, 0)
 		// for each execution of inner loop, select nodes with distinct ip, try to avoid multiple replicas of a partition locate on same machine
 		for i := 0; i < len(sortedNodes); i++ {
 			node := sortedNodes[i]
+			// the nodes in the failure domains taken are never selected
+			if !picked.allow(node) {
+				continue
+			}
 			addr := node.GetAddr()
 			ipAndPort := strings.Split(addr, ":")
 			ip := ipAndPort[0]
@@ -332,12 +356,10 @@ func (s *AvailableSpaceFirstNodeSelector) Select(ns *nodeSet, excludeHosts []str
 			}
 
 			distinctIpSet[ip] = struct{}{}
-			node.SelectNodeForWrite()
-			orderHosts = append(orderHosts, node.GetAddr())
-			peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr(), ReplicaPort: node.GetReplicaPort(), HeartbeatPort: node.GetHeartbeatPort()}
-			peers = append(peers, peer)
+			picked.take(node)
+			selected = append(selected, node)
 
-			if len(orderHosts) == replicaNum {
+			if len(selected) == replicaNum {
 				break
 			}
 		}
@@ -347,11 +369,18 @@ func (s *AvailableSpaceFirstNodeSelector) Select(ns *nodeSet, excludeHosts []str
 		distinctIpSet = make(map[string]struct{})
 	}
 	// if we cannot get enough writable nodes, return error
-	if len(orderHosts) < replicaNum {
-		err = fmt.Errorf("action[%vNodeSelector-Select] no enough writable hosts,replicaNum:%v  MatchNodeCount:%v  ",
-			s.GetName(), replicaNum, len(orderHosts))
+	if len(selected) < replicaNum {
+		err = fmt.Errorf("action[%vNodeSelector-Select] no enough writable hosts,replicaNum:%v  MatchNodeCount:%v  spread:%v",
+			s.GetName(), replicaNum, len(selected), spread)
 		return
 	}
+	spread.merge(picked)
+	for _, node := range selected {
+		node.SelectNodeForWrite()
+		orderHosts = append(orderHosts, node.GetAddr())
+		peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr(), ReplicaPort: node.GetReplicaPort(), HeartbeatPort: node.GetHeartbeatPort()}
+		peers = append(peers, peer)
+	}
 	log.LogInfof("action[%vNodeSelector-Select] peers[%v]", s.GetName(), peers)
 	// reshuffle for primary-backup replication
 	if newHosts, err = reshuffleHosts(orderHosts); err != nil {
@@ -377,7 +406,7 @@ func (s *RoundRobinNodeSelector) GetName() string {
 	return RoundRobinNodeSelectorName
 }
 
-func (s *RoundRobinNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+func (s *RoundRobinNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int, spread *spreadConstraint) (newHosts []string, peers []proto.Peer, err error) {
 	newHosts = make([]string, 0)
 	peers = make([]proto.Peer, 0)
 	// if replica == 0, return
@@ -402,6 +431,8 @@ func (s *RoundRobinNodeSelector) Select(ns *nodeSet, excludeHosts []string, repl
 		return sortedNodes[i].GetID() < sortedNodes[j].GetID()
 	})
 	nodeIndex := 0
+	selected := make([]Node, 0, replicaNum)
+	picked := spread.clone()
 	// pick first N nodes
 	for i := 0; i < replicaNum && nodeIndex < len(sortedNodes); i++ {
 		selectedIndex := len(sortedNodes)
@@ -409,7 +440,7 @@ func (s *RoundRobinNodeSelector) Select(ns *nodeSet, excludeHosts []string, repl
 		for nodeIndex < len(sortedNodes) {
 			node := sortedNodes[(nodeIndex+s.index)%len(sortedNodes)]
 			nodeIndex += 1
-			if canAllocPartition(node) {
+			if canAllocPartition(node) && picked.allow(node) {
 				if excludeHosts == nil || !contains(excludeHosts, node.GetAddr()) {
 					selectedIndex = nodeIndex - 1
 					break
@@ -419,18 +450,23 @@ func (s *RoundRobinNodeSelector) Select(ns *nodeSet, excludeHosts []string, repl
 		// if we get a writable node, append it to host list
 		if selectedIndex != len(sortedNodes) {
 			node := sortedNodes[(selectedIndex+s.index)%len(sortedNodes)]
-			orderHosts = append(orderHosts, node.GetAddr())
-			node.SelectNodeForWrite()
-			peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr(), ReplicaPort: node.GetReplicaPort(), HeartbeatPort: node.GetHeartbeatPort()}
-			peers = append(peers, peer)
+			picked.take(node)
+			selected = append(selected, node)
 		}
 	}
 	// if we cannot get enough writable nodes, return error
-	if len(orderHosts) < replicaNum {
-		err = fmt.Errorf("action[%vNodeSelector-Select] no enough writable hosts,replicaNum:%v  MatchNodeCount:%v  ",
-			s.GetName(), replicaNum, len(orderHosts))
+	if len(selected) < replicaNum {
+		err = fmt.Errorf("action[%vNodeSelector-Select] no enough writable hosts,replicaNum:%v  MatchNodeCount:%v  spread:%v",
+			s.GetName(), replicaNum, len(selected), spread)
 		return
 	}
+	spread.merge(picked)
+	for _, node := range selected {
+		orderHosts = append(orderHosts, node.GetAddr())
+		node.SelectNodeForWrite()
+		peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr(), ReplicaPort: node.GetReplicaPort(), HeartbeatPort: node.GetHeartbeatPort()}
+		peers = append(peers, peer)
+	}
 	// move the index of selector
 	s.index += nodeIndex
 	log.LogInfof("action[%vNodeSelector-Select] peers[%v]", s.GetName(), peers)
@@ -466,8 +502,8 @@ func (s *StrawNodeSelector) getWeight(node Node) float64 {
 	return float64(node.GetAvailableSpace()) / util.GB
 }
 
-// select a node with max straw and it's ip didn't exist in excludedIpSet
-func (s *StrawNodeSelector) selectOneNode(nodes []Node, excludedIpSet map[string]struct{}) (index int, maxNode Node) {
+// select a node with max straw and it's ip didn't exist in excludedIpSet, and it's failure domain isn't taken
+func (s *StrawNodeSelector) selectOneNode(nodes []Node, excludedIpSet map[string]struct{}, spread *spreadConstraint) (index int, maxNode Node) {
 	maxStraw := float64(0)
 	maxStrawNodeIp := ""
 	index = -1
@@ -479,6 +515,9 @@ func (s *StrawNodeSelector) selectOneNode(nodes []Node, excludedIpSet map[string
 		if _, ok := excludedIpSet[ip]; ok {
 			continue
 		}
+		if !spread.allow(node) {
+			continue
+		}
 
 		straw := float64(s.rand.Intn(StrawNodeSelectorRandMax))
 		straw = math.Log(straw/float64(StrawNodeSelectorRandMax)) / s.getWeight(node)
@@ -496,7 +535,7 @@ func (s *StrawNodeSelector) selectOneNode(nodes []Node, excludedIpSet map[string
 	return
 }
 
-func (s *StrawNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+func (s *StrawNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int, spread *spreadConstraint) (newHosts []string, peers []proto.Peer, err error) {
 	nodes := make([]Node, 0)
 	ns.getNodes(s.nodeType).Range(func(key, value interface{}) bool {
 		node := asNodeWrap(value, s.nodeType)
@@ -518,15 +557,18 @@ func (s *StrawNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNu
 
 	distinctIpSet := make(map[string]struct{})
 	orderHosts := make([]string, 0)
+	selected := make([]Node, 0, replicaNum)
+	picked := spread.clone()
 
 	// select replica number of nodes, try to avoid multiple replicas of a partition locate on same machine
 	// If raftPartitionCanUsingDifferentPort is enabled, candidate nodes may contain nodes with same ip
 
 	// outer loop: select until we get replica number of nodes
-	for len(orderHosts) < replicaNum {
+	for len(selected) < replicaNum {
+		count := len(selected)
 		// for each execution of inner loop, select nodes with distinct ip, try to avoid multiple replicas of a partition locate on same machine
 		for {
-			index, node := s.selectOneNode(nodes, distinctIpSet)
+			index, node := s.selectOneNode(nodes, distinctIpSet, picked)
 			if index == -1 {
 				break
 			}
@@ -536,24 +578,33 @@ func (s *StrawNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNu
 			}
 			nodes = nodes[1:]
 
-			orderHosts = append(orderHosts, node.GetAddr())
-			node.SelectNodeForWrite()
-			peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr(), ReplicaPort: node.GetReplicaPort(), HeartbeatPort: node.GetHeartbeatPort()}
-			peers = append(peers, peer)
-			if len(orderHosts) == replicaNum {
+			picked.take(node)
+			selected = append(selected, node)
+			if len(selected) == replicaNum {
 				break
 			}
 		}
+		// no node can be selected in the failure domains left
+		if count == len(selected) {
+			break
+		}
 		// number of nodes with distinct ip can not satisfy replica requirement
 		distinctIpSet = make(map[string]struct{})
 	}
 
 	// if we cannot get enough writable nodes, return error
-	if len(orderHosts) < replicaNum {
-		err = fmt.Errorf("action[%vNodeSelector-Select] no enough writable hosts,replicaNum:%v  MatchNodeCount:%v  ",
-			s.GetName(), replicaNum, len(orderHosts))
+	if len(selected) < replicaNum {
+		err = fmt.Errorf("action[%vNodeSelector-Select] no enough writable hosts,replicaNum:%v  MatchNodeCount:%v  spread:%v",
+			s.GetName(), replicaNum, len(selected), spread)
 		return
 	}
+	spread.merge(picked)
+	for _, node := range selected {
+		orderHosts = append(orderHosts, node.GetAddr())
+		node.SelectNodeForWrite()
+		peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr(), ReplicaPort: node.GetReplicaPort(), HeartbeatPort: node.GetHeartbeatPort()}
+		peers = append(peers, peer)
+	}
 	log.LogInfof("action[%vNodeSelector-Select] peers[%v]", s.GetName(), peers)
 	// reshuffle for primary-backup replication
 	if newHosts, err = reshuffleHosts(orderHosts); err != nil {
@@ -585,20 +636,20 @@ func NewNodeSelector(name string, nodeType NodeType) NodeSelector {
 	}
 }
 
-func (ns *nodeSet) getAvailMetaNodeHosts(excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+func (ns *nodeSet) getAvailMetaNodeHosts(excludeHosts []string, replicaNum int, spread *spreadConstraint) (newHosts []string, peers []proto.Peer, err error) {
 	ns.nodeSelectLock.Lock()
 	defer ns.nodeSelectLock.Unlock()
 	// we need a read lock to block the modification of node selector
 	ns.metaNodeSelectorLock.RLock()
 	defer ns.metaNodeSelectorLock.RUnlock()
-	return ns.metaNodeSelector.Select(ns, excludeHosts, replicaNum)
+	return ns.metaNodeSelector.Select(ns, excludeHosts, replicaNum, spread)
 }
 
-func (ns *nodeSet) getAvailDataNodeHosts(excludeHosts []string, replicaNum int) (hosts []string, peers []proto.Peer, err error) {
+func (ns *nodeSet) getAvailDataNodeHosts(excludeHosts []string, replicaNum int, spread *spreadConstraint) (hosts []string, peers []proto.Peer, err error) {
 	ns.nodeSelectLock.Lock()
 	defer ns.nodeSelectLock.Unlock()
 	// we need a read lock to block the modification of node selector
 	ns.dataNodeSelectorLock.Lock()
 	defer ns.dataNodeSelectorLock.Unlock()
-	return ns.dataNodeSelector.Select(ns, excludeHosts, replicaNum)
+	return ns.dataNodeSelector.Select(ns, excludeHosts, replicaNum, spread)
 }
@@ -0,0 +1,127 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+const (
+	// the node which has not reported for s3QosLeaseExpireIntervals intervals is not active
+	s3QosLeaseExpireIntervals = 3
+	s3QosMinLeaseInterval     = 1
+	s3QosMaxLeaseInterval     = 60
+)
+
+func s3QosUidKey(api, uid, limitType string) string {
+	return S3QoSPrefix + api + keySeparator + uid + keySeparator + limitType
+}
+
+func s3QosBucketKey(api, bucket, limitType string) string {
+	return S3QoSPrefix + api + keySeparator + proto.S3QoSScopeBucket + keySeparator + bucket + keySeparator + limitType
+}
+
+type s3QosNodeDemand struct {
+	demands map[string]uint64
+	expire  time.Time
+}
+
+// s3QosLeaseMgr leases the shares of the s3 qos quotas to the ObjectNodes by their demands,
+// the leases are kept in the memory of the leader only, and rebuilt by the reports of the
+// ObjectNodes after the leader is changed.
+type s3QosLeaseMgr struct {
+	sync.Mutex
+	nodes map[string]*s3QosNodeDemand // node --> demands
+}
+
+func newS3QosLeaseMgr() *s3QosLeaseMgr {
+	return &s3QosLeaseMgr{nodes: make(map[string]*s3QosNodeDemand)}
+}
+
+// lease records the demands of the node, and returns the shares of the limits demanded by it.
+// The quotas of the limits are looked up by the quota function, the limits without quota are
+// not leased.
+func (m *s3QosLeaseMgr) lease(req *proto.S3QoSLeaseRequest, quota func(key string) uint64, now time.Time) *proto.S3QoSLeaseResponse {
+	interval := req.Interval
+	if interval < s3QosMinLeaseInterval {
+		interval = s3QosMinLeaseInterval
+	}
+	if interval > s3QosMaxLeaseInterval {
+		interval = s3QosMaxLeaseInterval
+	}
+	expire := time.Duration(interval*s3QosLeaseExpireIntervals) * time.Second
+
+	m.Lock()
+	defer m.Unlock()
+	m.nodes[req.Node] = &s3QosNodeDemand{demands: req.Demands, expire: now.Add(expire)}
+	nodes := make([]string, 0, len(m.nodes))
+	for node, demand := range m.nodes {
+		if now.After(demand.expire) {
+			delete(m.nodes, node)
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	// the nodes are sorted so that the spare quota is divided stably
+	sort.Strings(nodes)
+
+	resp := &proto.S3QoSLeaseResponse{
+		Shares: make(map[string]uint64, len(req.Demands)),
+		Nodes:  uint64(len(nodes)),
+		Expire: int64(expire / time.Second),
+	}
+	demands := make([]uint64, len(nodes))
+	for key := range req.Demands {
+		total := quota(key)
+		if total == 0 {
+			continue
+		}
+		self := 0
+		for i, node := range nodes {
+			if node == req.Node {
+				self = i
+			}
+			demands[i] = m.nodes[node].demands[key]
+		}
+		resp.Shares[key] = proto.AllocateS3QoSShares(total, demands)[self]
+	}
+	return resp
+}
+
+// s3QosQuota returns the quota of the cluster for the limit key, the quota of the default
+// uid is used if the quota of the uid is not set.
+func (c *Cluster) s3QosQuota(key string) uint64 {
+	scope, api, id, limitType, ok := proto.ParseS3QoSLimitKey(key)
+	if !ok {
+		return 0
+	}
+	load := func(k string) uint64 {
+		if value, exist := c.S3ApiQosQuota.Load(k); exist {
+			return value.(uint64)
+		}
+		return 0
+	}
+	if scope == proto.S3QoSScopeBucket {
+		return load(s3QosBucketKey(api, id, limitType))
+	}
+	if quota := load(s3QosUidKey(api, id, limitType)); quota != 0 {
+		return quota
+	}
+	return load(s3QosUidKey(api, proto.DefaultUid, limitType))
+}
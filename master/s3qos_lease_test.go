@@ -0,0 +1,102 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+func TestS3QosLease(t *testing.T) {
+	c := &Cluster{S3ApiQosQuota: new(sync.Map)}
+	c.S3ApiQosQuota.Store(s3QosUidKey("getobject", proto.DefaultUid, proto.QPSLimit), uint64(1000))
+	c.S3ApiQosQuota.Store(s3QosBucketKey("getobject", "photos", proto.FlowLimit), uint64(4000))
+
+	uidKey := proto.S3QoSLimitKey(proto.S3QoSScopeUid, "getobject", "alice", proto.QPSLimit)
+	bucketKey := proto.S3QoSLimitKey(proto.S3QoSScopeBucket, "getobject", "photos", proto.FlowLimit)
+	otherKey := proto.S3QoSLimitKey(proto.S3QoSScopeBucket, "getobject", "docs", proto.FlowLimit)
+	if quota := c.s3QosQuota(uidKey); quota != 1000 {
+		t.Errorf("quota of the default uid expected 1000, got %v", quota)
+	}
+
+	mgr := newS3QosLeaseMgr()
+	now := time.Now()
+	// the idle node reports nothing
+	resp := mgr.lease(&proto.S3QoSLeaseRequest{Node: "node2", Interval: 5}, c.s3QosQuota, now)
+	if resp.Nodes != 1 || len(resp.Shares) != 0 || resp.Expire != 15 {
+		t.Errorf("unexpected lease of the idle node: %+v", resp)
+	}
+	// the busy node takes most of the quota
+	resp = mgr.lease(&proto.S3QoSLeaseRequest{
+		Node: "node1", Interval: 5,
+		Demands: map[string]uint64{uidKey: 5000, bucketKey: 100, otherKey: 100},
+	}, c.s3QosQuota, now)
+	if resp.Nodes != 2 {
+		t.Errorf("active nodes expected 2, got %v", resp.Nodes)
+	}
+	if resp.Shares[uidKey] != 950 {
+		t.Errorf("share of uid expected 950, got %v", resp.Shares[uidKey])
+	}
+	if share := resp.Shares[bucketKey]; share < 100 || share > 4000 {
+		t.Errorf("share of bucket expected in [100, 4000], got %v", share)
+	}
+	if _, ok := resp.Shares[otherKey]; ok {
+		t.Errorf("the limit without quota should not be leased")
+	}
+
+	// the node which does not report any more is expired
+	resp = mgr.lease(&proto.S3QoSLeaseRequest{
+		Node: "node1", Interval: 5, Demands: map[string]uint64{uidKey: 5000},
+	}, c.s3QosQuota, now.Add(time.Minute))
+	if resp.Nodes != 1 || resp.Shares[uidKey] != 1000 {
+		t.Errorf("unexpected lease after the node expired: %+v", resp)
+	}
+}
+
+func TestS3QosParamKey(t *testing.T) {
+	uidKey := s3QosParamKey(&proto.S3QosRequest{Uid: "Default", Api: "GetObject", Type: proto.QPSLimit})
+	if uidKey != s3QosUidKey("getobject", proto.DefaultUid, proto.QPSLimit) {
+		t.Errorf("unexpected uid key %v", uidKey)
+	}
+	api, uid, limitType, _, err := parseS3QoSKey(uidKey)
+	if err != nil || api != "getobject" || uid != proto.DefaultUid || limitType != proto.QPSLimit {
+		t.Errorf("unexpected parsed uid key: %v %v %v %v", api, uid, limitType, err)
+	}
+
+	bucketKey := s3QosParamKey(&proto.S3QosRequest{Bucket: "photos", Api: "GetObject", Type: proto.FlowLimit})
+	api, uid, limitType, _, err = parseS3QoSKey(bucketKey)
+	if err != nil || api != "getobject" || limitType != proto.FlowLimit {
+		t.Errorf("unexpected parsed bucket key: %v %v %v %v", api, uid, limitType, err)
+	}
+	if bucket, ok := parseS3QoSBucket(uid); !ok || bucket != "photos" {
+		t.Errorf("unexpected bucket %v", uid)
+	}
+
+	for _, param := range []*proto.S3QosRequest{
+		{Bucket: "photos", Uid: "alice", Api: "getobject", Type: proto.QPSLimit},
+		{Bucket: "photos", Api: "getobject", Type: proto.ConcurrentLimit},
+		{Bucket: "photos", Api: "putobject", Type: proto.QPSLimit},
+	} {
+		if isS3QosConfigValid(param) {
+			t.Errorf("param should be invalid: %+v", param)
+		}
+	}
+	if !isS3QosConfigValid(&proto.S3QosRequest{Bucket: "photos", Api: "put", Type: proto.FlowLimit}) {
+		t.Errorf("quota of the bucket should be valid")
+	}
+}
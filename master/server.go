@@ -65,6 +65,8 @@ const (
 	AuthNodeHost             = "authNodeHost"
 	AuthNodeEnableHTTPS      = "authNodeEnableHTTPS"
 	AuthNodeCertFile         = "authNodeCertFile"
+	cfgEnableAPIToken        = "enableApiToken"
+	cfgBootstrapAPIToken     = "bootstrapApiToken"
 )
 
 var (
@@ -127,6 +129,9 @@ type Server struct {
 	apiServer       *http.Server
 	cliMgr          *ClientMgr
 	leaderChangeLk  sync.RWMutex
+	// the admin APIs require the API tokens granted their roles if enabled
+	enableAPIToken    bool
+	bootstrapAPIToken string
 }
 
 // NewServer creates a new server
@@ -172,6 +177,17 @@ func (m *Server) Start(cfg *config.Config) (err error) {
 	if m.cluster.authenticate {
 		m.cluster.initAuthentication(cfg)
 	}
+	m.enableAPIToken = cfg.GetBool(cfgEnableAPIToken)
+	m.bootstrapAPIToken = cfg.GetString(cfgBootstrapAPIToken)
+	if m.enableAPIToken && m.bootstrapAPIToken == "" {
+		log.LogWarnf("action[Start] api token enabled without the bootstrap token, only the stored tokens are accepted")
+	}
+	// the token sent with the data partitions pushed to the followers, which requires the node operator
+	masterAPIToken := cfg.GetString(proto.MasterAPIToken)
+	if masterAPIToken == "" {
+		masterAPIToken = m.bootstrapAPIToken
+	}
+	m.cluster.masterClient.SetAPIToken(masterAPIToken)
 	WarnMetrics = newWarningMetrics(m.cluster)
 	m.cluster.scheduleTask()
 	m.startHTTPService(ModuleName, cfg)
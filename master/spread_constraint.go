@@ -0,0 +1,340 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// the partitions violating the spread constraint repaired in one round, the replicas
+// are migrated one by one, so that the repairs never overload the cluster.
+const defaultSpreadRepairLimit = 5
+
+// spreadConstraint keeps the replicas of a partition in distinct failure domains, which are the values
+// of the topology label of the nodes, such as the racks. The node without the label is a domain of its
+// own, so that the clusters not labeled yet are placed as before. The nil constraint allows any node.
+type spreadConstraint struct {
+	label   string
+	domains map[string]struct{} // the domains taken by the replicas
+}
+
+func newSpreadConstraint(label string) *spreadConstraint {
+	if label == "" {
+		return nil
+	}
+	return &spreadConstraint{
+		label:   label,
+		domains: make(map[string]struct{}),
+	}
+}
+
+func (s *spreadConstraint) String() string {
+	if s == nil {
+		return "none"
+	}
+	domains := make([]string, 0, len(s.domains))
+	for domain := range s.domains {
+		domains = append(domains, domain)
+	}
+	return fmt.Sprintf("label(%v) taken(%v)", s.label, domains)
+}
+
+// allow returns true if the node is in a domain not taken yet, the node without the label is always allowed.
+func (s *spreadConstraint) allow(node Node) bool {
+	if s == nil {
+		return true
+	}
+	domain, ok := node.GetLabels()[s.label]
+	if !ok {
+		return true
+	}
+	_, taken := s.domains[domain]
+	return !taken
+}
+
+func (s *spreadConstraint) take(node Node) {
+	if s == nil {
+		return
+	}
+	if domain, ok := node.GetLabels()[s.label]; ok {
+		s.domains[domain] = struct{}{}
+	}
+}
+
+// clone returns the copy to select with, which is merged back only if the selection succeeds.
+func (s *spreadConstraint) clone() *spreadConstraint {
+	if s == nil {
+		return nil
+	}
+	c := newSpreadConstraint(s.label)
+	for domain := range s.domains {
+		c.domains[domain] = struct{}{}
+	}
+	return c
+}
+
+func (s *spreadConstraint) merge(c *spreadConstraint) {
+	if s == nil || c == nil {
+		return
+	}
+	for domain := range c.domains {
+		s.domains[domain] = struct{}{}
+	}
+}
+
+func (c *Cluster) getNodeByType(nodeType uint32, addr string) (Node, error) {
+	if nodeType == TypeDataPartition {
+		return c.dataNode(addr)
+	}
+	return c.metaNode(addr)
+}
+
+// newSpreadConstraint returns the constraint of the volume, the domains of the hosts, which are
+// the replicas kept when the others are placed, are taken.
+func (c *Cluster) newSpreadConstraint(volName string, nodeType uint32, hosts []string) *spreadConstraint {
+	vol, err := c.getVol(volName)
+	if err != nil {
+		return nil
+	}
+	spread := newSpreadConstraint(vol.spreadLabel)
+	if spread == nil {
+		return nil
+	}
+	for _, host := range hosts {
+		if node, err := c.getNodeByType(nodeType, host); err == nil {
+			spread.take(node)
+		}
+	}
+	return spread
+}
+
+// keptHosts returns the hosts kept when the replica on the source is migrated.
+func keptHosts(hosts []string, srcAddr string) []string {
+	kept := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if host != srcAddr {
+			kept = append(kept, host)
+		}
+	}
+	return kept
+}
+
+// checkSpreadTarget checks whether the replica on the target keeps the spread constraint with the other hosts.
+func (c *Cluster) checkSpreadTarget(volName string, nodeType uint32, hosts []string, target string) (err error) {
+	spread := c.newSpreadConstraint(volName, nodeType, hosts)
+	if spread == nil {
+		return
+	}
+	var node Node
+	if node, err = c.getNodeByType(nodeType, target); err != nil {
+		return
+	}
+	if !spread.allow(node) {
+		return fmt.Errorf("target[%v] violates the spread constraint of vol[%v], %v", target, volName, spread)
+	}
+	return
+}
+
+// checkSpreadConstraint checks whether the hosts of the partition are in distinct failure domains.
+func (c *Cluster) checkSpreadConstraint(volName string, nodeType uint32, hosts []string) (err error) {
+	vol, err := c.getVol(volName)
+	if err != nil {
+		return nil
+	}
+	if _, violated := c.spreadDomains(vol.spreadLabel, nodeType, hosts); violated {
+		return fmt.Errorf("hosts[%v] violate the spread label[%v] of vol[%v]", hosts, vol.spreadLabel, volName)
+	}
+	return nil
+}
+
+// spreadDomains returns the failure domains of the hosts, and whether any two of them share a domain.
+// The domain of the host without the label is empty, which is never shared.
+func (c *Cluster) spreadDomains(label string, nodeType uint32, hosts []string) (domains []string, violated bool) {
+	if label == "" {
+		return
+	}
+	domains = make([]string, len(hosts))
+	seen := make(map[string]struct{}, len(hosts))
+	for i, host := range hosts {
+		node, err := c.getNodeByType(nodeType, host)
+		if err != nil {
+			// the node is removed, which is handled by the decommission
+			continue
+		}
+		domain, ok := node.GetLabels()[label]
+		if !ok {
+			continue
+		}
+		if _, exist := seen[domain]; exist {
+			violated = true
+		}
+		seen[domain] = struct{}{}
+		domains[i] = domain
+	}
+	return
+}
+
+// spreadRepairSource returns the replica to migrate, which is the last one sharing the domain
+// with another replica.
+func spreadRepairSource(hosts, domains []string) string {
+	seen := make(map[string]struct{}, len(domains))
+	src := ""
+	for i, domain := range domains {
+		if domain == "" {
+			continue
+		}
+		if _, exist := seen[domain]; exist {
+			src = hosts[i]
+		}
+		seen[domain] = struct{}{}
+	}
+	return src
+}
+
+func (c *Cluster) scheduleToCheckSpreadConstraint() {
+	c.runTask(&cTask{
+		tickTime: time.Second * time.Duration(c.cfg.IntervalToCheckDataPartition),
+		name:     "scheduleToCheckSpreadConstraint",
+		function: func() (fin bool) {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.checkSpreadConstraints()
+			}
+			return
+		},
+	})
+}
+
+// checkSpreadConstraints reports the partitions violating the spread constraints of the volumes.
+// If the spread repair of the cluster is enabled, some of them are repaired by migrating the replicas
+// to the nodes in the other failure domains.
+func (c *Cluster) checkSpreadConstraints() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("checkSpreadConstraints occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"checkSpreadConstraints occurred panic")
+		}
+	}()
+
+	dpViolations := make(map[uint64]*proto.SpreadViolation)
+	mpViolations := make(map[uint64]*proto.SpreadViolation)
+	repairs := 0
+	repairLimit := 0
+	if c.getEnableSpreadRepair() {
+		repairLimit = defaultSpreadRepairLimit
+	}
+	for _, vol := range c.allVols() {
+		if vol.spreadLabel == "" || vol.status() == proto.VolStatusMarkDelete {
+			continue
+		}
+		vol.dataPartitions.Range(func(dp *DataPartition) bool {
+			dp.RLock()
+			hosts := make([]string, len(dp.Hosts))
+			copy(hosts, dp.Hosts)
+			dp.RUnlock()
+			domains, violated := c.spreadDomains(vol.spreadLabel, TypeDataPartition, hosts)
+			if !violated {
+				return true
+			}
+			violation := &proto.SpreadViolation{
+				PartitionID:   dp.PartitionID,
+				PartitionType: "data",
+				VolName:       vol.Name,
+				SpreadLabel:   vol.spreadLabel,
+				Hosts:         hosts,
+				Domains:       domains,
+			}
+			dpViolations[dp.PartitionID] = violation
+			if repairs < repairLimit && !dp.IsDecommissionRunning() && !dp.isRecover {
+				if src := spreadRepairSource(hosts, domains); src != "" {
+					repairs++
+					violation.Repairing = true
+					if err := c.migrateDataPartition(src, "", dp, false, "spread constraint violated"); err != nil {
+						log.LogWarnf("action[checkSpreadConstraints] vol[%v] dp[%v] migrate from[%v] failed, err[%v]",
+							vol.Name, dp.PartitionID, src, err)
+						violation.Repairing = false
+					}
+				}
+			}
+			return true
+		})
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			hosts := make([]string, len(mp.Hosts))
+			copy(hosts, mp.Hosts)
+			isRecover := mp.IsRecover
+			mp.RUnlock()
+			domains, violated := c.spreadDomains(vol.spreadLabel, TypeMetaPartition, hosts)
+			if !violated {
+				continue
+			}
+			violation := &proto.SpreadViolation{
+				PartitionID:   mp.PartitionID,
+				PartitionType: "meta",
+				VolName:       vol.Name,
+				SpreadLabel:   vol.spreadLabel,
+				Hosts:         hosts,
+				Domains:       domains,
+			}
+			mpViolations[mp.PartitionID] = violation
+			if repairs < repairLimit && !isRecover {
+				if src := spreadRepairSource(hosts, domains); src != "" {
+					repairs++
+					violation.Repairing = true
+					if err := c.migrateMetaPartition(src, "", mp); err != nil {
+						log.LogWarnf("action[checkSpreadConstraints] vol[%v] mp[%v] migrate from[%v] failed, err[%v]",
+							vol.Name, mp.PartitionID, src, err)
+						violation.Repairing = false
+					}
+				}
+			}
+		}
+	}
+
+	resetSpreadViolations(&c.SpreadViolationDps, dpViolations)
+	resetSpreadViolations(&c.SpreadViolationMps, mpViolations)
+	if len(dpViolations) > 0 || len(mpViolations) > 0 {
+		Warn(c.Name, fmt.Sprintf("clusterID[%v] %v data partitions and %v meta partitions violate the spread constraints, %v being repaired",
+			c.Name, len(dpViolations), len(mpViolations), repairs))
+	}
+}
+
+func resetSpreadViolations(m *sync.Map, violations map[uint64]*proto.SpreadViolation) {
+	m.Range(func(key, _ interface{}) bool {
+		if _, ok := violations[key.(uint64)]; !ok {
+			m.Delete(key)
+		}
+		return true
+	})
+	for id, violation := range violations {
+		m.Store(id, violation)
+	}
+}
+
+func (c *Cluster) getSpreadViolations() (violations []*proto.SpreadViolation) {
+	violations = make([]*proto.SpreadViolation, 0)
+	for _, m := range []*sync.Map{&c.SpreadViolationDps, &c.SpreadViolationMps} {
+		m.Range(func(_, value interface{}) bool {
+			violations = append(violations, value.(*proto.SpreadViolation))
+			return true
+		})
+	}
+	return
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cubefs/cubefs/util"
+)
+
+// newSpreadTestNodeSet returns a node set with two data nodes in each of the racks.
+func newSpreadTestNodeSet(racks ...string) *nodeSet {
+	ns := &nodeSet{dataNodes: new(sync.Map), metaNodes: new(sync.Map)}
+	id := uint64(0)
+	for _, rack := range racks {
+		for i := 0; i < 2; i++ {
+			id++
+			node := &DataNode{
+				ID:             id,
+				Addr:           fmt.Sprintf("192.168.0.%v:17310", id),
+				Total:          100 * util.GB,
+				AvailableSpace: 100 * util.GB,
+				isActive:       true,
+				Labels:         map[string]string{"rack": rack},
+			}
+			ns.dataNodes.Store(node.Addr, node)
+		}
+	}
+	return ns
+}
+
+func TestNodeSelectorSpreadConstraint(t *testing.T) {
+	for _, name := range []string{CarryWeightNodeSelectorName, AvailableSpaceFirstNodeSelectorName, RoundRobinNodeSelectorName, StrawNodeSelectorName} {
+		ns := newSpreadTestNodeSet("r1", "r2", "r3")
+		selector := NewNodeSelector(name, DataNodeType)
+		spread := newSpreadConstraint("rack")
+		hosts, _, err := selector.Select(ns, nil, 3, spread)
+		if err != nil {
+			t.Errorf("%v failed to select nodes %v", name, err)
+			continue
+		}
+		racks := make(map[string]struct{})
+		for _, host := range hosts {
+			value, _ := ns.dataNodes.Load(host)
+			racks[value.(*DataNode).Labels["rack"]] = struct{}{}
+		}
+		if len(racks) != 3 || len(spread.domains) != 3 {
+			t.Errorf("%v selected hosts %v in racks %v, spread %v", name, hosts, racks, spread)
+		}
+
+		// all the racks are taken
+		if _, _, err = selector.Select(ns, nil, 1, spread); err == nil {
+			t.Errorf("%v selected nodes in the racks taken", name)
+		}
+		// the constraint is left as it was if the selection fails
+		spread = newSpreadConstraint("rack")
+		if _, _, err = selector.Select(ns, nil, 4, spread); err == nil || len(spread.domains) != 0 {
+			t.Errorf("%v selected 4 replicas in 3 racks, spread %v err %v", name, spread, err)
+		}
+		// the nodes without the label are in domains of their own
+		if _, _, err = selector.Select(ns, nil, 4, newSpreadConstraint("pdu")); err != nil {
+			t.Errorf("%v failed to select the nodes without the label %v", name, err)
+		}
+		// no constraint
+		if _, _, err = selector.Select(ns, nil, 4, nil); err != nil {
+			t.Errorf("%v failed to select nodes without constraint %v", name, err)
+		}
+	}
+}
+
+func TestSpreadRepairSource(t *testing.T) {
+	hosts := []string{"a", "b", "c"}
+	if src := spreadRepairSource(hosts, []string{"r1", "r2", "r3"}); src != "" {
+		t.Errorf("repair source of distinct domains should be empty, got %v", src)
+	}
+	if src := spreadRepairSource(hosts, []string{"r1", "r1", "r2"}); src != "b" {
+		t.Errorf("repair source should be b, got %v", src)
+	}
+	if src := spreadRepairSource(hosts, []string{"r1", "r2", "r2"}); src != "c" {
+		t.Errorf("repair source should be c, got %v", src)
+	}
+	if src := spreadRepairSource(hosts, []string{"", "r2", "r3"}); src != "" {
+		t.Errorf("repair source of the host without the label should be empty, got %v", src)
+	}
+	if src := spreadRepairSource(hosts, []string{"", "", "r3"}); src != "" {
+		t.Errorf("hosts without the label should not share a domain, got %v", src)
+	}
+}
+
+func TestSpreadConstraintNil(t *testing.T) {
+	var spread *spreadConstraint
+	node := &DataNode{Labels: map[string]string{"rack": "r1"}}
+	if !spread.allow(node) || spread.clone() != nil || newSpreadConstraint("") != nil {
+		t.Errorf("nil constraint should allow any node")
+	}
+	spread.take(node)
+	spread.merge(newSpreadConstraint("rack"))
+
+	spread = newSpreadConstraint("rack")
+	if !spread.allow(node) {
+		t.Errorf("node in the free rack should be allowed")
+	}
+	spread.take(node)
+	if spread.allow(node) {
+		t.Errorf("node in the rack taken should not be allowed")
+	}
+	unlabeled := &DataNode{}
+	spread.take(unlabeled)
+	if !spread.allow(unlabeled) || len(spread.domains) != 1 {
+		t.Errorf("node without the label should always be allowed, spread %v", spread)
+	}
+	if kept := keptHosts([]string{"a", "b", "c"}, "b"); len(kept) != 2 || kept[0] != "a" || kept[1] != "c" {
+		t.Errorf("kept hosts should be [a c], got %v", kept)
+	}
+}
@@ -28,9 +28,13 @@ type User struct {
 	userStore      sync.Map // K: userID, V: UserInfo
 	AKStore        sync.Map // K: ak, V: userID
 	volUser        sync.Map // K: vol, V: userIDs
+	roleStore      sync.Map // K: ownerID/roleName, V: RoleInfo
+	apiTokenStore  sync.Map // K: token name, V: apiToken
 	userStoreMutex sync.RWMutex
 	AKStoreMutex   sync.RWMutex
 	volUserMutex   sync.RWMutex
+	roleStoreMutex sync.RWMutex
+	apiTokenMutex  sync.RWMutex
 }
 
 func newUser(fsm *MetadataFsm, partition raftstore.Partition) (u *User) {
@@ -152,6 +156,8 @@ func (u *User) deleteKey(userID string) (err error) {
 	u.AKStore.Delete(akUser.AccessKey)
 	// delete userID from related policy in volUserStore
 	u.removeUserFromAllVol(userID)
+	// the roles of the user can not be assumed any more
+	u.deleteRolesOfOwner(userID)
 	log.LogInfof("action[deleteUser], userID: %v, accesskey[%v]", userID, userInfo.AccessKey)
 	return
 }
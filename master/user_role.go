@@ -0,0 +1,175 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+var roleNameRegexp = regexp.MustCompile(`^[\w+=,.@-]{1,64}$`)
+
+func roleKey(ownerID, roleName string) string {
+	return ownerID + "/" + roleName
+}
+
+// validRolePolicy checks the policy is a JSON object, the statements of the
+// policy are evaluated by the object nodes when the role is assumed.
+func validRolePolicy(policy string) bool {
+	var doc map[string]interface{}
+	return json.Unmarshal([]byte(policy), &doc) == nil && len(doc) > 0
+}
+
+func (u *User) createRole(param *proto.RoleParam) (roleInfo *proto.RoleInfo, err error) {
+	if !roleNameRegexp.MatchString(param.RoleName) {
+		err = proto.ErrInvalidRole
+		return
+	}
+	if !validRolePolicy(param.TrustPolicy) || !validRolePolicy(param.PermissionPolicy) {
+		err = proto.ErrInvalidRole
+		return
+	}
+	maxSessionDuration := param.MaxSessionDuration
+	if maxSessionDuration == 0 {
+		maxSessionDuration = proto.RoleDefaultSessionDuration
+	}
+	if maxSessionDuration < proto.RoleDefaultSessionDuration || maxSessionDuration > proto.RoleMaxSessionDuration {
+		err = proto.ErrInvalidRole
+		return
+	}
+	if _, err = u.getUserInfo(param.OwnerID); err != nil {
+		return
+	}
+
+	u.roleStoreMutex.Lock()
+	defer u.roleStoreMutex.Unlock()
+	key := roleKey(param.OwnerID, param.RoleName)
+	if _, exist := u.roleStore.Load(key); exist {
+		err = proto.ErrDuplicateRole
+		return
+	}
+	roleInfo = &proto.RoleInfo{
+		RoleName: param.RoleName, OwnerID: param.OwnerID, TrustPolicy: param.TrustPolicy,
+		PermissionPolicy: param.PermissionPolicy, MaxSessionDuration: maxSessionDuration,
+		Description: param.Description, CreateTime: time.Unix(time.Now().Unix(), 0).Format(proto.TimeFormat),
+		// the role recreated with the same name never matches the version of the deleted one
+		Version: uint64(time.Now().UnixNano()),
+	}
+	if err = u.syncAddRoleInfo(roleInfo); err != nil {
+		return
+	}
+	u.roleStore.Store(key, roleInfo)
+	log.LogInfof("action[createRole], owner: %v, role: %v", param.OwnerID, param.RoleName)
+	return
+}
+
+func (u *User) updateRole(param *proto.RoleParam) (roleInfo *proto.RoleInfo, err error) {
+	u.roleStoreMutex.Lock()
+	defer u.roleStoreMutex.Unlock()
+	var current *proto.RoleInfo
+	if current, err = u.getRole(param.OwnerID, param.RoleName); err != nil {
+		return
+	}
+	newRole := *current
+	if param.TrustPolicy != "" {
+		if !validRolePolicy(param.TrustPolicy) {
+			err = proto.ErrInvalidRole
+			return
+		}
+		newRole.TrustPolicy = param.TrustPolicy
+	}
+	if param.PermissionPolicy != "" {
+		if !validRolePolicy(param.PermissionPolicy) {
+			err = proto.ErrInvalidRole
+			return
+		}
+		newRole.PermissionPolicy = param.PermissionPolicy
+	}
+	if param.MaxSessionDuration != 0 {
+		if param.MaxSessionDuration < proto.RoleDefaultSessionDuration || param.MaxSessionDuration > proto.RoleMaxSessionDuration {
+			err = proto.ErrInvalidRole
+			return
+		}
+		newRole.MaxSessionDuration = param.MaxSessionDuration
+	}
+	if param.Description != "" {
+		newRole.Description = param.Description
+	}
+	newRole.Version++
+	if err = u.syncUpdateRoleInfo(&newRole); err != nil {
+		return
+	}
+	roleInfo = &newRole
+	u.roleStore.Store(roleKey(roleInfo.OwnerID, roleInfo.RoleName), roleInfo)
+	log.LogInfof("action[updateRole], owner: %v, role: %v", param.OwnerID, param.RoleName)
+	return
+}
+
+func (u *User) deleteRole(ownerID, roleName string) (err error) {
+	u.roleStoreMutex.Lock()
+	defer u.roleStoreMutex.Unlock()
+	var roleInfo *proto.RoleInfo
+	if roleInfo, err = u.getRole(ownerID, roleName); err != nil {
+		return
+	}
+	if err = u.syncDeleteRoleInfo(roleInfo); err != nil {
+		return
+	}
+	u.roleStore.Delete(roleKey(ownerID, roleName))
+	log.LogInfof("action[deleteRole], owner: %v, role: %v", ownerID, roleName)
+	return
+}
+
+// deleteRolesOfOwner deletes the roles of the deleted user, which can not be assumed any more.
+func (u *User) deleteRolesOfOwner(ownerID string) {
+	for _, roleInfo := range u.getRolesOfOwner(ownerID) {
+		if err := u.deleteRole(ownerID, roleInfo.RoleName); err != nil {
+			log.LogWarnf("action[deleteRolesOfOwner], owner: %v, role: %v, err: %v", ownerID, roleInfo.RoleName, err)
+		}
+	}
+}
+
+func (u *User) getRole(ownerID, roleName string) (roleInfo *proto.RoleInfo, err error) {
+	value, exist := u.roleStore.Load(roleKey(ownerID, roleName))
+	if !exist {
+		err = proto.ErrRoleNotExists
+		return
+	}
+	roleInfo = value.(*proto.RoleInfo)
+	return
+}
+
+func (u *User) getRolesOfOwner(ownerID string) (roles []*proto.RoleInfo) {
+	roles = make([]*proto.RoleInfo, 0)
+	u.roleStore.Range(func(key, value interface{}) bool {
+		roleInfo := value.(*proto.RoleInfo)
+		if roleInfo.OwnerID == ownerID {
+			roles = append(roles, roleInfo)
+		}
+		return true
+	})
+	return
+}
+
+func (u *User) clearRoleStore() {
+	u.roleStore.Range(func(key, value interface{}) bool {
+		u.roleStore.Delete(key)
+		return true
+	})
+}
@@ -103,6 +103,50 @@ func (u *User) syncPutVolUser(opType uint32, volUser *proto.VolUser) (err error)
 	return u.submit(userInfo)
 }
 
+// key = #role#ownerID/roleName, value = roleInfo
+func (u *User) syncAddRoleInfo(roleInfo *proto.RoleInfo) (err error) {
+	return u.syncPutRoleInfo(opSyncAddRoleInfo, roleInfo)
+}
+
+func (u *User) syncDeleteRoleInfo(roleInfo *proto.RoleInfo) (err error) {
+	return u.syncPutRoleInfo(opSyncDeleteRoleInfo, roleInfo)
+}
+
+func (u *User) syncUpdateRoleInfo(roleInfo *proto.RoleInfo) (err error) {
+	return u.syncPutRoleInfo(opSyncUpdateRoleInfo, roleInfo)
+}
+
+func (u *User) syncPutRoleInfo(opType uint32, roleInfo *proto.RoleInfo) (err error) {
+	raftCmd := new(RaftCmd)
+	raftCmd.Op = opType
+	raftCmd.K = rolePrefix + roleKey(roleInfo.OwnerID, roleInfo.RoleName)
+	raftCmd.V, err = json.Marshal(roleInfo)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	return u.submit(raftCmd)
+}
+
+// key = #apitoken#name, value = apiToken
+func (u *User) syncAddAPIToken(t *apiToken) (err error) {
+	return u.syncPutAPIToken(opSyncAddAPIToken, t)
+}
+
+func (u *User) syncDeleteAPIToken(t *apiToken) (err error) {
+	return u.syncPutAPIToken(opSyncDeleteAPIToken, t)
+}
+
+func (u *User) syncPutAPIToken(opType uint32, t *apiToken) (err error) {
+	raftCmd := new(RaftCmd)
+	raftCmd.Op = opType
+	raftCmd.K = apiTokenPrefix + t.Name
+	raftCmd.V, err = json.Marshal(t)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	return u.submit(raftCmd)
+}
+
 func (u *User) loadUserStore() (err error) {
 	result, err := u.fsm.store.SeekForPrefix([]byte(userPrefix))
 	if err != nil {
@@ -156,3 +200,39 @@ func (u *User) loadVolUsers() (err error) {
 	}
 	return
 }
+
+func (u *User) loadRoleStore() (err error) {
+	result, err := u.fsm.store.SeekForPrefix([]byte(rolePrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadRoleStore], err: %v", err.Error())
+		return err
+	}
+	for _, value := range result {
+		roleInfo := &proto.RoleInfo{}
+		if err = json.Unmarshal(value, roleInfo); err != nil {
+			err = fmt.Errorf("action[loadRoleStore], unmarshal err: %v", err.Error())
+			return err
+		}
+		u.roleStore.Store(roleKey(roleInfo.OwnerID, roleInfo.RoleName), roleInfo)
+		log.LogInfof("action[loadRoleStore], owner[%v], role[%v]", roleInfo.OwnerID, roleInfo.RoleName)
+	}
+	return
+}
+
+func (u *User) loadAPITokenStore() (err error) {
+	result, err := u.fsm.store.SeekForPrefix([]byte(apiTokenPrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadAPITokenStore], err: %v", err.Error())
+		return err
+	}
+	for _, value := range result {
+		t := &apiToken{}
+		if err = json.Unmarshal(value, t); err != nil {
+			err = fmt.Errorf("action[loadAPITokenStore], unmarshal err: %v", err.Error())
+			return err
+		}
+		u.apiTokenStore.Store(t.Name, t)
+		log.LogInfof("action[loadAPITokenStore], name[%v], role[%v]", t.Name, t.Role)
+	}
+	return
+}
@@ -35,6 +35,7 @@ import (
 type VolVarargs struct {
 	zoneName                 string
 	description              string
+	spreadLabel              string
 	capacity                 uint64 // GB
 	deleteLockTime           int64  // h
 	followerRead             bool
@@ -149,6 +150,7 @@ type Vol struct {
 	user          *User
 	createTime    int64
 	description   string
+	spreadLabel   string // the replicas are spread over the nodes with distinct values of the topology label
 	TrashInterval int64
 
 	dpReplicaNum      uint8
@@ -228,6 +230,7 @@ func newVol(vv volValue) (vol *Vol) {
 	vol.createTime = vv.CreateTime
 	vol.DeleteLockTime = vv.DeleteLockTime
 	vol.description = vv.Description
+	vol.spreadLabel = vv.SpreadLabel
 	vol.defaultPriority = vv.DefaultPriority
 	vol.domainId = vv.DomainId
 	vol.enablePosixAcl = vv.EnablePosixAcl
@@ -550,12 +553,16 @@ func (vol *Vol) metaPartition(partitionID uint64) (mp *MetaPartition, err error)
 	return
 }
 
+// maxMetaPartitionID returns the id of the rear meta partition, which covers the largest inode range.
+// It is not always the largest id since a meta partition may be split at an inode boundary.
 func (vol *Vol) maxMetaPartitionID() (maxPartitionID uint64) {
 	vol.mpsLock.RLock()
 	defer vol.mpsLock.RUnlock()
-	for id := range vol.MetaPartitions {
-		if id > maxPartitionID {
+	var maxStart uint64
+	for id, mp := range vol.MetaPartitions {
+		if maxPartitionID == 0 || mp.Start > maxStart {
 			maxPartitionID = id
+			maxStart = mp.Start
 		}
 	}
 	return
@@ -1707,7 +1714,7 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 		var excludeZone []string
 		zoneNum := c.decideZoneNum(vol, proto.StorageClass_Unspecified)
 		if hosts, peers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, nil, nil,
-			int(vol.mpReplicaNum), zoneNum, vol.zoneName, proto.StorageClass_Unspecified); err != nil {
+			int(vol.mpReplicaNum), zoneNum, vol.zoneName, proto.StorageClass_Unspecified, newSpreadConstraint(vol.spreadLabel)); err != nil {
 			log.LogErrorf("action[doCreateMetaPartition] getHostFromNormalZone err[%v]", err)
 			return nil, errors.NewError(err)
 		}
@@ -1716,6 +1723,9 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 	if err = c.checkMultipleReplicasOnSameMachine(hosts); err != nil {
 		return nil, err
 	}
+	if err = c.checkSpreadConstraint(vol.Name, TypeMetaPartition, hosts); err != nil {
+		return nil, err
+	}
 
 	log.LogInfof("target meta hosts:%v,peers:%v", hosts, peers)
 	if partitionID, err = c.idAlloc.allocateMetaPartitionID(); err != nil {
@@ -1806,6 +1816,7 @@ func setVolFromArgs(args *VolVarargs, vol *Vol) {
 	}
 
 	vol.description = args.description
+	vol.spreadLabel = args.spreadLabel
 
 	vol.dpSelectorName = args.dpSelectorName
 	vol.dpSelectorParm = args.dpSelectorParm
@@ -1854,6 +1865,7 @@ func getVolVarargs(vol *Vol) *VolVarargs {
 		zoneName:                 vol.zoneName,
 		crossZone:                vol.crossZone,
 		description:              vol.description,
+		spreadLabel:              vol.spreadLabel,
 		capacity:                 vol.Capacity,
 		deleteLockTime:           vol.DeleteLockTime,
 		followerRead:             vol.FollowerRead,
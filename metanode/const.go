@@ -107,6 +107,8 @@ type (
 	UpdateInodeMetaRequest = proto.UpdateInodeMetaRequest
 	// Master -> MetaNode
 	SetFreezeReq = proto.FreezeMetaPartitionRequest
+	// Master -> MetaNode
+	SplitMetaPartitionReq = proto.SplitMetaPartitionRequest
 )
 
 // op code should be fixed, order change will cause raft fsm log apply fail
@@ -209,6 +211,13 @@ const (
 
 	// freeze meta partition
 	opFSMSetFreeze = 92
+
+	opFSMCondUpdateDentry = 93
+
+	// split meta partition at an inode boundary
+	opFSMSplitMetaPartition = 94
+
+	opFSMCompareAndSetXAttr = 95
 )
 
 // new inode opCode
@@ -238,6 +247,7 @@ const (
 	cfgTotalMem                  = "totalMem"
 	cfgMemRatio                  = "memRatio"
 	cfgZoneName                  = "zoneName"
+	cfgTopologyLabels            = "topologyLabels" // object, such as {"rack": "r1"}
 	cfgTickInterval              = "tickInterval"
 	cfgRaftRecvBufSize           = "raftRecvBufSize"
 	cfgSmuxPortShift             = "smuxPortShift"             // int
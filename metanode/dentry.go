@@ -558,6 +558,41 @@ func (td *TxUpdateDentry) Unmarshal(raw []byte) (err error) {
 	return
 }
 
+// CondUpdateDentry updates the dentry only if it refers to the old inode.
+type CondUpdateDentry struct {
+	Dentry   *Dentry
+	OldInode uint64
+}
+
+func (cd *CondUpdateDentry) Marshal() (result []byte, err error) {
+	buff := bytes.NewBuffer(make([]byte, 0))
+	if err = binary.Write(buff, binary.BigEndian, cd.OldInode); err != nil {
+		return nil, err
+	}
+	bs, err := cd.Dentry.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = buff.Write(bs); err != nil {
+		return nil, err
+	}
+	result = buff.Bytes()
+	return
+}
+
+func (cd *CondUpdateDentry) Unmarshal(raw []byte) (err error) {
+	buff := bytes.NewBuffer(raw)
+	if err = binary.Read(buff, binary.BigEndian, &cd.OldInode); err != nil {
+		return
+	}
+	dentry := &Dentry{}
+	if err = dentry.Unmarshal(buff.Bytes()); err != nil {
+		return
+	}
+	cd.Dentry = dentry
+	return
+}
+
 type DentryBatch []*Dentry
 
 // Marshal marshals a dentry into a byte array. which will alloc mem in runtime
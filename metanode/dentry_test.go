@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"io/fs"
 	"testing"
+
+	"github.com/cubefs/cubefs/proto"
 )
 
 func dentryDqual(d1, d2 *Dentry) bool {
@@ -184,3 +186,45 @@ func BenchmarkDentryUnMarshal(b *testing.B) {
 		d2.Unmarshal(data)
 	}
 }
+
+func TestCondUpdateDentryMarshal(t *testing.T) {
+	cd := &CondUpdateDentry{
+		Dentry:   &Dentry{ParentId: 1, Name: "test", Inode: 1024, Type: uint32(fs.ModePerm)},
+		OldInode: 1000,
+	}
+	data, err := cd.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cd2 := &CondUpdateDentry{}
+	if err = cd2.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if cd2.OldInode != cd.OldInode || !dentryDqual(cd.Dentry, cd2.Dentry) {
+		t.Fatalf("unmarshal mismatch: %+v %+v", cd2, cd2.Dentry)
+	}
+}
+
+func TestFsmCondUpdateDentry(t *testing.T) {
+	mp := newMetaPartition(10010, nil)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "obj", Inode: 1001}, true)
+
+	// the dentry refers to another inode
+	resp := mp.fsmCondUpdateDentry(&Dentry{ParentId: 1, Name: "obj", Inode: 1003}, 1002)
+	if resp.Status != proto.OpDentryNotMatchErr {
+		t.Fatalf("unexpected status %v", resp.Status)
+	}
+	resp = mp.fsmCondUpdateDentry(&Dentry{ParentId: 1, Name: "none", Inode: 1003}, 1001)
+	if resp.Status != proto.OpNotExistErr {
+		t.Fatalf("unexpected status %v", resp.Status)
+	}
+
+	resp = mp.fsmCondUpdateDentry(&Dentry{ParentId: 1, Name: "obj", Inode: 1003}, 1001)
+	if resp.Status != proto.OpOk || resp.Msg.Inode != 1001 {
+		t.Fatalf("unexpected resp %v %v", resp.Status, resp.Msg)
+	}
+	item := mp.dentryTree.Get(&Dentry{ParentId: 1, Name: "obj"})
+	if item == nil || item.(*Dentry).Inode != 1003 {
+		t.Fatalf("dentry is not updated: %v", item)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
 )
 
@@ -54,3 +55,34 @@ func TestExtend_Bytes(t *testing.T) {
 		}
 	}
 }
+
+func TestFsmCompareAndSetXAttr(t *testing.T) {
+	mp := newMetaPartition(10011, nil)
+	req := &proto.CompareAndSetXAttrRequest{Inode: 1, Key: "k", Value: "v1"}
+
+	// the xattr absent matches the empty expected value
+	if resp := mp.fsmCompareAndSetXAttr(req); resp.Status != proto.OpOk {
+		t.Fatalf("unexpected status %v", resp.Status)
+	}
+	req.Value = "v2"
+	if resp := mp.fsmCompareAndSetXAttr(req); resp.Status != proto.OpXAttrNotMatchErr {
+		t.Fatalf("unexpected status %v", resp.Status)
+	}
+	req.Expected = "v1"
+	if resp := mp.fsmCompareAndSetXAttr(req); resp.Status != proto.OpOk {
+		t.Fatalf("unexpected status %v", resp.Status)
+	}
+	item := mp.extendTree.Get(NewExtend(1))
+	if value, _ := item.(*Extend).Get([]byte("k")); string(value) != "v2" {
+		t.Fatalf("xattr is not set: %s", value)
+	}
+
+	// the empty value removes the xattr
+	req.Expected, req.Value = "v2", ""
+	if resp := mp.fsmCompareAndSetXAttr(req); resp.Status != proto.OpOk {
+		t.Fatalf("unexpected status %v", resp.Status)
+	}
+	if _, exist := item.(*Extend).Get([]byte("k")); exist {
+		t.Fatalf("xattr is not removed")
+	}
+}
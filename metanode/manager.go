@@ -276,7 +276,7 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opDeleteDentry(conn, p, remoteAddr)
 	case proto.OpMetaBatchDeleteDentry:
 		err = m.opBatchDeleteDentry(conn, p, remoteAddr)
-	case proto.OpMetaUpdateDentry:
+	case proto.OpMetaUpdateDentry, proto.OpMetaCondUpdateDentry:
 		err = m.opUpdateDentry(conn, p, remoteAddr)
 	case proto.OpMetaReadDir:
 		err = m.opReadDir(conn, p, remoteAddr)
@@ -336,9 +336,13 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opRemoveBackupMetaPartition(conn, p, remoteAddr)
 	case proto.OpIsRaftStatusOk:
 		err = m.opIsRaftStatusOk(conn, p, remoteAddr)
+	case proto.OpSplitMetaPartition:
+		err = m.opSplitMetaPartition(conn, p, remoteAddr)
 	// operations for extend attributes
 	case proto.OpMetaSetXAttr:
 		err = m.opMetaSetXAttr(conn, p, remoteAddr)
+	case proto.OpMetaCompareAndSetXAttr:
+		err = m.opMetaCompareAndSetXAttr(conn, p, remoteAddr)
 	case proto.OpMetaBatchSetXAttr:
 		err = m.opMetaBatchSetXAttr(conn, p, remoteAddr)
 	case proto.OpMetaGetXAttr:
@@ -790,6 +794,18 @@ func (m *metadataManager) createPartition(request *proto.CreateMetaPartitionRequ
 
 	partition := NewMetaPartition(mpc, m)
 
+	if request.SplitFrom != 0 {
+		var src MetaPartition
+		if src, err = m.getPartition(request.SplitFrom); err != nil {
+			err = errors.NewErrorf("[createPartition]->%s", err.Error())
+			return
+		}
+		if err = partition.(*metaPartition).seedFromSplit(src.(*metaPartition)); err != nil {
+			err = errors.NewErrorf("[createPartition]->%s", err.Error())
+			return
+		}
+	}
+
 	if err = partition.RenameStaleMetadata(); err != nil {
 		log.LogErrorf("[createPartition]->%s", err.Error())
 	}
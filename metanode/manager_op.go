@@ -37,6 +37,7 @@ import (
 	"github.com/cubefs/cubefs/util/auditlog"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 const (
@@ -137,6 +138,9 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 				log.LogWarnf("[opMasterHeartbeat] change GOGC, old(%v) new(%v)", oldGOGC, req.MetaNodeGOGC)
 			}
 		}
+		if err := tlsutil.SetTrustFrom(conn, req.TLSMode, req.TLSCAPem); err != nil {
+			log.LogErrorf("[opMasterHeartbeat] apply tls trust failed, tlsMode(%v) err(%v)", req.TLSMode, err)
+		}
 
 		if m.fileStatsConfig != nil {
 			m.fileStatsConfig.Lock()
@@ -244,6 +248,7 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 			return true
 		})
 		resp.ZoneName = m.zoneName
+		resp.Labels = m.metaNode.topologyLabels
 		resp.ReceivedForbidWriteOpOfProtoVer0 = m.metaNode.nodeForbidWriteOpOfProtoVer0
 		resp.Status = proto.TaskSucceeds
 	end:
@@ -2003,6 +2008,37 @@ func (m *metadataManager) opMetaSetXAttr(conn net.Conn, p *Packet, remoteAddr st
 	return
 }
 
+func (m *metadataManager) opMetaCompareAndSetXAttr(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.CompareAndSetXAttrRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	if err = m.checkMultiVersionStatus(mp, p); err != nil {
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		m.respondToClientWithVer(conn, p)
+		return
+	}
+	err = mp.CompareAndSetXAttr(req, p)
+	m.updatePackRspSeq(mp, p)
+	_ = m.respondToClientWithVer(conn, p)
+	log.LogDebugf("%s [opMetaCompareAndSetXAttr] req: %d - %v, resp: %v",
+		remoteAddr, p.GetReqID(), req.Key, p.GetResultMsg())
+	return
+}
+
 func (m *metadataManager) opMetaBatchSetXAttr(conn net.Conn, p *Packet, remoteAddr string) (err error) {
 	req := &proto.BatchSetXAttrRequest{}
 	if err = json.Unmarshal(p.Data, req); err != nil {
@@ -3127,6 +3163,62 @@ func (m *metadataManager) opFreezeEmptyMetaPartition(conn net.Conn, p *Packet,
 	return
 }
 
+func (m *metadataManager) opSplitMetaPartition(conn net.Conn, p *Packet,
+	remoteAddr string,
+) (err error) {
+	req := &proto.SplitMetaPartitionRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
+
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		return
+	}
+
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+
+	if req.Phase == proto.MetaPartitionSplitPrepare && req.SplitKey == 0 {
+		if req.SplitKey, err = mp.(*metaPartition).GetSplitKey(); err != nil {
+			p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+			m.respondToClientWithVer(conn, p)
+			return
+		}
+	}
+
+	resp, err := mp.SplitPartition(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		return
+	}
+
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		return
+	}
+	p.PacketOkWithBody(reply)
+	m.respondToClientWithVer(conn, p)
+	log.LogWarnf("%s [opSplitMetaPartition] req: %d - %v, resp: %v",
+		remoteAddr, p.GetReqID(), req, resp)
+	return
+}
+
 func (m *metadataManager) opBackupEmptyMetaPartition(conn net.Conn,
 	p *Packet, remoteAddr string,
 ) (err error) {
@@ -33,6 +33,11 @@ func (m *metadataManager) IsForbiddenOp(mp MetaPartition, reqOp uint8) bool {
 	if !mp.IsForbidden() {
 		return false
 	}
+	return isWriteOp(reqOp)
+}
+
+// isWriteOp returns if the op changes the meta partition.
+func isWriteOp(reqOp uint8) bool {
 	switch reqOp {
 	case
 		// dentry
@@ -43,10 +48,12 @@ func (m *metadataManager) IsForbiddenOp(mp MetaPartition, reqOp uint8) bool {
 		proto.OpMetaTxDeleteDentry,
 		proto.OpMetaBatchDeleteDentry,
 		proto.OpMetaUpdateDentry,
+		proto.OpMetaCondUpdateDentry,
 		proto.OpMetaTxUpdateDentry,
 		// extend
 		proto.OpMetaUpdateXAttr,
 		proto.OpMetaSetXAttr,
+		proto.OpMetaCompareAndSetXAttr,
 		proto.OpMetaBatchSetXAttr,
 		proto.OpMetaRemoveXAttr,
 		// extent
@@ -92,7 +99,7 @@ func (m *metadataManager) serveProxy(conn net.Conn, mp MetaPartition,
 	p *Packet,
 ) (ok bool) {
 	var (
-		mConn      *net.TCPConn
+		mConn      net.Conn
 		leaderAddr string
 		err        error
 		reqID      = p.ReqID
@@ -107,6 +114,19 @@ func (m *metadataManager) serveProxy(conn net.Conn, mp MetaPartition,
 		return false
 	}
 
+	// hold off the writes while the partition is being split, the client retries on OpAgain
+	if mp.IsSplitting() && isWriteOp(reqOp) {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(ErrMetaPartitionSplitting.Error()))
+		m.respondToClient(conn, p)
+		return false
+	}
+
+	if mp.IsOutOfRange(p) {
+		p.PacketErrorWithBody(proto.OpInodeOutOfRangeErr, []byte(ErrInodeOutOfRange.Error()))
+		m.respondToClient(conn, p)
+		return false
+	}
+
 	followerRead := func() bool {
 		if !p.IsReadMetaPkt() {
 			return false
@@ -76,6 +76,7 @@ type MetaNode struct {
 	raftRetainLogs                     uint64
 	raftSyncSnapFormatVersion          uint32 // format version of snapshot that raft leader sent to follower
 	zoneName                           string
+	topologyLabels                     map[string]string
 	httpStopC                          chan uint8
 	smuxStopC                          chan uint8
 	metrics                            *MetaNodeMetrics
@@ -226,6 +227,9 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	m.tickInterval = int(cfg.GetFloat(cfgTickInterval))
 	m.raftRecvBufSize = int(cfg.GetInt(cfgRaftRecvBufSize))
 	m.zoneName = cfg.GetString(cfgZoneName)
+	if m.topologyLabels, err = proto.ParseTopologyLabels(cfg.GetValue(cfgTopologyLabels)); err != nil {
+		return
+	}
 
 	deleteBatchCount := cfg.GetInt64(cfgDeleteBatchCount)
 	if deleteBatchCount > 1 {
@@ -359,6 +363,8 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	poolSize := cfg.GetInt64(proto.CfgHttpPoolSize)
 	syslog.Printf("parseConfig: http pool size %d", poolSize)
 	masterClient.SetTransport(proto.GetHttpTransporter(&proto.HttpCfg{PoolSize: int(poolSize)}))
+	// the node operator token to register the node and report the task responses
+	masterClient.SetAPIToken(cfg.GetString(proto.MasterAPIToken))
 
 	if err = masterClient.Start(); err != nil {
 		return err
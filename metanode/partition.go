@@ -102,6 +102,9 @@ type MetaPartitionConfig struct {
 	Forbidden                bool                `json:"-"`
 	ForbidWriteOpOfProtoVer0 bool                `json:"ForbidWriteOpOfProtoVer0"`
 	Freeze                   bool                `json:"freeze"`
+	SplitKey                 uint64              `json:"split_key"`   // the inodes not less than it are moving to a new partition
+	Split                    bool                `json:"split"`       // the range is shrunk by a split
+	SplitIndex               uint64              `json:"split_index"` // the applied index of the split prepare
 }
 
 func (c *MetaPartitionConfig) checkMeta() (err error) {
@@ -158,6 +161,7 @@ type OpInode interface {
 
 type OpExtend interface {
 	SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err error)
+	CompareAndSetXAttr(req *proto.CompareAndSetXAttrRequest, p *Packet) (err error)
 	BatchSetXAttr(req *proto.BatchSetXAttrRequest, p *Packet) (err error)
 	GetXAttr(req *proto.GetXAttrRequest, p *Packet) (err error)
 	GetAllXAttr(req *proto.GetAllXAttrRequest, p *Packet) (err error)
@@ -294,6 +298,9 @@ type MetaPartition interface {
 	GetStatByStorageClass() []*proto.StatOfStorageClass
 	GetMigrateStatByStorageClass() []*proto.StatOfStorageClass
 	SetFreeze(req *proto.FreezeMetaPartitionRequest) (err error)
+	IsSplitting() bool
+	IsOutOfRange(p *Packet) bool
+	SplitPartition(req *proto.SplitMetaPartitionRequest) (resp *proto.SplitMetaPartitionResponse, err error)
 }
 
 type UidManager struct {
@@ -522,6 +529,8 @@ type metaPartition struct {
 	statByStorageClass        []*proto.StatOfStorageClass
 	statByMigrateStorageClass []*proto.StatOfStorageClass
 	syncAtimeCh               chan uint64
+	splitLock                 sync.Mutex
+	splitSnap                 *splitSnapshot // the frozen upper range cloned by the split prepare
 }
 
 // IsLeader returns the raft leader address and if the current meta partition is the leader.
@@ -1225,6 +1234,10 @@ func (mp *metaPartition) load(isCreate bool) (err error) {
 	// 2. store the snapshot files for new mp, because
 	// mp.load() will check all the snapshot files when mn startup
 	if isCreate {
+		// the partition seeded by a split allocates inodes after the largest one
+		if item := mp.inodeTree.MaxItem(); item != nil && item.(*Inode).Inode > mp.GetCursor() {
+			atomic.StoreUint64(&mp.config.Cursor, item.(*Inode).Inode)
+		}
 		if err = mp.storeSnapshotFiles(); err != nil {
 			err = errors.NewErrorf("[onStart] storeSnapshotFiles for partition id=%d: %s",
 				mp.config.PartitionId, err.Error())
@@ -1719,12 +1732,13 @@ func (mp *metaPartition) initTxInfo(txInfo *proto.TransactionInfo) error {
 }
 
 func (mp *metaPartition) storeSnapshotFiles() (err error) {
+	// the trees are empty unless the partition is seeded by a split
 	msg := &storeMsg{
 		applyIndex:     mp.applyID,
 		txId:           mp.txProcessor.txManager.txIdAlloc.getTransactionID(),
-		inodeTree:      NewBtree(),
-		dentryTree:     NewBtree(),
-		extendTree:     NewBtree(),
+		inodeTree:      mp.inodeTree.GetTree(),
+		dentryTree:     mp.dentryTree.GetTree(),
+		extendTree:     mp.extendTree.GetTree(),
 		multipartTree:  NewBtree(),
 		txTree:         NewBtree(),
 		txRbInodeTree:  NewBtree(),
@@ -212,6 +212,19 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		}
 
 		resp = mp.fsmUpdateDentry(den)
+	case opFSMCondUpdateDentry:
+		cd := &CondUpdateDentry{}
+		if err = cd.Unmarshal(msg.V); err != nil {
+			return
+		}
+
+		status := mp.dentryInTx(cd.Dentry.ParentId, cd.Dentry.Name)
+		if status != proto.OpOk {
+			resp = &DentryResponse{Status: status}
+			return
+		}
+
+		resp = mp.fsmCondUpdateDentry(cd.Dentry, cd.OldInode)
 	case opFSMUpdatePartition:
 		req := &UpdatePartitionReq{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
@@ -310,6 +323,12 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		resp = mp.fsmLockDir(req)
+	case opFSMCompareAndSetXAttr:
+		req := &proto.CompareAndSetXAttrRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmCompareAndSetXAttr(req)
 	case opFSMCreateMultipart:
 		var multipart *Multipart
 		multipart = MultipartFromBytes(msg.V)
@@ -495,6 +514,12 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		resp, err = mp.fsmSetFreeze(req.Freeze)
+	case opFSMSplitMetaPartition:
+		req := &SplitMetaPartitionReq{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmSplitMetaPartition(req, index)
 	default:
 		// do nothing
 	case opFSMSyncInodeAccessTime:
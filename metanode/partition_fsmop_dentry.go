@@ -368,6 +368,14 @@ func (mp *metaPartition) fsmTxUpdateDentry(txUpDateDentry *TxUpdateDentry) (resp
 
 func (mp *metaPartition) fsmUpdateDentry(dentry *Dentry) (
 	resp *DentryResponse,
+) {
+	return mp.fsmCondUpdateDentry(dentry, 0)
+}
+
+// fsmCondUpdateDentry updates the dentry only if it refers to the old inode, or
+// updates it unconditionally if the old inode is 0.
+func (mp *metaPartition) fsmCondUpdateDentry(dentry *Dentry, oldInode uint64) (
+	resp *DentryResponse,
 ) {
 	resp = NewDentryResponse()
 	resp.Status = proto.OpOk
@@ -377,6 +385,10 @@ func (mp *metaPartition) fsmUpdateDentry(dentry *Dentry) (
 			return
 		}
 		d := item.(*Dentry)
+		if oldInode != 0 && d.Inode != oldInode {
+			resp.Status = proto.OpDentryNotMatchErr
+			return
+		}
 		if dentry.Inode == d.Inode {
 			return
 		}
@@ -168,6 +168,34 @@ func (mp *metaPartition) fsmSetXAttr(extend *Extend) (err error) {
 	return
 }
 
+// fsmCompareAndSetXAttr sets or removes the xattr if its current value is the expected one.
+func (mp *metaPartition) fsmCompareAndSetXAttr(req *proto.CompareAndSetXAttrRequest) (resp *proto.CompareAndSetXAttrResponse) {
+	resp = &proto.CompareAndSetXAttrResponse{Status: proto.OpOk}
+	var current []byte
+	if treeItem := mp.extendTree.CopyGet(NewExtend(req.Inode)); treeItem != nil {
+		current, _ = treeItem.(*Extend).Get([]byte(req.Key))
+	}
+	if string(current) != req.Expected {
+		resp.Status = proto.OpXAttrNotMatchErr
+		return
+	}
+
+	var err error
+	extend := NewExtend(req.Inode)
+	if req.Value == "" {
+		extend.Put([]byte(req.Key), nil, 0)
+		err = mp.fsmRemoveXAttr(extend)
+	} else {
+		extend.Put([]byte(req.Key), []byte(req.Value), mp.GetVerSeq())
+		err = mp.fsmSetXAttr(extend)
+	}
+	if err != nil {
+		log.LogErrorf("fsmCompareAndSetXAttr: mp(%v) inode(%v) key(%v) err(%v)", mp.config.PartitionId, req.Inode, req.Key, err)
+		resp.Status = proto.OpErr
+	}
+	return
+}
+
 // todo(leon chang):check snapshot delete relation with attr
 func (mp *metaPartition) fsmRemoveXAttr(reqExtend *Extend) (err error) {
 	treeItem := mp.extendTree.CopyGet(reqExtend)
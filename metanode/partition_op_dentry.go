@@ -487,6 +487,14 @@ func (mp *metaPartition) UpdateDentry(req *UpdateDentryReq, p *Packet, remoteAdd
 		p.PacketErrorWithBody(proto.OpExistErr, []byte(err.Error()))
 		return
 	}
+	// the expected inode is only honoured under its own opcode, which old
+	// metanodes reject instead of silently dropping the condition
+	cond := p.Opcode == proto.OpMetaCondUpdateDentry
+	if cond && req.OldInode == 0 {
+		err = fmt.Errorf("conditional update without expected inode")
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
 
 	dentry := &Dentry{
 		ParentId: req.ParentID,
@@ -494,12 +502,21 @@ func (mp *metaPartition) UpdateDentry(req *UpdateDentryReq, p *Packet, remoteAdd
 		Inode:    req.Inode,
 	}
 	dentry.setVerSeq(mp.verSeq)
-	val, err := dentry.Marshal()
+	var (
+		op  uint32 = opFSMUpdateDentry
+		val []byte
+	)
+	if cond {
+		op = opFSMCondUpdateDentry
+		val, err = (&CondUpdateDentry{Dentry: dentry, OldInode: req.OldInode}).Marshal()
+	} else {
+		val, err = dentry.Marshal()
+	}
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
 		return
 	}
-	resp, err := mp.submit(opFSMUpdateDentry, val)
+	resp, err := mp.submit(op, val)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
 		return
@@ -39,6 +39,23 @@ func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err er
 	return
 }
 
+// CompareAndSetXAttr sets the xattr only if its value is the expected one when it is applied,
+// so that the updates of the xattr from different clients are never lost.
+func (mp *metaPartition) CompareAndSetXAttr(req *proto.CompareAndSetXAttrRequest, p *Packet) (err error) {
+	val, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	r, err := mp.submit(opFSMCompareAndSetXAttr, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(r.(*proto.CompareAndSetXAttrResponse).Status, nil)
+	return
+}
+
 func (mp *metaPartition) BatchSetXAttr(req *proto.BatchSetXAttrRequest, p *Packet) (err error) {
 	extend := NewExtend(req.Inode)
 	for key, val := range req.Attrs {
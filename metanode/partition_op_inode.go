@@ -18,6 +18,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/cubefs/cubefs/depends/tiglabs/raft"
@@ -364,6 +365,12 @@ func (mp *metaPartition) TxUnlinkInode(req *proto.TxUnlinkInodeRequest, p *Packe
 		p.PacketErrorWithBody(proto.OpNotPerm, []byte(err.Error()))
 		return
 	}
+	if mp.objectLocked(respIno) {
+		err = fmt.Errorf("the current Inode[%v] is protected by the object lock", req.Inode)
+		log.LogWarnf("TxUnlinkInode: %v", err)
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(err.Error()))
+		return
+	}
 
 	ti := &TxInode{
 		Inode:  inoResp.Msg,
@@ -392,6 +399,30 @@ func (mp *metaPartition) TxUnlinkInode(req *proto.TxUnlinkInodeRequest, p *Packe
 }
 
 // DeleteInode deletes an inode.
+// objectLocked returns whether the unlink of the inode is rejected by the object lock, which
+// is kept in the xattrs of the inode by the ObjectNode. It is checked by the leader before the
+// unlink is submitted, so that all the clients are subject to it. Only the last link of the
+// object is protected, the other links, such as the one dropped when the object is archived as
+// a non-current version, can still be removed.
+func (mp *metaPartition) objectLocked(ino *Inode) bool {
+	if !proto.IsRegular(ino.Type) || ino.GetNLink() > 1 {
+		return false
+	}
+	item := mp.extendTree.Get(NewExtend(ino.Inode))
+	if item == nil {
+		return false
+	}
+	extend := item.(*Extend)
+	if hold, ok := extend.Get([]byte(proto.XAttrKeyObjectLegalHold)); ok && string(hold) == proto.ObjectLegalHoldOn {
+		return true
+	}
+	if until, ok := extend.Get([]byte(proto.XAttrKeyObjectLock)); ok && len(until) > 0 {
+		retainUntil, err := strconv.ParseInt(string(until), 10, 64)
+		return err != nil || retainUntil > time.Now().UnixNano()
+	}
+	return false
+}
+
 func (mp *metaPartition) UnlinkInode(req *UnlinkInoReq, p *Packet, remoteAddr string) (err error) {
 	var (
 		msg   *InodeResponse
@@ -426,6 +457,12 @@ func (mp *metaPartition) UnlinkInode(req *UnlinkInoReq, p *Packet, remoteAddr st
 		p.PacketErrorWithBody(proto.OpNotExistErr, []byte(err.Error()))
 		return
 	} else {
+		if mp.objectLocked(item.(*Inode)) {
+			err = fmt.Errorf("mp[%v] inode[%v] is protected by the object lock", mp.config.PartitionId, req.Inode)
+			log.LogWarnf("action[UnlinkInode] %v", err)
+			p.PacketErrorWithBody(proto.OpNotPerm, []byte(err.Error()))
+			return
+		}
 		ino.UpdateHybridCloudParams(item.(*Inode))
 	}
 	enableSnapshot := mp.manager != nil && mp.manager.metaNode != nil && mp.manager.metaNode.clusterEnableSnapshot
@@ -464,6 +501,12 @@ func (mp *metaPartition) UnlinkInodeBatch(req *BatchUnlinkInoReq, p *Packet, rem
 	var inodes InodeBatch
 	start := time.Now()
 	for i, id := range req.Inodes {
+		if item := mp.inodeTree.Get(NewInode(id, 0)); item != nil && mp.objectLocked(item.(*Inode)) {
+			err = fmt.Errorf("mp[%v] inode[%v] is protected by the object lock", mp.config.PartitionId, id)
+			log.LogWarnf("action[UnlinkInodeBatch] %v", err)
+			p.PacketErrorWithBody(proto.OpNotPerm, []byte(err.Error()))
+			return
+		}
 		inodes = append(inodes, NewInode(id, 0))
 		ino := id
 		fullPath := ""
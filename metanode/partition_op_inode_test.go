@@ -3,6 +3,7 @@ package metanode
 import (
 	"encoding/json"
 	"math/rand"
+	"strconv"
 	"testing"
 	"time"
 
@@ -75,3 +76,40 @@ func TestInodeGetPerf(t *testing.T) {
 		t.Logf("TestInodeGetPerf: cnt %d, cost %dus", testNum, time.Since(start).Microseconds())
 	}
 }
+
+func TestUnlinkInodeObjectLocked(t *testing.T) {
+	initMp(t)
+
+	newObject := func(inoId uint64, key, value string) {
+		ino := NewInode(inoId, FileModeType)
+		ino.NLink = 1
+		mp.inodeTree.ReplaceOrInsert(ino, true)
+		extend := NewExtend(inoId)
+		extend.Put([]byte(key), []byte(value), 0)
+		mp.extendTree.ReplaceOrInsert(extend, true)
+	}
+	retainUntil := strconv.FormatInt(time.Now().Add(time.Hour).UnixNano(), 10)
+	newObject(2001, proto.XAttrKeyObjectLegalHold, proto.ObjectLegalHoldOn)
+	newObject(2002, proto.XAttrKeyObjectLock, retainUntil)
+
+	for _, inoId := range []uint64{2001, 2002} {
+		pkt := &Packet{}
+		err := mp.UnlinkInode(&UnlinkInoReq{Inode: inoId}, pkt, "")
+		require.Error(t, err)
+		require.Equal(t, proto.OpNotPerm, pkt.ResultCode)
+
+		pkt = &Packet{}
+		err = mp.UnlinkInodeBatch(&BatchUnlinkInoReq{Inodes: []uint64{inoId}}, pkt, "")
+		require.Error(t, err)
+		require.Equal(t, proto.OpNotPerm, pkt.ResultCode)
+	}
+
+	// the links other than the last one are not protected
+	item := mp.inodeTree.Get(NewInode(2001, 0))
+	item.(*Inode).NLink = 2
+	require.False(t, mp.objectLocked(item.(*Inode)))
+
+	// the retention is expired
+	newObject(2003, proto.XAttrKeyObjectLock, strconv.FormatInt(time.Now().Add(-time.Hour).UnixNano(), 10))
+	require.False(t, mp.objectLocked(mp.inodeTree.Get(NewInode(2003, 0)).(*Inode)))
+}
@@ -0,0 +1,532 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+var (
+	ErrMetaPartitionSplitting = errors.New("meta partition is splitting")
+	ErrInodeOutOfRange        = errors.New("inode is moved out of the meta partition by a split")
+)
+
+const (
+	splitPersistRetryTimes   = 3
+	splitRetryInterval       = time.Second
+	splitSnapshotWaitTimeout = time.Minute
+)
+
+// splitSnapshot is the upper range of a meta partition frozen by the split prepare at the applied
+// index. Every replica takes the copy-on-write clones of the trees when it applies the same raft log,
+// and builds and stores the snapshot out of the apply, so the new partition created on each replica
+// starts from the identical items.
+type splitSnapshot struct {
+	key        uint64
+	index      uint64
+	inodeTree  *BTree
+	dentryTree *BTree
+	extendTree *BTree
+	done       chan struct{} // closed once the snapshot is built and stored
+}
+
+// IsSplitting returns if the writes of the meta partition are held off by a split.
+func (mp *metaPartition) IsSplitting() bool {
+	return atomic.LoadUint64(&mp.config.SplitKey) != 0
+}
+
+// IsOutOfRange returns if the request is of an inode moved out of the partition by a split. The clients
+// with the view before the split refresh the view on it rather than take the inode as not existing.
+// The dentries are located by the parent inode.
+func (mp *metaPartition) IsOutOfRange(p *Packet) bool {
+	if !mp.config.Split || len(p.Data) == 0 {
+		return false
+	}
+	req := &struct {
+		ParentID *uint64 `json:"pino"`
+		Inode    *uint64 `json:"ino"`
+	}{}
+	if err := json.Unmarshal(p.Data, req); err != nil {
+		return false
+	}
+	ino := req.Inode
+	if req.ParentID != nil {
+		ino = req.ParentID
+	}
+	return ino != nil && *ino > mp.config.End
+}
+
+// GetSplitKey returns the median inode of the partition, which splits the inodes into halves.
+func (mp *metaPartition) GetSplitKey() (key uint64, err error) {
+	inodeTree := mp.inodeTree.GetTree()
+	count := inodeTree.Len()
+	if count < 2 {
+		err = errors.NewErrorf("partition(%v) has only %v inodes", mp.config.PartitionId, count)
+		return
+	}
+	index := 0
+	inodeTree.Ascend(func(i BtreeItem) bool {
+		if index == count/2 {
+			key = i.(*Inode).Inode
+			return false
+		}
+		index++
+		return true
+	})
+	return
+}
+
+// SplitPartition submits a phase of splitting the meta partition at the split key.
+func (mp *metaPartition) SplitPartition(req *proto.SplitMetaPartitionRequest) (resp *proto.SplitMetaPartitionResponse, err error) {
+	reqData, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+	r, err := mp.submit(opFSMSplitMetaPartition, reqData)
+	if err != nil {
+		return
+	}
+	if status := r.(uint8); status != proto.OpOk {
+		p := &Packet{}
+		p.ResultCode = status
+		err = errors.NewErrorf("[SplitPartition]: %s", p.GetResultMsg())
+		return
+	}
+
+	resp = &proto.SplitMetaPartitionResponse{
+		PartitionID: mp.config.PartitionId,
+		SplitKey:    req.SplitKey,
+	}
+	if req.Phase != proto.MetaPartitionSplitPrepare {
+		return
+	}
+	snap, err := mp.waitSplitSnapshot(req.SplitKey)
+	if err != nil {
+		return
+	}
+	resp.InodeCount = uint64(snap.inodeTree.Len())
+	resp.DentryCount = uint64(snap.dentryTree.Len())
+	return
+}
+
+// waitSplitSnapshot waits for the split snapshot at the key to be built and stored.
+func (mp *metaPartition) waitSplitSnapshot(key uint64) (snap *splitSnapshot, err error) {
+	if snap = mp.getSplitSnapshot(key); snap == nil {
+		err = errors.NewErrorf("partition(%v) is not splitting at %v", mp.config.PartitionId, key)
+		return
+	}
+	select {
+	case <-snap.done:
+	case <-time.After(splitSnapshotWaitTimeout):
+		err = errors.NewErrorf("partition(%v) split snapshot at %v is not ready", mp.config.PartitionId, key)
+	}
+	return
+}
+
+func (mp *metaPartition) getSplitSnapshot(key uint64) *splitSnapshot {
+	mp.splitLock.Lock()
+	defer mp.splitLock.Unlock()
+	if mp.splitSnap == nil || mp.splitSnap.key != key {
+		return nil
+	}
+	return mp.splitSnap
+}
+
+func (mp *metaPartition) setSplitSnapshot(snap *splitSnapshot) {
+	mp.splitLock.Lock()
+	mp.splitSnap = snap
+	mp.splitLock.Unlock()
+}
+
+// fsmSplitMetaPartition applies a phase of the split. It is deterministic on all the replicas: the
+// snapshot is built out of the apply, and a replica failing to persist the split state stops rather
+// than answers a different result.
+func (mp *metaPartition) fsmSplitMetaPartition(req *proto.SplitMetaPartitionRequest, index uint64) (status uint8) {
+	status = proto.OpOk
+	oldKey := mp.config.SplitKey
+	switch req.Phase {
+	case proto.MetaPartitionSplitPrepare:
+		if oldKey == req.SplitKey {
+			// the snapshot lost by a restart is built again when the prepare is applied again
+			if index == mp.config.SplitIndex && mp.getSplitSnapshot(oldKey) == nil {
+				mp.startSplitSnapshot(oldKey, index)
+			}
+			return
+		}
+		if oldKey != 0 || req.SplitKey <= mp.config.Start || req.SplitKey > mp.config.End {
+			log.LogErrorf("[fsmSplitMetaPartition] partition(%v) range(%v,%v) splitting at %v, can not split at %v",
+				mp.config.PartitionId, mp.config.Start, mp.config.End, oldKey, req.SplitKey)
+			status = proto.OpArgMismatchErr
+			return
+		}
+		// the transactions may span the split key, wait for them to finish
+		if mp.txProcessor.txManager.txTree.Len() != 0 || mp.txProcessor.txResource.txRbInodeTree.Len() != 0 ||
+			mp.txProcessor.txResource.txRbDentryTree.Len() != 0 {
+			status = proto.OpAgain
+			return
+		}
+		atomic.StoreUint64(&mp.config.SplitKey, req.SplitKey)
+		mp.config.SplitIndex = index
+		mp.persistSplitMetadata()
+		mp.startSplitSnapshot(req.SplitKey, index)
+	case proto.MetaPartitionSplitCommit:
+		// the commit may be resent after it is applied
+		if oldKey == 0 && mp.config.End == req.SplitKey-1 {
+			return
+		}
+		if oldKey != req.SplitKey {
+			status = proto.OpArgMismatchErr
+			return
+		}
+		inodes, dentries, extends := mp.purgeSplitItems(req.SplitKey)
+		oldIndex := mp.config.SplitIndex
+		mp.config.End = req.SplitKey - 1
+		mp.config.Split = true
+		atomic.StoreUint64(&mp.config.SplitKey, 0)
+		mp.config.SplitIndex = 0
+		mp.persistSplitMetadata()
+		mp.setSplitSnapshot(nil)
+		mp.removeSplitSnapshot(oldIndex)
+		log.LogWarnf("[fsmSplitMetaPartition] partition(%v) split at %v, purged inodes(%v) dentries(%v) extends(%v)",
+			mp.config.PartitionId, req.SplitKey, inodes, dentries, extends)
+	case proto.MetaPartitionSplitAbort:
+		if oldKey != req.SplitKey {
+			return
+		}
+		oldIndex := mp.config.SplitIndex
+		atomic.StoreUint64(&mp.config.SplitKey, 0)
+		mp.config.SplitIndex = 0
+		mp.persistSplitMetadata()
+		mp.setSplitSnapshot(nil)
+		mp.removeSplitSnapshot(oldIndex)
+	default:
+		status = proto.OpArgMismatchErr
+	}
+	return
+}
+
+// persistSplitMetadata persists the split state applied. The replica stops if it can not persist it,
+// and applies the raft log again after it restarts.
+func (mp *metaPartition) persistSplitMetadata() {
+	var err error
+	for i := 0; i < splitPersistRetryTimes; i++ {
+		if err = mp.PersistMetadata(); err == nil {
+			return
+		}
+		log.LogErrorf("[persistSplitMetadata] partition(%v) save meta data failed: %s", mp.config.PartitionId, err.Error())
+		time.Sleep(splitRetryInterval)
+	}
+	panic(fmt.Sprintf("partition(%v) persist split state failed: %v", mp.config.PartitionId, err))
+}
+
+// startSplitSnapshot takes the copy-on-write clones of the trees at the applied index, and builds
+// and stores the split snapshot from them out of the apply.
+func (mp *metaPartition) startSplitSnapshot(key, index uint64) {
+	snap := &splitSnapshot{key: key, index: index, done: make(chan struct{})}
+	inodeTree, dentryTree, extendTree := mp.inodeTree.GetTree(), mp.dentryTree.GetTree(), mp.extendTree.GetTree()
+	mp.setSplitSnapshot(snap)
+	go mp.buildSplitSnapshot(snap, inodeTree, dentryTree, extendTree)
+}
+
+// buildSplitSnapshot builds the split snapshot, and retries to store it until it is stored, or the
+// split is done or aborted.
+func (mp *metaPartition) buildSplitSnapshot(snap *splitSnapshot, inodeTree, dentryTree, extendTree *BTree) {
+	snap.inodeTree = splitInodes(inodeTree, snap.key)
+	snap.dentryTree = splitDentries(dentryTree, snap.key)
+	snap.extendTree = splitExtends(extendTree, snap.key)
+	for {
+		err := mp.storeSplitSnapshot(snap)
+		if err == nil {
+			break
+		}
+		log.LogErrorf("[buildSplitSnapshot] partition(%v) store split snapshot at %v failed: %s",
+			mp.config.PartitionId, snap.key, err.Error())
+		select {
+		case <-mp.stopC:
+			return
+		case <-time.After(splitRetryInterval):
+		}
+		if mp.getSplitSnapshot(snap.key) != snap {
+			return
+		}
+	}
+	mp.splitLock.Lock()
+	defer mp.splitLock.Unlock()
+	// the snapshot stored after the split is done or aborted is stale
+	if mp.splitSnap != snap {
+		mp.removeSplitSnapshot(snap.index)
+		return
+	}
+	close(snap.done)
+}
+
+// splitInodes returns the inodes not less than the key, the inodes marked deleted are left
+// to the free list of the partition.
+func splitInodes(src *BTree, key uint64) *BTree {
+	tree := NewBtree()
+	src.AscendGreaterOrEqual(NewInode(key, 0), func(i BtreeItem) bool {
+		if ino := i.(*Inode); !ino.ShouldDelete() {
+			tree.ReplaceOrInsert(ino.Copy(), true)
+		}
+		return true
+	})
+	return tree
+}
+
+// splitDentries returns the dentries whose parent is not less than the key.
+func splitDentries(src *BTree, key uint64) *BTree {
+	tree := NewBtree()
+	src.AscendGreaterOrEqual(&Dentry{ParentId: key}, func(i BtreeItem) bool {
+		tree.ReplaceOrInsert(i.(*Dentry).Copy(), true)
+		return true
+	})
+	return tree
+}
+
+// splitExtends returns the extended attributes of the inodes not less than the key.
+func splitExtends(src *BTree, key uint64) *BTree {
+	tree := NewBtree()
+	src.AscendGreaterOrEqual(NewExtend(key), func(i BtreeItem) bool {
+		tree.ReplaceOrInsert(i.(*Extend).Copy(), true)
+		return true
+	})
+	return tree
+}
+
+// purgeSplitItems drops the items moved to the new partition without freeing their extents,
+// and moves the cursor back into the remaining range.
+func (mp *metaPartition) purgeSplitItems(key uint64) (inodes, dentries, extends int) {
+	cursor := mp.config.Start
+	mp.inodeTree.GetTree().Ascend(func(i BtreeItem) bool {
+		ino := i.(*Inode)
+		if ino.Inode < key {
+			cursor = ino.Inode
+			return true
+		}
+		if !ino.ShouldDelete() {
+			mp.inodeTree.Delete(ino)
+			inodes++
+		}
+		return true
+	})
+	mp.dentryTree.GetTree().AscendGreaterOrEqual(&Dentry{ParentId: key}, func(i BtreeItem) bool {
+		mp.dentryTree.Delete(i)
+		dentries++
+		return true
+	})
+	mp.extendTree.GetTree().AscendGreaterOrEqual(NewExtend(key), func(i BtreeItem) bool {
+		mp.extendTree.Delete(i)
+		extends++
+		return true
+	})
+	atomic.StoreUint64(&mp.config.Cursor, cursor)
+	return
+}
+
+// seedFromSplit fills the new partition with the upper range frozen in the source partition.
+func (mp *metaPartition) seedFromSplit(src *metaPartition) (err error) {
+	snap, err := src.waitSplitSnapshot(mp.config.Start)
+	if err != nil {
+		return
+	}
+	if src.config.End != mp.config.End {
+		err = errors.NewErrorf("partition(%v) end %v mismatch %v", src.config.PartitionId, src.config.End, mp.config.End)
+		return
+	}
+	snap.inodeTree.Ascend(func(i BtreeItem) bool {
+		mp.inodeTree.ReplaceOrInsert(i.(*Inode).Copy(), true)
+		return true
+	})
+	snap.dentryTree.Ascend(func(i BtreeItem) bool {
+		mp.dentryTree.ReplaceOrInsert(i.(*Dentry).Copy(), true)
+		return true
+	})
+	snap.extendTree.Ascend(func(i BtreeItem) bool {
+		mp.extendTree.ReplaceOrInsert(i.(*Extend).Copy(), true)
+		return true
+	})
+	log.LogWarnf("[seedFromSplit] partition(%v) seeded from partition(%v) inodes(%v) dentries(%v) extends(%v)",
+		mp.config.PartitionId, src.config.PartitionId, mp.inodeTree.Len(), mp.dentryTree.Len(), mp.extendTree.Len())
+	return
+}
+
+// storeSplitSnapshot writes the items of the split snapshot, each one prefixed by its length, and the
+// crc of them at the end.
+func (mp *metaPartition) storeSplitSnapshot(snap *splitSnapshot) (err error) {
+	filename := path.Join(mp.config.RootDir, fmt.Sprintf("%s_%d", splitSnapshotFileTmp, snap.index))
+	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0o644)
+	if err != nil {
+		return
+	}
+	defer func() {
+		fp.Close()
+		os.Remove(filename)
+	}()
+
+	crc := crc32.NewIEEE()
+	writer := bufio.NewWriterSize(io.MultiWriter(fp, crc), writeBuffSize)
+	varintTmp := make([]byte, binary.MaxVarintLen64)
+	writeUvarint := func(v uint64) (err error) {
+		n := binary.PutUvarint(varintTmp, v)
+		_, err = writer.Write(varintTmp[:n])
+		return
+	}
+	writeTree := func(tree *BTree, marshal func(BtreeItem) ([]byte, error)) (err error) {
+		if err = writeUvarint(uint64(tree.Len())); err != nil {
+			return
+		}
+		tree.Ascend(func(i BtreeItem) bool {
+			var raw []byte
+			if raw, err = marshal(i); err != nil {
+				return false
+			}
+			if err = writeUvarint(uint64(len(raw))); err != nil {
+				return false
+			}
+			_, err = writer.Write(raw)
+			return err == nil
+		})
+		return
+	}
+
+	if err = writeUvarint(snap.key); err != nil {
+		return
+	}
+	if err = writeUvarint(snap.index); err != nil {
+		return
+	}
+	if err = writeTree(snap.inodeTree, func(i BtreeItem) ([]byte, error) { return i.(*Inode).Marshal() }); err != nil {
+		return
+	}
+	if err = writeTree(snap.dentryTree, func(i BtreeItem) ([]byte, error) { return i.(*Dentry).Marshal() }); err != nil {
+		return
+	}
+	if err = writeTree(snap.extendTree, func(i BtreeItem) ([]byte, error) { return i.(*Extend).Bytes() }); err != nil {
+		return
+	}
+	if err = writer.Flush(); err != nil {
+		return
+	}
+	if err = binary.Write(fp, binary.BigEndian, crc.Sum32()); err != nil {
+		return
+	}
+	if err = fp.Sync(); err != nil {
+		return
+	}
+	return os.Rename(filename, mp.splitSnapshotPath(snap.index))
+}
+
+// loadSplitSnapshot loads the split snapshot stored by the split prepare.
+func (mp *metaPartition) loadSplitSnapshot() (err error) {
+	data, err := os.ReadFile(mp.splitSnapshotPath(mp.config.SplitIndex))
+	if err != nil {
+		return
+	}
+	if len(data) < 4 {
+		return errors.NewErrorf("split snapshot too short: %v", len(data))
+	}
+	body := data[:len(data)-4]
+	if crc := crc32.ChecksumIEEE(body); crc != binary.BigEndian.Uint32(data[len(data)-4:]) {
+		return errors.NewErrorf("split snapshot crc mismatch: %v", crc)
+	}
+
+	readUvarint := func() (v uint64, err error) {
+		v, n := binary.Uvarint(body)
+		if n <= 0 {
+			return 0, errors.New("split snapshot corrupted")
+		}
+		body = body[n:]
+		return
+	}
+	readTree := func(unmarshal func([]byte) (BtreeItem, error)) (tree *BTree, err error) {
+		var count, size uint64
+		if count, err = readUvarint(); err != nil {
+			return
+		}
+		tree = NewBtree()
+		for ; count > 0; count-- {
+			if size, err = readUvarint(); err != nil {
+				return
+			}
+			if size > uint64(len(body)) {
+				return nil, errors.New("split snapshot corrupted")
+			}
+			var item BtreeItem
+			if item, err = unmarshal(body[:size]); err != nil {
+				return
+			}
+			tree.ReplaceOrInsert(item, true)
+			body = body[size:]
+		}
+		return
+	}
+
+	snap := &splitSnapshot{done: make(chan struct{})}
+	if snap.key, err = readUvarint(); err != nil {
+		return
+	}
+	if snap.index, err = readUvarint(); err != nil {
+		return
+	}
+	if snap.key != mp.config.SplitKey || snap.index != mp.config.SplitIndex {
+		return errors.NewErrorf("split snapshot at %v index %v mismatch %v index %v",
+			snap.key, snap.index, mp.config.SplitKey, mp.config.SplitIndex)
+	}
+	if snap.inodeTree, err = readTree(func(raw []byte) (BtreeItem, error) {
+		ino := NewInode(0, 0)
+		return ino, ino.Unmarshal(raw)
+	}); err != nil {
+		return
+	}
+	if snap.dentryTree, err = readTree(func(raw []byte) (BtreeItem, error) {
+		dentry := &Dentry{}
+		return dentry, dentry.Unmarshal(raw)
+	}); err != nil {
+		return
+	}
+	if snap.extendTree, err = readTree(func(raw []byte) (BtreeItem, error) {
+		return NewExtendFromBytes(raw)
+	}); err != nil {
+		return
+	}
+	close(snap.done)
+	mp.setSplitSnapshot(snap)
+	return
+}
+
+// splitSnapshotPath returns the path of the split snapshot, which is named by the applied index of the
+// split prepare, so that the snapshot of an aborted split never replaces the one of a later split.
+func (mp *metaPartition) splitSnapshotPath(index uint64) string {
+	return path.Join(mp.config.RootDir, fmt.Sprintf("%s_%d", splitSnapshotFile, index))
+}
+
+func (mp *metaPartition) removeSplitSnapshot(index uint64) {
+	if err := os.Remove(mp.splitSnapshotPath(index)); err != nil && !os.IsNotExist(err) {
+		log.LogWarnf("[removeSplitSnapshot] partition(%v) remove split snapshot failed: %v", mp.config.PartitionId, err)
+	}
+}
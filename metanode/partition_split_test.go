@@ -0,0 +1,150 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newSplitTestPartition(t *testing.T, ctrl *gomock.Controller) *metaPartition {
+	mp := mockPartitionRaftForTest(ctrl)
+	mp.config.Start = 0
+	mp.config.End = 1000
+	mp.config.Cursor = 10
+	mp.config.Peers = []proto.Peer{{ID: 1, Addr: "127.0.0.1:17210"}}
+	mp.config.RootDir = t.TempDir()
+	for id := uint64(1); id <= 10; id++ {
+		ino := NewInode(id, 0)
+		if id == 9 {
+			ino.SetDeleteMark()
+		}
+		mp.inodeTree.ReplaceOrInsert(ino, true)
+		mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: id - 1, Name: fmt.Sprintf("d%v", id), Inode: id}, true)
+		mp.extendTree.ReplaceOrInsert(NewExtend(id), true)
+	}
+	return mp
+}
+
+func TestSplitMetaPartition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	src := newSplitTestPartition(t, ctrl)
+
+	key, err := src.GetSplitKey()
+	require.NoError(t, err)
+	require.EqualValues(t, 6, key)
+
+	resp, err := src.SplitPartition(&proto.SplitMetaPartitionRequest{PartitionID: src.config.PartitionId, SplitKey: key, Phase: proto.MetaPartitionSplitPrepare})
+	require.NoError(t, err)
+	require.True(t, src.IsSplitting())
+	// the inode 9 marked deleted is left to the source partition
+	require.EqualValues(t, 4, resp.InodeCount)
+	require.EqualValues(t, 4, resp.DentryCount)
+
+	// another split is refused until the current one is done
+	_, err = src.SplitPartition(&proto.SplitMetaPartitionRequest{PartitionID: src.config.PartitionId, SplitKey: 3, Phase: proto.MetaPartitionSplitPrepare})
+	require.Error(t, err)
+
+	dst := NewMetaPartition(&MetaPartitionConfig{PartitionId: 2, VolName: VolNameForTest, Start: key, End: 1000}, nil).(*metaPartition)
+	require.NoError(t, dst.seedFromSplit(src))
+	require.Equal(t, 4, dst.inodeTree.Len())
+	require.Equal(t, 4, dst.dentryTree.Len())
+	require.Equal(t, 5, dst.extendTree.Len())
+	require.Nil(t, dst.inodeTree.Get(NewInode(9, 0)))
+
+	_, err = src.SplitPartition(&proto.SplitMetaPartitionRequest{PartitionID: src.config.PartitionId, SplitKey: key, Phase: proto.MetaPartitionSplitCommit})
+	require.NoError(t, err)
+	require.False(t, src.IsSplitting())
+	require.EqualValues(t, key-1, src.config.End)
+	require.EqualValues(t, 5, src.GetCursor())
+	require.Equal(t, 6, src.inodeTree.Len())
+	require.Equal(t, 6, src.dentryTree.Len())
+	require.Equal(t, 5, src.extendTree.Len())
+	require.Error(t, dst.seedFromSplit(src))
+
+	// the commit resent is ignored
+	_, err = src.SplitPartition(&proto.SplitMetaPartitionRequest{PartitionID: src.config.PartitionId, SplitKey: key, Phase: proto.MetaPartitionSplitCommit})
+	require.NoError(t, err)
+
+	// the clients with the stale view are told to refresh it
+	for _, c := range []struct {
+		data       string
+		outOfRange bool
+	}{
+		{`{"ino":3}`, false},
+		{`{"ino":7}`, true},
+		{`{"pino":7,"ino":3}`, true},
+		{`{"pino":3,"ino":7}`, false},
+		{`{"ino":[7]}`, false},
+	} {
+		require.Equal(t, c.outOfRange, src.IsOutOfRange(&Packet{Packet: proto.Packet{Data: []byte(c.data)}}), c.data)
+	}
+}
+
+func TestSplitMetaPartitionReload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	src := newSplitTestPartition(t, ctrl)
+
+	_, err := src.SplitPartition(&proto.SplitMetaPartitionRequest{PartitionID: src.config.PartitionId, SplitKey: 6, Phase: proto.MetaPartitionSplitPrepare})
+	require.NoError(t, err)
+
+	// the split snapshot is loaded after the metanode restarts
+	src.setSplitSnapshot(nil)
+	require.NoError(t, src.loadSplitSnapshot())
+	dst := NewMetaPartition(&MetaPartitionConfig{PartitionId: 2, VolName: VolNameForTest, Start: 6, End: 1000}, nil).(*metaPartition)
+	require.NoError(t, dst.seedFromSplit(src))
+	require.Equal(t, 4, dst.inodeTree.Len())
+	require.Equal(t, 4, dst.dentryTree.Len())
+	require.Equal(t, 5, dst.extendTree.Len())
+
+	// the snapshot lost is built again when the prepare is applied again at the same index
+	src.setSplitSnapshot(nil)
+	src.removeSplitSnapshot(src.config.SplitIndex)
+	req := &proto.SplitMetaPartitionRequest{PartitionID: src.config.PartitionId, SplitKey: 6, Phase: proto.MetaPartitionSplitPrepare}
+	require.EqualValues(t, proto.OpOk, src.fsmSplitMetaPartition(req, src.config.SplitIndex))
+	snap, err := src.waitSplitSnapshot(6)
+	require.NoError(t, err)
+	require.Equal(t, 4, snap.inodeTree.Len())
+	src.setSplitSnapshot(nil)
+	require.NoError(t, src.loadSplitSnapshot())
+
+	_, err = src.SplitPartition(&proto.SplitMetaPartitionRequest{PartitionID: src.config.PartitionId, SplitKey: 6, Phase: proto.MetaPartitionSplitAbort})
+	require.NoError(t, err)
+	require.Error(t, src.loadSplitSnapshot())
+}
+
+func TestSplitMetaPartitionAbort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	src := newSplitTestPartition(t, ctrl)
+
+	_, err := src.SplitPartition(&proto.SplitMetaPartitionRequest{PartitionID: src.config.PartitionId, SplitKey: 2000, Phase: proto.MetaPartitionSplitPrepare})
+	require.Error(t, err)
+
+	_, err = src.SplitPartition(&proto.SplitMetaPartitionRequest{PartitionID: src.config.PartitionId, SplitKey: 4, Phase: proto.MetaPartitionSplitPrepare})
+	require.NoError(t, err)
+	_, err = src.SplitPartition(&proto.SplitMetaPartitionRequest{PartitionID: src.config.PartitionId, SplitKey: 4, Phase: proto.MetaPartitionSplitAbort})
+	require.NoError(t, err)
+	require.False(t, src.IsSplitting())
+	require.EqualValues(t, 1000, src.config.End)
+	require.Equal(t, 10, src.inodeTree.Len())
+	require.Nil(t, src.getSplitSnapshot(4))
+}
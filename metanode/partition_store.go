@@ -53,6 +53,8 @@ const (
 	uniqIDFile              = "uniqID"
 	uniqCheckerFile         = "uniqChecker"
 	verdataFile             = "multiVer"
+	splitSnapshotFile       = "split_snapshot"
+	splitSnapshotFileTmp    = ".split_snapshot"
 	StaleMetadataSuffix     = ".old"
 	StaleMetadataTimeFormat = "20060102150405.000000000"
 	writeBuffSize           = 1024 * 1024
@@ -141,6 +143,16 @@ func (mp *metaPartition) loadMetadata() (err error) {
 	mp.config.Peers = mConf.Peers
 	mp.config.Cursor = mp.config.Start
 	mp.config.UniqId = 0
+	mp.config.SplitKey = mConf.SplitKey
+	mp.config.Split = mConf.Split
+	mp.config.SplitIndex = mConf.SplitIndex
+	if mp.config.SplitKey != 0 {
+		// the new partition can not be seeded without it, the master aborts the split then
+		if e := mp.loadSplitSnapshot(); e != nil {
+			log.LogErrorf("loadMetadata: partition(%v) load split snapshot at %v failed: %v",
+				mp.config.PartitionId, mp.config.SplitKey, e)
+		}
+	}
 
 	mp.uidManager = NewUidMgr(mp.config.VolName, mp.config.PartitionId)
 	mp.mqMgr = NewQuotaManager(mp.config.VolName, mp.config.PartitionId)
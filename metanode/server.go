@@ -25,6 +25,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 // StartTcpService binds and listens to the specified port.
@@ -37,7 +38,7 @@ func (m *MetaNode) startServer() (err error) {
 		addr = fmt.Sprintf("%s:%s", m.localAddr, m.listen)
 	}
 
-	ln, err := net.Listen("tcp", addr)
+	ln, err := tlsutil.Listen("tcp", addr)
 	if err != nil {
 		return
 	}
@@ -78,9 +79,10 @@ func (m *MetaNode) serveConn(conn net.Conn, stopC chan uint8) {
 		m.RemoveConnection()
 	}()
 	m.AddConnection()
-	c := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
+	if c, ok := tlsutil.TCPConn(conn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
 	remoteAddr := conn.RemoteAddr().String()
 	for {
 		select {
@@ -129,7 +131,7 @@ func (m *MetaNode) startSmuxServer() (err error) {
 		ipPort = fmt.Sprintf("%s:%s", m.localAddr, m.listen)
 	}
 	addr := util.ShiftAddrPort(ipPort, smuxPortShift)
-	ln, err := net.Listen("tcp", addr)
+	ln, err := tlsutil.Listen("tcp", addr)
 	if err != nil {
 		return
 	}
@@ -174,9 +176,10 @@ func (m *MetaNode) serveSmuxConn(conn net.Conn, stopC chan uint8) {
 		m.RemoveConnection()
 	}()
 	m.AddConnection()
-	c := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
+	if c, ok := tlsutil.TCPConn(conn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
 	remoteAddr := conn.RemoteAddr().String()
 
 	var sess *smux.Session
@@ -1045,7 +1045,7 @@ func (tm *TransactionManager) rollbackTx(txId string, skipSetStat bool) (status
 
 func (tm *TransactionManager) sendPacketToMP(addr string, p *proto.Packet) (err error) {
 	var (
-		mConn *net.TCPConn
+		mConn net.Conn
 		reqID = p.ReqID
 		reqOp = p.Opcode
 	)
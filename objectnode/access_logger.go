@@ -0,0 +1,363 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultAccessLogFlushInterval = 300      // 5 minutes
+	defaultAccessLogBatchSize     = 16 << 20 // 16MB
+	defaultAccessLogSpoolSize     = 1 << 30  // 1GB
+	accessLogActiveFileSuffix     = ".active"
+	accessLogSealedFileSuffix     = ".log"
+
+	MetricAccessLogDelivered = "access_log_delivered"
+	MetricAccessLogDropped   = "access_log_dropped"
+)
+
+var errAccessLogSpoolFull = errors.New("access log spool is full")
+
+// AccessLoggingConfig is the configuration of the server access logging on the ObjectNode.
+type AccessLoggingConfig struct {
+	SpoolDir      string `json:"spoolDir"`
+	FlushInterval int64  `json:"flushInterval,omitempty"` // seconds
+	MaxBatchSize  int64  `json:"maxBatchSize,omitempty"`  // bytes of a log object
+	MaxSpoolSize  int64  `json:"maxSpoolSize,omitempty"`  // bytes spooled of a bucket
+}
+
+// accessLogDeliverFunc writes the batch of the log lines of the bucket as a log object.
+type accessLogDeliverFunc func(bucket string, data []byte) error
+
+// accessLogBatch is the spool of the log lines of a bucket. The lines are appended to the
+// active file, which is sealed when it is large enough or the flush interval elapsed. The
+// sealed files are delivered in order, and removed after delivered.
+type accessLogBatch struct {
+	bucket string
+	dir    string
+
+	mu     sync.Mutex
+	active *os.File
+	name   string   // name of the active file without the suffix
+	size   int64    // size of the active file
+	sealed []string // names of the sealed files not delivered, in order
+	total  int64    // size of all the files of the spool
+}
+
+// AccessLogger batches the server access logs per bucket, and delivers the batches to the
+// target buckets periodically. The logs are spooled locally before delivered, so that they
+// survive the restart of the ObjectNode, and the delivery is retried at the next flush.
+type AccessLogger struct {
+	conf    AccessLoggingConfig
+	deliver accessLogDeliverFunc
+	seq     uint64
+
+	mu      sync.Mutex
+	batches map[string]*accessLogBatch
+
+	delivered *exporter.Counter
+	dropped   *exporter.Counter
+
+	notify chan struct{} // signaled when a file is sealed for its size
+	stopC  chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewAccessLogger(conf AccessLoggingConfig, deliver accessLogDeliverFunc) (*AccessLogger, error) {
+	if conf.SpoolDir == "" {
+		return nil, errors.New("spool directory is not specified")
+	}
+	if conf.FlushInterval <= 0 {
+		conf.FlushInterval = defaultAccessLogFlushInterval
+	}
+	if conf.MaxBatchSize <= 0 {
+		conf.MaxBatchSize = defaultAccessLogBatchSize
+	}
+	if conf.MaxSpoolSize <= 0 {
+		conf.MaxSpoolSize = defaultAccessLogSpoolSize
+	}
+	if err := os.MkdirAll(conf.SpoolDir, 0o755); err != nil {
+		return nil, err
+	}
+	l := &AccessLogger{
+		conf:      conf,
+		deliver:   deliver,
+		batches:   make(map[string]*accessLogBatch),
+		delivered: exporter.NewCounter(MetricAccessLogDelivered),
+		dropped:   exporter.NewCounter(MetricAccessLogDropped),
+		notify:    make(chan struct{}, 1),
+		stopC:     make(chan struct{}),
+	}
+	entries, err := os.ReadDir(conf.SpoolDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		b, err := l.recoverBatch(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		l.batches[b.bucket] = b
+	}
+	return l, nil
+}
+
+// recoverBatch loads the files spooled before the restart, the active files are sealed.
+func (l *AccessLogger) recoverBatch(bucket string) (*accessLogBatch, error) {
+	b := &accessLogBatch{bucket: bucket, dir: filepath.Join(l.conf.SpoolDir, bucket)}
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(b.dir, name)
+		switch {
+		case strings.HasSuffix(name, accessLogSealedFileSuffix):
+			name = strings.TrimSuffix(name, accessLogSealedFileSuffix)
+		case strings.HasSuffix(name, accessLogActiveFileSuffix):
+			name = strings.TrimSuffix(name, accessLogActiveFileSuffix)
+			if err = recoverAccessLogFile(path, filepath.Join(b.dir, name+accessLogSealedFileSuffix)); err != nil {
+				return nil, err
+			}
+		default:
+			_ = os.Remove(path)
+			continue
+		}
+		info, err := os.Stat(filepath.Join(b.dir, name+accessLogSealedFileSuffix))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		b.sealed = append(b.sealed, name)
+		b.total += info.Size()
+	}
+	sort.Strings(b.sealed)
+	return b, nil
+}
+
+// recoverAccessLogFile seals the active file, the incomplete line is truncated if the
+// ObjectNode crashed while writing it, and the empty file is removed.
+func recoverAccessLogFile(path, sealedPath string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if i := bytes.LastIndexByte(data, '\n'); i+1 < len(data) {
+		if err = os.Truncate(path, int64(i+1)); err != nil {
+			return err
+		}
+	}
+	if bytes.IndexByte(data, '\n') < 0 {
+		return os.Remove(path)
+	}
+	return os.Rename(path, sealedPath)
+}
+
+func (l *AccessLogger) Start() {
+	l.wg.Add(1)
+	go l.loop()
+}
+
+// Close stops the delivery and seals the active files, which are delivered after restart.
+func (l *AccessLogger) Close() {
+	close(l.stopC)
+	l.wg.Wait()
+	for _, b := range l.getBatches() {
+		b.mu.Lock()
+		if err := b.seal(); err != nil {
+			log.LogWarnf("AccessLogger: seal log file fail: bucket(%v) name(%v) err(%v)", b.bucket, b.name, err)
+		}
+		b.mu.Unlock()
+	}
+}
+
+func (l *AccessLogger) getBatch(bucket string) *accessLogBatch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.batches[bucket]
+	if !ok {
+		b = &accessLogBatch{bucket: bucket, dir: filepath.Join(l.conf.SpoolDir, bucket)}
+		l.batches[bucket] = b
+	}
+	return b
+}
+
+func (l *AccessLogger) getBatches() []*accessLogBatch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	batches := make([]*accessLogBatch, 0, len(l.batches))
+	for _, b := range l.batches {
+		batches = append(batches, b)
+	}
+	return batches
+}
+
+func (l *AccessLogger) fileName() string {
+	return fmt.Sprintf("%016x-%08x", time.Now().UnixNano(), atomic.AddUint64(&l.seq, 1)&0xffffffff)
+}
+
+// Log spools the log record of the bucket.
+func (l *AccessLogger) Log(bucket string, record *AccessLogRecord) {
+	if err := l.append(bucket, []byte(record.String()+"\n")); err != nil {
+		log.LogWarnf("AccessLogger: spool log fail: bucket(%v) requestID(%v) err(%v)", bucket, record.RequestID, err)
+		l.dropped.Add(1)
+	}
+}
+
+func (l *AccessLogger) append(bucket string, line []byte) (err error) {
+	b := l.getBatch(bucket)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.total+int64(len(line)) > l.conf.MaxSpoolSize {
+		return errAccessLogSpoolFull
+	}
+	if b.active == nil {
+		if err = os.MkdirAll(b.dir, 0o755); err != nil {
+			return
+		}
+		name := l.fileName()
+		path := filepath.Join(b.dir, name+accessLogActiveFileSuffix)
+		if b.active, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+			return
+		}
+		b.name, b.size = name, 0
+	}
+	if _, err = b.active.Write(line); err != nil {
+		return
+	}
+	b.size += int64(len(line))
+	b.total += int64(len(line))
+	if b.size >= l.conf.MaxBatchSize {
+		if err = b.seal(); err != nil {
+			return
+		}
+		select {
+		case l.notify <- struct{}{}:
+		default:
+		}
+	}
+	return
+}
+
+// seal closes the active file to deliver, it must be called with the lock held.
+func (b *accessLogBatch) seal() error {
+	if b.active == nil {
+		return nil
+	}
+	err := b.active.Close()
+	b.active = nil
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(filepath.Join(b.dir, b.name+accessLogActiveFileSuffix),
+		filepath.Join(b.dir, b.name+accessLogSealedFileSuffix)); err != nil {
+		return err
+	}
+	b.sealed = append(b.sealed, b.name)
+	return nil
+}
+
+func (l *AccessLogger) loop() {
+	defer l.wg.Done()
+	ticker := time.NewTicker(time.Duration(l.conf.FlushInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stopC:
+			return
+		case <-ticker.C:
+			l.flush(true)
+		case <-l.notify:
+			l.flush(false)
+		}
+	}
+}
+
+// flush delivers the sealed files of all the buckets, the active files are sealed
+// before if seal is true.
+func (l *AccessLogger) flush(seal bool) {
+	for _, b := range l.getBatches() {
+		if seal {
+			b.mu.Lock()
+			if err := b.seal(); err != nil {
+				log.LogWarnf("AccessLogger: seal log file fail: bucket(%v) name(%v) err(%v)", b.bucket, b.name, err)
+			}
+			b.mu.Unlock()
+		}
+		l.deliverBatch(b)
+	}
+}
+
+// deliverBatch delivers the sealed files of the bucket in order, and stops at the first
+// failure, which is retried at the next flush.
+func (l *AccessLogger) deliverBatch(b *accessLogBatch) {
+	for {
+		select {
+		case <-l.stopC:
+			return
+		default:
+		}
+		b.mu.Lock()
+		if len(b.sealed) == 0 {
+			b.mu.Unlock()
+			return
+		}
+		name := b.sealed[0]
+		b.mu.Unlock()
+
+		path := filepath.Join(b.dir, name+accessLogSealedFileSuffix)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if err = l.deliver(b.bucket, data); err != nil {
+				log.LogWarnf("AccessLogger: deliver log fail: bucket(%v) name(%v) err(%v)", b.bucket, name, err)
+				return
+			}
+			l.delivered.Add(1)
+		} else {
+			log.LogWarnf("AccessLogger: drop unreadable log file: bucket(%v) name(%v) err(%v)", b.bucket, name, err)
+		}
+		if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.LogWarnf("AccessLogger: remove log file fail: bucket(%v) name(%v) err(%v)", b.bucket, name, err)
+		}
+		b.mu.Lock()
+		b.sealed = b.sealed[1:]
+		if b.total -= int64(len(data)); b.total < 0 {
+			b.total = 0
+		}
+		b.mu.Unlock()
+	}
+}
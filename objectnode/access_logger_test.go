@@ -0,0 +1,148 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testAccessLogTarget struct {
+	mu      sync.Mutex
+	fail    bool
+	batches map[string][]string
+}
+
+func (t *testAccessLogTarget) deliver(bucket string, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.fail {
+		return errors.New("target unavailable")
+	}
+	if t.batches == nil {
+		t.batches = make(map[string][]string)
+	}
+	t.batches[bucket] = append(t.batches[bucket], string(data))
+	return nil
+}
+
+func newTestAccessLogger(t *testing.T, dir string, target *testAccessLogTarget, batchSize int64) *AccessLogger {
+	l, err := NewAccessLogger(AccessLoggingConfig{SpoolDir: dir, MaxBatchSize: batchSize}, target.deliver)
+	require.NoError(t, err)
+	return l
+}
+
+func TestAccessLoggerFlush(t *testing.T) {
+	dir := t.TempDir()
+	target := &testAccessLogTarget{}
+	l := newTestAccessLogger(t, dir, target, 0)
+
+	l.Log("bucket1", &AccessLogRecord{Bucket: "bucket1", RequestID: "1"})
+	l.Log("bucket1", &AccessLogRecord{Bucket: "bucket1", RequestID: "2"})
+	l.Log("bucket2", &AccessLogRecord{Bucket: "bucket2", RequestID: "3"})
+	l.flush(true)
+	require.Len(t, target.batches["bucket1"], 1)
+	lines := strings.Split(strings.TrimSuffix(target.batches["bucket1"][0], "\n"), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], " 1 ")
+	require.Contains(t, lines[1], " 2 ")
+	require.Len(t, target.batches["bucket2"], 1)
+
+	// nothing is delivered without new logs
+	l.flush(true)
+	require.Len(t, target.batches["bucket1"], 1)
+	entries, err := os.ReadDir(filepath.Join(dir, "bucket1"))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestAccessLoggerRetry(t *testing.T) {
+	dir := t.TempDir()
+	target := &testAccessLogTarget{fail: true}
+	l := newTestAccessLogger(t, dir, target, 0)
+
+	l.Log("bucket", &AccessLogRecord{Bucket: "bucket", RequestID: "1"})
+	l.flush(true)
+	l.Log("bucket", &AccessLogRecord{Bucket: "bucket", RequestID: "2"})
+	l.flush(true)
+	require.Empty(t, target.batches)
+
+	// the failed batches are delivered in order
+	target.fail = false
+	l.flush(false)
+	require.Len(t, target.batches["bucket"], 2)
+	require.Contains(t, target.batches["bucket"][0], " 1 ")
+	require.Contains(t, target.batches["bucket"][1], " 2 ")
+}
+
+func TestAccessLoggerBatchSize(t *testing.T) {
+	dir := t.TempDir()
+	target := &testAccessLogTarget{}
+	l := newTestAccessLogger(t, dir, target, 1)
+
+	l.Log("bucket", &AccessLogRecord{Bucket: "bucket", RequestID: "1"})
+	l.Log("bucket", &AccessLogRecord{Bucket: "bucket", RequestID: "2"})
+	// the files are sealed for the size without waiting for the flush interval
+	l.flush(false)
+	require.Len(t, target.batches["bucket"], 2)
+}
+
+func TestAccessLoggerRecover(t *testing.T) {
+	dir := t.TempDir()
+	target := &testAccessLogTarget{fail: true}
+	l := newTestAccessLogger(t, dir, target, 0)
+	l.Start()
+	l.Log("bucket", &AccessLogRecord{Bucket: "bucket", RequestID: "1"})
+	l.flush(true)
+	l.Log("bucket", &AccessLogRecord{Bucket: "bucket", RequestID: "2"})
+	l.Close()
+
+	// the active file with an incomplete line left by the crash
+	bucketDir := filepath.Join(dir, "bucket")
+	require.NoError(t, os.WriteFile(filepath.Join(bucketDir, "ffffffffffffffff-00000000"+accessLogActiveFileSuffix),
+		[]byte("line 3\nincomplete"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(bucketDir, "ffffffffffffffff-00000001"+accessLogActiveFileSuffix),
+		[]byte("incomplete"), 0o644))
+
+	target.fail = false
+	l = newTestAccessLogger(t, dir, target, 0)
+	l.flush(false)
+	require.Len(t, target.batches["bucket"], 3)
+	require.Contains(t, target.batches["bucket"][0], " 1 ")
+	require.Contains(t, target.batches["bucket"][1], " 2 ")
+	require.Equal(t, "line 3\n", target.batches["bucket"][2])
+	entries, err := os.ReadDir(bucketDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestAccessLoggerSpoolFull(t *testing.T) {
+	dir := t.TempDir()
+	target := &testAccessLogTarget{}
+	l, err := NewAccessLogger(AccessLoggingConfig{SpoolDir: dir, MaxSpoolSize: 1}, target.deliver)
+	require.NoError(t, err)
+	l.Log("bucket", &AccessLogRecord{Bucket: "bucket"})
+	l.flush(true)
+	require.Empty(t, target.batches)
+
+	_, err = NewAccessLogger(AccessLoggingConfig{}, target.deliver)
+	require.Error(t, err)
+}
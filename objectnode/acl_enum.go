@@ -60,6 +60,7 @@ var (
 		proto.OSSListObjectsAction:          PermissionRead,
 		proto.OSSHeadBucketAction:           PermissionRead,
 		proto.OSSListMultipartUploadsAction: PermissionRead,
+		proto.OSSListObjectVersionsAction:   PermissionRead,
 		// bucket write
 		proto.OSSPutObjectAction:               PermissionWrite,
 		proto.OSSPostObjectAction:              PermissionWrite,
@@ -67,6 +68,8 @@ var (
 		proto.OSSCreateMultipartUploadAction:   PermissionWrite,
 		proto.OSSUploadPartAction:              PermissionWrite,
 		proto.OSSCompleteMultipartUploadAction: PermissionWrite,
+		proto.OSSAppendObjectAction:            PermissionWrite,
+		proto.OSSRenameObjectAction:            PermissionWrite,
 		proto.OSSDeleteObjectAction:            PermissionWrite,
 		proto.OSSDeleteObjectsAction:           PermissionWrite,
 		// bucket acp
@@ -29,12 +29,15 @@ const (
 	ContextKeyRequestAction = "ctx_request_action"
 	ContextKeyStatusCode    = "status_code"
 	ContextKeyErrorMessage  = "error_message"
+	ContextKeyErrorCode     = "error_code"
 	ContextKeyBucket        = "bucket"
 	ContextKeyObject        = "object"
 	ContextKeyUid           = "uid"
 	ContextKeyAccessKey     = "access_key"
 	ContextKeyRequester     = "requester"
 	ContextKeyOwner         = "owner"
+	ContextKeyWebsite       = "website"
+	ContextKeyJobId         = "jobId"
 )
 
 func SetRequestID(r *http.Request, requestID string) {
@@ -53,6 +56,16 @@ func GetActionFromContext(r *http.Request) (action proto.Action) {
 	return proto.ParseAction(mux.Vars(r)[ContextKeyRequestAction])
 }
 
+// SetWebsiteKey marks the request as a request of the website endpoint with the requested key.
+func SetWebsiteKey(r *http.Request, key string) {
+	mux.Vars(r)[ContextKeyWebsite] = key
+}
+
+func GetWebsiteKey(r *http.Request) (key string, is bool) {
+	key, is = mux.Vars(r)[ContextKeyWebsite]
+	return
+}
+
 func SetResponseStatusCode(r *http.Request, code string) {
 	mux.Vars(r)[ContextKeyStatusCode] = code
 }
@@ -72,3 +85,11 @@ func SetResponseErrorMessage(r *http.Request, message string) {
 func getResponseErrorMessage(r *http.Request) string {
 	return mux.Vars(r)[ContextKeyErrorMessage]
 }
+
+func SetResponseErrorCode(r *http.Request, code string) {
+	mux.Vars(r)[ContextKeyErrorCode] = code
+}
+
+func getResponseErrorCode(r *http.Request) string {
+	return mux.Vars(r)[ContextKeyErrorCode]
+}
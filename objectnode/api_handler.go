@@ -136,6 +136,9 @@ func (o *ObjectNode) errorResponse(w http.ResponseWriter, r *http.Request, err e
 		if ec == nil {
 			ec = InternalErrorCode(err)
 		}
+		if o.websiteErrorResponse(w, r, ec) {
+			return
+		}
 		ec.ServeResponse(w, r)
 	}
 }
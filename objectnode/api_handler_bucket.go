@@ -83,10 +83,10 @@ func (o *ObjectNode) createBucketHandler(w http.ResponseWriter, r *http.Request)
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(userInfo.UserID, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(userInfo.UserID, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(userInfo.UserID, param.apiName)
+	defer rateLimit.ReleaseLimitResource(userInfo.UserID, param.Bucket(), param.apiName)
 
 	length, errorCode := VerifyContentLength(r, BodyLimit)
 	if errorCode != nil {
@@ -119,6 +119,11 @@ func (o *ObjectNode) createBucketHandler(w http.ResponseWriter, r *http.Request)
 		log.LogErrorf("createBucketHandler: parse acl fail: requestID(%v) err(%v)", GetRequestID(r), err)
 		return
 	}
+	if err = o.checkPublicACL(nil, acl); err != nil {
+		log.LogErrorf("createBucketHandler: check public acl fail: requestID(%v) volume(%v) acl(%+v) err(%v)",
+			GetRequestID(r), bucket, acl, err)
+		return
+	}
 
 	if err = o.mc.AdminAPI().CreateDefaultVolume(bucket, userInfo.UserID); err != nil {
 		log.LogErrorf("createBucketHandler: create bucket fail: requestID(%v) volume(%v) accessKey(%v) err(%v)",
@@ -182,10 +187,10 @@ func (o *ObjectNode) deleteBucketHandler(w http.ResponseWriter, r *http.Request)
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	if !vol.IsEmpty() {
 		errorCode = BucketNotEmpty
@@ -233,10 +238,10 @@ func (o *ObjectNode) listBucketsHandler(w http.ResponseWriter, r *http.Request)
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(userInfo.UserID, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(userInfo.UserID, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(userInfo.UserID, param.apiName)
+	defer rateLimit.ReleaseLimitResource(userInfo.UserID, param.Bucket(), param.apiName)
 
 	type bucket struct {
 		XMLName      xml.Name `xml:"Bucket"`
@@ -305,10 +310,10 @@ func (o *ObjectNode) getBucketLocationHandler(w http.ResponseWriter, r *http.Req
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	location := LocationResponse{Location: o.region}
 	response, err := MarshalXMLEntity(location)
@@ -346,10 +351,10 @@ func (o *ObjectNode) getBucketTaggingHandler(w http.ResponseWriter, r *http.Requ
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	var xattrInfo *proto.XAttrInfo
 	if xattrInfo, err = vol.GetXAttr(bucketRootPath, XAttrKeyOSSTagging); err != nil {
@@ -398,10 +403,10 @@ func (o *ObjectNode) putBucketTaggingHandler(w http.ResponseWriter, r *http.Requ
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	_, errorCode = VerifyContentLength(r, BodyLimit)
 	if errorCode != nil {
@@ -463,10 +468,10 @@ func (o *ObjectNode) deleteBucketTaggingHandler(w http.ResponseWriter, r *http.R
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	if err = vol.DeleteXAttr(bucketRootPath, XAttrKeyOSSTagging); err != nil {
 		log.LogErrorf("deleteBucketTaggingHandler: delete tagging xattr fail: requestID(%v) volume(%v) err(%v)",
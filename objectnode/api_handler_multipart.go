@@ -71,10 +71,10 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	var userInfo *proto.UserInfo
 	if userInfo, err = o.getUserInfoByAccessKeyV2(param.AccessKey()); err != nil {
@@ -116,6 +116,11 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 			GetRequestID(r), acl, err)
 		return
 	}
+	if err = o.checkPublicACL(vol, acl); err != nil {
+		log.LogErrorf("createMultipleUploadHandler: check public acl fail: requestID(%v) volume(%v) acl(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), acl, err)
+		return
+	}
 	opt := &PutFileOption{
 		MIMEType:     contentType,
 		Disposition:  contentDisposition,
@@ -125,6 +130,22 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		Expires:      expires,
 		ACL:          acl,
 	}
+	// Check server-side encryption
+	if opt.Encryption, err = o.newObjectEncryption(r.Header, vol); err != nil {
+		log.LogErrorf("createMultipleUploadHandler: new object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	// Check bucket replication, the object is replicated when the upload is completed
+	_, opt.ReplicationStatus = o.matchReplication(r.Header, vol, param.Object(), tagging)
+	// Check the checksum algorithm of the parts
+	if raw := r.Header.Get(XAmzChecksumAlgorithm); raw != "" {
+		var algorithm string
+		if algorithm, errorCode = parseChecksumAlgorithm(raw); errorCode != nil {
+			return
+		}
+		opt.Checksum = &ObjectChecksum{Algorithm: algorithm}
+	}
 
 	var uploadID string
 	if uploadID, err = vol.InitMultipart(param.Object(), opt); err != nil {
@@ -145,6 +166,10 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	setEncryptionHeaders(w.Header(), opt.Encryption)
+	if opt.Checksum != nil {
+		w.Header().Set(XAmzChecksumAlgorithm, opt.Checksum.Algorithm)
+	}
 	writeSuccessResponseXML(w, response)
 }
 
@@ -237,26 +262,57 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// Flow Control
 	var reader io.Reader
 	if length > DefaultFlowLimitSize {
-		reader = rateLimit.GetReader(vol.owner, param.apiName, r.Body)
+		reader = rateLimit.GetReader(vol.owner, param.Bucket(), param.apiName, r.Body)
 	} else {
 		reader = r.Body
 	}
 
+	var multipartInfo *proto.MultipartInfo
+	if multipartInfo, err = vol.mw.GetMultipart_ll(param.Object(), uploadId); err != nil {
+		log.LogErrorf("uploadPartHandler: meta get multipart fail: requestID(%v) volume(%v) path(%v) uploadId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, err)
+		err = handleWritePartErr(err)
+		return
+	}
+	// Server-side encryption of the upload
+	var sse *ObjectEncryption
+	if sse, err = o.openUploadEncryption(r.Header, multipartInfo); err != nil {
+		log.LogErrorf("uploadPartHandler: open upload encryption fail: requestID(%v) volume(%v) path(%v) uploadId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, err)
+		return
+	}
+	// Checksum of the part, which is computed with the algorithm of the upload if not specified
+	var uploadAlgorithm string
+	if uploadChecksum := parseObjectChecksum(multipartInfo.Extend[XAttrKeyOSSChecksum]); uploadChecksum != nil {
+		uploadAlgorithm = uploadChecksum.Algorithm
+	}
+	var checksumReader *ChecksumReader
+	if checksumReader, errorCode = NewChecksumReader(r, reader, uploadAlgorithm); errorCode != nil {
+		return
+	}
+	if checksumReader != nil {
+		reader = checksumReader
+	}
+
 	// Write Part
 	start := time.Now()
-	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, reader)
+	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, reader, sse, checksumReader.Checksum())
 	span.AppendTrackLog("part.w", start, err)
 	if err != nil {
 		log.LogErrorf("uploadPartHandler: write part fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) err(%v)",
 			GetRequestID(r), vol.Name(), param.Object(), uploadId, partNumberInt, err)
+		if errorCode = checksumReader.Err(); errorCode != nil {
+			err = nil
+			return
+		}
 		err = handleWritePartErr(err)
 		return
 	}
@@ -271,6 +327,10 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 
 	// write header to response
 	w.Header()[ETag] = []string{"\"" + fsFileInfo.ETag + "\""}
+	setEncryptionHeaders(w.Header(), sse)
+	if checksum := checksumReader.Checksum(); checksum != nil {
+		w.Header().Set(checksumHeader(checksum.Algorithm), checksum.Value)
+	}
 }
 
 // Upload part copy
@@ -325,10 +385,10 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// step2: extract params from req
 	srcBucket, srcObject, _, err := extractSrcBucketKey(r)
@@ -361,6 +421,24 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 	if errorCode != nil {
 		return
 	}
+	if err = o.openObjectEncryption(r.Header, srcFileInfo.Encryption, true); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: open source encryption fail: requestId(%v) srcVol(%v) path(%v) err(%v)",
+			GetRequestID(r), srcBucket, srcObject, err)
+		return
+	}
+	var multipartInfo *proto.MultipartInfo
+	if multipartInfo, err = vol.mw.GetMultipart_ll(param.Object(), uploadId); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: meta get multipart fail: requestID(%v) volume(%v) path(%v) uploadId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, err)
+		err = handleWritePartErr(err)
+		return
+	}
+	var sse *ObjectEncryption
+	if sse, err = o.openUploadEncryption(r.Header, multipartInfo); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: open upload encryption fail: requestID(%v) volume(%v) path(%v) uploadId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, err)
+		return
+	}
 
 	// step4: extract range params
 	copyRange := r.Header.Get(XAmzCopySourceRange)
@@ -381,8 +459,12 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	reader, writer := io.Pipe()
+	var srcWriter io.Writer = writer
+	if srcFileInfo.Encryption != nil {
+		srcWriter = srcFileInfo.Encryption.DecryptWriter(writer, fb)
+	}
 	go func() {
-		err = srcVol.readFile(srcFileInfo.Inode, size, srcObject, writer, fb, cl, srcFileInfo.StorageClass)
+		err = srcVol.readFile(srcFileInfo.Inode, size, srcObject, srcWriter, fb, cl, srcFileInfo.StorageClass)
 		if err != nil {
 			log.LogErrorf("uploadPartCopyHandler: read srcObj err(%v): requestId(%v) srcVol(%v) path(%v)",
 				err, GetRequestID(r), srcBucket, srcObject)
@@ -393,12 +475,18 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 	// step5: upload part by copy and flow control
 	var rd io.Reader
 	if copyLength > DefaultFlowLimitSize {
-		rd = rateLimit.GetReader(vol.owner, param.apiName, reader)
+		rd = rateLimit.GetReader(vol.owner, param.Bucket(), param.apiName, reader)
 	} else {
 		rd = reader
 	}
+	// the part checksum is computed with the algorithm of the upload
+	var checksum *ObjectChecksum
+	if uploadChecksum := parseObjectChecksum(multipartInfo.Extend[XAttrKeyOSSChecksum]); uploadChecksum != nil {
+		checksumReader := newChecksumReader(rd, uploadChecksum.Algorithm)
+		rd, checksum = checksumReader, checksumReader.Checksum()
+	}
 	start = time.Now()
-	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, rd)
+	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, rd, sse, checksum)
 	span.AppendTrackLog("part.w", start, err)
 	if err != nil {
 		log.LogErrorf("uploadPartCopyHandler: write part fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) err(%v)",
@@ -419,6 +507,7 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 
 	Etag := "\"" + fsFileInfo.ETag + "\""
 	w.Header()[ETag] = []string{Etag}
+	setEncryptionHeaders(w.Header(), sse)
 	response := NewS3CopyPartResult(Etag, fsFileInfo.CreateTime.UTC().Format(time.RFC3339)).String()
 
 	writeSuccessResponseXML(w, []byte(response))
@@ -508,10 +597,10 @@ func (o *ObjectNode) listPartsHandler(w http.ResponseWriter, r *http.Request) {
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// List Parts
 	start := time.Now()
@@ -672,6 +761,13 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 		errorCode = KeyTooLong
 		return
 	}
+	// the precondition of the conditional write
+	var cond *WriteCondition
+	if cond, errorCode = ParseWriteCondition(r); errorCode != nil {
+		log.LogErrorf("completeMultipartUploadHandler: invalid write condition: requestID(%v) ifMatch(%v) ifNoneMatch(%v)",
+			GetRequestID(r), r.Header.Get(IfMatch), r.Header.Get(IfNoneMatch))
+		return
+	}
 
 	var vol *Volume
 	if vol, err = o.getVol(param.Bucket()); err != nil {
@@ -682,10 +778,10 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// get uploaded part info in request
 	_, errorCode = VerifyContentLength(r, BodyLimit)
@@ -762,9 +858,24 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	// checksum of the object made up of the part checksums
+	var checksum *ObjectChecksum
+	if uploadChecksum := parseObjectChecksum(multipartInfo.Extend[XAttrKeyOSSChecksum]); uploadChecksum != nil {
+		if checksum, err = vol.multipartChecksum(uploadChecksum.Algorithm, committedPartInfo.Parts, multipartUploadRequest.Parts); err != nil {
+			log.LogErrorf("completeMultipartUploadHandler: compute checksum fail: requestID(%v) volume(%v) uploadID(%v) err(%v)",
+				GetRequestID(r), param.Bucket(), uploadId, err)
+			return
+		}
+		if checksum != nil {
+			committedPartInfo.Extend[XAttrKeyOSSChecksum] = checksum.Encode()
+		} else {
+			delete(committedPartInfo.Extend, XAttrKeyOSSChecksum)
+		}
+	}
+
 	// complete multipart
 	start = time.Now()
-	fsFileInfo, err := vol.CompleteMultipart(param.Object(), uploadId, committedPartInfo, discardedInods)
+	fsFileInfo, err := vol.CompleteMultipart(param.Object(), uploadId, committedPartInfo, discardedInods, cond)
 	span.AppendTrackLog("part.c", start, err)
 	if err != nil {
 		log.LogErrorf("completeMultipartUploadHandler: complete multipart fail: requestID(%v) volume(%v) uploadID(%v) err(%v)",
@@ -774,12 +885,26 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 		}
 		return
 	}
+	if multipartInfo.Extend[XAttrKeyOSSReplStatus] == ReplicationStatusPending {
+		tagging, _ := ParseTagging(multipartInfo.Extend[XAttrKeyOSSTagging])
+		replRule, _ := o.matchReplication(nil, vol, param.Object(), tagging)
+		o.submitReplication(GetRequestID(r), vol, param.Object(), fsFileInfo, replRule)
+	}
+	o.notifyEvent(r, vol, EventObjectCreatedCompleteMultipartUpload, param.Object(), fsFileInfo.Size, fsFileInfo.ETag, fsFileInfo.VersionId)
 
+	if len(fsFileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	sse, _ := ParseObjectEncryption([]byte(multipartInfo.Extend[XAttrKeyOSSSSE]))
+	setEncryptionHeaders(w.Header(), sse)
 	completeResult := CompleteMultipartResult{
 		Bucket: param.Bucket(),
 		Key:    param.Object(),
 		ETag:   wrapUnescapedQuot(fsFileInfo.ETag),
 	}
+	if checksum != nil {
+		completeResult.setChecksum(checksum)
+	}
 	response, ierr := MarshalXMLEntity(completeResult)
 	if ierr != nil {
 		log.LogErrorf("completeMultipartUploadHandler: xml marshal result fail: requestID(%v) result(%v) err(%v)",
@@ -825,10 +950,10 @@ func (o *ObjectNode) abortMultipartUploadHandler(w http.ResponseWriter, r *http.
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// Abort multipart upload
 	if err = vol.AbortMultipart(param.Object(), uploadId); err != nil {
@@ -894,10 +1019,10 @@ func (o *ObjectNode) listMultipartUploadsHandler(w http.ResponseWriter, r *http.
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// List multipart uploads
 	start := time.Now()
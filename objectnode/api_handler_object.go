@@ -71,10 +71,10 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// parse http range option
 	var (
@@ -155,13 +155,25 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, xattr, err := vol.ObjectMeta(param.Object())
+	versionId := r.URL.Query().Get(ParamVersionId)
+	fileInfo, xattr, err := vol.ObjectVersionMeta(param.Object(), versionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("getObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
+		log.LogErrorf("getObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
+			// the object whose current version is a delete marker
+			if versionId == "" {
+				if markerId, ok := vol.CurrentDeleteMarker(param.Object()); ok {
+					w.Header().Set(XAmzDeleteMarker, "true")
+					w.Header().Set(XAmzVersionId, markerId)
+				}
+			}
+		}
+		if err == MethodNotAllowed {
+			w.Header().Set(XAmzDeleteMarker, "true")
+			w.Header().Set(XAmzVersionId, versionId)
 		}
 		return
 	}
@@ -172,6 +184,13 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// server-side encryption check
+	if err = o.openObjectEncryption(r.Header, fileInfo.Encryption, false); err != nil {
+		log.LogErrorf("getObjectHandler: open object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
 	// validate and fix range
 	if isRangeRead && rangeUpper > uint64(fileInfo.Size)-1 {
 		rangeUpper = uint64(fileInfo.Size) - 1
@@ -232,6 +251,23 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(XAmzObjectLockMode, ComplianceMode)
 		w.Header().Set(XAmzObjectLockRetainUntilDate, fileInfo.RetainUntilDate)
 	}
+	if fileInfo.LegalHold == LegalHoldOn {
+		w.Header().Set(XAmzObjectLockLegalHold, LegalHoldOn)
+	}
+	if len(fileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fileInfo.VersionId)
+	}
+	setEncryptionHeaders(w.Header(), fileInfo.Encryption)
+	if fileInfo.ReplicationStatus != "" {
+		w.Header().Set(XAmzReplicationStatus, fileInfo.ReplicationStatus)
+	}
+	if restore := formatRestoreHeader(fileInfo.Restore); restore != "" {
+		w.Header().Set(XAmzRestore, restore)
+	}
+	// the checksum is of the whole object
+	if !isRangeRead {
+		setChecksumHeaders(r, w.Header(), fileInfo.Checksum)
+	}
 
 	// check request is whether contain param : partNumber
 	partNumber := r.URL.Query().Get(ParamPartNumber)
@@ -293,14 +329,23 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 	// Flow Control
 	var writer io.Writer
 	if size > DefaultFlowLimitSize {
-		writer = rateLimit.GetResponseWriter(vol.owner, param.apiName, w)
+		writer = rateLimit.GetResponseWriter(vol.owner, param.Bucket(), param.apiName, w)
 	} else {
 		writer = w
 	}
+	if fileInfo.Encryption != nil {
+		writer = fileInfo.Encryption.DecryptWriter(writer, offset)
+	}
+
+	// read the restored copy of the blobstore object if it is available
+	readInode, readStorageClass := fileInfo.Inode, fileInfo.StorageClass
+	if fileInfo.Restore.Restored() && !fileInfo.Restore.Expired(time.Now()) {
+		readInode, readStorageClass = fileInfo.Restore.CopyInode, fileInfo.Restore.CopyStorageClass
+	}
 
 	// read file
 	start = time.Now()
-	err = vol.readFile(fileInfo.Inode, fileSize, param.Object(), writer, offset, size, fileInfo.StorageClass)
+	err = vol.readFile(readInode, fileSize, param.Object(), writer, offset, size, readStorageClass)
 	span.AppendTrackLog("file.r", start, err)
 	if err != nil {
 		log.LogErrorf("getObjectHandler: read file fail: requestID(%v) volume(%v) path(%v) offset(%v) size(%v) err(%v)",
@@ -401,20 +446,32 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// get object meta
 	start := time.Now()
-	fileInfo, _, err := vol.ObjectMeta(param.Object())
+	versionId := r.URL.Query().Get(ParamVersionId)
+	fileInfo, _, err := vol.ObjectVersionMeta(param.Object(), versionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("headObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
+		log.LogErrorf("headObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
+			// the object whose current version is a delete marker
+			if versionId == "" {
+				if markerId, ok := vol.CurrentDeleteMarker(param.Object()); ok {
+					w.Header().Set(XAmzDeleteMarker, "true")
+					w.Header().Set(XAmzVersionId, markerId)
+				}
+			}
+		}
+		if err == MethodNotAllowed {
+			w.Header().Set(XAmzDeleteMarker, "true")
+			w.Header().Set(XAmzVersionId, versionId)
 		}
 		return
 	}
@@ -478,6 +535,13 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// server-side encryption check
+	if err = o.openObjectEncryption(r.Header, fileInfo.Encryption, false); err != nil {
+		log.LogErrorf("headObjectHandler: open object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
 	// set response header
 	w.Header().Set(AcceptRanges, ValueAcceptRanges)
 	w.Header().Set(LastModified, formatTimeRFC1123(fileInfo.ModifyTime))
@@ -500,6 +564,20 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(XAmzObjectLockMode, ComplianceMode)
 		w.Header().Set(XAmzObjectLockRetainUntilDate, fileInfo.RetainUntilDate)
 	}
+	if fileInfo.LegalHold == LegalHoldOn {
+		w.Header().Set(XAmzObjectLockLegalHold, LegalHoldOn)
+	}
+	if len(fileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fileInfo.VersionId)
+	}
+	setEncryptionHeaders(w.Header(), fileInfo.Encryption)
+	if fileInfo.ReplicationStatus != "" {
+		w.Header().Set(XAmzReplicationStatus, fileInfo.ReplicationStatus)
+	}
+	if restore := formatRestoreHeader(fileInfo.Restore); restore != "" {
+		w.Header().Set(XAmzRestore, restore)
+	}
+	setChecksumHeaders(r, w.Header(), fileInfo.Checksum)
 
 	// check request is whether contain param : partNumber
 	partNumber := r.URL.Query().Get(ParamPartNumber)
@@ -654,25 +732,33 @@ func (o *ObjectNode) deleteObjectsHandler(w http.ResponseWriter, r *http.Request
 			})
 			continue
 		}
-		log.LogWarnf("deleteObjectsHandler: delete path: requestID(%v) remote(%v) volume(%v) path(%v)",
-			GetRequestID(r), getRequestIP(r), vol.Name(), object.Key)
+		log.LogWarnf("deleteObjectsHandler: delete path: requestID(%v) remote(%v) volume(%v) path(%v) versionId(%v)",
+			GetRequestID(r), getRequestIP(r), vol.Name(), object.Key, object.VersionId)
 		// QPS and Concurrency Limit
 		rateLimit := o.AcquireRateLimiter()
-		if err = rateLimit.AcquireLimitResource(vol.owner, DELETE_OBJECT); err != nil {
+		if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), DELETE_OBJECT); err != nil {
 			return
 		}
-		if err1 := vol.DeletePath(object.Key); err1 != nil {
-			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) err(%v)",
-				GetRequestID(r), vol.Name(), object.Key, err1)
+		if deleted, err1 := vol.DeleteObject(object.Key, object.VersionId); err1 != nil {
+			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+				GetRequestID(r), vol.Name(), object.Key, object.VersionId, err1)
 			if !strings.Contains(err1.Error(), AccessDenied.ErrorMessage) {
-				deletedErrors = append(deletedErrors, Error{Key: object.Key, Code: "InternalError", Message: err1.Error()})
+				deletedErrors = append(deletedErrors, Error{Key: object.Key, VersionId: object.VersionId, Code: "InternalError", Message: err1.Error()})
 			} else {
-				deletedErrors = append(deletedErrors, Error{Key: object.Key, Code: "AccessDenied", Message: err1.Error()})
+				deletedErrors = append(deletedErrors, Error{Key: object.Key, VersionId: object.VersionId, Code: "AccessDenied", Message: err1.Error()})
 			}
 		} else {
-			deletedObjects = append(deletedObjects, Deleted{Key: object.Key})
+			result := Deleted{Key: object.Key, VersionId: object.VersionId}
+			event := EventObjectRemovedDelete
+			if deleted.DeleteMarker {
+				result.DeleteMarker = "true"
+				result.DeleteMarkerVersionId = deleted.VersionId
+				event = EventObjectRemovedDeleteMarkerCreated
+			}
+			deletedObjects = append(deletedObjects, result)
+			o.notifyEvent(r, vol, event, object.Key, 0, "", deleted.VersionId)
 		}
-		rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+		rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 	}
 	span.AppendTrackLog("files.d", start, err)
 
@@ -714,6 +800,23 @@ func extractSrcBucketKey(r *http.Request) (srcBucketId, srcKey, versionId string
 	return
 }
 
+// extractRenameSourceKey parses the source key of the rename from "x-amz-rename-source", which
+// is in the format of "/bucket/key" or "bucket/key" and must be in the same bucket as the target.
+func extractRenameSourceKey(r *http.Request, bucket string) (srcKey string, err error) {
+	renameSource, err := url.QueryUnescape(r.Header.Get(XAmzRenameSource))
+	if err != nil {
+		return "", InvalidArgument
+	}
+	path := strings.SplitN(strings.TrimPrefix(renameSource, "/"), "/", 2)
+	if len(path) == 1 || path[1] == "" {
+		return "", InvalidArgument
+	}
+	if path[0] != bucket {
+		return "", InvalidRenameSource
+	}
+	return path[1], nil
+}
+
 // Copy object
 // API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_CopyObject.html .
 func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
@@ -740,6 +843,13 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = KeyTooLong
 		return
 	}
+	// the precondition of the conditional write
+	var cond *WriteCondition
+	if cond, errorCode = ParseWriteCondition(r); errorCode != nil {
+		log.LogErrorf("copyObjectHandler: invalid write condition: requestID(%v) ifMatch(%v) ifNoneMatch(%v)",
+			GetRequestID(r), r.Header.Get(IfMatch), r.Header.Get(IfNoneMatch))
+		return
+	}
 	var vol *Volume
 	if vol, err = o.getVol(param.Bucket()); err != nil {
 		log.LogErrorf("copyObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
@@ -749,10 +859,10 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// client can reset these system metadata: Content-Type, Content-Disposition
 	contentType := r.Header.Get(ContentType)
@@ -781,7 +891,7 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// parse x-amz-copy-source header
-	sourceBucket, sourceObject, _, err := extractSrcBucketKey(r)
+	sourceBucket, sourceObject, sourceVersionId, err := extractSrcBucketKey(r)
 	if err != nil {
 		log.LogErrorf("copyObjectHandler: copySource(%v) argument invalid: requestID(%v) volume(%v) err(%v)",
 			r.Header.Get(XAmzCopySource), GetRequestID(r), param.Bucket(), err)
@@ -801,6 +911,11 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 			GetRequestID(r), param.Bucket(), acl, err)
 		return
 	}
+	if err = o.checkPublicACL(vol, acl); err != nil {
+		log.LogErrorf("copyObjectHandler: check public acl fail: requestID(%v) volume(%v) acl(%+v) err(%v)",
+			GetRequestID(r), param.Bucket(), acl, err)
+		return
+	}
 
 	// get src object meta
 	var sourceVol *Volume
@@ -812,14 +927,18 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, _, err := sourceVol.ObjectMeta(sourceObject)
+	fileInfo, sourceXAttr, err := sourceVol.ObjectVersionMeta(sourceObject, sourceVersionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("copyObjectHandler: get object meta fail: requestID(%v) srcVolume(%v) srcObject(%v) err(%v)",
-			GetRequestID(r), sourceBucket, sourceObject, err)
+		log.LogErrorf("copyObjectHandler: get object meta fail: requestID(%v) srcVolume(%v) srcObject(%v) srcVersionId(%v) err(%v)",
+			GetRequestID(r), sourceBucket, sourceObject, sourceVersionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
 		}
+		if err == MethodNotAllowed {
+			// a delete marker can not be the copy source
+			errorCode = InvalidRequestCopyDeleteMarker
+		}
 		return
 	}
 	if fileInfo.Size > SinglePutLimit {
@@ -885,9 +1004,29 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// server-side encryption of the source and target object
+	if err = o.openObjectEncryption(r.Header, fileInfo.Encryption, true); err != nil {
+		log.LogErrorf("copyObjectHandler: open source encryption fail: requestID(%v) srcVolume(%v) srcObject(%v) err(%v)",
+			GetRequestID(r), sourceBucket, sourceObject, err)
+		return
+	}
+	var encryption *ObjectEncryption
+	if encryption, err = o.newObjectEncryption(r.Header, vol); err != nil {
+		log.LogErrorf("copyObjectHandler: new object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+
 	// parse user-defined metadata
 	metadata := ParseUserDefinedMetadata(r.Header)
 
+	// the tagging of the source object is copied with the COPY directive
+	var tagging *Tagging
+	if metadataDirective == MetadataDirectiveCopy && sourceXAttr != nil {
+		tagging, _ = ParseTagging(string(sourceXAttr.Get(XAttrKeyOSSTagging)))
+	}
+	replRule, replStatus := o.matchReplication(r.Header, vol, param.Object(), tagging)
+
 	// copy file
 	opt := &PutFileOption{
 		MIMEType:     contentType,
@@ -897,6 +1036,12 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          acl,
 		ObjectLock:   objetLock,
+		Encryption:   encryption,
+		Condition:    cond,
+
+		SourceVersionId:   sourceVersionId,
+		SourceEncryption:  fileInfo.Encryption,
+		ReplicationStatus: replStatus,
 	}
 	start = time.Now()
 	fsFileInfo, err := vol.CopyFile(sourceVol, sourceObject, param.Object(), metadataDirective, opt)
@@ -918,7 +1063,16 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = CopySourceSizeTooLarge
 		return
 	}
+	o.submitReplication(GetRequestID(r), vol, param.Object(), fsFileInfo, replRule)
+	o.notifyEvent(r, vol, EventObjectCreatedCopy, param.Object(), fsFileInfo.Size, fsFileInfo.ETag, fsFileInfo.VersionId)
 
+	if len(fsFileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	if len(sourceVersionId) > 0 {
+		w.Header().Set(XAmzCopySourceVersionId, sourceVersionId)
+	}
+	setEncryptionHeaders(w.Header(), encryption)
 	copyResult := CopyResult{
 		ETag:         "\"" + fsFileInfo.ETag + "\"",
 		LastModified: formatTimeISO(fsFileInfo.ModifyTime),
@@ -959,10 +1113,10 @@ func (o *ObjectNode) getBucketV1Handler(w http.ResponseWriter, r *http.Request)
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// get options
 	marker := r.URL.Query().Get(ParamMarker)
@@ -1098,10 +1252,10 @@ func (o *ObjectNode) getBucketV2Handler(w http.ResponseWriter, r *http.Request)
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// get options
 	prefix := r.URL.Query().Get(ParamPrefix)
@@ -1265,6 +1419,13 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = KeyTooLong
 		return
 	}
+	// the precondition of the conditional write
+	var cond *WriteCondition
+	if cond, errorCode = ParseWriteCondition(r); errorCode != nil {
+		log.LogErrorf("putObjectHandler: invalid write condition: requestID(%v) ifMatch(%v) ifNoneMatch(%v)",
+			GetRequestID(r), r.Header.Get(IfMatch), r.Header.Get(IfNoneMatch))
+		return
+	}
 	var vol *Volume
 	if vol, err = o.getVol(param.Bucket()); err != nil {
 		log.LogErrorf("putObjectHandler: load volume fail: requestID(%v)  volume(%v) err(%v)",
@@ -1297,10 +1458,10 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// Check 'x-amz-tagging' header
 	// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObject.html#API_PutObject_RequestSyntax
@@ -1331,6 +1492,11 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 			GetRequestID(r), vol.Name(), param.Object(), acl, err)
 		return
 	}
+	if err = o.checkPublicACL(vol, acl); err != nil {
+		log.LogErrorf("putObjectHandler: check public acl fail: requestID(%v) volume(%v) path(%v) acl(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), acl, err)
+		return
+	}
 
 	// Verify ContentLength
 	length := GetContentLength(r)
@@ -1363,6 +1529,15 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	// Checking user-defined metadata
 	metadata := ParseUserDefinedMetadata(r.Header)
+	// Checking server-side encryption
+	var encryption *ObjectEncryption
+	if encryption, err = o.newObjectEncryption(r.Header, vol); err != nil {
+		log.LogErrorf("putObjectHandler: new object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	// Checking bucket replication
+	replRule, replStatus := o.matchReplication(r.Header, vol, param.Object(), tagging)
 	// Audit file write
 	log.LogInfof("Audit: put object: requestID(%v) remote(%v) volume(%v) path(%v) type(%v)",
 		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), contentType)
@@ -1370,10 +1545,18 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 	// Flow Control
 	var reader io.Reader
 	if length > DefaultFlowLimitSize {
-		reader = rateLimit.GetReader(vol.owner, param.apiName, r.Body)
+		reader = rateLimit.GetReader(vol.owner, param.Bucket(), param.apiName, r.Body)
 	} else {
 		reader = r.Body
 	}
+	// Verify the additional checksum of the data
+	var checksumReader *ChecksumReader
+	if checksumReader, errorCode = NewChecksumReader(r, reader, ""); errorCode != nil {
+		return
+	}
+	if checksumReader != nil {
+		reader = checksumReader
+	}
 
 	// Put Object
 	opt := &PutFileOption{
@@ -1385,6 +1568,11 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          acl,
 		ObjectLock:   objetLock,
+		Encryption:   encryption,
+		Checksum:     checksumReader.Checksum(),
+		Condition:    cond,
+
+		ReplicationStatus: replStatus,
 	}
 	start := time.Now()
 	fsFileInfo, err := vol.PutObject(param.Object(), reader, opt)
@@ -1392,9 +1580,14 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.LogErrorf("putObjectHandler: put object fail: requestId(%v) volume(%v) path(%v) remote(%v) err(%v)",
 			GetRequestID(r), vol.Name(), param.Object(), getRequestIP(r), err)
+		if errorCode = checksumReader.Err(); errorCode != nil {
+			err = nil
+			return
+		}
 		err = handlePutObjectErr(err)
 		return
 	}
+	o.submitReplication(GetRequestID(r), vol, param.Object(), fsFileInfo, replRule)
 
 	// check content MD5
 	if requestMD5 != "" && requestMD5 != fsFileInfo.ETag {
@@ -1403,9 +1596,268 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = BadDigest
 		return
 	}
+	o.notifyEvent(r, vol, EventObjectCreatedPut, param.Object(), fsFileInfo.Size, fsFileInfo.ETag, fsFileInfo.VersionId)
 
 	// set response header
 	w.Header()[ETag] = []string{wrapUnescapedQuot(fsFileInfo.ETag)}
+	if len(fsFileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	setEncryptionHeaders(w.Header(), encryption)
+	if checksum := checksumReader.Checksum(); checksum != nil {
+		w.Header().Set(checksumHeader(checksum.Algorithm), checksum.Value)
+	}
+}
+
+// Append object
+// The data is appended to the appendable object at the position, which must be equal to the
+// length of the object, compatible with the append semantics of OSS and COS.
+func (o *ObjectNode) appendObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" || strings.HasSuffix(param.Object(), pathSep) {
+		errorCode = InvalidKey
+		return
+	}
+	if len(param.Object()) > MaxKeyLength {
+		errorCode = KeyTooLong
+		return
+	}
+	position, parseErr := strconv.ParseUint(r.URL.Query().Get(ParamPosition), 10, 64)
+	if parseErr != nil {
+		log.LogErrorf("appendObjectHandler: invalid position: requestID(%v) position(%v) err(%v)",
+			GetRequestID(r), r.URL.Query().Get(ParamPosition), parseErr)
+		errorCode = InvalidArgument
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("appendObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	// the object is appended in place, which is not possible if the data is versioned,
+	// encrypted or stored in the blobstore
+	var versioning *VersioningConfiguration
+	if versioning, err = vol.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("appendObjectHandler: load versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	var encryption *ObjectEncryption
+	if encryption, err = o.newObjectEncryption(r.Header, vol); err != nil {
+		log.LogErrorf("appendObjectHandler: new object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	if versioning.Configured() || encryption != nil || proto.IsCold(vol.volType) {
+		log.LogErrorf("appendObjectHandler: append not supported: requestID(%v) volume(%v) versioning(%v) "+
+			"encrypted(%v) volType(%v)", GetRequestID(r), vol.Name(), versioning.Configured(), encryption != nil, vol.volType)
+		errorCode = AppendNotSupported
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
+
+	// the tagging, ACL and metadata only take effect when the object is created
+	var tagging *Tagging
+	if xAmxTagging := r.Header.Get(XAmzTagging); xAmxTagging != "" {
+		if tagging, err = ParseTagging(xAmxTagging); err != nil {
+			errorCode = InvalidArgument
+			return
+		}
+		if err = tagging.Validate(); err != nil {
+			log.LogErrorf("appendObjectHandler: tagging validate fail: requestID(%v) volume(%v) path(%v) tagging(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), tagging, err)
+			return
+		}
+	}
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKeyV2(param.AccessKey()); err != nil {
+		log.LogErrorf("appendObjectHandler: get user info fail: requestID(%v) volume(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), param.AccessKey(), err)
+		return
+	}
+	acl, err := ParseACL(r, userInfo.UserID, false, vol.GetOwner() != userInfo.UserID)
+	if err != nil {
+		log.LogErrorf("appendObjectHandler: parse acl fail: requestID(%v) volume(%v) path(%v) acl(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), acl, err)
+		return
+	}
+	if err = o.checkPublicACL(vol, acl); err != nil {
+		log.LogErrorf("appendObjectHandler: check public acl fail: requestID(%v) volume(%v) path(%v) acl(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), acl, err)
+		return
+	}
+
+	// Verify ContentLength
+	length := GetContentLength(r)
+	if length > SinglePutLimit {
+		errorCode = EntityTooLarge
+		return
+	}
+	if length < 0 {
+		errorCode = MissingContentLength
+		return
+	}
+
+	cacheControl := r.Header.Get(CacheControl)
+	if len(cacheControl) > 0 && !ValidateCacheControl(cacheControl) {
+		errorCode = InvalidCacheArgument
+		return
+	}
+	expires := r.Header.Get(Expires)
+	if len(expires) > 0 && !ValidateCacheExpires(expires) {
+		errorCode = InvalidCacheArgument
+		return
+	}
+	log.LogInfof("Audit: append object: requestID(%v) remote(%v) volume(%v) path(%v) position(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), position)
+
+	// Flow Control
+	var reader io.Reader
+	if length > DefaultFlowLimitSize {
+		reader = rateLimit.GetReader(vol.owner, param.Bucket(), param.apiName, r.Body)
+	} else {
+		reader = r.Body
+	}
+
+	opt := &PutFileOption{
+		MIMEType:     r.Header.Get(ContentType),
+		Disposition:  r.Header.Get(ContentDisposition),
+		Tagging:      tagging,
+		Metadata:     ParseUserDefinedMetadata(r.Header),
+		CacheControl: cacheControl,
+		Expires:      expires,
+		ACL:          acl,
+	}
+	start := time.Now()
+	fsFileInfo, nextPosition, err := vol.AppendObject(param.Object(), position, reader, opt)
+	span.AppendTrackLog("file.a", start, err)
+	if err != nil {
+		log.LogErrorf("appendObjectHandler: append object fail: requestId(%v) volume(%v) path(%v) position(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), position, err)
+		if err == PositionNotEqualToLength {
+			w.Header().Set(XAmzNextAppendPosition, strconv.FormatUint(nextPosition, 10))
+		}
+		err = handlePutObjectErr(err)
+		return
+	}
+	o.notifyEvent(r, vol, EventObjectCreatedAppend, param.Object(), fsFileInfo.Size, fsFileInfo.ETag, "")
+
+	w.Header()[ETag] = []string{wrapUnescapedQuot(fsFileInfo.ETag)}
+	w.Header().Set(XAmzNextAppendPosition, strconv.FormatUint(nextPosition, 10))
+}
+
+// Rename object
+// The object or the prefix is renamed to the target key in the same bucket atomically, the target
+// is authorized as PutObject and the source as DeleteObject.
+func (o *ObjectNode) renameObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	if len(param.Object()) > MaxKeyLength {
+		errorCode = KeyTooLong
+		return
+	}
+	var srcKey string
+	if srcKey, err = extractRenameSourceKey(r, param.Bucket()); err != nil {
+		log.LogErrorf("renameObjectHandler: invalid rename source: requestID(%v) volume(%v) renameSource(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), r.Header.Get(XAmzRenameSource), err)
+		return
+	}
+	// only the absence of the target can be guaranteed atomically by the rename
+	cond, ec := ParseWriteCondition(r)
+	if ec != nil {
+		errorCode = ec
+		return
+	}
+	if cond != nil && cond.IfMatch != "" {
+		errorCode = UnsupportedOperation
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("renameObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	// the versions of the object are kept by the key, and the retention of the objects
+	// under the prefix can not be checked atomically
+	var versioning *VersioningConfiguration
+	if versioning, err = vol.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("renameObjectHandler: load versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	var objectLock *ObjectLockConfig
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("renameObjectHandler: load object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if versioning.Configured() || (objectLock != nil && strings.HasSuffix(srcKey, pathSep)) {
+		log.LogErrorf("renameObjectHandler: rename not supported: requestID(%v) volume(%v) versioning(%v) "+
+			"objectLock(%v) srcKey(%v)", GetRequestID(r), vol.Name(), versioning.Configured(), objectLock != nil, srcKey)
+		errorCode = RenameNotSupported
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
+
+	log.LogInfof("Audit: rename object: requestID(%v) remote(%v) volume(%v) srcKey(%v) dstKey(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), srcKey, param.Object())
+
+	start := time.Now()
+	err = vol.RenameObject(srcKey, param.Object(), cond)
+	span.AppendTrackLog("file.rename", start, err)
+	if err != nil {
+		log.LogErrorf("renameObjectHandler: rename object fail: requestID(%v) volume(%v) srcKey(%v) dstKey(%v) err(%v)",
+			GetRequestID(r), vol.Name(), srcKey, param.Object(), err)
+		err = handlePutObjectErr(err)
+		return
+	}
 }
 
 // Post object
@@ -1443,10 +1895,10 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// qps and concurrency limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// parse the request form
 	formReq := NewFormRequest(r)
@@ -1506,6 +1958,11 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 			errorCode.ErrorMessage = fmt.Sprintf("%s (%v)", errorCode.ErrorMessage, err)
 			return
 		}
+		if err = o.checkPublicACL(vol, aclInfo); err != nil {
+			log.LogErrorf("postObjectHandler: check public acl fail: requestID(%v) volume(%v) acl(%v) err(%v)",
+				GetRequestID(r), param.Bucket(), acl, err)
+			return
+		}
 	}
 
 	var tagging *Tagging
@@ -1595,10 +2052,26 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// server-side encryption specified by the form fields
+	sseHeader := make(http.Header)
+	for _, name := range []string{XAmzServerSideEncryption, XAmzServerSideEncryptionKMSKeyId,
+		XAmzSSECustomerAlgorithm, XAmzSSECustomerKey, XAmzSSECustomerKeyMD5} {
+		if value := formReq.MultipartFormValue(name); value != "" {
+			sseHeader.Set(name, value)
+		}
+	}
+	var encryption *ObjectEncryption
+	if encryption, err = o.newObjectEncryption(sseHeader, vol); err != nil {
+		log.LogErrorf("postObjectHandler: new object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, err)
+		return
+	}
+	replRule, replStatus := o.matchReplication(nil, vol, key, tagging)
+
 	// flow control
 	var reader io.Reader
 	if size > DefaultFlowLimitSize {
-		reader = rateLimit.GetReader(vol.owner, param.apiName, f)
+		reader = rateLimit.GetReader(vol.owner, param.Bucket(), param.apiName, f)
 	} else {
 		reader = f
 	}
@@ -1613,6 +2086,9 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          aclInfo,
 		ObjectLock:   objetLock,
+		Encryption:   encryption,
+
+		ReplicationStatus: replStatus,
 	}
 	start := time.Now()
 	fsFileInfo, err := vol.PutObject(key, reader, putOpt)
@@ -1623,6 +2099,7 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 		err = handlePutObjectErr(err)
 		return
 	}
+	o.submitReplication(GetRequestID(r), vol, key, fsFileInfo, replRule)
 
 	// check content-md5 of actual data if specified in the request
 	if requestMD5 != "" && requestMD5 != fsFileInfo.ETag {
@@ -1631,10 +2108,15 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = BadDigest
 		return
 	}
+	o.notifyEvent(r, vol, EventObjectCreatedPost, key, fsFileInfo.Size, fsFileInfo.ETag, fsFileInfo.VersionId)
 
 	// set response header
 	etag := wrapUnescapedQuot(fsFileInfo.ETag)
 	w.Header()[ETag] = []string{etag}
+	if len(fsFileInfo.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	setEncryptionHeaders(w.Header(), encryption)
 
 	// return response depending on success_action_xxx parameter
 	if successRedirectURL != nil {
@@ -1707,27 +2189,38 @@ func (o *ObjectNode) deleteObjectHandler(w http.ResponseWriter, r *http.Request)
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
+
+	versionId := r.URL.Query().Get(ParamVersionId)
 
 	// Audit deletion
-	log.LogInfof("Audit: delete object: requestID(%v) remote(%v) volume(%v) path(%v)",
-		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object())
+	log.LogInfof("Audit: delete object: requestID(%v) remote(%v) volume(%v) path(%v) versionId(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), versionId)
 
 	// Delete file
 	start := time.Now()
-	err = vol.DeletePath(param.Object())
+	result, err := vol.DeleteObject(param.Object(), versionId)
 	span.AppendTrackLog("file.d", start, err)
 	if err != nil {
 		log.LogErrorf("deleteObjectHandler: Volume delete file fail: "+
-			"requestID(%v) volume(%v) path(%v) err(%v)", GetRequestID(r), vol.Name(), param.Object(), err)
+			"requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)", GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		if strings.Contains(err.Error(), AccessDenied.ErrorMessage) {
 			err = AccessDenied
 		}
 		return
 	}
+	if len(result.VersionId) > 0 {
+		w.Header().Set(XAmzVersionId, result.VersionId)
+	}
+	event := EventObjectRemovedDelete
+	if result.DeleteMarker {
+		w.Header().Set(XAmzDeleteMarker, "true")
+		event = EventObjectRemovedDeleteMarkerCreated
+	}
+	o.notifyEvent(r, vol, event, param.Object(), 0, "", result.VersionId)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -1763,10 +2256,10 @@ func (o *ObjectNode) getObjectTaggingHandler(w http.ResponseWriter, r *http.Requ
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	// get xattr
 	start := time.Now()
@@ -1825,10 +2318,10 @@ func (o *ObjectNode) putObjectTaggingHandler(w http.ResponseWriter, r *http.Requ
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	_, errorCode = VerifyContentLength(r, BodyLimit)
 	if errorCode != nil {
@@ -1866,6 +2359,7 @@ func (o *ObjectNode) putObjectTaggingHandler(w http.ResponseWriter, r *http.Requ
 		}
 		return
 	}
+	o.notifyEvent(r, vol, EventObjectTaggingPut, param.Object(), 0, "", "")
 }
 
 // Delete object tagging
@@ -1899,10 +2393,10 @@ func (o *ObjectNode) deleteObjectTaggingHandler(w http.ResponseWriter, r *http.R
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	start := time.Now()
 	err = vol.DeleteXAttr(param.object, XAttrKeyOSSTagging)
@@ -1915,6 +2409,7 @@ func (o *ObjectNode) deleteObjectTaggingHandler(w http.ResponseWriter, r *http.R
 		}
 		return
 	}
+	o.notifyEvent(r, vol, EventObjectTaggingDelete, param.Object(), 0, "", "")
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -1949,10 +2444,10 @@ func (o *ObjectNode) putObjectXAttrHandler(w http.ResponseWriter, r *http.Reques
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	_, errorCode = VerifyContentLength(r, BodyLimit)
 	if errorCode != nil {
@@ -2024,10 +2519,10 @@ func (o *ObjectNode) getObjectXAttrHandler(w http.ResponseWriter, r *http.Reques
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	var xattrKey string
 	if xattrKey = param.GetVar(ParamKey); len(xattrKey) == 0 {
@@ -2093,10 +2588,10 @@ func (o *ObjectNode) deleteObjectXAttrHandler(w http.ResponseWriter, r *http.Req
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	var xattrKey string
 	if xattrKey = param.GetVar(ParamKey); len(xattrKey) == 0 {
@@ -2147,10 +2642,10 @@ func (o *ObjectNode) listObjectXAttrs(w http.ResponseWriter, r *http.Request) {
 
 	// QPS and Concurrency Limit
 	rateLimit := o.AcquireRateLimiter()
-	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.Bucket(), param.apiName); err != nil {
 		return
 	}
-	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.Bucket(), param.apiName)
 
 	start := time.Now()
 	keys, err := vol.ListXAttrs(param.object)
@@ -2243,6 +2738,334 @@ func (o *ObjectNode) getObjectRetentionHandler(w http.ResponseWriter, r *http.Re
 	writeSuccessResponseXML(w, b)
 }
 
+// GetObjectLegalHold
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLegalHold.html
+func (o *ObjectNode) getObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	// check args
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	var objectLock *ObjectLockConfig
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: load object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if objectLock == nil {
+		errorCode = MissingObjectLockConfiguration
+		return
+	}
+
+	// get object legal hold
+	versionId := r.URL.Query().Get(ParamVersionId)
+	start := time.Now()
+	status, err := vol.GetObjectLegalHold(param.Object(), versionId)
+	span.AppendTrackLog("xattr.r", start, err)
+	if err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: get legal hold fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+	if status == "" {
+		errorCode = NoSuchObjectLockConfiguration
+		return
+	}
+	legalHold := ObjectLegalHold{XMLNS: S3Namespace, Status: status}
+	b, err := MarshalXMLEntity(legalHold)
+	if err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: xml marshal fail: requestId(%v) volume(%v) result(%v) err(%v)",
+			GetRequestID(r), vol.Name(), legalHold, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, b)
+}
+
+// PutObjectLegalHold
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLegalHold.html
+func (o *ObjectNode) putObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	// check args
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	var objectLock *ObjectLockConfig
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: load object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if objectLock == nil {
+		errorCode = MissingObjectLockConfiguration
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxObjectLegalHoldSize+1)); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxObjectLegalHoldSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var legalHold *ObjectLegalHold
+	if legalHold, errorCode = ParseObjectLegalHold(body); errorCode != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: parse legal hold fail: requestID(%v) volume(%v) body(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+
+	versionId := r.URL.Query().Get(ParamVersionId)
+	start := time.Now()
+	err = vol.SetObjectLegalHold(param.Object(), versionId, legalHold.Status)
+	span.AppendTrackLog("xattr.w", start, err)
+	if err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: set legal hold fail: requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+	log.LogInfof("Audit: put object legal hold: requestID(%v) remote(%v) volume(%v) path(%v) versionId(%v) status(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), versionId, legalHold.Status)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Restore object
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_RestoreObject.html
+func (o *ObjectNode) restoreObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	// check args
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("restoreObjectHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxRestoreRequestSize+1)); err != nil {
+		log.LogErrorf("restoreObjectHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxRestoreRequestSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var req *RestoreRequest
+	if req, errorCode = ParseRestoreRequest(body); errorCode != nil {
+		log.LogErrorf("restoreObjectHandler: parse restore request fail: requestID(%v) volume(%v) body(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+
+	versionId := r.URL.Query().Get(ParamVersionId)
+	start := time.Now()
+	accepted, err := vol.RestoreObject(param.Object(), versionId, req.Days, func(task *proto.RestoreTask) error {
+		return o.mc.AdminAPI().RestoreObject(task)
+	})
+	span.AppendTrackLog("restore", start, err)
+	if err != nil {
+		log.LogErrorf("restoreObjectHandler: restore object fail: requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+	log.LogInfof("Audit: restore object: requestID(%v) remote(%v) volume(%v) path(%v) versionId(%v) days(%v) accepted(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), versionId, req.Days, accepted)
+
+	if accepted {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Select object content
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+func (o *ObjectNode) selectObjectContentHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	// check args
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("selectObjectContentHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxSelectRequestSize+1)); err != nil {
+		log.LogErrorf("selectObjectContentHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxSelectRequestSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var req *SelectObjectContentRequest
+	if req, errorCode = ParseSelectObjectContentRequest(body); errorCode != nil {
+		log.LogErrorf("selectObjectContentHandler: parse request fail: requestID(%v) volume(%v) body(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+	var query *sqlQuery
+	if query, err = parseSQL(req.Expression); err != nil {
+		log.LogErrorf("selectObjectContentHandler: parse expression fail: requestID(%v) volume(%v) expression(%v) err(%v)",
+			GetRequestID(r), vol.Name(), req.Expression, err)
+		errorCode = NewError("UnsupportedSyntax", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// get object meta
+	start := time.Now()
+	fileInfo, _, err := vol.ObjectMeta(param.Object())
+	span.AppendTrackLog("meta.r", start, err)
+	if err != nil {
+		log.LogErrorf("selectObjectContentHandler: get file meta fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+	if fileInfo.Mode.IsDir() {
+		errorCode = NoSuchKey
+		return
+	}
+	if err = o.openObjectEncryption(r.Header, fileInfo.Encryption, false); err != nil {
+		log.LogErrorf("selectObjectContentHandler: open object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	readInode, readStorageClass := fileInfo.Inode, fileInfo.StorageClass
+	if fileInfo.Restore.Restored() && !fileInfo.Restore.Expired(time.Now()) {
+		readInode, readStorageClass = fileInfo.Restore.CopyInode, fileInfo.Restore.CopyStorageClass
+	}
+
+	// the records are filtered as soon as the data is read, the reading is stopped if
+	// the select is finished before the end of the object.
+	pr, pw := io.Pipe()
+	go func() {
+		var writer io.Writer = pw
+		if fileInfo.Encryption != nil {
+			writer = fileInfo.Encryption.DecryptWriter(writer, 0)
+		}
+		size := uint64(fileInfo.Size)
+		pw.CloseWithError(vol.readFile(readInode, size, param.Object(), writer, 0, size, readStorageClass))
+	}()
+	defer pr.Close()
+
+	w.Header().Set(ContentType, ValueContentTypeStream)
+	w.WriteHeader(http.StatusOK)
+	ew := newSelectEventWriter(w)
+	start = time.Now()
+	err = selectObject(pr, req, query, ew)
+	span.AppendTrackLog("select", start, err)
+	if err != nil {
+		log.LogErrorf("selectObjectContentHandler: select fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		code, message := "InternalError", err.Error()
+		if se, ok := err.(*selectError); ok {
+			code, message = se.code, se.err.Error()
+		}
+		_ = ew.writeError(code, message)
+		// the response header has been written
+		err = nil
+	}
+	log.LogInfof("Audit: select object content: requestID(%v) remote(%v) volume(%v) path(%v) expression(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), req.Expression)
+}
+
 func parsePartInfo(partNumber uint64, fileSize uint64) (uint64, uint64, uint64, uint64) {
 	var partSize uint64
 	var partCount uint64
@@ -97,6 +97,7 @@ func (o *ObjectNode) traceMiddleware(next http.Handler) http.Handler {
 			if o.externalAudit != nil {
 				o.externalAudit.Logger(w, r)
 			}
+			o.logAccess(w, r)
 		}()
 
 		requestID, err := generateRequestID()
@@ -195,9 +196,14 @@ func (o *ObjectNode) policyCheckMiddleware(next http.Handler) http.Handler {
 // ContentMiddleware returns a middleware handler to process reader for content.
 // If the request contains the "X-amz-Decoded-Content-Length" header, it means that the data
 // in the request body is chunked. Use ChunkedReader to parse the data.
+// The unsigned aws-chunked payload with trailing headers is decoded here too, while the signed
+// one is decoded by the signature verification.
 func (o *ObjectNode) contentMiddleware(next http.Handler) http.Handler {
 	var handlerFunc http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
-		if r.Header.Get(XAmzDecodedContentLength) != "" && r.Header.Get(ContentEncoding) != streamingContentEncoding {
+		if r.Header.Get(XAmzContentSha256) == StreamingUnsignedPayloadTrailer {
+			r.Body = NewUnsignedTrailerChunkedReader(r.Body)
+			log.LogDebugf("contentMiddleware: unsigned trailer chunk reader inited: requestID(%v)", GetRequestID(r))
+		} else if r.Header.Get(XAmzDecodedContentLength) != "" && r.Header.Get(ContentEncoding) != streamingContentEncoding {
 			r.Body = NewClosableChunkedReader(r.Body)
 			log.LogDebugf("contentMiddleware: chunk reader inited: requestID(%v)", GetRequestID(r))
 		}
@@ -118,6 +118,10 @@ const (
 
 	UnsignedPayload   = "UNSIGNED-PAYLOAD"
 	EmptyStringSHA256 = `e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855`
+
+	StreamingSignedPayload          = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	StreamingSignedPayloadTrailer   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	StreamingUnsignedPayloadTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
 )
 
 type Auther interface {
@@ -200,6 +204,13 @@ func (o *ObjectNode) validateAuthInfo(r *http.Request, auth Auther) (err error)
 				GetRequestID(r), reqAK, token, err)
 			return err
 		}
+		if stsInfo.RoleName != "" {
+			if err = o.roleStore.CheckSession(stsInfo.UserInfo.UserID, stsInfo.RoleName, stsInfo.RoleVersion); err != nil {
+				log.LogErrorf("validateAuthInfo: check role of session fail: requestID(%v) ak(%v) owner(%v) role(%v) version(%v) err(%v)",
+					GetRequestID(r), reqAK, stsInfo.UserInfo.UserID, stsInfo.RoleName, stsInfo.RoleVersion, err)
+				return err
+			}
+		}
 		uid, ak, sk = stsInfo.UserInfo.UserID, stsInfo.UserInfo.AccessKey, stsInfo.FedSK
 	}
 
@@ -240,7 +251,7 @@ func (o *ObjectNode) validateAuthInfo(r *http.Request, auth Auther) (err error)
 			return AccessDenied
 		}
 		action := "s3:" + param.apiName
-		if !stsInfo.Policy.IsAllow(action, param.bucket, param.object) {
+		if !stsInfo.IsAllow(action, param.bucket, param.object) {
 			log.LogErrorf("validateAuthInfo: sts policy not allow: requestID(%v) policy(%v) api(%v) resource(%v)",
 				GetRequestID(r), *stsInfo.Policy, param.apiName, param.resource)
 			return AccessDenied
@@ -261,7 +272,7 @@ func (o *ObjectNode) validateAuthInfo(r *http.Request, auth Auther) (err error)
 			return AccessDenied
 		}
 		action := "s3:" + param.apiName
-		if !stsInfo.Policy.IsAllow(action, param.bucket, param.object) {
+		if !stsInfo.IsAllow(action, param.bucket, param.object) {
 			log.LogErrorf("validateAuthInfo: sts policy not allow: requestID(%v) policy(%v) api(%v) resource(%v)",
 				GetRequestID(r), *stsInfo.Policy, param.apiName, param.resource)
 			return AccessDenied
@@ -181,11 +181,21 @@ func (auth *HeaderAuth) SignatureMatch(secretKey string, wildcards Wildcards) bo
 		return auth.signature == auth.buildSignatureV2(secretKey, wildcards)
 	case signatureV4:
 		var signature string
-		if auth.request.Header.Get(XAmzDecodedContentLength) != "" &&
-			auth.request.Header.Get(ContentEncoding) == streamingContentEncoding {
-			signature = auth.buildSignatureChunk(secretKey)
-		} else {
+		switch auth.request.Header.Get(XAmzContentSha256) {
+		case StreamingSignedPayload:
+			signature = auth.buildSignatureChunk(secretKey, false)
+		case StreamingSignedPayloadTrailer:
+			signature = auth.buildSignatureChunk(secretKey, true)
+		case StreamingUnsignedPayloadTrailer:
+			// the unsigned chunks are decoded in the content middleware
 			signature = auth.buildSignatureV4(secretKey)
+		default:
+			if auth.request.Header.Get(XAmzDecodedContentLength) != "" &&
+				auth.request.Header.Get(ContentEncoding) == streamingContentEncoding {
+				signature = auth.buildSignatureChunk(secretKey, false)
+			} else {
+				signature = auth.buildSignatureV4(secretKey)
+			}
 		}
 		return auth.signature == signature
 	default:
@@ -221,7 +231,8 @@ func (auth *HeaderAuth) buildSignatureV4(secretKey string) string {
 }
 
 // https://docs.aws.amazon.com/zh_cn/AmazonS3/latest/API/sigv4-streaming.html
-func (auth *HeaderAuth) buildSignatureChunk(secretKey string) string {
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming-trailers.html
+func (auth *HeaderAuth) buildSignatureChunk(secretKey string, trailing bool) string {
 	req := auth.request
 
 	auth.canonicalRequest = buildCanonicalRequest(req, auth.signedHeaders, false)
@@ -233,7 +244,11 @@ func (auth *HeaderAuth) buildSignatureChunk(secretKey string) string {
 
 	signature := calculateSignature(signingKey, auth.stringToSign)
 
-	auth.request.Body = NewSignChunkedReader(auth.request.Body, signingKey, scope, cred.Date, signature)
+	if trailing {
+		auth.request.Body = NewSignTrailerChunkedReader(auth.request.Body, signingKey, scope, cred.TimeStamp, signature)
+	} else {
+		auth.request.Body = NewSignChunkedReader(auth.request.Body, signingKey, scope, cred.TimeStamp, signature)
+	}
 
 	return signature
 }
@@ -20,19 +20,22 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
+	"net/http"
 	"strings"
 )
 
+// maxChunkTrailers is the max number of the trailing headers after the last chunk.
+const maxChunkTrailers = 16
+
 // https://docs.aws.amazon.com/zh_cn/AmazonS3/latest/API/sigv4-streaming.html#sigv4-chunked-body-definition
 
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming-trailers.html
+
 func NewSignChunkedReader(r io.Reader, key []byte, scope, datetime, seed string) *SignChunkedReader {
-	br, ok := r.(*bufio.Reader)
-	if !ok {
-		br = bufio.NewReader(r)
-	}
 	return &SignChunkedReader{
-		reader:   br,
+		reader:   newBufioReader(r),
 		buf:      bytes.NewBuffer(nil),
+		signed:   true,
 		key:      key,
 		scope:    scope,
 		datetime: datetime,
@@ -40,9 +43,40 @@ func NewSignChunkedReader(r io.Reader, key []byte, scope, datetime, seed string)
 	}
 }
 
+// NewSignTrailerChunkedReader returns a reader for the signed chunks followed by the signed trailing headers,
+// i.e. the payload of STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER.
+func NewSignTrailerChunkedReader(r io.Reader, key []byte, scope, datetime, seed string) *SignChunkedReader {
+	cr := NewSignChunkedReader(r, key, scope, datetime, seed)
+	cr.trailing = true
+	return cr
+}
+
+// NewUnsignedTrailerChunkedReader returns a reader for the unsigned chunks followed by the trailing headers,
+// i.e. the payload of STREAMING-UNSIGNED-PAYLOAD-TRAILER.
+func NewUnsignedTrailerChunkedReader(r io.Reader) *SignChunkedReader {
+	return &SignChunkedReader{
+		reader:   newBufioReader(r),
+		buf:      bytes.NewBuffer(nil),
+		trailing: true,
+	}
+}
+
+func newBufioReader(r io.Reader) *bufio.Reader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return br
+}
+
 type SignChunkedReader struct {
 	reader *bufio.Reader
 	buf    *bytes.Buffer
+	eof    bool
+
+	signed   bool        // whether the chunks are signed
+	trailing bool        // whether the trailing headers follow the last chunk
+	trailer  http.Header // trailing headers, available after io.EOF is returned
 
 	key      []byte // signingKey
 	scope    string // <yyyymmdd>/<region>/<service>/aws4_request
@@ -50,6 +84,11 @@ type SignChunkedReader struct {
 	prevSig  string // previous signature
 }
 
+// Trailer returns the trailing headers, which are only available after the whole body is read.
+func (cr *SignChunkedReader) Trailer() http.Header {
+	return cr.trailer
+}
+
 func (cr *SignChunkedReader) Read(p []byte) (n int, err error) {
 	for err == nil {
 		if len(p) == 0 {
@@ -75,6 +114,9 @@ func (cr *SignChunkedReader) fillCheck() error {
 	if cr.buf.Len() > 0 {
 		return nil
 	}
+	if cr.eof {
+		return io.EOF
+	}
 
 	cr.buf.Reset()
 	header, truncated, err := cr.reader.ReadLine()
@@ -82,20 +124,36 @@ func (cr *SignChunkedReader) fillCheck() error {
 		return errors.New("header line of chunk is too long")
 	}
 	if err != nil {
-		if err == io.EOF && len(header) > 0 {
+		// the body must be terminated by the chunk of size 0
+		if err == io.EOF {
 			err = io.ErrUnexpectedEOF
 		}
 		return err
 	}
 
-	signature, size, err := parseSignChunkedHeader(string(header))
+	var (
+		signature string
+		size      int64
+	)
+	if cr.signed {
+		signature, size, err = parseSignChunkedHeader(string(header))
+	} else {
+		size, err = parseUnsignedChunkedHeader(string(header))
+	}
 	if err != nil {
 		return err
 	}
 	if size == 0 {
-		if signature != cr.getSignature(cr.buf.Bytes()) {
+		if cr.signed && signature != cr.getSignature(cr.buf.Bytes()) {
 			return errors.New("signature of chunk does not match")
 		}
+		cr.prevSig = signature
+		if cr.trailing {
+			if err = cr.readTrailer(); err != nil {
+				return err
+			}
+		}
+		cr.eof = true
 		return io.EOF
 	}
 
@@ -109,7 +167,7 @@ func (cr *SignChunkedReader) fillCheck() error {
 	if cn != size {
 		return io.ErrShortBuffer
 	}
-	if signature != cr.getSignature(cr.buf.Bytes()) {
+	if cr.signed && signature != cr.getSignature(cr.buf.Bytes()) {
 		return errors.New("signature of chunk does not match")
 	}
 	last := make([]byte, 2)
@@ -124,6 +182,65 @@ func (cr *SignChunkedReader) fillCheck() error {
 	return nil
 }
 
+// readTrailer reads the trailing headers until an empty line, and verifies the trailer signature if signed.
+func (cr *SignChunkedReader) readTrailer() error {
+	var (
+		trailer   = make(http.Header)
+		canonical bytes.Buffer
+		signature string
+	)
+	for {
+		line, truncated, err := cr.reader.ReadLine()
+		if truncated {
+			return errors.New("trailing header line is too long")
+		}
+		if err == io.EOF && len(line) == 0 && len(trailer) > 0 {
+			// tolerate the missing empty line at the end of the body
+			break
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if len(line) == 0 {
+			break
+		}
+		name, value, found := strings.Cut(string(line), ":")
+		if !found {
+			return errors.New("malformed trailing header")
+		}
+		name, value = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value)
+		if name == "x-amz-trailer-signature" {
+			signature = value
+			continue
+		}
+		if len(trailer) >= maxChunkTrailers {
+			return errors.New("too many trailing headers")
+		}
+		trailer.Set(name, value)
+		canonical.WriteString(name + ":" + value + "\n")
+	}
+	if cr.signed && signature != cr.getTrailerSignature(canonical.Bytes()) {
+		return errors.New("signature of trailer does not match")
+	}
+	cr.trailer = trailer
+	return nil
+}
+
+func (cr *SignChunkedReader) getTrailerSignature(trailer []byte) string {
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256-TRAILER",
+		cr.datetime,
+		cr.scope,
+		cr.prevSig,
+		hex.EncodeToString(MakeSha256(trailer)),
+	}, "\n")
+
+	return hex.EncodeToString(MakeHmacSha256(cr.key, []byte(stringToSign)))
+}
+
 func (cr *SignChunkedReader) getSignature(data []byte) string {
 	stringToSign := strings.Join([]string{
 		"AWS4-HMAC-SHA256-PAYLOAD",
@@ -159,6 +276,24 @@ func parseSignChunkedHeader(header string) (sign string, size int64, err error)
 	return
 }
 
+func parseUnsignedChunkedHeader(header string) (size int64, err error) {
+	// ignore the chunk extensions if any
+	if i := strings.IndexByte(header, ';'); i >= 0 {
+		header = header[:i]
+	}
+	header = strings.TrimSpace(header)
+	if header == "" {
+		err = errors.New("malformed chunked encoding")
+		return
+	}
+	csize, err := parseHexUint([]byte(header))
+	if err != nil {
+		return
+	}
+	size = int64(csize)
+	return
+}
+
 // parseHexUint copy from net/http/internal/chunked.go
 func parseHexUint(v []byte) (n uint64, err error) {
 	for i, b := range v {
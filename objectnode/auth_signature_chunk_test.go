@@ -17,6 +17,7 @@ package objectnode
 import (
 	"bytes"
 	"io"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -55,3 +56,64 @@ func TestSignChunkedReader(t *testing.T) {
 	require.Equal(t, 66560, len(b))
 	require.Equal(t, strings.Repeat("a", 66560), string(b))
 }
+
+func TestUnsignedTrailerChunkedReader(t *testing.T) {
+	body := "6\r\nhello \r\n5;ext=1\r\nworld\r\n0\r\nx-amz-checksum-crc32c:yZRlqg==\r\n\r\n"
+	reader := NewUnsignedTrailerChunkedReader(strings.NewReader(body))
+	require.Nil(t, reader.Trailer())
+	b, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(b))
+	require.Equal(t, "yZRlqg==", reader.Trailer().Get("x-amz-checksum-crc32c"))
+
+	// the empty line at the end is omitted
+	reader = NewUnsignedTrailerChunkedReader(strings.NewReader("0\r\nx-amz-checksum-crc32:AAAAAA==\r\n"))
+	b, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Empty(t, b)
+	require.Equal(t, "AAAAAA==", reader.Trailer().Get("x-amz-checksum-crc32"))
+
+	for _, body := range []string{
+		"6\r\nhello \r\n",
+		"6\r\nhello \r\n0\r\n",
+		"6\r\nhello \r\n0\r\nx-amz-checksum-crc32c\r\n\r\n",
+		"zz\r\nhello \r\n0\r\n\r\n",
+	} {
+		_, err = io.ReadAll(NewUnsignedTrailerChunkedReader(strings.NewReader(body)))
+		require.Error(t, err, body)
+	}
+}
+
+func TestSignTrailerChunkedReader(t *testing.T) {
+	sk := "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+	key := buildSigningKey("AWS4", sk, "20130524", "us-east-1", "s3", "aws4_request")
+	scope := "20130524/us-east-1/s3/aws4_request"
+	datetime := "20130524T000000Z"
+	seed := "106e2a8a18243abcf37539882f36619c00e2dfc72633413f02d3b74544bfeb8e"
+
+	// sign the body as the client does
+	signer := NewSignChunkedReader(nil, key, scope, datetime, seed)
+	buf := bytes.NewBuffer(nil)
+	for _, chunk := range []string{strings.Repeat("a", 65536), strings.Repeat("a", 1024), ""} {
+		signer.prevSig = signer.getSignature([]byte(chunk))
+		buf.WriteString(strconv.FormatInt(int64(len(chunk)), 16) + ";chunk-signature=" + signer.prevSig + "\r\n")
+		if chunk != "" {
+			buf.WriteString(chunk + "\r\n")
+		}
+	}
+	trailer := "x-amz-checksum-crc32c:sOO8/Q==\n"
+	buf.WriteString("x-amz-checksum-crc32c:sOO8/Q==\r\n")
+	buf.WriteString("x-amz-trailer-signature:" + signer.getTrailerSignature([]byte(trailer)) + "\r\n\r\n")
+	data := buf.Bytes()
+
+	reader := NewSignTrailerChunkedReader(bytes.NewReader(data), key, scope, datetime, seed)
+	b, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("a", 66560), string(b))
+	require.Equal(t, "sOO8/Q==", reader.Trailer().Get("x-amz-checksum-crc32c"))
+
+	// the trailing header is modified
+	tampered := bytes.Replace(data, []byte("sOO8/Q=="), []byte("AAAAAA=="), 1)
+	_, err = io.ReadAll(NewSignTrailerChunkedReader(bytes.NewReader(tampered), key, scope, datetime, seed))
+	require.Error(t, err)
+}
@@ -0,0 +1,403 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/batch-ops.html
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+const (
+	MaxBatchJobRequestSize = 1 << 20 // 1MB
+	MaxBatchJobListResults = 1000
+
+	BatchJobXMLNS = "http://awss3control.amazonaws.com/doc/2018-08-20/"
+
+	BatchManifestFormatCSV = "S3BatchOperations_CSV_20180820"
+	BatchReportFormatCSV   = "Report_CSV_20180820"
+)
+
+var (
+	NoSuchJob              = &ErrorCode{ErrorCode: "NoSuchJob", ErrorMessage: "The specified job does not exist.", StatusCode: http.StatusNotFound}
+	InvalidJobStatus       = &ErrorCode{ErrorCode: "JobStatusException", ErrorMessage: "The job can't be updated to the requested status.", StatusCode: http.StatusConflict}
+	InvalidJobOperation    = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The job operation is invalid.", StatusCode: http.StatusBadRequest}
+	InvalidJobManifest     = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The job manifest is invalid.", StatusCode: http.StatusBadRequest}
+	InvalidJobReport       = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The job report is invalid.", StatusCode: http.StatusBadRequest}
+	MissingJobAccountId    = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Missing required header for this request: x-amz-account-id.", StatusCode: http.StatusBadRequest}
+	InvalidJobListArgument = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Invalid job statuses or max results.", StatusCode: http.StatusBadRequest}
+)
+
+type BatchJobS3Tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+type BatchJobPutObjectTagging struct {
+	TagSet []BatchJobS3Tag `xml:"TagSet>S3Tag"`
+}
+
+type BatchJobPutObjectCopy struct {
+	TargetResource  string `xml:"TargetResource,omitempty"`
+	TargetKeyPrefix string `xml:"TargetKeyPrefix,omitempty"`
+	StorageClass    string `xml:"StorageClass,omitempty"`
+}
+
+type BatchJobAccessControlPolicy struct {
+	CannedAccessControlList string `xml:"CannedAccessControlList"`
+}
+
+type BatchJobPutObjectAcl struct {
+	AccessControlPolicy BatchJobAccessControlPolicy `xml:"AccessControlPolicy"`
+}
+
+type BatchJobDeleteObject struct{}
+
+// BatchJobOperation holds exactly one of the operations.
+type BatchJobOperation struct {
+	S3PutObjectTagging *BatchJobPutObjectTagging `xml:"S3PutObjectTagging,omitempty"`
+	S3PutObjectCopy    *BatchJobPutObjectCopy    `xml:"S3PutObjectCopy,omitempty"`
+	S3DeleteObject     *BatchJobDeleteObject     `xml:"S3DeleteObject,omitempty"`
+	S3PutObjectAcl     *BatchJobPutObjectAcl     `xml:"S3PutObjectAcl,omitempty"`
+}
+
+type BatchJobManifestSpec struct {
+	Format string   `xml:"Format"`
+	Fields []string `xml:"Fields>member"`
+}
+
+type BatchJobManifestLocation struct {
+	ObjectArn string `xml:"ObjectArn"`
+	ETag      string `xml:"ETag,omitempty"`
+}
+
+type BatchJobManifest struct {
+	Spec     BatchJobManifestSpec     `xml:"Spec"`
+	Location BatchJobManifestLocation `xml:"Location"`
+}
+
+type BatchJobManifestFilter struct {
+	MatchAnyPrefix []string `xml:"KeyNameConstraint>MatchAnyPrefix>member"`
+}
+
+type BatchJobManifestGenerator struct {
+	SourceBucket string                  `xml:"SourceBucket"`
+	Filter       *BatchJobManifestFilter `xml:"Filter,omitempty"`
+}
+
+type BatchJobManifestGeneratorWrapper struct {
+	S3JobManifestGenerator *BatchJobManifestGenerator `xml:"S3JobManifestGenerator,omitempty"`
+}
+
+type BatchJobReport struct {
+	Bucket      string `xml:"Bucket,omitempty"`
+	Enabled     bool   `xml:"Enabled"`
+	Format      string `xml:"Format,omitempty"`
+	Prefix      string `xml:"Prefix,omitempty"`
+	ReportScope string `xml:"ReportScope,omitempty"`
+}
+
+type CreateJobRequest struct {
+	XMLNS                string                            `xml:"xmlns,attr,omitempty"`
+	XMLName              xml.Name                          `xml:"CreateJobRequest"`
+	ConfirmationRequired bool                              `xml:"ConfirmationRequired"`
+	Operation            BatchJobOperation                 `xml:"Operation"`
+	Report               BatchJobReport                    `xml:"Report"`
+	ClientRequestToken   string                            `xml:"ClientRequestToken"`
+	Manifest             *BatchJobManifest                 `xml:"Manifest,omitempty"`
+	ManifestGenerator    *BatchJobManifestGeneratorWrapper `xml:"ManifestGenerator,omitempty"`
+	Description          string                            `xml:"Description,omitempty"`
+	Priority             int                               `xml:"Priority"`
+	RoleArn              string                            `xml:"RoleArn,omitempty"`
+}
+
+type CreateJobResult struct {
+	XMLName xml.Name `xml:"CreateJobResult"`
+	JobId   string   `xml:"JobId"`
+}
+
+type BatchJobProgressSummary struct {
+	TotalNumberOfTasks     int64 `xml:"TotalNumberOfTasks"`
+	NumberOfTasksSucceeded int64 `xml:"NumberOfTasksSucceeded"`
+	NumberOfTasksFailed    int64 `xml:"NumberOfTasksFailed"`
+}
+
+type BatchJobFailure struct {
+	FailureCode   string `xml:"FailureCode"`
+	FailureReason string `xml:"FailureReason"`
+}
+
+type BatchJobDescriptor struct {
+	JobId                string                            `xml:"JobId"`
+	ConfirmationRequired bool                              `xml:"ConfirmationRequired"`
+	Description          string                            `xml:"Description,omitempty"`
+	Status               string                            `xml:"Status"`
+	Manifest             *BatchJobManifest                 `xml:"Manifest,omitempty"`
+	ManifestGenerator    *BatchJobManifestGeneratorWrapper `xml:"ManifestGenerator,omitempty"`
+	Operation            BatchJobOperation                 `xml:"Operation"`
+	Priority             int                               `xml:"Priority"`
+	ProgressSummary      BatchJobProgressSummary           `xml:"ProgressSummary"`
+	StatusUpdateReason   string                            `xml:"StatusUpdateReason,omitempty"`
+	FailureReasons       []BatchJobFailure                 `xml:"FailureReasons>member,omitempty"`
+	Report               BatchJobReport                    `xml:"Report"`
+	CreationTime         string                            `xml:"CreationTime"`
+	TerminationDate      string                            `xml:"TerminationDate,omitempty"`
+}
+
+type DescribeJobResult struct {
+	XMLName xml.Name           `xml:"DescribeJobResult"`
+	Job     BatchJobDescriptor `xml:"Job"`
+}
+
+type BatchJobListDescriptor struct {
+	JobId           string                  `xml:"JobId"`
+	Description     string                  `xml:"Description,omitempty"`
+	Operation       string                  `xml:"Operation"`
+	Priority        int                     `xml:"Priority"`
+	Status          string                  `xml:"Status"`
+	CreationTime    string                  `xml:"CreationTime"`
+	TerminationDate string                  `xml:"TerminationDate,omitempty"`
+	ProgressSummary BatchJobProgressSummary `xml:"ProgressSummary"`
+}
+
+type ListJobsResult struct {
+	XMLName   xml.Name                 `xml:"ListJobsResult"`
+	NextToken string                   `xml:"NextToken,omitempty"`
+	Jobs      []BatchJobListDescriptor `xml:"Jobs>member"`
+}
+
+type UpdateJobStatusResult struct {
+	XMLName            xml.Name `xml:"UpdateJobStatusResult"`
+	JobId              string   `xml:"JobId"`
+	Status             string   `xml:"Status"`
+	StatusUpdateReason string   `xml:"StatusUpdateReason,omitempty"`
+}
+
+// parseBatchJobArn returns the bucket and the key of either "arn:aws:s3:::<bucket>[/<key>]"
+// or the plain "<bucket>[/<key>]".
+func parseBatchJobArn(arn string) (bucket, key string) {
+	arn = strings.TrimPrefix(arn, replicationAWSBucketARNPrefix)
+	if index := strings.Index(arn, "/"); index >= 0 {
+		return arn[:index], arn[index+1:]
+	}
+	return arn, ""
+}
+
+func ParseCreateJobRequest(data []byte) (*CreateJobRequest, *ErrorCode) {
+	req := &CreateJobRequest{}
+	if err := xml.Unmarshal(data, req); err != nil {
+		return nil, MalformedXML
+	}
+	if (req.Manifest == nil) == (req.ManifestGenerator == nil || req.ManifestGenerator.S3JobManifestGenerator == nil) {
+		return nil, InvalidJobManifest
+	}
+	if req.ClientRequestToken == "" {
+		return nil, InvalidArgument
+	}
+	return req, nil
+}
+
+// ToBatchJob converts the request to the job of the owner, which is validated by the master.
+func (req *CreateJobRequest) ToBatchJob() (*proto.BatchJob, *ErrorCode) {
+	job := &proto.BatchJob{
+		ClientRequestToken: req.ClientRequestToken,
+		Description:        req.Description,
+		Priority:           req.Priority,
+		Operation:          &proto.BatchOperation{},
+		Manifest:           &proto.BatchManifest{},
+		Report:             &proto.BatchReport{Enabled: req.Report.Enabled},
+		Status:             proto.BatchJobReady,
+	}
+	if req.ConfirmationRequired {
+		job.Status = proto.BatchJobSuspended
+	}
+
+	op, ops := job.Operation, 0
+	if tagging := req.Operation.S3PutObjectTagging; tagging != nil {
+		op.Type, ops = proto.BatchOpPutObjectTagging, ops+1
+		for _, tag := range tagging.TagSet {
+			op.Tags = append(op.Tags, proto.BatchTag{Key: tag.Key, Value: tag.Value})
+		}
+	}
+	if cp := req.Operation.S3PutObjectCopy; cp != nil {
+		op.Type, ops = proto.BatchOpPutObjectCopy, ops+1
+		op.TargetBucket, _ = parseBatchJobArn(cp.TargetResource)
+		op.TargetPrefix = cp.TargetKeyPrefix
+		op.StorageClass = cp.StorageClass
+	}
+	if req.Operation.S3DeleteObject != nil {
+		op.Type, ops = proto.BatchOpDeleteObject, ops+1
+	}
+	if acl := req.Operation.S3PutObjectAcl; acl != nil {
+		op.Type, ops = proto.BatchOpPutObjectAcl, ops+1
+		op.CannedACL = acl.AccessControlPolicy.CannedAccessControlList
+		if _, err := ParseCannedAcl(op.CannedACL, ""); err != nil {
+			return nil, ErrInvalidCannedACL
+		}
+	}
+	if ops != 1 || op.Validate() != nil {
+		return nil, InvalidJobOperation
+	}
+
+	if m := req.Manifest; m != nil {
+		if m.Spec.Format != BatchManifestFormatCSV {
+			return nil, InvalidJobManifest
+		}
+		job.Manifest.Bucket, job.Manifest.Key = parseBatchJobArn(m.Location.ObjectArn)
+		job.Manifest.ETag = strings.Trim(m.Location.ETag, "\"")
+		job.Manifest.Fields = m.Spec.Fields
+	} else {
+		g := req.ManifestGenerator.S3JobManifestGenerator
+		job.Manifest.Bucket, _ = parseBatchJobArn(g.SourceBucket)
+		if g.Filter != nil {
+			job.Manifest.Prefixes = g.Filter.MatchAnyPrefix
+		}
+		if len(job.Manifest.Prefixes) == 0 {
+			// scan the whole bucket
+			job.Manifest.Prefixes = []string{""}
+		}
+	}
+	if job.Manifest.Validate() != nil {
+		return nil, InvalidJobManifest
+	}
+
+	if job.Report.Enabled {
+		if req.Report.Format != "" && req.Report.Format != BatchReportFormatCSV {
+			return nil, InvalidJobReport
+		}
+		job.Report.Bucket, _ = parseBatchJobArn(req.Report.Bucket)
+		job.Report.Prefix = req.Report.Prefix
+		job.Report.Scope = req.Report.ReportScope
+	}
+	if job.Report.Validate() != nil {
+		return nil, InvalidJobReport
+	}
+	return job, nil
+}
+
+// batchJobBuckets returns the buckets referenced by the job, which must exist.
+func batchJobBuckets(job *proto.BatchJob) []string {
+	buckets := []string{job.Manifest.Bucket}
+	if job.Operation.TargetBucket != "" {
+		buckets = append(buckets, job.Operation.TargetBucket)
+	}
+	if job.Report.Enabled {
+		buckets = append(buckets, job.Report.Bucket)
+	}
+	return buckets
+}
+
+func formatBatchJobTime(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+func newBatchJobOperation(op *proto.BatchOperation) (o BatchJobOperation) {
+	switch op.Type {
+	case proto.BatchOpPutObjectTagging:
+		o.S3PutObjectTagging = &BatchJobPutObjectTagging{}
+		for _, tag := range op.Tags {
+			o.S3PutObjectTagging.TagSet = append(o.S3PutObjectTagging.TagSet, BatchJobS3Tag{Key: tag.Key, Value: tag.Value})
+		}
+	case proto.BatchOpPutObjectCopy:
+		o.S3PutObjectCopy = &BatchJobPutObjectCopy{
+			TargetKeyPrefix: op.TargetPrefix,
+			StorageClass:    op.StorageClass,
+		}
+		if op.TargetBucket != "" {
+			o.S3PutObjectCopy.TargetResource = replicationAWSBucketARNPrefix + op.TargetBucket
+		}
+	case proto.BatchOpDeleteObject:
+		o.S3DeleteObject = &BatchJobDeleteObject{}
+	case proto.BatchOpPutObjectAcl:
+		o.S3PutObjectAcl = &BatchJobPutObjectAcl{
+			AccessControlPolicy: BatchJobAccessControlPolicy{CannedAccessControlList: op.CannedACL},
+		}
+	}
+	return
+}
+
+func newBatchJobProgressSummary(job *proto.BatchJob) BatchJobProgressSummary {
+	p := job.Progress()
+	return BatchJobProgressSummary{
+		TotalNumberOfTasks:     p.Total,
+		NumberOfTasksSucceeded: p.Succeeded,
+		NumberOfTasksFailed:    p.Failed,
+	}
+}
+
+func NewBatchJobDescriptor(job *proto.BatchJob) BatchJobDescriptor {
+	d := BatchJobDescriptor{
+		JobId:              job.JobId,
+		Description:        job.Description,
+		Status:             job.Status,
+		Operation:          newBatchJobOperation(job.Operation),
+		Priority:           job.Priority,
+		ProgressSummary:    newBatchJobProgressSummary(job),
+		StatusUpdateReason: job.StatusUpdateReason,
+		Report: BatchJobReport{
+			Enabled:     job.Report.Enabled,
+			ReportScope: job.Report.Scope,
+			Prefix:      job.Report.Prefix,
+		},
+		CreationTime:    formatBatchJobTime(job.CreationTime),
+		TerminationDate: formatBatchJobTime(job.TerminationTime),
+	}
+	if job.Report.Enabled {
+		d.Report.Bucket = replicationAWSBucketARNPrefix + job.Report.Bucket
+		d.Report.Format = BatchReportFormatCSV
+	}
+	if job.Manifest.IsPrefix() {
+		g := &BatchJobManifestGenerator{SourceBucket: replicationAWSBucketARNPrefix + job.Manifest.Bucket}
+		if len(job.Manifest.Prefixes) != 1 || job.Manifest.Prefixes[0] != "" {
+			g.Filter = &BatchJobManifestFilter{MatchAnyPrefix: job.Manifest.Prefixes}
+		}
+		d.ManifestGenerator = &BatchJobManifestGeneratorWrapper{S3JobManifestGenerator: g}
+	} else {
+		d.Manifest = &BatchJobManifest{
+			Spec: BatchJobManifestSpec{Format: BatchManifestFormatCSV, Fields: job.Manifest.Fields},
+			Location: BatchJobManifestLocation{
+				ObjectArn: replicationAWSBucketARNPrefix + job.Manifest.Bucket + "/" + job.Manifest.Key,
+				ETag:      job.Manifest.ETag,
+			},
+		}
+	}
+	for _, task := range job.Tasks {
+		if task.Result != "" {
+			d.FailureReasons = append(d.FailureReasons, BatchJobFailure{FailureCode: "TaskFailed", FailureReason: task.Result})
+		}
+	}
+	return d
+}
+
+func NewBatchJobListDescriptor(job *proto.BatchJob) BatchJobListDescriptor {
+	return BatchJobListDescriptor{
+		JobId:           job.JobId,
+		Description:     job.Description,
+		Operation:       job.Operation.Type,
+		Priority:        job.Priority,
+		Status:          job.Status,
+		CreationTime:    formatBatchJobTime(job.CreationTime),
+		TerminationDate: formatBatchJobTime(job.TerminationTime),
+		ProgressSummary: newBatchJobProgressSummary(job),
+	}
+}
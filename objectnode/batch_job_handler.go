@@ -0,0 +1,251 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// checkBatchJobRequest returns the user of the batch job request, which is neither anonymous
+// nor made with the STS credentials.
+func (o *ObjectNode) checkBatchJobRequest(r *http.Request, fn string) (param *RequestParam, user *proto.UserInfo, err error) {
+	if r.Header.Get(XAmzSecurityToken) != "" {
+		return nil, nil, AccessDeniedBySTS
+	}
+	if r.Header.Get(XAmzAccountId) == "" {
+		return nil, nil, MissingJobAccountId
+	}
+	param = ParseRequestParam(r)
+	if isAnonymous(param.AccessKey()) {
+		return nil, nil, AccessDenied
+	}
+	if user, err = o.getUserInfoByAccessKeyV2(param.AccessKey()); err != nil {
+		log.LogErrorf("%v: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			fn, GetRequestID(r), param.AccessKey(), err)
+		return nil, nil, err
+	}
+	return
+}
+
+// batchJobErrorCode maps the errors of the master to the error codes.
+func batchJobErrorCode(err error) *ErrorCode {
+	switch err.Error() {
+	case proto.ErrBatchJobNotExists.Error():
+		return NoSuchJob
+	case proto.ErrInvalidBatchJobStatus.Error():
+		return InvalidJobStatus
+	}
+	return nil
+}
+
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_CreateJob.html
+func (o *ObjectNode) createJobHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err error
+		erc *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, erc)
+	}()
+
+	param, user, err := o.checkBatchJobRequest(r, "createJobHandler")
+	if err != nil {
+		return
+	}
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxBatchJobRequestSize+1)); err != nil {
+		log.LogErrorf("createJobHandler: read request body fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+	if len(body) > MaxBatchJobRequestSize {
+		erc = EntityTooLarge
+		return
+	}
+	var req *CreateJobRequest
+	if req, erc = ParseCreateJobRequest(body); erc != nil {
+		log.LogErrorf("createJobHandler: parse request fail: requestID(%v) body(%v) err(%v)",
+			GetRequestID(r), string(body), erc)
+		return
+	}
+	var job *proto.BatchJob
+	if job, erc = req.ToBatchJob(); erc != nil {
+		log.LogErrorf("createJobHandler: invalid job: requestID(%v) body(%v) err(%v)",
+			GetRequestID(r), string(body), erc)
+		return
+	}
+	for _, bucket := range batchJobBuckets(job) {
+		if _, err = o.getVol(bucket); err != nil {
+			log.LogErrorf("createJobHandler: load bucket fail: requestID(%v) bucket(%v) err(%v)",
+				GetRequestID(r), bucket, err)
+			return
+		}
+	}
+	job.Owner = user.UserID
+	job.AccessKey = param.AccessKey()
+
+	if job, err = o.mc.AdminAPI().CreateBatchJob(job); err != nil {
+		log.LogErrorf("createJobHandler: create job fail: requestID(%v) owner(%v) err(%v)",
+			GetRequestID(r), user.UserID, err)
+		erc = batchJobErrorCode(err)
+		return
+	}
+	log.LogInfof("Audit: create batch job: requestID(%v) remote(%v) owner(%v) job(%v) operation(%v) status(%v)",
+		GetRequestID(r), getRequestIP(r), user.UserID, job.JobId, job.Operation.Type, job.Status)
+
+	var data []byte
+	if data, err = MarshalXMLEntity(&CreateJobResult{JobId: job.JobId}); err != nil {
+		log.LogErrorf("createJobHandler: xml marshal result fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+	writeSuccessResponseXML(w, data)
+}
+
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_DescribeJob.html
+func (o *ObjectNode) describeJobHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err error
+		erc *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, erc)
+	}()
+
+	param, user, err := o.checkBatchJobRequest(r, "describeJobHandler")
+	if err != nil {
+		return
+	}
+	jobId := param.GetVar(ContextKeyJobId)
+	job, err := o.mc.AdminAPI().GetBatchJob(jobId, user.UserID)
+	if err != nil {
+		log.LogErrorf("describeJobHandler: get job fail: requestID(%v) owner(%v) job(%v) err(%v)",
+			GetRequestID(r), user.UserID, jobId, err)
+		erc = batchJobErrorCode(err)
+		return
+	}
+
+	var data []byte
+	if data, err = MarshalXMLEntity(&DescribeJobResult{Job: NewBatchJobDescriptor(job)}); err != nil {
+		log.LogErrorf("describeJobHandler: xml marshal result fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+	writeSuccessResponseXML(w, data)
+}
+
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_ListJobs.html
+func (o *ObjectNode) listJobsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err error
+		erc *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, erc)
+	}()
+
+	_, user, err := o.checkBatchJobRequest(r, "listJobsHandler")
+	if err != nil {
+		return
+	}
+	query := r.URL.Query()
+	statuses := query["jobStatuses"]
+	maxResults := MaxBatchJobListResults
+	if value := query.Get("maxResults"); value != "" {
+		if maxResults, err = strconv.Atoi(value); err != nil || maxResults <= 0 || maxResults > MaxBatchJobListResults {
+			erc = InvalidJobListArgument
+			return
+		}
+	}
+	// the jobs are listed from the latest, the token is the last job of the previous page
+	nextToken := query.Get("nextToken")
+
+	jobs, err := o.mc.AdminAPI().ListBatchJobs(user.UserID, statuses)
+	if err != nil {
+		log.LogErrorf("listJobsHandler: list jobs fail: requestID(%v) owner(%v) err(%v)",
+			GetRequestID(r), user.UserID, err)
+		return
+	}
+	result := &ListJobsResult{}
+	for _, job := range jobs {
+		if nextToken != "" {
+			if job.JobId == nextToken {
+				nextToken = ""
+			}
+			continue
+		}
+		if len(result.Jobs) == maxResults {
+			result.NextToken = result.Jobs[len(result.Jobs)-1].JobId
+			break
+		}
+		result.Jobs = append(result.Jobs, NewBatchJobListDescriptor(job))
+	}
+
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("listJobsHandler: xml marshal result fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+	writeSuccessResponseXML(w, data)
+}
+
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_UpdateJobStatus.html
+// Besides the Cancelled and the Ready status, the job can be Suspended, and the Suspended or
+// Failed job is resumed by the Ready status.
+func (o *ObjectNode) updateJobStatusHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err error
+		erc *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, erc)
+	}()
+
+	param, user, err := o.checkBatchJobRequest(r, "updateJobStatusHandler")
+	if err != nil {
+		return
+	}
+	req := &proto.BatchJobStatusRequest{
+		JobId:  param.GetVar(ContextKeyJobId),
+		Owner:  user.UserID,
+		Status: r.URL.Query().Get("requestedJobStatus"),
+		Reason: r.URL.Query().Get("statusUpdateReason"),
+	}
+	switch req.Status {
+	case proto.BatchJobCancelled, proto.BatchJobReady, proto.BatchJobSuspended:
+	default:
+		erc = InvalidArgument
+		return
+	}
+	job, err := o.mc.AdminAPI().UpdateBatchJobStatus(req)
+	if err != nil {
+		log.LogErrorf("updateJobStatusHandler: update job status fail: requestID(%v) owner(%v) job(%v) status(%v) err(%v)",
+			GetRequestID(r), user.UserID, req.JobId, req.Status, err)
+		erc = batchJobErrorCode(err)
+		return
+	}
+	log.LogInfof("Audit: update batch job status: requestID(%v) remote(%v) owner(%v) job(%v) status(%v) reason(%v)",
+		GetRequestID(r), getRequestIP(r), user.UserID, job.JobId, job.Status, req.Reason)
+
+	var data []byte
+	result := &UpdateJobStatusResult{JobId: job.JobId, Status: job.Status, StatusUpdateReason: job.StatusUpdateReason}
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("updateJobStatusHandler: xml marshal result fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+	writeSuccessResponseXML(w, data)
+}
@@ -0,0 +1,161 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCreateJobRequest(t *testing.T) {
+	csv := `<CreateJobRequest xmlns="http://awss3control.amazonaws.com/doc/2018-08-20/">
+	<ConfirmationRequired>true</ConfirmationRequired>
+	<Operation><S3PutObjectTagging><TagSet><S3Tag><Key>k</Key><Value>v</Value></S3Tag></TagSet></S3PutObjectTagging></Operation>
+	<Report><Bucket>arn:aws:s3:::reports</Bucket><Enabled>true</Enabled><Format>Report_CSV_20180820</Format><Prefix>batch</Prefix><ReportScope>FailedTasksOnly</ReportScope></Report>
+	<ClientRequestToken>token</ClientRequestToken>
+	<Manifest>
+		<Spec><Format>S3BatchOperations_CSV_20180820</Format><Fields><member>Bucket</member><member>Key</member></Fields></Spec>
+		<Location><ObjectArn>arn:aws:s3:::manifests/a/list.csv</ObjectArn><ETag>"etag"</ETag></Location>
+	</Manifest>
+	<Priority>10</Priority>
+</CreateJobRequest>`
+	req, erc := ParseCreateJobRequest([]byte(csv))
+	require.Nil(t, erc)
+	job, erc := req.ToBatchJob()
+	require.Nil(t, erc)
+	require.Equal(t, proto.BatchJobSuspended, job.Status)
+	require.Equal(t, 10, job.Priority)
+	require.Equal(t, proto.BatchOpPutObjectTagging, job.Operation.Type)
+	require.Equal(t, []proto.BatchTag{{Key: "k", Value: "v"}}, job.Operation.Tags)
+	require.Equal(t, &proto.BatchManifest{Bucket: "manifests", Key: "a/list.csv", ETag: "etag", Fields: []string{"Bucket", "Key"}}, job.Manifest)
+	require.Equal(t, &proto.BatchReport{Enabled: true, Bucket: "reports", Prefix: "batch", Scope: proto.BatchReportFailedTasksOnly}, job.Report)
+	require.Equal(t, []string{"manifests", "reports"}, batchJobBuckets(job))
+
+	prefix := `<CreateJobRequest>
+	<Operation><S3PutObjectCopy><TargetResource>arn:aws:s3:::dst</TargetResource><StorageClass>STANDARD_IA</StorageClass></S3PutObjectCopy></Operation>
+	<Report><Enabled>false</Enabled></Report>
+	<ClientRequestToken>token</ClientRequestToken>
+	<ManifestGenerator><S3JobManifestGenerator><SourceBucket>arn:aws:s3:::src</SourceBucket>
+		<Filter><KeyNameConstraint><MatchAnyPrefix><member>logs/</member><member>data/</member></MatchAnyPrefix></KeyNameConstraint></Filter>
+	</S3JobManifestGenerator></ManifestGenerator>
+</CreateJobRequest>`
+	req, erc = ParseCreateJobRequest([]byte(prefix))
+	require.Nil(t, erc)
+	job, erc = req.ToBatchJob()
+	require.Nil(t, erc)
+	require.Equal(t, proto.BatchJobReady, job.Status)
+	require.Equal(t, &proto.BatchOperation{Type: proto.BatchOpPutObjectCopy, TargetBucket: "dst", StorageClass: "STANDARD_IA"}, job.Operation)
+	require.Equal(t, &proto.BatchManifest{Bucket: "src", Prefixes: []string{"logs/", "data/"}}, job.Manifest)
+	require.Equal(t, []string{"src", "dst"}, batchJobBuckets(job))
+
+	invalid := []struct {
+		body string
+		erc  *ErrorCode
+	}{
+		{`<CreateJobRequest>`, MalformedXML},
+		// neither manifest nor generator
+		{`<CreateJobRequest><ClientRequestToken>t</ClientRequestToken><Operation><S3DeleteObject/></Operation></CreateJobRequest>`, InvalidJobManifest},
+		// two operations
+		{`<CreateJobRequest><ClientRequestToken>t</ClientRequestToken><Operation><S3DeleteObject/><S3PutObjectAcl><AccessControlPolicy><CannedAccessControlList>private</CannedAccessControlList></AccessControlPolicy></S3PutObjectAcl></Operation>
+			<ManifestGenerator><S3JobManifestGenerator><SourceBucket>src</SourceBucket></S3JobManifestGenerator></ManifestGenerator></CreateJobRequest>`, InvalidJobOperation},
+		{`<CreateJobRequest><ClientRequestToken>t</ClientRequestToken><Operation><S3PutObjectAcl><AccessControlPolicy><CannedAccessControlList>any</CannedAccessControlList></AccessControlPolicy></S3PutObjectAcl></Operation>
+			<ManifestGenerator><S3JobManifestGenerator><SourceBucket>src</SourceBucket></S3JobManifestGenerator></ManifestGenerator></CreateJobRequest>`, ErrInvalidCannedACL},
+		{`<CreateJobRequest><ClientRequestToken>t</ClientRequestToken><Operation><S3DeleteObject/></Operation>
+			<Manifest><Spec><Format>S3InventoryReport_CSV_20161130</Format></Spec><Location><ObjectArn>arn:aws:s3:::b/k</ObjectArn></Location></Manifest></CreateJobRequest>`, InvalidJobManifest},
+		{`<CreateJobRequest><ClientRequestToken>t</ClientRequestToken><Operation><S3DeleteObject/></Operation><Report><Enabled>true</Enabled></Report>
+			<ManifestGenerator><S3JobManifestGenerator><SourceBucket>src</SourceBucket></S3JobManifestGenerator></ManifestGenerator></CreateJobRequest>`, InvalidJobReport},
+	}
+	for _, c := range invalid {
+		req, erc = ParseCreateJobRequest([]byte(c.body))
+		if erc == nil {
+			_, erc = req.ToBatchJob()
+		}
+		require.Equal(t, c.erc, erc, c.body)
+	}
+}
+
+func TestBatchJobDescriptor(t *testing.T) {
+	job := &proto.BatchJob{
+		JobId:     "job1",
+		Priority:  1,
+		Operation: &proto.BatchOperation{Type: proto.BatchOpDeleteObject},
+		Manifest:  &proto.BatchManifest{Bucket: "src", Prefixes: []string{""}},
+		Report:    &proto.BatchReport{Enabled: true, Bucket: "reports", Scope: proto.BatchReportAllTasks},
+		Status:    proto.BatchJobFailed,
+		Tasks: []*proto.BatchJobTask{
+			{Shard: 0, Progress: proto.BatchJobProgress{Total: 3, Succeeded: 2, Failed: 1}, Result: "manifest changed"},
+			{Shard: 1, Progress: proto.BatchJobProgress{Total: 2, Succeeded: 2}, Done: true},
+		},
+		CreationTime: 1700000000,
+	}
+	d := NewBatchJobDescriptor(job)
+	require.Equal(t, BatchJobProgressSummary{TotalNumberOfTasks: 5, NumberOfTasksSucceeded: 4, NumberOfTasksFailed: 1}, d.ProgressSummary)
+	require.Nil(t, d.Manifest)
+	require.Equal(t, "arn:aws:s3:::src", d.ManifestGenerator.S3JobManifestGenerator.SourceBucket)
+	require.Nil(t, d.ManifestGenerator.S3JobManifestGenerator.Filter)
+	require.NotNil(t, d.Operation.S3DeleteObject)
+	require.Equal(t, "arn:aws:s3:::reports", d.Report.Bucket)
+	require.Equal(t, []BatchJobFailure{{FailureCode: "TaskFailed", FailureReason: "manifest changed"}}, d.FailureReasons)
+	require.Equal(t, "2023-11-14T22:13:20Z", d.CreationTime)
+	require.Empty(t, d.TerminationDate)
+
+	data, err := xml.Marshal(&DescribeJobResult{Job: d})
+	require.NoError(t, err)
+	require.Contains(t, string(data), "<Operation><S3DeleteObject></S3DeleteObject></Operation>")
+
+	l := NewBatchJobListDescriptor(job)
+	require.Equal(t, proto.BatchOpDeleteObject, l.Operation)
+	require.Equal(t, proto.BatchJobFailed, l.Status)
+}
+
+func TestBatchJobRouters(t *testing.T) {
+	o := &ObjectNode{domains: []string{"cube.io"}}
+	router := mux.NewRouter().SkipClean(true)
+	o.registerApiRouters(router)
+
+	cases := []struct {
+		method  string
+		url     string
+		account bool
+		action  proto.Action
+		jobId   string
+	}{
+		{http.MethodPost, "http://123.cube.io/v20180820/jobs", true, proto.OSSCreateJobAction, ""},
+		{http.MethodGet, "http://cube.io/v20180820/jobs?jobStatuses=Active", true, proto.OSSListJobsAction, ""},
+		{http.MethodGet, "http://cube.io/v20180820/jobs/job1", true, proto.OSSDescribeJobAction, "job1"},
+		{http.MethodPost, "http://cube.io/v20180820/jobs/job1/status?requestedJobStatus=Cancelled", true, proto.OSSUpdateJobStatusAction, "job1"},
+		// the object of the bucket without the account id
+		{http.MethodGet, "http://cube.io/v20180820/jobs/job1", false, proto.OSSGetObjectAction, ""},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.url, nil)
+		if c.account {
+			req.Header.Set(XAmzAccountId, "123")
+		}
+		var match mux.RouteMatch
+		require.True(t, router.Match(req, &match), c.url)
+		require.Equal(t, c.action, ActionFromRouteName(match.Route.GetName()), c.url)
+		require.Equal(t, c.jobId, match.Vars[ContextKeyJobId], c.url)
+		if c.account {
+			require.Empty(t, match.Vars[ContextKeyBucket], c.url)
+		}
+	}
+}
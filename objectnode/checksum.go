@@ -0,0 +1,290 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/checking-object-integrity.html
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ChecksumAlgorithmCRC32  = "CRC32"
+	ChecksumAlgorithmCRC32C = "CRC32C"
+	ChecksumAlgorithmSHA1   = "SHA1"
+	ChecksumAlgorithmSHA256 = "SHA256"
+
+	ChecksumModeEnabled = "ENABLED"
+)
+
+var checksumAlgorithms = []string{
+	ChecksumAlgorithmCRC32,
+	ChecksumAlgorithmCRC32C,
+	ChecksumAlgorithmSHA1,
+	ChecksumAlgorithmSHA256,
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+func newChecksumHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case ChecksumAlgorithmCRC32:
+		return crc32.NewIEEE()
+	case ChecksumAlgorithmCRC32C:
+		return crc32.New(crc32cTable)
+	case ChecksumAlgorithmSHA1:
+		return sha1.New()
+	case ChecksumAlgorithmSHA256:
+		return sha256.New()
+	default:
+		return nil
+	}
+}
+
+// checksumHeader returns the header carrying the checksum of the algorithm, e.g. x-amz-checksum-crc32c.
+func checksumHeader(algorithm string) string {
+	return XAmzChecksumPrefix + strings.ToLower(algorithm)
+}
+
+func parseChecksumAlgorithm(raw string) (string, *ErrorCode) {
+	algorithm := strings.ToUpper(raw)
+	if newChecksumHash(algorithm) == nil {
+		return "", InvalidChecksumAlgorithm
+	}
+	return algorithm, nil
+}
+
+// ObjectChecksum is the additional checksum of the object data, which is stored in the xattr
+// as "<algorithm>:<base64 value>". The checksum of the multipart object is the checksum of the
+// concatenated part checksums, suffixed with "-<part count>". The multipart upload only stores
+// the algorithm until it is completed.
+type ObjectChecksum struct {
+	Algorithm string
+	Value     string
+}
+
+func (c *ObjectChecksum) Encode() string {
+	if c.Value == "" {
+		return c.Algorithm
+	}
+	return c.Algorithm + ":" + c.Value
+}
+
+func parseObjectChecksum(raw string) *ObjectChecksum {
+	if raw == "" {
+		return nil
+	}
+	algorithm, value, _ := strings.Cut(raw, ":")
+	if newChecksumHash(algorithm) == nil {
+		return nil
+	}
+	return &ObjectChecksum{Algorithm: algorithm, Value: value}
+}
+
+// setChecksumHeaders writes the checksum of the object to the response if the request enables the checksum mode.
+func setChecksumHeaders(r *http.Request, header http.Header, checksum *ObjectChecksum) {
+	if checksum == nil || checksum.Value == "" || !strings.EqualFold(r.Header.Get(XAmzChecksumMode), ChecksumModeEnabled) {
+		return
+	}
+	header.Set(checksumHeader(checksum.Algorithm), checksum.Value)
+}
+
+// ChecksumReader computes the checksum of the data read through it, and verifies it at the end of
+// the data against the one specified in the header or in the trailing header of the request.
+type ChecksumReader struct {
+	reader   io.Reader
+	hash     hash.Hash
+	expected string          // the checksum specified in the header
+	trailer  string          // the trailing header carrying the checksum
+	body     io.Reader       // the request body with the trailing headers
+	checksum *ObjectChecksum // the checksum of the data, valid after the data is verified
+	done     bool            // whether the data has been verified
+	err      *ErrorCode      // the verification error
+}
+
+// NewChecksumReader returns a reader to compute and verify the checksum specified by the request.
+// The checksum is either specified in the "x-amz-checksum-*" header, or in the trailing header
+// declared by "x-amz-trailer". If the request only specifies the algorithm by the
+// "x-amz-sdk-checksum-algorithm" header, or the multipart upload specifies the default algorithm,
+// the checksum is computed without verified. It returns nil if there is no checksum to compute.
+func NewChecksumReader(r *http.Request, reader io.Reader, defaultAlgorithm string) (*ChecksumReader, *ErrorCode) {
+	var algorithm, expected, trailer string
+	for _, alg := range checksumAlgorithms {
+		value := r.Header.Get(checksumHeader(alg))
+		if value == "" {
+			continue
+		}
+		if algorithm != "" {
+			return nil, MultipleChecksumHeaders
+		}
+		algorithm, expected = alg, value
+	}
+	if trailer = strings.ToLower(strings.TrimSpace(r.Header.Get(XAmzTrailer))); trailer != "" {
+		if !strings.HasPrefix(trailer, XAmzChecksumPrefix) {
+			return nil, MalformedTrailer
+		}
+		if algorithm != "" {
+			return nil, MultipleChecksumHeaders
+		}
+		alg, errCode := parseChecksumAlgorithm(strings.TrimPrefix(trailer, XAmzChecksumPrefix))
+		if errCode != nil {
+			return nil, errCode
+		}
+		if _, ok := r.Body.(interface{ Trailer() http.Header }); !ok {
+			return nil, MalformedTrailer
+		}
+		algorithm = alg
+	}
+	if raw := r.Header.Get(XAmzSdkChecksumAlgorithm); raw != "" {
+		alg, errCode := parseChecksumAlgorithm(raw)
+		if errCode != nil {
+			return nil, errCode
+		}
+		if algorithm == "" {
+			algorithm = alg
+		} else if algorithm != alg {
+			return nil, InvalidChecksumValue
+		}
+	}
+	if algorithm == "" {
+		algorithm = defaultAlgorithm
+	}
+	if algorithm == "" {
+		return nil, nil
+	}
+	if defaultAlgorithm != "" && algorithm != defaultAlgorithm {
+		return nil, ChecksumTypeMismatch
+	}
+	cr := newChecksumReader(reader, algorithm)
+	if expected != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(expected); err != nil || len(decoded) != cr.hash.Size() {
+			return nil, InvalidChecksumValue
+		}
+		cr.expected = expected
+	}
+	if trailer != "" {
+		cr.trailer, cr.body = trailer, r.Body
+	}
+	return cr, nil
+}
+
+// newChecksumReader returns a reader to compute the checksum of the algorithm without verified.
+func newChecksumReader(reader io.Reader, algorithm string) *ChecksumReader {
+	return &ChecksumReader{
+		reader:   reader,
+		hash:     newChecksumHash(algorithm),
+		checksum: &ObjectChecksum{Algorithm: algorithm},
+	}
+}
+
+func (cr *ChecksumReader) Read(p []byte) (n int, err error) {
+	if cr.err != nil {
+		return 0, cr.err
+	}
+	n, err = cr.reader.Read(p)
+	cr.hash.Write(p[:n])
+	if err == io.EOF && !cr.done {
+		cr.done = true
+		if cr.err = cr.verify(); cr.err != nil {
+			return n, cr.err
+		}
+	}
+	return
+}
+
+func (cr *ChecksumReader) verify() *ErrorCode {
+	actual := base64.StdEncoding.EncodeToString(cr.hash.Sum(nil))
+	expected := cr.expected
+	if cr.trailer != "" {
+		if trailer := cr.body.(interface{ Trailer() http.Header }).Trailer(); trailer != nil {
+			expected = trailer.Get(cr.trailer)
+		}
+		if expected == "" {
+			return MalformedTrailer
+		}
+	}
+	if expected != "" && expected != actual {
+		log.LogWarnf("ChecksumReader: checksum mismatch: algorithm(%v) expected(%v) actual(%v)",
+			cr.checksum.Algorithm, expected, actual)
+		return BadChecksum
+	}
+	cr.checksum.Value = actual
+	return nil
+}
+
+// Checksum returns the checksum of the data, whose value is only set after the data is verified.
+func (cr *ChecksumReader) Checksum() *ObjectChecksum {
+	if cr == nil {
+		return nil
+	}
+	return cr.checksum
+}
+
+// Err returns the error if the checksum of the data does not match.
+func (cr *ChecksumReader) Err() *ErrorCode {
+	if cr == nil {
+		return nil
+	}
+	return cr.err
+}
+
+// multipartChecksum computes the checksum of the multipart object, i.e. the checksum of the concatenated
+// part checksums suffixed with the part count. The checksums specified in the request are verified too.
+// It returns nil if any part has no checksum of the algorithm of the upload.
+func (v *Volume) multipartChecksum(algorithm string, parts []*proto.MultipartPartInfo, reqParts []*PartRequest) (*ObjectChecksum, error) {
+	reqChecksums := make(map[uint16]string, len(reqParts))
+	for _, reqPart := range reqParts {
+		reqChecksums[uint16(reqPart.PartNumber)] = reqPart.checksum(algorithm)
+	}
+	h := newChecksumHash(algorithm)
+	for _, part := range parts {
+		info, err := v.mw.XAttrGet_ll(part.Inode, XAttrKeyOSSChecksum)
+		if err != nil {
+			log.LogErrorf("multipartChecksum: meta get xattr fail: volume(%v) inode(%v) err(%v)", v.name, part.Inode, err)
+			return nil, err
+		}
+		checksum := parseObjectChecksum(string(info.Get(XAttrKeyOSSChecksum)))
+		if checksum == nil || checksum.Algorithm != algorithm || checksum.Value == "" {
+			log.LogWarnf("multipartChecksum: part has no checksum: volume(%v) part(%v) inode(%v) algorithm(%v)",
+				v.name, part.ID, part.Inode, algorithm)
+			return nil, nil
+		}
+		if expected := reqChecksums[part.ID]; expected != "" && expected != checksum.Value {
+			log.LogErrorf("multipartChecksum: part checksum mismatch: volume(%v) part(%v) expected(%v) actual(%v)",
+				v.name, part.ID, expected, checksum.Value)
+			return nil, InvalidPart
+		}
+		decoded, err := base64.StdEncoding.DecodeString(checksum.Value)
+		if err != nil {
+			return nil, nil
+		}
+		h.Write(decoded)
+	}
+	return &ObjectChecksum{
+		Algorithm: algorithm,
+		Value:     base64.StdEncoding.EncodeToString(h.Sum(nil)) + "-" + strconv.Itoa(len(parts)),
+	}, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseObjectChecksum(t *testing.T) {
+	checksum := parseObjectChecksum("CRC32C:yZRlqg==")
+	require.Equal(t, &ObjectChecksum{Algorithm: ChecksumAlgorithmCRC32C, Value: "yZRlqg=="}, checksum)
+	require.Equal(t, "CRC32C:yZRlqg==", checksum.Encode())
+
+	checksum = parseObjectChecksum("SHA1")
+	require.Equal(t, &ObjectChecksum{Algorithm: ChecksumAlgorithmSHA1}, checksum)
+	require.Equal(t, "SHA1", checksum.Encode())
+
+	require.Nil(t, parseObjectChecksum(""))
+	require.Nil(t, parseObjectChecksum("MD5:abc"))
+}
+
+func TestChecksumReader(t *testing.T) {
+	data := "hello world"
+	tests := []struct {
+		algorithm string
+		value     string
+	}{
+		{ChecksumAlgorithmCRC32, "DUoRhQ=="},
+		{ChecksumAlgorithmCRC32C, "yZRlqg=="},
+		{ChecksumAlgorithmSHA1, "Kq5sNclPz7QV2+lfQIuc6R7oRu0="},
+		{ChecksumAlgorithmSHA256, "uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek="},
+	}
+	for _, tt := range tests {
+		r, _ := http.NewRequest(http.MethodPut, "/bucket/key", nil)
+		r.Header.Set(checksumHeader(tt.algorithm), tt.value)
+		cr, errCode := NewChecksumReader(r, strings.NewReader(data), "")
+		require.Nil(t, errCode)
+		b, err := io.ReadAll(cr)
+		require.NoError(t, err)
+		require.Equal(t, data, string(b))
+		require.Equal(t, &ObjectChecksum{Algorithm: tt.algorithm, Value: tt.value}, cr.Checksum())
+	}
+
+	// mismatched checksum
+	r, _ := http.NewRequest(http.MethodPut, "/bucket/key", nil)
+	r.Header.Set(checksumHeader(ChecksumAlgorithmCRC32), "yZRlqg==")
+	cr, errCode := NewChecksumReader(r, strings.NewReader(data), "")
+	require.Nil(t, errCode)
+	_, err := io.ReadAll(cr)
+	require.Equal(t, BadChecksum, err)
+	require.Equal(t, BadChecksum, cr.Err())
+
+	// only the algorithm is specified
+	r, _ = http.NewRequest(http.MethodPut, "/bucket/key", nil)
+	r.Header.Set(XAmzSdkChecksumAlgorithm, "sha1")
+	cr, errCode = NewChecksumReader(r, strings.NewReader(data), "")
+	require.Nil(t, errCode)
+	_, err = io.ReadAll(cr)
+	require.NoError(t, err)
+	require.Equal(t, "Kq5sNclPz7QV2+lfQIuc6R7oRu0=", cr.Checksum().Value)
+
+	// the default algorithm of the multipart upload
+	r, _ = http.NewRequest(http.MethodPut, "/bucket/key", nil)
+	cr, errCode = NewChecksumReader(r, strings.NewReader(data), ChecksumAlgorithmCRC32C)
+	require.Nil(t, errCode)
+	_, err = io.ReadAll(cr)
+	require.NoError(t, err)
+	require.Equal(t, "yZRlqg==", cr.Checksum().Value)
+
+	// no checksum
+	r, _ = http.NewRequest(http.MethodPut, "/bucket/key", nil)
+	cr, errCode = NewChecksumReader(r, strings.NewReader(data), "")
+	require.Nil(t, errCode)
+	require.Nil(t, cr)
+	require.Nil(t, cr.Checksum())
+	require.Nil(t, cr.Err())
+}
+
+func TestChecksumReaderInvalidRequest(t *testing.T) {
+	tests := []struct {
+		header    map[string]string
+		algorithm string
+		errCode   *ErrorCode
+	}{
+		{map[string]string{"x-amz-checksum-crc32": "DUoRhQ==", "x-amz-checksum-sha1": "Kq5sNclPz7QV2+lfQIuc6R7oRu0="}, "", MultipleChecksumHeaders},
+		{map[string]string{"x-amz-checksum-crc32": "DUoRhQ==", XAmzTrailer: "x-amz-checksum-crc32c"}, "", MultipleChecksumHeaders},
+		{map[string]string{"x-amz-checksum-crc32": "invalid"}, "", InvalidChecksumValue},
+		{map[string]string{"x-amz-checksum-crc32": "Kq5sNclPz7QV2+lfQIuc6R7oRu0="}, "", InvalidChecksumValue},
+		{map[string]string{XAmzSdkChecksumAlgorithm: "MD5"}, "", InvalidChecksumAlgorithm},
+		{map[string]string{XAmzTrailer: "x-amz-checksum-md5"}, "", InvalidChecksumAlgorithm},
+		{map[string]string{XAmzTrailer: "x-amz-meta-key"}, "", MalformedTrailer},
+		{map[string]string{XAmzTrailer: "x-amz-checksum-crc32"}, "", MalformedTrailer},
+		{map[string]string{"x-amz-checksum-crc32": "DUoRhQ=="}, ChecksumAlgorithmSHA256, ChecksumTypeMismatch},
+	}
+	for _, tt := range tests {
+		r, _ := http.NewRequest(http.MethodPut, "/bucket/key", bytes.NewReader(nil))
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+		_, errCode := NewChecksumReader(r, r.Body, tt.algorithm)
+		require.Equal(t, tt.errCode, errCode, tt.header)
+	}
+}
+
+func TestChecksumReaderTrailer(t *testing.T) {
+	newRequest := func(body string) *http.Request {
+		r, _ := http.NewRequest(http.MethodPut, "/bucket/key", nil)
+		r.Header.Set(XAmzContentSha256, StreamingUnsignedPayloadTrailer)
+		r.Header.Set(XAmzTrailer, "x-amz-checksum-crc32c")
+		r.Body = NewUnsignedTrailerChunkedReader(strings.NewReader(body))
+		return r
+	}
+
+	r := newRequest("6\r\nhello \r\n5\r\nworld\r\n0\r\nx-amz-checksum-crc32c:yZRlqg==\r\n\r\n")
+	cr, errCode := NewChecksumReader(r, r.Body, "")
+	require.Nil(t, errCode)
+	b, err := io.ReadAll(cr)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(b))
+	require.Equal(t, &ObjectChecksum{Algorithm: ChecksumAlgorithmCRC32C, Value: "yZRlqg=="}, cr.Checksum())
+
+	r = newRequest("b\r\nhello world\r\n0\r\nx-amz-checksum-crc32c:DUoRhQ==\r\n\r\n")
+	cr, errCode = NewChecksumReader(r, r.Body, "")
+	require.Nil(t, errCode)
+	_, err = io.ReadAll(cr)
+	require.Equal(t, BadChecksum, err)
+
+	// the declared trailing header is missing
+	r = newRequest("b\r\nhello world\r\n0\r\nx-amz-checksum-crc32:DUoRhQ==\r\n\r\n")
+	cr, errCode = NewChecksumReader(r, r.Body, "")
+	require.Nil(t, errCode)
+	_, err = io.ReadAll(cr)
+	require.Equal(t, MalformedTrailer, err)
+}
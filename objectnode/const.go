@@ -14,7 +14,11 @@
 
 package objectnode
 
-import "os"
+import (
+	"os"
+
+	"github.com/cubefs/cubefs/proto"
+)
 
 const (
 	MaxRetry = 3
@@ -85,6 +89,29 @@ const (
 	XAmzSecurityToken               = "X-Amz-Security-Token" // #nosec G101
 	XAmzObjectLockMode              = "X-Amz-Object-Lock-Mode"
 	XAmzObjectLockRetainUntilDate   = "X-Amz-Object-Lock-Retain-Until-Date"
+	XAmzObjectLockLegalHold         = "X-Amz-Object-Lock-Legal-Hold"
+	XAmzVersionId                   = "x-amz-version-id"
+	XAmzDeleteMarker                = "x-amz-delete-marker"
+	XAmzCopySourceVersionId         = "x-amz-copy-source-version-id"
+	XAmzReplicationStatus           = "x-amz-replication-status"
+	XAmzRestore                     = "x-amz-restore"
+	XAmzAccountId                   = "x-amz-account-id"
+	XAmzChecksumPrefix              = "x-amz-checksum-"
+	XAmzChecksumAlgorithm           = "x-amz-checksum-algorithm"
+	XAmzChecksumMode                = "x-amz-checksum-mode"
+	XAmzSdkChecksumAlgorithm        = "x-amz-sdk-checksum-algorithm"
+	XAmzTrailer                     = "x-amz-trailer"
+	XAmzNextAppendPosition          = "x-amz-next-append-position"
+	XAmzRenameSource                = "x-amz-rename-source"
+
+	XAmzServerSideEncryption           = "x-amz-server-side-encryption"
+	XAmzServerSideEncryptionKMSKeyId   = "x-amz-server-side-encryption-aws-kms-key-id"
+	XAmzSSECustomerAlgorithm           = "x-amz-server-side-encryption-customer-algorithm"
+	XAmzSSECustomerKey                 = "x-amz-server-side-encryption-customer-key"
+	XAmzSSECustomerKeyMD5              = "x-amz-server-side-encryption-customer-key-MD5"
+	XAmzCopySourceSSECustomerAlgorithm = "x-amz-copy-source-server-side-encryption-customer-algorithm"
+	XAmzCopySourceSSECustomerKey       = "x-amz-copy-source-server-side-encryption-customer-key"
+	XAmzCopySourceSSECustomerKeyMD5    = "x-amz-copy-source-server-side-encryption-customer-key-MD5"
 
 	HeaderNameXAmzDecodedContentLength = "x-amz-decoded-content-length"
 )
@@ -95,6 +122,7 @@ const (
 	ValueContentTypeStream    = "application/octet-stream"
 	ValueContentTypeXML       = "application/xml"
 	ValueContentTypeJSON      = "application/json"
+	ValueContentTypeText      = "text/plain"
 	ValueContentTypeDirectory = "application/directory"
 	ValueMultipartFormData    = "multipart/form-data"
 )
@@ -123,6 +151,14 @@ const (
 	ParamPartDelimiter  = "delimiter"
 	ParamEncodingType   = "encoding-type"
 
+	ParamVersionId       = "versionId"
+	ParamVersionIdMarker = "version-id-marker"
+
+	ParamAppend   = "append"
+	ParamPosition = "position"
+
+	ParamRenameObject = "renameObject"
+
 	ParamResponseCacheControl       = "response-cache-control"
 	ParamResponseContentType        = "response-content-type"
 	ParamResponseContentDisposition = "response-content-disposition"
@@ -144,16 +180,32 @@ const (
 // XAttr keys for ObjectNode compatible feature
 const (
 	XAttrKeyOSSPrefix       = "oss:"
-	XAttrKeyOSSETag         = "oss:etag"
-	XAttrKeyOSSTagging      = "oss:tagging"
+	XAttrKeyOSSETag         = proto.XAttrKeyETag
+	XAttrKeyOSSTagging      = proto.XAttrKeyTagging
 	XAttrKeyOSSPolicy       = "oss:policy"
 	XAttrKeyOSSACL          = "oss:acl"
 	XAttrKeyOSSMIME         = "oss:mime"
 	XAttrKeyOSSDISPOSITION  = "oss:disposition"
 	XAttrKeyOSSCORS         = "oss:cors"
-	XAttrKeyOSSLock         = "oss:lock"
+	XAttrKeyOSSLock         = proto.XAttrKeyObjectLock
+	XAttrKeyOSSLegalHold    = proto.XAttrKeyObjectLegalHold
 	XAttrKeyOSSCacheControl = "oss:cache"
 	XAttrKeyOSSExpires      = "oss:expires"
+	XAttrKeyOSSVersioning   = "oss:versioning"
+	XAttrKeyOSSVersionId    = "oss:version-id"
+	XAttrKeyOSSEncryption   = "oss:encryption"
+	XAttrKeyOSSSSE          = "oss:sse"
+	XAttrKeyOSSReplication  = "oss:replication"
+	XAttrKeyOSSReplStatus   = "oss:replication-status"
+	XAttrKeyOSSWebsite      = "oss:website"
+	XAttrKeyOSSNotification = "oss:notification"
+	XAttrKeyOSSPublicBlock  = "oss:public-access-block"
+	XAttrKeyOSSRestore      = proto.XAttrKeyRestore
+	XAttrKeyOSSChecksum     = "oss:checksum"
+	XAttrKeyOSSLogging      = "oss:logging"
+	// XAttrKeyOSSVersions is the prefix of the xattrs stored on the parent directory,
+	// each of which keeps the non-current versions of one object key.
+	XAttrKeyOSSVersions = proto.XAttrKeyVersions
 
 	// Deprecated
 	XAttrKeyOSSETagDeprecated = "oss:tag"
@@ -118,6 +118,8 @@ func NewRandomUUIDETagValue(partNum int, ts time.Time) ETagValue {
 
 func ParseETagValue(raw string) ETagValue {
 	value := ETagValue{}
+	// the append state of the appendable object follows the ETag
+	raw, _, _ = strings.Cut(raw, appendETagSeparator)
 	if !regexpEncodedETagValue.MatchString(raw) {
 		return value
 	}
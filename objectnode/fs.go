@@ -18,23 +18,31 @@ import (
 	"os"
 	"sort"
 	"time"
+
+	"github.com/cubefs/cubefs/proto"
 )
 
 type FSFileInfo struct {
-	Path            string
-	Size            int64
-	Mode            os.FileMode
-	ModifyTime      time.Time
-	CreateTime      time.Time
-	ETag            string
-	Inode           uint64
-	MIMEType        string
-	Disposition     string
-	CacheControl    string
-	Expires         string
-	Metadata        map[string]string `graphql:"-"` // User-defined metadata
-	RetainUntilDate string
-	StorageClass    uint32
+	Path              string
+	Size              int64
+	Mode              os.FileMode
+	ModifyTime        time.Time
+	CreateTime        time.Time
+	ETag              string
+	Inode             uint64
+	MIMEType          string
+	Disposition       string
+	CacheControl      string
+	Expires           string
+	Metadata          map[string]string `graphql:"-"` // User-defined metadata
+	RetainUntilDate   string
+	LegalHold         string
+	StorageClass      uint32
+	VersionId         string
+	Encryption        *ObjectEncryption `graphql:"-"` // Server-side encryption
+	ReplicationStatus string
+	Restore           *proto.RestoreStatus `graphql:"-"` // Restore status of the blobstore object
+	Checksum          *ObjectChecksum      `graphql:"-"` // Additional checksum of the object data
 }
 
 type Prefixes []string
@@ -71,8 +71,11 @@ func (s *CacheUserInfoStore) LoadUser(accessKey string) (*proto.UserInfo, error)
 	return s.selectLoader(accessKey).LoadUser(accessKey)
 }
 
-func NewUserInfoStore(masters []string, strict bool) UserInfoStore {
+// NewUserInfoStore creates the store of the users, the API token is required to get the users
+// if the master requires the API tokens.
+func NewUserInfoStore(masters []string, strict bool, apiToken string) UserInfoStore {
 	mc := master.NewMasterClient(masters, false)
+	mc.SetAPIToken(apiToken)
 	if strict {
 		return &StrictUserInfoStore{
 			mc: mc,
@@ -93,6 +93,31 @@ type PutFileOption struct {
 	CacheControl string
 	Expires      string
 	ObjectLock   *ObjectLockConfig
+
+	// SourceVersionId specifies the version of the source object to copy.
+	SourceVersionId string
+
+	// Encryption encrypts the object data if it is specified.
+	Encryption *ObjectEncryption
+	// SourceEncryption decrypts the data of the source object to copy.
+	SourceEncryption *ObjectEncryption
+
+	// ReplicationStatus is set if the object is to be replicated or is a replica.
+	ReplicationStatus string
+
+	// Checksum is the additional checksum of the object data. Its value is set after the data
+	// is read through the ChecksumReader, and only the algorithm is set for the multipart upload.
+	Checksum *ObjectChecksum
+
+	// Condition is the precondition which the current object of the key must satisfy.
+	Condition *WriteCondition
+}
+
+func (opt *PutFileOption) writeCondition() *WriteCondition {
+	if opt == nil {
+		return nil
+	}
+	return opt.Condition
 }
 
 type ListFilesV1Option struct {
@@ -213,6 +238,48 @@ func (v *Volume) loadOSSMeta() {
 		return
 	}
 	v.metaLoader.storeObjectLock(objectlock)
+
+	var versioning *VersioningConfiguration
+	if versioning, err = v.loadBucketVersioning(); err != nil {
+		return
+	}
+	v.metaLoader.storeVersioning(versioning)
+
+	var encryption *ServerSideEncryptionConfiguration
+	if encryption, err = v.loadBucketEncryption(); err != nil {
+		return
+	}
+	v.metaLoader.storeEncryption(encryption)
+
+	var replication *ReplicationConfiguration
+	if replication, err = v.loadBucketReplication(); err != nil {
+		return
+	}
+	v.metaLoader.storeReplication(replication)
+
+	var website *WebsiteConfiguration
+	if website, err = v.loadBucketWebsite(); err != nil {
+		return
+	}
+	v.metaLoader.storeWebsite(website)
+
+	var notification *NotificationConfiguration
+	if notification, err = v.loadBucketNotification(); err != nil {
+		return
+	}
+	v.metaLoader.storeNotification(notification)
+
+	var publicAccessBlock *PublicAccessBlockConfiguration
+	if publicAccessBlock, err = v.loadBucketPublicAccessBlock(); err != nil {
+		return
+	}
+	v.metaLoader.storePublicAccessBlock(publicAccessBlock)
+
+	var logging *BucketLoggingStatus
+	if logging, err = v.loadBucketLogging(); err != nil {
+		return
+	}
+	v.metaLoader.storeLogging(logging)
 	v.metaLoader.setSynced()
 }
 
@@ -292,6 +359,111 @@ func (v *Volume) loadObjectLock() (configuration *ObjectLockConfig, err error) {
 	return configuration, nil
 }
 
+func (v *Volume) loadBucketVersioning() (configuration *VersioningConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSVersioning); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &VersioningConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketEncryption() (configuration *ServerSideEncryptionConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSEncryption); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &ServerSideEncryptionConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketReplication() (configuration *ReplicationConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSReplication); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &ReplicationConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketWebsite() (configuration *WebsiteConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSWebsite); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &WebsiteConfiguration{}
+	if err = xml.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketNotification() (configuration *NotificationConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSNotification); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &NotificationConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketLogging() (status *BucketLoggingStatus, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSLogging); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	status = &BucketLoggingStatus{}
+	if err = json.Unmarshal(raw, status); err != nil {
+		return
+	}
+	return status, nil
+}
+
+func (v *Volume) loadBucketPublicAccessBlock() (configuration *PublicAccessBlockConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSPublicBlock); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &PublicAccessBlockConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
 func (v *Volume) getInodeFromPath(path string) (inode uint64, err error) {
 	if path == "/" {
 		return volumeRootInode, nil
@@ -731,14 +903,20 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		}
 	}()
 
+	// The ETag is always the MD5 of the plaintext, so the data is encrypted after hashed.
+	var dataHash hash.Hash = md5Hash
+	if opt != nil && opt.Encryption != nil {
+		reader = opt.Encryption.EncryptReader(io.TeeReader(reader, md5Hash), 0)
+		dataHash = nil
+	}
 	if proto.IsCold(v.volType) || proto.IsStorageClassBlobStore(invisibleTempDataInode.StorageClass) {
-		if _, err = v.ebsWrite(invisibleTempDataInode.Inode, reader, md5Hash, invisibleTempDataInode.StorageClass); err != nil {
+		if _, err = v.ebsWrite(invisibleTempDataInode.Inode, reader, dataHash, invisibleTempDataInode.StorageClass); err != nil {
 			log.LogErrorf("PutObject: ebs write fail: volume(%v) path(%v) inode(%v) err(%v)",
 				v.name, path, invisibleTempDataInode.Inode, err)
 			return
 		}
 	} else {
-		if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, md5Hash, invisibleTempDataInode.StorageClass); err != nil {
+		if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, dataHash, invisibleTempDataInode.StorageClass); err != nil {
 			log.LogErrorf("PutObject: stream write fail: volume(%v) path(%v) inode(%v) err(%v)",
 				v.name, path, invisibleTempDataInode.Inode, err)
 			return
@@ -793,6 +971,15 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 	if opt != nil && opt.ObjectLock != nil && opt.ObjectLock.ToRetention() != nil {
 		attr.XAttrs[XAttrKeyOSSLock] = formatRetentionDateStr(finalInode.ModifyTime, opt.ObjectLock.ToRetention())
 	}
+	if opt != nil && opt.Encryption != nil {
+		attr.XAttrs[XAttrKeyOSSSSE] = opt.Encryption.Encode()
+	}
+	if opt != nil && opt.ReplicationStatus != "" {
+		attr.XAttrs[XAttrKeyOSSReplStatus] = opt.ReplicationStatus
+	}
+	if opt != nil && opt.Checksum != nil && opt.Checksum.Value != "" {
+		attr.XAttrs[XAttrKeyOSSChecksum] = opt.Checksum.Encode()
+	}
 
 	// If user-defined metadata have been specified, use extend attributes for storage.
 	if opt != nil && len(opt.Metadata) > 0 {
@@ -823,13 +1010,16 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 	}
 
 	// apply new inode to dentry
-	err = v.applyInodeToDEntry(parentId, lastPathItem.Name, invisibleTempDataInode.Inode, false,
-		fixedPath, invisibleTempDataInode.StorageClass)
+	fsInfo.VersionId, err = v.applyInodeToDEntry(parentId, lastPathItem.Name, invisibleTempDataInode.Inode, false,
+		fixedPath, invisibleTempDataInode.StorageClass, opt.writeCondition())
 	if err != nil {
 		log.LogErrorf("PutObject: apply new inode to dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 			parentId, lastPathItem.Name, invisibleTempDataInode.Inode, err)
 		return
 	}
+	if fsInfo.VersionId != "" {
+		attr.XAttrs[XAttrKeyOSSVersionId] = fsInfo.VersionId
+	}
 
 	// force updating dentry and attrs in cache
 	updateDentryCache(parentId, invisibleTempDataInode.Inode, DefaultFileMode, lastPathItem.Name, v.name)
@@ -839,19 +1029,34 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 }
 
 func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64, isCompleteMultipart bool,
-	fullPath string, storageClass uint32,
-) (err error) {
+	fullPath string, storageClass uint32, cond *WriteCondition,
+) (versionId string, err error) {
+	var versioning *VersioningConfiguration
+	if versioning, err = v.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("applyInodeToDEntry: load versioning fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if versioning.Configured() {
+		return v.applyInodeToVersionedDEntry(parentId, name, inode, isCompleteMultipart, fullPath, storageClass,
+			versioning, cond)
+	}
+
+	var existInode uint64
 	var existMode uint32
-	_, existMode, err = v.mw.Lookup_ll(parentId, name) // exist object inode
+	existInode, existMode, err = v.mw.Lookup_ll(parentId, name) // exist object inode
 	if err != nil && err != syscall.ENOENT {
 		log.LogErrorf("applyInodeToDEntry: meta lookup fail: parentID(%v) name(%v) err(%v)", parentId, name, err)
 		return
 	}
 
 	if err == syscall.ENOENT {
+		if _, err = v.checkWriteCondition(cond, 0); err != nil {
+			return
+		}
 		if err = v.applyInodeToNewDentry(parentId, name, inode, fullPath); err != nil {
 			log.LogErrorf("applyInodeToDEntry: apply inode to new dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 				parentId, name, inode, err)
+			err = writeConditionErr(cond, err)
 			return
 		}
 		log.LogDebugf("applyInodeToDEntry: apply inode to new dentry: parentID(%v) name(%v) inode(%v)",
@@ -863,12 +1068,18 @@ func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64,
 			err = syscall.EINVAL
 			return
 		}
-		// current implementation doesn't support object versioning, so uploading a object with a key already existed in bucket
+		var expectedInode uint64
+		if expectedInode, err = v.checkWriteCondition(cond, existInode); err != nil {
+			return
+		}
+		// versioning is not configured, so uploading a object with a key already existed in bucket
 		// is implemented with replacing the old one instead.
 		// refer: https://docs.aws.amazon.com/AmazonS3/latest/userguide/upload-objects.html
-		if err = v.applyInodeToExistDentry(parentId, name, inode, isCompleteMultipart, fullPath, storageClass); err != nil {
+		if err = v.applyInodeToExistDentry(parentId, name, inode, expectedInode, isCompleteMultipart, fullPath,
+			storageClass); err != nil {
 			log.LogErrorf("applyInodeToDEntry: apply inode to exist dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 				parentId, name, inode, err)
+			err = writeConditionErr(cond, err)
 			return
 		}
 	}
@@ -912,6 +1123,17 @@ func (v *Volume) DeletePath(path string) (err error) {
 		if err != nil || len(dentries) > 0 {
 			return
 		}
+		// The directory may still keep the non-current versions of some object keys.
+		var versioning *VersioningConfiguration
+		if versioning, err = v.metaLoader.loadVersioning(); err != nil {
+			return
+		}
+		if versioning.Configured() {
+			var hasVersions bool
+			if hasVersions, err = v.hasObjectVersions(ino); err != nil || hasVersions {
+				return
+			}
+		}
 	}
 	// check whether object is protected by object lock
 	objetLock, err := v.metaLoader.loadObjectLock()
@@ -939,6 +1161,8 @@ func (v *Volume) DeletePath(path string) (err error) {
 	deleteDentryCache(parent, name, v.name)
 	deleteAttrCache(parent, v.name)
 
+	v.deleteRestoredCopy(ino, path)
+
 	log.LogInfof("DeletePath: evict: volume(%v) path(%v) inode(%v)", v.name, path, ino)
 	// Evict inode
 	if err = v.mw.Evict(ino, path); err != nil {
@@ -997,6 +1221,17 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 	if opt != nil && opt.ACL != nil {
 		extend[XAttrKeyOSSACL] = opt.ACL.Encode()
 	}
+	// The data key is shared by all the parts of the upload.
+	if opt != nil && opt.Encryption != nil {
+		extend[XAttrKeyOSSSSE] = opt.Encryption.Encode()
+	}
+	if opt != nil && opt.ReplicationStatus != "" {
+		extend[XAttrKeyOSSReplStatus] = opt.ReplicationStatus
+	}
+	// The algorithm is replaced with the checksum of the object when the upload is completed.
+	if opt != nil && opt.Checksum != nil {
+		extend[XAttrKeyOSSChecksum] = opt.Checksum.Algorithm
+	}
 
 	if v.mw.EnableQuota {
 		var parentId uint64
@@ -1020,7 +1255,9 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 	return multipartID, nil
 }
 
-func (v *Volume) WritePart(path string, multipartId string, partId uint16, reader io.Reader) (*FSFileInfo, error) {
+func (v *Volume) WritePart(path string, multipartId string, partId uint16, reader io.Reader, sse *ObjectEncryption,
+	checksum *ObjectChecksum,
+) (*FSFileInfo, error) {
 	var exist bool
 	var err error
 	defer func() {
@@ -1065,10 +1302,16 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 	}()
 
 	var (
-		size    uint64
-		etag    string
-		md5Hash = md5.New()
+		size     uint64
+		etag     string
+		md5Hash  = md5.New()
+		dataHash hash.Hash
 	)
+	dataHash = md5Hash
+	if sse != nil {
+		reader = sse.EncryptReader(io.TeeReader(reader, md5Hash), tempInodeInfo.Inode)
+		dataHash = nil
+	}
 	isCache := false
 	if proto.IsCold(v.volType) || proto.IsStorageClassBlobStore(tempInodeInfo.StorageClass) {
 		isCache = true
@@ -1085,14 +1328,14 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 		}
 	}()
 	if proto.IsCold(v.volType) || proto.IsStorageClassBlobStore(tempInodeInfo.StorageClass) {
-		if size, err = v.ebsWrite(tempInodeInfo.Inode, reader, md5Hash, tempInodeInfo.StorageClass); err != nil {
+		if size, err = v.ebsWrite(tempInodeInfo.Inode, reader, dataHash, tempInodeInfo.StorageClass); err != nil {
 			log.LogErrorf("WritePart: ebs write fail: volume(%v) inode(%v) multipartID(%v) partID(%v) err(%v)",
 				v.name, tempInodeInfo.Inode, multipartId, partId, err)
 			return nil, err
 		}
 	} else {
 		// Write data to data node
-		if size, err = v.streamWrite(tempInodeInfo.Inode, reader, md5Hash, tempInodeInfo.StorageClass); err != nil {
+		if size, err = v.streamWrite(tempInodeInfo.Inode, reader, dataHash, tempInodeInfo.StorageClass); err != nil {
 			log.LogErrorf("WritePart: stream write fail: volume(%v) inode(%v) multipartID(%v) partID(%v) err(%v)",
 				v.name, tempInodeInfo.Inode, multipartId, partId, err)
 			return nil, err
@@ -1107,6 +1350,15 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 	// compute file md5
 	etag = hex.EncodeToString(md5Hash.Sum(nil))
 
+	// the part checksums make up the checksum of the object when the upload is completed
+	if checksum != nil && checksum.Value != "" {
+		if err = v.mw.XAttrSet_ll(tempInodeInfo.Inode, []byte(XAttrKeyOSSChecksum), []byte(checksum.Encode())); err != nil {
+			log.LogErrorf("WritePart: meta set checksum fail: volume(%v) inode(%v) multipartID(%v) partID(%v) err(%v)",
+				v.name, tempInodeInfo.Inode, multipartId, partId, err)
+			return nil, err
+		}
+	}
+
 	// update temp file inode to meta with session, overwrite existing part can result in exist == true
 	oldInode, exist, err = v.mw.AddMultipartPart_ll(path, multipartId, partId, size, etag, tempInodeInfo)
 	if err != nil {
@@ -1165,7 +1417,9 @@ func (v *Volume) AbortMultipart(path string, multipartID string) (err error) {
 	return nil
 }
 
-func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *proto.MultipartInfo, discardedPartInodes map[uint64]uint16) (fsFileInfo *FSFileInfo, err error) {
+func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *proto.MultipartInfo, discardedPartInodes map[uint64]uint16,
+	cond *WriteCondition,
+) (fsFileInfo *FSFileInfo, err error) {
 	defer func() {
 		log.LogInfof("Audit: CompleteMultipart: volume(%v) path(%v) multipartID(%v) err(%v)",
 			v.name, path, multipartID, err)
@@ -1322,6 +1576,20 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 	if objectLock != nil && objectLock.ToRetention() != nil {
 		attrs[XAttrKeyOSSLock] = formatRetentionDateStr(finalInode.ModifyTime, objectLock.ToRetention())
 	}
+	// record the parts of the encrypted object, which is required to decrypt the data
+	if raw := attrs[XAttrKeyOSSSSE]; raw != "" {
+		var sse *ObjectEncryption
+		if sse, err = ParseObjectEncryption([]byte(raw)); err != nil {
+			log.LogErrorf("CompleteMultipart: parse encryption fail: volume(%v) multipartID(%v) inode(%v) err(%v)",
+				v.name, multipartID, finalInode.Inode, err)
+			return
+		}
+		sse.Parts = make([]SSEPart, 0, len(parts))
+		for _, part := range parts {
+			sse.Parts = append(sse.Parts, SSEPart{Inode: part.Inode, Size: part.Size})
+		}
+		attrs[XAttrKeyOSSSSE] = sse.Encode()
+	}
 	if err = v.mw.BatchSetXAttr_ll(finalInode.Inode, attrs); err != nil {
 		log.LogErrorf("CompleteMultipart: store multipart extend fail: volume(%v) multipartID(%v) inode(%v) "+
 			"attrs(%v) err(%v)", v.name, multipartID, finalInode.Inode, attrs, err)
@@ -1329,8 +1597,9 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 	}
 
 	// apply new inode to dentry
-	if err = v.applyInodeToDEntry(parentId, filename, completeInodeInfo.Inode, true,
-		path, completeInodeInfo.StorageClass); err != nil {
+	var versionId string
+	if versionId, err = v.applyInodeToDEntry(parentId, filename, completeInodeInfo.Inode, true,
+		path, completeInodeInfo.StorageClass, cond); err != nil {
 		log.LogErrorf("CompleteMultipart: apply inode to dentry fail: volume(%v) multipartID(%v) parentId(%v) "+
 			"fileName(%v) inode(%v) err(%v)", v.name, multipartID, parentId, filename, completeInodeInfo.Inode, err)
 		return
@@ -1366,6 +1635,9 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 	}()
 
 	// force updating dentry and attrs in cache
+	if versionId != "" {
+		attrs[XAttrKeyOSSVersionId] = versionId
+	}
 	updateDentryCache(parentId, completeInodeInfo.Inode, DefaultFileMode, filename, v.name)
 	putAttrCache(attrItem, v.name)
 
@@ -1380,6 +1652,7 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		ModifyTime: time.Now(),
 		ETag:       etagValue.ETag(),
 		Inode:      finalInode.Inode,
+		VersionId:  versionId,
 	}
 
 	return fInfo, nil
@@ -1392,11 +1665,19 @@ func (v *Volume) ebsWrite(inode uint64, reader io.Reader, h hash.Hash, storageCl
 }
 
 func (v *Volume) streamWrite(inode uint64, reader io.Reader, h hash.Hash, storageClass uint32) (size uint64, err error) {
+	return v.streamWriteAt(inode, 0, reader, h, storageClass)
+}
+
+// streamWriteAt writes the data from the reader into the inode starting at the offset.
+func (v *Volume) streamWriteAt(inode uint64, offset int, reader io.Reader, h hash.Hash, storageClass uint32) (size uint64, err error) {
 	var (
-		buf                   = make([]byte, 2*util.BlockSize)
-		teeReader             = io.TeeReader(reader, h)
-		readN, writeN, offset int
+		buf           = make([]byte, 2*util.BlockSize)
+		teeReader     = reader
+		readN, writeN int
 	)
+	if h != nil {
+		teeReader = io.TeeReader(reader, h)
+	}
 	for {
 		readN, err = teeReader.Read(buf)
 		if err != nil && err != io.EOF {
@@ -1494,11 +1775,18 @@ func (v *Volume) applyInodeToNewDentry(parentID uint64, name string, inode uint6
 	return
 }
 
-func (v *Volume) applyInodeToExistDentry(parentID uint64, name string, inode uint64, isCompleteMultipart bool,
-	fullPath string, storageClass uint32,
+// applyInodeToExistDentry replaces the inode of the dentry. If expectedInode is not zero, the dentry is
+// updated only if it still refers to the expected inode, otherwise syscall.ESTALE is returned.
+func (v *Volume) applyInodeToExistDentry(parentID uint64, name string, inode, expectedInode uint64,
+	isCompleteMultipart bool, fullPath string, storageClass uint32,
 ) (err error) {
 	var oldInode uint64
-	oldInode, err = v.mw.DentryUpdate_ll(parentID, name, inode, fullPath)
+	if expectedInode != 0 {
+		err = v.mw.DentryCompareAndUpdate_ll(parentID, name, inode, expectedInode, fullPath)
+		oldInode = expectedInode
+	} else {
+		oldInode, err = v.mw.DentryUpdate_ll(parentID, name, inode, fullPath)
+	}
 	if err != nil {
 		log.LogErrorf("applyInodeToExistDentry: meta update dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 			parentID, name, inode, err)
@@ -1523,6 +1811,8 @@ func (v *Volume) applyInodeToExistDentry(parentID uint64, name string, inode uin
 		}
 	}
 
+	v.deleteRestoredCopy(oldInode, fullPath)
+
 	// unlink and evict old inode
 	log.LogWarnf("applyInodeToExistDentry: unlink inode: volume(%v) inode(%v)", v.name, oldInode)
 	if _, err = v.mw.InodeUnlink_ll(oldInode, fullPath); err != nil {
@@ -1728,6 +2018,11 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		break
 	}
 
+	return v.buildObjectMeta(path, mode, inoInfo)
+}
+
+func (v *Volume) buildObjectMeta(path string, mode os.FileMode, inoInfo *proto.InodeInfo) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
+	inode := inoInfo.Inode
 	var (
 		etagValue    ETagValue
 		mimeType     string
@@ -1806,6 +2101,13 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		}
 	}
 
+	var encryption *ObjectEncryption
+	if encryption, err = ParseObjectEncryption(xattr.Get(XAttrKeyOSSSSE)); err != nil {
+		log.LogErrorf("getObjectMeta: parse encryption fail: volume(%v) path(%v) err(%v)",
+			v.Name(), path, err)
+		return
+	}
+
 	// Validating ETag value.
 	if !mode.IsDir() && (!etagValue.Valid() || etagValue.TS.Before(inoInfo.ModifyTime)) {
 		log.LogWarnf("ObjectMeta: etag invalid or before inode modTime: volume(%v) path(%v) inoInfo(%v) etagVal(%v)",
@@ -1827,6 +2129,13 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		Metadata:        metadata,
 		RetainUntilDate: retainUntilDate,
 		StorageClass:    inoInfo.StorageClass,
+		VersionId:       string(xattr.Get(XAttrKeyOSSVersionId)),
+		Encryption:      encryption,
+		LegalHold:       string(xattr.Get(XAttrKeyOSSLegalHold)),
+
+		ReplicationStatus: string(xattr.Get(XAttrKeyOSSReplStatus)),
+		Restore:           parseRestoreStatus(xattr.Get(XAttrKeyOSSRestore)),
+		Checksum:          parseObjectChecksum(string(xattr.Get(XAttrKeyOSSChecksum))),
 	}
 	return
 }
@@ -2632,7 +2941,18 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		sInodeInfo *proto.InodeInfo
 	)
 
-	if _, sInode, sName, sMode, err = sv.recursiveLookupTarget(sourcePath, false); err != nil {
+	var sourceVersionId string
+	if opt != nil {
+		sourceVersionId = opt.SourceVersionId
+	}
+	if sourceVersionId != "" {
+		if sInode, sMode, err = sv.lookupVersionInode(sourcePath, sourceVersionId); err != nil {
+			log.LogErrorf("CopyFile: look up source version fail, source path(%v) versionId(%v) err(%v)",
+				sourcePath, sourceVersionId, err)
+			return
+		}
+		sName = os_path.Base(sourcePath)
+	} else if _, sInode, sName, sMode, err = sv.recursiveLookupTarget(sourcePath, false); err != nil {
 		log.LogErrorf("CopyFile: look up source path fail, source path(%v) err(%v)", sourcePath, err)
 		return
 	}
@@ -2663,11 +2983,18 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	var xattr *proto.XAttrInfo
 	// if source path is same with target path, just reset file metadata
 	// source path is same with target path, and metadata directive is not 'REPLACE', objectNode does nothing
-	if targetPath == sourcePath && v.name == sv.name {
+	// the data is rewritten if the source or the target is encrypted
+	isEncrypted := opt != nil && (opt.Encryption != nil || opt.SourceEncryption != nil)
+	if targetPath == sourcePath && v.name == sv.name && sourceVersionId == "" && !isEncrypted {
 		if metaDirective != MetadataDirectiveReplace {
 			log.LogInfof("CopyFile: targetPath(%v) is equal with sourcePath(%v),but metaDirective(%v) is not REPLACE",
 				targetPath, sourcePath, metaDirective)
 		} else {
+			// the metadata is replaced in place, the condition is evaluated against the object itself,
+			// whose ETag is not changed afterwards once the condition is met
+			if _, err = v.checkWriteCondition(opt.writeCondition(), sInode); err != nil {
+				return
+			}
 			// check whether target object is protected by object lock
 			if opt != nil && opt.ObjectLock != nil {
 				err = isObjectLocked(v, sInode, sName, sourcePath)
@@ -2840,6 +3167,14 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		buf         = make([]byte, 2*util.BlockSize)
 	)
 
+	var sourceCipher, targetCipher *SSECipher
+	if opt != nil && opt.SourceEncryption != nil {
+		sourceCipher = opt.SourceEncryption.NewCipher(0)
+	}
+	if opt != nil && opt.Encryption != nil {
+		targetCipher = opt.Encryption.NewCipher(0)
+	}
+
 	var sctx context.Context
 	var ebsReader *blobstore.Reader
 	var tctx context.Context
@@ -2871,6 +3206,13 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			return
 		}
 		if readN > 0 {
+			if sourceCipher != nil {
+				sourceCipher.XORKeyStream(buf[:readN], buf[:readN])
+			}
+			md5Hash.Write(buf[:readN])
+			if targetCipher != nil {
+				targetCipher.XORKeyStream(buf[:readN], buf[:readN])
+			}
 			if proto.IsCold(v.volType) || proto.IsStorageClassBlobStore(tInodeInfo.StorageClass) {
 				writeN, err = ebsWriter.WriteWithoutPool(tctx, writeOffset, buf[:readN])
 			} else {
@@ -2883,7 +3225,6 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			}
 			readOffset += readN
 			writeOffset += writeN
-			md5Hash.Write(buf[:readN])
 		}
 		if err == io.EOF {
 			err = nil
@@ -2923,6 +3264,12 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		},
 	}
 	targetAttr.XAttrs[XAttrKeyOSSETag] = etagValue.Encode()
+	if opt != nil && opt.Encryption != nil {
+		targetAttr.XAttrs[XAttrKeyOSSSSE] = opt.Encryption.Encode()
+	}
+	if opt != nil && opt.ReplicationStatus != "" {
+		targetAttr.XAttrs[XAttrKeyOSSReplStatus] = opt.ReplicationStatus
+	}
 
 	// copy source file metadata to write target file metadata
 	if metaDirective != MetadataDirectiveReplace {
@@ -2931,7 +3278,8 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			return
 		}
 		for key, val := range xattr.XAttrs {
-			if key == XAttrKeyOSSETag {
+			if key == XAttrKeyOSSETag || key == XAttrKeyOSSVersionId || key == XAttrKeyOSSSSE || key == XAttrKeyOSSReplStatus ||
+				key == XAttrKeyOSSRestore {
 				continue
 			}
 			targetAttr.XAttrs[key] = val
@@ -3005,12 +3353,15 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	}
 
 	// apply new inode to dentry
-	err = v.applyInodeToDEntry(tParentId, tLastName, tInodeInfo.Inode, false,
-		targetPath, tInodeInfo.StorageClass)
+	info.VersionId, err = v.applyInodeToDEntry(tParentId, tLastName, tInodeInfo.Inode, false,
+		targetPath, tInodeInfo.StorageClass, opt.writeCondition())
 	if err != nil {
 		log.LogErrorf("CopyFile: apply inode to new dentry fail: path(%v) parentID(%v) name(%v) inode(%v) err(%v)",
 			targetPath, tParentId, tLastName, tInodeInfo.Inode, err)
 	}
+	if info.VersionId != "" {
+		targetAttr.XAttrs[XAttrKeyOSSVersionId] = info.VersionId
+	}
 
 	// force updating dentry and attrs in cache
 	updateDentryCache(tParentId, tInodeInfo.Inode, DefaultFileMode, tLastName, v.name)
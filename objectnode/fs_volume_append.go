@@ -0,0 +1,484 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/md5"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// appendLockLease is the lease in seconds of the lock held on the object while appending,
+// so that the lock is released even if the ObjectNode fails during the appending.
+const appendLockLease = 300
+
+// appendETagSeparator separates the ETag of the appendable object from its append state, both are
+// kept in the ETag xattr, so that they are changed together by the compare-and-set of the xattr.
+const appendETagSeparator = ";"
+
+var errAppendLockLost = errors.New("append lock lost")
+
+// appendLock is the lock held while appending, the lease is renewed while the data is written.
+// It is taken as lost once it is not renewed in half of the lease, even if it could be renewed,
+// as another appender may have taken and released the lock in between.
+type appendLock struct {
+	v       *Volume
+	inode   uint64
+	lockId  int64
+	renewed time.Time // when the lease was requested last
+}
+
+func (l *appendLock) keep() error {
+	elapsed := time.Since(l.renewed)
+	if elapsed >= appendLockLease*time.Second/2 {
+		log.LogWarnf("appendLock: lease expired: volume(%v) inode(%v) lockId(%v) elapsed(%v)",
+			l.v.name, l.inode, l.lockId, elapsed)
+		return errAppendLockLost
+	}
+	if elapsed < appendLockLease*time.Second/4 {
+		return nil
+	}
+	now := time.Now()
+	if _, err := l.v.mw.LockDir(l.inode, appendLockLease, l.lockId); err != nil {
+		log.LogWarnf("appendLock: renew lease fail: volume(%v) inode(%v) lockId(%v) err(%v)",
+			l.v.name, l.inode, l.lockId, err)
+		return errAppendLockLost
+	}
+	l.renewed = now
+	return nil
+}
+
+// appendLockReader checks the lock after each read, which is right before the data is written.
+type appendLockReader struct {
+	io.Reader
+	lock *appendLock
+}
+
+func (r *appendLockReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	if keepErr := r.lock.keep(); keepErr != nil {
+		return 0, keepErr
+	}
+	return
+}
+
+// newAppendHash restores the MD5 of the data appended before and the length of the data from
+// the encoded state, so the ETag of the appendable object is the MD5 of the whole data without
+// reading it again. The length is the one committed by the last appending, the data beyond it
+// is left by an interrupted appending.
+func newAppendHash(state string) (h hash.Hash, length uint64, err error) {
+	h = md5.New()
+	if state == "" {
+		return h, 0, nil
+	}
+	lengthStr, hashStr, found := strings.Cut(state, ":")
+	if !found {
+		return nil, 0, syscall.EINVAL
+	}
+	if length, err = strconv.ParseUint(lengthStr, 10, 64); err != nil {
+		return nil, 0, err
+	}
+	var data []byte
+	if data, err = base64.StdEncoding.DecodeString(hashStr); err != nil {
+		return nil, 0, err
+	}
+	if err = h.(encoding.BinaryUnmarshaler).UnmarshalBinary(data); err != nil {
+		return nil, 0, err
+	}
+	return h, length, nil
+}
+
+func encodeAppendHash(h hash.Hash, length uint64) (string, error) {
+	data, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(length, 10) + ":" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// encodeAppendETag returns the ETag xattr of the appendable object, which is the encoded ETag
+// followed by the append state.
+func encodeAppendETag(h hash.Hash, length uint64, ts time.Time) (etagValue ETagValue, raw string, err error) {
+	var state string
+	if state, err = encodeAppendHash(h, length); err != nil {
+		return
+	}
+	etagValue = ETagValue{Value: hex.EncodeToString(h.Sum(nil)), TS: ts}
+	return etagValue, etagValue.Encode() + appendETagSeparator + state, nil
+}
+
+// appendStateOf returns the append state in the ETag xattr, which is empty if the object
+// is not appendable.
+func appendStateOf(raw string) string {
+	_, state, _ := strings.Cut(raw, appendETagSeparator)
+	return state
+}
+
+// AppendObject appends the data to the appendable object at the position, which must be equal
+// to the length of the object. The object is created if it does not exist and the position is
+// zero. The appenders of the same object are serialized by the lease lock on the inode in the
+// meta partition, and the concurrent appender is rejected as if its position mismatched.
+//
+// The nextPosition is the length of the object after appending, or the current length
+// if the position mismatched.
+func (v *Volume) AppendObject(path string, position uint64, reader io.Reader, opt *PutFileOption) (
+	fsInfo *FSFileInfo, nextPosition uint64, err error,
+) {
+	defer func() {
+		log.LogInfof("Audit: AppendObject: volume(%v) path(%v) position(%v) nextPosition(%v) err(%v)",
+			v.name, path, position, nextPosition, err)
+	}()
+
+	pathItems := NewPathIterator(path).ToSlice()
+	if len(pathItems) == 0 || pathItems[len(pathItems)-1].IsDirectory {
+		err = syscall.EINVAL
+		return
+	}
+	var parentId uint64
+	if parentId, err = v.recursiveMakeDirectory(path); err != nil {
+		log.LogErrorf("AppendObject: recursive make directory fail: volume(%v) path(%v) err(%v)",
+			v.name, path, err)
+		return
+	}
+	name := pathItems[len(pathItems)-1].Name
+
+	var inode uint64
+	var mode uint32
+	inode, mode, err = v.mw.Lookup_ll(parentId, name)
+	if err != nil && err != syscall.ENOENT {
+		log.LogErrorf("AppendObject: lookup name fail: volume(%v) path(%v) parentID(%v) name(%v) err(%v)",
+			v.name, path, parentId, name, err)
+		return
+	}
+	if err == syscall.ENOENT {
+		if position != 0 {
+			return nil, 0, PositionNotEqualToLength
+		}
+		return v.createAppendableObject(parentId, name, path, reader, opt)
+	}
+	if os.FileMode(mode).IsDir() {
+		log.LogErrorf("AppendObject: the last name is a dir: volume(%v) path(%v) name(%v)", v.name, path, name)
+		err = syscall.EINVAL
+		return
+	}
+	return v.appendToObject(parentId, inode, name, path, position, reader)
+}
+
+// createAppendableObject creates the appendable object with the data, the dentry is created only
+// if the key is still absent, otherwise the object has been created by a concurrent appender.
+func (v *Volume) createAppendableObject(parentId uint64, name, path string, reader io.Reader, opt *PutFileOption) (
+	fsInfo *FSFileInfo, nextPosition uint64, err error,
+) {
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path); err != nil {
+		log.LogErrorf("createAppendableObject: inode create fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			log.LogWarnf("createAppendableObject: unlink and evict inode: volume(%v) path(%v) inode(%v)",
+				v.name, path, inoInfo.Inode)
+			_, _ = v.mw.InodeUnlink_ll(inoInfo.Inode, path)
+			_ = v.mw.Evict(inoInfo.Inode, path)
+		}
+	}()
+
+	if err = v.ec.OpenStream(inoInfo.Inode, true, false, path); err != nil {
+		log.LogErrorf("createAppendableObject: open stream fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inoInfo.Inode, err)
+		return
+	}
+	defer func() {
+		if closeErr := v.ec.CloseStream(inoInfo.Inode); closeErr != nil {
+			log.LogErrorf("createAppendableObject: close stream fail: volume(%v) inode(%v) err(%v)",
+				v.name, inoInfo.Inode, closeErr)
+		}
+	}()
+	h := md5.New()
+	if _, err = v.streamWriteAt(inoInfo.Inode, 0, reader, h, inoInfo.StorageClass); err != nil {
+		log.LogErrorf("createAppendableObject: stream write fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inoInfo.Inode, err)
+		return
+	}
+	if err = v.ec.Flush(inoInfo.Inode); err != nil {
+		log.LogErrorf("createAppendableObject: data flush fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inoInfo.Inode, err)
+		return
+	}
+	var finalInode *proto.InodeInfo
+	if finalInode, err = v.mw.InodeGet_ll(inoInfo.Inode); err != nil {
+		log.LogErrorf("createAppendableObject: get final inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inoInfo.Inode, err)
+		return
+	}
+
+	attr := &AttrItem{
+		XAttrInfo: proto.XAttrInfo{
+			Inode:  finalInode.Inode,
+			XAttrs: make(map[string]string),
+		},
+	}
+	var etagValue ETagValue
+	if etagValue, attr.XAttrs[XAttrKeyOSSETag], err = encodeAppendETag(h, finalInode.Size, finalInode.ModifyTime); err != nil {
+		return
+	}
+	if opt != nil && opt.MIMEType != "" {
+		attr.XAttrs[XAttrKeyOSSMIME] = opt.MIMEType
+	}
+	if opt != nil && len(opt.Disposition) > 0 {
+		attr.XAttrs[XAttrKeyOSSDISPOSITION] = opt.Disposition
+	}
+	if opt != nil && opt.Tagging != nil {
+		attr.XAttrs[XAttrKeyOSSTagging] = opt.Tagging.Encode()
+	}
+	if opt != nil && len(opt.CacheControl) > 0 {
+		attr.XAttrs[XAttrKeyOSSCacheControl] = opt.CacheControl
+	}
+	if opt != nil && len(opt.Expires) > 0 {
+		attr.XAttrs[XAttrKeyOSSExpires] = opt.Expires
+	}
+	if opt != nil && opt.ACL != nil {
+		attr.XAttrs[XAttrKeyOSSACL] = opt.ACL.Encode()
+	}
+	if opt != nil {
+		for key, value := range opt.Metadata {
+			attr.XAttrs[key] = value
+		}
+	}
+	if err = v.mw.BatchSetXAttr_ll(finalInode.Inode, attr.XAttrs); err != nil {
+		log.LogErrorf("createAppendableObject: batch set xattr fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, finalInode.Inode, err)
+		return
+	}
+
+	cond := &WriteCondition{IfNoneMatch: true}
+	if _, err = v.applyInodeToDEntry(parentId, name, finalInode.Inode, false, path, finalInode.StorageClass, cond); err != nil {
+		log.LogErrorf("createAppendableObject: apply inode to dentry fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, finalInode.Inode, err)
+		if err == PreconditionFailed {
+			err = PositionNotEqualToLength
+		}
+		return
+	}
+	updateDentryCache(parentId, finalInode.Inode, DefaultFileMode, name, v.name)
+	putAttrCache(attr, v.name)
+
+	fsInfo = &FSFileInfo{
+		Path:       path,
+		Size:       int64(finalInode.Size),
+		Mode:       os.FileMode(finalInode.Mode),
+		CreateTime: finalInode.CreateTime,
+		ModifyTime: finalInode.ModifyTime,
+		ETag:       etagValue.ETag(),
+		Inode:      finalInode.Inode,
+	}
+	return fsInfo, finalInode.Size, nil
+}
+
+// appendToObject appends the data to the existing appendable object with the lock held. The lease
+// of the lock is kept while the data is written, and the appending stops once it is lost, so the
+// data is never written by two appenders at the same time. The appending is committed by the
+// compare-and-set of the ETag xattr, which keeps both the ETag and the append state, it fails if
+// the xattr is changed by another appender since it is read. The data appended is truncated if
+// the appending is not committed, so the length matches the ETag and the MD5 state.
+func (v *Volume) appendToObject(parentId, inode uint64, name, path string, position uint64, reader io.Reader) (
+	fsInfo *FSFileInfo, nextPosition uint64, err error,
+) {
+	lock := &appendLock{v: v, inode: inode, renewed: time.Now()}
+	if lock.lockId, err = v.mw.LockDir(inode, appendLockLease, 0); err != nil {
+		log.LogWarnf("appendToObject: lock inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		if err == syscall.EEXIST {
+			// the length is changing, the current one is only a hint for the appender
+			if inoInfo, getErr := v.mw.InodeGet_ll(inode); getErr == nil {
+				nextPosition = inoInfo.Size
+			}
+			err = PositionNotEqualToLength
+		}
+		return
+	}
+	defer func() {
+		if err == errAppendLockLost {
+			// the lock may be held by another appender now
+			return
+		}
+		if _, unlockErr := v.mw.LockDir(inode, 0, lock.lockId); unlockErr != nil {
+			log.LogWarnf("appendToObject: unlock inode fail: volume(%v) path(%v) inode(%v) lockId(%v) err(%v)",
+				v.name, path, inode, lock.lockId, unlockErr)
+		}
+	}()
+
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(inode); err != nil {
+		log.LogErrorf("appendToObject: get inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	var xattr *proto.XAttrInfo
+	if xattr, err = v.mw.XAttrGet_ll(inode, XAttrKeyOSSETag); err != nil {
+		log.LogErrorf("appendToObject: get xattr fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	raw := xattr.Get(XAttrKeyOSSETag)
+	state := appendStateOf(string(raw))
+	if len(state) == 0 || proto.IsStorageClassBlobStore(inoInfo.StorageClass) {
+		err = ObjectNotAppendable
+		return
+	}
+	if err = isObjectLocked(v, inode, name, path); err != nil {
+		return
+	}
+	var (
+		h      hash.Hash
+		length uint64
+	)
+	if h, length, err = newAppendHash(state); err != nil {
+		log.LogErrorf("appendToObject: restore hash fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	if inoInfo.Size < length {
+		log.LogErrorf("appendToObject: size less than appended length: volume(%v) path(%v) inode(%v) size(%v) length(%v)",
+			v.name, path, inode, inoInfo.Size, length)
+		err = ObjectNotAppendable
+		return
+	}
+	if nextPosition = length; position != nextPosition {
+		err = PositionNotEqualToLength
+		return
+	}
+
+	if err = v.ec.OpenStream(inode, true, false, path); err != nil {
+		log.LogErrorf("appendToObject: open stream fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	// the data beyond the position is either left by an interrupted appending or written by this
+	// one, it is truncated unless the appending is committed or the lock is lost
+	var truncate bool
+	defer func() {
+		if truncate && lock.keep() == nil {
+			log.LogWarnf("appendToObject: truncate appended data: volume(%v) path(%v) inode(%v) size(%v)",
+				v.name, path, inode, position)
+			if truncErr := v.ec.Truncate(v.mw, parentId, inode, int(position), path); truncErr != nil {
+				log.LogErrorf("appendToObject: truncate fail: volume(%v) path(%v) inode(%v) size(%v) err(%v)",
+					v.name, path, inode, position, truncErr)
+			} else {
+				v.touchAppendETag(inode, path, raw)
+			}
+		}
+		if closeErr := v.ec.CloseStream(inode); closeErr != nil {
+			log.LogErrorf("appendToObject: close stream fail: volume(%v) inode(%v) err(%v)", v.name, inode, closeErr)
+		}
+	}()
+	if inoInfo.Size > position {
+		log.LogWarnf("appendToObject: data of interrupted appending: volume(%v) path(%v) inode(%v) size(%v) length(%v)",
+			v.name, path, inode, inoInfo.Size, length)
+		if err = lock.keep(); err != nil {
+			return
+		}
+		if err = v.ec.Truncate(v.mw, parentId, inode, int(position), path); err != nil {
+			log.LogErrorf("appendToObject: truncate fail: volume(%v) path(%v) inode(%v) size(%v) err(%v)",
+				v.name, path, inode, position, err)
+			return
+		}
+	}
+	truncate = true
+	var size uint64
+	lockReader := &appendLockReader{Reader: reader, lock: lock}
+	if size, err = v.streamWriteAt(inode, int(position), lockReader, h, inoInfo.StorageClass); err != nil {
+		log.LogErrorf("appendToObject: stream write fail: volume(%v) path(%v) inode(%v) position(%v) err(%v)",
+			v.name, path, inode, position, err)
+		return
+	}
+	if err = v.ec.Flush(inode); err != nil {
+		log.LogErrorf("appendToObject: data flush fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	if inoInfo, err = v.mw.InodeGet_ll(inode); err != nil {
+		log.LogErrorf("appendToObject: get final inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+
+	nextPosition = position + size
+	var (
+		etagValue ETagValue
+		newRaw    string
+	)
+	if etagValue, newRaw, err = encodeAppendETag(h, nextPosition, inoInfo.ModifyTime); err != nil {
+		return
+	}
+	// the data flushed is only committed if it is written with the lock held
+	if err = lock.keep(); err != nil {
+		return
+	}
+	if err = v.mw.XAttrCompareAndSet_ll(inode, XAttrKeyOSSETag, raw, []byte(newRaw)); err != nil {
+		log.LogErrorf("appendToObject: commit append fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		if err == syscall.ESTALE {
+			// the object is changed by others, the data is not ours to truncate
+			truncate = false
+			nextPosition = inoInfo.Size
+			err = PositionNotEqualToLength
+		}
+		return
+	}
+	truncate = false
+	updateAttrCache(inode, XAttrKeyOSSETag, newRaw, v.name)
+
+	fsInfo = &FSFileInfo{
+		Path:       path,
+		Size:       int64(nextPosition),
+		Mode:       os.FileMode(inoInfo.Mode),
+		CreateTime: inoInfo.CreateTime,
+		ModifyTime: inoInfo.ModifyTime,
+		ETag:       etagValue.ETag(),
+		Inode:      inode,
+	}
+	return fsInfo, nextPosition, nil
+}
+
+// touchAppendETag updates the time of the ETag after the data not committed is truncated, which
+// changes the modify time of the inode, so the ETag is not taken as outdated and regenerated.
+func (v *Volume) touchAppendETag(inode uint64, path string, raw []byte) {
+	inoInfo, err := v.mw.InodeGet_ll(inode)
+	if err != nil {
+		return
+	}
+	etagValue := ParseETagValue(string(raw))
+	etagValue.TS = inoInfo.ModifyTime
+	newRaw := etagValue.Encode() + appendETagSeparator + appendStateOf(string(raw))
+	if err = v.mw.XAttrCompareAndSet_ll(inode, XAttrKeyOSSETag, raw, []byte(newRaw)); err != nil {
+		log.LogWarnf("touchAppendETag: update etag fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	updateAttrCache(inode, XAttrKeyOSSETag, newRaw, v.name)
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendHash(t *testing.T) {
+	h, length, err := newAppendHash("")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), length)
+	_, _ = h.Write([]byte("hello "))
+	state, err := encodeAppendHash(h, 6)
+	require.NoError(t, err)
+
+	// the MD5 continues from the state of the data appended before
+	h, length, err = newAppendHash(state)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), length)
+	_, _ = h.Write([]byte("world"))
+	sum := md5.Sum([]byte("hello world"))
+	require.Equal(t, hex.EncodeToString(sum[:]), hex.EncodeToString(h.Sum(nil)))
+
+	_, _, err = newAppendHash("invalid state")
+	require.Error(t, err)
+	_, _, err = newAppendHash("8:aW52YWxpZA==")
+	require.Error(t, err)
+	_, _, err = newAppendHash("x:" + state[2:])
+	require.Error(t, err)
+}
+
+func TestAppendETag(t *testing.T) {
+	h, _, err := newAppendHash("")
+	require.NoError(t, err)
+	_, _ = h.Write([]byte("hello"))
+	ts := time.Unix(1700000000, 0)
+	etagValue, raw, err := encodeAppendETag(h, 5, ts)
+	require.NoError(t, err)
+	sum := md5.Sum([]byte("hello"))
+	require.Equal(t, hex.EncodeToString(sum[:]), etagValue.ETag())
+
+	// the ETag is parsed without the append state, which restores the hash
+	parsed := ParseETagValue(raw)
+	require.True(t, parsed.Valid())
+	require.Equal(t, etagValue.ETag(), parsed.ETag())
+	require.Equal(t, ts.Unix(), parsed.TSUnix())
+	state := appendStateOf(raw)
+	require.True(t, strings.HasPrefix(state, "5:"))
+	_, length, err := newAppendHash(state)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), length)
+
+	// the ETag of the object not appendable has no append state
+	require.Equal(t, "", appendStateOf(etagValue.Encode()))
+}
+
+func TestAppendLockExpired(t *testing.T) {
+	lock := &appendLock{v: &Volume{name: "vol"}, inode: 1, lockId: 1, renewed: time.Now()}
+	require.NoError(t, lock.keep())
+
+	// the lock is lost once it is not renewed in half of the lease
+	lock.renewed = time.Now().Add(-appendLockLease * time.Second / 2)
+	require.Equal(t, errAppendLockLost, lock.keep())
+	reader := &appendLockReader{Reader: strings.NewReader("data"), lock: lock}
+	n, err := reader.Read(make([]byte, 4))
+	require.Equal(t, 0, n)
+	require.Equal(t, errAppendLockLost, err)
+}
@@ -26,10 +26,24 @@ type ossMetaLoader interface {
 	loadACL() (p *AccessControlPolicy, err error)
 	loadCORS() (cors *CORSConfiguration, err error)
 	loadObjectLock() (config *ObjectLockConfig, err error)
+	loadVersioning() (config *VersioningConfiguration, err error)
+	loadEncryption() (config *ServerSideEncryptionConfiguration, err error)
+	loadReplication() (config *ReplicationConfiguration, err error)
+	loadWebsite() (config *WebsiteConfiguration, err error)
+	loadNotification() (config *NotificationConfiguration, err error)
+	loadPublicAccessBlock() (config *PublicAccessBlockConfiguration, err error)
+	loadLogging() (status *BucketLoggingStatus, err error)
 	storePolicy(p *Policy)
 	storeACL(p *AccessControlPolicy)
 	storeCORS(cors *CORSConfiguration)
 	storeObjectLock(config *ObjectLockConfig)
+	storeVersioning(config *VersioningConfiguration)
+	storeEncryption(config *ServerSideEncryptionConfiguration)
+	storeReplication(config *ReplicationConfiguration)
+	storeWebsite(config *WebsiteConfiguration)
+	storeNotification(config *NotificationConfiguration)
+	storePublicAccessBlock(config *PublicAccessBlockConfiguration)
+	storeLogging(status *BucketLoggingStatus)
 	setSynced()
 }
 
@@ -50,10 +64,24 @@ type OSSMeta struct {
 	acl        *AccessControlPolicy
 	corsConfig *CORSConfiguration
 	lockConfig *ObjectLockConfig
+	versioning *VersioningConfiguration
+	encryption *ServerSideEncryptionConfiguration
+	repl       *ReplicationConfiguration
+	website    *WebsiteConfiguration
+	notify     *NotificationConfiguration
+	pab        *PublicAccessBlockConfiguration
+	logging    *BucketLoggingStatus
 	policyLock sync.RWMutex
 	aclLock    sync.RWMutex
 	corsLock   sync.RWMutex
 	objectLock sync.RWMutex
+	verLock    sync.RWMutex
+	sseLock    sync.RWMutex
+	replLock   sync.RWMutex
+	webLock    sync.RWMutex
+	notifyLock sync.RWMutex
+	pabLock    sync.RWMutex
+	logLock    sync.RWMutex
 }
 
 func (c *cacheMetaLoader) loadPolicy() (p *Policy, err error) {
@@ -152,6 +180,174 @@ func (c *cacheMetaLoader) storeObjectLock(config *ObjectLockConfig) {
 	c.om.objectLock.Unlock()
 }
 
+func (c *cacheMetaLoader) loadVersioning() (config *VersioningConfiguration, err error) {
+	c.om.verLock.RLock()
+	config = c.om.versioning
+	c.om.verLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSVersioning, func() (interface{}, error) {
+			vc, err := c.sml.loadVersioning()
+			return vc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*VersioningConfiguration)
+		c.storeVersioning(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeVersioning(config *VersioningConfiguration) {
+	c.om.verLock.Lock()
+	c.om.versioning = config
+	c.om.verLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadEncryption() (config *ServerSideEncryptionConfiguration, err error) {
+	c.om.sseLock.RLock()
+	config = c.om.encryption
+	c.om.sseLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSEncryption, func() (interface{}, error) {
+			ec, err := c.sml.loadEncryption()
+			return ec, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*ServerSideEncryptionConfiguration)
+		c.storeEncryption(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeEncryption(config *ServerSideEncryptionConfiguration) {
+	c.om.sseLock.Lock()
+	c.om.encryption = config
+	c.om.sseLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadReplication() (config *ReplicationConfiguration, err error) {
+	c.om.replLock.RLock()
+	config = c.om.repl
+	c.om.replLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSReplication, func() (interface{}, error) {
+			rc, err := c.sml.loadReplication()
+			return rc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*ReplicationConfiguration)
+		c.storeReplication(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeReplication(config *ReplicationConfiguration) {
+	c.om.replLock.Lock()
+	c.om.repl = config
+	c.om.replLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadWebsite() (config *WebsiteConfiguration, err error) {
+	c.om.webLock.RLock()
+	config = c.om.website
+	c.om.webLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSWebsite, func() (interface{}, error) {
+			wc, err := c.sml.loadWebsite()
+			return wc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*WebsiteConfiguration)
+		c.storeWebsite(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeWebsite(config *WebsiteConfiguration) {
+	c.om.webLock.Lock()
+	c.om.website = config
+	c.om.webLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadNotification() (config *NotificationConfiguration, err error) {
+	c.om.notifyLock.RLock()
+	config = c.om.notify
+	c.om.notifyLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSNotification, func() (interface{}, error) {
+			nc, err := c.sml.loadNotification()
+			return nc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*NotificationConfiguration)
+		c.storeNotification(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeNotification(config *NotificationConfiguration) {
+	c.om.notifyLock.Lock()
+	c.om.notify = config
+	c.om.notifyLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadPublicAccessBlock() (config *PublicAccessBlockConfiguration, err error) {
+	c.om.pabLock.RLock()
+	config = c.om.pab
+	c.om.pabLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSPublicBlock, func() (interface{}, error) {
+			pc, err := c.sml.loadPublicAccessBlock()
+			return pc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*PublicAccessBlockConfiguration)
+		c.storePublicAccessBlock(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storePublicAccessBlock(config *PublicAccessBlockConfiguration) {
+	c.om.pabLock.Lock()
+	c.om.pab = config
+	c.om.pabLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadLogging() (status *BucketLoggingStatus, err error) {
+	c.om.logLock.RLock()
+	status = c.om.logging
+	c.om.logLock.RUnlock()
+	if status == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSLogging, func() (interface{}, error) {
+			ls, err := c.sml.loadLogging()
+			return ls, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		status = ret.(*BucketLoggingStatus)
+		c.storeLogging(status)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeLogging(status *BucketLoggingStatus) {
+	c.om.logLock.Lock()
+	c.om.logging = status
+	c.om.logLock.Unlock()
+}
+
 func (c *cacheMetaLoader) setSynced() {
 	atomic.StoreInt32(c.synced, 1)
 }
@@ -188,6 +384,62 @@ func (s *strictMetaLoader) storeObjectLock(cors *ObjectLockConfig) {
 	// do nothing
 }
 
+func (s *strictMetaLoader) loadVersioning() (config *VersioningConfiguration, err error) {
+	return s.v.loadBucketVersioning()
+}
+
+func (s *strictMetaLoader) storeVersioning(config *VersioningConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadEncryption() (config *ServerSideEncryptionConfiguration, err error) {
+	return s.v.loadBucketEncryption()
+}
+
+func (s *strictMetaLoader) storeEncryption(config *ServerSideEncryptionConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadReplication() (config *ReplicationConfiguration, err error) {
+	return s.v.loadBucketReplication()
+}
+
+func (s *strictMetaLoader) storeReplication(config *ReplicationConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadWebsite() (config *WebsiteConfiguration, err error) {
+	return s.v.loadBucketWebsite()
+}
+
+func (s *strictMetaLoader) storeWebsite(config *WebsiteConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadNotification() (config *NotificationConfiguration, err error) {
+	return s.v.loadBucketNotification()
+}
+
+func (s *strictMetaLoader) storeNotification(config *NotificationConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadPublicAccessBlock() (config *PublicAccessBlockConfiguration, err error) {
+	return s.v.loadBucketPublicAccessBlock()
+}
+
+func (s *strictMetaLoader) storePublicAccessBlock(config *PublicAccessBlockConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadLogging() (status *BucketLoggingStatus, err error) {
+	return s.v.loadBucketLogging()
+}
+
+func (s *strictMetaLoader) storeLogging(status *BucketLoggingStatus) {
+	// do nothing
+}
+
 func (s *strictMetaLoader) setSynced() {
 	// do nothing
 }
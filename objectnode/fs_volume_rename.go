@@ -0,0 +1,115 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// RenameObject renames the object or the prefix (the path ends with "/") of srcPath to dstPath
+// in the volume, which is done by the rename of the dentry in the meta partitions, and is
+// atomic if the transaction of rename is enabled on the volume. The data is never copied.
+//
+// The existing target object is overwritten unless "If-None-Match: *" is specified, while the
+// existing target prefix is never overwritten.
+func (v *Volume) RenameObject(srcPath, dstPath string, cond *WriteCondition) (err error) {
+	defer func() {
+		log.LogInfof("Audit: RenameObject: volume(%v) srcPath(%v) dstPath(%v) err(%v)",
+			v.name, srcPath, dstPath, err)
+	}()
+
+	isPrefix := strings.HasSuffix(srcPath, pathSep)
+	if isPrefix != strings.HasSuffix(dstPath, pathSep) || (isPrefix && strings.HasPrefix(dstPath, srcPath)) {
+		err = InvalidRenameSource
+		return
+	}
+	dstItems := NewPathIterator(dstPath).ToSlice()
+	if len(dstItems) == 0 {
+		err = InvalidKey
+		return
+	}
+
+	var (
+		srcParentId, srcIno uint64
+		srcName             string
+		srcMode             os.FileMode
+	)
+	if srcParentId, srcIno, srcName, srcMode, err = v.recursiveLookupTarget(srcPath, true); err != nil {
+		log.LogErrorf("RenameObject: lookup source fail: volume(%v) srcPath(%v) err(%v)", v.name, srcPath, err)
+		if err == syscall.ENOENT {
+			err = NoSuchKey
+		}
+		return
+	}
+	if srcPath == dstPath {
+		return
+	}
+	if !isPrefix {
+		if err = isObjectLocked(v, srcIno, srcName, srcPath); err != nil {
+			return
+		}
+	}
+
+	var dstParentId uint64
+	if dstParentId, err = v.recursiveMakeDirectory(strings.TrimSuffix(dstPath, pathSep)); err != nil {
+		log.LogErrorf("RenameObject: recursive make directory fail: volume(%v) dstPath(%v) err(%v)",
+			v.name, dstPath, err)
+		return
+	}
+	dstName := dstItems[len(dstItems)-1].Name
+
+	overwritten := !isPrefix && (cond == nil || !cond.IfNoneMatch)
+	if overwritten {
+		// the locked target object can not be overwritten
+		dstIno, dstMode, lookupErr := v.mw.Lookup_ll(dstParentId, dstName)
+		if lookupErr != nil && lookupErr != syscall.ENOENT {
+			log.LogErrorf("RenameObject: lookup target fail: volume(%v) dstPath(%v) err(%v)",
+				v.name, dstPath, lookupErr)
+			err = lookupErr
+			return
+		}
+		if lookupErr == nil && !os.FileMode(dstMode).IsDir() {
+			if err = isObjectLocked(v, dstIno, dstName, dstPath); err != nil {
+				return
+			}
+			defer func() {
+				if err == nil {
+					deleteAttrCache(dstIno, v.name)
+				}
+			}()
+		}
+	}
+
+	if err = v.mw.Rename_ll(srcParentId, srcName, dstParentId, dstName, srcPath, dstPath, overwritten); err != nil {
+		log.LogErrorf("RenameObject: rename fail: volume(%v) srcPath(%v) dstPath(%v) overwritten(%v) err(%v)",
+			v.name, srcPath, dstPath, overwritten, err)
+		switch {
+		case err == syscall.ENOENT:
+			err = NoSuchKey
+		case err == syscall.EEXIST && cond != nil && cond.IfNoneMatch:
+			err = PreconditionFailed
+		case err == syscall.EEXIST:
+			err = RenameTargetExists
+		}
+		return
+	}
+	deleteDentryCache(srcParentId, srcName, v.name)
+	updateDentryCache(dstParentId, srcIno, uint32(srcMode), dstName, v.name)
+	return
+}
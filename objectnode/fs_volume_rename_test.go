@@ -0,0 +1,64 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractRenameSourceKey(t *testing.T) {
+	tests := []struct {
+		source string
+		key    string
+		err    error
+	}{
+		{"/bucket/a/b.txt", "a/b.txt", nil},
+		{"bucket/a/b.txt", "a/b.txt", nil},
+		{"bucket/a%2Fb%20c.txt", "a/b c.txt", nil},
+		{"bucket/dir/", "dir/", nil},
+		{"/other/a.txt", "", InvalidRenameSource},
+		{"bucket", "", InvalidArgument},
+		{"bucket/", "", InvalidArgument},
+		{"", "", InvalidArgument},
+		{"bucket/%zz", "", InvalidArgument},
+	}
+	for _, tt := range tests {
+		r, err := http.NewRequest(http.MethodPut, "http://bucket.s3.local/dst?renameObject", nil)
+		require.NoError(t, err)
+		r.Header.Set(XAmzRenameSource, tt.source)
+		key, err := extractRenameSourceKey(r, "bucket")
+		require.Equal(t, tt.err, err, tt.source)
+		require.Equal(t, tt.key, key, tt.source)
+	}
+}
+
+func TestRenameObjectInvalidTarget(t *testing.T) {
+	v := &Volume{name: "bucket"}
+	// the kind of the source and the target must be the same
+	require.Equal(t, InvalidRenameSource, v.RenameObject("a/b.txt", "a/c/", nil))
+	require.Equal(t, InvalidRenameSource, v.RenameObject("a/b/", "a/c.txt", nil))
+	// the prefix can not be renamed into itself
+	require.Equal(t, InvalidRenameSource, v.RenameObject("a/b/", "a/b/c/", nil))
+	require.Equal(t, InvalidRenameSource, v.RenameObject("a/b/", "a/b/", nil))
+}
+
+func TestRenameObjectPolicyActions(t *testing.T) {
+	require.True(t, objectApiList.Contain(RENAME_OBJECT))
+	require.Contains(t, S3ActionToApis[ACTION_PUT_OBJECT], RENAME_OBJECT)
+	require.NotContains(t, S3ActionToApis[ACTION_DELETE_OBJECT], RENAME_OBJECT)
+}
@@ -0,0 +1,900 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"errors"
+	"os"
+	os_path "path"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Object versioning is built on top of the POSIX namespace of the volume:
+//
+//  1. The current version of an object key is the inode linked by the dentry, as for unversioned buckets.
+//  2. Non-current versions and delete markers of a key are recorded in the xattr "oss:versions:<name>"
+//     of the parent directory, newest first. The data of a non-current version stays in its inode,
+//     which keeps one link but is no longer referenced by any dentry.
+//  3. The version id of an inode is kept in its xattr "oss:version-id". Inodes without this xattr
+//     were written before versioning was enabled and are treated as the "null" version.
+//  4. The version list is updated by the compare-and-set of the xattr on the metanode, the update
+//     is retried with the list reloaded if it is modified by another request concurrently.
+
+const (
+	// maxObjectVersions caps the versions kept in the xattr of one object key, the writes of the key are
+	// rejected once it is reached until the noncurrent versions are deleted or expired.
+	maxObjectVersions = 1000
+	// the retries of updating the version list modified by other requests concurrently
+	maxVersionsUpdateRetry = 32
+)
+
+// errVersionsNotChanged is returned by the update of the version list which changes nothing.
+var errVersionsNotChanged = errors.New("versions not changed")
+
+type DeleteVersionResult struct {
+	VersionId    string
+	DeleteMarker bool
+}
+
+type ListVersionsOption struct {
+	Prefix          string
+	Delimiter       string
+	KeyMarker       string
+	VersionIdMarker string
+	MaxKeys         uint64
+}
+
+type ListVersionsOutput struct {
+	Versions            []*FSVersionInfo
+	CommonPrefixes      []string
+	Truncated           bool
+	NextKeyMarker       string
+	NextVersionIdMarker string
+}
+
+type FSVersionInfo struct {
+	Key          string
+	VersionId    string
+	IsLatest     bool
+	DeleteMarker bool
+	ETag         string
+	Size         int64
+	ModifyTime   time.Time
+}
+
+func (v *Volume) loadObjectVersions(parentId uint64, name string) (versions ObjectVersions, err error) {
+	key := versionsXAttrKey(name)
+	var info *proto.XAttrInfo
+	if info, err = v.mw.XAttrGet_ll(parentId, key); err != nil {
+		log.LogErrorf("loadObjectVersions: meta get xattr fail: volume(%v) parentID(%v) name(%v) err(%v)",
+			v.name, parentId, name, err)
+		return
+	}
+	if versions, err = ParseObjectVersions(info.Get(key)); err != nil {
+		log.LogErrorf("loadObjectVersions: parse versions fail: volume(%v) parentID(%v) name(%v) err(%v)",
+			v.name, parentId, name, err)
+	}
+	return
+}
+
+// updateObjectVersions applies the update to the version list of the key, which is stored only if
+// the list is not modified since it was loaded. The update is applied again to the list reloaded
+// otherwise, so it must not have side effects.
+func (v *Volume) updateObjectVersions(parentId uint64, name string, update func(versions ObjectVersions) (ObjectVersions, error)) (err error) {
+	key := versionsXAttrKey(name)
+	for i := 0; i < maxVersionsUpdateRetry; i++ {
+		var info *proto.XAttrInfo
+		if info, err = v.mw.XAttrGet_ll(parentId, key); err != nil {
+			log.LogErrorf("updateObjectVersions: meta get xattr fail: volume(%v) parentID(%v) name(%v) err(%v)",
+				v.name, parentId, name, err)
+			return
+		}
+		raw := info.Get(key)
+		var versions ObjectVersions
+		if versions, err = ParseObjectVersions(raw); err != nil {
+			log.LogErrorf("updateObjectVersions: parse versions fail: volume(%v) parentID(%v) name(%v) err(%v)",
+				v.name, parentId, name, err)
+			return
+		}
+		if versions, err = update(versions); err != nil {
+			if err == errVersionsNotChanged {
+				err = nil
+			}
+			return
+		}
+		var value []byte
+		if len(versions) > 0 {
+			value = []byte(versions.Encode())
+		}
+		if err = v.mw.XAttrCompareAndSet_ll(parentId, key, raw, value); err != syscall.ESTALE {
+			if err != nil {
+				log.LogErrorf("updateObjectVersions: meta store versions fail: volume(%v) parentID(%v) name(%v) err(%v)",
+					v.name, parentId, name, err)
+			}
+			return
+		}
+		log.LogDebugf("updateObjectVersions: versions modified concurrently: volume(%v) parentID(%v) name(%v) retry(%v)",
+			v.name, parentId, name, i)
+	}
+	log.LogErrorf("updateObjectVersions: versions modified concurrently too many times: volume(%v) parentID(%v) name(%v)",
+		v.name, parentId, name)
+	return
+}
+
+// checkVersionsLimit rejects the write of the key whose version list is full before anything is
+// changed, the limit is enforced again when the version list is updated.
+func (v *Volume) checkVersionsLimit(parentId uint64, name string) (err error) {
+	var versions ObjectVersions
+	if versions, err = v.loadObjectVersions(parentId, name); err != nil {
+		return
+	}
+	if len(versions) >= maxObjectVersions {
+		log.LogWarnf("checkVersionsLimit: too many versions: volume(%v) parentID(%v) name(%v) versions(%v)",
+			v.name, parentId, name, len(versions))
+		err = TooManyObjectVersions
+	}
+	return
+}
+
+// loadObjectVersion builds the version record of the specified inode.
+func (v *Volume) loadObjectVersion(inode uint64) (version *ObjectVersion, err error) {
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(inode); err != nil {
+		log.LogErrorf("loadObjectVersion: meta get inode fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+		return
+	}
+	var xattrs []*proto.XAttrInfo
+	keys := []string{XAttrKeyOSSVersionId, XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated}
+	if xattrs, err = v.mw.BatchGetXAttr([]uint64{inode}, keys); err != nil {
+		log.LogErrorf("loadObjectVersion: meta get xattr fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+		return
+	}
+	version = &ObjectVersion{
+		VersionId:  NullVersionId,
+		Inode:      inode,
+		Size:       int64(inoInfo.Size),
+		ModifyTime: inoInfo.ModifyTime.UnixNano(),
+	}
+	if len(xattrs) > 0 && xattrs[0].Inode == inode {
+		if vid := xattrs[0].Get(XAttrKeyOSSVersionId); len(vid) > 0 {
+			version.VersionId = string(vid)
+		}
+		rawETag := string(xattrs[0].Get(XAttrKeyOSSETag))
+		if len(rawETag) == 0 {
+			rawETag = string(xattrs[0].Get(XAttrKeyOSSETagDeprecated))
+		}
+		if len(rawETag) > 0 {
+			version.ETag = ParseETagValue(rawETag).ETag()
+		}
+	}
+	return
+}
+
+// purgeVersionInode removes the data of a version which is no longer referenced.
+func (v *Volume) purgeVersionInode(inode uint64, fullPath string) {
+	v.deleteRestoredCopy(inode, fullPath)
+	log.LogInfof("purgeVersionInode: unlink inode: volume(%v) path(%v) inode(%v)", v.name, fullPath, inode)
+	if _, err := v.mw.InodeUnlink_ll(inode, fullPath); err != nil {
+		log.LogWarnf("purgeVersionInode: unlink inode fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+	}
+	if err := v.ec.EvictStream(inode); err != nil {
+		log.LogWarnf("purgeVersionInode: evict stream fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+	}
+	if err := v.mw.Evict(inode, fullPath); err != nil {
+		log.LogWarnf("purgeVersionInode: evict inode fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+	}
+}
+
+// archiveVersion inserts the given versions into the version list of the key by the modify time.
+// If versioning is suspended, the existing "null" version is overwritten by the new one,
+// so it is removed from the list permanently. If limited, the list growing beyond the limit
+// is rejected with nothing changed.
+func (v *Volume) archiveVersion(parentId uint64, name, fullPath string, suspended, limited bool,
+	archived ...*ObjectVersion,
+) (err error) {
+	var purged []*ObjectVersion
+	err = v.updateObjectVersions(parentId, name, func(versions ObjectVersions) (ObjectVersions, error) {
+		purged = purged[:0]
+		count := len(versions)
+		if suspended {
+			if index, version := versions.Find(NullVersionId); version != nil {
+				versions = versions.Remove(index)
+				if !version.DeleteMarker {
+					purged = append(purged, version)
+				}
+			}
+		}
+		for _, version := range archived {
+			if suspended && version.VersionId == NullVersionId && !version.DeleteMarker {
+				purged = append(purged, version)
+				continue
+			}
+			versions = versions.Insert(version)
+		}
+		if limited && len(versions) > count && len(versions) > maxObjectVersions {
+			log.LogWarnf("archiveVersion: too many versions: volume(%v) parentID(%v) name(%v) versions(%v)",
+				v.name, parentId, name, count)
+			return nil, TooManyObjectVersions
+		}
+		return versions, nil
+	})
+	if err != nil {
+		return
+	}
+	for _, version := range purged {
+		v.purgeVersionInode(version.Inode, fullPath)
+	}
+	return
+}
+
+// applyInodeToVersionedDEntry makes the inode the current version of the object key,
+// the previous current version is kept as a non-current version.
+func (v *Volume) applyInodeToVersionedDEntry(parentId uint64, name string, inode uint64, isCompleteMultipart bool,
+	fullPath string, storageClass uint32, config *VersioningConfiguration, cond *WriteCondition,
+) (versionId string, err error) {
+	versionId = NullVersionId
+	if config.Enabled() {
+		versionId = newVersionId()
+	}
+	if err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSVersionId), []byte(versionId)); err != nil {
+		log.LogErrorf("applyInodeToVersionedDEntry: meta set version id fail: volume(%v) inode(%v) err(%v)",
+			v.name, inode, err)
+		return
+	}
+
+	if err = v.checkVersionsLimit(parentId, name); err != nil {
+		return
+	}
+
+	var existInode uint64
+	var existMode uint32
+	existInode, existMode, err = v.mw.Lookup_ll(parentId, name)
+	if err != nil && err != syscall.ENOENT {
+		log.LogErrorf("applyInodeToVersionedDEntry: meta lookup fail: parentID(%v) name(%v) err(%v)",
+			parentId, name, err)
+		return
+	}
+	if err == syscall.ENOENT {
+		// the key whose current version is a delete marker is absent for the condition
+		if _, err = v.checkWriteCondition(cond, 0); err != nil {
+			return
+		}
+		if err = v.applyInodeToNewDentry(parentId, name, inode, fullPath); err != nil {
+			err = writeConditionErr(cond, err)
+			return
+		}
+		if config.Suspended() {
+			err = v.archiveVersion(parentId, name, fullPath, true, true)
+		}
+		return
+	}
+	if os.FileMode(existMode).IsDir() {
+		log.LogErrorf("applyInodeToVersionedDEntry: target mode conflict: parentID(%v) name(%v) mode(%v)",
+			parentId, name, os.FileMode(existMode).String())
+		err = syscall.EINVAL
+		return
+	}
+
+	var expectedInode uint64
+	if expectedInode, err = v.checkWriteCondition(cond, existInode); err != nil {
+		return
+	}
+	// the version of the current object is loaded before it is replaced, so that it is archived
+	// once it is replaced
+	var old *ObjectVersion
+	if old, err = v.loadObjectVersion(existInode); err != nil {
+		return
+	}
+
+	var oldInode uint64
+	if expectedInode != 0 {
+		err = v.mw.DentryCompareAndUpdate_ll(parentId, name, inode, expectedInode, fullPath)
+		oldInode = expectedInode
+	} else {
+		oldInode, err = v.mw.DentryUpdate_ll(parentId, name, inode, fullPath)
+	}
+	if err != nil {
+		log.LogErrorf("applyInodeToVersionedDEntry: meta update dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
+			parentId, name, inode, err)
+		err = writeConditionErr(cond, err)
+		return
+	}
+	if oldInode == 0 {
+		log.LogWarnf("applyInodeToVersionedDEntry: dentry update the same inode: inode(%v)", inode)
+		return
+	}
+	// the previous current version is restored if it fails to be archived, so it is never left
+	// unreferenced
+	defer func() {
+		if err != nil && v.restoreReplacedVersion(parentId, name, inode, oldInode, old, fullPath, config.Suspended()) {
+			// the object is written anyway
+			err = nil
+		}
+	}()
+	if oldInode != old.Inode {
+		// the dentry is changed concurrently after it was looked up
+		if old, err = v.loadObjectVersion(oldInode); err != nil {
+			return
+		}
+	}
+	// concurrent completeMultipart request: temporary data security check
+	if isCompleteMultipart {
+		var isSameExtent bool
+		if isSameExtent, err = v.referenceExtentKey(oldInode, inode, storageClass); err != nil || isSameExtent {
+			return
+		}
+	}
+	err = v.archiveVersion(parentId, name, fullPath, config.Suspended(), true, old)
+	return
+}
+
+// restoreReplacedVersion makes the replaced inode current again after its version fails to be
+// archived. If the dentry is changed concurrently, the version is archived regardless of the limit
+// of the versions, and it returns true as the object is written.
+func (v *Volume) restoreReplacedVersion(parentId uint64, name string, inode, oldInode uint64, old *ObjectVersion,
+	fullPath string, suspended bool,
+) (written bool) {
+	err := v.mw.DentryCompareAndUpdate_ll(parentId, name, oldInode, inode, fullPath)
+	if err == nil {
+		log.LogWarnf("restoreReplacedVersion: version restored: volume(%v) parentID(%v) name(%v) inode(%v) old(%v)",
+			v.name, parentId, name, inode, oldInode)
+		deleteDentryCache(parentId, name, v.name)
+		return false
+	}
+	log.LogWarnf("restoreReplacedVersion: restore dentry fail: volume(%v) parentID(%v) name(%v) inode(%v) old(%v) err(%v)",
+		v.name, parentId, name, inode, oldInode, err)
+	if old == nil || old.Inode != oldInode {
+		if old, err = v.loadObjectVersion(oldInode); err != nil {
+			log.LogErrorf("restoreReplacedVersion: load version fail, the version is lost: volume(%v) parentID(%v) name(%v) inode(%v) err(%v)",
+				v.name, parentId, name, oldInode, err)
+			return true
+		}
+	}
+	if err = v.archiveVersion(parentId, name, fullPath, suspended, false, old); err != nil {
+		log.LogErrorf("restoreReplacedVersion: archive version fail, the version is lost: volume(%v) parentID(%v) name(%v) version(%+v) err(%v)",
+			v.name, parentId, name, old, err)
+	}
+	return true
+}
+
+// DeleteObject deletes the object in the way matching the versioning state of the bucket.
+func (v *Volume) DeleteObject(path, versionId string) (result *DeleteVersionResult, err error) {
+	var versioning *VersioningConfiguration
+	if versioning, err = v.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("DeleteObject: load versioning fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if versioning.Configured() {
+		return v.DeleteVersionedPath(path, versionId, versioning)
+	}
+	// objects in an unversioned bucket only have the "null" version
+	if versionId != "" && versionId != NullVersionId {
+		return &DeleteVersionResult{VersionId: versionId}, nil
+	}
+	if err = v.DeletePath(path); err != nil {
+		return
+	}
+	return &DeleteVersionResult{VersionId: versionId}, nil
+}
+
+// DeleteVersionedPath deletes the object from a bucket which has versioning configured.
+//
+// Without versionId, a delete marker becomes the current version of the key and the
+// previous current version is kept. With versionId, the specified version is removed
+// permanently, and the newest remaining version takes over if it was the current one.
+func (v *Volume) DeleteVersionedPath(path, versionId string, config *VersioningConfiguration) (result *DeleteVersionResult, err error) {
+	defer func() {
+		log.LogInfof("Audit: DeleteVersionedPath: volume(%v) path(%v) versionId(%v) result(%+v) err(%v)",
+			v.name, path, versionId, result, err)
+	}()
+
+	if strings.HasSuffix(path, pathSep) {
+		// directories are not versioned
+		return &DeleteVersionResult{}, v.DeletePath(path)
+	}
+	dirs, name := splitPath(path)
+	var parentId uint64
+	if parentId, err = v.lookupDirectories(dirs, false); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+			if versionId == "" {
+				result = &DeleteVersionResult{}
+			} else {
+				result = &DeleteVersionResult{VersionId: versionId}
+			}
+		}
+		return
+	}
+
+	if versionId == "" {
+		result, err = v.putDeleteMarker(parentId, name, path, config)
+	} else {
+		result, err = v.deleteObjectVersion(parentId, name, path, versionId)
+	}
+	if err != nil {
+		return
+	}
+
+	// recursive deletion of empty directory
+	if os_path.Dir(path) != "." {
+		if err = v.DeletePath(os_path.Dir(path) + pathSep); err != nil {
+			log.LogErrorf("DeleteVersionedPath: delete parent path fail: path(%v) err(%v)", path, err)
+		}
+	}
+	return
+}
+
+func (v *Volume) putDeleteMarker(parentId uint64, name, path string, config *VersioningConfiguration) (result *DeleteVersionResult, err error) {
+	marker := &ObjectVersion{
+		VersionId:    NullVersionId,
+		DeleteMarker: true,
+		ModifyTime:   time.Now().UnixNano(),
+	}
+	if config.Enabled() {
+		marker.VersionId = newVersionId()
+	}
+	if err = v.checkVersionsLimit(parentId, name); err != nil {
+		return
+	}
+
+	var (
+		ino  uint64
+		mode uint32
+	)
+	ino, mode, err = v.mw.Lookup_ll(parentId, name)
+	if err != nil && err != syscall.ENOENT {
+		log.LogErrorf("putDeleteMarker: meta lookup fail: parentID(%v) name(%v) err(%v)", parentId, name, err)
+		return
+	}
+	archived := make([]*ObjectVersion, 0, 2)
+	archived = append(archived, marker)
+	var current *ObjectVersion
+	if err == nil {
+		if os.FileMode(mode).IsDir() {
+			return &DeleteVersionResult{}, v.DeletePath(path + pathSep)
+		}
+		if current, err = v.loadObjectVersion(ino); err != nil {
+			return
+		}
+		// keep one more link so the data survives the removal of the dentry
+		if _, err = v.mw.InodeLink_ll(ino, path); err != nil {
+			log.LogErrorf("putDeleteMarker: meta link inode fail: volume(%v) inode(%v) err(%v)", v.name, ino, err)
+			return
+		}
+		if _, err = v.mw.Delete_ll(parentId, name, false, path); err != nil {
+			log.LogErrorf("putDeleteMarker: meta delete dentry fail: parentID(%v) name(%v) err(%v)",
+				parentId, name, err)
+			if _, unlinkErr := v.mw.InodeUnlink_ll(ino, path); unlinkErr != nil {
+				log.LogWarnf("putDeleteMarker: unlink inode fail: volume(%v) inode(%v) err(%v)", v.name, ino, unlinkErr)
+			}
+			return
+		}
+		deleteDentryCache(parentId, name, v.name)
+		deleteAttrCache(ino, v.name)
+		archived = append(archived, current)
+	} else {
+		var versions ObjectVersions
+		if versions, err = v.loadObjectVersions(parentId, name); err != nil {
+			return
+		}
+		// the key has never been written, nothing to hide
+		if len(versions) == 0 {
+			return &DeleteVersionResult{}, nil
+		}
+	}
+	if err = v.archiveVersion(parentId, name, path, config.Suspended(), true, archived...); err != nil {
+		if current != nil {
+			v.restoreDeletedVersion(parentId, name, path, mode, current, config.Suspended())
+		}
+		return
+	}
+	return &DeleteVersionResult{VersionId: marker.VersionId, DeleteMarker: true}, nil
+}
+
+// restoreDeletedVersion makes the current version removed by the delete marker current again after
+// it fails to be archived. If the key is written concurrently, the version is archived regardless of
+// the limit of the versions.
+func (v *Volume) restoreDeletedVersion(parentId uint64, name, path string, mode uint32, current *ObjectVersion,
+	suspended bool,
+) {
+	err := v.mw.DentryCreate_ll(parentId, name, current.Inode, mode, path)
+	if err == nil {
+		log.LogWarnf("restoreDeletedVersion: version restored: volume(%v) parentID(%v) name(%v) inode(%v)",
+			v.name, parentId, name, current.Inode)
+		// drop the link kept for the non-current version
+		if _, err = v.mw.InodeUnlink_ll(current.Inode, path); err != nil {
+			log.LogWarnf("restoreDeletedVersion: unlink inode fail: volume(%v) inode(%v) err(%v)",
+				v.name, current.Inode, err)
+		}
+		return
+	}
+	log.LogWarnf("restoreDeletedVersion: restore dentry fail: volume(%v) parentID(%v) name(%v) inode(%v) err(%v)",
+		v.name, parentId, name, current.Inode, err)
+	if err = v.archiveVersion(parentId, name, path, suspended, false, current); err != nil {
+		log.LogErrorf("restoreDeletedVersion: archive version fail, the version is lost: volume(%v) parentID(%v) name(%v) version(%+v) err(%v)",
+			v.name, parentId, name, current, err)
+	}
+}
+
+func (v *Volume) deleteObjectVersion(parentId uint64, name, path, versionId string) (result *DeleteVersionResult, err error) {
+	result = &DeleteVersionResult{VersionId: versionId}
+
+	var (
+		ino  uint64
+		mode uint32
+	)
+	ino, mode, err = v.mw.Lookup_ll(parentId, name)
+	if err != nil && err != syscall.ENOENT {
+		log.LogErrorf("deleteObjectVersion: meta lookup fail: parentID(%v) name(%v) err(%v)", parentId, name, err)
+		return
+	}
+	hasCurrent := err == nil && !os.FileMode(mode).IsDir()
+	err = nil
+
+	var current *ObjectVersion
+	if hasCurrent {
+		if current, err = v.loadObjectVersion(ino); err != nil {
+			return
+		}
+	}
+
+	if current != nil && current.VersionId == versionId {
+		// check whether object is protected by object lock
+		if err = isObjectLocked(v, ino, name, path); err != nil {
+			return
+		}
+		if _, err = v.mw.DeleteWithCond_ll(parentId, ino, name, false, path); err != nil {
+			log.LogErrorf("deleteObjectVersion: meta delete dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
+				parentId, name, ino, err)
+			return
+		}
+		v.deleteRestoredCopy(ino, path)
+		if err = v.ec.EvictStream(ino); err != nil {
+			log.LogWarnf("deleteObjectVersion: evict stream fail: path(%v) inode(%v) err(%v)", path, ino, err)
+		}
+		if err = v.mw.Evict(ino, path); err != nil {
+			log.LogWarnf("deleteObjectVersion: evict inode fail: path(%v) inode(%v) err(%v)", path, ino, err)
+		}
+		err = nil
+		deleteDentryCache(parentId, name, v.name)
+		deleteAttrCache(ino, v.name)
+		hasCurrent = false
+	} else {
+		var removed *ObjectVersion
+		err = v.updateObjectVersions(parentId, name, func(versions ObjectVersions) (ObjectVersions, error) {
+			index, version := versions.Find(versionId)
+			if removed = version; version == nil {
+				// deleting a version which does not exist is a success
+				return nil, errVersionsNotChanged
+			}
+			if !version.DeleteMarker {
+				if err := isObjectLocked(v, version.Inode, name, path); err != nil {
+					return nil, err
+				}
+			}
+			return versions.Remove(index), nil
+		})
+		if err != nil || removed == nil {
+			return
+		}
+		if removed.DeleteMarker {
+			result.DeleteMarker = true
+		} else {
+			v.purgeVersionInode(removed.Inode, path)
+		}
+	}
+
+	if !hasCurrent {
+		err = v.promoteNewestVersion(parentId, name, path)
+	}
+	return
+}
+
+// promoteNewestVersion makes the newest noncurrent version the current one if it is not a delete marker.
+// The dentry is created before the version is removed from the list, so the version written concurrently
+// is never overwritten by the promoted one.
+func (v *Volume) promoteNewestVersion(parentId uint64, name, path string) (err error) {
+	var versions ObjectVersions
+	if versions, err = v.loadObjectVersions(parentId, name); err != nil {
+		return
+	}
+	if len(versions) == 0 || versions[0].DeleteMarker {
+		return
+	}
+	promoted := versions[0]
+	if err = v.mw.DentryCreate_ll(parentId, name, promoted.Inode, DefaultFileMode, path); err != nil {
+		if err == syscall.EEXIST {
+			// the key is written or promoted by another request
+			return nil
+		}
+		log.LogErrorf("promoteNewestVersion: meta create dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
+			parentId, name, promoted.Inode, err)
+		return
+	}
+	updateDentryCache(parentId, promoted.Inode, DefaultFileMode, name, v.name)
+	return v.updateObjectVersions(parentId, name, func(versions ObjectVersions) (ObjectVersions, error) {
+		index, version := versions.Find(promoted.VersionId)
+		if version == nil || version.Inode != promoted.Inode {
+			return nil, errVersionsNotChanged
+		}
+		return versions.Remove(index), nil
+	})
+}
+
+// ObjectVersionMeta returns the meta of the specified version of the object.
+// MethodNotAllowed is returned if the version is a delete marker.
+func (v *Volume) ObjectVersionMeta(path, versionId string) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
+	if versionId == "" {
+		return v.ObjectMeta(path)
+	}
+	var (
+		inode uint64
+		mode  os.FileMode
+	)
+	if inode, mode, err = v.lookupVersionInode(path, versionId); err != nil {
+		return
+	}
+	return v.inodeMeta(path, inode, mode)
+}
+
+// CurrentDeleteMarker returns the version id of the delete marker if it is the current version of the
+// object which is absent.
+func (v *Volume) CurrentDeleteMarker(path string) (versionId string, ok bool) {
+	dirs, name := splitPath(path)
+	parentId, err := v.lookupDirectories(dirs, false)
+	if err != nil {
+		return
+	}
+	versions, err := v.loadObjectVersions(parentId, name)
+	if err != nil || len(versions) == 0 || !versions[0].DeleteMarker {
+		return
+	}
+	return versions[0].VersionId, true
+}
+
+// lookupVersionInode finds the inode which keeps the data of the specified version of the object.
+func (v *Volume) lookupVersionInode(path, versionId string) (inode uint64, mode os.FileMode, err error) {
+	dirs, name := splitPath(path)
+	var parentId uint64
+	if parentId, err = v.lookupDirectories(dirs, false); err != nil {
+		if err == syscall.ENOENT {
+			err = NoSuchVersion
+		}
+		return
+	}
+
+	var curMode uint32
+	inode, curMode, err = v.mw.Lookup_ll(parentId, name)
+	if err != nil && err != syscall.ENOENT {
+		log.LogErrorf("lookupVersionInode: meta lookup fail: parentID(%v) name(%v) err(%v)", parentId, name, err)
+		return
+	}
+	if err == nil && !os.FileMode(curMode).IsDir() {
+		var current *ObjectVersion
+		if current, err = v.loadObjectVersion(inode); err != nil {
+			return
+		}
+		if current.VersionId == versionId {
+			return inode, os.FileMode(curMode), nil
+		}
+	}
+
+	var versions ObjectVersions
+	if versions, err = v.loadObjectVersions(parentId, name); err != nil {
+		return
+	}
+	_, version := versions.Find(versionId)
+	if version == nil {
+		err = NoSuchVersion
+		return
+	}
+	if version.DeleteMarker {
+		err = MethodNotAllowed
+		return
+	}
+	return version.Inode, DefaultFileMode, nil
+}
+
+func (v *Volume) inodeMeta(path string, inode uint64, mode os.FileMode) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(inode); err != nil {
+		log.LogErrorf("inodeMeta: get inode fail: volume(%v) path(%v) inode(%v) err(%v)", v.name, path, inode, err)
+		return
+	}
+	return v.buildObjectMeta(path, mode, inoInfo)
+}
+
+// versionsWalker walks the namespace in the lexicographical order of object keys
+// and collects the versions of each key.
+type versionsWalker struct {
+	v       *Volume
+	opt     *ListVersionsOption
+	output  *ListVersionsOutput
+	count   uint64
+	prefix  string // the last common prefix
+	stopped bool
+}
+
+func (v *Volume) ListObjectVersions(opt *ListVersionsOption) (output *ListVersionsOutput, err error) {
+	w := &versionsWalker{
+		v:      v,
+		opt:    opt,
+		output: &ListVersionsOutput{},
+	}
+	if opt.MaxKeys == 0 {
+		return w.output, nil
+	}
+	if err = w.walk(proto.RootIno, ""); err != nil {
+		log.LogErrorf("ListObjectVersions: walk fail: volume(%v) option(%+v) err(%v)", v.name, opt, err)
+		return
+	}
+	return w.output, nil
+}
+
+// accept returns false if no more entries can be added.
+func (w *versionsWalker) accept() bool {
+	if w.count >= w.opt.MaxKeys {
+		w.output.Truncated = true
+		w.stopped = true
+		return false
+	}
+	w.count++
+	return true
+}
+
+func (w *versionsWalker) walk(dirIno uint64, dirPath string) (err error) {
+	var dentries []proto.Dentry
+	if dentries, err = w.v.mw.ReadDir_ll(dirIno); err != nil {
+		return
+	}
+	var xattrKeys []string
+	if xattrKeys, err = w.v.mw.XAttrsList_ll(dirIno); err != nil {
+		return
+	}
+
+	entries := make(map[string]*proto.Dentry)
+	for i := range dentries {
+		dentry := &dentries[i]
+		if os.FileMode(dentry.Type).IsDir() {
+			entries[dentry.Name+pathSep] = dentry
+		} else {
+			entries[dentry.Name] = dentry
+		}
+	}
+	for _, key := range xattrKeys {
+		if name := strings.TrimPrefix(key, XAttrKeyOSSVersions); name != key {
+			if _, has := entries[name]; !has {
+				entries[name] = nil
+			}
+		}
+	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if w.stopped {
+			return
+		}
+		key := dirPath + name
+		// skip the entries which can not match the prefix
+		if !strings.HasPrefix(key, w.opt.Prefix) && !strings.HasPrefix(w.opt.Prefix, key) {
+			continue
+		}
+		// skip the entries before the marker
+		if key < w.opt.KeyMarker && !strings.HasPrefix(w.opt.KeyMarker, key) {
+			continue
+		}
+
+		dentry := entries[name]
+		isDir := dentry != nil && os.FileMode(dentry.Type).IsDir()
+		if w.opt.Delimiter != "" && strings.HasPrefix(key, w.opt.Prefix) {
+			rest := strings.TrimPrefix(key, w.opt.Prefix)
+			if index := strings.Index(rest, w.opt.Delimiter); index >= 0 {
+				commonPrefix := w.opt.Prefix + rest[:index+len(w.opt.Delimiter)]
+				if commonPrefix != w.prefix && commonPrefix > w.opt.KeyMarker {
+					if !w.accept() {
+						return
+					}
+					w.prefix = commonPrefix
+					w.output.CommonPrefixes = append(w.output.CommonPrefixes, commonPrefix)
+					w.output.NextKeyMarker = commonPrefix
+					w.output.NextVersionIdMarker = ""
+				}
+				continue
+			}
+		}
+		if isDir {
+			if err = w.walk(dentry.Inode, key); err != nil {
+				return
+			}
+			continue
+		}
+		if !strings.HasPrefix(key, w.opt.Prefix) || key < w.opt.KeyMarker {
+			continue
+		}
+		if err = w.collect(dirIno, name, key, dentry); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (w *versionsWalker) collect(parentId uint64, name, key string, dentry *proto.Dentry) (err error) {
+	versions := make(ObjectVersions, 0)
+	if dentry != nil {
+		var current *ObjectVersion
+		if current, err = w.v.loadObjectVersion(dentry.Inode); err != nil {
+			if err == syscall.ENOENT {
+				err = nil
+			}
+			return
+		}
+		versions = append(versions, current)
+	}
+	var nonCurrent ObjectVersions
+	if nonCurrent, err = w.v.loadObjectVersions(parentId, name); err != nil {
+		return
+	}
+	versions = append(versions, nonCurrent...)
+
+	skip := key == w.opt.KeyMarker
+	for i, version := range versions {
+		if skip {
+			// skip the versions up to and including the version id marker
+			if w.opt.VersionIdMarker != "" && version.VersionId == w.opt.VersionIdMarker {
+				skip = false
+			}
+			continue
+		}
+		if !w.accept() {
+			return
+		}
+		w.output.Versions = append(w.output.Versions, &FSVersionInfo{
+			Key:          key,
+			VersionId:    version.VersionId,
+			IsLatest:     i == 0,
+			DeleteMarker: version.DeleteMarker,
+			ETag:         version.ETag,
+			Size:         version.Size,
+			ModifyTime:   time.Unix(0, version.ModifyTime),
+		})
+		w.output.NextKeyMarker = key
+		w.output.NextVersionIdMarker = version.VersionId
+	}
+	return
+}
+
+// hasObjectVersions returns true if any version list is kept on the directory.
+func (v *Volume) hasObjectVersions(dirIno uint64) (has bool, err error) {
+	var keys []string
+	if keys, err = v.mw.XAttrsList_ll(dirIno); err != nil {
+		return
+	}
+	for _, key := range keys {
+		if strings.HasPrefix(key, XAttrKeyOSSVersions) {
+			return true, nil
+		}
+	}
+	return
+}
@@ -0,0 +1,337 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const ParamInventoryId = "id"
+
+var (
+	NoSuchInventoryConfiguration   = &ErrorCode{ErrorCode: "NoSuchConfiguration", ErrorMessage: "The specified inventory configuration does not exist.", StatusCode: http.StatusNotFound}
+	InventoryIdMismatch            = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The inventory ID in the request does not match the ID in the configuration.", StatusCode: http.StatusBadRequest}
+	InvalidInventoryDestination    = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The destination bucket of the inventory does not exist or is not owned by the bucket owner.", StatusCode: http.StatusBadRequest}
+	TooManyInventoryConfigurations = &ErrorCode{ErrorCode: "TooManyConfigurations", ErrorMessage: "You are attempting to create a new configuration but have already reached the 1,000-configuration limit.", StatusCode: http.StatusBadRequest}
+)
+
+type InventoryConfiguration struct {
+	XMLName                xml.Name                `xml:"InventoryConfiguration"`
+	Id                     string                  `xml:"Id"`
+	IsEnabled              bool                    `xml:"IsEnabled"`
+	Filter                 *InventoryFilter        `xml:"Filter,omitempty"`
+	Destination            *InventoryDestination   `xml:"Destination"`
+	Schedule               *InventorySchedule      `xml:"Schedule"`
+	IncludedObjectVersions string                  `xml:"IncludedObjectVersions"`
+	OptionalFields         *InventoryOptionalField `xml:"OptionalFields,omitempty"`
+}
+
+type InventoryFilter struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type InventoryDestination struct {
+	S3BucketDestination *InventoryS3BucketDestination `xml:"S3BucketDestination"`
+}
+
+type InventoryS3BucketDestination struct {
+	AccountId string `xml:"AccountId,omitempty"`
+	Bucket    string `xml:"Bucket"` // either "arn:aws:s3:::<bucket>" or the plain bucket name
+	Format    string `xml:"Format"`
+	Prefix    string `xml:"Prefix,omitempty"`
+}
+
+type InventorySchedule struct {
+	Frequency string `xml:"Frequency"`
+}
+
+type InventoryOptionalField struct {
+	Fields []string `xml:"Field"`
+}
+
+type ListInventoryConfigurationsResult struct {
+	XMLName                 xml.Name                  `xml:"ListInventoryConfigurationsResult"`
+	InventoryConfigurations []*InventoryConfiguration `xml:"InventoryConfiguration"`
+	IsTruncated             bool                      `xml:"IsTruncated"`
+}
+
+// ToProto converts the configuration to the one kept by the master, which is validated as well.
+func (c *InventoryConfiguration) ToProto() (*proto.InventoryConfiguration, error) {
+	conf := &proto.InventoryConfiguration{
+		Id:                     c.Id,
+		Enabled:                c.IsEnabled,
+		IncludedObjectVersions: c.IncludedObjectVersions,
+	}
+	if c.Filter != nil {
+		conf.Prefix = c.Filter.Prefix
+	}
+	if c.Destination != nil && c.Destination.S3BucketDestination != nil {
+		dest := c.Destination.S3BucketDestination
+		conf.Destination = &proto.InventoryDestination{
+			Bucket: strings.TrimPrefix(dest.Bucket, replicationAWSBucketARNPrefix),
+			Prefix: dest.Prefix,
+			Format: dest.Format,
+		}
+	}
+	if c.Schedule != nil {
+		conf.Frequency = c.Schedule.Frequency
+	}
+	if c.OptionalFields != nil {
+		conf.Fields = c.OptionalFields.Fields
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func NewInventoryConfiguration(conf *proto.InventoryConfiguration) *InventoryConfiguration {
+	c := &InventoryConfiguration{
+		Id:        conf.Id,
+		IsEnabled: conf.Enabled,
+		Destination: &InventoryDestination{
+			S3BucketDestination: &InventoryS3BucketDestination{
+				Bucket: replicationAWSBucketARNPrefix + conf.Destination.Bucket,
+				Format: conf.Destination.Format,
+				Prefix: conf.Destination.Prefix,
+			},
+		},
+		Schedule:               &InventorySchedule{Frequency: conf.Frequency},
+		IncludedObjectVersions: conf.IncludedObjectVersions,
+	}
+	if conf.Prefix != "" {
+		c.Filter = &InventoryFilter{Prefix: conf.Prefix}
+	}
+	if len(conf.Fields) > 0 {
+		c.OptionalFields = &InventoryOptionalField{Fields: conf.Fields}
+	}
+	return c
+}
+
+// getBucketInventory returns the inventory configurations of the bucket, which are empty if there is none.
+func (o *ObjectNode) getBucketInventory(bucket string) (*proto.BucketInventory, error) {
+	inventory, err := o.mc.AdminAPI().GetBucketInventory(bucket)
+	if err != nil {
+		if err.Error() == proto.ErrNoSuchInventoryConfiguration.Error() {
+			return &proto.BucketInventory{VolName: bucket}, nil
+		}
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketInventoryConfiguration.html
+func (o *ObjectNode) putBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		errorCode = NoSuchBucket
+		return
+	}
+
+	_, errorCode = VerifyContentLength(r, BodyLimit)
+	if errorCode != nil {
+		return
+	}
+	var requestBody []byte
+	if requestBody, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		log.LogErrorf("putBucketInventory failed: read request body data err: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+
+	config := &InventoryConfiguration{}
+	if err = UnmarshalXMLEntity(requestBody, config); err != nil {
+		log.LogWarnf("putBucketInventory failed: decode request body err: requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = MalformedXML
+		return
+	}
+	if config.Id != param.GetVar(ParamInventoryId) {
+		errorCode = InventoryIdMismatch
+		return
+	}
+	var conf *proto.InventoryConfiguration
+	if conf, err = config.ToProto(); err != nil {
+		log.LogWarnf("putBucketInventory failed: validate err: requestID(%v) config(%+v) err(%v)", GetRequestID(r), config, err)
+		errorCode = &ErrorCode{
+			ErrorCode:    "InvalidArgument",
+			ErrorMessage: err.Error(),
+			StatusCode:   http.StatusBadRequest,
+		}
+		return
+	}
+
+	// the inventory is written with the credentials of the bucket owner
+	var destVol *Volume
+	if destVol, err = o.vm.Volume(conf.Destination.Bucket); err != nil || destVol.Owner() != vol.Owner() {
+		log.LogWarnf("putBucketInventory failed: invalid destination: requestID(%v) bucket(%v) destination(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), conf.Destination.Bucket, err)
+		err = nil
+		errorCode = InvalidInventoryDestination
+		return
+	}
+
+	var inventory *proto.BucketInventory
+	if inventory, err = o.getBucketInventory(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketInventory failed: GetBucketInventory err: requestID(%v) bucket(%v) err(%v)", GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if err = inventory.Put(conf); err != nil {
+		err = nil
+		errorCode = TooManyInventoryConfigurations
+		return
+	}
+	if err = o.mc.AdminAPI().SetBucketInventory(inventory); err != nil {
+		log.LogErrorf("putBucketInventory failed: SetBucketInventory err: requestID(%v) bucket(%v) err(%v)", GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	log.LogInfof("putBucketInventory success: requestID(%v) bucket(%v) config(%+v)", GetRequestID(r), param.Bucket(), conf)
+}
+
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketInventoryConfiguration.html
+func (o *ObjectNode) getBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if _, err = o.vm.Volume(param.Bucket()); err != nil {
+		errorCode = NoSuchBucket
+		return
+	}
+
+	var inventory *proto.BucketInventory
+	if inventory, err = o.getBucketInventory(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketInventory failed: requestID(%v) bucket(%v) err(%v)", GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	conf := inventory.Get(param.GetVar(ParamInventoryId))
+	if conf == nil {
+		errorCode = NoSuchInventoryConfiguration
+		return
+	}
+
+	var data []byte
+	if data, err = MarshalXMLEntity(NewInventoryConfiguration(conf)); err != nil {
+		log.LogErrorf("getBucketInventory failed: marshal err: requestID(%v) bucket(%v) err(%v)", GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListBucketInventoryConfigurations.html
+func (o *ObjectNode) listBucketInventoryConfigurationsHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if _, err = o.vm.Volume(param.Bucket()); err != nil {
+		errorCode = NoSuchBucket
+		return
+	}
+
+	var inventory *proto.BucketInventory
+	if inventory, err = o.getBucketInventory(param.Bucket()); err != nil {
+		log.LogErrorf("listBucketInventory failed: requestID(%v) bucket(%v) err(%v)", GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	// all the configurations are listed at once since there are at most 1000 of them
+	result := &ListInventoryConfigurationsResult{}
+	for _, conf := range inventory.Configurations {
+		result.InventoryConfigurations = append(result.InventoryConfigurations, NewInventoryConfiguration(conf))
+	}
+
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("listBucketInventory failed: marshal err: requestID(%v) bucket(%v) err(%v)", GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketInventoryConfiguration.html
+func (o *ObjectNode) deleteBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if _, err = o.vm.Volume(param.Bucket()); err != nil {
+		errorCode = NoSuchBucket
+		return
+	}
+
+	var inventory *proto.BucketInventory
+	if inventory, err = o.getBucketInventory(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketInventory failed: requestID(%v) bucket(%v) err(%v)", GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if !inventory.Delete(param.GetVar(ParamInventoryId)) {
+		errorCode = NoSuchInventoryConfiguration
+		return
+	}
+	if len(inventory.Configurations) == 0 {
+		err = o.mc.AdminAPI().DelBucketInventory(param.Bucket())
+	} else {
+		err = o.mc.AdminAPI().SetBucketInventory(inventory)
+	}
+	if err != nil {
+		log.LogErrorf("deleteBucketInventory failed: requestID(%v) bucket(%v) err(%v)", GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventoryConfiguration(t *testing.T) {
+	inventoryXml := `
+<InventoryConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+   <Id>report1</Id>
+   <IsEnabled>true</IsEnabled>
+   <Filter>
+      <Prefix>logs/</Prefix>
+   </Filter>
+   <Destination>
+      <S3BucketDestination>
+         <Format>CSV</Format>
+         <Bucket>arn:aws:s3:::dest</Bucket>
+         <Prefix>reports</Prefix>
+      </S3BucketDestination>
+   </Destination>
+   <Schedule>
+      <Frequency>Daily</Frequency>
+   </Schedule>
+   <IncludedObjectVersions>Current</IncludedObjectVersions>
+   <OptionalFields>
+      <Field>Size</Field>
+      <Field>ETag</Field>
+   </OptionalFields>
+</InventoryConfiguration>
+`
+	config := &InventoryConfiguration{}
+	require.NoError(t, xml.Unmarshal([]byte(inventoryXml), config))
+	conf, err := config.ToProto()
+	require.NoError(t, err)
+	require.Equal(t, "report1", conf.Id)
+	require.True(t, conf.Enabled)
+	require.Equal(t, "logs/", conf.Prefix)
+	require.Equal(t, &proto.InventoryDestination{Bucket: "dest", Prefix: "reports", Format: proto.InventoryFormatCSV}, conf.Destination)
+	require.Equal(t, []string{proto.InventoryFieldSize, proto.InventoryFieldETag}, conf.Fields)
+
+	// the configuration is converted back as it was
+	data, err := xml.Marshal(NewInventoryConfiguration(conf))
+	require.NoError(t, err)
+	converted := &InventoryConfiguration{}
+	require.NoError(t, xml.Unmarshal(data, converted))
+	config.XMLName = converted.XMLName
+	require.Equal(t, config, converted)
+
+	config.IncludedObjectVersions = "All"
+	_, err = config.ToProto()
+	require.Equal(t, proto.InventoryErrVersions, err)
+	config.IncludedObjectVersions = proto.InventoryVersionsCurrent
+
+	config.Schedule.Frequency = "Hourly"
+	_, err = config.ToProto()
+	require.Equal(t, proto.InventoryErrFrequency, err)
+	config.Schedule.Frequency = proto.InventoryFrequencyWeekly
+
+	config.OptionalFields.Fields = append(config.OptionalFields.Fields, "Owner")
+	_, err = config.ToProto()
+	require.Equal(t, proto.InventoryErrField, err)
+	config.OptionalFields = nil
+
+	config.Destination = nil
+	_, err = config.ToProto()
+	require.Equal(t, proto.InventoryErrDestination, err)
+}
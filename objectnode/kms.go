@@ -0,0 +1,121 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var (
+	ErrKMSKeyNotFound  = errors.New("kms: master key not found")
+	ErrKMSSealedKey    = errors.New("kms: invalid sealed key")
+	ErrKMSNotSupported = errors.New("kms: unsupported provider")
+)
+
+// DataKey is a data encryption key generated by the KMS.
+// The plaintext is only kept in memory, and the sealed key is stored with the object.
+type DataKey struct {
+	KeyID     string
+	Plaintext []byte
+	Sealed    []byte
+}
+
+// KMS is the key provider of server-side encryption. It keeps the master keys and
+// wraps (seals) the data keys of objects, so that no plaintext key is persisted.
+type KMS interface {
+	// GenerateKey generates a new data key sealed by the master key keyID.
+	// The default master key is used if keyID is empty.
+	GenerateKey(keyID string) (*DataKey, error)
+	// DecryptKey unseals a data key which is sealed by the master key keyID.
+	DecryptKey(keyID string, sealed []byte) ([]byte, error)
+	Close() error
+}
+
+// KMSCreator creates a KMS instance with the raw configuration of the provider.
+type KMSCreator func(conf json.RawMessage) (KMS, error)
+
+var (
+	kmsCreatorsMu sync.RWMutex
+	kmsCreators   = map[string]KMSCreator{
+		LocalKMSProvider: NewLocalKMS,
+	}
+)
+
+// RegisterKMS registers a KMS provider, which can be referenced by name in the configuration.
+func RegisterKMS(name string, creator KMSCreator) {
+	kmsCreatorsMu.Lock()
+	defer kmsCreatorsMu.Unlock()
+	kmsCreators[name] = creator
+}
+
+type KMSConfig struct {
+	Provider string          `json:"provider"`
+	Config   json.RawMessage `json:"config,omitempty"`
+}
+
+func NewKMS(conf KMSConfig) (KMS, error) {
+	kmsCreatorsMu.RLock()
+	creator, ok := kmsCreators[conf.Provider]
+	kmsCreatorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrKMSNotSupported, conf.Provider)
+	}
+	return creator(conf.Config)
+}
+
+// sealKey encrypts the key with the key encryption key by AES-GCM,
+// the output is the random nonce followed by the ciphertext.
+func sealKey(kek, key, additional []byte) ([]byte, error) {
+	aead, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(key)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, key, additional), nil
+}
+
+func unsealKey(kek, sealed, additional []byte) ([]byte, error) {
+	aead, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrKMSSealedKey
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	key, err := aead.Open(nil, nonce, ciphertext, additional)
+	if err != nil {
+		return nil, ErrKMSSealedKey
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
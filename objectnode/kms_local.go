@@ -0,0 +1,152 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const LocalKMSProvider = "local"
+
+// LocalKMSConfig is the configuration of the local-file key provider.
+// Example:
+//
+//	{
+//		"keyFile": "/cfs/conf/sse.keys",
+//		"defaultKeyId": "key-2"
+//	}
+//
+// Each non-empty line of the key file is a master key in the form of
+// "<key id>:<base64 encoded 32 bytes key>", lines started with '#' are ignored.
+// Old master keys must be kept in the file after rotation, since the data keys
+// of existing objects are still sealed by them.
+type LocalKMSConfig struct {
+	KeyFile      string `json:"keyFile"`
+	DefaultKeyID string `json:"defaultKeyId,omitempty"`
+}
+
+// LocalKMS is a KMS which loads the master keys from a local file.
+type LocalKMS struct {
+	defaultKeyID string
+	keys         map[string][]byte
+}
+
+func NewLocalKMS(raw json.RawMessage) (KMS, error) {
+	var conf LocalKMSConfig
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &conf); err != nil {
+			return nil, err
+		}
+	}
+	if conf.KeyFile == "" {
+		return nil, errors.New("kms: key file of local provider is not specified")
+	}
+	data, err := os.ReadFile(conf.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	keys, firstKeyID, err := parseLocalKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	kms := &LocalKMS{
+		defaultKeyID: conf.DefaultKeyID,
+		keys:         keys,
+	}
+	if kms.defaultKeyID == "" {
+		kms.defaultKeyID = firstKeyID
+	}
+	if _, ok := kms.keys[kms.defaultKeyID]; !ok {
+		return nil, fmt.Errorf("%w: %v", ErrKMSKeyNotFound, kms.defaultKeyID)
+	}
+	return kms, nil
+}
+
+func parseLocalKeys(data []byte) (keys map[string][]byte, firstKeyID string, err error) {
+	keys = make(map[string][]byte)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		index := strings.Index(text, ":")
+		if index <= 0 {
+			return nil, "", fmt.Errorf("kms: invalid key at line %d", line)
+		}
+		keyID := strings.TrimSpace(text[:index])
+		var key []byte
+		if key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(text[index+1:])); err != nil {
+			return nil, "", fmt.Errorf("kms: decode key %v fail: %v", keyID, err)
+		}
+		if len(key) != sseKeySize {
+			return nil, "", fmt.Errorf("kms: key %v must be %d bytes", keyID, sseKeySize)
+		}
+		if _, ok := keys[keyID]; ok {
+			return nil, "", fmt.Errorf("kms: duplicate key %v", keyID)
+		}
+		keys[keyID] = key
+		if firstKeyID == "" {
+			firstKeyID = keyID
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, "", err
+	}
+	if len(keys) == 0 {
+		return nil, "", errors.New("kms: no key found in key file")
+	}
+	return
+}
+
+func (k *LocalKMS) GenerateKey(keyID string) (*DataKey, error) {
+	if keyID == "" {
+		keyID = k.defaultKeyID
+	}
+	masterKey, ok := k.keys[keyID]
+	if !ok {
+		return nil, ErrKMSKeyNotFound
+	}
+	key := make([]byte, sseKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	sealed, err := sealKey(masterKey, key, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyID: keyID, Plaintext: key, Sealed: sealed}, nil
+}
+
+func (k *LocalKMS) DecryptKey(keyID string, sealed []byte) ([]byte, error) {
+	masterKey, ok := k.keys[keyID]
+	if !ok {
+		return nil, ErrKMSKeyNotFound
+	}
+	return unsealKey(masterKey, sealed, []byte(keyID))
+}
+
+func (k *LocalKMS) Close() error {
+	return nil
+}
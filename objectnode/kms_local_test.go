@@ -0,0 +1,117 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testMasterKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, sseKeySize))
+}
+
+func TestParseLocalKeys(t *testing.T) {
+	tests := []struct {
+		data       string
+		keys       int
+		firstKeyID string
+		hasErr     bool
+	}{
+		{
+			data:       fmt.Sprintf("# master keys\nkey-1:%s\n\nkey-2: %s\n", testMasterKey(1), testMasterKey(2)),
+			keys:       2,
+			firstKeyID: "key-1",
+		},
+		{
+			data:   "",
+			hasErr: true,
+		},
+		{
+			data:   fmt.Sprintf("%s\n", testMasterKey(1)),
+			hasErr: true,
+		},
+		{
+			data:   "key-1:invalid base64",
+			hasErr: true,
+		},
+		{
+			data:   "key-1:" + base64.StdEncoding.EncodeToString([]byte("short key")),
+			hasErr: true,
+		},
+		{
+			data:   fmt.Sprintf("key-1:%s\nkey-1:%s\n", testMasterKey(1), testMasterKey(2)),
+			hasErr: true,
+		},
+	}
+	for _, tt := range tests {
+		keys, firstKeyID, err := parseLocalKeys([]byte(tt.data))
+		if tt.hasErr {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Len(t, keys, tt.keys)
+		require.Equal(t, tt.firstKeyID, firstKeyID)
+	}
+}
+
+func TestLocalKMS(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "sse.keys")
+	data := fmt.Sprintf("key-1:%s\nkey-2:%s\n", testMasterKey(1), testMasterKey(2))
+	require.NoError(t, os.WriteFile(keyFile, []byte(data), 0o600))
+
+	// the default key must exist
+	raw, _ := json.Marshal(LocalKMSConfig{KeyFile: keyFile, DefaultKeyID: "key-3"})
+	_, err := NewKMS(KMSConfig{Provider: LocalKMSProvider, Config: raw})
+	require.ErrorIs(t, err, ErrKMSKeyNotFound)
+	_, err = NewKMS(KMSConfig{Provider: "unknown"})
+	require.ErrorIs(t, err, ErrKMSNotSupported)
+
+	raw, _ = json.Marshal(LocalKMSConfig{KeyFile: keyFile, DefaultKeyID: "key-2"})
+	kms, err := NewKMS(KMSConfig{Provider: LocalKMSProvider, Config: raw})
+	require.NoError(t, err)
+	defer kms.Close()
+
+	dataKey, err := kms.GenerateKey("")
+	require.NoError(t, err)
+	require.Equal(t, "key-2", dataKey.KeyID)
+	require.Len(t, dataKey.Plaintext, sseKeySize)
+	key, err := kms.DecryptKey(dataKey.KeyID, dataKey.Sealed)
+	require.NoError(t, err)
+	require.Equal(t, dataKey.Plaintext, key)
+
+	// the sealed key is bound to the master key id
+	_, err = kms.DecryptKey("key-1", dataKey.Sealed)
+	require.ErrorIs(t, err, ErrKMSSealedKey)
+	_, err = kms.DecryptKey("key-3", dataKey.Sealed)
+	require.ErrorIs(t, err, ErrKMSKeyNotFound)
+	_, err = kms.GenerateKey("key-3")
+	require.ErrorIs(t, err, ErrKMSKeyNotFound)
+
+	dataKey, err = kms.GenerateKey("key-1")
+	require.NoError(t, err)
+	require.Equal(t, "key-1", dataKey.KeyID)
+	key, err = kms.DecryptKey("key-1", dataKey.Sealed)
+	require.NoError(t, err)
+	require.Equal(t, dataKey.Plaintext, key)
+}
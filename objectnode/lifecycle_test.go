@@ -406,3 +406,86 @@ func TestValidRulePrefix(t *testing.T) {
 	}
 	require.NoError(t, proto.ValidRulePrefix(rules))
 }
+
+func TestLifecycleConfigurationFilters(t *testing.T) {
+	LifecycleXml := `
+<LifecycleConfiguration>
+    <Rule>
+        <ID>tags</ID>
+        <Filter>
+           <And>
+              <Prefix>logs/</Prefix>
+              <Tag><Key>type</Key><Value>tmp</Value></Tag>
+              <Tag><Key>owner</Key><Value>app</Value></Tag>
+              <ObjectSizeGreaterThan>100</ObjectSizeGreaterThan>
+              <ObjectSizeLessThan>1000</ObjectSizeLessThan>
+           </And>
+        </Filter>
+        <Status>Enabled</Status>
+        <Transition>
+           <Days>30</Days>
+           <StorageClass>HDD</StorageClass>
+        </Transition>
+    </Rule>
+    <Rule>
+        <ID>uploads</ID>
+        <Filter>
+           <Prefix>uploads/</Prefix>
+        </Filter>
+        <Status>Enabled</Status>
+        <AbortIncompleteMultipartUpload>
+           <DaysAfterInitiation>7</DaysAfterInitiation>
+        </AbortIncompleteMultipartUpload>
+        <Expiration>
+           <ExpiredObjectDeleteMarker>true</ExpiredObjectDeleteMarker>
+        </Expiration>
+    </Rule>
+</LifecycleConfiguration>
+`
+	l := NewLifecycleConfiguration()
+	require.NoError(t, xml.Unmarshal([]byte(LifecycleXml), l))
+	// the expired delete markers are removed even if the expiration is disabled
+	proto.ExpirationEnabled = false
+	require.NoError(t, proto.ValidRules(l.Rules))
+	proto.ExpirationEnabled = true
+
+	tags, uploads := l.Rules[0], l.Rules[1]
+	require.Equal(t, "logs/", tags.GetPrefix())
+	require.Equal(t, []proto.LcTag{{Key: "type", Value: "tmp"}, {Key: "owner", Value: "app"}}, tags.Tags())
+	require.False(t, tags.MatchSize(100))
+	require.True(t, tags.MatchSize(101))
+	require.False(t, tags.MatchSize(1000))
+	require.True(t, tags.MatchTags(map[string]string{"type": "tmp", "owner": "app", "other": "x"}))
+	require.False(t, tags.MatchTags(map[string]string{"type": "tmp"}))
+	require.False(t, tags.MatchTags(map[string]string{"type": "log", "owner": "app"}))
+
+	require.Equal(t, 7, uploads.AbortIncompleteMultipartUpload.DaysAfterInitiation)
+	require.True(t, uploads.Expiration.ExpiresDeleteMarkers())
+	require.False(t, uploads.Expiration.ExpiresObjects())
+	require.True(t, uploads.MatchTags(nil))
+	require.True(t, uploads.MatchSize(0))
+	tasks := (&proto.LcConfiguration{VolName: "vol", Rules: l.Rules}).GenEnabledRuleTasks()
+	require.Len(t, tasks, 2)
+
+	size := int64(10)
+	cases := []struct {
+		rule *proto.Rule
+		err  error
+	}{
+		{&proto.Rule{Filter: &proto.Filter{Prefix: "a", Tag: &proto.LcTag{Key: "k"}}}, proto.LifeCycleErrFilter},
+		{&proto.Rule{Filter: &proto.Filter{Prefix: "a", And: &proto.FilterAnd{Prefix: "b"}}}, proto.LifeCycleErrFilter},
+		{&proto.Rule{Filter: &proto.Filter{And: &proto.FilterAnd{Tags: []proto.LcTag{{Key: "k"}, {Key: "k"}}}}}, proto.LifeCycleErrTag},
+		{&proto.Rule{Filter: &proto.Filter{And: &proto.FilterAnd{ObjectSizeGreaterThan: &size, ObjectSizeLessThan: &size}}}, proto.LifeCycleErrObjectSize},
+		{&proto.Rule{Filter: &proto.Filter{ObjectSizeLessThan: &size}, AbortIncompleteMultipartUpload: &proto.AbortIncompleteMultipartUpload{DaysAfterInitiation: 1}}, proto.LifeCycleErrAbortMultipart},
+		{&proto.Rule{AbortIncompleteMultipartUpload: &proto.AbortIncompleteMultipartUpload{}}, proto.LifeCycleErrAbortMultipart},
+		{&proto.Rule{Filter: &proto.Filter{Tag: &proto.LcTag{Key: "k"}}, Expiration: &proto.Expiration{ExpiredObjectDeleteMarker: new(bool)}}, proto.LifeCycleErrDeleteMarker},
+		{&proto.Rule{Expiration: &proto.Expiration{Days: &[]int{1}[0], ExpiredObjectDeleteMarker: new(bool)}}, proto.LifeCycleErrDeleteMarker},
+	}
+	for i, c := range cases {
+		c.rule.ID, c.rule.Status = "id", proto.RuleEnabled
+		if c.rule.Expiration == nil && c.rule.AbortIncompleteMultipartUpload == nil {
+			c.rule.AbortIncompleteMultipartUpload = &proto.AbortIncompleteMultipartUpload{DaysAfterInitiation: 1}
+		}
+		require.Equal(t, c.err, proto.ValidRules([]*proto.Rule{c.rule}), i)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/ServerLogs.html
+
+import (
+	"crypto/tls"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxBucketLoggingConfigSize = 64 << 10 // 64KB
+
+	accessLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+)
+
+// BucketLoggingStatus is the server access logging configuration of a bucket, the logging
+// is disabled if LoggingEnabled is not specified.
+type BucketLoggingStatus struct {
+	XMLNS          string          `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName        xml.Name        `xml:"BucketLoggingStatus" json:"-"`
+	LoggingEnabled *LoggingEnabled `xml:"LoggingEnabled,omitempty" json:"loggingEnabled,omitempty"`
+}
+
+type LoggingEnabled struct {
+	TargetBucket string `xml:"TargetBucket" json:"targetBucket"`
+	TargetPrefix string `xml:"TargetPrefix" json:"targetPrefix"`
+}
+
+func ParseBucketLoggingStatus(data []byte) (*BucketLoggingStatus, *ErrorCode) {
+	status := &BucketLoggingStatus{}
+	if err := xml.Unmarshal(data, status); err != nil {
+		return nil, MalformedXML
+	}
+	if status.LoggingEnabled != nil {
+		if status.LoggingEnabled.TargetBucket == "" {
+			return nil, InvalidTargetBucketForLogging
+		}
+		if len(status.LoggingEnabled.TargetPrefix) > MaxKeyLength/2 {
+			return nil, InvalidArgument
+		}
+	}
+	return status, nil
+}
+
+func (s *BucketLoggingStatus) IsEnabled() bool {
+	return s != nil && s.LoggingEnabled != nil
+}
+
+func storeBucketLogging(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSLogging, bytes)
+}
+
+func deleteBucketLogging(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSLogging)
+}
+
+// AccessLogRecord is a record of the server access log, which is formatted as the log line of S3.
+type AccessLogRecord struct {
+	BucketOwner string
+	Bucket      string
+	Time        time.Time
+	RemoteIP    string
+	Requester   string
+	RequestID   string
+	Operation   string
+	Key         string
+	RequestURI  string
+	HTTPStatus  int
+	ErrorCode   string
+	BytesSent   int64
+	ObjectSize  int64
+	TotalTime   time.Duration
+	Referer     string
+	UserAgent   string
+	VersionID   string
+	SigVersion  string
+	CipherSuite string
+	AuthType    string
+	HostHeader  string
+	TLSVersion  string
+}
+
+func accessLogField(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+func accessLogQuoted(v string) string {
+	if v == "" {
+		return `"-"`
+	}
+	return strconv.Quote(v)
+}
+
+func accessLogNumber(v int64) string {
+	if v <= 0 {
+		return "-"
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+// String formats the record to the log line without the line break.
+func (rec *AccessLogRecord) String() string {
+	key := rec.Key
+	if key != "" {
+		key = url.QueryEscape(key)
+	}
+	fields := []string{
+		accessLogField(rec.BucketOwner),
+		accessLogField(rec.Bucket),
+		"[" + rec.Time.UTC().Format(accessLogTimeFormat) + "]",
+		accessLogField(rec.RemoteIP),
+		accessLogField(rec.Requester),
+		accessLogField(rec.RequestID),
+		accessLogField(rec.Operation),
+		accessLogField(key),
+		accessLogQuoted(rec.RequestURI),
+		strconv.Itoa(rec.HTTPStatus),
+		accessLogField(rec.ErrorCode),
+		accessLogNumber(rec.BytesSent),
+		accessLogNumber(rec.ObjectSize),
+		strconv.FormatInt(rec.TotalTime.Milliseconds(), 10),
+		"-", // turn-around time
+		accessLogQuoted(rec.Referer),
+		accessLogQuoted(rec.UserAgent),
+		accessLogField(rec.VersionID),
+		"-", // host id
+		accessLogField(rec.SigVersion),
+		accessLogField(rec.CipherSuite),
+		accessLogField(rec.AuthType),
+		accessLogField(rec.HostHeader),
+		accessLogField(rec.TLSVersion),
+		"-", // access point ARN
+		"-", // ACL required
+	}
+	return strings.Join(fields, " ")
+}
+
+// accessLogSubresources are the sub-resources which name the operation of the request.
+var accessLogSubresources = []string{
+	"acl", "cors", "delete", "encryption", "legal-hold", "lifecycle", "logging", "notification",
+	"object-lock", "policy", "publicAccessBlock", "replication", "restore", "retention", "select",
+	"tagging", "uploads", "uploadId", "versioning", "versions", "website",
+}
+
+// accessLogOperation returns the operation of the request, e.g. REST.PUT.OBJECT, REST.GET.VERSIONING.
+func accessLogOperation(r *http.Request, key string) string {
+	return "REST." + r.Method + "." + accessLogResource(r, key)
+}
+
+func accessLogResource(r *http.Request, key string) string {
+	query := r.URL.Query()
+	for _, sub := range accessLogSubresources {
+		if _, ok := query[sub]; !ok {
+			continue
+		}
+		switch {
+		case sub == "delete":
+			return "MULTI_OBJECT_DELETE"
+		case sub == "uploadId" && r.Method == http.MethodPut:
+			return "PART"
+		case sub == "uploadId":
+			return "UPLOAD"
+		case sub == "tagging" && key != "":
+			return "OBJECT_TAGGING"
+		default:
+			return strings.ToUpper(strings.ReplaceAll(sub, "-", "_"))
+		}
+	}
+	if key != "" {
+		return "OBJECT"
+	}
+	return "BUCKET"
+}
+
+func accessLogAuth(r *http.Request) (sigVersion, authType string) {
+	if auth := r.Header.Get(Authorization); auth != "" {
+		if strings.HasPrefix(auth, signV4Algorithm) {
+			return "SigV4", "AuthHeader"
+		}
+		return "SigV2", "AuthHeader"
+	}
+	query := r.URL.Query()
+	if query.Get(XAmzAlgorithm) != "" {
+		return "SigV4", "QueryString"
+	}
+	if query.Get(AWSAccessKeyID) != "" {
+		return "SigV2", "QueryString"
+	}
+	return "", ""
+}
+
+func accessLogTLSVersion(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLSv1"
+	case tls.VersionTLS11:
+		return "TLSv1.1"
+	case tls.VersionTLS12:
+		return "TLSv1.2"
+	case tls.VersionTLS13:
+		return "TLSv1.3"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket logging
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLogging.html
+func (o *ObjectNode) getBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketLoggingHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var status *BucketLoggingStatus
+	if status, err = vol.metaLoader.loadLogging(); err != nil {
+		log.LogErrorf("getBucketLoggingHandler: load logging fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	// an empty status is returned if the logging is disabled
+	output := &BucketLoggingStatus{XMLNS: S3Namespace}
+	if status != nil {
+		output.LoggingEnabled = status.LoggingEnabled
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		log.LogErrorf("getBucketLoggingHandler: xml marshal fail: requestID(%v) volume(%v) status(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), output, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket logging
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLogging.html
+func (o *ObjectNode) putBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxBucketLoggingConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxBucketLoggingConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var status *BucketLoggingStatus
+	if status, errorCode = ParseBucketLoggingStatus(body); errorCode != nil {
+		log.LogErrorf("putBucketLoggingHandler: parse logging status fail: requestID(%v) volume(%v) status(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+
+	// an empty status disables the logging of the bucket
+	if !status.IsEnabled() {
+		if err = deleteBucketLogging(vol); err != nil {
+			log.LogErrorf("putBucketLoggingHandler: delete logging status fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			return
+		}
+		vol.metaLoader.storeLogging(nil)
+		log.LogInfof("Audit: delete bucket logging: requestID(%v) remote(%v) volume(%v)",
+			GetRequestID(r), getRequestIP(r), vol.Name())
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if o.accessLogger == nil {
+		errorCode = AccessLoggingNotConfigured
+		return
+	}
+	// the target bucket must exist and be owned by the owner of the source bucket
+	var target *Volume
+	if target, err = o.getVol(status.LoggingEnabled.TargetBucket); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: load target volume fail: requestID(%v) volume(%v) target(%v) err(%v)",
+			GetRequestID(r), vol.Name(), status.LoggingEnabled.TargetBucket, err)
+		if err == NoSuchBucket {
+			err, errorCode = nil, InvalidTargetBucketForLogging
+		}
+		return
+	}
+	if target.GetOwner() != vol.GetOwner() {
+		log.LogErrorf("putBucketLoggingHandler: target volume owner mismatch: requestID(%v) volume(%v) owner(%v) target(%v) targetOwner(%v)",
+			GetRequestID(r), vol.Name(), vol.GetOwner(), target.Name(), target.GetOwner())
+		errorCode = InvalidTargetBucketForLogging
+		return
+	}
+	if body, err = json.Marshal(status); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: json marshal logging status fail: requestID(%v) volume(%v) status(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), status, err)
+		return
+	}
+	if err = storeBucketLogging(body, vol); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: store logging status fail: requestID(%v) volume(%v) status(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeLogging(status)
+	log.LogInfof("Audit: put bucket logging: requestID(%v) remote(%v) volume(%v) status(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), string(body))
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// logAccess spools the server access log of the request if the logging of the bucket is enabled.
+func (o *ObjectNode) logAccess(w http.ResponseWriter, r *http.Request) {
+	if o.accessLogger == nil {
+		return
+	}
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		return
+	}
+	vol, err := o.vm.Volume(param.Bucket())
+	if err != nil {
+		return
+	}
+	status, err := vol.metaLoader.loadLogging()
+	if err != nil || !status.IsEnabled() {
+		return
+	}
+
+	record := &AccessLogRecord{
+		BucketOwner: vol.GetOwner(),
+		Bucket:      param.Bucket(),
+		Time:        time.Now(),
+		RemoteIP:    getRequestIP(r),
+		Requester:   param.Requester(),
+		RequestID:   param.RequestID(),
+		Operation:   accessLogOperation(r, param.Object()),
+		Key:         param.Object(),
+		RequestURI:  r.Method + " " + r.RequestURI + " " + r.Proto,
+		ErrorCode:   getResponseErrorCode(r),
+		Referer:     r.Referer(),
+		UserAgent:   r.UserAgent(),
+		HostHeader:  r.Host,
+	}
+	if stater, ok := w.(*ResponseStater); ok {
+		record.HTTPStatus = stater.StatusCode
+		record.BytesSent = stater.Written
+		record.Time = stater.StartTime
+		record.TotalTime = time.Since(stater.StartTime)
+	}
+	switch r.Method {
+	case http.MethodPut, http.MethodPost:
+		record.ObjectSize = r.ContentLength
+	case http.MethodGet, http.MethodHead:
+		if param.Object() != "" && w.Header().Get(ContentRange) == "" {
+			record.ObjectSize, _ = strconv.ParseInt(w.Header().Get(ContentLength), 10, 64)
+		}
+	}
+	if record.VersionID = w.Header().Get(XAmzVersionId); record.VersionID == "" {
+		record.VersionID = r.URL.Query().Get(ParamVersionId)
+	}
+	record.SigVersion, record.AuthType = accessLogAuth(r)
+	if r.TLS != nil {
+		record.CipherSuite = tls.CipherSuiteName(r.TLS.CipherSuite)
+		record.TLSVersion = accessLogTLSVersion(r.TLS.Version)
+	}
+
+	o.accessLogger.Log(param.Bucket(), record)
+}
+
+// accessLogObjectKey returns the key of the log object, e.g. "logs/2006-01-02-15-04-05-0123456789ABCDEF".
+func accessLogObjectKey(prefix string, now time.Time) string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return prefix + now.UTC().Format("2006-01-02-15-04-05-") + strings.ToUpper(hex.EncodeToString(b))
+}
+
+// deliverAccessLog writes the batch of the access logs of the bucket into the target bucket. The
+// batch is dropped if the logging is disabled or the target bucket does not exist any more.
+func (o *ObjectNode) deliverAccessLog(bucket string, data []byte) error {
+	vol, err := o.getVol(bucket)
+	if err == NoSuchBucket {
+		log.LogWarnf("deliverAccessLog: drop logs of the deleted volume: volume(%v)", bucket)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	status, err := vol.metaLoader.loadLogging()
+	if err != nil {
+		return err
+	}
+	if !status.IsEnabled() {
+		log.LogWarnf("deliverAccessLog: drop logs of the volume with logging disabled: volume(%v)", bucket)
+		return nil
+	}
+	target, err := o.getVol(status.LoggingEnabled.TargetBucket)
+	if err == NoSuchBucket {
+		log.LogWarnf("deliverAccessLog: drop logs as the target volume is deleted: volume(%v) target(%v)",
+			bucket, status.LoggingEnabled.TargetBucket)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	key := accessLogObjectKey(status.LoggingEnabled.TargetPrefix, time.Now())
+	if _, err = target.PutObject(key, bytes.NewReader(data), &PutFileOption{MIMEType: ValueContentTypeText}); err != nil {
+		log.LogErrorf("deliverAccessLog: put log object fail: volume(%v) target(%v) key(%v) err(%v)",
+			bucket, target.Name(), key, err)
+		return err
+	}
+	log.LogDebugf("deliverAccessLog: put log object: volume(%v) target(%v) key(%v) size(%v)",
+		bucket, target.Name(), key, len(data))
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBucketLoggingStatus(t *testing.T) {
+	status, errCode := ParseBucketLoggingStatus([]byte(`
+<BucketLoggingStatus xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+	<LoggingEnabled>
+		<TargetBucket>logs</TargetBucket>
+		<TargetPrefix>access/</TargetPrefix>
+	</LoggingEnabled>
+</BucketLoggingStatus>`))
+	require.Nil(t, errCode)
+	require.True(t, status.IsEnabled())
+	require.Equal(t, &LoggingEnabled{TargetBucket: "logs", TargetPrefix: "access/"}, status.LoggingEnabled)
+
+	status, errCode = ParseBucketLoggingStatus([]byte(`<BucketLoggingStatus xmlns="http://s3.amazonaws.com/doc/2006-03-01/"/>`))
+	require.Nil(t, errCode)
+	require.False(t, status.IsEnabled())
+
+	_, errCode = ParseBucketLoggingStatus([]byte(`<BucketLoggingStatus>`))
+	require.Equal(t, MalformedXML, errCode)
+	_, errCode = ParseBucketLoggingStatus([]byte(`<BucketLoggingStatus><LoggingEnabled><TargetPrefix>a/</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`))
+	require.Equal(t, InvalidTargetBucketForLogging, errCode)
+}
+
+func TestAccessLogRecord(t *testing.T) {
+	rec := &AccessLogRecord{
+		BucketOwner: "owner",
+		Bucket:      "bucket",
+		Time:        time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC),
+		RemoteIP:    "192.168.0.1",
+		Requester:   "user",
+		RequestID:   "3E57427F3EXAMPLE",
+		Operation:   "REST.GET.OBJECT",
+		Key:         "dir/a b.txt",
+		RequestURI:  "GET /bucket/dir/a%20b.txt HTTP/1.1",
+		HTTPStatus:  200,
+		BytesSent:   113,
+		ObjectSize:  113,
+		TotalTime:   25 * time.Millisecond,
+		UserAgent:   "aws-cli/2.0",
+		SigVersion:  "SigV4",
+		AuthType:    "AuthHeader",
+		HostHeader:  "bucket.s3.example.com",
+	}
+	require.Equal(t, `owner bucket [06/May/2023:07:08:09 +0000] 192.168.0.1 user 3E57427F3EXAMPLE REST.GET.OBJECT `+
+		`dir%2Fa+b.txt "GET /bucket/dir/a%20b.txt HTTP/1.1" 200 - 113 113 25 - "-" "aws-cli/2.0" - - SigV4 - `+
+		`AuthHeader bucket.s3.example.com - - -`, rec.String())
+
+	// the anonymous request of the failure
+	rec = &AccessLogRecord{Bucket: "bucket", Operation: "REST.PUT.OBJECT", HTTPStatus: 403, ErrorCode: "AccessDenied"}
+	fields := strings.Fields(rec.String())
+	require.Len(t, fields, 27) // the time has a space
+	require.Equal(t, []string{"-", "-", "-", "REST.PUT.OBJECT", "-", `"-"`}, fields[4:10])
+	require.Equal(t, []string{"403", "AccessDenied", "-", "-"}, fields[10:14])
+}
+
+func TestAccessLogOperation(t *testing.T) {
+	tests := []struct {
+		method    string
+		url       string
+		key       string
+		operation string
+	}{
+		{http.MethodGet, "/bucket/key", "key", "REST.GET.OBJECT"},
+		{http.MethodPut, "/bucket/key", "key", "REST.PUT.OBJECT"},
+		{http.MethodGet, "/bucket", "", "REST.GET.BUCKET"},
+		{http.MethodGet, "/bucket?versioning", "", "REST.GET.VERSIONING"},
+		{http.MethodPut, "/bucket?logging", "", "REST.PUT.LOGGING"},
+		{http.MethodGet, "/bucket?tagging", "", "REST.GET.TAGGING"},
+		{http.MethodPut, "/bucket/key?tagging", "key", "REST.PUT.OBJECT_TAGGING"},
+		{http.MethodGet, "/bucket/key?legal-hold", "key", "REST.GET.LEGAL_HOLD"},
+		{http.MethodPost, "/bucket?delete", "", "REST.POST.MULTI_OBJECT_DELETE"},
+		{http.MethodPost, "/bucket/key?uploads", "key", "REST.POST.UPLOADS"},
+		{http.MethodPut, "/bucket/key?partNumber=1&uploadId=abc", "key", "REST.PUT.PART"},
+		{http.MethodPost, "/bucket/key?uploadId=abc", "key", "REST.POST.UPLOAD"},
+	}
+	for _, tt := range tests {
+		r, _ := http.NewRequest(tt.method, tt.url, nil)
+		require.Equal(t, tt.operation, accessLogOperation(r, tt.key), tt.url)
+	}
+}
+
+func TestAccessLogAuth(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/bucket/key", nil)
+	r.Header.Set(Authorization, "AWS4-HMAC-SHA256 Credential=ak/20230506/us-east-1/s3/aws4_request")
+	sigVersion, authType := accessLogAuth(r)
+	require.Equal(t, []string{"SigV4", "AuthHeader"}, []string{sigVersion, authType})
+
+	r, _ = http.NewRequest(http.MethodGet, "/bucket/key?AWSAccessKeyId=ak&Signature=sig&Expires=1", nil)
+	sigVersion, authType = accessLogAuth(r)
+	require.Equal(t, []string{"SigV2", "QueryString"}, []string{sigVersion, authType})
+
+	r, _ = http.NewRequest(http.MethodGet, "/bucket/key", nil)
+	sigVersion, authType = accessLogAuth(r)
+	require.Empty(t, sigVersion)
+	require.Empty(t, authType)
+}
+
+func TestAccessLogObjectKey(t *testing.T) {
+	prefix := "logs/2023-05-06-07-08-09-"
+	key := accessLogObjectKey("logs/", time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC))
+	require.True(t, strings.HasPrefix(key, prefix), key)
+	require.Len(t, key, len(prefix)+16)
+	require.Equal(t, strings.ToUpper(key[len(prefix):]), key[len(prefix):])
+	require.NotEqual(t, key, accessLogObjectKey("logs/", time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)))
+}
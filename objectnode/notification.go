@@ -0,0 +1,245 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/EventNotifications.html
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	MaxNotificationConfigSize = 128 << 10 // 128KB
+	MaxNotificationRules      = 100
+
+	NotificationFilterPrefix = "prefix"
+	NotificationFilterSuffix = "suffix"
+)
+
+// The event types supported by the bucket notification.
+const (
+	EventObjectCreatedAll                     = "s3:ObjectCreated:*"
+	EventObjectCreatedPut                     = "s3:ObjectCreated:Put"
+	EventObjectCreatedPost                    = "s3:ObjectCreated:Post"
+	EventObjectCreatedCopy                    = "s3:ObjectCreated:Copy"
+	EventObjectCreatedCompleteMultipartUpload = "s3:ObjectCreated:CompleteMultipartUpload"
+	EventObjectCreatedAppend                  = "s3:ObjectCreated:Append"
+	EventObjectRemovedAll                     = "s3:ObjectRemoved:*"
+	EventObjectRemovedDelete                  = "s3:ObjectRemoved:Delete"
+	EventObjectRemovedDeleteMarkerCreated     = "s3:ObjectRemoved:DeleteMarkerCreated"
+	EventObjectTaggingAll                     = "s3:ObjectTagging:*"
+	EventObjectTaggingPut                     = "s3:ObjectTagging:Put"
+	EventObjectTaggingDelete                  = "s3:ObjectTagging:Delete"
+)
+
+var supportedNotificationEvents = map[string]struct{}{
+	EventObjectCreatedAll:                     {},
+	EventObjectCreatedPut:                     {},
+	EventObjectCreatedPost:                    {},
+	EventObjectCreatedCopy:                    {},
+	EventObjectCreatedCompleteMultipartUpload: {},
+	EventObjectCreatedAppend:                  {},
+	EventObjectRemovedAll:                     {},
+	EventObjectRemovedDelete:                  {},
+	EventObjectRemovedDeleteMarkerCreated:     {},
+	EventObjectTaggingAll:                     {},
+	EventObjectTaggingPut:                     {},
+	EventObjectTaggingDelete:                  {},
+}
+
+// NotificationConfiguration is the event notification configuration of a bucket. The queue
+// and topic configurations are both delivered to the targets configured on the ObjectNode,
+// the target is the last field of the ARN, e.g. arn:cubefs:sqs::cfs:<target>.
+type NotificationConfiguration struct {
+	XMLNS   string              `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName xml.Name            `xml:"NotificationConfiguration" json:"-"`
+	Queues  []*NotificationRule `xml:"QueueConfiguration,omitempty" json:"queues,omitempty"`
+	Topics  []*NotificationRule `xml:"TopicConfiguration,omitempty" json:"topics,omitempty"`
+}
+
+type NotificationRule struct {
+	ID     string              `xml:"Id,omitempty" json:"id,omitempty"`
+	Queue  string              `xml:"Queue,omitempty" json:"queue,omitempty"`
+	Topic  string              `xml:"Topic,omitempty" json:"topic,omitempty"`
+	Events []string            `xml:"Event" json:"events"`
+	Filter *NotificationFilter `xml:"Filter,omitempty" json:"filter,omitempty"`
+}
+
+type NotificationFilter struct {
+	Rules []NotificationFilterRule `xml:"S3Key>FilterRule" json:"rules,omitempty"`
+}
+
+type NotificationFilterRule struct {
+	Name  string `xml:"Name" json:"name"`
+	Value string `xml:"Value" json:"value"`
+}
+
+func ParseNotificationConfig(data []byte) (*NotificationConfiguration, *ErrorCode) {
+	config := &NotificationConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	if errCode := config.validate(); errCode != nil {
+		return nil, errCode
+	}
+	return config, nil
+}
+
+func invalidNotificationArgument(message string) *ErrorCode {
+	return NewError("InvalidArgument", message, http.StatusBadRequest)
+}
+
+func (c *NotificationConfiguration) rules() []*NotificationRule {
+	rules := make([]*NotificationRule, 0, len(c.Queues)+len(c.Topics))
+	rules = append(rules, c.Queues...)
+	return append(rules, c.Topics...)
+}
+
+// IsEmpty checks whether no event is published by the configuration.
+func (c *NotificationConfiguration) IsEmpty() bool {
+	return c == nil || len(c.Queues)+len(c.Topics) == 0
+}
+
+func (c *NotificationConfiguration) validate() *ErrorCode {
+	rules := c.rules()
+	if len(rules) > MaxNotificationRules {
+		return invalidNotificationArgument("The number of notification configurations must not exceed the allowed limit of 100.")
+	}
+	ids := make(map[string]struct{}, len(rules))
+	for i, rule := range rules {
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("notification-%d", i+1)
+		}
+		if _, ok := ids[rule.ID]; ok {
+			return invalidNotificationArgument("Configuration ID must be unique.")
+		}
+		ids[rule.ID] = struct{}{}
+		if errCode := rule.validate(); errCode != nil {
+			return errCode
+		}
+	}
+	return nil
+}
+
+func (c *NotificationRule) validate() *ErrorCode {
+	if c.Queue != "" && c.Topic != "" || c.ARN() == "" {
+		return invalidNotificationArgument("A destination must be provided in the notification rule.")
+	}
+	if _, ok := parseNotificationARN(c.ARN()); !ok {
+		return invalidNotificationArgument(fmt.Sprintf("The destination ARN %v is not valid.", c.ARN()))
+	}
+	if len(c.Events) == 0 {
+		return invalidNotificationArgument("At least one event must be provided in the notification rule.")
+	}
+	for _, event := range c.Events {
+		if _, ok := supportedNotificationEvents[event]; !ok {
+			return invalidNotificationArgument(fmt.Sprintf("The event %v is not supported for notification.", event))
+		}
+	}
+	if c.Filter == nil {
+		return nil
+	}
+	var hasPrefix, hasSuffix bool
+	for _, rule := range c.Filter.Rules {
+		switch strings.ToLower(rule.Name) {
+		case NotificationFilterPrefix:
+			if hasPrefix {
+				return invalidNotificationArgument("Cannot specify more than one prefix rule in a filter.")
+			}
+			hasPrefix = true
+		case NotificationFilterSuffix:
+			if hasSuffix {
+				return invalidNotificationArgument("Cannot specify more than one suffix rule in a filter.")
+			}
+			hasSuffix = true
+		default:
+			return invalidNotificationArgument("filter rule name must be either prefix or suffix")
+		}
+	}
+	return nil
+}
+
+// ARN returns the destination of the notification rule.
+func (c *NotificationRule) ARN() string {
+	if c.Queue != "" {
+		return c.Queue
+	}
+	return c.Topic
+}
+
+// Target returns the id of the target on the ObjectNode which the events are delivered to.
+func (c *NotificationRule) Target() string {
+	target, _ := parseNotificationARN(c.ARN())
+	return target
+}
+
+// parseNotificationARN returns the target of the ARN in the format of
+// arn:partition:service:region:account-id:target.
+func parseNotificationARN(arn string) (string, bool) {
+	fields := strings.Split(arn, ":")
+	if len(fields) != 6 || fields[0] != "arn" || fields[5] == "" {
+		return "", false
+	}
+	return fields[5], true
+}
+
+// Match checks whether the event of the key is published by the notification rule.
+func (c *NotificationRule) Match(event, key string) bool {
+	if c.Filter != nil {
+		for _, rule := range c.Filter.Rules {
+			switch strings.ToLower(rule.Name) {
+			case NotificationFilterPrefix:
+				if !strings.HasPrefix(key, rule.Value) {
+					return false
+				}
+			case NotificationFilterSuffix:
+				if !strings.HasSuffix(key, rule.Value) {
+					return false
+				}
+			}
+		}
+	}
+	for _, e := range c.Events {
+		if e == event || strings.HasSuffix(e, ":*") && strings.HasPrefix(event, strings.TrimSuffix(e, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns all the notification rules which publish the event of the key.
+func (c *NotificationConfiguration) Match(event, key string) []*NotificationRule {
+	if c.IsEmpty() {
+		return nil
+	}
+	var matched []*NotificationRule
+	for _, rule := range c.rules() {
+		if rule.Match(event, key) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+func storeBucketNotification(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSNotification, bytes)
+}
+
+func deleteBucketNotification(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSNotification)
+}
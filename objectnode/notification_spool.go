@@ -0,0 +1,158 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const notificationEventFileSuffix = ".event"
+
+var errNotificationSpoolFull = errors.New("notification spool is full")
+
+// notificationSpool is the local spool of the events to deliver to a target. Each event
+// is kept in a file of the spool directory until it is delivered, so that the events are
+// neither lost when the target is down nor when the ObjectNode restarts. The events are
+// delivered in the order they are spooled.
+type notificationSpool struct {
+	dir   string
+	limit int
+	seq   uint64
+
+	mu     sync.Mutex
+	names  []string      // names of the event files not delivered, in order
+	notify chan struct{} // signaled when an event is spooled
+}
+
+func newNotificationSpool(dir string, limit int) (*notificationSpool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &notificationSpool{
+		dir:    dir,
+		limit:  limit,
+		notify: make(chan struct{}, 1),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if !strings.HasSuffix(name, notificationEventFileSuffix) {
+			// the temporary file is left if the ObjectNode crashed while writing it
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		s.names = append(s.names, name)
+	}
+	sort.Strings(s.names)
+	return s, nil
+}
+
+func (s *notificationSpool) eventName() string {
+	return fmt.Sprintf("%016x-%08x%s", time.Now().UnixNano(), atomic.AddUint64(&s.seq, 1)&0xffffffff,
+		notificationEventFileSuffix)
+}
+
+// Push spools the event.
+func (s *notificationSpool) Push(data []byte) error {
+	s.mu.Lock()
+	full := s.limit > 0 && len(s.names) >= s.limit
+	s.mu.Unlock()
+	if full {
+		return errNotificationSpoolFull
+	}
+
+	name := s.eventName()
+	tmp := filepath.Join(s.dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	// the names are generated in order, except the events pushed concurrently
+	i := sort.SearchStrings(s.names, name)
+	s.names = append(s.names, "")
+	copy(s.names[i+1:], s.names[i:])
+	s.names[i] = name
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Peek returns the oldest event of the spool.
+func (s *notificationSpool) Peek() (name string, data []byte, ok bool) {
+	for {
+		s.mu.Lock()
+		if len(s.names) == 0 {
+			s.mu.Unlock()
+			return "", nil, false
+		}
+		name = s.names[0]
+		s.mu.Unlock()
+		var err error
+		if data, err = os.ReadFile(filepath.Join(s.dir, name)); err == nil {
+			return name, data, true
+		}
+		log.LogWarnf("notificationSpool: drop unreadable event file: dir(%v) name(%v) err(%v)", s.dir, name, err)
+		s.Remove(name)
+	}
+}
+
+// Remove removes the delivered event from the spool.
+func (s *notificationSpool) Remove(name string) {
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+		log.LogWarnf("notificationSpool: remove event file fail: dir(%v) name(%v) err(%v)", s.dir, name, err)
+	}
+	s.mu.Lock()
+	for i, n := range s.names {
+		if n == name {
+			s.names = append(s.names[:i], s.names[i+1:]...)
+			break
+		}
+	}
+	s.mu.Unlock()
+}
+
+// Notify returns the channel signaled when an event is spooled.
+func (s *notificationSpool) Notify() <-chan struct{} {
+	return s.notify
+}
+
+// Len returns the number of the events not delivered.
+func (s *notificationSpool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.names)
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationSpool(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newNotificationSpool(dir, 2)
+	require.NoError(t, err)
+	_, _, ok := s.Peek()
+	require.False(t, ok)
+
+	require.NoError(t, s.Push([]byte("1")))
+	require.NoError(t, s.Push([]byte("2")))
+	require.Equal(t, errNotificationSpoolFull, s.Push([]byte("3")))
+	require.Equal(t, 2, s.Len())
+	select {
+	case <-s.Notify():
+	default:
+		t.Fatal("spool is not notified")
+	}
+
+	name, data, ok := s.Peek()
+	require.True(t, ok)
+	require.Equal(t, "1", string(data))
+	s.Remove(name)
+	_, err = os.Stat(filepath.Join(dir, name))
+	require.True(t, os.IsNotExist(err))
+
+	// the events not delivered are reloaded in order, and the temporary file is dropped
+	require.NoError(t, s.Push([]byte("4")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken"+notificationEventFileSuffix+".tmp"), []byte("5"), 0o644))
+	s, err = newNotificationSpool(dir, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, s.Len())
+	for _, expected := range []string{"2", "4"} {
+		name, data, ok = s.Peek()
+		require.True(t, ok)
+		require.Equal(t, expected, string(data))
+		s.Remove(name)
+	}
+	require.Equal(t, 0, s.Len())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 0)
+}
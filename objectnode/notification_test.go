@@ -0,0 +1,164 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNotificationConfig(t *testing.T) {
+	cases := []struct {
+		config string
+		valid  bool
+	}{
+		{`<NotificationConfiguration></NotificationConfiguration>`, true},
+		{`<NotificationConfiguration><QueueConfiguration><Queue>arn:cubefs:sqs::cfs:uploads</Queue>
+			<Event>s3:ObjectCreated:*</Event></QueueConfiguration></NotificationConfiguration>`, true},
+		{`<NotificationConfiguration><TopicConfiguration><Id>t</Id><Topic>arn:cubefs:sns::cfs:uploads</Topic>
+			<Event>s3:ObjectRemoved:Delete</Event><Filter><S3Key><FilterRule><Name>Prefix</Name><Value>images/</Value></FilterRule>
+			<FilterRule><Name>suffix</Name><Value>.jpg</Value></FilterRule></S3Key></Filter></TopicConfiguration></NotificationConfiguration>`, true},
+		// invalid ARN
+		{`<NotificationConfiguration><QueueConfiguration><Queue>uploads</Queue>
+			<Event>s3:ObjectCreated:*</Event></QueueConfiguration></NotificationConfiguration>`, false},
+		// no event
+		{`<NotificationConfiguration><QueueConfiguration><Queue>arn:cubefs:sqs::cfs:uploads</Queue>
+			</QueueConfiguration></NotificationConfiguration>`, false},
+		// unsupported event
+		{`<NotificationConfiguration><QueueConfiguration><Queue>arn:cubefs:sqs::cfs:uploads</Queue>
+			<Event>s3:Replication:*</Event></QueueConfiguration></NotificationConfiguration>`, false},
+		// duplicate prefix rules
+		{`<NotificationConfiguration><QueueConfiguration><Queue>arn:cubefs:sqs::cfs:uploads</Queue>
+			<Event>s3:ObjectCreated:*</Event><Filter><S3Key><FilterRule><Name>prefix</Name><Value>a</Value></FilterRule>
+			<FilterRule><Name>prefix</Name><Value>b</Value></FilterRule></S3Key></Filter></QueueConfiguration></NotificationConfiguration>`, false},
+		// duplicate ids
+		{`<NotificationConfiguration><QueueConfiguration><Id>a</Id><Queue>arn:cubefs:sqs::cfs:uploads</Queue>
+			<Event>s3:ObjectCreated:*</Event></QueueConfiguration><TopicConfiguration><Id>a</Id><Topic>arn:cubefs:sns::cfs:uploads</Topic>
+			<Event>s3:ObjectCreated:*</Event></TopicConfiguration></NotificationConfiguration>`, false},
+		{`<NotificationConfiguration>`, false},
+	}
+	for i, c := range cases {
+		_, errCode := ParseNotificationConfig([]byte(c.config))
+		require.Equal(t, c.valid, errCode == nil, "case %d: %v", i, errCode)
+	}
+}
+
+func TestNotificationMatch(t *testing.T) {
+	config, errCode := ParseNotificationConfig([]byte(`<NotificationConfiguration>
+		<QueueConfiguration><Id>created</Id><Queue>arn:cubefs:sqs::cfs:uploads</Queue><Event>s3:ObjectCreated:*</Event>
+			<Filter><S3Key><FilterRule><Name>prefix</Name><Value>images/</Value></FilterRule>
+			<FilterRule><Name>suffix</Name><Value>.jpg</Value></FilterRule></S3Key></Filter></QueueConfiguration>
+		<TopicConfiguration><Topic>arn:cubefs:sns::cfs:audit</Topic><Event>s3:ObjectRemoved:Delete</Event>
+			<Event>s3:ObjectCreated:Copy</Event></TopicConfiguration>
+	</NotificationConfiguration>`))
+	require.Nil(t, errCode)
+	require.Equal(t, "uploads", config.Queues[0].Target())
+	require.Equal(t, "notification-2", config.Topics[0].ID)
+
+	targets := func(event, key string) (ids []string) {
+		for _, rule := range config.Match(event, key) {
+			ids = append(ids, rule.ID)
+		}
+		return
+	}
+	require.Equal(t, []string{"created"}, targets(EventObjectCreatedPut, "images/a.jpg"))
+	require.Equal(t, []string{"created", "notification-2"}, targets(EventObjectCreatedCopy, "images/a.jpg"))
+	require.Nil(t, targets(EventObjectCreatedPut, "images/a.png"))
+	require.Nil(t, targets(EventObjectCreatedPut, "docs/a.jpg"))
+	require.Equal(t, []string{"notification-2"}, targets(EventObjectRemovedDelete, "images/a.jpg"))
+	require.Nil(t, targets(EventObjectRemovedDeleteMarkerCreated, "images/a.jpg"))
+	require.Nil(t, (*NotificationConfiguration)(nil).Match(EventObjectCreatedPut, "a"))
+}
+
+func TestNotifierDelivery(t *testing.T) {
+	var (
+		requests int32
+		received = make(chan []byte, 1)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the target is down at the first delivery
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		received <- data
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(NotificationConfig{
+		SpoolDir: t.TempDir(),
+		Webhook:  map[string]WebhookConfig{"pipeline": {Endpoint: server.URL}},
+	})
+	require.NoError(t, err)
+	notifier.Start()
+	defer notifier.Close()
+
+	config, errCode := ParseNotificationConfig([]byte(`<NotificationConfiguration><QueueConfiguration><Id>uploads</Id>
+		<Queue>arn:cubefs:sqs::cfs:pipeline</Queue><Event>s3:ObjectCreated:*</Event></QueueConfiguration></NotificationConfiguration>`))
+	require.Nil(t, errCode)
+	require.Nil(t, notifier.ValidateConfig(config))
+	missing, _ := ParseNotificationConfig([]byte(`<NotificationConfiguration><QueueConfiguration>
+		<Queue>arn:cubefs:sqs::cfs:missing</Queue><Event>s3:ObjectCreated:*</Event></QueueConfiguration></NotificationConfiguration>`))
+	require.NotNil(t, notifier.ValidateConfig(missing))
+
+	notifier.Notify(config, &EventInfo{
+		Event:     EventObjectCreatedPut,
+		Region:    "cfs_dev",
+		Bucket:    "photos",
+		Owner:     "owner",
+		Key:       "a b.jpg",
+		Size:      3,
+		ETag:      "etag",
+		VersionId: "v1",
+		RequestID: "req",
+		Requester: "user",
+		SourceIP:  "127.0.0.1",
+		Time:      time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	notifier.Notify(config, &EventInfo{Event: EventObjectRemovedDelete, Bucket: "photos", Key: "a"})
+
+	var data []byte
+	select {
+	case data = <-received:
+	case <-time.After(10 * time.Second):
+		t.Fatal("event is not delivered")
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	event := &NotificationEvent{}
+	require.NoError(t, json.Unmarshal(data, event))
+	require.Len(t, event.Records, 1)
+	record := event.Records[0]
+	require.Equal(t, "ObjectCreated:Put", record.EventName)
+	require.Equal(t, "cfs_dev", record.AwsRegion)
+	require.Equal(t, "2023-01-02T03:04:05.000Z", record.EventTime)
+	require.Equal(t, "uploads", record.S3.ConfigurationID)
+	require.Equal(t, "photos", record.S3.Bucket.Name)
+	require.Equal(t, "a+b.jpg", record.S3.Object.Key)
+	require.Equal(t, int64(3), record.S3.Object.Size)
+	require.Equal(t, "v1", record.S3.Object.VersionID)
+	require.Equal(t, "req", record.ResponseElements["x-amz-request-id"])
+
+	require.Eventually(t, func() bool {
+		return notifier.dispatchers["pipeline"].spool.Len() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
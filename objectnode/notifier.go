@@ -0,0 +1,380 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultNotificationSpoolEvents = 1000000
+
+	notificationEventVersion  = "2.1"
+	notificationEventSource   = "cubefs:s3"
+	notificationSchemaVersion = "1.0"
+	notificationMinRetryDelay = time.Second
+	notificationMaxRetryDelay = 5 * time.Minute
+	notificationSendTimeout   = 10 * time.Second
+
+	MetricNotificationPending   = "notification_pending_events"
+	MetricNotificationDelivered = "notification_delivered"
+	MetricNotificationDropped   = "notification_dropped"
+)
+
+var NotificationWebhookUserAgent = "Golang cubefs/objectnode notification webhook"
+
+// NotificationConfig is the configuration of the bucket event notification on the ObjectNode.
+// The key of the target maps is the id of the target referred by the ARN of the notification
+// rules, which must be unique among all the targets.
+type NotificationConfig struct {
+	SpoolDir       string                   `json:"spoolDir"`
+	MaxSpoolEvents int                      `json:"maxSpoolEvents,omitempty"`
+	Kafka          map[string]KafkaConfig   `json:"kafka,omitempty"`
+	Webhook        map[string]WebhookConfig `json:"webhook,omitempty"`
+}
+
+// NotificationEvent is the message delivered to the targets, in the format of the S3 event.
+type NotificationEvent struct {
+	Records []NotificationRecord `json:"Records"`
+}
+
+type NotificationRecord struct {
+	EventVersion      string                    `json:"eventVersion"`
+	EventSource       string                    `json:"eventSource"`
+	AwsRegion         string                    `json:"awsRegion"`
+	EventTime         string                    `json:"eventTime"`
+	EventName         string                    `json:"eventName"`
+	UserIdentity      NotificationIdentity      `json:"userIdentity"`
+	RequestParameters NotificationRequestParams `json:"requestParameters"`
+	ResponseElements  map[string]string         `json:"responseElements"`
+	S3                NotificationS3            `json:"s3"`
+}
+
+type NotificationIdentity struct {
+	PrincipalID string `json:"principalId"`
+}
+
+type NotificationRequestParams struct {
+	SourceIPAddress string `json:"sourceIPAddress"`
+}
+
+type NotificationS3 struct {
+	SchemaVersion   string             `json:"s3SchemaVersion"`
+	ConfigurationID string             `json:"configurationId"`
+	Bucket          NotificationBucket `json:"bucket"`
+	Object          NotificationObject `json:"object"`
+}
+
+type NotificationBucket struct {
+	Name          string               `json:"name"`
+	OwnerIdentity NotificationIdentity `json:"ownerIdentity"`
+	ARN           string               `json:"arn"`
+}
+
+type NotificationObject struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size,omitempty"`
+	ETag      string `json:"eTag,omitempty"`
+	VersionID string `json:"versionId,omitempty"`
+	Sequencer string `json:"sequencer"`
+}
+
+// EventInfo is the information of an object event to notify.
+type EventInfo struct {
+	Event     string
+	Region    string
+	Bucket    string
+	Owner     string
+	Key       string
+	Size      int64
+	ETag      string
+	VersionId string
+	RequestID string
+	Requester string
+	SourceIP  string
+	Time      time.Time
+}
+
+func newNotificationRecord(rule *NotificationRule, info *EventInfo) NotificationRecord {
+	return NotificationRecord{
+		EventVersion:      notificationEventVersion,
+		EventSource:       notificationEventSource,
+		AwsRegion:         info.Region,
+		EventTime:         info.Time.UTC().Format("2006-01-02T15:04:05.000Z"),
+		EventName:         strings.TrimPrefix(info.Event, "s3:"),
+		UserIdentity:      NotificationIdentity{PrincipalID: info.Requester},
+		RequestParameters: NotificationRequestParams{SourceIPAddress: info.SourceIP},
+		ResponseElements:  map[string]string{"x-amz-request-id": info.RequestID},
+		S3: NotificationS3{
+			SchemaVersion:   notificationSchemaVersion,
+			ConfigurationID: rule.ID,
+			Bucket: NotificationBucket{
+				Name:          info.Bucket,
+				OwnerIdentity: NotificationIdentity{PrincipalID: info.Owner},
+				ARN:           "arn:aws:s3:::" + info.Bucket,
+			},
+			Object: NotificationObject{
+				Key:       url.QueryEscape(info.Key),
+				Size:      info.Size,
+				ETag:      info.ETag,
+				VersionID: info.VersionId,
+				Sequencer: strings.ToUpper(strconv.FormatInt(info.Time.UnixNano(), 16)),
+			},
+		},
+	}
+}
+
+// notificationTarget delivers the events to the external system.
+type notificationTarget interface {
+	Send(data []byte) error
+	Close() error
+}
+
+// kafkaNotificationTarget connects to the kafka brokers on demand, so that the events are
+// spooled instead of failing the startup of the ObjectNode if the brokers are down.
+type kafkaNotificationTarget struct {
+	conf KafkaConfig
+
+	mu       sync.Mutex
+	producer sarama.SyncProducer
+}
+
+func (k *kafkaNotificationTarget) Send(data []byte) (err error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.producer == nil {
+		if k.producer, err = k.conf.BuildSyncProducer(); err != nil {
+			return
+		}
+	}
+	if _, _, err = k.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: k.conf.Topic,
+		Value: sarama.ByteEncoder(data),
+	}); err != nil {
+		// reconnect at the next delivery
+		k.producer.Close()
+		k.producer = nil
+	}
+	return
+}
+
+func (k *kafkaNotificationTarget) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.producer == nil {
+		return nil
+	}
+	err := k.producer.Close()
+	k.producer = nil
+	return err
+}
+
+type webhookNotificationTarget struct {
+	conf   WebhookConfig
+	client *http.Client
+}
+
+func (w *webhookNotificationTarget) Send(data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.conf.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(ContentType, ValueContentTypeJSON)
+	req.Header.Set(UserAgent, NotificationWebhookUserAgent)
+	if w.conf.Authorization != "" {
+		req.Header.Set(Authorization, w.conf.Authorization)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notificationSendTimeout)
+	defer cancel()
+	resp, err := w.client.Do(req.WithContext(ctx))
+	if resp != nil && resp.Body != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if err != nil || resp.StatusCode/100 == 2 {
+		return err
+	}
+
+	return fmt.Errorf("%s returns '%s' statuscode", w.conf.Endpoint, resp.Status)
+}
+
+func (w *webhookNotificationTarget) Close() error {
+	return nil
+}
+
+type notificationDispatcher struct {
+	id     string
+	target notificationTarget
+	spool  *notificationSpool
+}
+
+// Notifier delivers the bucket events to the targets at least once. The events are spooled
+// locally before delivered, and the delivery to a target is retried until it succeeds.
+type Notifier struct {
+	dispatchers map[string]*notificationDispatcher
+
+	pending   *exporter.Gauge
+	delivered *exporter.Counter
+	dropped   *exporter.Counter
+
+	stopC chan struct{}
+	wg    sync.WaitGroup
+}
+
+func NewNotifier(conf NotificationConfig) (*Notifier, error) {
+	if conf.SpoolDir == "" {
+		return nil, errors.New("spool directory is not specified")
+	}
+	if len(conf.Kafka)+len(conf.Webhook) == 0 {
+		return nil, errors.New("no notification target")
+	}
+	if conf.MaxSpoolEvents <= 0 {
+		conf.MaxSpoolEvents = defaultNotificationSpoolEvents
+	}
+	targets := make(map[string]notificationTarget)
+	for id, kc := range conf.Kafka {
+		if err := kc.FixConfig(); err != nil {
+			return nil, fmt.Errorf("target %v: %v", id, err)
+		}
+		targets[id] = &kafkaNotificationTarget{conf: kc}
+	}
+	for id, wc := range conf.Webhook {
+		if _, ok := targets[id]; ok {
+			return nil, fmt.Errorf("duplicate target %v", id)
+		}
+		if err := wc.FixConfig(); err != nil {
+			return nil, fmt.Errorf("target %v: %v", id, err)
+		}
+		client, err := wc.BuildClient()
+		if err != nil {
+			return nil, fmt.Errorf("target %v: %v", id, err)
+		}
+		targets[id] = &webhookNotificationTarget{conf: wc, client: client}
+	}
+
+	n := &Notifier{
+		dispatchers: make(map[string]*notificationDispatcher),
+		pending:     exporter.NewGauge(MetricNotificationPending),
+		delivered:   exporter.NewCounter(MetricNotificationDelivered),
+		dropped:     exporter.NewCounter(MetricNotificationDropped),
+		stopC:       make(chan struct{}),
+	}
+	for id, target := range targets {
+		spool, err := newNotificationSpool(filepath.Join(conf.SpoolDir, id), conf.MaxSpoolEvents)
+		if err != nil {
+			return nil, err
+		}
+		n.dispatchers[id] = &notificationDispatcher{id: id, target: target, spool: spool}
+	}
+	return n, nil
+}
+
+func (n *Notifier) Start() {
+	for _, d := range n.dispatchers {
+		n.wg.Add(1)
+		go n.dispatch(d)
+	}
+}
+
+func (n *Notifier) Close() {
+	close(n.stopC)
+	n.wg.Wait()
+	for _, d := range n.dispatchers {
+		d.target.Close()
+	}
+}
+
+// ValidateConfig checks all the targets of the notification rules are available.
+func (n *Notifier) ValidateConfig(config *NotificationConfiguration) *ErrorCode {
+	for _, rule := range config.rules() {
+		if _, ok := n.dispatchers[rule.Target()]; !ok {
+			return invalidNotificationArgument(fmt.Sprintf("Unable to validate the destination %v.", rule.ARN()))
+		}
+	}
+	return nil
+}
+
+// Notify spools the event for all the notification rules matched.
+func (n *Notifier) Notify(config *NotificationConfiguration, info *EventInfo) {
+	for _, rule := range config.Match(info.Event, info.Key) {
+		d, ok := n.dispatchers[rule.Target()]
+		if !ok {
+			log.LogWarnf("Notifier: target not found: volume(%v) path(%v) rule(%v) target(%v)",
+				info.Bucket, info.Key, rule.ID, rule.Target())
+			continue
+		}
+		data, err := json.Marshal(&NotificationEvent{Records: []NotificationRecord{newNotificationRecord(rule, info)}})
+		if err != nil {
+			log.LogErrorf("Notifier: json marshal event fail: volume(%v) path(%v) err(%v)", info.Bucket, info.Key, err)
+			continue
+		}
+		if err = d.spool.Push(data); err != nil {
+			log.LogErrorf("Notifier: spool event fail: volume(%v) path(%v) event(%v) target(%v) err(%v)",
+				info.Bucket, info.Key, info.Event, d.id, err)
+			n.dropped.AddWithLabels(1, map[string]string{"target": d.id})
+		}
+	}
+}
+
+func (n *Notifier) dispatch(d *notificationDispatcher) {
+	defer n.wg.Done()
+	labels := map[string]string{"target": d.id}
+	delay := notificationMinRetryDelay
+	for {
+		n.pending.SetWithLabels(float64(d.spool.Len()), labels)
+		name, data, ok := d.spool.Peek()
+		if !ok {
+			select {
+			case <-n.stopC:
+				return
+			case <-d.spool.Notify():
+			}
+			continue
+		}
+		if err := d.target.Send(data); err != nil {
+			log.LogWarnf("Notifier: deliver event fail: target(%v) event(%v) retry(%v) err(%v)", d.id, name, delay, err)
+			select {
+			case <-n.stopC:
+				return
+			case <-time.After(delay):
+			}
+			if delay *= 2; delay > notificationMaxRetryDelay {
+				delay = notificationMaxRetryDelay
+			}
+			continue
+		}
+		delay = notificationMinRetryDelay
+		d.spool.Remove(name)
+		n.delivered.AddWithLabels(1, labels)
+	}
+}
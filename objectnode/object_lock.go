@@ -17,9 +17,12 @@ package objectnode
 import (
 	"encoding/xml"
 	"errors"
+	"os"
 	"strconv"
+	"syscall"
 	"time"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -37,6 +40,10 @@ var (
 const (
 	ComplianceMode = "COMPLIANCE"
 	Enabled        = "Enabled"
+	LegalHoldOn    = "ON"
+	LegalHoldOff   = "OFF"
+
+	MaxObjectLegalHoldSize = 1 << 10 // 1KB
 
 	MaxObjectLockSize     = 1 << 12 // 16KB
 	maximumRetentionDays  = 70 * 365
@@ -179,13 +186,39 @@ func storeObjectLock(bytes []byte, vol *Volume) (err error) {
 	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSLock, bytes)
 }
 
+type ObjectLegalHold struct {
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	XMLName xml.Name `xml:"LegalHold"`
+	Status  string   `xml:"Status"`
+}
+
+func ParseObjectLegalHold(data []byte) (*ObjectLegalHold, *ErrorCode) {
+	legalHold := &ObjectLegalHold{}
+	if err := xml.Unmarshal(data, legalHold); err != nil {
+		return nil, MalformedXML
+	}
+	if legalHold.Status != LegalHoldOn && legalHold.Status != LegalHoldOff {
+		return nil, MalformedXML
+	}
+	return legalHold, nil
+}
+
 func isObjectLocked(v *Volume, inode uint64, name, path string) error {
-	xattrInfo, err := v.mw.XAttrGet_ll(inode, XAttrKeyOSSLock)
+	xattrs, err := v.mw.BatchGetXAttr([]uint64{inode}, []string{XAttrKeyOSSLock, XAttrKeyOSSLegalHold})
 	if err != nil {
 		log.LogErrorf("isObjectLocked: check ObjectLock err(%v) volume(%v) path(%v) name(%v)",
 			err, v.name, path, name)
 		return err
 	}
+	if len(xattrs) == 0 {
+		return nil
+	}
+	xattrInfo := xattrs[0]
+	if string(xattrInfo.Get(XAttrKeyOSSLegalHold)) == LegalHoldOn {
+		log.LogWarnf("isObjectLocked: object is under legal hold, volume(%v) path(%v) name(%v)",
+			v.name, path, name)
+		return AccessDenied
+	}
 	retainUntilDate := xattrInfo.Get(XAttrKeyOSSLock)
 	if len(retainUntilDate) > 0 {
 		retainUntilDateInt64, err := strconv.ParseInt(string(retainUntilDate), 10, 64)
@@ -201,6 +234,46 @@ func isObjectLocked(v *Volume, inode uint64, name, path string) error {
 	return nil
 }
 
+// lookupObjectInode returns the inode of the object version, the current version is
+// returned if the version id is not specified.
+func (v *Volume) lookupObjectInode(path, versionId string) (inode uint64, err error) {
+	if versionId == "" {
+		return v.getInodeFromPath(path)
+	}
+	var mode os.FileMode
+	if inode, mode, err = v.lookupVersionInode(path, versionId); err == nil && mode.IsDir() {
+		err = syscall.ENOENT
+	}
+	return
+}
+
+// GetObjectLegalHold returns the legal hold status of the object version, it is empty
+// if the legal hold has never been set.
+func (v *Volume) GetObjectLegalHold(path, versionId string) (status string, err error) {
+	var inode uint64
+	if inode, err = v.lookupObjectInode(path, versionId); err != nil {
+		return
+	}
+	var xattr *proto.XAttrInfo
+	if xattr, err = v.mw.XAttrGet_ll(inode, XAttrKeyOSSLegalHold); err != nil {
+		return
+	}
+	return string(xattr.Get(XAttrKeyOSSLegalHold)), nil
+}
+
+// SetObjectLegalHold sets the legal hold status of the object version.
+func (v *Volume) SetObjectLegalHold(path, versionId, status string) (err error) {
+	var inode uint64
+	if inode, err = v.lookupObjectInode(path, versionId); err != nil {
+		return
+	}
+	if err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSLegalHold), []byte(status)); err != nil {
+		return
+	}
+	deleteAttrCache(inode, v.name)
+	return
+}
+
 func formatRetentionDateStr(modifyTime time.Time, retention *Retention) string {
 	retentionDateUnixNano := modifyTime.Add(retention.Duration).UnixNano()
 	return strconv.FormatInt(retentionDateUnixNano, 10)
@@ -217,3 +217,45 @@ func TestMarshalXML(t *testing.T) {
 	_, err := xml.Marshal(objectRetention)
 	require.NoError(t, err)
 }
+
+func TestParseObjectLegalHold(t *testing.T) {
+	tests := []struct {
+		value    string
+		status   string
+		expected *ErrorCode
+	}{
+		{
+			value:  `<LegalHold xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Status>ON</Status></LegalHold>`,
+			status: LegalHoldOn,
+		},
+		{
+			value:  `<LegalHold><Status>OFF</Status></LegalHold>`,
+			status: LegalHoldOff,
+		},
+		{
+			value:    `<LegalHold><Status>on</Status></LegalHold>`,
+			expected: MalformedXML,
+		},
+		{
+			value:    `<LegalHold></LegalHold>`,
+			expected: MalformedXML,
+		},
+		{
+			value:    `<LegalHold><Status>ON</Status>`,
+			expected: MalformedXML,
+		},
+	}
+	for _, tt := range tests {
+		legalHold, errCode := ParseObjectLegalHold([]byte(tt.value))
+		require.Equal(t, tt.expected, errCode)
+		if tt.expected == nil {
+			require.Equal(t, tt.status, legalHold.Status)
+		}
+	}
+
+	data, err := MarshalXMLEntity(&ObjectLegalHold{XMLNS: S3Namespace, Status: LegalHoldOn})
+	require.NoError(t, err)
+	legalHold, errCode := ParseObjectLegalHold(data)
+	require.Nil(t, errCode)
+	require.Equal(t, LegalHoldOn, legalHold.Status)
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"syscall"
 
 	"github.com/cubefs/cubefs/proto"
@@ -138,178 +139,270 @@ func (p *Policy) IsAllowed(params *RequestParam, reqUid, ownerUid string, condit
 	return result
 }
 
-func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var (
-			err error
-			ec  *ErrorCode
-		)
-		allowed := false
-		defer func() {
-			if allowed {
-				f(w, r)
-			} else {
-				if ec == nil && err == nil {
-					ec = AccessDenied
-				}
-				o.errorResponse(w, r, err, ec)
-			}
-		}()
-
-		param := ParseRequestParam(r)
-		if param.Bucket() == "" {
-			log.LogDebugf("policyCheck: no bucket specified: requestID(%v)", GetRequestID(r))
-			allowed = true
-			return
+// IsDeniedUnderPrefix checks whether any statement denies the api on a key under the prefix, the keys
+// moved by a prefix rename are not known until they are renamed.
+func (p *Policy) IsDeniedUnderPrefix(apiName, reqUid, prefix string, conditionCheck map[string]string) bool {
+	for _, s := range p.Statements {
+		if s.effect() != POLICY_DENY || !s.matchPrincipal(reqUid) || !s.matchAction(apiName) {
+			continue
 		}
+		if s.matchKeyPrefixInResource(prefix) && s.matchCondition(conditionCheck) {
+			return true
+		}
+	}
+	return false
+}
 
-		// step1. The account level api does not need to check any user policy and volume policy.
-		if IsAccountLevelApi(param.apiName) {
-			if !isAnonymous(param.accessKey) {
-				allowed = true
-				return
-			}
-			log.LogErrorf("policyCheck: anonymous user is not allowed by api(%v) requestID(%v)",
-				param.apiName, GetRequestID(r))
-			allowed = false
+func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed, ec, err := o.checkPolicy(r)
+		if allowed {
+			f(w, r)
 			return
 		}
-		if bucket := mux.Vars(r)[ContextKeyBucket]; len(bucket) > 0 {
-			if _, err = o.getVol(bucket); err != nil {
-				allowed = false
-				return
-			}
+		if ec == nil && err == nil {
+			ec = AccessDenied
 		}
+		o.errorResponse(w, r, err, ec)
+	}
+}
+
+// checkPolicy checks whether the request is allowed by the user policy, the bucket policy and the ACL.
+func (o *ObjectNode) checkPolicy(r *http.Request) (allowed bool, ec *ErrorCode, err error) {
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		log.LogDebugf("policyCheck: no bucket specified: requestID(%v)", GetRequestID(r))
+		allowed = true
+		return
+	}
 
-		// step2. Check user policy
-		userInfo := new(proto.UserInfo)
-		var userPolicy *proto.UserPolicy
-		isOwner := false
-		if isAnonymous(param.accessKey) && apiAllowAnonymous(param.apiName) {
-			log.LogDebugf("anonymous user: requestID(%v)", GetRequestID(r))
-			goto policycheck
+	// step1. The account level api does not need to check any user policy and volume policy.
+	if IsAccountLevelApi(param.apiName) {
+		if !isAnonymous(param.accessKey) {
+			allowed = true
+			return
 		}
-		if isAnonymous(param.accessKey) && !apiAllowAnonymous(param.apiName) {
-			log.LogErrorf("policyCheck: anonymous user is not allowed by api(%v) requestID(%v)",
-				param.apiName, GetRequestID(r))
+		log.LogErrorf("policyCheck: anonymous user is not allowed by api(%v) requestID(%v)",
+			param.apiName, GetRequestID(r))
+		allowed = false
+		return
+	}
+	if bucket := mux.Vars(r)[ContextKeyBucket]; len(bucket) > 0 {
+		if _, err = o.getVol(bucket); err != nil {
 			allowed = false
 			return
 		}
-		userInfo, err = o.getUserInfoByAccessKey(param.AccessKey())
+	}
+
+	// step2. Check user policy
+	userInfo := new(proto.UserInfo)
+	var userPolicy *proto.UserPolicy
+	isOwner := false
+	if isAnonymous(param.accessKey) && apiAllowAnonymous(param.apiName) {
+		log.LogDebugf("anonymous user: requestID(%v)", GetRequestID(r))
+		goto policycheck
+	}
+	if isAnonymous(param.accessKey) && !apiAllowAnonymous(param.apiName) {
+		log.LogErrorf("policyCheck: anonymous user is not allowed by api(%v) requestID(%v)",
+			param.apiName, GetRequestID(r))
+		allowed = false
+		return
+	}
+	userInfo, err = o.getUserInfoByAccessKey(param.AccessKey())
+	if err != nil {
+		log.LogErrorf("user policy check: load user policy from master fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		allowed = false
+		return
+	}
+	// White list for admin and root user.
+	if userInfo.UserType == proto.UserTypeRoot || userInfo.UserType == proto.UserTypeAdmin {
+		log.LogDebugf("user policy check: user is admin: requestID(%v) userID(%v) accessKey(%v) volume(%v)",
+			GetRequestID(r), userInfo.UserID, param.AccessKey(), param.Bucket())
+		allowed = true
+		return
+	}
+	userPolicy = userInfo.Policy
+	isOwner = userPolicy.IsOwn(param.Bucket())
+	// The bucket is not owned by request user who has not been authorized, so bucket policy should be checked.
+	if !isOwner && userPolicy.IsAuthorizedS3(param.Bucket(), param.apiName) {
+		log.LogInfof("user policy check:  permission url(%v) requestID(%v) userID(%v) accessKey(%v) volume(%v) object(%v) action(%v) authorizedVols(%v)",
+			r.URL, GetRequestID(r), userInfo.UserID, param.AccessKey(), param.Bucket(), param.Object(), param.Action(), userPolicy.AuthorizedVols)
+		allowed = true
+		return
+	}
+	// copy api should check srcBucket policy additionally
+	if param.apiName == COPY_OBJECT || param.apiName == UPLOAD_PART_COPY {
+		err = o.allowedBySrcBucketPolicy(param, userInfo.UserID)
 		if err != nil {
-			log.LogErrorf("user policy check: load user policy from master fail: requestID(%v) accessKey(%v) err(%v)",
-				GetRequestID(r), param.AccessKey(), err)
-			allowed = false
 			return
 		}
-		// White list for admin and root user.
-		if userInfo.UserType == proto.UserTypeRoot || userInfo.UserType == proto.UserTypeAdmin {
-			log.LogDebugf("user policy check: user is admin: requestID(%v) userID(%v) accessKey(%v) volume(%v)",
-				GetRequestID(r), userInfo.UserID, param.AccessKey(), param.Bucket())
-			allowed = true
+	}
+	// rename api should check the deletion of the source additionally
+	if param.apiName == RENAME_OBJECT {
+		err = o.allowedByRenameSourcePolicy(param, userInfo.UserID)
+		if err != nil {
 			return
 		}
-		userPolicy = userInfo.Policy
-		isOwner = userPolicy.IsOwn(param.Bucket())
-		// The bucket is not owned by request user who has not been authorized, so bucket policy should be checked.
-		if !isOwner && userPolicy.IsAuthorizedS3(param.Bucket(), param.apiName) {
-			log.LogInfof("user policy check:  permission url(%v) requestID(%v) userID(%v) accessKey(%v) volume(%v) object(%v) action(%v) authorizedVols(%v)",
-				r.URL, GetRequestID(r), userInfo.UserID, param.AccessKey(), param.Bucket(), param.Object(), param.Action(), userPolicy.AuthorizedVols)
-			allowed = true
-			return
+	}
+	// batch delete will delay to check just before delete for each key
+	if param.apiName == BATCH_DELETE {
+		log.LogDebugf("user policy check: delete objects delay check: requestID(%v) userID(%v) volume(%v)",
+			GetRequestID(r), userInfo.UserID, param.Bucket())
+		allowed = true
+		return
+	}
+
+	// step3. Check bucket policy
+policycheck:
+	vol, acl, policy, err := o.loadBucketMeta(param.Bucket())
+	if err != nil {
+		log.LogErrorf("bucket policy check: load bucket metadata fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		allowed = false
+		return
+	}
+	log.LogDebugf("bucket policy check: load bucket metadata, requestID(%v) userPolicy(%v/%+v) vol(%v/%v) acl(%+v) policy(%+v)",
+		GetRequestID(r), userInfo.UserID, userInfo.Policy, vol.Name(), vol.GetOwner(), acl, policy)
+	if vol != nil && policy != nil && !policy.IsEmpty() {
+		log.LogDebugf("bucket policy check: requestID(%v) policy(%v)", GetRequestID(r), policy)
+		conditionCheck := map[string]string{
+			SOURCEIP: param.sourceIP,
+			REFERER:  param.r.Referer(),
+			HOST:     param.r.Host,
 		}
-		// copy api should check srcBucket policy additionally
-		if param.apiName == COPY_OBJECT || param.apiName == UPLOAD_PART_COPY {
-			err = o.allowedBySrcBucketPolicy(param, userInfo.UserID)
-			if err != nil {
-				return
-			}
+		if !IsBucketApi(param.apiName) {
+			conditionCheck[KEYNAME] = param.object
 		}
-		// batch delete will delay to check just before delete for each key
-		if param.apiName == BATCH_DELETE {
-			log.LogDebugf("user policy check: delete objects delay check: requestID(%v) userID(%v) volume(%v)",
-				GetRequestID(r), userInfo.UserID, param.Bucket())
+		pcr := policy.IsAllowed(param, userInfo.UserID, vol.owner, conditionCheck)
+		switch pcr {
+		case POLICY_ALLOW:
 			allowed = true
+			log.LogDebugf("bucket policy check: policy allowed: requestID(%v)", GetRequestID(r))
 			return
-		}
-
-		// step3. Check bucket policy
-	policycheck:
-		vol, acl, policy, err := o.loadBucketMeta(param.Bucket())
-		if err != nil {
-			log.LogErrorf("bucket policy check: load bucket metadata fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		case POLICY_DENY:
 			allowed = false
+			log.LogWarnf("bucket policy check: policy not allowed: requestID(%v) ", GetRequestID(r))
 			return
+		case POLICY_UNKNOW:
+			// policy check result is unknown so that acl should be checked
+			log.LogWarnf("bucket policy check: policy unknown: requestID(%v) ", GetRequestID(r))
+		default:
+			// do nothing
 		}
-		log.LogDebugf("bucket policy check: load bucket metadata, requestID(%v) userPolicy(%v/%+v) vol(%v/%v) acl(%+v) policy(%+v)",
-			GetRequestID(r), userInfo.UserID, userInfo.Policy, vol.Name(), vol.GetOwner(), acl, policy)
-		if vol != nil && policy != nil && !policy.IsEmpty() {
-			log.LogDebugf("bucket policy check: requestID(%v) policy(%v)", GetRequestID(r), policy)
-			conditionCheck := map[string]string{
-				SOURCEIP: param.sourceIP,
-				REFERER:  param.r.Referer(),
-				HOST:     param.r.Host,
-			}
-			if !IsBucketApi(param.apiName) {
-				conditionCheck[KEYNAME] = param.object
-			}
-			pcr := policy.IsAllowed(param, userInfo.UserID, vol.owner, conditionCheck)
-			switch pcr {
-			case POLICY_ALLOW:
-				allowed = true
-				log.LogDebugf("bucket policy check: policy allowed: requestID(%v)", GetRequestID(r))
-				return
-			case POLICY_DENY:
-				allowed = false
-				log.LogWarnf("bucket policy check: policy not allowed: requestID(%v) ", GetRequestID(r))
-				return
-			case POLICY_UNKNOW:
-				// policy check result is unknown so that acl should be checked
-				log.LogWarnf("bucket policy check: policy unknown: requestID(%v) ", GetRequestID(r))
-			default:
-				// do nothing
-			}
-		}
+	}
 
-		// step4. Check acl
-		if IsApiSupportByACL(param.Action()) {
-			if vol != nil && IsApiSupportByObjectAcl(param.Action()) {
-				if param.Object() == "" {
-					ec = InvalidKey
-					log.LogErrorf("acl check: no object key specified: requestID(%v) volume(%v) action(%v)",
-						GetRequestID(r), param.Bucket(), param.Action())
-					return
-				}
-				if acl, err = getObjectACL(vol, param.object, true); err != nil && err != syscall.ENOENT {
-					log.LogErrorf("acl check: get object acl fail: requestID(%v) volume(%v) action(%v) err(%v)",
-						GetRequestID(r), param.Bucket(), param.Action(), err)
-					return
-				}
-				err = nil
+	// step4. Check acl
+	if IsApiSupportByACL(param.Action()) {
+		if vol != nil && IsApiSupportByObjectAcl(param.Action()) {
+			if param.Object() == "" {
+				ec = InvalidKey
+				log.LogErrorf("acl check: no object key specified: requestID(%v) volume(%v) action(%v)",
+					GetRequestID(r), param.Bucket(), param.Action())
+				return
 			}
-			if acl == nil && !isOwner {
-				allowed = false
-				log.LogWarnf("acl check: empty acl disallows: requestID(%v) reqUid(%v) ownerUid(%v) volume(%v) action(%v)",
-					GetRequestID(r), userInfo.UserID, vol.GetOwner(), param.Bucket(), param.Action())
+			if acl, err = getObjectACL(vol, param.object, true); err != nil && err != syscall.ENOENT {
+				log.LogErrorf("acl check: get object acl fail: requestID(%v) volume(%v) action(%v) err(%v)",
+					GetRequestID(r), param.Bucket(), param.Action(), err)
 				return
 			}
-			if acl != nil && !acl.IsAllowed(userInfo.UserID, param.Action()) {
-				allowed = false
-				log.LogWarnf("acl check: acl not allowed: requestID(%v) reqUid(%v) acl(%+v) volume(%v) action(%v)",
-					GetRequestID(r), userInfo.UserID, acl, param.Bucket(), param.Action())
+			if acl, err = o.ignorePublicACL(vol, acl); err != nil {
+				log.LogErrorf("acl check: load public access block fail: requestID(%v) volume(%v) action(%v) err(%v)",
+					GetRequestID(r), param.Bucket(), param.Action(), err)
 				return
 			}
-		} else if !isOwner {
+		}
+		if acl == nil && !isOwner {
 			allowed = false
-			log.LogWarnf("acl check: action not support acl: requestID(%v) reqUid(%v) ownerUid(%v) volume(%v) action(%v)",
+			log.LogWarnf("acl check: empty acl disallows: requestID(%v) reqUid(%v) ownerUid(%v) volume(%v) action(%v)",
 				GetRequestID(r), userInfo.UserID, vol.GetOwner(), param.Bucket(), param.Action())
 			return
 		}
+		if acl != nil && !acl.IsAllowed(userInfo.UserID, param.Action()) {
+			allowed = false
+			log.LogWarnf("acl check: acl not allowed: requestID(%v) reqUid(%v) acl(%+v) volume(%v) action(%v)",
+				GetRequestID(r), userInfo.UserID, acl, param.Bucket(), param.Action())
+			return
+		}
+	} else if !isOwner {
+		allowed = false
+		log.LogWarnf("acl check: action not support acl: requestID(%v) reqUid(%v) ownerUid(%v) volume(%v) action(%v)",
+			GetRequestID(r), userInfo.UserID, vol.GetOwner(), param.Bucket(), param.Action())
+		return
+	}
 
-		allowed = true
-		log.LogDebugf("bucket acl check: action allowed: requestID(%v) reqUid(%v) accessKey(%v) volume(%v) action(%v)",
-			GetRequestID(r), userInfo, param.AccessKey(), param.Bucket(), param.Action())
+	allowed = true
+	log.LogDebugf("bucket acl check: action allowed: requestID(%v) reqUid(%v) accessKey(%v) volume(%v) action(%v)",
+		GetRequestID(r), userInfo, param.AccessKey(), param.Bucket(), param.Action())
+	return
+}
+
+// allowedByRenameSourcePolicy checks whether the source key of the rename can be deleted by the
+// requester, as the target key is checked as PutObject.
+func (o *ObjectNode) allowedByRenameSourcePolicy(param *RequestParam, reqUid string) (err error) {
+	paramCopy := *param
+	srcKey, err := extractRenameSourceKey(paramCopy.r, paramCopy.Bucket())
+	if err != nil {
+		log.LogDebugf("renameSource(%v) argument invalid: requestID(%v)", paramCopy.r.Header.Get(XAmzRenameSource), GetRequestID(paramCopy.r))
+		return
+	}
+	vol, acl, policy, err := o.loadBucketMeta(paramCopy.Bucket())
+	if err != nil {
+		log.LogErrorf("renameSource policy check: load bucket metadata fail: requestID(%v) err(%v)", GetRequestID(paramCopy.r), err)
+		return
+	}
+	paramCopy.apiName = DELETE_OBJECT
+	paramCopy.action = proto.OSSDeleteObjectAction
+	if vol != nil && policy != nil && !policy.IsEmpty() {
+		conditionCheck := map[string]string{
+			SOURCEIP: paramCopy.sourceIP,
+			KEYNAME:  srcKey,
+			REFERER:  paramCopy.r.Referer(),
+			HOST:     paramCopy.r.Host,
+		}
+		// the keys under the prefix are renamed as well, reject it if any of them may be denied
+		if strings.HasSuffix(srcKey, pathSep) {
+			dstCheck := make(map[string]string, len(conditionCheck))
+			for k, v := range conditionCheck {
+				dstCheck[k] = v
+			}
+			dstCheck[KEYNAME] = param.Object()
+			if policy.IsDeniedUnderPrefix(DELETE_OBJECT, reqUid, srcKey, conditionCheck) ||
+				policy.IsDeniedUnderPrefix(RENAME_OBJECT, reqUid, param.Object(), dstCheck) {
+				log.LogWarnf("renameSource policy check: policy denies keys under the prefix: requestID(%v) src(%v) dst(%v)",
+					GetRequestID(paramCopy.r), srcKey, param.Object())
+				return AccessDenied
+			}
+		}
+		pcr := policy.IsAllowed(&paramCopy, reqUid, vol.owner, conditionCheck)
+		switch pcr {
+		case POLICY_ALLOW:
+			log.LogDebugf("renameSource policy check: policy allowed: requestID(%v)", GetRequestID(paramCopy.r))
+			return
+		case POLICY_DENY:
+			log.LogWarnf("renameSource policy check: policy not allowed: requestID(%v) ", GetRequestID(paramCopy.r))
+			return AccessDenied
+		case POLICY_UNKNOW:
+			// policy check result is unknown so that acl should be checked
+			log.LogWarnf("renameSource policy check: policy unknown: requestID(%v) ", GetRequestID(paramCopy.r))
+		default:
+			// do nothing
+		}
 	}
+
+	isOwner := reqUid == vol.owner
+	if acl == nil && !isOwner {
+		log.LogWarnf("renameSource acl check: empty acl disallows: requestID(%v) reqUid(%v) ownerUid(%v) volume(%v) action(%v)",
+			GetRequestID(paramCopy.r), reqUid, vol.owner, paramCopy.Bucket(), paramCopy.Action())
+		return AccessDenied
+	}
+	if acl != nil && !acl.IsAllowed(reqUid, paramCopy.Action()) {
+		log.LogWarnf("renameSource acl check: acl not allowed: requestID(%v) reqUid(%v) acl(%+v) volume(%v) path(%v) action(%v)",
+			GetRequestID(paramCopy.r), reqUid, acl, paramCopy.Bucket(), srcKey, paramCopy.Action())
+		return AccessDenied
+	}
+	log.LogDebugf("renameSource acl check: action allowed: requestID(%v) accessKey(%v) volume(%v) action(%v)",
+		GetRequestID(paramCopy.r), paramCopy.AccessKey(), paramCopy.Bucket(), paramCopy.Action())
+	return
 }
 
 func (o *ObjectNode) loadBucketMeta(bucket string) (vol *Volume, acl *AccessControlPolicy, policy *Policy, err error) {
@@ -322,6 +415,13 @@ func (o *ObjectNode) loadBucketMeta(bucket string) (vol *Volume, acl *AccessCont
 	if policy, err = vol.metaLoader.loadPolicy(); err != nil {
 		return
 	}
+	// the public access of the bucket is blocked by the public access block
+	if acl, err = o.ignorePublicACL(vol, acl); err != nil {
+		return
+	}
+	if policy, err = o.restrictPublicPolicy(vol, policy); err != nil {
+		return
+	}
 	return
 }
 
@@ -369,7 +469,11 @@ func (o *ObjectNode) allowedBySrcBucketPolicy(param *RequestParam, reqUid string
 			GetRequestID(paramCopy.r), srcBucketId, srcKey, err)
 		return
 	}
-	err = nil
+	if acl, err = o.ignorePublicACL(vol, acl); err != nil {
+		log.LogErrorf("srcBucket acl check: load public access block fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(paramCopy.r), srcBucketId, srcKey, err)
+		return
+	}
 	if acl == nil && !isOwner {
 		log.LogWarnf("srcBucket acl check: empty acl disallows: requestID(%v) reqUid(%v) ownerUid(%v) volume(%v) action(%v)",
 			GetRequestID(paramCopy.r), reqUid, vol.owner, srcBucketId, paramCopy.Action())
@@ -41,8 +41,8 @@ const (
 
 // if more s3 api is supported by policy, need extend bucketApiList, objectApiList
 var (
-	bucketApiList = SliceString{LIST_OBJECTS, LIST_OBJECTS_V2, HEAD_BUCKET, DELETE_BUCKET, LIST_MULTIPART_UPLOADS, GET_BUCKET_LOCATION, GET_OBJECT_LOCK_CFG, PUT_OBJECT_LOCK_CFG}
-	objectApiList = SliceString{GET_OBJECT, HEAD_OBJECT, DELETE_OBJECT, PUT_OBJECT, POST_OBJECT, INITIALE_MULTIPART_UPLOAD, UPLOAD_PART, UPLOAD_PART_COPY, COMPLETE_MULTIPART_UPLOAD, COPY_OBJECT, ABORT_MULTIPART_UPLOAD, LIST_PARTS, BATCH_DELETE, GET_OBJECT_RETENTION}
+	bucketApiList = SliceString{LIST_OBJECTS, LIST_OBJECTS_V2, HEAD_BUCKET, DELETE_BUCKET, LIST_MULTIPART_UPLOADS, GET_BUCKET_LOCATION, GET_OBJECT_LOCK_CFG, PUT_OBJECT_LOCK_CFG, GET_BUCKET_OBJECT_VERSIONS, GET_BUCKET_VERSIONING, PUT_BUCKET_VERSIONING}
+	objectApiList = SliceString{GET_OBJECT, HEAD_OBJECT, DELETE_OBJECT, PUT_OBJECT, POST_OBJECT, INITIALE_MULTIPART_UPLOAD, UPLOAD_PART, UPLOAD_PART_COPY, COMPLETE_MULTIPART_UPLOAD, COPY_OBJECT, ABORT_MULTIPART_UPLOAD, LIST_PARTS, BATCH_DELETE, GET_OBJECT_RETENTION, SELECT_OBJECT_CONTENT, APPEND_OBJECT, RENAME_OBJECT}
 )
 
 type SliceString []string
@@ -64,6 +64,9 @@ const (
 	ACTION_GET_BUCKET_LOCATION           = "getbucketlocation"
 	ACTION_GET_OBJECT_LOCK_CFG           = "getobjectlockconfiguration"
 	ACTION_PUT_OBJECT_LOCK_CFG           = "putobjectlockconfiguration"
+	ACTION_LIST_BUCKET_VERSIONS          = "listbucketversions"
+	ACTION_GET_BUCKET_VERSIONING         = "getbucketversioning"
+	ACTION_PUT_BUCKET_VERSIONING         = "putbucketversioning"
 
 	// bucket + object level
 	ACTION_ANY = "*"
@@ -71,8 +74,8 @@ const (
 
 // action => api list, this should be consistent with bucketApiList&&objectApiList
 var S3ActionToApis = map[string]SliceString{
-	ACTION_PUT_OBJECT:                    {PUT_OBJECT, POST_OBJECT, COPY_OBJECT, INITIALE_MULTIPART_UPLOAD, UPLOAD_PART, UPLOAD_PART_COPY, COMPLETE_MULTIPART_UPLOAD},
-	ACTION_GET_OBJECT:                    {GET_OBJECT, HEAD_OBJECT},
+	ACTION_PUT_OBJECT:                    {PUT_OBJECT, POST_OBJECT, COPY_OBJECT, INITIALE_MULTIPART_UPLOAD, UPLOAD_PART, UPLOAD_PART_COPY, COMPLETE_MULTIPART_UPLOAD, APPEND_OBJECT, RENAME_OBJECT},
+	ACTION_GET_OBJECT:                    {GET_OBJECT, HEAD_OBJECT, SELECT_OBJECT_CONTENT},
 	ACTION_DELETE_OBJECT:                 {DELETE_OBJECT, BATCH_DELETE},
 	ACTION_ABORT_MULTIPART_UPLOAD:        {ABORT_MULTIPART_UPLOAD},
 	ACTION_LIST_BUCKET:                   {LIST_OBJECTS, LIST_OBJECTS_V2, HEAD_BUCKET},
@@ -83,12 +86,15 @@ var S3ActionToApis = map[string]SliceString{
 	ACTION_GET_OBJECT_LOCK_CFG:           {GET_OBJECT_LOCK_CFG},
 	ACTION_PUT_OBJECT_LOCK_CFG:           {PUT_OBJECT_LOCK_CFG},
 	ACTION_GET_OBJECT_RETENTION:          {GET_OBJECT_RETENTION},
+	ACTION_LIST_BUCKET_VERSIONS:          {GET_BUCKET_OBJECT_VERSIONS},
+	ACTION_GET_BUCKET_VERSIONING:         {GET_BUCKET_VERSIONING},
+	ACTION_PUT_BUCKET_VERSIONING:         {PUT_BUCKET_VERSIONING},
 }
 
 var allowAnonymousActions = SliceString{ACTION_GET_OBJECT}
 
 // if more bucket actions support policy, need extend validBucketActions
-var validBucketActions = SliceString{ACTION_LIST_BUCKET, ACTION_DELETE_BUCKET, ACTION_LIST_BUCKET_MULTIPART_UPLOADS, ACTION_GET_BUCKET_LOCATION, ACTION_PUT_OBJECT_LOCK_CFG, ACTION_GET_OBJECT_LOCK_CFG}
+var validBucketActions = SliceString{ACTION_LIST_BUCKET, ACTION_DELETE_BUCKET, ACTION_LIST_BUCKET_MULTIPART_UPLOADS, ACTION_GET_BUCKET_LOCATION, ACTION_PUT_OBJECT_LOCK_CFG, ACTION_GET_OBJECT_LOCK_CFG, ACTION_LIST_BUCKET_VERSIONS, ACTION_GET_BUCKET_VERSIONING, ACTION_PUT_BUCKET_VERSIONING}
 
 func isPolicyApi(apiName string) bool {
 	return apiName == PUT_BUCKET_POLICY || apiName == GET_BUCKET_POLICY || apiName == DELETE_BUCKET_POLICY
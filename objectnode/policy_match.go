@@ -201,6 +201,21 @@ func (s *Statement) matchKeyInResource(keyname string) bool { // 可以处理 ke
 	}
 }
 
+// matchKeyPrefixInResource checks whether any key resource of the statement could match a key under the prefix.
+func (s *Statement) matchKeyPrefixInResource(prefix string) bool {
+	switch r := s.Resource.(type) {
+	case string:
+		return ResourceElement(r).matchPrefix(prefix)
+	case []interface{}:
+		for _, r1 := range r {
+			if r2, ok := r1.(string); ok && ResourceElement(r2).matchPrefix(prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func ResourceElement(r string) ResourceElementType {
 	r1 := strings.TrimPrefix(r, S3_RESOURCE_PREFIX)
 	return ResourceElementType(r1)
@@ -230,6 +245,27 @@ func (r ResourceElementType) match(keyname string) bool {
 	return ok
 }
 
+// matchPrefix checks whether the key pattern could match a key under the prefix, the patterns with
+// a wildcard before the end of the prefix are taken as matched.
+func (r ResourceElementType) matchPrefix(prefix string) bool {
+	if !r.isKeyFormat() {
+		return false
+	}
+	r1 := string(r)
+	rawPattern := r1[strings.Index(r1, "/")+1:]
+	if rawPattern == "" {
+		return false
+	}
+	literal := rawPattern
+	if i := strings.IndexAny(rawPattern, "*?"); i >= 0 {
+		literal = rawPattern[:i]
+		if strings.HasPrefix(prefix, literal) {
+			return true
+		}
+	}
+	return strings.HasPrefix(literal, prefix)
+}
+
 func makeRegexPattern(raw string) string {
 	pattern := strings.Replace(raw, "?", ".", -1)
 	pattern = strings.Replace(pattern, "*", ".*", -1)
@@ -323,3 +323,38 @@ func TestStringNotLikeMatch(t *testing.T) {
 		require.False(t, result)
 	}
 }
+
+func TestIsDeniedUnderPrefix(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`{"Version":"2012-10-17","Statement":[
+{"Effect":"Allow","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::bucket/*"},
+{"Effect":"Deny","Principal":"*","Action":"s3:DeleteObject","Resource":"arn:aws:s3:::bucket/dir/protected/*"}]}`))
+	require.NoError(t, err)
+	for _, c := range []struct {
+		prefix string
+		denied bool
+	}{
+		{"dir/", true},
+		{"dir/protected/", true},
+		{"dir/protected/sub/", true},
+		{"dir/other/", false},
+		{"other/", false},
+	} {
+		require.Equal(t, c.denied, policy.IsDeniedUnderPrefix(DELETE_OBJECT, "1001", c.prefix, map[string]string{KEYNAME: c.prefix}), c.prefix)
+	}
+	require.False(t, policy.IsDeniedUnderPrefix(GET_OBJECT, "1001", "dir/", map[string]string{KEYNAME: "dir/"}))
+
+	for _, c := range []struct {
+		resource string
+		prefix   string
+		matched  bool
+	}{
+		{"bucket/*", "dir/", true},
+		{"bucket/dir/a", "dir/", true},
+		{"bucket/d?r/*", "dir/", true},
+		{"bucket/dir", "dir/", false},
+		{"bucket/other/*", "dir/", false},
+		{"bucket", "dir/", false},
+	} {
+		require.Equal(t, c.matched, ResourceElement(c.resource).matchPrefix(c.prefix), c.resource)
+	}
+}
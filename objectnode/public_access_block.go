@@ -0,0 +1,273 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/access-control-block-public-access.html
+
+import (
+	"encoding/xml"
+	"net"
+	"strings"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const MaxPublicAccessBlockConfigSize = 1 << 10 // 1KB
+
+// PublicAccessBlockConfiguration is the public access block configuration of a bucket, it is
+// also used as the cluster-wide default configured on the ObjectNode. The settings of the
+// bucket and the default are combined, so that the most restrictive one takes effect.
+type PublicAccessBlockConfiguration struct {
+	XMLNS                 string   `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName               xml.Name `xml:"PublicAccessBlockConfiguration" json:"-"`
+	BlockPublicAcls       bool     `xml:"BlockPublicAcls" json:"blockPublicAcls"`
+	IgnorePublicAcls      bool     `xml:"IgnorePublicAcls" json:"ignorePublicAcls"`
+	BlockPublicPolicy     bool     `xml:"BlockPublicPolicy" json:"blockPublicPolicy"`
+	RestrictPublicBuckets bool     `xml:"RestrictPublicBuckets" json:"restrictPublicBuckets"`
+}
+
+func ParsePublicAccessBlockConfig(data []byte) (*PublicAccessBlockConfiguration, *ErrorCode) {
+	config := &PublicAccessBlockConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	return config, nil
+}
+
+// IsEmpty checks whether none of the public access is blocked by the configuration.
+func (c *PublicAccessBlockConfiguration) IsEmpty() bool {
+	return c == nil || !c.BlockPublicAcls && !c.IgnorePublicAcls && !c.BlockPublicPolicy && !c.RestrictPublicBuckets
+}
+
+// Merge returns the most restrictive combination of the two configurations.
+func (c *PublicAccessBlockConfiguration) Merge(other *PublicAccessBlockConfiguration) *PublicAccessBlockConfiguration {
+	if c.IsEmpty() {
+		return other
+	}
+	if other.IsEmpty() {
+		return c
+	}
+	return &PublicAccessBlockConfiguration{
+		BlockPublicAcls:       c.BlockPublicAcls || other.BlockPublicAcls,
+		IgnorePublicAcls:      c.IgnorePublicAcls || other.IgnorePublicAcls,
+		BlockPublicPolicy:     c.BlockPublicPolicy || other.BlockPublicPolicy,
+		RestrictPublicBuckets: c.RestrictPublicBuckets || other.RestrictPublicBuckets,
+	}
+}
+
+func (c *PublicAccessBlockConfiguration) blockPublicAcls() bool {
+	return c != nil && c.BlockPublicAcls
+}
+
+func (c *PublicAccessBlockConfiguration) ignorePublicAcls() bool {
+	return c != nil && c.IgnorePublicAcls
+}
+
+func (c *PublicAccessBlockConfiguration) blockPublicPolicy() bool {
+	return c != nil && c.BlockPublicPolicy
+}
+
+func (c *PublicAccessBlockConfiguration) restrictPublicBuckets() bool {
+	return c != nil && c.RestrictPublicBuckets
+}
+
+func storeBucketPublicAccessBlock(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSPublicBlock, bytes)
+}
+
+func deleteBucketPublicAccessBlock(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSPublicBlock)
+}
+
+// isPublic checks whether the grant gives the permission to everyone or any authenticated user.
+func (g *Grant) isPublic() bool {
+	return g.Grantee.Type == TypeGroup && (g.Grantee.URI == GroupAllUser || g.Grantee.URI == GroupAuthenticated)
+}
+
+// IsPublic checks whether any permission is granted to everyone or any authenticated user by the ACL.
+func (acp *AccessControlPolicy) IsPublic() bool {
+	if acp == nil {
+		return false
+	}
+	for i := range acp.Acl.Grants {
+		if acp.Acl.Grants[i].isPublic() {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutPublicGrants returns a copy of the ACL which ignores the public grants.
+func (acp *AccessControlPolicy) withoutPublicGrants() *AccessControlPolicy {
+	if !acp.IsPublic() {
+		return acp
+	}
+	cp := &AccessControlPolicy{Owner: acp.Owner}
+	for _, g := range acp.Acl.Grants {
+		if !g.isPublic() {
+			cp.Acl.Grants = append(cp.Acl.Grants, g)
+		}
+	}
+	return cp
+}
+
+// isPublic checks whether the statement allows everyone to access the bucket. A statement with
+// the source ip condition is not public if the access is restricted to the specified networks,
+// none of which is broader than /8 for IPv4 or /32 for IPv6 as AWS does.
+func (s *Statement) isPublic() bool {
+	if !strings.EqualFold(s.Effect, Allow) {
+		return false
+	}
+	var principals []string
+	switch p := s.Principal.(type) {
+	case string:
+		principals = append(principals, p)
+	case map[string]interface{}:
+		switch pval := p[S3_PRINCIPAL_PREFIX].(type) {
+		case string:
+			principals = append(principals, pval)
+		case []interface{}:
+			for _, v := range pval {
+				if pv, ok := v.(string); ok {
+					principals = append(principals, pv)
+				}
+			}
+		}
+	}
+	var anyone bool
+	for _, principal := range principals {
+		if PrincipalElementType(principal) == Principal_Any {
+			anyone = true
+			break
+		}
+	}
+	if !anyone {
+		return false
+	}
+	for _, op := range s.Condition {
+		if ipOp, ok := op.(*ipAddressOp); ok && ipOp.isRestricted() {
+			return false
+		}
+	}
+	return true
+}
+
+// isRestricted checks whether every network of the source ip condition is fixed enough to
+// restrict the access, the ranges broader than /8 for IPv4 or /32 for IPv6 are public.
+func (op *ipAddressOp) isRestricted() bool {
+	infos := op.m[AWSSourceIP]
+	if len(infos) == 0 {
+		return false
+	}
+	for _, info := range infos {
+		ones, bits := info.Net.Mask.Size()
+		if bits == 8*net.IPv4len && ones < 8 || bits == 8*net.IPv6len && ones < 32 {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPublic checks whether any statement of the policy allows everyone to access the bucket.
+func (p *Policy) IsPublic() bool {
+	if p == nil {
+		return false
+	}
+	for i := range p.Statements {
+		if p.Statements[i].isPublic() {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutPublicStatements returns a copy of the policy which ignores the public statements,
+// the statements denying the access are kept.
+func (p *Policy) withoutPublicStatements() *Policy {
+	if !p.IsPublic() {
+		return p
+	}
+	cp := &Policy{Version: p.Version, Id: p.Id}
+	for _, s := range p.Statements {
+		if !s.isPublic() {
+			cp.Statements = append(cp.Statements, s)
+		}
+	}
+	return cp
+}
+
+// loadPublicAccessBlock returns the public access block configuration which takes effect on the
+// bucket, the cluster-wide default is returned if the volume is not specified.
+func (o *ObjectNode) loadPublicAccessBlock(vol *Volume) (config *PublicAccessBlockConfiguration, err error) {
+	config = o.publicAccessBlock
+	if vol == nil {
+		return
+	}
+	var bucketConfig *PublicAccessBlockConfiguration
+	if bucketConfig, err = vol.metaLoader.loadPublicAccessBlock(); err != nil {
+		return
+	}
+	return config.Merge(bucketConfig), nil
+}
+
+// checkPublicACL rejects the ACL granting the public access if the public ACLs are blocked.
+func (o *ObjectNode) checkPublicACL(vol *Volume, acl *AccessControlPolicy) error {
+	if !acl.IsPublic() {
+		return nil
+	}
+	config, err := o.loadPublicAccessBlock(vol)
+	if err != nil {
+		return err
+	}
+	if config.blockPublicAcls() {
+		if vol != nil {
+			log.LogWarnf("checkPublicACL: public acl is blocked: volume(%v) acl(%+v)", vol.Name(), acl)
+		}
+		return AccessDenied
+	}
+	return nil
+}
+
+// ignorePublicACL returns the ACL which takes effect on the authorization, the public grants
+// are ignored if the public ACLs are ignored on the bucket.
+func (o *ObjectNode) ignorePublicACL(vol *Volume, acl *AccessControlPolicy) (*AccessControlPolicy, error) {
+	if !acl.IsPublic() {
+		return acl, nil
+	}
+	config, err := o.loadPublicAccessBlock(vol)
+	if err != nil {
+		return nil, err
+	}
+	if config.ignorePublicAcls() {
+		return acl.withoutPublicGrants(), nil
+	}
+	return acl, nil
+}
+
+// restrictPublicPolicy returns the bucket policy which takes effect on the authorization, the
+// public statements are ignored if the access to the bucket with public policy is restricted.
+func (o *ObjectNode) restrictPublicPolicy(vol *Volume, policy *Policy) (*Policy, error) {
+	if !policy.IsPublic() {
+		return policy, nil
+	}
+	config, err := o.loadPublicAccessBlock(vol)
+	if err != nil {
+		return nil, err
+	}
+	if config.restrictPublicBuckets() {
+		return policy.withoutPublicStatements(), nil
+	}
+	return policy, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePublicAccessBlockConfig(t *testing.T) {
+	config, errCode := ParsePublicAccessBlockConfig([]byte(`
+<PublicAccessBlockConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+	<BlockPublicAcls>true</BlockPublicAcls>
+	<IgnorePublicAcls>false</IgnorePublicAcls>
+	<RestrictPublicBuckets>true</RestrictPublicBuckets>
+</PublicAccessBlockConfiguration>`))
+	require.Nil(t, errCode)
+	require.True(t, config.BlockPublicAcls)
+	require.False(t, config.IgnorePublicAcls)
+	require.False(t, config.BlockPublicPolicy)
+	require.True(t, config.RestrictPublicBuckets)
+	require.False(t, config.IsEmpty())
+
+	config, errCode = ParsePublicAccessBlockConfig([]byte(`<PublicAccessBlockConfiguration></PublicAccessBlockConfiguration>`))
+	require.Nil(t, errCode)
+	require.True(t, config.IsEmpty())
+
+	_, errCode = ParsePublicAccessBlockConfig([]byte(`<PublicAccessBlockConfiguration><BlockPublicAcls>yes</BlockPublicAcls></PublicAccessBlockConfiguration>`))
+	require.Equal(t, MalformedXML, errCode)
+}
+
+func TestPublicAccessBlockMerge(t *testing.T) {
+	var empty *PublicAccessBlockConfiguration
+	bucket := &PublicAccessBlockConfiguration{BlockPublicAcls: true}
+	cluster := &PublicAccessBlockConfiguration{RestrictPublicBuckets: true}
+
+	require.Nil(t, empty.Merge(nil))
+	require.Equal(t, bucket, empty.Merge(bucket))
+	require.Equal(t, cluster, cluster.Merge(nil))
+
+	merged := cluster.Merge(bucket)
+	require.True(t, merged.blockPublicAcls())
+	require.False(t, merged.ignorePublicAcls())
+	require.False(t, merged.blockPublicPolicy())
+	require.True(t, merged.restrictPublicBuckets())
+	require.False(t, empty.restrictPublicBuckets())
+}
+
+func TestAccessControlPolicyIsPublic(t *testing.T) {
+	owner, user := "owner", "user"
+
+	private := &AccessControlPolicy{}
+	private.SetPrivate(owner)
+	require.False(t, private.IsPublic())
+	require.Equal(t, private, private.withoutPublicGrants())
+
+	acl := &AccessControlPolicy{}
+	acl.SetPublicRead(owner)
+	require.True(t, acl.IsPublic())
+	require.True(t, acl.IsAllowed(user, proto.OSSGetObjectAction))
+	require.True(t, acl.IsAllowed(AnonymousUser, proto.OSSGetObjectAction))
+	ignored := acl.withoutPublicGrants()
+	require.False(t, ignored.IsPublic())
+	require.False(t, ignored.IsAllowed(user, proto.OSSGetObjectAction))
+	require.False(t, ignored.IsAllowed(AnonymousUser, proto.OSSGetObjectAction))
+	require.True(t, ignored.IsAllowed(owner, proto.OSSGetObjectAction))
+	require.True(t, acl.IsPublic(), "the original acl should not be modified")
+
+	acl = &AccessControlPolicy{}
+	acl.SetAuthenticatedRead(owner)
+	require.True(t, acl.IsPublic())
+}
+
+func TestPolicyIsPublic(t *testing.T) {
+	tests := []struct {
+		policy   string
+		isPublic bool
+	}{
+		{
+			policy:   `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`,
+			isPublic: true,
+		},
+		{
+			policy:   `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["1001","*"]},"Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`,
+			isPublic: true,
+		},
+		{
+			policy:   `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"1001"},"Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`,
+			isPublic: false,
+		},
+		{
+			policy:   `{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`,
+			isPublic: false,
+		},
+		{
+			policy:   `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*","Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}}]}`,
+			isPublic: false,
+		},
+		{
+			policy:   `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*","Condition":{"StringLike":{"aws:Referer":"http://www.example.com/*"}}}]}`,
+			isPublic: true,
+		},
+	}
+	// the source ip condition restricts the access only if none of the networks is broader than /8 for IPv4 or /32 for IPv6
+	for _, c := range []struct {
+		sourceIP string
+		isPublic bool
+	}{
+		{`"0.0.0.0/0"`, true},
+		{`"10.0.0.0/7"`, true},
+		{`["10.0.0.0/8","0.0.0.0/0"]`, true},
+		{`["10.0.0.0/8","192.168.1.1"]`, false},
+		{`"::/0"`, true},
+		{`"2001:db8::/31"`, true},
+		{`"2001:db8::/32"`, false},
+	} {
+		tests = append(tests, struct {
+			policy   string
+			isPublic bool
+		}{
+			policy:   `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*","Condition":{"IpAddress":{"aws:SourceIp":` + c.sourceIP + `}}}]}`,
+			isPublic: c.isPublic,
+		})
+	}
+	for _, tt := range tests {
+		policy, err := ParsePolicy([]byte(tt.policy))
+		require.NoError(t, err)
+		require.Equal(t, tt.isPublic, policy.IsPublic(), tt.policy)
+	}
+
+	policy, err := ParsePolicy([]byte(`{"Version":"2012-10-17","Statement":[
+{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"},
+{"Effect":"Deny","Principal":"*","Action":"s3:DeleteObject","Resource":"arn:aws:s3:::bucket/*"},
+{"Effect":"Allow","Principal":{"AWS":"1001"},"Action":"s3:PutObject","Resource":"arn:aws:s3:::bucket/*"}]}`))
+	require.NoError(t, err)
+	restricted := policy.withoutPublicStatements()
+	require.False(t, restricted.IsPublic())
+	require.Len(t, restricted.Statements, 2)
+	require.Len(t, policy.Statements, 3)
+}
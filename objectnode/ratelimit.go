@@ -17,15 +17,12 @@ package objectnode
 import (
 	"encoding/json"
 	"io"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/concurrent"
-	"github.com/cubefs/cubefs/util/flowctrl"
+	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
-	"github.com/cubefs/cubefs/util/ratelimit"
 )
 
 const (
@@ -53,206 +50,148 @@ var listApi = map[string]string{
 var _ = listApi
 
 type RateLimiter interface {
-	AcquireLimitResource(uid string, api string) error
-	ReleaseLimitResource(uid string, api string)
-	GetResponseWriter(uid string, api string, w io.Writer) io.Writer
-	GetReader(uid string, api string, r io.Reader) io.Reader
+	AcquireLimitResource(uid, bucket, api string) error
+	ReleaseLimitResource(uid, bucket, api string)
+	GetResponseWriter(uid, bucket, api string, w io.Writer) io.Writer
+	GetReader(uid, bucket, api string, r io.Reader) io.Reader
 }
 
+// RateLimit limits the requests by the quotas of the uid and the bucket. The QPS and the bandwidth
+// quotas of the cluster are leased to the ObjectNodes by the QuotaLeaser, while the concurrency
+// quota is divided by the nodes statically.
 type RateLimit struct {
-	S3ApiRateLimitMgr map[string]UserRateManager      // api -> UserRateMgr
-	ApiLimitConf      map[string]*proto.UserLimitConf // api -> UserLimitConf
-	putApi            map[string]string
+	ApiLimitConf    map[string]*proto.UserLimitConf   // api -> UserLimitConf
+	BucketLimitConf map[string]*proto.BucketLimitConf // api -> BucketLimitConf
+	ConcurrentLimit *concurrent.KeyConcurrentLimit
+	nodes           uint64
+	quotas          *QuotaLeaser
+	putApi          map[string]string
 }
 
-func NewRateLimit(apiLimitConf map[string]*proto.UserLimitConf) RateLimiter {
-	if len(apiLimitConf) == 0 {
+func NewRateLimit(conf *proto.S3QoSResponse, quotas *QuotaLeaser) RateLimiter {
+	if conf.Nodes == 0 || (len(conf.ApiLimitConf) == 0 && len(conf.BucketLimitConf) == 0) {
 		return &NullRateLimit{}
 	}
 
-	s3ApiRateLimitMgr := make(map[string]UserRateManager, len(apiLimitConf))
-	for api, userLimitConf := range apiLimitConf {
-		userRateManager := NewUserRateMgr(userLimitConf)
-		s3ApiRateLimitMgr[api] = userRateManager
-	}
-
 	rateLimit := &RateLimit{
-		S3ApiRateLimitMgr: s3ApiRateLimitMgr,
-		ApiLimitConf:      apiLimitConf,
-		putApi:            putApi,
+		ApiLimitConf:    conf.ApiLimitConf,
+		BucketLimitConf: conf.BucketLimitConf,
+		ConcurrentLimit: concurrent.NewLimit(),
+		nodes:           conf.Nodes,
+		quotas:          quotas,
+		putApi:          putApi,
 	}
 	return rateLimit
 }
 
-func (r *RateLimit) AcquireLimitResource(uid string, api string) error {
+func (r *RateLimit) normalizeApi(api string) string {
 	api = strings.ToLower(api)
 	if putTotal, isPutApi := r.putApi[api]; isPutApi {
 		api = putTotal
 	}
-	userRateMgr, ok := r.S3ApiRateLimitMgr[api]
-	if !ok {
-		return nil
+	return api
+}
+
+// forEachLimit calls fn with the key and the quota of the limits of the uid and the bucket,
+// and stops if fn returns false.
+func (r *RateLimit) forEachLimit(uid, bucket, api, limitType string, fn func(key string, quota uint64) bool) bool {
+	if userLimitConf, ok := r.ApiLimitConf[api]; ok {
+		quotas := userLimitConf.QPSQuota
+		if limitType == proto.FlowLimit {
+			quotas = userLimitConf.BandWidthQuota
+		}
+		if quota := getUserLimitQuota(quotas[proto.DefaultUid], quotas[uid]); quota != 0 {
+			if !fn(proto.S3QoSLimitKey(proto.S3QoSScopeUid, api, uid, limitType), quota) {
+				return false
+			}
+		}
 	}
+	if bucketLimitConf, ok := r.BucketLimitConf[api]; ok && bucket != "" {
+		quotas := bucketLimitConf.QPSQuota
+		if limitType == proto.FlowLimit {
+			quotas = bucketLimitConf.BandWidthQuota
+		}
+		if quota := quotas[bucket]; quota != 0 {
+			if !fn(proto.S3QoSLimitKey(proto.S3QoSScopeBucket, api, bucket, limitType), quota) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *RateLimit) AcquireLimitResource(uid, bucket, api string) error {
+	api = r.normalizeApi(api)
 	// QPS
-	if allowed, _ := userRateMgr.QPSLimitAllowed(uid); !allowed {
+	quotas := make(map[string]uint64, 2)
+	r.forEachLimit(uid, bucket, api, proto.QPSLimit, func(key string, quota uint64) bool {
+		quotas[key] = quota
+		return true
+	})
+	if len(quotas) > 0 && !r.quotas.AllowAll(quotas, r.nodes) {
 		return TooManyRequests
 	}
 	// Concurrency
-	if err := userRateMgr.ConcurrentLimitAcquire(uid); err != nil {
+	userLimitConf, ok := r.ApiLimitConf[api]
+	if !ok {
+		return nil
+	}
+	quota := getUserLimitQuota(userLimitConf.ConcurrentQuota[proto.DefaultUid], userLimitConf.ConcurrentQuota[uid])
+	if quota == 0 {
+		return nil
+	}
+	log.LogDebugf("ConcurrentLimit: quota[%d] nodes[%d] uid[%s]", quota, r.nodes, uid)
+	if err := r.ConcurrentLimit.Acquire(api+"/"+uid, int64(staticQuotaShare(quota, r.nodes))); err != nil {
+		exporter.NewCounter(MetricS3QoSThrottled).AddWithLabels(1, map[string]string{
+			"scope": proto.S3QoSScopeUid, "api": api, "type": proto.ConcurrentLimit,
+		})
 		return TooManyRequests
 	}
 	return nil
 }
 
-func (r *RateLimit) ReleaseLimitResource(uid string, api string) {
-	api = strings.ToLower(api)
-	if putTotal, isPutApi := r.putApi[api]; isPutApi {
-		api = putTotal
-	}
-	userRateMgr, ok := r.S3ApiRateLimitMgr[api]
-	if !ok {
-		return
-	}
-	userRateMgr.ConcurrentLimitRelease(uid)
+func (r *RateLimit) ReleaseLimitResource(uid, bucket, api string) {
+	api = r.normalizeApi(api)
+	r.ConcurrentLimit.Release(api + "/" + uid)
 }
 
-func (r *RateLimit) GetResponseWriter(uid string, api string, w io.Writer) io.Writer {
+func (r *RateLimit) GetResponseWriter(uid, bucket, api string, w io.Writer) io.Writer {
 	api = strings.ToLower(api)
-	userRateMgr, ok := r.S3ApiRateLimitMgr[api]
-	if !ok {
-		return w
-	}
-	return userRateMgr.GetResponseWriter(uid, w)
+	r.forEachLimit(uid, bucket, api, proto.FlowLimit, func(key string, quota uint64) bool {
+		w = r.quotas.Writer(key, quota, r.nodes, w)
+		return true
+	})
+	return w
 }
 
-func (r *RateLimit) GetReader(uid string, api string, reader io.Reader) io.Reader {
-	api = strings.ToLower(api)
-	if putTotal, isPutApi := r.putApi[api]; isPutApi {
-		api = putTotal
-	}
-	userRateMgr, ok := r.S3ApiRateLimitMgr[api]
-	if !ok {
-		return reader
-	}
-	return userRateMgr.GetReader(uid, reader)
+func (r *RateLimit) GetReader(uid, bucket, api string, reader io.Reader) io.Reader {
+	api = r.normalizeApi(api)
+	r.forEachLimit(uid, bucket, api, proto.FlowLimit, func(key string, quota uint64) bool {
+		reader = r.quotas.Reader(key, quota, r.nodes, reader)
+		return true
+	})
+	return reader
 }
 
 // No RateLimit
 type NullRateLimit struct{}
 
-func (n *NullRateLimit) AcquireLimitResource(uid string, api string) error {
+func (n *NullRateLimit) AcquireLimitResource(uid, bucket, api string) error {
 	return nil
 }
 
-func (n *NullRateLimit) ReleaseLimitResource(uid string, api string) {
+func (n *NullRateLimit) ReleaseLimitResource(uid, bucket, api string) {
 	_ = struct{}{}
 }
 
-func (n *NullRateLimit) GetResponseWriter(uid string, api string, w io.Writer) io.Writer {
+func (n *NullRateLimit) GetResponseWriter(uid, bucket, api string, w io.Writer) io.Writer {
 	return w
 }
 
-func (n *NullRateLimit) GetReader(uid string, api string, r io.Reader) io.Reader {
+func (n *NullRateLimit) GetReader(uid, bucket, api string, r io.Reader) io.Reader {
 	return r
 }
 
-type UserRateManager interface {
-	QPSLimitAllowed(uid string) (bool, time.Duration)
-	ConcurrentLimitAcquire(uid string) error
-	ConcurrentLimitRelease(uid string)
-	GetResponseWriter(uid string, w io.Writer) io.Writer
-	GetReader(uid string, r io.Reader) io.Reader
-}
-
-// UserRateMgr specific api user rate Manager
-type UserRateMgr struct {
-	BandWidthLimit  *flowctrl.KeyFlowCtrl
-	QPSLimit        *ratelimit.KeyRateLimit
-	ConcurrentLimit *concurrent.KeyConcurrentLimit
-	UserLimitConf   *proto.UserLimitConf
-}
-
-func NewUserRateMgr(conf *proto.UserLimitConf) UserRateManager {
-	bandWidthLimit := flowctrl.NewKeyFlowCtrl()
-	qpsLimit := ratelimit.NewKeyRateLimit()
-	concurrentLimit := concurrent.NewLimit()
-
-	userRateMgr := &UserRateMgr{
-		BandWidthLimit:  bandWidthLimit,
-		QPSLimit:        qpsLimit,
-		ConcurrentLimit: concurrentLimit,
-		UserLimitConf:   conf,
-	}
-
-	return userRateMgr
-}
-
-func (r *UserRateMgr) QPSLimitAllowed(uid string) (bool, time.Duration) {
-	defaultQPSLimit := r.UserLimitConf.QPSQuota[proto.DefaultUid]
-	usrQPSLimit := r.UserLimitConf.QPSQuota[uid]
-	qpsQuota := getUserLimitQuota(defaultQPSLimit, usrQPSLimit)
-	qps, err := safeConvertUint64ToInt(qpsQuota)
-	if err != nil {
-		log.LogWarnf("QPSLimitAllowed: safeConvertUint64ToInt err[%v]", err)
-		return true, 0
-	}
-	if qps == 0 {
-		return true, 0
-	}
-	log.LogDebugf("QPSLimit: defaultQPSLimit[%d] usrQPSLimit[%d] uid[%s]", defaultQPSLimit, usrQPSLimit, uid)
-	qpsLimit := r.QPSLimit.Acquire(uid, qps)
-
-	return !qpsLimit.Limit(), 0
-}
-
-func (r *UserRateMgr) ConcurrentLimitAcquire(uid string) error {
-	defaultConcurrentLimit := r.UserLimitConf.ConcurrentQuota[proto.DefaultUid]
-	usrConcurrentLimit := r.UserLimitConf.ConcurrentQuota[uid]
-
-	concurrentQuota := getUserLimitQuota(defaultConcurrentLimit, usrConcurrentLimit)
-	if concurrentQuota == 0 {
-		return nil
-	}
-	log.LogDebugf("ConcurrentLimit: defaultConcurrentLimit[%d] usrConcurrentLimit[%d] uid[%s]", defaultConcurrentLimit, usrConcurrentLimit, uid)
-	return r.ConcurrentLimit.Acquire(uid, int64(concurrentQuota))
-}
-
-func (r *UserRateMgr) ConcurrentLimitRelease(uid string) {
-	r.ConcurrentLimit.Release(uid)
-}
-
-func (r *UserRateMgr) GetResponseWriter(uid string, w io.Writer) io.Writer {
-	defaultBandWidthLimit := r.UserLimitConf.BandWidthQuota[proto.DefaultUid]
-	usrBandWidthLimit := r.UserLimitConf.BandWidthQuota[uid]
-
-	bandWidthQuota := getUserLimitQuota(defaultBandWidthLimit, usrBandWidthLimit)
-	if bandWidthQuota == 0 {
-		return w
-	}
-	log.LogDebugf("WriterFlowCtrl: defaultBandWidthLimit[%d] usrBandWidthLimit[%d] uid[%s]", defaultBandWidthLimit, usrBandWidthLimit, uid)
-	rate, _ := convertUint64ToInt(bandWidthQuota)
-	flowCtrl := r.BandWidthLimit.Acquire(uid, rate)
-	w = flowctrl.NewRateWriterWithCtrl(w, flowCtrl)
-
-	return w
-}
-
-func (r *UserRateMgr) GetReader(uid string, reader io.Reader) io.Reader {
-	defaultBandWidthLimit := r.UserLimitConf.BandWidthQuota[proto.DefaultUid]
-	usrBandWidthLimit := r.UserLimitConf.BandWidthQuota[uid]
-
-	bandWidthQuota := getUserLimitQuota(defaultBandWidthLimit, usrBandWidthLimit)
-	if bandWidthQuota == 0 {
-		return reader
-	}
-	log.LogDebugf("ReaderFlowCtrl: defaultBandWidthLimit[%d] usrBandWidthLimit[%d] uid[%s]", defaultBandWidthLimit, usrBandWidthLimit, uid)
-	rate, _ := convertUint64ToInt(bandWidthQuota)
-	flowCtrl := r.BandWidthLimit.Acquire(uid, rate)
-	reader = flowctrl.NewRateReaderWithCtrl(reader, flowCtrl)
-
-	return reader
-}
-
 // priority: usrLimit > defaultLimit
 func getUserLimitQuota(defaultLimit, usrLimit uint64) uint64 {
 	if usrLimit != 0 {
@@ -261,44 +200,28 @@ func getUserLimitQuota(defaultLimit, usrLimit uint64) uint64 {
 	return defaultLimit
 }
 
+// staticQuotaShare divides the quota of the cluster by the nodes, which is at least 1.
+func staticQuotaShare(quota, nodes uint64) uint64 {
+	if nodes == 0 {
+		return quota
+	}
+	share := quota / nodes
+	if share == 0 && quota != 0 {
+		share = 1
+	}
+	return share
+}
+
 func (o *ObjectNode) Reload(data []byte) error {
 	s3QosResponse := proto.S3QoSResponse{}
 	if err := json.Unmarshal(data, &s3QosResponse); err != nil {
 		return err
 	}
-	apiLimitConf := s3QosResponse.ApiLimitConf
-	s3NodeNum := s3QosResponse.Nodes
-	if s3NodeNum == 0 {
-		o.limitMutex.Lock()
-		o.rateLimit = &NullRateLimit{}
-		o.limitMutex.Unlock()
-		return nil
-	}
-	for _, userLimitConf := range apiLimitConf {
-		for uid, bandWidthQuota := range userLimitConf.BandWidthQuota {
-			quota := bandWidthQuota / s3NodeNum
-			if quota == 0 && bandWidthQuota != 0 {
-				quota += 1
-			}
-			userLimitConf.BandWidthQuota[uid] = quota
-		}
-		for uid, qpsQuota := range userLimitConf.QPSQuota {
-			quota := qpsQuota / s3NodeNum
-			if quota == 0 && qpsQuota != 0 {
-				quota += 1
-			}
-			userLimitConf.QPSQuota[uid] = quota
-		}
-		for uid, concurrentQuota := range userLimitConf.ConcurrentQuota {
-			quota := concurrentQuota / s3NodeNum
-			if quota == 0 && concurrentQuota != 0 {
-				quota += 1
-			}
-			userLimitConf.ConcurrentQuota[uid] = quota
-		}
-	}
+	// the quotas are kept as the quotas of the cluster, which are leased or divided by the nodes
+	// when the requests are limited
+	rateLimit := NewRateLimit(&s3QosResponse, o.qosLeaser)
 	o.limitMutex.Lock()
-	o.rateLimit = NewRateLimit(apiLimitConf)
+	o.rateLimit = rateLimit
 	o.limitMutex.Unlock()
 	return nil
 }
@@ -317,12 +240,3 @@ func (o *ObjectNode) AcquireRateLimiter() RateLimiter {
 	o.limitMutex.RUnlock()
 	return rateLimit
 }
-
-func convertUint64ToInt(num uint64) (int, error) {
-	str := strconv.FormatUint(num, 10)
-	parsed, err := strconv.ParseInt(str, 10, 0)
-	if err != nil {
-		return 0, err
-	}
-	return int(parsed), nil
-}
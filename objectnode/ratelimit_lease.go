@@ -0,0 +1,348 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// the limit which is not used for s3QoSIdleIntervals intervals is removed
+	s3QoSIdleIntervals = 12
+	// the minimum interval of the lease triggered by the new limit
+	s3QoSMinLeaseInterval = time.Second
+
+	MetricS3QoSThrottled   = "s3_qos_throttled"
+	MetricS3QoSLeaseFailed = "s3_qos_lease_failed"
+)
+
+// leasedLimit is the token bucket of the limit, whose rate is the share of the quota leased
+// from the master, or the quota divided by the nodes if the lease is not available.
+type leasedLimit struct {
+	bucket    *rate.Limiter
+	scope     string
+	api       string
+	limitType string
+
+	used      uint64 // tokens taken in the current interval, atomic
+	throttled uint64 // times of throttling in the current interval, atomic
+
+	// the fields below are protected by the mutex of the QuotaLeaser, and read with the read lock
+	total  uint64 // quota of the cluster
+	nodes  uint64 // nodes which the quota is divided by statically
+	share  uint64 // share leased from the master
+	expire time.Time
+	rate   uint64 // rate applied to the bucket
+	idle   int
+}
+
+func (l *leasedLimit) burst(rate uint64) int {
+	burst := rate
+	if l.limitType == proto.FlowLimit {
+		// the bandwidth is smoothed by the burst of 100ms
+		burst = rate / 10
+		if burst < DefaultFlowLimitSize {
+			burst = DefaultFlowLimitSize
+		}
+	}
+	if burst > math.MaxInt32 {
+		burst = math.MaxInt32
+	}
+	return int(burst)
+}
+
+func (l *leasedLimit) throttle() {
+	atomic.AddUint64(&l.throttled, 1)
+	exporter.NewCounter(MetricS3QoSThrottled).AddWithLabels(1, map[string]string{
+		"scope": l.scope, "api": l.api, "type": l.limitType,
+	})
+}
+
+// QuotaLeaser leases the shares of the QPS and the bandwidth quotas from the master by the
+// demands observed in the last interval, so that the ObjectNode serving more requests takes
+// more of the quota. The quota is divided by the nodes statically if the lease is not available,
+// e.g. the master is unreachable, or the lease func is nil.
+type QuotaLeaser struct {
+	node     string
+	interval time.Duration
+	lease    func(req *proto.S3QoSLeaseRequest) (*proto.S3QoSLeaseResponse, error)
+
+	mutex       sync.RWMutex
+	limits      map[string]*leasedLimit // limit key --> limit
+	activeNodes uint64                  // active nodes returned by the last lease
+	leaseExpire time.Time
+
+	kickC     chan struct{}
+	stopC     chan struct{}
+	closeOnce sync.Once
+}
+
+func NewQuotaLeaser(node string, interval time.Duration,
+	lease func(req *proto.S3QoSLeaseRequest) (*proto.S3QoSLeaseResponse, error),
+) *QuotaLeaser {
+	return &QuotaLeaser{
+		node:     node,
+		interval: interval,
+		lease:    lease,
+		limits:   make(map[string]*leasedLimit),
+		kickC:    make(chan struct{}, 1),
+		stopC:    make(chan struct{}),
+	}
+}
+
+// rateOf returns the rate of the limit at the time now, the limit without the lease takes the
+// minimum share of the active nodes if the lease service is available.
+func (q *QuotaLeaser) rateOf(l *leasedLimit, now time.Time) uint64 {
+	if l.share != 0 && now.Before(l.expire) {
+		return l.share
+	}
+	if q.activeNodes != 0 && now.Before(q.leaseExpire) {
+		return proto.S3QoSMinShare(l.total, q.activeNodes)
+	}
+	return staticQuotaShare(l.total, l.nodes)
+}
+
+// limit returns the limit of the key and applies the current rate of it. The limit whose quota
+// and rate are not changed is returned with the read lock only, which is the case of most requests.
+func (q *QuotaLeaser) limit(key string, total, nodes uint64) *leasedLimit {
+	now := time.Now()
+	q.mutex.RLock()
+	l, ok := q.limits[key]
+	if ok && l.total == total && l.nodes == nodes && l.rate == q.rateOf(l, now) {
+		q.mutex.RUnlock()
+		return l
+	}
+	q.mutex.RUnlock()
+
+	q.mutex.Lock()
+	l, ok = q.limits[key]
+	if !ok {
+		scope, api, _, limitType, _ := proto.ParseS3QoSLimitKey(key)
+		l = &leasedLimit{scope: scope, api: api, limitType: limitType}
+		q.limits[key] = l
+	}
+	l.total, l.nodes = total, nodes
+	r := q.rateOf(l, now)
+	if l.bucket == nil {
+		l.bucket = rate.NewLimiter(rate.Limit(r), l.burst(r))
+	} else if r != l.rate {
+		l.bucket.SetLimitAt(now, rate.Limit(r))
+		l.bucket.SetBurstAt(now, l.burst(r))
+	}
+	l.rate = r
+	q.mutex.Unlock()
+
+	if !ok {
+		// lease the new limit as soon as possible
+		select {
+		case q.kickC <- struct{}{}:
+		default:
+		}
+	}
+	return l
+}
+
+// Allow takes one token of the limit for the request.
+func (q *QuotaLeaser) Allow(key string, total, nodes uint64) bool {
+	return q.AllowAll(map[string]uint64{key: total}, nodes)
+}
+
+// AllowAll takes one token of each limit for the request, the quotas are keyed by the limit keys.
+// No token is taken if any of the limits is exhausted, so the request rejected by one limit does
+// not consume the others.
+func (q *QuotaLeaser) AllowAll(quotas map[string]uint64, nodes uint64) bool {
+	limits := make([]*leasedLimit, 0, len(quotas))
+	for key, total := range quotas {
+		l := q.limit(key, total, nodes)
+		atomic.AddUint64(&l.used, 1)
+		limits = append(limits, l)
+	}
+	now := time.Now()
+	reservations := make([]*rate.Reservation, 0, len(limits))
+	for _, l := range limits {
+		r := l.bucket.ReserveN(now, 1)
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			l.throttle()
+			r.CancelAt(now)
+			for _, taken := range reservations {
+				taken.CancelAt(now)
+			}
+			return false
+		}
+		reservations = append(reservations, r)
+	}
+	return true
+}
+
+func (q *QuotaLeaser) Reader(key string, total, nodes uint64, r io.Reader) io.Reader {
+	return &leasedReader{limit: q.limit(key, total, nodes), underlying: r}
+}
+
+func (q *QuotaLeaser) Writer(key string, total, nodes uint64, w io.Writer) io.Writer {
+	return &leasedWriter{limit: q.limit(key, total, nodes), underlying: w}
+}
+
+// wait takes n tokens of the bandwidth limit and waits until they are produced.
+func (l *leasedLimit) wait(n int) {
+	atomic.AddUint64(&l.used, uint64(n))
+	now := time.Now()
+	r := l.bucket.ReserveN(now, n)
+	if !r.OK() {
+		// the burst is reduced by the new rate after the size of the data is taken
+		r = l.bucket.ReserveN(now, l.bucket.Burst())
+	}
+	if wait := r.DelayFrom(now); r.OK() && wait > 0 {
+		l.throttle()
+		time.Sleep(wait)
+	}
+}
+
+type leasedReader struct {
+	limit      *leasedLimit
+	underlying io.Reader
+}
+
+func (r *leasedReader) Read(p []byte) (n int, err error) {
+	if burst := r.limit.bucket.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err = r.underlying.Read(p)
+	if n > 0 {
+		r.limit.wait(n)
+	}
+	return
+}
+
+type leasedWriter struct {
+	limit      *leasedLimit
+	underlying io.Writer
+}
+
+func (w *leasedWriter) Write(p []byte) (written int, err error) {
+	burst := w.limit.bucket.Burst()
+	for len(p) > 0 {
+		size := len(p)
+		if size > burst {
+			size = burst
+		}
+		w.limit.wait(size)
+		var n int
+		n, err = w.underlying.Write(p[:size])
+		written += n
+		if err != nil {
+			return
+		}
+		p = p[size:]
+	}
+	return
+}
+
+// demands returns the demands of the limits in the last interval, and removes the idle limits.
+// The demand of the saturated bandwidth limit is doubled to probe for more of the quota, since
+// the bandwidth is throttled by waiting and the demand beyond the rate is not observed.
+func (q *QuotaLeaser) demands() map[string]uint64 {
+	seconds := uint64(q.interval / time.Second)
+	if seconds == 0 {
+		seconds = 1
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	demands := make(map[string]uint64, len(q.limits))
+	for key, l := range q.limits {
+		used, throttled := atomic.SwapUint64(&l.used, 0), atomic.SwapUint64(&l.throttled, 0)
+		if used == 0 {
+			if l.idle++; l.idle > s3QoSIdleIntervals {
+				delete(q.limits, key)
+			}
+			continue
+		}
+		l.idle = 0
+		demand := used / seconds
+		if demand == 0 {
+			demand = 1
+		}
+		if l.limitType == proto.FlowLimit && throttled > 0 {
+			demand *= 2
+		}
+		demands[key] = demand
+	}
+	return demands
+}
+
+func (q *QuotaLeaser) renew() {
+	req := &proto.S3QoSLeaseRequest{
+		Node:     q.node,
+		Interval: int64(q.interval / time.Second),
+		Demands:  q.demands(),
+	}
+	resp, err := q.lease(req)
+	if err != nil {
+		// the leases are expired and the quotas are divided by the nodes statically
+		log.LogWarnf("QuotaLeaser: lease fail: node(%v) err(%v)", q.node, err)
+		exporter.NewCounter(MetricS3QoSLeaseFailed).Add(1)
+		return
+	}
+	now := time.Now()
+	expire := now.Add(time.Duration(resp.Expire) * time.Second)
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.activeNodes, q.leaseExpire = resp.Nodes, expire
+	for key := range req.Demands {
+		if l, ok := q.limits[key]; ok {
+			l.share, l.expire = resp.Shares[key], expire
+		}
+	}
+	log.LogDebugf("QuotaLeaser: lease: node(%v) nodes(%v) demands(%v) shares(%v)",
+		q.node, resp.Nodes, req.Demands, resp.Shares)
+}
+
+func (q *QuotaLeaser) Start() {
+	if q.lease == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(q.interval)
+		defer ticker.Stop()
+		var last time.Time
+		for {
+			select {
+			case <-q.stopC:
+				return
+			case <-ticker.C:
+			case <-q.kickC:
+				if time.Since(last) < s3QoSMinLeaseInterval {
+					continue
+				}
+			}
+			q.renew()
+			last = time.Now()
+		}
+	}()
+}
+
+func (q *QuotaLeaser) Close() {
+	q.closeOnce.Do(func() {
+		close(q.stopC)
+	})
+}
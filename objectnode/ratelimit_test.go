@@ -0,0 +1,135 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitUidAndBucket(t *testing.T) {
+	conf := &proto.S3QoSResponse{
+		ApiLimitConf: map[string]*proto.UserLimitConf{
+			"getobject": {QPSQuota: map[string]uint64{proto.DefaultUid: 20}},
+		},
+		BucketLimitConf: map[string]*proto.BucketLimitConf{
+			"getobject": {QPSQuota: map[string]uint64{"photos": 4}},
+		},
+		Nodes: 2,
+	}
+	rateLimit := NewRateLimit(conf, NewQuotaLeaser("node1", time.Second, nil))
+
+	// the quota of the bucket is divided by the nodes
+	for i := 0; i < 2; i++ {
+		require.NoError(t, rateLimit.AcquireLimitResource("alice", "photos", "GetObject"))
+	}
+	require.Equal(t, TooManyRequests, rateLimit.AcquireLimitResource("alice", "photos", "GetObject"))
+
+	// the other buckets are limited by the quota of the default uid
+	for i := 0; i < 10; i++ {
+		require.NoError(t, rateLimit.AcquireLimitResource("bob", "docs", "GetObject"))
+	}
+	require.Equal(t, TooManyRequests, rateLimit.AcquireLimitResource("bob", "docs", "GetObject"))
+
+	// the api without quota is not limited
+	require.NoError(t, rateLimit.AcquireLimitResource("bob", "docs", "HeadObject"))
+
+	_, ok := NewRateLimit(&proto.S3QoSResponse{Nodes: 2}, nil).(*NullRateLimit)
+	require.True(t, ok)
+}
+
+func TestQuotaLeaserShare(t *testing.T) {
+	key := proto.S3QoSLimitKey(proto.S3QoSScopeBucket, "getobject", "photos", proto.QPSLimit)
+	var leaseErr error
+	leaser := NewQuotaLeaser("node1", time.Second, func(req *proto.S3QoSLeaseRequest) (*proto.S3QoSLeaseResponse, error) {
+		if leaseErr != nil {
+			return nil, leaseErr
+		}
+		return &proto.S3QoSLeaseResponse{Shares: map[string]uint64{key: 8}, Nodes: 4, Expire: 3}, nil
+	})
+
+	// the quota is divided by the nodes before the lease
+	for i := 0; i < 5; i++ {
+		require.True(t, leaser.Allow(key, 10, 2))
+	}
+	require.False(t, leaser.Allow(key, 10, 2))
+	require.EqualValues(t, 5, leaser.limits[key].bucket.Limit())
+
+	// the leased share takes effect
+	leaser.renew()
+	require.Equal(t, uint64(4), leaser.activeNodes)
+	leaser.Allow(key, 10, 2)
+	require.EqualValues(t, 8, leaser.limits[key].bucket.Limit())
+
+	// the limit without the share takes the minimum share of the active nodes
+	other := proto.S3QoSLimitKey(proto.S3QoSScopeUid, "getobject", "alice", proto.QPSLimit)
+	leaser.Allow(other, 100, 2)
+	require.EqualValues(t, int(proto.S3QoSMinShare(100, 4)), leaser.limits[other].bucket.Limit())
+
+	// the expired lease falls back to the static share
+	leaseErr = errors.New("master unavailable")
+	leaser.renew()
+	leaser.mutex.Lock()
+	leaser.limits[key].expire = time.Now()
+	leaser.leaseExpire = time.Now()
+	leaser.mutex.Unlock()
+	leaser.Allow(key, 10, 2)
+	require.EqualValues(t, 5, leaser.limits[key].bucket.Limit())
+}
+
+func TestQuotaLeaserAllowAll(t *testing.T) {
+	leaser := NewQuotaLeaser("node1", time.Second, nil)
+	user := proto.S3QoSLimitKey(proto.S3QoSScopeUid, "getobject", "alice", proto.QPSLimit)
+	bucket := proto.S3QoSLimitKey(proto.S3QoSScopeBucket, "getobject", "photos", proto.QPSLimit)
+	require.True(t, leaser.Allow(bucket, 1, 1))
+
+	// the request rejected by the bucket limit takes no token of the user limit
+	for i := 0; i < 5; i++ {
+		require.False(t, leaser.AllowAll(map[string]uint64{user: 1, bucket: 1}, 1))
+	}
+	require.True(t, leaser.Allow(user, 1, 1))
+	require.False(t, leaser.Allow(user, 1, 1))
+	require.Equal(t, uint64(6), atomic.LoadUint64(&leaser.limits[bucket].used))
+}
+
+func TestQuotaLeaserDemands(t *testing.T) {
+	leaser := NewQuotaLeaser("node1", 2*time.Second, nil)
+	qps := proto.S3QoSLimitKey(proto.S3QoSScopeUid, "getobject", "alice", proto.QPSLimit)
+	flow := proto.S3QoSLimitKey(proto.S3QoSScopeBucket, "getobject", "photos", proto.FlowLimit)
+	for i := 0; i < 10; i++ {
+		leaser.Allow(qps, 1000, 1)
+	}
+	data := bytes.Repeat([]byte("a"), 3*DefaultFlowLimitSize)
+	_, err := io.Copy(io.Discard, leaser.Reader(flow, 10*DefaultFlowLimitSize, 1, bytes.NewReader(data)))
+	require.NoError(t, err)
+
+	demands := leaser.demands()
+	require.Equal(t, uint64(5), demands[qps])
+	// the throttled bandwidth demand is doubled
+	require.Equal(t, uint64(len(data)), demands[flow])
+
+	// the idle limits are removed
+	for i := 0; i <= s3QoSIdleIntervals; i++ {
+		require.Empty(t, leaser.demands())
+	}
+	require.Empty(t, leaser.limits)
+}
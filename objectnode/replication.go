@@ -0,0 +1,242 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/replication.html
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+const (
+	ReplicationRuleEnabled  = "Enabled"
+	ReplicationRuleDisabled = "Disabled"
+
+	// The replication status of the objects, which is exposed by the x-amz-replication-status header.
+	ReplicationStatusPending   = "PENDING"
+	ReplicationStatusCompleted = "COMPLETED"
+	ReplicationStatusFailed    = "FAILED"
+	ReplicationStatusReplica   = "REPLICA"
+
+	// The destination bucket of a rule is either "arn:aws:s3:::<bucket>" which is replicated to the
+	// only remote target, or "arn:cubefs:replication::<target>:<bucket>" for the specified target.
+	replicationAWSBucketARNPrefix = "arn:aws:s3:::"
+	replicationTargetARNPrefix    = "arn:cubefs:replication::"
+
+	MaxReplicationConfigSize = 1 << 20 // 1MB
+	MaxReplicationRules      = 1000
+	MaxReplicationRuleIDSize = 255
+)
+
+type ReplicationConfiguration struct {
+	XMLNS   string             `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName xml.Name           `xml:"ReplicationConfiguration" json:"-"`
+	Role    string             `xml:"Role,omitempty" json:"role,omitempty"`
+	Rules   []*ReplicationRule `xml:"Rule" json:"rules"`
+}
+
+type ReplicationRule struct {
+	ID                      string                   `xml:"ID,omitempty" json:"id,omitempty"`
+	Status                  string                   `xml:"Status" json:"status"`
+	Priority                int                      `xml:"Priority,omitempty" json:"priority,omitempty"`
+	Prefix                  *string                  `xml:"Prefix" json:"prefix,omitempty"` // deprecated by Filter
+	Filter                  *ReplicationFilter       `xml:"Filter,omitempty" json:"filter,omitempty"`
+	Destination             *ReplicationDestination  `xml:"Destination" json:"destination"`
+	DeleteMarkerReplication *DeleteMarkerReplication `xml:"DeleteMarkerReplication,omitempty" json:"delete_marker_replication,omitempty"`
+}
+
+type ReplicationFilter struct {
+	Prefix string          `xml:"Prefix,omitempty" json:"prefix,omitempty"`
+	Tag    *Tag            `xml:"Tag,omitempty" json:"tag,omitempty"`
+	And    *ReplicationAnd `xml:"And,omitempty" json:"and,omitempty"`
+}
+
+type ReplicationAnd struct {
+	Prefix string `xml:"Prefix,omitempty" json:"prefix,omitempty"`
+	Tags   []Tag  `xml:"Tag,omitempty" json:"tags,omitempty"`
+}
+
+type ReplicationDestination struct {
+	Bucket       string `xml:"Bucket" json:"bucket"`
+	StorageClass string `xml:"StorageClass,omitempty" json:"storage_class,omitempty"`
+}
+
+// DeleteMarkerReplication is accepted for compatibility, only the new and overwritten
+// objects are replicated and the delete markers are never replicated.
+type DeleteMarkerReplication struct {
+	Status string `xml:"Status" json:"status"`
+}
+
+func ParseReplicationConfig(data []byte) (*ReplicationConfiguration, *ErrorCode) {
+	config := &ReplicationConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	if len(config.Rules) == 0 || len(config.Rules) > MaxReplicationRules {
+		return nil, MalformedXML
+	}
+	ids := make(map[string]struct{})
+	priorities := make(map[int]struct{})
+	for _, rule := range config.Rules {
+		if errCode := rule.validate(); errCode != nil {
+			return nil, errCode
+		}
+		if rule.ID != "" {
+			if _, ok := ids[rule.ID]; ok {
+				return nil, InvalidReplicationRule
+			}
+			ids[rule.ID] = struct{}{}
+		}
+		if rule.Filter != nil {
+			if _, ok := priorities[rule.Priority]; ok {
+				return nil, InvalidReplicationRule
+			}
+			priorities[rule.Priority] = struct{}{}
+		}
+	}
+	return config, nil
+}
+
+func (r *ReplicationRule) validate() *ErrorCode {
+	if len(r.ID) > MaxReplicationRuleIDSize {
+		return InvalidReplicationRule
+	}
+	if r.Status != ReplicationRuleEnabled && r.Status != ReplicationRuleDisabled {
+		return MalformedXML
+	}
+	if r.Destination == nil || r.Destination.Bucket == "" {
+		return MalformedXML
+	}
+	if _, _, ok := parseReplicationDestination(r.Destination.Bucket); !ok {
+		return InvalidReplicationDestination
+	}
+	if r.Prefix != nil && r.Filter != nil {
+		return MalformedXML
+	}
+	if r.DeleteMarkerReplication != nil && r.DeleteMarkerReplication.Status != ReplicationRuleDisabled {
+		return InvalidReplicationRule
+	}
+	if f := r.Filter; f != nil {
+		if f.And != nil && (f.Prefix != "" || f.Tag != nil) {
+			return MalformedXML
+		}
+		var tags []Tag
+		if f.Tag != nil {
+			tags = append(tags, *f.Tag)
+		}
+		if f.And != nil {
+			tags = append(tags, f.And.Tags...)
+		}
+		if len(tags) > 0 {
+			if err := (Tagging{TagSet: tags}).Validate(); err != nil {
+				return InvalidReplicationRule
+			}
+		}
+	}
+	return nil
+}
+
+func (r *ReplicationRule) prefix() string {
+	switch {
+	case r.Prefix != nil:
+		return *r.Prefix
+	case r.Filter == nil:
+		return ""
+	case r.Filter.And != nil:
+		return r.Filter.And.Prefix
+	default:
+		return r.Filter.Prefix
+	}
+}
+
+func (r *ReplicationRule) tags() []Tag {
+	switch {
+	case r.Filter == nil:
+		return nil
+	case r.Filter.And != nil:
+		return r.Filter.And.Tags
+	case r.Filter.Tag != nil:
+		return []Tag{*r.Filter.Tag}
+	default:
+		return nil
+	}
+}
+
+func (r *ReplicationRule) match(key string, tagging *Tagging) bool {
+	if r.Status != ReplicationRuleEnabled || !strings.HasPrefix(key, r.prefix()) {
+		return false
+	}
+	for _, tag := range r.tags() {
+		found := false
+		if tagging != nil {
+			for _, t := range tagging.TagSet {
+				if t == tag {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Match returns the enabled rule with the highest priority which matches the object,
+// nil is returned if the object is not to be replicated.
+func (c *ReplicationConfiguration) Match(key string, tagging *Tagging) *ReplicationRule {
+	if c == nil {
+		return nil
+	}
+	var matched *ReplicationRule
+	for _, rule := range c.Rules {
+		if rule.match(key, tagging) && (matched == nil || rule.Priority > matched.Priority) {
+			matched = rule
+		}
+	}
+	return matched
+}
+
+// parseReplicationDestination parses the destination bucket ARN of the replication rule,
+// the target is empty if it is not specified by the ARN.
+func parseReplicationDestination(arn string) (target, bucket string, ok bool) {
+	switch {
+	case strings.HasPrefix(arn, replicationAWSBucketARNPrefix):
+		bucket = arn[len(replicationAWSBucketARNPrefix):]
+	case strings.HasPrefix(arn, replicationTargetARNPrefix):
+		rest := arn[len(replicationTargetARNPrefix):]
+		index := strings.Index(rest, ":")
+		if index <= 0 {
+			return "", "", false
+		}
+		target, bucket = rest[:index], rest[index+1:]
+	default:
+		return "", "", false
+	}
+	if bucket == "" || strings.ContainsAny(bucket, ":/") {
+		return "", "", false
+	}
+	return target, bucket, true
+}
+
+func storeBucketReplication(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSReplication, bytes)
+}
+
+func deleteBucketReplication(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSReplication)
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket replication
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketReplication.html
+func (o *ObjectNode) getBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketReplicationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *ReplicationConfiguration
+	if config, err = vol.metaLoader.loadReplication(); err != nil {
+		log.LogErrorf("getBucketReplicationHandler: load replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config == nil {
+		errorCode = NoSuchReplicationConfiguration
+		return
+	}
+	output := &ReplicationConfiguration{XMLNS: S3Namespace, Role: config.Role, Rules: config.Rules}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		log.LogErrorf("getBucketReplicationHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), output, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket replication
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketReplication.html
+func (o *ObjectNode) putBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if o.replicator == nil {
+		errorCode = ReplicationNotConfigured
+		return
+	}
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxReplicationConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxReplicationConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var config *ReplicationConfiguration
+	if config, errorCode = ParseReplicationConfig(body); errorCode != nil {
+		log.LogErrorf("putBucketReplicationHandler: parse replication config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+	if errorCode = o.replicator.ValidateConfig(config); errorCode != nil {
+		log.LogErrorf("putBucketReplicationHandler: invalid replication destination: requestID(%v) volume(%v) config(%v)",
+			GetRequestID(r), vol.Name(), string(body))
+		return
+	}
+	if body, err = json.Marshal(config); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: json marshal replication config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketReplication(body, vol); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: store replication config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeReplication(config)
+	log.LogInfof("Audit: put bucket replication: requestID(%v) remote(%v) volume(%v) config(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), string(body))
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Delete bucket replication
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketReplication.html
+func (o *ObjectNode) deleteBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketReplicationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if err = deleteBucketReplication(vol); err != nil {
+		log.LogErrorf("deleteBucketReplicationHandler: delete replication config fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	vol.metaLoader.storeReplication(nil)
+	log.LogInfof("Audit: delete bucket replication: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// matchReplication returns the replication rule matched by the object to write and the
+// initial replication status of the object. The object put by the replication of another
+// cluster is marked as a replica, which is never replicated again.
+func (o *ObjectNode) matchReplication(header http.Header, vol *Volume, key string, tagging *Tagging) (*ReplicationRule, string) {
+	if header.Get(XAmzReplicationStatus) == ReplicationStatusReplica {
+		return nil, ReplicationStatusReplica
+	}
+	if o.replicator == nil {
+		return nil, ""
+	}
+	config, err := vol.metaLoader.loadReplication()
+	if err != nil {
+		log.LogWarnf("matchReplication: load replication fail: volume(%v) path(%v) err(%v)", vol.Name(), key, err)
+		return nil, ""
+	}
+	rule := config.Match(key, tagging)
+	if rule == nil {
+		return nil, ""
+	}
+	return rule, ReplicationStatusPending
+}
+
+// submitReplication queues the replication of the object written successfully.
+func (o *ObjectNode) submitReplication(requestID string, vol *Volume, path string, info *FSFileInfo, rule *ReplicationRule) {
+	if rule == nil || o.replicator == nil {
+		return
+	}
+	if err := o.replicator.Submit(vol.Name(), path, info, rule); err != nil {
+		log.LogErrorf("submitReplication: submit replication fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			requestID, vol.Name(), path, err)
+		if err = vol.mw.XAttrSet_ll(info.Inode, []byte(XAttrKeyOSSReplStatus), []byte(ReplicationStatusFailed)); err != nil {
+			log.LogWarnf("submitReplication: set replication status fail: requestID(%v) volume(%v) path(%v) err(%v)",
+				requestID, vol.Name(), path, err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	replicationTaskFileSuffix    = ".task"
+	replicationTaskTmpFileSuffix = replicationTaskFileSuffix + ".tmp"
+)
+
+// ReplicationTask is a pending replication of an object version.
+type ReplicationTask struct {
+	ID        string    `json:"id"`
+	Volume    string    `json:"volume"`
+	Path      string    `json:"path"`
+	VersionId string    `json:"version_id,omitempty"`
+	Inode     uint64    `json:"inode"`
+	Target    string    `json:"target"`
+	Bucket    string    `json:"bucket"`
+	Created   time.Time `json:"created"`
+	Attempts  int       `json:"attempts,omitempty"`
+	NextRetry time.Time `json:"next_retry,omitempty"`
+
+	StorageClass string `json:"storage_class,omitempty"`
+}
+
+func newReplicationTaskID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("%016x-%s", time.Now().UnixNano(), hex.EncodeToString(b[:]))
+}
+
+// replicationQueue is a persistent queue of the replication tasks. Each task is kept
+// in a file of the queue directory until it is done, so that the tasks survive the
+// restart of the ObjectNode, and the failed tasks are retried later.
+type replicationQueue struct {
+	dir   string
+	ready chan *ReplicationTask
+
+	mu       sync.Mutex
+	tasks    map[string]*ReplicationTask // all the tasks not done
+	inflight map[string]struct{}         // tasks in the ready channel or being processed
+}
+
+func newReplicationQueue(dir string, size int) (*replicationQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	q := &replicationQueue{
+		dir:      dir,
+		ready:    make(chan *ReplicationTask, size),
+		tasks:    make(map[string]*ReplicationTask),
+		inflight: make(map[string]struct{}),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, replicationTaskTmpFileSuffix) {
+			// the temporary file is left if the ObjectNode crashed while writing it
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		if !strings.HasSuffix(name, replicationTaskFileSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		task := &ReplicationTask{}
+		if err = json.Unmarshal(data, task); err != nil || task.ID+replicationTaskFileSuffix != name {
+			// the task file is broken if the ObjectNode crashed while writing it
+			log.LogWarnf("newReplicationQueue: drop broken task file: dir(%v) name(%v) err(%v)", dir, name, err)
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		q.tasks[task.ID] = task
+	}
+	return q, nil
+}
+
+func (q *replicationQueue) taskFile(id string) string {
+	return filepath.Join(q.dir, id+replicationTaskFileSuffix)
+}
+
+func (q *replicationQueue) persist(task *ReplicationTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(q.dir, task.ID+replicationTaskTmpFileSuffix)
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, q.taskFile(task.ID)); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Push adds a new task to the queue, the task is processed immediately if the
+// ready channel is not full, otherwise it is picked up by the next Schedule.
+func (q *replicationQueue) Push(task *ReplicationTask) error {
+	if err := q.persist(task); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.tasks[task.ID] = task
+	select {
+	case q.ready <- task:
+		q.inflight[task.ID] = struct{}{}
+	default:
+	}
+	return nil
+}
+
+// Schedule moves the due tasks into the ready channel.
+func (q *replicationQueue) Schedule(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for id, task := range q.tasks {
+		if _, ok := q.inflight[id]; ok || task.NextRetry.After(now) {
+			continue
+		}
+		select {
+		case q.ready <- task:
+			q.inflight[id] = struct{}{}
+		default:
+			return
+		}
+	}
+}
+
+// Ready returns the channel of the tasks to be processed.
+func (q *replicationQueue) Ready() <-chan *ReplicationTask {
+	return q.ready
+}
+
+// Done removes the task from the queue.
+func (q *replicationQueue) Done(task *ReplicationTask) {
+	if err := os.Remove(q.taskFile(task.ID)); err != nil && !os.IsNotExist(err) {
+		log.LogWarnf("replicationQueue: remove task file fail: id(%v) err(%v)", task.ID, err)
+	}
+	q.mu.Lock()
+	delete(q.tasks, task.ID)
+	delete(q.inflight, task.ID)
+	q.mu.Unlock()
+}
+
+// Retry puts the task back to the queue, which is scheduled again after the delay.
+func (q *replicationQueue) Retry(task *ReplicationTask, delay time.Duration) {
+	task.Attempts++
+	task.NextRetry = time.Now().Add(delay)
+	if err := q.persist(task); err != nil {
+		log.LogWarnf("replicationQueue: persist task fail: id(%v) err(%v)", task.ID, err)
+	}
+	q.mu.Lock()
+	delete(q.inflight, task.ID)
+	q.mu.Unlock()
+}
+
+// Len returns the number of the tasks not done.
+func (q *replicationQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.tasks)
+}
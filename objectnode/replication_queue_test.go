@@ -0,0 +1,81 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReplicationTask(path string) *ReplicationTask {
+	return &ReplicationTask{
+		ID:      newReplicationTaskID(),
+		Volume:  "vol",
+		Path:    path,
+		Inode:   1,
+		Target:  "remote",
+		Bucket:  "backup",
+		Created: time.Now(),
+	}
+}
+
+func TestReplicationQueue(t *testing.T) {
+	dir := t.TempDir()
+	q, err := newReplicationQueue(dir, 1)
+	require.NoError(t, err)
+
+	task1, task2 := newTestReplicationTask("a"), newTestReplicationTask("b")
+	require.NoError(t, q.Push(task1))
+	require.NoError(t, q.Push(task2)) // the ready channel is full
+	require.Equal(t, 2, q.Len())
+	require.Equal(t, task1, <-q.Ready())
+
+	// task2 is scheduled after task1 left the ready channel
+	q.Schedule(time.Now())
+	require.Equal(t, task2, <-q.Ready())
+
+	// the retried task is not scheduled until the delay elapses
+	q.Retry(task1, time.Hour)
+	require.Equal(t, 1, task1.Attempts)
+	q.Schedule(time.Now())
+	require.Len(t, q.Ready(), 0)
+	q.Schedule(time.Now().Add(2 * time.Hour))
+	require.Equal(t, task1, <-q.Ready())
+
+	q.Done(task2)
+	require.Equal(t, 1, q.Len())
+	_, err = os.Stat(filepath.Join(dir, task2.ID+replicationTaskFileSuffix))
+	require.True(t, os.IsNotExist(err))
+
+	// the tasks not done are reloaded with the retry state, and the broken and temporary files are dropped
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken"+replicationTaskFileSuffix), []byte("{"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "partial"+replicationTaskTmpFileSuffix), []byte("{"), 0o644))
+	q, err = newReplicationQueue(dir, 2)
+	require.NoError(t, err)
+	require.Equal(t, 1, q.Len())
+	q.Schedule(time.Now().Add(2 * time.Hour))
+	reloaded := <-q.Ready()
+	require.Equal(t, task1.ID, reloaded.ID)
+	require.Equal(t, task1.Path, reloaded.Path)
+	require.Equal(t, 1, reloaded.Attempts)
+	_, err = os.Stat(filepath.Join(dir, "broken"+replicationTaskFileSuffix))
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "partial"+replicationTaskTmpFileSuffix))
+	require.True(t, os.IsNotExist(err))
+}
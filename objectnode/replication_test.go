@@ -0,0 +1,224 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReplicationConfig(t *testing.T) {
+	valid := `<ReplicationConfiguration>
+	<Role>arn:aws:iam::123456789012:role/replication</Role>
+	<Rule>
+		<ID>logs</ID>
+		<Status>Enabled</Status>
+		<Priority>1</Priority>
+		<Filter><Prefix>logs/</Prefix></Filter>
+		<Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination>
+		<DeleteMarkerReplication><Status>Disabled</Status></DeleteMarkerReplication>
+	</Rule>
+	<Rule>
+		<ID>tagged</ID>
+		<Status>Enabled</Status>
+		<Priority>2</Priority>
+		<Filter><And><Prefix>data/</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></And></Filter>
+		<Destination><Bucket>arn:cubefs:replication::remote:backup</Bucket><StorageClass>STANDARD</StorageClass></Destination>
+	</Rule>
+</ReplicationConfiguration>`
+	config, errCode := ParseReplicationConfig([]byte(valid))
+	require.Nil(t, errCode)
+	require.Len(t, config.Rules, 2)
+	require.Equal(t, "logs/", config.Rules[0].prefix())
+	require.Equal(t, "data/", config.Rules[1].prefix())
+	require.Equal(t, []Tag{{Key: "k", Value: "v"}}, config.Rules[1].tags())
+
+	legacy := `<ReplicationConfiguration><Rule><Status>Enabled</Status><Prefix>a/</Prefix>
+		<Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination></Rule></ReplicationConfiguration>`
+	config, errCode = ParseReplicationConfig([]byte(legacy))
+	require.Nil(t, errCode)
+	require.Equal(t, "a/", config.Rules[0].prefix())
+
+	rule := func(body string) string {
+		return "<ReplicationConfiguration><Rule>" + body + "</Rule></ReplicationConfiguration>"
+	}
+	dest := "<Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination>"
+	cases := []struct {
+		data    string
+		errCode *ErrorCode
+	}{
+		{"<ReplicationConfiguration", MalformedXML},
+		{"<ReplicationConfiguration></ReplicationConfiguration>", MalformedXML},
+		{rule("<Status>On</Status>" + dest), MalformedXML},
+		{rule("<Status>Enabled</Status>"), MalformedXML},
+		{rule("<Status>Enabled</Status><Destination><Bucket>backup</Bucket></Destination>"), InvalidReplicationDestination},
+		{rule("<Status>Enabled</Status><Destination><Bucket>arn:cubefs:replication::backup</Bucket></Destination>"), InvalidReplicationDestination},
+		{rule("<Status>Enabled</Status><Prefix>a</Prefix><Filter><Prefix>a</Prefix></Filter>" + dest), MalformedXML},
+		{rule("<Status>Enabled</Status><Filter><Prefix>a</Prefix><And><Prefix>a</Prefix></And></Filter>" + dest), MalformedXML},
+		{rule("<Status>Enabled</Status><DeleteMarkerReplication><Status>Enabled</Status></DeleteMarkerReplication>" + dest), InvalidReplicationRule},
+		{rule("<ID>" + strings.Repeat("a", MaxReplicationRuleIDSize+1) + "</ID><Status>Enabled</Status>" + dest), InvalidReplicationRule},
+		{rule("<Status>Enabled</Status><Filter><Tag><Key></Key><Value>v</Value></Tag></Filter>" + dest), InvalidReplicationRule},
+		{"<ReplicationConfiguration><Rule><ID>a</ID><Status>Enabled</Status>" + dest + "</Rule>" +
+			"<Rule><ID>a</ID><Status>Enabled</Status>" + dest + "</Rule></ReplicationConfiguration>", InvalidReplicationRule},
+		{"<ReplicationConfiguration><Rule><Status>Enabled</Status><Priority>1</Priority><Filter></Filter>" + dest + "</Rule>" +
+			"<Rule><Status>Enabled</Status><Priority>1</Priority><Filter></Filter>" + dest + "</Rule></ReplicationConfiguration>", InvalidReplicationRule},
+	}
+	for _, c := range cases {
+		_, errCode = ParseReplicationConfig([]byte(c.data))
+		require.Equal(t, c.errCode, errCode, c.data)
+	}
+}
+
+func TestReplicationConfigMatch(t *testing.T) {
+	dest := &ReplicationDestination{Bucket: "arn:aws:s3:::backup"}
+	config := &ReplicationConfiguration{Rules: []*ReplicationRule{
+		{ID: "all", Status: ReplicationRuleEnabled, Priority: 1, Filter: &ReplicationFilter{}, Destination: dest},
+		{ID: "logs", Status: ReplicationRuleEnabled, Priority: 2, Filter: &ReplicationFilter{Prefix: "logs/"}, Destination: dest},
+		{ID: "tag", Status: ReplicationRuleEnabled, Priority: 3, Filter: &ReplicationFilter{Tag: &Tag{Key: "k", Value: "v"}}, Destination: dest},
+		{ID: "and", Status: ReplicationRuleEnabled, Priority: 4, Filter: &ReplicationFilter{
+			And: &ReplicationAnd{Prefix: "logs/", Tags: []Tag{{Key: "k", Value: "v"}, {Key: "x", Value: "y"}}},
+		}, Destination: dest},
+		{ID: "disabled", Status: ReplicationRuleDisabled, Priority: 5, Filter: &ReplicationFilter{}, Destination: dest},
+	}}
+	tagging := func(tags ...Tag) *Tagging { return &Tagging{TagSet: tags} }
+
+	require.Equal(t, "all", config.Match("data/a", nil).ID)
+	require.Equal(t, "logs", config.Match("logs/a", nil).ID)
+	require.Equal(t, "tag", config.Match("logs/a", tagging(Tag{Key: "k", Value: "v"})).ID)
+	require.Equal(t, "tag", config.Match("data/a", tagging(Tag{Key: "k", Value: "v"}, Tag{Key: "x", Value: "y"})).ID)
+	require.Equal(t, "and", config.Match("logs/a", tagging(Tag{Key: "x", Value: "y"}, Tag{Key: "k", Value: "v"})).ID)
+	require.Equal(t, "logs", config.Match("logs/a", tagging(Tag{Key: "k", Value: "w"})).ID)
+
+	config.Rules[0].Status = ReplicationRuleDisabled
+	require.Nil(t, config.Match("data/a", nil))
+
+	var empty *ReplicationConfiguration
+	require.Nil(t, empty.Match("data/a", nil))
+}
+
+func TestParseReplicationDestination(t *testing.T) {
+	cases := []struct {
+		arn            string
+		target, bucket string
+		ok             bool
+	}{
+		{"arn:aws:s3:::backup", "", "backup", true},
+		{"arn:cubefs:replication::remote:backup", "remote", "backup", true},
+		{"arn:aws:s3:::", "", "", false},
+		{"arn:aws:s3:::a/b", "", "", false},
+		{"arn:cubefs:replication::remote", "", "", false},
+		{"arn:cubefs:replication:::backup", "", "", false},
+		{"arn:cubefs:replication::remote:", "", "", false},
+		{"backup", "", "", false},
+	}
+	for _, c := range cases {
+		target, bucket, ok := parseReplicationDestination(c.arn)
+		require.Equal(t, c.ok, ok, c.arn)
+		require.Equal(t, c.target, target, c.arn)
+		require.Equal(t, c.bucket, bucket, c.arn)
+	}
+}
+
+func TestReplicatorValidateConfig(t *testing.T) {
+	targets := map[string]ReplicationTargetConfig{"remote": {Endpoint: "http://127.0.0.1:17410"}}
+	r, err := NewReplicator(ReplicationConfig{QueueDir: t.TempDir(), Targets: targets}, nil, nil)
+	require.NoError(t, err)
+
+	config := func(bucket string) *ReplicationConfiguration {
+		return &ReplicationConfiguration{Rules: []*ReplicationRule{{Destination: &ReplicationDestination{Bucket: bucket}}}}
+	}
+	require.Nil(t, r.ValidateConfig(config("arn:aws:s3:::backup")))
+	require.Nil(t, r.ValidateConfig(config("arn:cubefs:replication::remote:backup")))
+	require.Equal(t, InvalidReplicationDestination, r.ValidateConfig(config("arn:cubefs:replication::other:backup")))
+
+	targets["other"] = ReplicationTargetConfig{Endpoint: "http://127.0.0.1:17411"}
+	r, err = NewReplicator(ReplicationConfig{QueueDir: t.TempDir(), Targets: targets}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, InvalidReplicationDestination, r.ValidateConfig(config("arn:aws:s3:::backup")))
+	require.Nil(t, r.ValidateConfig(config("arn:cubefs:replication::other:backup")))
+
+	_, err = NewReplicator(ReplicationConfig{Targets: targets}, nil, nil)
+	require.Error(t, err)
+	_, err = NewReplicator(ReplicationConfig{QueueDir: t.TempDir()}, nil, nil)
+	require.Error(t, err)
+}
+
+func TestReplicationTargetPutObject(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+		objects  = make(map[string]string)
+		parts    = make(map[string]string)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		require.Equal(t, ReplicationStatusReplica, r.Header.Get(XAmzReplicationStatus))
+		switch {
+		case r.Method == http.MethodPut && query.Get("partNumber") != "":
+			parts[query.Get("partNumber")] = string(body)
+			w.Header().Set(ETag, `"etag"`)
+		case r.Method == http.MethodPut:
+			require.Equal(t, "text/plain", r.Header.Get(ContentType))
+			require.Equal(t, "v", r.Header.Get(XAmzMetaPrefix+"k"))
+			objects[r.URL.Path] = string(body)
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			_, _ = w.Write([]byte(`<InitiateMultipartUploadResult><Bucket>backup</Bucket><Key>large</Key>` +
+				`<UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`))
+		case r.Method == http.MethodPost && query.Get("uploadId") == "upload-1":
+			objects[r.URL.Path] = parts["1"] + parts["2"] + parts["3"]
+			_, _ = w.Write([]byte(`<CompleteMultipartUploadResult><ETag>"etag-3"</ETag></CompleteMultipartUploadResult>`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	target, err := newReplicationTarget("remote", ReplicationTargetConfig{Endpoint: server.URL, AccessKey: "AK", SecretKey: "SK"})
+	require.NoError(t, err)
+
+	newObject := func(data string) *replicationObject {
+		return &replicationObject{
+			info: &FSFileInfo{Size: int64(len(data)), MIMEType: "text/plain", Metadata: map[string]string{"k": "v"}},
+			read: func(w io.Writer, offset, size uint64) error {
+				_, err := io.WriteString(w, data[offset:offset+size])
+				return err
+			},
+		}
+	}
+	require.NoError(t, target.putObject(context.Background(), "backup", "small", "", newObject("hello"), 16))
+	require.Equal(t, "hello", objects["/backup/small"])
+
+	require.NoError(t, target.putObject(context.Background(), "backup", "large", "", newObject("0123456789"), 4))
+	require.Equal(t, "0123456789", objects["/backup/large"])
+	require.Equal(t, []string{
+		"PUT /backup/small",
+		"POST /backup/large",
+		"PUT /backup/large",
+		"PUT /backup/large",
+		"PUT /backup/large",
+		"POST /backup/large",
+	}, requests)
+}
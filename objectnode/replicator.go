@@ -0,0 +1,478 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultReplicationWorkers    = 4
+	defaultReplicationMaxRetries = 20
+	defaultReplicationPartSize   = 16 << 20 // 16MB
+	defaultReplicationRegion     = "us-east-1"
+
+	replicationMaxParts        = 10000
+	replicationScheduleTick    = 5 * time.Second
+	replicationMinRetryDelay   = 5 * time.Second
+	replicationMaxRetryDelay   = 30 * time.Minute
+	replicationRequestTimeout  = 10 * time.Minute
+	replicationTaskChannelSize = 1024
+
+	MetricReplicationLag       = "replication_lag_seconds"
+	MetricReplicationPending   = "replication_pending_tasks"
+	MetricReplicationCompleted = "replication_completed"
+	MetricReplicationFailed    = "replication_failed"
+)
+
+// ReplicationConfig is the configuration of the bucket replication on the ObjectNode.
+// The destination of a replication rule must be one of the remote targets.
+type ReplicationConfig struct {
+	QueueDir   string                             `json:"queueDir"`
+	Workers    int                                `json:"workers,omitempty"`
+	MaxRetries int                                `json:"maxRetries,omitempty"`
+	PartSize   int64                              `json:"partSize,omitempty"`
+	Targets    map[string]ReplicationTargetConfig `json:"targets"`
+}
+
+// ReplicationTargetConfig is a remote S3-compatible endpoint, such as the ObjectNode of
+// another CubeFS cluster.
+type ReplicationTargetConfig struct {
+	Endpoint         string `json:"endpoint"`
+	Region           string `json:"region,omitempty"`
+	AccessKey        string `json:"accessKey"`
+	SecretKey        string `json:"secretKey"`
+	VirtualHostStyle bool   `json:"virtualHostStyle,omitempty"`
+}
+
+type replicationTarget struct {
+	id     string
+	client *s3.S3
+}
+
+func newReplicationTarget(id string, conf ReplicationTargetConfig) (*replicationTarget, error) {
+	if conf.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint of target %v is not specified", id)
+	}
+	if conf.Region == "" {
+		conf.Region = defaultReplicationRegion
+	}
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:         aws.String(conf.Endpoint),
+		Region:           aws.String(conf.Region),
+		Credentials:      credentials.NewStaticCredentials(conf.AccessKey, conf.SecretKey, ""),
+		S3ForcePathStyle: aws.Bool(!conf.VirtualHostStyle),
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := s3.New(sess)
+	client.Handlers.Build.PushBack(replicaHeader)
+	return &replicationTarget{id: id, client: client}, nil
+}
+
+// replicaHeader marks the object put to the target as a replica, so that a replica is
+// never replicated again if the target replicates back to this cluster.
+func replicaHeader(r *request.Request) {
+	r.HTTPRequest.Header.Set(XAmzReplicationStatus, ReplicationStatusReplica)
+}
+
+// replicationObject is the source object to replicate.
+type replicationObject struct {
+	info    *FSFileInfo
+	tagging string
+	// read writes the plaintext data of the object in range [offset, offset+size) to w.
+	read func(w io.Writer, offset, size uint64) error
+}
+
+func (t *replicationTarget) putObject(ctx context.Context, bucket, key, storageClass string, obj *replicationObject, partSize int64) (err error) {
+	info := obj.info
+	var (
+		contentType, disposition, cacheControl, tagging, sse, class *string
+		expires                                                     *time.Time
+		metadata                                                    map[string]*string
+	)
+	if info.MIMEType != "" {
+		contentType = aws.String(info.MIMEType)
+	}
+	if info.Disposition != "" {
+		disposition = aws.String(info.Disposition)
+	}
+	if info.CacheControl != "" {
+		cacheControl = aws.String(info.CacheControl)
+	}
+	if info.Expires != "" {
+		if e, err := http.ParseTime(info.Expires); err == nil {
+			expires = aws.Time(e)
+		}
+	}
+	if obj.tagging != "" {
+		tagging = aws.String(obj.tagging)
+	}
+	if info.Encryption != nil {
+		sse = aws.String(info.Encryption.Algorithm)
+	}
+	if storageClass != "" {
+		class = aws.String(storageClass)
+	}
+	if len(info.Metadata) > 0 {
+		metadata = aws.StringMap(info.Metadata)
+	}
+
+	size := info.Size
+	if size <= partSize {
+		buf := bytes.NewBuffer(make([]byte, 0, size))
+		if err = obj.read(buf, 0, uint64(size)); err != nil {
+			return
+		}
+		_, err = t.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+			Bucket:               aws.String(bucket),
+			Key:                  aws.String(key),
+			Body:                 bytes.NewReader(buf.Bytes()),
+			ContentType:          contentType,
+			ContentDisposition:   disposition,
+			CacheControl:         cacheControl,
+			Expires:              expires,
+			Tagging:              tagging,
+			ServerSideEncryption: sse,
+			StorageClass:         class,
+			Metadata:             metadata,
+		})
+		return
+	}
+
+	// the large object is replicated by multipart upload
+	if size > partSize*replicationMaxParts {
+		partSize = (size + replicationMaxParts - 1) / replicationMaxParts
+	}
+	created, err := t.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		ContentType:          contentType,
+		ContentDisposition:   disposition,
+		CacheControl:         cacheControl,
+		Expires:              expires,
+		Tagging:              tagging,
+		ServerSideEncryption: sse,
+		StorageClass:         class,
+		Metadata:             metadata,
+	})
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_, _ = t.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(bucket),
+				Key:      aws.String(key),
+				UploadId: created.UploadId,
+			})
+		}
+	}()
+	var (
+		parts []*s3.CompletedPart
+		buf   = bytes.NewBuffer(make([]byte, 0, partSize))
+	)
+	for offset, number := int64(0), int64(1); offset < size; offset, number = offset+partSize, number+1 {
+		n := partSize
+		if size-offset < n {
+			n = size - offset
+		}
+		buf.Reset()
+		if err = obj.read(buf, uint64(offset), uint64(n)); err != nil {
+			return
+		}
+		var uploaded *s3.UploadPartOutput
+		if uploaded, err = t.client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(key),
+			UploadId:   created.UploadId,
+			PartNumber: aws.Int64(number),
+			Body:       bytes.NewReader(buf.Bytes()),
+		}); err != nil {
+			return
+		}
+		parts = append(parts, &s3.CompletedPart{ETag: uploaded.ETag, PartNumber: aws.Int64(number)})
+	}
+	_, err = t.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        created.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	return
+}
+
+// Replicator replicates the objects to the remote targets asynchronously.
+type Replicator struct {
+	conf    ReplicationConfig
+	getVol  func(name string) (*Volume, error)
+	kms     KMS
+	queue   *replicationQueue
+	targets map[string]*replicationTarget
+
+	lag       *exporter.Gauge
+	pending   *exporter.Gauge
+	completed *exporter.Counter
+	failed    *exporter.Counter
+
+	stopC chan struct{}
+	wg    sync.WaitGroup
+}
+
+func NewReplicator(conf ReplicationConfig, getVol func(name string) (*Volume, error), kms KMS) (*Replicator, error) {
+	if conf.QueueDir == "" {
+		return nil, errors.New("queue directory is not specified")
+	}
+	if len(conf.Targets) == 0 {
+		return nil, errors.New("no remote target")
+	}
+	if conf.Workers <= 0 {
+		conf.Workers = defaultReplicationWorkers
+	}
+	if conf.MaxRetries <= 0 {
+		conf.MaxRetries = defaultReplicationMaxRetries
+	}
+	if conf.PartSize <= 0 {
+		conf.PartSize = defaultReplicationPartSize
+	}
+	r := &Replicator{
+		conf:      conf,
+		getVol:    getVol,
+		kms:       kms,
+		targets:   make(map[string]*replicationTarget),
+		lag:       exporter.NewGauge(MetricReplicationLag),
+		pending:   exporter.NewGauge(MetricReplicationPending),
+		completed: exporter.NewCounter(MetricReplicationCompleted),
+		failed:    exporter.NewCounter(MetricReplicationFailed),
+		stopC:     make(chan struct{}),
+	}
+	for id, tc := range conf.Targets {
+		target, err := newReplicationTarget(id, tc)
+		if err != nil {
+			return nil, err
+		}
+		r.targets[id] = target
+	}
+	var err error
+	if r.queue, err = newReplicationQueue(conf.QueueDir, replicationTaskChannelSize); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Replicator) Start() {
+	r.wg.Add(1)
+	go r.schedule()
+	for i := 0; i < r.conf.Workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+}
+
+func (r *Replicator) Close() {
+	close(r.stopC)
+	r.wg.Wait()
+}
+
+// resolveTarget returns the id of the remote target of the destination, the only
+// target is returned if it is not specified by the destination.
+func (r *Replicator) resolveTarget(target string) (string, bool) {
+	if target == "" && len(r.targets) == 1 {
+		for id := range r.targets {
+			return id, true
+		}
+	}
+	_, ok := r.targets[target]
+	return target, ok
+}
+
+// ValidateConfig checks all the destinations of the replication rules are available.
+func (r *Replicator) ValidateConfig(config *ReplicationConfiguration) *ErrorCode {
+	for _, rule := range config.Rules {
+		target, _, _ := parseReplicationDestination(rule.Destination.Bucket)
+		if _, ok := r.resolveTarget(target); !ok {
+			return InvalidReplicationDestination
+		}
+	}
+	return nil
+}
+
+// Submit queues the replication of the object matched by the rule.
+func (r *Replicator) Submit(vol, path string, info *FSFileInfo, rule *ReplicationRule) error {
+	target, bucket, _ := parseReplicationDestination(rule.Destination.Bucket)
+	target, ok := r.resolveTarget(target)
+	if !ok {
+		return InvalidReplicationDestination
+	}
+	return r.queue.Push(&ReplicationTask{
+		ID:        newReplicationTaskID(),
+		Volume:    vol,
+		Path:      path,
+		VersionId: info.VersionId,
+		Inode:     info.Inode,
+		Target:    target,
+		Bucket:    bucket,
+		Created:   time.Now(),
+
+		StorageClass: rule.Destination.StorageClass,
+	})
+}
+
+func (r *Replicator) schedule() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(replicationScheduleTick)
+	defer ticker.Stop()
+	for {
+		r.queue.Schedule(time.Now())
+		r.pending.Set(float64(r.queue.Len()))
+		select {
+		case <-r.stopC:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Replicator) work() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.stopC:
+			return
+		case task := <-r.queue.Ready():
+			r.process(task)
+		}
+	}
+}
+
+func (r *Replicator) retryDelay(attempts int) time.Duration {
+	delay := replicationMinRetryDelay
+	for i := 0; i < attempts && delay < replicationMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > replicationMaxRetryDelay {
+		delay = replicationMaxRetryDelay
+	}
+	return delay
+}
+
+func (r *Replicator) process(task *ReplicationTask) {
+	labels := map[string]string{"volume": task.Volume, "target": task.Target}
+	status, err := r.replicate(task)
+	if err != nil {
+		log.LogWarnf("Replicator: replicate object fail: volume(%v) path(%v) versionId(%v) target(%v) bucket(%v) attempts(%v) err(%v)",
+			task.Volume, task.Path, task.VersionId, task.Target, task.Bucket, task.Attempts, err)
+		if status == "" && task.Attempts+1 < r.conf.MaxRetries {
+			r.queue.Retry(task, r.retryDelay(task.Attempts))
+			return
+		}
+		status = ReplicationStatusFailed
+	}
+	switch status {
+	case ReplicationStatusCompleted:
+		r.completed.AddWithLabels(1, labels)
+		r.lag.SetWithLabels(time.Since(task.Created).Seconds(), labels)
+	case ReplicationStatusFailed:
+		r.failed.AddWithLabels(1, labels)
+		exporter.Warning(fmt.Sprintf("replicate object fail: volume(%v) path(%v) versionId(%v) target(%v) err(%v)",
+			task.Volume, task.Path, task.VersionId, task.Target, err))
+	}
+	if status != "" {
+		r.setStatus(task, status)
+	}
+	r.queue.Done(task)
+}
+
+// replicate replicates the object of the task, and returns the final replication status of
+// the object. An empty status with an error means the task is to be retried, and an empty
+// status without error means the object version of the task no longer exists.
+func (r *Replicator) replicate(task *ReplicationTask) (status string, err error) {
+	target, ok := r.targets[task.Target]
+	if !ok {
+		return ReplicationStatusFailed, fmt.Errorf("target %v not found", task.Target)
+	}
+	vol, err := r.getVol(task.Volume)
+	if err != nil {
+		if err == NoSuchBucket {
+			return "", nil
+		}
+		return
+	}
+	// Only the current version is replicated, which keeps the target from being overwritten
+	// by a stale version if the tasks of the same object are processed out of order.
+	info, xattr, err := vol.ObjectMeta(task.Path)
+	if err == syscall.ENOENT || (err == nil && info.Inode != task.Inode) {
+		// the object has been deleted or overwritten, the newer object has its own task
+		return "", nil
+	}
+	if err != nil {
+		return
+	}
+	if info.Encryption.IsCustomer() {
+		// the customer key is not kept by the server, so the SSE-C objects can not be replicated
+		return ReplicationStatusFailed, errors.New("object encrypted with customer key")
+	}
+	if info.Encryption != nil {
+		if err = info.Encryption.Unseal(r.kms, nil); err != nil {
+			return
+		}
+	}
+
+	obj := &replicationObject{
+		info:    info,
+		tagging: string(xattr.Get(XAttrKeyOSSTagging)),
+		read: func(w io.Writer, offset, size uint64) error {
+			if info.Encryption != nil {
+				w = info.Encryption.DecryptWriter(w, offset)
+			}
+			return vol.readFile(info.Inode, uint64(info.Size), task.Path, w, offset, size, info.StorageClass)
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), replicationRequestTimeout)
+	defer cancel()
+	if err = target.putObject(ctx, task.Bucket, task.Path, task.StorageClass, obj, r.conf.PartSize); err != nil {
+		return
+	}
+	return ReplicationStatusCompleted, nil
+}
+
+func (r *Replicator) setStatus(task *ReplicationTask, status string) {
+	vol, err := r.getVol(task.Volume)
+	if err == nil {
+		err = vol.mw.XAttrSet_ll(task.Inode, []byte(XAttrKeyOSSReplStatus), []byte(status))
+	}
+	if err != nil {
+		log.LogWarnf("Replicator: set replication status fail: volume(%v) path(%v) inode(%v) status(%v) err(%v)",
+			task.Volume, task.Path, task.Inode, status, err)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_RestoreObject.html
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	MaxRestoreRequestSize = 1 << 10 // 1KB
+
+	RestoreRequestTypeSelect = "SELECT"
+
+	// restoreStaleTimeout is the time after which an ongoing restore is regarded as lost,
+	// e.g. the LcNode running it is down, so that the object can be restored again.
+	restoreStaleTimeout = 24 * time.Hour
+)
+
+type GlacierJobParameters struct {
+	Tier string `xml:"Tier,omitempty"`
+}
+
+type RestoreRequest struct {
+	XMLNS                string                `xml:"xmlns,attr,omitempty"`
+	XMLName              xml.Name              `xml:"RestoreRequest"`
+	Days                 int                   `xml:"Days"`
+	GlacierJobParameters *GlacierJobParameters `xml:"GlacierJobParameters,omitempty"`
+	Type                 string                `xml:"Type,omitempty"`
+}
+
+func ParseRestoreRequest(data []byte) (*RestoreRequest, *ErrorCode) {
+	req := &RestoreRequest{}
+	if err := xml.Unmarshal(data, req); err != nil {
+		return nil, MalformedXML
+	}
+	if strings.EqualFold(req.Type, RestoreRequestTypeSelect) {
+		return nil, UnsupportedOperation
+	}
+	if req.Days <= 0 {
+		return nil, InvalidArgument
+	}
+	return req, nil
+}
+
+func parseRestoreStatus(data []byte) *proto.RestoreStatus {
+	if len(data) == 0 {
+		return nil
+	}
+	status := &proto.RestoreStatus{}
+	if err := json.Unmarshal(data, status); err != nil {
+		log.LogWarnf("parseRestoreStatus: unmarshal fail: data(%v) err(%v)", string(data), err)
+		return nil
+	}
+	return status
+}
+
+// formatRestoreHeader returns the value of the x-amz-restore header of the object.
+func formatRestoreHeader(status *proto.RestoreStatus) string {
+	if status == nil {
+		return ""
+	}
+	if status.Ongoing {
+		return `ongoing-request="true"`
+	}
+	if !status.Restored() {
+		return ""
+	}
+	return fmt.Sprintf(`ongoing-request="false", expiry-date="%s"`,
+		time.Unix(status.ExpiryDate, 0).UTC().Format(http.TimeFormat))
+}
+
+// getRestoreStatus returns the restore status of the object, and the raw status to compare
+// when it is changed.
+func (v *Volume) getRestoreStatus(inode uint64) (*proto.RestoreStatus, []byte, error) {
+	xattr, err := v.mw.XAttrGet_ll(inode, XAttrKeyOSSRestore)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw := xattr.Get(XAttrKeyOSSRestore)
+	return parseRestoreStatus(raw), raw, nil
+}
+
+// setRestoreStatus sets the restore status if the raw status is not changed, since the status
+// is updated by the LcNode as well, and returns the raw status set.
+func (v *Volume) setRestoreStatus(inode uint64, expected []byte, status *proto.RestoreStatus) ([]byte, error) {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	if err = v.mw.XAttrCompareAndSet_ll(inode, XAttrKeyOSSRestore, expected, data); err != nil {
+		return nil, err
+	}
+	deleteAttrCache(inode, v.name)
+	return data, nil
+}
+
+func (v *Volume) clearRestoreStatus(inode uint64, expected []byte) error {
+	if err := v.mw.XAttrCompareAndSet_ll(inode, XAttrKeyOSSRestore, expected, nil); err != nil {
+		return err
+	}
+	deleteAttrCache(inode, v.name)
+	return nil
+}
+
+// deleteRestoredCopy removes the restored copy of the object which is about to be deleted,
+// otherwise the copy is leaked since the lifecycle scanner can no longer find it.
+func (v *Volume) deleteRestoredCopy(inode uint64, path string) {
+	if proto.IsCold(v.volType) {
+		return
+	}
+	status, _, err := v.getRestoreStatus(inode)
+	if err != nil || status == nil || status.CopyInode == 0 {
+		return
+	}
+	log.LogInfof("deleteRestoredCopy: volume(%v) path(%v) inode(%v) copyInode(%v)", v.name, path, inode, status.CopyInode)
+	if _, err = v.mw.InodeUnlink_ll(status.CopyInode, path); err != nil {
+		log.LogWarnf("deleteRestoredCopy: unlink inode fail: volume(%v) inode(%v) err(%v)", v.name, status.CopyInode, err)
+	}
+	if err = v.ec.EvictStream(status.CopyInode); err != nil {
+		log.LogWarnf("deleteRestoredCopy: evict stream fail: volume(%v) inode(%v) err(%v)", v.name, status.CopyInode, err)
+	}
+	if err = v.mw.Evict(status.CopyInode, path); err != nil {
+		log.LogWarnf("deleteRestoredCopy: evict inode fail: volume(%v) inode(%v) err(%v)", v.name, status.CopyInode, err)
+	}
+}
+
+// RestoreObject requests a temporary copy of the blobstore object for the specified days. The
+// expiry date is extended if the object has been restored, otherwise a restore task is submitted
+// to the master, and accepted is returned true. RestoreAlreadyInProgress is returned if the status
+// is changed concurrently, e.g. the restored copy is expired meanwhile.
+func (v *Volume) RestoreObject(path, versionId string, days int, submit func(task *proto.RestoreTask) error) (accepted bool, err error) {
+	var inode uint64
+	if inode, err = v.lookupObjectInode(path, versionId); err != nil {
+		return
+	}
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(inode); err != nil {
+		return
+	}
+	if proto.IsCold(v.volType) || !proto.IsStorageClassBlobStore(inoInfo.StorageClass) {
+		err = InvalidObjectState
+		return
+	}
+	var (
+		status *proto.RestoreStatus
+		raw    []byte
+	)
+	if status, raw, err = v.getRestoreStatus(inode); err != nil {
+		return
+	}
+	defer func() {
+		if err == syscall.ESTALE {
+			err = RestoreAlreadyInProgress
+		}
+	}()
+
+	now := time.Now()
+	if status.Restored() {
+		status.Days = days
+		status.ExpiryDate = proto.RestoreExpiryDate(now, days).Unix()
+		_, err = v.setRestoreStatus(inode, raw, status)
+		return
+	}
+	if status != nil && status.Ongoing && now.Sub(time.Unix(status.RequestTime, 0)) < restoreStaleTimeout {
+		err = RestoreAlreadyInProgress
+		return
+	}
+
+	status = &proto.RestoreStatus{Ongoing: true, RequestTime: now.Unix(), Days: days}
+	if raw, err = v.setRestoreStatus(inode, raw, status); err != nil {
+		return
+	}
+	task := &proto.RestoreTask{
+		Id:      fmt.Sprintf("%s:%d:%d", v.name, inode, now.UnixNano()),
+		VolName: v.name,
+		Path:    path,
+		Inode:   inode,
+		Days:    days,
+	}
+	if err = submit(task); err != nil {
+		if clearErr := v.clearRestoreStatus(inode, raw); clearErr != nil {
+			log.LogWarnf("RestoreObject: clear restore status fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, inode, clearErr)
+		}
+		return
+	}
+	accepted = true
+	return
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRestoreRequest(t *testing.T) {
+	req, errCode := ParseRestoreRequest([]byte(`
+<RestoreRequest xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+	<Days>2</Days>
+	<GlacierJobParameters>
+		<Tier>Standard</Tier>
+	</GlacierJobParameters>
+</RestoreRequest>`))
+	require.Nil(t, errCode)
+	require.Equal(t, 2, req.Days)
+	require.Equal(t, "Standard", req.GlacierJobParameters.Tier)
+
+	_, errCode = ParseRestoreRequest([]byte(`<RestoreRequest><Days>0</Days></RestoreRequest>`))
+	require.Equal(t, InvalidArgument, errCode)
+
+	_, errCode = ParseRestoreRequest([]byte(`<RestoreRequest><Days>1</Days><Type>SELECT</Type></RestoreRequest>`))
+	require.Equal(t, UnsupportedOperation, errCode)
+
+	_, errCode = ParseRestoreRequest([]byte(`<RestoreRequest><Days>one</Days></RestoreRequest>`))
+	require.Equal(t, MalformedXML, errCode)
+}
+
+func TestFormatRestoreHeader(t *testing.T) {
+	require.Empty(t, formatRestoreHeader(nil))
+	require.Empty(t, formatRestoreHeader(parseRestoreStatus([]byte("invalid"))))
+
+	status := parseRestoreStatus([]byte(`{"ongoing":true,"requestTime":1700000000,"days":1}`))
+	require.Equal(t, `ongoing-request="true"`, formatRestoreHeader(status))
+	require.False(t, status.Restored())
+
+	expiry := proto.RestoreExpiryDate(time.Date(2012, 12, 21, 10, 0, 0, 0, time.UTC), 1)
+	status = &proto.RestoreStatus{Days: 1, ExpiryDate: expiry.Unix(), CopyInode: 100}
+	require.True(t, status.Restored())
+	require.Equal(t, `ongoing-request="false", expiry-date="Sun, 23 Dec 2012 00:00:00 GMT"`, formatRestoreHeader(status))
+	require.False(t, status.Expired(expiry.Add(-time.Second)))
+	require.True(t, status.Expired(expiry))
+}
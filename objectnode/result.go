@@ -97,11 +97,28 @@ type InitMultipartResult struct {
 }
 
 type CompleteMultipartResult struct {
-	XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
-	Location string   `xml:"Location"`
-	Bucket   string   `xml:"Bucket"`
-	Key      string   `xml:"Key"`
-	ETag     string   `xml:"ETag"`
+	XMLName        xml.Name `xml:"CompleteMultipartUploadResult"`
+	Location       string   `xml:"Location"`
+	Bucket         string   `xml:"Bucket"`
+	Key            string   `xml:"Key"`
+	ETag           string   `xml:"ETag"`
+	ChecksumCRC32  string   `xml:"ChecksumCRC32,omitempty"`
+	ChecksumCRC32C string   `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA1   string   `xml:"ChecksumSHA1,omitempty"`
+	ChecksumSHA256 string   `xml:"ChecksumSHA256,omitempty"`
+}
+
+func (r *CompleteMultipartResult) setChecksum(checksum *ObjectChecksum) {
+	switch checksum.Algorithm {
+	case ChecksumAlgorithmCRC32:
+		r.ChecksumCRC32 = checksum.Value
+	case ChecksumAlgorithmCRC32C:
+		r.ChecksumCRC32C = checksum.Value
+	case ChecksumAlgorithmSHA1:
+		r.ChecksumSHA1 = checksum.Value
+	case ChecksumAlgorithmSHA256:
+		r.ChecksumSHA256 = checksum.Value
+	}
 }
 
 type Initiator struct {
@@ -190,6 +207,42 @@ type ListBucketResult struct {
 	CommonPrefixes []*CommonPrefix `xml:"CommonPrefixes"`
 }
 
+type ListVersionsResult struct {
+	XMLName             xml.Name             `xml:"ListVersionsResult"`
+	Name                string               `xml:"Name"`
+	Prefix              string               `xml:"Prefix"`
+	KeyMarker           string               `xml:"KeyMarker"`
+	VersionIdMarker     string               `xml:"VersionIdMarker"`
+	NextKeyMarker       string               `xml:"NextKeyMarker,omitempty"`
+	NextVersionIdMarker string               `xml:"NextVersionIdMarker,omitempty"`
+	MaxKeys             int                  `xml:"MaxKeys"`
+	Delimiter           string               `xml:"Delimiter,omitempty"`
+	EncodingType        string               `xml:"EncodingType,omitempty"`
+	IsTruncated         bool                 `xml:"IsTruncated"`
+	Versions            []*VersionContent    `xml:"Version"`
+	DeleteMarkers       []*DeleteMarkerEntry `xml:"DeleteMarker"`
+	CommonPrefixes      []*CommonPrefix      `xml:"CommonPrefixes"`
+}
+
+type VersionContent struct {
+	Key          string       `xml:"Key"`
+	VersionId    string       `xml:"VersionId"`
+	IsLatest     bool         `xml:"IsLatest"`
+	LastModified string       `xml:"LastModified"`
+	ETag         string       `xml:"ETag"`
+	Size         int          `xml:"Size"`
+	StorageClass string       `xml:"StorageClass"`
+	Owner        *BucketOwner `xml:"Owner,omitempty"`
+}
+
+type DeleteMarkerEntry struct {
+	Key          string       `xml:"Key"`
+	VersionId    string       `xml:"VersionId"`
+	IsLatest     bool         `xml:"IsLatest"`
+	LastModified string       `xml:"LastModified"`
+	Owner        *BucketOwner `xml:"Owner,omitempty"`
+}
+
 func NewParts(fsParts []*FSPart) []*Part {
 	parts := make([]*Part, 0)
 	for _, fsPart := range fsParts {
@@ -389,9 +442,29 @@ type ListXAttrsOutput struct {
 }
 
 type PartRequest struct {
-	XMLName    xml.Name `xml:"Part"`
-	PartNumber int      `xml:"PartNumber"`
-	ETag       string   `xml:"ETag"`
+	XMLName        xml.Name `xml:"Part"`
+	PartNumber     int      `xml:"PartNumber"`
+	ETag           string   `xml:"ETag"`
+	ChecksumCRC32  string   `xml:"ChecksumCRC32,omitempty"`
+	ChecksumCRC32C string   `xml:"ChecksumCRC32C,omitempty"`
+	ChecksumSHA1   string   `xml:"ChecksumSHA1,omitempty"`
+	ChecksumSHA256 string   `xml:"ChecksumSHA256,omitempty"`
+}
+
+// checksum returns the part checksum of the algorithm specified in the request.
+func (p *PartRequest) checksum(algorithm string) string {
+	switch algorithm {
+	case ChecksumAlgorithmCRC32:
+		return p.ChecksumCRC32
+	case ChecksumAlgorithmCRC32C:
+		return p.ChecksumCRC32C
+	case ChecksumAlgorithmSHA1:
+		return p.ChecksumSHA1
+	case ChecksumAlgorithmSHA256:
+		return p.ChecksumSHA256
+	default:
+		return ""
+	}
 }
 
 type CompleteMultipartUploadRequest struct {
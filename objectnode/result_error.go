@@ -91,6 +91,55 @@ var (
 	TooManyRequests                     = &ErrorCode{"TooManyRequests", "too many requests, please retry later", http.StatusTooManyRequests}
 	MalformedPOSTRequest                = &ErrorCode{ErrorCode: "MalformedPOSTRequest", ErrorMessage: "The body of your POST request is not well-formed multipart/form-data.", StatusCode: http.StatusBadRequest}
 	DuplicateVol                        = &ErrorCode{ErrorCode: "DuplicateVol", ErrorMessage: "Duplicate Vol", StatusCode: http.StatusBadRequest}
+	IllegalVersioningConfiguration      = &ErrorCode{ErrorCode: "IllegalVersioningConfigurationException", ErrorMessage: "The versioning configuration specified in the request is invalid.", StatusCode: http.StatusBadRequest}
+	NoSuchVersion                       = &ErrorCode{ErrorCode: "NoSuchVersion", ErrorMessage: "The specified version does not exist.", StatusCode: http.StatusNotFound}
+	InvalidVersionId                    = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Invalid version id specified", StatusCode: http.StatusBadRequest}
+	VersioningLockedByObjectLock        = &ErrorCode{ErrorCode: "InvalidBucketState", ErrorMessage: "An Object Lock configuration is present on this bucket, so the versioning state cannot be changed.", StatusCode: http.StatusConflict}
+	TooManyObjectVersions               = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The object has too many versions, delete the noncurrent versions before writing it.", StatusCode: http.StatusConflict}
+	InvalidRequestCopyDeleteMarker      = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The source of a copy request may not specifically refer to a delete marker by version id.", StatusCode: http.StatusBadRequest}
+	MissingObjectLockConfiguration      = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Bucket is missing Object Lock Configuration", StatusCode: http.StatusBadRequest}
+	MethodNotAllowed                    = &ErrorCode{ErrorCode: "MethodNotAllowed", ErrorMessage: "The specified method is not allowed against this resource.", StatusCode: http.StatusMethodNotAllowed}
+	NoSuchEncryptionConfiguration       = &ErrorCode{ErrorCode: "ServerSideEncryptionConfigurationNotFoundError", ErrorMessage: "The server side encryption configuration was not found.", StatusCode: http.StatusNotFound}
+	InvalidEncryptionAlgorithm          = &ErrorCode{ErrorCode: "InvalidEncryptionAlgorithmError", ErrorMessage: "The encryption request you specified is not valid. The valid value is AES256 or aws:kms.", StatusCode: http.StatusBadRequest}
+	InvalidSSECustomerAlgorithm         = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Requests specifying Server Side Encryption with Customer provided keys must provide a valid encryption algorithm.", StatusCode: http.StatusBadRequest}
+	InvalidSSECustomerKey               = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The secret key was invalid for the specified algorithm.", StatusCode: http.StatusBadRequest}
+	SSECustomerKeyMD5Mismatch           = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The calculated MD5 hash of the key did not match the hash that was provided.", StatusCode: http.StatusBadRequest}
+	SSECustomerKeyMismatch              = &ErrorCode{ErrorCode: "AccessDenied", ErrorMessage: "The provided customer key does not match the key used to encrypt the object.", StatusCode: http.StatusForbidden}
+	MissingSSECustomerKey               = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.", StatusCode: http.StatusBadRequest}
+	SSEParametersNotApplicable          = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The encryption parameters are not applicable to this object.", StatusCode: http.StatusBadRequest}
+	SSEMethodConflict                   = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Server Side Encryption with Customer provided key is incompatible with the encryption method specified.", StatusCode: http.StatusBadRequest}
+	KMSNotConfigured                    = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Server side encryption with the key management service is not configured.", StatusCode: http.StatusNotImplemented}
+	KMSKeyNotFound                      = &ErrorCode{ErrorCode: "KMS.NotFoundException", ErrorMessage: "The specified KMS key does not exist.", StatusCode: http.StatusBadRequest}
+	NoSuchReplicationConfiguration      = &ErrorCode{ErrorCode: "ReplicationConfigurationNotFoundError", ErrorMessage: "The replication configuration was not found.", StatusCode: http.StatusNotFound}
+	InvalidReplicationRule              = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The replication rule is invalid.", StatusCode: http.StatusBadRequest}
+	InvalidReplicationDestination       = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The destination bucket of the replication rule is not a configured remote target.", StatusCode: http.StatusBadRequest}
+	NotificationNotConfigured           = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "The event notification is not configured on the server.", StatusCode: http.StatusNotImplemented}
+	NoSuchPublicAccessBlock             = &ErrorCode{ErrorCode: "NoSuchPublicAccessBlockConfiguration", ErrorMessage: "The public access block configuration was not found.", StatusCode: http.StatusNotFound}
+	NoSuchWebsiteConfiguration          = &ErrorCode{ErrorCode: "NoSuchWebsiteConfiguration", ErrorMessage: "The specified bucket does not have a website configuration.", StatusCode: http.StatusNotFound}
+	ReplicationNotConfigured            = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "The replication is not configured on the server.", StatusCode: http.StatusNotImplemented}
+	InvalidObjectState                  = &ErrorCode{ErrorCode: "InvalidObjectState", ErrorMessage: "The operation is not valid for the current state of the object.", StatusCode: http.StatusForbidden}
+	RestoreAlreadyInProgress            = &ErrorCode{ErrorCode: "RestoreAlreadyInProgress", ErrorMessage: "Object restore is already in progress.", StatusCode: http.StatusConflict}
+	MissingSelectExpression             = &ErrorCode{ErrorCode: "MissingRequiredParameter", ErrorMessage: "The SelectRequest entity is missing a required parameter: Expression.", StatusCode: http.StatusBadRequest}
+	SelectExpressionTooLong             = &ErrorCode{ErrorCode: "ExpressionTooLong", ErrorMessage: "The SQL expression is too long: The maximum byte-length for the SQL expression is 256 KB.", StatusCode: http.StatusBadRequest}
+	InvalidExpressionType               = &ErrorCode{ErrorCode: "InvalidExpressionType", ErrorMessage: "The ExpressionType is invalid. Only SQL expressions are supported.", StatusCode: http.StatusBadRequest}
+	InvalidCompressionFormat            = &ErrorCode{ErrorCode: "InvalidCompressionFormat", ErrorMessage: "The file is not in a supported compression format. Only GZIP is supported.", StatusCode: http.StatusBadRequest}
+	InvalidChecksumAlgorithm            = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Checksum algorithm provided is unsupported. Please try again with any of the valid types: [CRC32, CRC32C, SHA1, SHA256].", StatusCode: http.StatusBadRequest}
+	InvalidChecksumValue                = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Value for the x-amz-checksum header is invalid.", StatusCode: http.StatusBadRequest}
+	MultipleChecksumHeaders             = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Expecting a single x-amz-checksum- header. Multiple checksum Types are not allowed.", StatusCode: http.StatusBadRequest}
+	ChecksumTypeMismatch                = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Checksum Type mismatch occurred, the checksum type should be the same as that of the multipart upload.", StatusCode: http.StatusBadRequest}
+	BadChecksum                         = &ErrorCode{ErrorCode: "BadDigest", ErrorMessage: "The checksum you specified did not match the calculated checksum.", StatusCode: http.StatusBadRequest}
+	MalformedTrailer                    = &ErrorCode{ErrorCode: "MalformedTrailerError", ErrorMessage: "The request contained trailing data that was not well-formed or did not conform to our published schema.", StatusCode: http.StatusBadRequest}
+	InvalidTargetBucketForLogging       = &ErrorCode{ErrorCode: "InvalidTargetBucketForLogging", ErrorMessage: "The target bucket for logging does not exist, is not owned by you, or does not have the appropriate grants for the log-delivery group.", StatusCode: http.StatusBadRequest}
+	AccessLoggingNotConfigured          = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "The server access logging is not configured on the server.", StatusCode: http.StatusNotImplemented}
+	ConditionalRequestConflict          = &ErrorCode{ErrorCode: "ConditionalRequestConflict", ErrorMessage: "A conflicting operation occurred. If using PutObject you can retry the request.", StatusCode: http.StatusConflict}
+	PositionNotEqualToLength            = &ErrorCode{ErrorCode: "PositionNotEqualToLength", ErrorMessage: "The position is not equal to the length of the object, or the object is being appended concurrently.", StatusCode: http.StatusConflict}
+	ObjectNotAppendable                 = &ErrorCode{ErrorCode: "ObjectNotAppendable", ErrorMessage: "The object is not appendable as it was not created by the append operation.", StatusCode: http.StatusConflict}
+	AppendNotSupported                  = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The append operation is not supported on the cold volume, or the bucket with versioning or encryption configured.", StatusCode: http.StatusBadRequest}
+	RenameNotSupported                  = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The rename operation is not supported on the bucket with versioning configured, or on the prefix of the bucket with object lock configured.", StatusCode: http.StatusBadRequest}
+	RenameTargetExists                  = &ErrorCode{ErrorCode: "RenameTargetExists", ErrorMessage: "The rename target already exists and can not be overwritten.", StatusCode: http.StatusConflict}
+	InvalidRenameSource                 = &ErrorCode{ErrorCode: "InvalidRenameSource", ErrorMessage: "The rename source must be a key or prefix in the same bucket and match the kind of the target.", StatusCode: http.StatusBadRequest}
+	InvalidIdentityToken                = &ErrorCode{ErrorCode: "InvalidIdentityToken", ErrorMessage: "The web identity token that was passed could not be validated.", StatusCode: http.StatusBadRequest}
+	IDPRejectedClaim                    = &ErrorCode{ErrorCode: "IDPRejectedClaim", ErrorMessage: "The claims of the web identity token are not mapped to any user or role.", StatusCode: http.StatusForbidden}
 )
 
 type ErrorCode struct {
@@ -120,6 +169,7 @@ func (ec *ErrorCode) ServeResponse(w http.ResponseWriter, r *http.Request) {
 	// traceMiddleWare send exception request to prometheus via status code
 	SetResponseStatusCode(r, strconv.Itoa(ec.StatusCode))
 	SetResponseErrorMessage(r, ec.ErrorMessage)
+	SetResponseErrorCode(r, ec.ErrorCode)
 
 	errorResponse := ErrorResponse{
 		Code:      ec.ErrorCode,
@@ -24,6 +24,18 @@ import (
 
 // register api routers
 func (o *ObjectNode) registerApiRouters(router *mux.Router) {
+	// The website endpoints are registered ahead of the api endpoints, since the website
+	// domains may be the subdomains of the api domains.
+	for _, d := range o.websiteDomains {
+		o.registerWebsiteRouters(router.Host("{bucket:.+}." + d).Subrouter())
+		o.registerWebsiteRouters(router.Host("{bucket:.+}." + d + ":{port:[0-9]+}").Subrouter())
+	}
+
+	// The batch operations endpoints of the S3 control api are registered ahead of the bucket
+	// endpoints, which are distinguished by the header of the account id.
+	jRouter := router.PathPrefix("/v20180820/jobs").Headers(XAmzAccountId, "").Subrouter()
+	o.registerBatchJobRouters(jRouter)
+
 	var bucketRouters []*mux.Router
 	bRouter := router.PathPrefix("/").Subrouter()
 	for _, d := range o.domains {
@@ -96,12 +108,11 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get object legal hold
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLegalHold.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetObjectLegalHoldAction)).
 			Methods(http.MethodGet).
 			Path("/{object:.+}").
 			Queries("legal-hold", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getObjectLegalHoldHandler)
 
 		// Get object retention
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectRetention.html
@@ -179,11 +190,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket encryption
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketEncryption.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketEncryptionAction)).
 			Methods(http.MethodGet).
 			Queries("encryption", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketEncryptionHandler)
 
 		// Get bucket cors
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketCors.html
@@ -195,19 +205,17 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket website
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketWebsite.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketWebsiteAction)).
 			Methods(http.MethodGet).
 			Queries("website", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketWebsiteHandler)
 
 		// Get public access block
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetPublicAccessBlock.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetPublicAccessBlockAction)).
 			Methods(http.MethodGet).
 			Queries("publicAccessBlock", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getPublicAccessBlockHandler)
 
 		// Get bucket request payment
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketRequestPayment.html
@@ -219,11 +227,24 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket replication
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketReplication.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketReplicationAction)).
 			Methods(http.MethodGet).
 			Queries("replication", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketReplicationHandler)
+
+		// Get bucket notification configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketNotificationConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketNotificationAction)).
+			Methods(http.MethodGet).
+			Queries("notification", "").
+			HandlerFunc(o.getBucketNotificationHandler)
+
+		// Get bucket logging
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLogging.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketLoggingAction)).
+			Methods(http.MethodGet).
+			Queries("logging", "").
+			HandlerFunc(o.getBucketLoggingHandler)
 
 		// Get bucket lifecycle
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLifecycle.html
@@ -240,21 +261,33 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("lifecycle", "").
 			HandlerFunc(o.getBucketLifecycleConfigurationHandler)
 
+		// Get bucket inventory configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketInventoryConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketInventoryConfigurationAction)).
+			Methods(http.MethodGet).
+			Queries("inventory", "", "id", "{id:.+}").
+			HandlerFunc(o.getBucketInventoryConfigurationHandler)
+
+		// List bucket inventory configurations
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListBucketInventoryConfigurations.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListBucketInventoryConfigurationsAction)).
+			Methods(http.MethodGet).
+			Queries("inventory", "").
+			HandlerFunc(o.listBucketInventoryConfigurationsHandler)
+
 		// Get bucket versioning
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketVersioning.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketVersioningAction)).
 			Methods(http.MethodGet).
 			Queries("versioning", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketVersioningHandler)
 
 		// List object versions
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListObjectVersionsAction)).
 			Methods(http.MethodGet).
 			Queries("versions", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.listObjectVersionsHandler)
 
 		// List objects version 1
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjects.html
@@ -280,14 +313,28 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("uploadId", "{uploadId:.*}").
 			HandlerFunc(o.completeMultipartUploadHandler)
 
+		// Append object
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSAppendObjectAction)).
+			Methods(http.MethodPost).
+			Path("/{object:.+}").
+			Queries(ParamAppend, "").
+			HandlerFunc(o.appendObjectHandler)
+
 		// Restore object
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_RestoreObject.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSRestoreObjectAction)).
 			Methods(http.MethodPost).
 			Path("/{object:.+}").
 			Queries("restore", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.restoreObjectHandler)
+
+		// Select object content
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSSelectObjectContentAction)).
+			Methods(http.MethodPost).
+			Path("/{object:.+}").
+			Queries("select", "", "select-type", "2").
+			HandlerFunc(o.selectObjectContentHandler)
 
 		// Delete objects (multiple objects)
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteObjects.html
@@ -328,6 +375,13 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}").
 			HandlerFunc(o.uploadPartHandler)
 
+		// Rename object
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSRenameObjectAction)).
+			Methods(http.MethodPut).
+			Path("/{object:.+}").
+			Queries(ParamRenameObject, "").
+			HandlerFunc(o.renameObjectHandler)
+
 		// Copy object
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_CopyObject.html .
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSCopyObjectAction)).
@@ -362,12 +416,11 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put object legal hold
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLegalHold.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutObjectLegalHoldAction)).
 			Methods(http.MethodPut).
 			Path("/{object:.+}").
 			Queries("legal-hold", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putObjectLegalHoldHandler)
 
 		// Put object retention
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectRetention.html
@@ -408,11 +461,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put bucket encryption
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketEncryption.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketEncryptionAction)).
 			Methods(http.MethodPut).
 			Queries("encryption", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketEncryptionHandler)
 
 		// Put bucket cors
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketCors.html
@@ -424,19 +476,17 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put bucket website
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketWebsite.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketWebsiteAction)).
 			Methods(http.MethodPut).
 			Queries("website", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketWebsiteHandler)
 
 		// Put public access block
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutPublicAccessBlock.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutPublicAccessBlockAction)).
 			Methods(http.MethodPut).
 			Queries("publicAccessBlock", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putPublicAccessBlockHandler)
 
 		// Put bucket request payment
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketRequestPayment.html
@@ -448,11 +498,24 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put bucket replication
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketReplication.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketReplicationAction)).
 			Methods(http.MethodPut).
 			Queries("replication", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketReplicationHandler)
+
+		// Put bucket notification configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketNotificationConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketNotificationAction)).
+			Methods(http.MethodPut).
+			Queries("notification", "").
+			HandlerFunc(o.putBucketNotificationHandler)
+
+		// Put bucket logging
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLogging.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketLoggingAction)).
+			Methods(http.MethodPut).
+			Queries("logging", "").
+			HandlerFunc(o.putBucketLoggingHandler)
 
 		// Put bucket lifecycle
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLifecycle.html
@@ -469,13 +532,19 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("lifecycle", "").
 			HandlerFunc(o.putBucketLifecycleConfigurationHandler)
 
+		// Put bucket inventory configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketInventoryConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketInventoryConfigurationAction)).
+			Methods(http.MethodPut).
+			Queries("inventory", "", "id", "{id:.+}").
+			HandlerFunc(o.putBucketInventoryConfigurationHandler)
+
 		// Put bucket versioning
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketVersioningAction)).
 			Methods(http.MethodPut).
 			Queries("versioning", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketVersioningHandler)
 
 		// Create bucket
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_CreateBucket.html
@@ -532,11 +601,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Delete bucket encryption
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketEncryption.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketEncryptionAction)).
 			Methods(http.MethodDelete).
 			Queries("encryption", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.deleteBucketEncryptionHandler)
 
 		// Delete bucket cors
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketCors.html
@@ -548,27 +616,24 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Delete bucket website
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketWebsite.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketWebsiteAction)).
 			Methods(http.MethodDelete).
 			Queries("website", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.deleteBucketWebsiteHandler)
 
 		// Delete public access block
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeletePublicAccessBlock.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeletePublicAccessBlockAction)).
 			Methods(http.MethodDelete).
 			Queries("publicAccessBlock", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.deletePublicAccessBlockHandler)
 
 		// Delete bucket replication
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketReplication.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketReplicationAction)).
 			Methods(http.MethodDelete).
 			Queries("replication", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.deleteBucketReplicationHandler)
 
 		// Delete bucket lifecycle
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketLifecycle.html
@@ -585,6 +650,13 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("lifecycle", "").
 			HandlerFunc(o.deleteBucketLifecycleConfigurationHandler)
 
+		// Delete bucket inventory configuration
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketInventoryConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketInventoryConfigurationAction)).
+			Methods(http.MethodDelete).
+			Queries("inventory", "", "id", "{id:.+}").
+			HandlerFunc(o.deleteBucketInventoryConfigurationHandler)
+
 		// Delete bucket
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucket.html
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketAction)).
@@ -615,6 +687,22 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 		Methods(http.MethodGet).
 		HandlerFunc(o.listBucketsHandler)
 
+	// Assume Role (STS)
+	// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRole.html
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSAssumeRoleAction)).
+		Methods(http.MethodPost).
+		Path("/").
+		MatcherFunc(stsActionMatcher(stsAssumeRoleAction)).
+		HandlerFunc(o.assumeRoleHandler)
+
+	// Assume Role With Web Identity (STS)
+	// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSAssumeRoleWithWebIdentityAction)).
+		Methods(http.MethodPost).
+		Path("/").
+		MatcherFunc(stsActionMatcher(stsAssumeRoleWithWebIdentityAction)).
+		HandlerFunc(o.assumeRoleWithWebIdentityHandler)
+
 	// Get Federation Token (STS)
 	// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_GetFederationToken.html
 	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetFederationTokenAction)).
@@ -625,3 +713,55 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 	// Unsupported operation
 	router.NotFoundHandler = http.HandlerFunc(o.unsupportedOperationHandler)
 }
+
+// register the routers of the static website endpoints
+func (o *ObjectNode) registerWebsiteRouters(r *mux.Router) {
+	// Get object by website endpoint
+	// API reference: https://docs.aws.amazon.com/AmazonS3/latest/userguide/WebsiteEndpoints.html
+	r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetObjectAction)).
+		Methods(http.MethodGet).
+		Path("/{object:.*}").
+		HandlerFunc(o.getObjectHandler)
+
+	// Head object by website endpoint
+	r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSHeadObjectAction)).
+		Methods(http.MethodHead).
+		Path("/{object:.*}").
+		HandlerFunc(o.headObjectHandler)
+
+	// The other requests are not allowed by the website endpoints, the route without
+	// action is denied by the trace middleware.
+	r.NewRoute().HandlerFunc(o.unsupportedOperationHandler)
+}
+
+// register the routers of the batch operations jobs
+func (o *ObjectNode) registerBatchJobRouters(r *mux.Router) {
+	// Create job
+	// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_CreateJob.html
+	r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSCreateJobAction)).
+		Methods(http.MethodPost).
+		Path("").
+		HandlerFunc(o.createJobHandler)
+
+	// List jobs
+	// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_ListJobs.html
+	r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListJobsAction)).
+		Methods(http.MethodGet).
+		Path("").
+		HandlerFunc(o.listJobsHandler)
+
+	// Describe job
+	// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_DescribeJob.html
+	r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDescribeJobAction)).
+		Methods(http.MethodGet).
+		Path("/{jobId}").
+		HandlerFunc(o.describeJobHandler)
+
+	// Update job status
+	// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_control_UpdateJobStatus.html
+	r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSUpdateJobStatusAction)).
+		Methods(http.MethodPost).
+		Path("/{jobId}/status").
+		Queries("requestedJobStatus", "{status}").
+		HandlerFunc(o.updateJobStatusHandler)
+}
@@ -22,6 +22,7 @@ package objectnode
 const (
 	UNSUPPORT_API              = "UnSupportAPI"
 	GET_FEDERATION_TOKEN       = "GetFederationToken"         // api:  POST /,  host=s3-cn-east-1.cs.com, create sts token
+	ASSUME_ROLE                = "AssumeRole"                 // api:  POST /,  host=s3-cn-east-1.cs.com, create sts token of the role
 	List_BUCKETS               = "ListBuckets"                // api:  GET / , host=s3-cn-east-1.cs.com, list all buckets
 	DELETE_BUCKET              = "DeleteBucket"               // api:  Delete /  , host=<bucket>.domain
 	DELETE_BUCKET_CORS         = "DeleteBucketCors"           // api:  Delete /?cors  , host=<bucket>.domain
@@ -45,7 +46,7 @@ const (
 	GET_BUCKET_METRICS         = "GetBucketMetrics"           // api:  Get /?metrics&id=<id>  , host=<bucket>.domain
 	GET_BUCKET_NOTIFICATION    = "GetBucketNotification"      // api:  Get /?notification  , host=<bucket>.domain
 	GET_BUCKET_POLICY_STATUS   = "GetBucketPolicyStatus"      // api:  Get /?policyStatus  , host=<bucket>.domain
-	GET_BUCKET_OBJECT_VERSIONS = "GetBucketObjectVersions"    // api:  Get /?versions  , host=<bucket>.domain
+	GET_BUCKET_OBJECT_VERSIONS = "ListObjectVersions"         // api:  Get /?versions  , host=<bucket>.domain
 	GET_BUCKET_POLICY          = "GetBucketPolicy"            // api:  Get /?policy  , host=<bucket>.domain
 	GET_BUCKET_REPLICATION     = "GetBucketReplication"       // api:  Get /?replication  , host=<bucket>.domain
 	GET_BUCKET_TAGGING         = "GetBucketTagging"           // api:  Get /?tagging  , host=<bucket>.domain
@@ -78,6 +79,9 @@ const (
 	GET_OBJECT_ACL             = "GetObjectAcl"               // api:  Get /<bucketname>/<objname>?acl   , host=<bucket>.domain
 	GET_OBJECT_TAGGING         = "GetObjectTagging"           // api:  Get /<bucketname>/<objname>?tagging   , host=<bucket>.domain
 	GET_OBJECT_RETENTION       = "GetObjectRetention"         // api:  Get /<bucketname>/<objname>?retention, host=<bucket>.domain
+	SELECT_OBJECT_CONTENT      = "SelectObjectContent"        // api:  POST /<objname>?select&select-type=2, host=<bucket>.domain
+	APPEND_OBJECT              = "AppendObject"               // api:  POST /<objname>?append&position=<Position>, host=<bucket>.domain
+	RENAME_OBJECT              = "RenameObject"               // api:  PUT /<objname>?renameObject, host=<bucket>.domain
 	HEAD_OBJECT                = "HeadObject"                 // api:  HEAD /<ObjectName> , host=<bucket>.domain
 	OPTIONS_OBJECT             = "OptionsObject"              // api:  OPTIONS /<ObjectName>, host=<bucket>.domain
 	POST_OBJECT                = "PostObject"                 // api:  Post /  , host=<bucket>.domain
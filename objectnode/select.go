@@ -0,0 +1,403 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+	"github.com/cubefs/cubefs/util/compressor"
+)
+
+const (
+	MaxSelectRequestSize    = 1 << 20   // 1MB
+	MaxSelectExpressionSize = 256 << 10 // 256KB
+
+	SelectExpressionTypeSQL = "SQL"
+
+	SelectCompressionNone = "NONE"
+	SelectCompressionGzip = "GZIP"
+
+	CSVFileHeaderUse    = "USE"
+	CSVFileHeaderIgnore = "IGNORE"
+	CSVFileHeaderNone   = "NONE"
+
+	CSVQuoteFieldsAlways   = "ALWAYS"
+	CSVQuoteFieldsAsNeeded = "ASNEEDED"
+
+	JSONTypeDocument = "DOCUMENT"
+	JSONTypeLines    = "LINES"
+
+	// the records are sent in the messages of the size
+	selectRecordsMessageSize = 128 << 10 // 128KB
+)
+
+type CSVInput struct {
+	AllowQuotedRecordDelimiter bool   `xml:"AllowQuotedRecordDelimiter,omitempty"`
+	Comments                   string `xml:"Comments,omitempty"`
+	FieldDelimiter             string `xml:"FieldDelimiter,omitempty"`
+	FileHeaderInfo             string `xml:"FileHeaderInfo,omitempty"`
+	QuoteCharacter             string `xml:"QuoteCharacter,omitempty"`
+	QuoteEscapeCharacter       string `xml:"QuoteEscapeCharacter,omitempty"`
+	RecordDelimiter            string `xml:"RecordDelimiter,omitempty"`
+}
+
+type JSONInput struct {
+	Type string `xml:"Type,omitempty"`
+}
+
+type ParquetInput struct{}
+
+type InputSerialization struct {
+	CompressionType string        `xml:"CompressionType,omitempty"`
+	CSV             *CSVInput     `xml:"CSV,omitempty"`
+	JSON            *JSONInput    `xml:"JSON,omitempty"`
+	Parquet         *ParquetInput `xml:"Parquet,omitempty"`
+}
+
+type CSVOutput struct {
+	FieldDelimiter       string `xml:"FieldDelimiter,omitempty"`
+	QuoteCharacter       string `xml:"QuoteCharacter,omitempty"`
+	QuoteEscapeCharacter string `xml:"QuoteEscapeCharacter,omitempty"`
+	QuoteFields          string `xml:"QuoteFields,omitempty"`
+	RecordDelimiter      string `xml:"RecordDelimiter,omitempty"`
+}
+
+type JSONOutput struct {
+	RecordDelimiter string `xml:"RecordDelimiter,omitempty"`
+}
+
+type OutputSerialization struct {
+	CSV  *CSVOutput  `xml:"CSV,omitempty"`
+	JSON *JSONOutput `xml:"JSON,omitempty"`
+}
+
+type RequestProgress struct {
+	Enabled bool `xml:"Enabled"`
+}
+
+type ScanRange struct {
+	Start *int64 `xml:"Start,omitempty"`
+	End   *int64 `xml:"End,omitempty"`
+}
+
+type SelectObjectContentRequest struct {
+	XMLNS               string               `xml:"xmlns,attr,omitempty"`
+	XMLName             xml.Name             `xml:"SelectObjectContentRequest"`
+	Expression          string               `xml:"Expression"`
+	ExpressionType      string               `xml:"ExpressionType"`
+	RequestProgress     *RequestProgress     `xml:"RequestProgress,omitempty"`
+	InputSerialization  *InputSerialization  `xml:"InputSerialization"`
+	OutputSerialization *OutputSerialization `xml:"OutputSerialization"`
+	ScanRange           *ScanRange           `xml:"ScanRange,omitempty"`
+}
+
+func ParseSelectObjectContentRequest(data []byte) (*SelectObjectContentRequest, *ErrorCode) {
+	req := &SelectObjectContentRequest{}
+	if err := xml.Unmarshal(data, req); err != nil {
+		return nil, MalformedXML
+	}
+	if errCode := req.validate(); errCode != nil {
+		return nil, errCode
+	}
+	return req, nil
+}
+
+func invalidSelectRequest(message string) *ErrorCode {
+	return NewError("InvalidRequestParameter", message, http.StatusBadRequest)
+}
+
+func (req *SelectObjectContentRequest) validate() *ErrorCode {
+	if req.Expression == "" {
+		return MissingSelectExpression
+	}
+	if len(req.Expression) > MaxSelectExpressionSize {
+		return SelectExpressionTooLong
+	}
+	if !strings.EqualFold(req.ExpressionType, SelectExpressionTypeSQL) {
+		return InvalidExpressionType
+	}
+	if req.ScanRange != nil {
+		return UnsupportedOperation
+	}
+
+	input := req.InputSerialization
+	if input == nil {
+		return invalidSelectRequest("The InputSerialization is missing.")
+	}
+	switch strings.ToUpper(input.CompressionType) {
+	case "", SelectCompressionNone, SelectCompressionGzip:
+	default:
+		return InvalidCompressionFormat
+	}
+	if input.Parquet != nil {
+		return UnsupportedOperation
+	}
+	if (input.CSV == nil) == (input.JSON == nil) {
+		return invalidSelectRequest("Exactly one of CSV and JSON must be specified in the InputSerialization.")
+	}
+	if csvInput := input.CSV; csvInput != nil {
+		switch strings.ToUpper(csvInput.FileHeaderInfo) {
+		case "", CSVFileHeaderUse, CSVFileHeaderIgnore, CSVFileHeaderNone:
+		default:
+			return invalidSelectRequest("The FileHeaderInfo is invalid. Only NONE, USE, and IGNORE are supported.")
+		}
+		if len([]rune(csvInput.FieldDelimiter)) > 1 || len([]rune(csvInput.Comments)) > 1 {
+			return invalidSelectRequest("The FieldDelimiter and Comments must be a single character.")
+		}
+		// the standard quotes and record delimiters are supported by the CSV reader
+		if csvInput.QuoteCharacter != "" && csvInput.QuoteCharacter != `"` ||
+			csvInput.QuoteEscapeCharacter != "" && csvInput.QuoteEscapeCharacter != `"` {
+			return invalidSelectRequest("Only the double quote is supported as the QuoteCharacter and QuoteEscapeCharacter.")
+		}
+		if rd := csvInput.RecordDelimiter; rd != "" && rd != "\n" && rd != "\r\n" {
+			return invalidSelectRequest("Only the newline is supported as the RecordDelimiter.")
+		}
+	}
+	if jsonInput := input.JSON; jsonInput != nil {
+		switch strings.ToUpper(jsonInput.Type) {
+		case "", JSONTypeDocument, JSONTypeLines:
+		default:
+			return invalidSelectRequest("The JSON Type is invalid. Only DOCUMENT and LINES are supported.")
+		}
+	}
+
+	output := req.OutputSerialization
+	if output == nil || (output.CSV == nil) == (output.JSON == nil) {
+		return invalidSelectRequest("Exactly one of CSV and JSON must be specified in the OutputSerialization.")
+	}
+	if csvOutput := output.CSV; csvOutput != nil {
+		switch strings.ToUpper(csvOutput.QuoteFields) {
+		case "", CSVQuoteFieldsAlways, CSVQuoteFieldsAsNeeded:
+		default:
+			return invalidSelectRequest("The QuoteFields is invalid. Only ALWAYS and ASNEEDED are supported.")
+		}
+	}
+	return nil
+}
+
+// selectStats is the statistics of the request, the bytes are counted by the readers.
+type selectStats struct {
+	BytesScanned   int64 `xml:"BytesScanned"`
+	BytesProcessed int64 `xml:"BytesProcessed"`
+	BytesReturned  int64 `xml:"BytesReturned"`
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  *int64
+	err    error // the error of the underlying reader
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	*r.count += int64(n)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return
+}
+
+// selectEventWriter writes the messages of the response in the event stream encoding.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/RESTSelectObjectAppendix.html
+type selectEventWriter struct {
+	encoder *eventstream.Encoder
+	writer  io.Writer
+}
+
+func newSelectEventWriter(w io.Writer) *selectEventWriter {
+	return &selectEventWriter{encoder: eventstream.NewEncoder(w), writer: w}
+}
+
+func (w *selectEventWriter) writeEvent(eventType, contentType string, payload []byte) error {
+	msg := eventstream.Message{Payload: payload}
+	msg.Headers.Set(":message-type", eventstream.StringValue("event"))
+	msg.Headers.Set(":event-type", eventstream.StringValue(eventType))
+	if contentType != "" {
+		msg.Headers.Set(":content-type", eventstream.StringValue(contentType))
+	}
+	return w.encode(msg)
+}
+
+func (w *selectEventWriter) writeError(code, message string) error {
+	msg := eventstream.Message{}
+	msg.Headers.Set(":message-type", eventstream.StringValue("error"))
+	msg.Headers.Set(":error-code", eventstream.StringValue(code))
+	msg.Headers.Set(":error-message", eventstream.StringValue(message))
+	return w.encode(msg)
+}
+
+func (w *selectEventWriter) encode(msg eventstream.Message) error {
+	if err := w.encoder.Encode(msg); err != nil {
+		return err
+	}
+	if flusher, ok := w.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func (w *selectEventWriter) writeRecords(records []byte) error {
+	return w.writeEvent("Records", "application/octet-stream", records)
+}
+
+func (w *selectEventWriter) writeStats(name string, stats *selectStats) error {
+	payload, err := xml.Marshal(struct {
+		XMLName xml.Name
+		*selectStats
+	}{XMLName: xml.Name{Local: name}, selectStats: stats})
+	if err != nil {
+		return err
+	}
+	return w.writeEvent(name, ValueContentTypeXML, payload)
+}
+
+func (w *selectEventWriter) writeEnd() error {
+	return w.writeEvent("End", "", nil)
+}
+
+// selectError is the error occurred while the records are processed, which is sent in the
+// error message since the response header has been written.
+type selectError struct {
+	code string
+	err  error
+}
+
+func (e *selectError) Error() string {
+	return e.code + ": " + e.err.Error()
+}
+
+// selectObject runs the query on the object data read from the reader, and writes the result
+// in the event stream. The reader is not read any more once the limit of the query is reached.
+func selectObject(r io.Reader, req *SelectObjectContentRequest, query *sqlQuery, w *selectEventWriter) (err error) {
+	stats := &selectStats{}
+	input := req.InputSerialization
+	encoding := ""
+	if strings.EqualFold(input.CompressionType, SelectCompressionGzip) {
+		encoding = compressor.EncodingGzip
+	}
+	scanned := &countingReader{reader: r, count: &stats.BytesScanned}
+	dr, err := compressor.New(encoding).NewReader(scanned)
+	if scanned.err != nil {
+		return scanned.err
+	}
+	if err != nil {
+		return &selectError{code: "InvalidCompressionFormat", err: err}
+	}
+	defer dr.Close()
+	data := &countingReader{reader: dr, count: &stats.BytesProcessed}
+
+	var reader selectRecordReader
+	parseErrorCode := "JSONParsingError"
+	if input.CSV != nil {
+		parseErrorCode = "CSVParsingError"
+		if reader, err = newCSVRecordReader(data, input.CSV); err != nil {
+			if scanned.err != nil {
+				return scanned.err
+			}
+			return &selectError{code: parseErrorCode, err: err}
+		}
+	} else {
+		reader = newJSONRecordReader(data)
+	}
+	var writer selectRecordWriter
+	if output := req.OutputSerialization; output.CSV != nil {
+		writer = newCSVRecordWriter(output.CSV)
+	} else {
+		writer = newJSONRecordWriter(output.JSON)
+	}
+	progress := req.RequestProgress != nil && req.RequestProgress.Enabled
+
+	buf := new(bytes.Buffer)
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		stats.BytesReturned += int64(buf.Len())
+		if err := w.writeRecords(buf.Bytes()); err != nil {
+			return err
+		}
+		buf.Reset()
+		if progress {
+			return w.writeStats("Progress", stats)
+		}
+		return nil
+	}
+
+	var returned int64
+	for query.limit < 0 || returned < query.limit || len(query.aggregates) > 0 {
+		var rec selectRecord
+		if rec, err = reader.Read(); err == io.EOF {
+			break
+		}
+		if scanned.err != nil {
+			return scanned.err
+		}
+		if err != nil {
+			return &selectError{code: parseErrorCode, err: err}
+		}
+		var matched bool
+		if matched, err = query.match(rec); err != nil {
+			return &selectError{code: "EvaluatorInvalidArguments", err: err}
+		}
+		if !matched {
+			continue
+		}
+		if len(query.aggregates) > 0 {
+			for _, agg := range query.aggregates {
+				if err = agg.accumulate(rec); err != nil {
+					return &selectError{code: "EvaluatorInvalidArguments", err: err}
+				}
+			}
+			continue
+		}
+		var columns []selectColumn
+		if columns, err = query.project(rec); err != nil {
+			return &selectError{code: "EvaluatorInvalidArguments", err: err}
+		}
+		if err = writer.Write(buf, columns); err != nil {
+			return &selectError{code: "EvaluatorInvalidArguments", err: err}
+		}
+		returned++
+		if buf.Len() >= selectRecordsMessageSize {
+			if err = flush(); err != nil {
+				return
+			}
+		}
+	}
+	if len(query.aggregates) > 0 {
+		var columns []selectColumn
+		// the columns out of the aggregate functions are missing
+		if columns, err = query.project(&csvRecord{}); err != nil {
+			return &selectError{code: "EvaluatorInvalidArguments", err: err}
+		}
+		if err = writer.Write(buf, columns); err != nil {
+			return &selectError{code: "EvaluatorInvalidArguments", err: err}
+		}
+	}
+	if err = flush(); err != nil {
+		return
+	}
+	if err = w.writeStats("Stats", stats); err != nil {
+		return
+	}
+	return w.writeEnd()
+}
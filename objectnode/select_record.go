@@ -0,0 +1,339 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type selectRecordReader interface {
+	// Read returns the next record, io.EOF is returned at the end of the input.
+	Read() (selectRecord, error)
+}
+
+type csvRecord struct {
+	header []string
+	fields []string
+}
+
+func (r *csvRecord) get(path []sqlPathElem) (interface{}, bool) {
+	if len(path) != 1 || path[0].name == "" {
+		return nil, false
+	}
+	name := path[0].name
+	index := -1
+	for i, h := range r.header {
+		if h == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 && !path[0].quoted {
+		for i, h := range r.header {
+			if strings.EqualFold(h, name) {
+				index = i
+				break
+			}
+		}
+	}
+	if index >= 0 {
+		if index < len(r.fields) {
+			return r.fields[index], true
+		}
+		return nil, false
+	}
+	// the column is referred by the position, e.g. _1
+	if strings.HasPrefix(name, "_") {
+		if n, err := strconv.Atoi(name[1:]); err == nil && n >= 1 && n <= len(r.fields) {
+			return r.fields[n-1], true
+		}
+	}
+	return nil, false
+}
+
+func (r *csvRecord) columns() []selectColumn {
+	columns := make([]selectColumn, len(r.fields))
+	for i, field := range r.fields {
+		columns[i].value = field
+		if i < len(r.header) {
+			columns[i].name = r.header[i]
+		} else {
+			columns[i].name = "_" + strconv.Itoa(i+1)
+		}
+	}
+	return columns
+}
+
+type csvRecordReader struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCSVRecordReader(r io.Reader, input *CSVInput) (*csvRecordReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if input.FieldDelimiter != "" {
+		reader.Comma = []rune(input.FieldDelimiter)[0]
+	}
+	if input.Comments != "" {
+		reader.Comment = []rune(input.Comments)[0]
+	}
+	rr := &csvRecordReader{reader: reader}
+	switch strings.ToUpper(input.FileHeaderInfo) {
+	case CSVFileHeaderUse, CSVFileHeaderIgnore:
+		header, err := reader.Read()
+		if err == io.EOF {
+			return rr, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(input.FileHeaderInfo, CSVFileHeaderUse) {
+			rr.header = header
+		}
+	}
+	return rr, nil
+}
+
+func (rr *csvRecordReader) Read() (selectRecord, error) {
+	fields, err := rr.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	return &csvRecord{header: rr.header, fields: fields}, nil
+}
+
+type jsonRecord struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (r *jsonRecord) get(path []sqlPathElem) (interface{}, bool) {
+	var v interface{} = r.values
+	for _, elem := range path {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			if elem.name == "" {
+				return nil, false
+			}
+			value, ok := t[elem.name]
+			if !ok && !elem.quoted {
+				for k, kv := range t {
+					if strings.EqualFold(k, elem.name) {
+						value, ok = kv, true
+						break
+					}
+				}
+			}
+			if !ok {
+				return nil, false
+			}
+			v = value
+		case []interface{}:
+			if elem.name != "" || elem.index >= len(t) {
+				return nil, false
+			}
+			v = t[elem.index]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func (r *jsonRecord) columns() []selectColumn {
+	columns := make([]selectColumn, len(r.keys))
+	for i, k := range r.keys {
+		columns[i] = selectColumn{name: k, value: r.values[k]}
+	}
+	return columns
+}
+
+// jsonRecordReader reads the JSON objects in sequence, which are either separated by the
+// newlines or concatenated in one document.
+type jsonRecordReader struct {
+	decoder *json.Decoder
+}
+
+func newJSONRecordReader(r io.Reader) *jsonRecordReader {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return &jsonRecordReader{decoder: decoder}
+}
+
+func (rr *jsonRecordReader) Read() (selectRecord, error) {
+	tok, err := rr.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("the JSON record is not an object: %v", tok)
+	}
+	rec := &jsonRecord{values: make(map[string]interface{})}
+	for rr.decoder.More() {
+		if tok, err = rr.decoder.Token(); err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid key of the JSON object: %v", tok)
+		}
+		var value interface{}
+		if err = rr.decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		if _, ok = rec.values[key]; !ok {
+			rec.keys = append(rec.keys, key)
+		}
+		rec.values[key] = normalizeJSONValue(value)
+	}
+	// the closing brace
+	if _, err = rr.decoder.Token(); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// normalizeJSONValue converts the JSON numbers to int64 or float64.
+func normalizeJSONValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	case map[string]interface{}:
+		for k, kv := range t {
+			t[k] = normalizeJSONValue(kv)
+		}
+	case []interface{}:
+		for i, iv := range t {
+			t[i] = normalizeJSONValue(iv)
+		}
+	}
+	return v
+}
+
+type selectRecordWriter interface {
+	Write(buf *bytes.Buffer, columns []selectColumn) error
+}
+
+type csvRecordWriter struct {
+	fieldDelimiter  string
+	recordDelimiter string
+	quote           string
+	quoteEscape     string
+	alwaysQuote     bool
+}
+
+func newCSVRecordWriter(output *CSVOutput) *csvRecordWriter {
+	w := &csvRecordWriter{
+		fieldDelimiter:  output.FieldDelimiter,
+		recordDelimiter: output.RecordDelimiter,
+		quote:           output.QuoteCharacter,
+		quoteEscape:     output.QuoteEscapeCharacter,
+		alwaysQuote:     strings.EqualFold(output.QuoteFields, CSVQuoteFieldsAlways),
+	}
+	if w.fieldDelimiter == "" {
+		w.fieldDelimiter = ","
+	}
+	if w.recordDelimiter == "" {
+		w.recordDelimiter = "\n"
+	}
+	if w.quote == "" {
+		w.quote = `"`
+	}
+	if w.quoteEscape == "" {
+		w.quoteEscape = w.quote
+	}
+	return w
+}
+
+func (w *csvRecordWriter) Write(buf *bytes.Buffer, columns []selectColumn) error {
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteString(w.fieldDelimiter)
+		}
+		field, err := formatSelectValue(column.value)
+		if err != nil {
+			return err
+		}
+		if w.alwaysQuote || strings.Contains(field, w.fieldDelimiter) || strings.Contains(field, w.quote) ||
+			strings.ContainsAny(field, "\r\n") || strings.Contains(field, w.recordDelimiter) {
+			field = w.quote + strings.ReplaceAll(field, w.quote, w.quoteEscape+w.quote) + w.quote
+		}
+		buf.WriteString(field)
+	}
+	buf.WriteString(w.recordDelimiter)
+	return nil
+}
+
+type jsonRecordWriter struct {
+	recordDelimiter string
+}
+
+func newJSONRecordWriter(output *JSONOutput) *jsonRecordWriter {
+	w := &jsonRecordWriter{recordDelimiter: output.RecordDelimiter}
+	if w.recordDelimiter == "" {
+		w.recordDelimiter = "\n"
+	}
+	return w
+}
+
+func (w *jsonRecordWriter) Write(buf *bytes.Buffer, columns []selectColumn) error {
+	buf.WriteByte('{')
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(column.name)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(column.value)
+		if err != nil {
+			return err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	buf.WriteString(w.recordDelimiter)
+	return nil
+}
+
+func formatSelectValue(v interface{}) (string, error) {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", errors.New("cannot format the value: " + err.Error())
+		}
+		return string(data), nil
+	}
+	return toSQLString(v), nil
+}
@@ -0,0 +1,1288 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// The SQL subset of S3 Select:
+//
+//	SELECT * | expr [[AS] alias], ... FROM S3Object [[AS] alias] [WHERE expr] [LIMIT n]
+//
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/s3-select-sql-reference.html
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type sqlTokenKind int
+
+const (
+	sqlTokenEOF sqlTokenKind = iota
+	sqlTokenIdent
+	sqlTokenQuotedIdent
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenOperator
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+	pos  int
+}
+
+var sqlOperators = []string{"<=", ">=", "<>", "!=", "||", "=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ",", ".", "[", "]"}
+
+func lexSQL(sql string) (tokens []sqlToken, err error) {
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"':
+			start := i
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated quote at position %d", start)
+				}
+				if runes[i] == c {
+					if i+1 < len(runes) && runes[i+1] == c {
+						sb.WriteRune(c)
+						i++
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+			}
+			kind := sqlTokenString
+			if c == '"' {
+				kind = sqlTokenQuotedIdent
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: sb.String(), pos: start})
+		case unicode.IsDigit(c) || c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				i++
+				if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+					i++
+				}
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenNumber, text: string(runes[start:i]), pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenIdent, text: string(runes[start:i]), pos: start})
+		default:
+			var matched string
+			for _, op := range sqlOperators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					matched = op
+					break
+				}
+			}
+			if matched == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenOperator, text: matched, pos: i})
+			i += len([]rune(matched))
+		}
+	}
+	tokens = append(tokens, sqlToken{kind: sqlTokenEOF, pos: len(runes)})
+	return
+}
+
+// selectRecord is a record of the input data, the value of the column is looked up by the path.
+type selectRecord interface {
+	get(path []sqlPathElem) (interface{}, bool)
+	columns() []selectColumn
+}
+
+type selectColumn struct {
+	name  string
+	value interface{}
+}
+
+type sqlPathElem struct {
+	name   string
+	quoted bool // the quoted name is case-sensitive
+	index  int  // index of the array element if name is empty
+}
+
+type sqlExpr interface {
+	eval(rec selectRecord) (interface{}, error)
+}
+
+type sqlLiteral struct {
+	value interface{}
+}
+
+func (e *sqlLiteral) eval(selectRecord) (interface{}, error) {
+	return e.value, nil
+}
+
+type sqlColumn struct {
+	path []sqlPathElem
+}
+
+func (e *sqlColumn) eval(rec selectRecord) (interface{}, error) {
+	v, _ := rec.get(e.path)
+	return v, nil
+}
+
+type sqlUnary struct {
+	op string
+	x  sqlExpr
+}
+
+func (e *sqlUnary) eval(rec selectRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	switch e.op {
+	case "NOT":
+		b, err := toSQLBool(v)
+		if err != nil {
+			return nil, err
+		}
+		return !b, nil
+	case "-":
+		return sqlArithmetic("-", int64(0), v)
+	default:
+		return toSQLNumber(v)
+	}
+}
+
+type sqlBinary struct {
+	op   string
+	l, r sqlExpr
+}
+
+func (e *sqlBinary) eval(rec selectRecord) (interface{}, error) {
+	l, err := e.l.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "AND", "OR":
+		return e.evalLogical(rec, l)
+	}
+	r, err := e.r.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	switch e.op {
+	case "||":
+		return toSQLString(l) + toSQLString(r), nil
+	case "+", "-", "*", "/", "%":
+		return sqlArithmetic(e.op, l, r)
+	}
+	cmp, ok := compareSQLValues(l, r)
+	if !ok {
+		return false, nil
+	}
+	switch e.op {
+	case "=":
+		return cmp == 0, nil
+	case "!=", "<>":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return nil, fmt.Errorf("unsupported operator %v", e.op)
+}
+
+// evalLogical evaluates AND and OR with the three-valued logic of SQL.
+func (e *sqlBinary) evalLogical(rec selectRecord, lv interface{}) (interface{}, error) {
+	short := e.op == "OR"
+	var l *bool
+	if lv != nil {
+		b, err := toSQLBool(lv)
+		if err != nil {
+			return nil, err
+		}
+		if b == short {
+			return short, nil
+		}
+		l = &b
+	}
+	rv, err := e.r.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	if rv == nil {
+		return nil, nil
+	}
+	r, err := toSQLBool(rv)
+	if err != nil {
+		return nil, err
+	}
+	if r == short {
+		return short, nil
+	}
+	if l == nil {
+		return nil, nil
+	}
+	return !short, nil
+}
+
+type sqlLike struct {
+	x, pattern, escape sqlExpr
+	re                 *regexp.Regexp // compiled if the pattern is a literal
+	not                bool
+}
+
+func (e *sqlLike) eval(rec selectRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	re := e.re
+	if re == nil {
+		if re, err = e.compile(rec); err != nil || re == nil {
+			return nil, err
+		}
+	}
+	return re.MatchString(toSQLString(v)) != e.not, nil
+}
+
+func (e *sqlLike) compile(rec selectRecord) (*regexp.Regexp, error) {
+	p, err := e.pattern.eval(rec)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	var escape rune
+	if e.escape != nil {
+		ev, err := e.escape.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		if es := []rune(toSQLString(ev)); len(es) == 1 {
+			escape = es[0]
+		} else {
+			return nil, errors.New("the escape of LIKE must be a single character")
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	runes := []rune(toSQLString(p))
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case escape != 0 && c == escape && i+1 < len(runes):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case c == '%':
+			sb.WriteString(".*")
+		case c == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+type sqlIsNull struct {
+	x   sqlExpr
+	not bool
+}
+
+func (e *sqlIsNull) eval(rec selectRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	return (v == nil) != e.not, nil
+}
+
+type sqlIn struct {
+	x    sqlExpr
+	list []sqlExpr
+	not  bool
+}
+
+func (e *sqlIn) eval(rec selectRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	for _, item := range e.list {
+		iv, err := item.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		if cmp, ok := compareSQLValues(v, iv); ok && cmp == 0 {
+			return !e.not, nil
+		}
+	}
+	return e.not, nil
+}
+
+type sqlBetween struct {
+	x, lower, upper sqlExpr
+	not             bool
+}
+
+func (e *sqlBetween) eval(rec selectRecord) (interface{}, error) {
+	var values [3]interface{}
+	for i, x := range []sqlExpr{e.x, e.lower, e.upper} {
+		v, err := x.eval(rec)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	lc, ok1 := compareSQLValues(values[0], values[1])
+	uc, ok2 := compareSQLValues(values[0], values[2])
+	return (ok1 && ok2 && lc >= 0 && uc <= 0) != e.not, nil
+}
+
+type sqlCast struct {
+	x   sqlExpr
+	typ string
+}
+
+func (e *sqlCast) eval(rec selectRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	switch e.typ {
+	case "INT", "INTEGER":
+		n, err := toSQLNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := n.(float64); ok {
+			return int64(f), nil
+		}
+		return n, nil
+	case "FLOAT", "DECIMAL", "NUMERIC":
+		n, err := toSQLNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := n.(int64); ok {
+			return float64(i), nil
+		}
+		return n, nil
+	case "BOOL", "BOOLEAN":
+		return toSQLBool(v)
+	default:
+		return toSQLString(v), nil
+	}
+}
+
+var sqlCastTypes = map[string]bool{
+	"INT": true, "INTEGER": true, "FLOAT": true, "DECIMAL": true, "NUMERIC": true,
+	"STRING": true, "VARCHAR": true, "CHAR": true, "BOOL": true, "BOOLEAN": true,
+}
+
+type sqlFunc struct {
+	name string
+	args []sqlExpr
+}
+
+var sqlFuncArgs = map[string][2]int{
+	"LOWER":            {1, 1},
+	"UPPER":            {1, 1},
+	"TRIM":             {1, 1},
+	"CHAR_LENGTH":      {1, 1},
+	"CHARACTER_LENGTH": {1, 1},
+	"SUBSTRING":        {2, 3},
+	"COALESCE":         {1, -1},
+}
+
+func (e *sqlFunc) eval(rec selectRecord) (interface{}, error) {
+	args := make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		v, err := arg.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	if e.name == "COALESCE" {
+		for _, v := range args {
+			if v != nil {
+				return v, nil
+			}
+		}
+		return nil, nil
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	s := toSQLString(args[0])
+	switch e.name {
+	case "LOWER":
+		return strings.ToLower(s), nil
+	case "UPPER":
+		return strings.ToUpper(s), nil
+	case "TRIM":
+		return strings.TrimSpace(s), nil
+	case "CHAR_LENGTH", "CHARACTER_LENGTH":
+		return int64(len([]rune(s))), nil
+	case "SUBSTRING":
+		runes := []rune(s)
+		start, err := toSQLInt(args[1])
+		if err != nil {
+			return nil, err
+		}
+		end := int64(len(runes)) + 1
+		if len(args) == 3 {
+			length, err := toSQLInt(args[2])
+			if err != nil {
+				return nil, err
+			}
+			if length < 0 {
+				return nil, errors.New("the length of SUBSTRING must not be negative")
+			}
+			end = start + length
+		}
+		// the position is 1-based
+		if start < 1 {
+			start = 1
+		}
+		if end > int64(len(runes))+1 {
+			end = int64(len(runes)) + 1
+		}
+		if start >= end {
+			return "", nil
+		}
+		return string(runes[start-1 : end-1]), nil
+	}
+	return nil, fmt.Errorf("unsupported function %v", e.name)
+}
+
+// sqlAggregate accumulates the values of all the records, the result is evaluated after the
+// input is exhausted.
+type sqlAggregate struct {
+	name  string
+	arg   sqlExpr // nil for COUNT(*)
+	count int64
+	value interface{}
+}
+
+func (e *sqlAggregate) eval(selectRecord) (interface{}, error) {
+	switch e.name {
+	case "COUNT":
+		return e.count, nil
+	case "AVG":
+		if e.count == 0 {
+			return nil, nil
+		}
+		n, err := toSQLNumber(e.value)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := n.(int64); ok {
+			return float64(i) / float64(e.count), nil
+		}
+		return n.(float64) / float64(e.count), nil
+	}
+	return e.value, nil
+}
+
+func (e *sqlAggregate) accumulate(rec selectRecord) (err error) {
+	if e.arg == nil {
+		e.count++
+		return nil
+	}
+	v, err := e.arg.eval(rec)
+	if err != nil || v == nil {
+		return err
+	}
+	e.count++
+	switch e.name {
+	case "SUM", "AVG":
+		if e.value == nil {
+			e.value, err = toSQLNumber(v)
+		} else {
+			e.value, err = sqlArithmetic("+", e.value, v)
+		}
+	case "MIN", "MAX":
+		if e.value == nil {
+			e.value = v
+			return nil
+		}
+		cmp, ok := compareSQLValues(v, e.value)
+		if !ok {
+			return fmt.Errorf("cannot compare %v with %v", v, e.value)
+		}
+		if e.name == "MIN" && cmp < 0 || e.name == "MAX" && cmp > 0 {
+			e.value = v
+		}
+	}
+	return
+}
+
+var sqlAggregateFuncs = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+
+func toSQLString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func toSQLNumber(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case int64, float64:
+		return t, nil
+	case string:
+		s := strings.TrimSpace(t)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %v to a number", v)
+}
+
+func toSQLInt(v interface{}) (int64, error) {
+	n, err := toSQLNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	if f, ok := n.(float64); ok {
+		return int64(f), nil
+	}
+	return n.(int64), nil
+}
+
+func toSQLBool(v interface{}) (bool, error) {
+	switch t := v.(type) {
+	case bool:
+		return t, nil
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(t)); err == nil {
+			return b, nil
+		}
+	}
+	return false, fmt.Errorf("cannot convert %v to a boolean", v)
+}
+
+func sqlArithmetic(op string, l, r interface{}) (interface{}, error) {
+	ln, err := toSQLNumber(l)
+	if err != nil {
+		return nil, err
+	}
+	rn, err := toSQLNumber(r)
+	if err != nil {
+		return nil, err
+	}
+	li, lok := ln.(int64)
+	ri, rok := rn.(int64)
+	if lok && rok {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		}
+		if ri == 0 {
+			return nil, errors.New("division by zero")
+		}
+		if op == "/" {
+			return li / ri, nil
+		}
+		return li % ri, nil
+	}
+	lf, rf := toSQLFloat(ln), toSQLFloat(rn)
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	}
+	if rf == 0 {
+		return nil, errors.New("division by zero")
+	}
+	if op == "/" {
+		return lf / rf, nil
+	}
+	return math.Mod(lf, rf), nil
+}
+
+func toSQLFloat(n interface{}) float64 {
+	if i, ok := n.(int64); ok {
+		return float64(i)
+	}
+	return n.(float64)
+}
+
+// compareSQLValues compares the values, the string is converted if the other one is a number
+// or a boolean, since the fields of CSV are always strings.
+func compareSQLValues(l, r interface{}) (int, bool) {
+	switch lv := l.(type) {
+	case string:
+		if rv, ok := r.(string); ok {
+			return strings.Compare(lv, rv), true
+		}
+	case bool:
+		rb, err := toSQLBool(r)
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case lv == rb:
+			return 0, true
+		case !lv:
+			return -1, true
+		default:
+			return 1, true
+		}
+	}
+	if rb, ok := r.(bool); ok {
+		cmp, ok := compareSQLValues(rb, l)
+		return -cmp, ok
+	}
+	ln, err := toSQLNumber(l)
+	if err != nil {
+		return 0, false
+	}
+	rn, err := toSQLNumber(r)
+	if err != nil {
+		return 0, false
+	}
+	li, lok := ln.(int64)
+	ri, rok := rn.(int64)
+	if lok && rok {
+		switch {
+		case li < ri:
+			return -1, true
+		case li > ri:
+			return 1, true
+		}
+		return 0, true
+	}
+	lf, rf := toSQLFloat(ln), toSQLFloat(rn)
+	switch {
+	case lf < rf:
+		return -1, true
+	case lf > rf:
+		return 1, true
+	}
+	return 0, true
+}
+
+type sqlProjection struct {
+	expr  sqlExpr // nil for *
+	alias string
+}
+
+type sqlQuery struct {
+	projections []sqlProjection
+	where       sqlExpr
+	limit       int64 // -1 if not limited
+	aggregates  []*sqlAggregate
+}
+
+// project returns the output columns of the record.
+func (q *sqlQuery) project(rec selectRecord) ([]selectColumn, error) {
+	if len(q.projections) == 1 && q.projections[0].expr == nil {
+		return rec.columns(), nil
+	}
+	columns := make([]selectColumn, len(q.projections))
+	for i, p := range q.projections {
+		v, err := p.expr.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = selectColumn{name: p.alias, value: v}
+	}
+	return columns, nil
+}
+
+// match checks whether the record satisfies the where clause.
+func (q *sqlQuery) match(rec selectRecord) (bool, error) {
+	if q.where == nil {
+		return true, nil
+	}
+	v, err := q.where.eval(rec)
+	if err != nil || v == nil {
+		return false, err
+	}
+	return toSQLBool(v)
+}
+
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "LIMIT": true, "AS": true, "AND": true, "OR": true,
+	"NOT": true, "LIKE": true, "ESCAPE": true, "IS": true, "NULL": true, "TRUE": true, "FALSE": true,
+	"IN": true, "BETWEEN": true, "CAST": true,
+}
+
+type sqlParser struct {
+	tokens     []sqlToken
+	pos        int
+	alias      string
+	aggregates []*sqlAggregate
+	inWhere    bool
+	inAgg      bool
+}
+
+// parseSQL parses the SQL expression of S3 Select.
+func parseSQL(sql string) (query *sqlQuery, err error) {
+	p := &sqlParser{}
+	if p.tokens, err = lexSQL(sql); err != nil {
+		return
+	}
+	query = &sqlQuery{limit: -1}
+	if err = p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+
+	// the projections are parsed after the alias of the table is known
+	projStart := p.pos
+	for depth := 0; ; p.pos++ {
+		tok := p.peek()
+		if tok.kind == sqlTokenEOF {
+			return nil, errors.New("missing FROM clause")
+		}
+		if tok.kind == sqlTokenOperator && tok.text == "(" {
+			depth++
+		} else if tok.kind == sqlTokenOperator && tok.text == ")" {
+			depth--
+		} else if depth == 0 && p.isKeyword("FROM") {
+			break
+		}
+	}
+	projEnd := p.pos
+	p.pos++
+	if tok := p.next(); tok.kind != sqlTokenIdent || !strings.EqualFold(tok.text, "S3Object") {
+		return nil, fmt.Errorf("unexpected %q at position %d, expecting S3Object", tok.text, tok.pos)
+	}
+	if p.isKeyword("AS") {
+		p.pos++
+		tok := p.next()
+		if tok.kind != sqlTokenIdent && tok.kind != sqlTokenQuotedIdent {
+			return nil, fmt.Errorf("unexpected %q at position %d, expecting alias", tok.text, tok.pos)
+		}
+		p.alias = tok.text
+	} else if tok := p.peek(); tok.kind == sqlTokenQuotedIdent || tok.kind == sqlTokenIdent && !sqlKeywords[strings.ToUpper(tok.text)] {
+		p.pos++
+		p.alias = tok.text
+	}
+	if p.isKeyword("WHERE") {
+		p.pos++
+		p.inWhere = true
+		if query.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		p.inWhere = false
+	}
+	if p.isKeyword("LIMIT") {
+		p.pos++
+		tok := p.next()
+		if tok.kind != sqlTokenNumber {
+			return nil, fmt.Errorf("unexpected %q at position %d, expecting number", tok.text, tok.pos)
+		}
+		if query.limit, err = strconv.ParseInt(tok.text, 10, 64); err != nil || query.limit < 0 {
+			return nil, fmt.Errorf("invalid limit %q", tok.text)
+		}
+	}
+	if tok := p.peek(); tok.kind != sqlTokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+
+	p.tokens = append(p.tokens[:projEnd:projEnd], sqlToken{kind: sqlTokenEOF, pos: p.tokens[projEnd].pos})
+	p.pos = projStart
+	if query.projections, err = p.parseProjections(); err != nil {
+		return nil, err
+	}
+	query.aggregates = p.aggregates
+	return
+}
+
+func (p *sqlParser) parseProjections() (projections []sqlProjection, err error) {
+	var aggregated int
+	for {
+		if p.isStar() {
+			projections = append(projections, sqlProjection{})
+		} else {
+			n := len(p.aggregates)
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if len(p.aggregates) > n {
+				aggregated++
+			}
+			proj := sqlProjection{expr: expr, alias: "_" + strconv.Itoa(len(projections)+1)}
+			if col, ok := expr.(*sqlColumn); ok && col.path[len(col.path)-1].name != "" {
+				proj.alias = col.path[len(col.path)-1].name
+			}
+			if p.isKeyword("AS") {
+				p.pos++
+				tok := p.next()
+				if tok.kind != sqlTokenIdent && tok.kind != sqlTokenQuotedIdent {
+					return nil, fmt.Errorf("unexpected %q at position %d, expecting alias", tok.text, tok.pos)
+				}
+				proj.alias = tok.text
+			} else if tok := p.peek(); tok.kind == sqlTokenQuotedIdent || tok.kind == sqlTokenIdent && !sqlKeywords[strings.ToUpper(tok.text)] {
+				p.pos++
+				proj.alias = tok.text
+			}
+			projections = append(projections, proj)
+		}
+		if tok := p.peek(); tok.kind == sqlTokenOperator && tok.text == "," {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if tok := p.peek(); tok.kind != sqlTokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	if len(projections) > 1 {
+		for _, proj := range projections {
+			if proj.expr == nil {
+				return nil, errors.New("* cannot be used with other projections")
+			}
+		}
+	}
+	if aggregated > 0 && aggregated != len(projections) {
+		return nil, errors.New("aggregate and non-aggregate projections cannot be mixed")
+	}
+	return
+}
+
+// isStar checks and consumes the projection * or alias.*
+func (p *sqlParser) isStar() bool {
+	tok := p.peek()
+	if tok.kind == sqlTokenOperator && tok.text == "*" {
+		p.pos++
+		return true
+	}
+	if p.alias != "" && (tok.kind == sqlTokenIdent || tok.kind == sqlTokenQuotedIdent) && tok.text == p.alias &&
+		p.pos+2 < len(p.tokens) && p.tokens[p.pos+1].text == "." && p.tokens[p.pos+2].text == "*" {
+		p.pos += 3
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != sqlTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *sqlParser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == sqlTokenIdent && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *sqlParser) isOperator(op string) bool {
+	tok := p.peek()
+	return tok.kind == sqlTokenOperator && tok.text == op
+}
+
+func (p *sqlParser) expectKeyword(keyword string) error {
+	if !p.isKeyword(keyword) {
+		tok := p.peek()
+		return fmt.Errorf("unexpected %q at position %d, expecting %v", tok.text, tok.pos, keyword)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *sqlParser) expectOperator(op string) error {
+	if !p.isOperator(op) {
+		tok := p.peek()
+		return fmt.Errorf("unexpected %q at position %d, expecting %q", tok.text, tok.pos, op)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *sqlParser) parseExpr() (sqlExpr, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("OR") {
+		p.pos++
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &sqlBinary{op: "OR", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseAnd() (sqlExpr, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("AND") {
+		p.pos++
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &sqlBinary{op: "AND", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseNot() (sqlExpr, error) {
+	if p.isKeyword("NOT") {
+		p.pos++
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlUnary{op: "NOT", x: x}, nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *sqlParser) parsePredicate() (sqlExpr, error) {
+	x, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if tok.kind == sqlTokenOperator {
+		switch tok.text {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.pos++
+			r, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return &sqlBinary{op: tok.text, l: x, r: r}, nil
+		}
+		return x, nil
+	}
+	if p.isKeyword("IS") {
+		p.pos++
+		not := p.isKeyword("NOT")
+		if not {
+			p.pos++
+		}
+		if err = p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &sqlIsNull{x: x, not: not}, nil
+	}
+	not := p.isKeyword("NOT")
+	if not {
+		p.pos++
+	}
+	switch {
+	case p.isKeyword("LIKE"):
+		p.pos++
+		like := &sqlLike{x: x, not: not}
+		if like.pattern, err = p.parseAdditive(); err != nil {
+			return nil, err
+		}
+		if p.isKeyword("ESCAPE") {
+			p.pos++
+			if like.escape, err = p.parseAdditive(); err != nil {
+				return nil, err
+			}
+		}
+		_, patternLiteral := like.pattern.(*sqlLiteral)
+		_, escapeLiteral := like.escape.(*sqlLiteral)
+		if patternLiteral && (like.escape == nil || escapeLiteral) {
+			if like.re, err = like.compile(nil); err != nil {
+				return nil, err
+			}
+		}
+		return like, nil
+	case p.isKeyword("IN"):
+		p.pos++
+		in := &sqlIn{x: x, not: not}
+		if err = p.expectOperator("("); err != nil {
+			return nil, err
+		}
+		if in.list, err = p.parseExprList(); err != nil {
+			return nil, err
+		}
+		return in, nil
+	case p.isKeyword("BETWEEN"):
+		p.pos++
+		between := &sqlBetween{x: x, not: not}
+		if between.lower, err = p.parseAdditive(); err != nil {
+			return nil, err
+		}
+		if err = p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		if between.upper, err = p.parseAdditive(); err != nil {
+			return nil, err
+		}
+		return between, nil
+	}
+	if not {
+		tok = p.peek()
+		return nil, fmt.Errorf("unexpected %q at position %d, expecting LIKE, IN or BETWEEN", tok.text, tok.pos)
+	}
+	return x, nil
+}
+
+// parseExprList parses the expressions separated by comma until the right parenthesis.
+func (p *sqlParser) parseExprList() (list []sqlExpr, err error) {
+	for {
+		var x sqlExpr
+		if x, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		list = append(list, x)
+		if p.isOperator(",") {
+			p.pos++
+			continue
+		}
+		return list, p.expectOperator(")")
+	}
+}
+
+func (p *sqlParser) parseAdditive() (sqlExpr, error) {
+	l, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+") || p.isOperator("-") || p.isOperator("||") {
+		op := p.next().text
+		r, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = &sqlBinary{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseMultiplicative() (sqlExpr, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*") || p.isOperator("/") || p.isOperator("%") {
+		op := p.next().text
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &sqlBinary{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseUnary() (sqlExpr, error) {
+	if p.isOperator("-") || p.isOperator("+") {
+		op := p.next().text
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlUnary{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *sqlParser) parsePrimary() (sqlExpr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case sqlTokenNumber:
+		n, err := toSQLNumber(tok.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return &sqlLiteral{value: n}, nil
+	case sqlTokenString:
+		return &sqlLiteral{value: tok.text}, nil
+	case sqlTokenOperator:
+		if tok.text == "(" {
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expectOperator(")")
+		}
+	case sqlTokenQuotedIdent:
+		return p.parseColumn(tok)
+	case sqlTokenIdent:
+		upper := strings.ToUpper(tok.text)
+		switch upper {
+		case "NULL":
+			return &sqlLiteral{}, nil
+		case "TRUE", "FALSE":
+			return &sqlLiteral{value: upper == "TRUE"}, nil
+		case "CAST":
+			return p.parseCast()
+		}
+		if p.isOperator("(") {
+			return p.parseFunc(upper)
+		}
+		if sqlKeywords[upper] {
+			break
+		}
+		return p.parseColumn(tok)
+	}
+	if tok.kind == sqlTokenEOF {
+		return nil, errors.New("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+func (p *sqlParser) parseCast() (sqlExpr, error) {
+	if err := p.expectOperator("("); err != nil {
+		return nil, err
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expectKeyword("AS"); err != nil {
+		return nil, err
+	}
+	tok := p.next()
+	typ := strings.ToUpper(tok.text)
+	if tok.kind != sqlTokenIdent || !sqlCastTypes[typ] {
+		return nil, fmt.Errorf("unsupported type %q at position %d", tok.text, tok.pos)
+	}
+	return &sqlCast{x: x, typ: typ}, p.expectOperator(")")
+}
+
+func (p *sqlParser) parseFunc(name string) (sqlExpr, error) {
+	p.pos++ // (
+	if sqlAggregateFuncs[name] {
+		if p.inWhere {
+			return nil, fmt.Errorf("aggregate function %v is not allowed in WHERE clause", name)
+		}
+		if p.inAgg {
+			return nil, fmt.Errorf("aggregate function %v cannot be nested", name)
+		}
+		agg := &sqlAggregate{name: name}
+		if name == "COUNT" && p.isOperator("*") {
+			p.pos++
+		} else {
+			p.inAgg = true
+			var err error
+			agg.arg, err = p.parseExpr()
+			p.inAgg = false
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expectOperator(")"); err != nil {
+			return nil, err
+		}
+		p.aggregates = append(p.aggregates, agg)
+		return agg, nil
+	}
+	nargs, ok := sqlFuncArgs[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported function %v", name)
+	}
+	args, err := p.parseExprList()
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < nargs[0] || nargs[1] >= 0 && len(args) > nargs[1] {
+		return nil, fmt.Errorf("invalid number of arguments for function %v", name)
+	}
+	return &sqlFunc{name: name, args: args}, nil
+}
+
+// parseColumn parses the column reference, e.g. s.name, s."Name", _1, s.a.b[0]
+func (p *sqlParser) parseColumn(first sqlToken) (sqlExpr, error) {
+	col := &sqlColumn{}
+	// the alias of the table is stripped from the path
+	if !(p.alias != "" && first.text == p.alias && p.isOperator(".")) {
+		col.path = append(col.path, sqlPathElem{name: first.text, quoted: first.kind == sqlTokenQuotedIdent})
+	}
+	for {
+		switch {
+		case p.isOperator("."):
+			p.pos++
+			tok := p.next()
+			if tok.kind != sqlTokenIdent && tok.kind != sqlTokenQuotedIdent {
+				return nil, fmt.Errorf("unexpected %q at position %d, expecting column name", tok.text, tok.pos)
+			}
+			col.path = append(col.path, sqlPathElem{name: tok.text, quoted: tok.kind == sqlTokenQuotedIdent})
+		case p.isOperator("["):
+			p.pos++
+			tok := p.next()
+			index, err := strconv.Atoi(tok.text)
+			if tok.kind != sqlTokenNumber || err != nil || index < 0 {
+				return nil, fmt.Errorf("unexpected %q at position %d, expecting array index", tok.text, tok.pos)
+			}
+			if err = p.expectOperator("]"); err != nil {
+				return nil, err
+			}
+			col.path = append(col.path, sqlPathElem{index: index})
+		default:
+			if len(col.path) == 0 {
+				return nil, fmt.Errorf("missing column name at position %d", first.pos)
+			}
+			return col, nil
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSQL(t *testing.T) {
+	query, err := parseSQL(`SELECT s.name, s."Age" AS age, _3 FROM S3Object s WHERE s.age > 20 LIMIT 10`)
+	require.NoError(t, err)
+	require.Len(t, query.projections, 3)
+	require.Equal(t, "name", query.projections[0].alias)
+	require.Equal(t, "age", query.projections[1].alias)
+	require.Equal(t, "_3", query.projections[2].alias)
+	require.Equal(t, []sqlPathElem{{name: "Age", quoted: true}}, query.projections[1].expr.(*sqlColumn).path)
+	require.NotNil(t, query.where)
+	require.Equal(t, int64(10), query.limit)
+
+	query, err = parseSQL(`select * from s3object`)
+	require.NoError(t, err)
+	require.Len(t, query.projections, 1)
+	require.Nil(t, query.projections[0].expr)
+	require.Equal(t, int64(-1), query.limit)
+
+	query, err = parseSQL(`SELECT s.* FROM S3Object AS s`)
+	require.NoError(t, err)
+	require.Nil(t, query.projections[0].expr)
+
+	query, err = parseSQL(`SELECT COUNT(*), AVG(CAST(s.age AS INT)) FROM S3Object s`)
+	require.NoError(t, err)
+	require.Len(t, query.aggregates, 2)
+
+	for _, sql := range []string{
+		``,
+		`SELECT`,
+		`SELECT * FROM table`,
+		`SELECT * FROM S3Object WHERE`,
+		`SELECT *, name FROM S3Object`,
+		`SELECT name, COUNT(*) FROM S3Object`,
+		`SELECT * FROM S3Object WHERE COUNT(*) > 1`,
+		`SELECT SUM(COUNT(*)) FROM S3Object`,
+		`SELECT UNKNOWN(name) FROM S3Object`,
+		`SELECT 'abc FROM S3Object`,
+		`SELECT * FROM S3Object LIMIT -1`,
+		`SELECT * FROM S3Object s extra`,
+		`SELECT CAST(a AS DATE) FROM S3Object`,
+	} {
+		_, err = parseSQL(sql)
+		require.Error(t, err, sql)
+	}
+}
+
+func TestSQLEval(t *testing.T) {
+	rec := &jsonRecord{
+		keys: []string{"name", "age", "score", "tags", "addr", "empty"},
+		values: map[string]interface{}{
+			"name":  "Alice",
+			"age":   int64(30),
+			"score": "85.5",
+			"tags":  []interface{}{"a", "b"},
+			"addr":  map[string]interface{}{"city": "Shanghai"},
+			"empty": nil,
+		},
+	}
+	tests := []struct {
+		expr   string
+		expect interface{}
+	}{
+		{`s.age`, int64(30)},
+		{`s.AGE`, int64(30)},
+		{`s."AGE"`, nil},
+		{`s.addr.city`, "Shanghai"},
+		{`s.tags[1]`, "b"},
+		{`s.tags[2]`, nil},
+		{`s.missing`, nil},
+		{`s.age + 1`, int64(31)},
+		{`s.age / 4`, int64(7)},
+		{`s.age % 7`, int64(2)},
+		{`s.score * 2`, 171.0},
+		{`-s.age`, int64(-30)},
+		{`s.age = 30`, true},
+		{`s.age <> 30`, false},
+		{`s.score > 80`, true},
+		{`s.score >= '85.5'`, true},
+		{`s.name < 'Bob'`, true},
+		{`s.name = 1`, false},
+		{`s.name LIKE 'A%e'`, true},
+		{`s.name LIKE '_lic_'`, true},
+		{`s.name NOT LIKE 'B%'`, true},
+		{`'10%' LIKE '10!%' ESCAPE '!'`, true},
+		{`s.name IN ('Bob', 'Alice')`, true},
+		{`s.age NOT IN (1, 2)`, true},
+		{`s.age BETWEEN 20 AND 30`, true},
+		{`s.age NOT BETWEEN 20 AND 30`, false},
+		{`s.empty IS NULL`, true},
+		{`s.missing IS NULL`, true},
+		{`s.age IS NOT NULL`, true},
+		{`s.empty = 1`, nil},
+		{`s.empty = 1 OR s.age = 30`, true},
+		{`s.empty = 1 AND s.age = 30`, nil},
+		{`s.empty = 1 AND s.age = 1`, false},
+		{`NOT (s.age > 40)`, true},
+		{`s.age > 20 AND (s.name = 'Bob' OR s.addr.city = 'Shanghai')`, true},
+		{`LOWER(s.name) || UPPER('x')`, "aliceX"},
+		{`CHAR_LENGTH(TRIM('  abc '))`, int64(3)},
+		{`SUBSTRING(s.name, 2, 3)`, "lic"},
+		{`SUBSTRING(s.name, 3)`, "ice"},
+		{`COALESCE(s.missing, s.empty, 'default')`, "default"},
+		{`CAST(s.score AS INT)`, int64(85)},
+		{`CAST(s.age AS FLOAT)`, 30.0},
+		{`CAST(s.age AS STRING)`, "30"},
+		{`CAST('true' AS BOOL)`, true},
+		{`1.5e1`, 15.0},
+		{`TRUE`, true},
+		{`NULL`, nil},
+	}
+	for _, tt := range tests {
+		query, err := parseSQL(`SELECT ` + tt.expr + ` FROM S3Object s`)
+		require.NoError(t, err, tt.expr)
+		v, err := query.projections[0].expr.eval(rec)
+		require.NoError(t, err, tt.expr)
+		require.Equal(t, tt.expect, v, tt.expr)
+	}
+
+	for _, expr := range []string{`s.age / 0`, `s.name + 1`, `CAST(s.name AS INT)`, `s.name AND TRUE`} {
+		query, err := parseSQL(`SELECT ` + expr + ` FROM S3Object s`)
+		require.NoError(t, err, expr)
+		_, err = query.projections[0].expr.eval(rec)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestSQLAggregate(t *testing.T) {
+	query, err := parseSQL(`SELECT COUNT(*), COUNT(_2), SUM(_2), AVG(_2), MIN(_1), MAX(_2) + 1 FROM S3Object WHERE _1 <> 'x'`)
+	require.NoError(t, err)
+	for _, fields := range [][]string{{"b", "1"}, {"a", "2.5"}, {"x", "100"}, {"c"}} {
+		rec := &csvRecord{fields: fields}
+		matched, err := query.match(rec)
+		require.NoError(t, err)
+		if !matched {
+			continue
+		}
+		for _, agg := range query.aggregates {
+			require.NoError(t, agg.accumulate(rec))
+		}
+	}
+	columns, err := query.project(&csvRecord{})
+	require.NoError(t, err)
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = column.value
+	}
+	require.Equal(t, []interface{}{int64(3), int64(2), 3.5, 1.75, "a", 3.5}, values)
+}
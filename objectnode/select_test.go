@@ -0,0 +1,217 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+	"github.com/cubefs/cubefs/util/compressor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelectObjectContentRequest(t *testing.T) {
+	req, errCode := ParseSelectObjectContentRequest([]byte(`
+<SelectObjectContentRequest xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+	<Expression>SELECT * FROM S3Object</Expression>
+	<ExpressionType>SQL</ExpressionType>
+	<InputSerialization>
+		<CompressionType>GZIP</CompressionType>
+		<CSV>
+			<FileHeaderInfo>USE</FileHeaderInfo>
+			<FieldDelimiter>|</FieldDelimiter>
+		</CSV>
+	</InputSerialization>
+	<OutputSerialization>
+		<JSON><RecordDelimiter>,</RecordDelimiter></JSON>
+	</OutputSerialization>
+</SelectObjectContentRequest>`))
+	require.Nil(t, errCode)
+	require.Equal(t, "SELECT * FROM S3Object", req.Expression)
+	require.Equal(t, "|", req.InputSerialization.CSV.FieldDelimiter)
+	require.Equal(t, ",", req.OutputSerialization.JSON.RecordDelimiter)
+
+	tests := []struct {
+		body    string
+		errCode string
+	}{
+		{`<SelectObjectContentRequest>`, MalformedXML.ErrorCode},
+		{`<SelectObjectContentRequest><ExpressionType>SQL</ExpressionType></SelectObjectContentRequest>`, MissingSelectExpression.ErrorCode},
+		{`<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>XPATH</ExpressionType></SelectObjectContentRequest>`, InvalidExpressionType.ErrorCode},
+		{`<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+			<InputSerialization><CompressionType>BZIP2</CompressionType><CSV/></InputSerialization><OutputSerialization><CSV/></OutputSerialization>
+			</SelectObjectContentRequest>`, InvalidCompressionFormat.ErrorCode},
+		{`<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+			<InputSerialization><Parquet/></InputSerialization><OutputSerialization><CSV/></OutputSerialization>
+			</SelectObjectContentRequest>`, UnsupportedOperation.ErrorCode},
+		{`<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+			<InputSerialization><CSV/><JSON/></InputSerialization><OutputSerialization><CSV/></OutputSerialization>
+			</SelectObjectContentRequest>`, "InvalidRequestParameter"},
+		{`<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+			<InputSerialization><CSV><QuoteCharacter>'</QuoteCharacter></CSV></InputSerialization><OutputSerialization><CSV/></OutputSerialization>
+			</SelectObjectContentRequest>`, "InvalidRequestParameter"},
+		{`<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+			<InputSerialization><JSON/></InputSerialization><OutputSerialization/>
+			</SelectObjectContentRequest>`, "InvalidRequestParameter"},
+	}
+	for _, tt := range tests {
+		_, errCode = ParseSelectObjectContentRequest([]byte(tt.body))
+		require.NotNil(t, errCode, tt.body)
+		require.Equal(t, tt.errCode, errCode.ErrorCode, tt.body)
+	}
+}
+
+// decodeSelectEvents decodes the event stream, and returns the records and the type of the events.
+func decodeSelectEvents(t *testing.T, data []byte) (records string, events []string) {
+	decoder := eventstream.NewDecoder(bytes.NewReader(data))
+	for {
+		msg, err := decoder.Decode(nil)
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+		if msg.Headers.Get(":message-type").String() == "error" {
+			events = append(events, "error:"+msg.Headers.Get(":error-code").String())
+			continue
+		}
+		eventType := msg.Headers.Get(":event-type").String()
+		events = append(events, eventType)
+		if eventType == "Records" {
+			records += string(msg.Payload)
+		}
+	}
+}
+
+func runSelectObject(t *testing.T, data []byte, input *InputSerialization, output *OutputSerialization, sql string) (string, []string, error) {
+	query, err := parseSQL(sql)
+	require.NoError(t, err)
+	req := &SelectObjectContentRequest{
+		Expression:          sql,
+		ExpressionType:      SelectExpressionTypeSQL,
+		InputSerialization:  input,
+		OutputSerialization: output,
+	}
+	require.Nil(t, req.validate())
+	buf := new(bytes.Buffer)
+	err = selectObject(bytes.NewReader(data), req, query, newSelectEventWriter(buf))
+	records, events := decodeSelectEvents(t, buf.Bytes())
+	return records, events, err
+}
+
+func TestSelectObjectCSV(t *testing.T) {
+	data := []byte("name,age,city\nAlice,30,\"Shanghai, China\"\nBob,25,Beijing\n# comment\nCarol,35,Shenzhen\n")
+	input := &InputSerialization{CSV: &CSVInput{FileHeaderInfo: "USE", Comments: "#"}}
+
+	records, events, err := runSelectObject(t, data, input, &OutputSerialization{CSV: &CSVOutput{}},
+		`SELECT s.name, s.city FROM S3Object s WHERE CAST(s.age AS INT) >= 30`)
+	require.NoError(t, err)
+	require.Equal(t, "Alice,\"Shanghai, China\"\nCarol,Shenzhen\n", records)
+	require.Equal(t, []string{"Records", "Stats", "End"}, events)
+
+	records, _, err = runSelectObject(t, data, input, &OutputSerialization{JSON: &JSONOutput{}},
+		`SELECT * FROM S3Object LIMIT 1`)
+	require.NoError(t, err)
+	require.Equal(t, "{\"name\":\"Alice\",\"age\":\"30\",\"city\":\"Shanghai, China\"}\n", records)
+
+	records, _, err = runSelectObject(t, data, input, &OutputSerialization{CSV: &CSVOutput{QuoteFields: "ALWAYS", FieldDelimiter: "|"}},
+		`SELECT COUNT(*), MAX(CAST(age AS INT)) FROM S3Object`)
+	require.NoError(t, err)
+	require.Equal(t, "\"3\"|\"35\"\n", records)
+
+	// the header is regarded as a record without the header info
+	records, _, err = runSelectObject(t, data, &InputSerialization{CSV: &CSVInput{Comments: "#"}}, &OutputSerialization{CSV: &CSVOutput{}},
+		`SELECT _1 FROM S3Object WHERE _3 LIKE 'S%'`)
+	require.NoError(t, err)
+	require.Equal(t, "Alice\nCarol\n", records)
+
+	// the gzip compressed input
+	compressed, err := compressor.New(compressor.EncodingGzip).Compress(data)
+	require.NoError(t, err)
+	input = &InputSerialization{CompressionType: "GZIP", CSV: &CSVInput{FileHeaderInfo: "IGNORE", Comments: "#"}}
+	records, _, err = runSelectObject(t, compressed, input, &OutputSerialization{CSV: &CSVOutput{}},
+		`SELECT _1 FROM S3Object`)
+	require.NoError(t, err)
+	require.Equal(t, "Alice\nBob\nCarol\n", records)
+
+	_, _, err = runSelectObject(t, data, input, &OutputSerialization{CSV: &CSVOutput{}}, `SELECT * FROM S3Object`)
+	require.Error(t, err)
+	require.Equal(t, "InvalidCompressionFormat", err.(*selectError).code)
+}
+
+func TestSelectObjectJSON(t *testing.T) {
+	data := []byte(`{"id":1,"user":{"name":"alice"},"tags":["a","b"]}
+{"id":2,"user":{"name":"bob"}}
+{"id":3.5,"user":null}
+`)
+	input := &InputSerialization{JSON: &JSONInput{Type: "LINES"}}
+
+	records, events, err := runSelectObject(t, data, input, &OutputSerialization{JSON: &JSONOutput{}},
+		`SELECT s.id, s.user.name AS name FROM S3Object s WHERE s.id < 3`)
+	require.NoError(t, err)
+	require.Equal(t, "{\"id\":1,\"name\":\"alice\"}\n{\"id\":2,\"name\":\"bob\"}\n", records)
+	require.Equal(t, []string{"Records", "Stats", "End"}, events)
+
+	records, _, err = runSelectObject(t, data, input, &OutputSerialization{CSV: &CSVOutput{}},
+		`SELECT * FROM S3Object s WHERE s.tags[0] = 'a'`)
+	require.NoError(t, err)
+	require.Equal(t, "1,\"{\"\"name\"\":\"\"alice\"\"}\",\"[\"\"a\"\",\"\"b\"\"]\"\n", records)
+
+	records, _, err = runSelectObject(t, data, input, &OutputSerialization{JSON: &JSONOutput{}},
+		`SELECT SUM(s.id) AS total, AVG(s.id) FROM S3Object s`)
+	require.NoError(t, err)
+	require.Equal(t, "{\"total\":6.5,\"_2\":2.1666666666666665}\n", records)
+
+	// no record is matched
+	records, events, err = runSelectObject(t, data, input, &OutputSerialization{JSON: &JSONOutput{}},
+		`SELECT * FROM S3Object s WHERE s.id > 10`)
+	require.NoError(t, err)
+	require.Empty(t, records)
+	require.Equal(t, []string{"Stats", "End"}, events)
+
+	_, _, err = runSelectObject(t, []byte(`{"id":1}[1]`), input, &OutputSerialization{JSON: &JSONOutput{}},
+		`SELECT * FROM S3Object`)
+	require.Error(t, err)
+	require.Equal(t, "JSONParsingError", err.(*selectError).code)
+
+	_, _, err = runSelectObject(t, data, input, &OutputSerialization{JSON: &JSONOutput{}},
+		`SELECT s.user.name + 1 FROM S3Object s`)
+	require.Error(t, err)
+	require.Equal(t, "EvaluatorInvalidArguments", err.(*selectError).code)
+}
+
+func TestSelectObjectLimit(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 100000; i++ {
+		sb.WriteString("line,")
+		sb.WriteString(strings.Repeat("x", 32))
+		sb.WriteString("\n")
+	}
+	data := []byte(sb.String())
+	records, events, err := runSelectObject(t, data, &InputSerialization{CSV: &CSVInput{}}, &OutputSerialization{CSV: &CSVOutput{}},
+		`SELECT _1 FROM S3Object LIMIT 2`)
+	require.NoError(t, err)
+	require.Equal(t, "line\nline\n", records)
+	require.Equal(t, []string{"Records", "Stats", "End"}, events)
+
+	// the records are sent in several messages
+	records, events, err = runSelectObject(t, data, &InputSerialization{CSV: &CSVInput{}}, &OutputSerialization{CSV: &CSVOutput{}},
+		`SELECT * FROM S3Object`)
+	require.NoError(t, err)
+	require.Equal(t, sb.String(), records)
+	require.Greater(t, len(events), 3)
+}
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"path"
 	"regexp"
 	"strings"
@@ -70,6 +71,23 @@ const (
 	//		}
 	configMasterAddr = proto.MasterAddr
 
+	// String type configuration item, used to configure the token of the master admin APIs called by the
+	// ObjectNode, such as creating the volume of a bucket and getting the users and the roles, if the master
+	// requires the API tokens. The APIs of the users and the roles require the cluster-admin role.
+	// Example:
+	//		{
+	//			"masterApiToken": "token"
+	//		}
+	configMasterAPIToken = proto.MasterAPIToken
+
+	// String type configuration item, used to configure the client ID and key of the ObjectNode issued by
+	// the AuthNode, which is required to get the roles if the master authenticates the requests.
+	// Example:
+	//		{
+	//			"masterClientIDKey": "clientIDKey"
+	//		}
+	configMasterClientIDKey = "masterClientIDKey"
+
 	// A bool type configuration is used to ensure that the topology information is consistent with the cluster
 	// in real time during the compatibility test. If true, the object node will not cache user information and
 	// volume topology. This configuration will cause a drastic decrease in performance after being turned on,
@@ -93,6 +111,18 @@ const (
 	// The configuration in the example will allow ObjectNode to automatically resolve "* .object.cube.io".
 	configDomains = "domains"
 
+	// The character array configuration item is used to configure the domain names of the static website
+	// endpoints. The buckets with website configuration are served as static websites by the anonymous GET
+	// and HEAD requests to "<bucket>.<domain>".
+	// Example:
+	//		{
+	//			"websiteDomains": [
+	//				"website.cube.io"
+	//			]
+	//		}
+	// The configuration in the example will serve bucket "docs" as a website at "docs.website.cube.io".
+	configWebsiteDomains = "websiteDomains"
+
 	disabledActions               = "disabledActions"
 	configSignatureIgnoredActions = "signatureIgnoredActions"
 
@@ -128,6 +158,119 @@ const (
 	// 		}
 	configAuditLog = "auditLog"
 
+	// Map type configuration item, used to configure the key provider of server-side encryption.
+	// The master keys supplied by the provider seal the data keys of the encrypted objects.
+	// For the parameters of the local provider, see the LocalKMSConfig structure.
+	// Example:
+	//		{
+	//			"kms": {
+	//				"provider": "local",
+	//				"config": {
+	//					"keyFile": "/cfs/conf/sse.keys"
+	//				}
+	//			}
+	//		}
+	configKMS = "kms"
+
+	// Map type configuration item, used to configure the asynchronous bucket replication.
+	// The replication tasks are persisted in the queue directory and retried until succeeded,
+	// the destination of the replication rules refers to the remote targets configured here.
+	// For the parameters, see the ReplicationConfig structure.
+	// Example:
+	//		{
+	//			"replication": {
+	//				"queueDir": "/cfs/data/replication",
+	//				"workers": 4,
+	//				"targets": {
+	//					"backup": {
+	//						"endpoint": "http://192.168.0.10:17410",
+	//						"region": "cfs_dev",
+	//						"accessKey": "AK",
+	//						"secretKey": "SK"
+	//					}
+	//				}
+	//			}
+	//		}
+	configReplication = "replication"
+
+	// Map type configuration item, used to configure the bucket event notification.
+	// The events are spooled in the spool directory and delivered to the targets at least once,
+	// the key of the kafka and webhook targets is the last field of the ARN in the notification
+	// rules of the buckets, e.g. arn:cubefs:sqs::cfs:uploads.
+	// For the parameters, see the NotificationConfig structure.
+	// Example:
+	//		{
+	//			"notification": {
+	//				"spoolDir": "/cfs/data/notification",
+	//				"kafka": {
+	//					"uploads": {
+	//						"brokers": "192.168.0.20:9092",
+	//						"topic": "bucket_events"
+	//					}
+	//				},
+	//				"webhook": {
+	//					"pipeline": {
+	//						"endpoint": "http://192.168.0.30:8080/events"
+	//					}
+	//				}
+	//			}
+	//		}
+	configNotification = "notification"
+
+	// Map type configuration item, used to configure the cluster-wide default of the public access block.
+	// It is combined with the public access block configuration of each bucket, the most restrictive
+	// setting takes effect. For the parameters, see the PublicAccessBlockConfiguration structure.
+	// Example:
+	//		{
+	//			"publicAccessBlock": {
+	//				"blockPublicAcls": true,
+	//				"ignorePublicAcls": true,
+	//				"blockPublicPolicy": true,
+	//				"restrictPublicBuckets": true
+	//			}
+	//		}
+	configPublicAccessBlock = "publicAccessBlock"
+
+	// Map type configuration item, used to enable the server access logging of buckets.
+	// The access logs of each bucket are spooled in the local directory, and delivered as log
+	// objects into the target bucket every flushInterval seconds, or once maxBatchSize bytes
+	// of logs are spooled. The logs are dropped if more than maxSpoolSize bytes of a bucket
+	// are not delivered.
+	// Example:
+	//		{
+	//			"accessLogging": {
+	//				"spoolDir": "/cfs/objectnode/access_log",
+	//				"flushInterval": 300,
+	//				"maxBatchSize": 16777216,
+	//				"maxSpoolSize": 1073741824
+	//			}
+	//		}
+	configAccessLogging = "accessLogging"
+
+	// Map type configuration item, used to configure the OpenID Connect issuers trusted by the STS
+	// AssumeRoleWithWebIdentity. The JWKS of each issuer is loaded from the local file or the URL,
+	// and reloaded every refreshInterval seconds. The tokens are mapped to the CubeFS users or roles
+	// by the "sub" or "groups" claims, the first matching mapping is used.
+	// For the parameters, see the OIDCConfig structure.
+	// Example:
+	//		{
+	//			"oidc": {
+	//				"refreshInterval": 3600,
+	//				"issuers": [
+	//					{
+	//						"issuer": "https://sso.example.com",
+	//						"audiences": ["cubefs"],
+	//						"jwksUrl": "https://sso.example.com/jwks",
+	//						"mappings": [
+	//							{"claim": "groups", "value": "admins", "roleArn": "arn:aws:iam::ltptest:role/admin"},
+	//							{"claim": "sub", "value": "svc-*", "user": "ltptest"}
+	//						]
+	//					}
+	//				]
+	//			}
+	//		}
+	configOIDC = "oidc"
+
 	// ObjMetaCache takes each path hierarchy of the path-like S3 object key as the cache key,
 	// and map it to the corresponding posix-compatible inode
 	// when enabled, the maxDentryCacheNum must at least be the minimum of defaultMaxDentryCacheNum
@@ -154,6 +297,10 @@ const (
 
 	// s3 QoS config refresh interval
 	s3QoSRefreshIntervalSec = "s3QoSRefreshIntervalSec"
+	// interval of leasing the shares of the s3 QoS quotas from the master, the shares are leased
+	// by the demands of each ObjectNode. A negative value disables the lease, and the quotas are
+	// divided by the ObjectNodes evenly.
+	s3QoSLeaseIntervalSec = "s3QoSLeaseIntervalSec"
 )
 
 // Default of configuration value
@@ -164,6 +311,7 @@ const (
 	defaultMaxInodeAttrCacheNum   = 1000000
 	defaultS3QoSReloadIntervalSec = 300
 	defaultS3QoSConfName          = "s3qosInfo.conf"
+	defaultS3QoSLeaseIntervalSec  = 5
 	// ebs
 	MaxSizePutOnce = int64(1) << 23
 )
@@ -181,20 +329,37 @@ var (
 )
 
 type ObjectNode struct {
-	domains    []string
-	wildcards  Wildcards
-	listen     string
-	region     string
-	httpServer *http.Server
-	vm         *VolumeManager
-	mc         *master.MasterClient
-	userStore  UserInfoStore
+	domains   []string
+	wildcards Wildcards
+	// domains of the static website endpoints
+	websiteDomains   []string
+	websiteWildcards Wildcards
+	listen           string
+	region           string
+	httpServer       *http.Server
+	vm               *VolumeManager
+	mc               *master.MasterClient
+	userStore        UserInfoStore
 	// state      uint32
 	// wg         sync.WaitGroup
 
 	localAuditHandler rpc.ProgressHandler
 	externalAudit     *ExternalAudit
 
+	kms        KMS         // key provider of server-side encryption, nil if not configured
+	replicator *Replicator // replicator of bucket replication, nil if not configured
+	notifier   *Notifier   // notifier of bucket event notification, nil if not configured
+
+	accessLogger *AccessLogger // logger of bucket server access logging, nil if not configured
+
+	oidc *OIDCProvider // trusted issuers of the web identity tokens, nil if not configured
+
+	qosLeaser *QuotaLeaser // leaser of the shares of the s3 QoS quotas
+
+	roleStore *RoleStore // roles of the sessions assumed
+
+	publicAccessBlock *PublicAccessBlockConfiguration // cluster-wide default of the public access block
+
 	closes []func() // close other resources after http server closed
 
 	signatureIgnoredActions proto.Actions // signature ignored actions
@@ -240,6 +405,14 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 	}
 	log.LogInfof("loadConfig: setup config: %v(%v)", configDomains, domains)
 
+	// parse website domain
+	websiteDomains := cfg.GetStringSlice(configWebsiteDomains)
+	o.websiteDomains = websiteDomains
+	if o.websiteWildcards, err = NewWildcards(websiteDomains); err != nil {
+		return
+	}
+	log.LogInfof("loadConfig: setup config: %v(%v)", configWebsiteDomains, websiteDomains)
+
 	// parse master config
 	masters := cfg.GetStringSlice(configMasterAddr)
 	if len(masters) == 0 {
@@ -286,18 +459,74 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 		log.LogInfof("loadConfig: setup config: %v(%v)", configAuditLog, rawAuditLog)
 	}
 
+	// parse kms config
+	if rawKMS := cfg.GetValue(configKMS); rawKMS != nil {
+		if err = o.setKMS(rawKMS); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configKMS, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configKMS, rawKMS)
+	}
+
+	// parse public access block config
+	if rawPublicAccessBlock := cfg.GetValue(configPublicAccessBlock); rawPublicAccessBlock != nil {
+		if err = o.setPublicAccessBlock(rawPublicAccessBlock); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configPublicAccessBlock, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configPublicAccessBlock, rawPublicAccessBlock)
+	}
+
 	// parse strict config
 	strict := cfg.GetBool(configStrict)
 	log.LogInfof("loadConfig: strict: %v", strict)
 	o.disableCreateBucketByS3 = cfg.GetBool(disableCreateBucketByS3)
 
 	o.mc = master.NewMasterClient(masters, false)
+	o.mc.SetAPIToken(cfg.GetString(configMasterAPIToken))
 	poolSize := cfg.GetInt64(proto.CfgHttpPoolSize)
 	log.LogWarnf("loadConfig: http pool size %d", poolSize)
 	o.mc.SetTransport(proto.GetHttpTransporter(&proto.HttpCfg{PoolSize: int(poolSize)}))
 
 	o.vm = NewVolumeManager(masters, strict)
-	o.userStore = NewUserInfoStore(masters, strict)
+	o.userStore = NewUserInfoStore(masters, strict, cfg.GetString(configMasterAPIToken))
+	o.roleStore = NewRoleStore(o.mc, cfg.GetString(configMasterClientIDKey))
+
+	// parse replication config
+	if rawReplication := cfg.GetValue(configReplication); rawReplication != nil {
+		if err = o.setReplication(rawReplication); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configReplication, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configReplication, rawReplication)
+	}
+
+	// parse notification config
+	if rawNotification := cfg.GetValue(configNotification); rawNotification != nil {
+		if err = o.setNotification(rawNotification); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configNotification, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configNotification, rawNotification)
+	}
+
+	// parse access logging config
+	if rawAccessLogging := cfg.GetValue(configAccessLogging); rawAccessLogging != nil {
+		if err = o.setAccessLogging(rawAccessLogging); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configAccessLogging, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configAccessLogging, rawAccessLogging)
+	}
+
+	// parse oidc config
+	if rawOIDC := cfg.GetValue(configOIDC); rawOIDC != nil {
+		if err = o.setOIDC(rawOIDC); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configOIDC, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configOIDC, rawOIDC)
+	}
 
 	// parse inode cache
 	cacheEnable := cfg.GetBool(configObjMetaCache)
@@ -374,6 +603,110 @@ func (o *ObjectNode) setAuditLog(raw interface{}) error {
 	return nil
 }
 
+func (o *ObjectNode) setKMS(raw interface{}) error {
+	var conf KMSConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	kms, err := NewKMS(conf)
+	if err != nil {
+		return err
+	}
+	o.kms = kms
+	o.closes = append(o.closes, func() { kms.Close() })
+
+	return nil
+}
+
+func (o *ObjectNode) setReplication(raw interface{}) error {
+	var conf ReplicationConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	replicator, err := NewReplicator(conf, o.getVol, o.kms)
+	if err != nil {
+		return err
+	}
+	replicator.Start()
+	o.replicator = replicator
+	o.closes = append(o.closes, func() { replicator.Close() })
+
+	return nil
+}
+
+func (o *ObjectNode) setNotification(raw interface{}) error {
+	var conf NotificationConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	notifier, err := NewNotifier(conf)
+	if err != nil {
+		return err
+	}
+	notifier.Start()
+	o.notifier = notifier
+	o.closes = append(o.closes, func() { notifier.Close() })
+
+	return nil
+}
+
+func (o *ObjectNode) setAccessLogging(raw interface{}) error {
+	var conf AccessLoggingConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	logger, err := NewAccessLogger(conf, o.deliverAccessLog)
+	if err != nil {
+		return err
+	}
+	logger.Start()
+	o.accessLogger = logger
+	o.closes = append(o.closes, func() { logger.Close() })
+
+	return nil
+}
+
+func (o *ObjectNode) setOIDC(raw interface{}) error {
+	var conf OIDCConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	provider, err := NewOIDCProvider(conf)
+	if err != nil {
+		return err
+	}
+	provider.Start()
+	o.oidc = provider
+	o.closes = append(o.closes, func() { provider.Close() })
+
+	return nil
+}
+
+func (o *ObjectNode) setQuotaLeaser(intervalSec int) {
+	var lease func(req *proto.S3QoSLeaseRequest) (*proto.S3QoSLeaseResponse, error)
+	if intervalSec > 0 {
+		lease = o.mc.AdminAPI().LeaseS3QoS
+	}
+	hostname, _ := os.Hostname()
+	leaser := NewQuotaLeaser(hostname+":"+o.listen, time.Duration(intervalSec)*time.Second, lease)
+	leaser.Start()
+	o.qosLeaser = leaser
+	o.closes = append(o.closes, func() { leaser.Close() })
+	log.LogInfof("setQuotaLeaser: node(%v) interval(%v)", leaser.node, intervalSec)
+}
+
+func (o *ObjectNode) setPublicAccessBlock(raw interface{}) error {
+	conf := &PublicAccessBlockConfiguration{}
+	if err := ParseJSONEntity(raw, conf); err != nil {
+		return err
+	}
+	if !conf.IsEmpty() {
+		o.publicAccessBlock = conf
+	}
+
+	return nil
+}
+
 func handleStart(s common.Server, cfg *config.Config) (err error) {
 	o, ok := s.(*ObjectNode)
 	if !ok {
@@ -406,6 +739,7 @@ func handleStart(s common.Server, cfg *config.Config) (err error) {
 		}
 	}
 	// s3 api qos info
+	o.setQuotaLeaser(cfg.GetIntWithDefault(s3QoSLeaseIntervalSec, defaultS3QoSLeaseIntervalSec))
 	reloadConf := &reloadconf.ReloadConf{
 		ConfName:      defaultS3QoSConfName,
 		ReloadSec:     cfg.GetIntWithDefault(s3QoSRefreshIntervalSec, defaultS3QoSReloadIntervalSec),
@@ -463,6 +797,7 @@ func (o *ObjectNode) startMuxRestAPI() (err error) {
 		o.traceMiddleware,
 		o.authMiddleware,
 		o.corsMiddleware,
+		o.websiteMiddleware,
 		o.policyCheckMiddleware,
 		o.contentMiddleware,
 	)
@@ -0,0 +1,359 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/serv-side-encryption.html
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"math"
+	"net/http"
+)
+
+const (
+	SSEAlgorithmAES256 = "AES256"
+	SSEAlgorithmKMS    = "aws:kms"
+
+	sseKeySize = 32
+	sseIVSize  = aes.BlockSize
+
+	MaxEncryptionConfigSize = 1 << 10 // 1KB
+)
+
+type ServerSideEncryptionConfiguration struct {
+	XMLNS   string            `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName xml.Name          `xml:"ServerSideEncryptionConfiguration" json:"-"`
+	Rules   []*EncryptionRule `xml:"Rule" json:"rules"`
+}
+
+type EncryptionRule struct {
+	Default          *EncryptionByDefault `xml:"ApplyServerSideEncryptionByDefault" json:"default"`
+	BucketKeyEnabled bool                 `xml:"BucketKeyEnabled,omitempty" json:"bucket_key_enabled,omitempty"`
+}
+
+type EncryptionByDefault struct {
+	SSEAlgorithm   string `xml:"SSEAlgorithm" json:"algorithm"`
+	KMSMasterKeyID string `xml:"KMSMasterKeyID,omitempty" json:"kms_key_id,omitempty"`
+}
+
+func ParseEncryptionConfig(data []byte) (*ServerSideEncryptionConfiguration, *ErrorCode) {
+	config := &ServerSideEncryptionConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	if len(config.Rules) != 1 || config.Rules[0].Default == nil {
+		return nil, MalformedXML
+	}
+	def := config.Rules[0].Default
+	switch def.SSEAlgorithm {
+	case SSEAlgorithmAES256:
+		if def.KMSMasterKeyID != "" {
+			return nil, InvalidArgument
+		}
+	case SSEAlgorithmKMS:
+	default:
+		return nil, InvalidEncryptionAlgorithm
+	}
+	return config, nil
+}
+
+// Default returns the default encryption applied to the objects put without encryption headers.
+func (c *ServerSideEncryptionConfiguration) Default() *EncryptionByDefault {
+	if c == nil || len(c.Rules) == 0 {
+		return nil
+	}
+	return c.Rules[0].Default
+}
+
+func storeBucketEncryption(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSEncryption, bytes)
+}
+
+func deleteBucketEncryption(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSEncryption)
+}
+
+// SSEPart is a part of a multipart object, each part is encrypted with an IV derived
+// from the part inode, so that a re-uploaded part never reuses the key stream.
+type SSEPart struct {
+	Inode uint64 `json:"i"`
+	Size  uint64 `json:"s"`
+}
+
+// ObjectEncryption is the server-side encryption metadata of an object.
+// The object data is encrypted by AES-256-CTR with a random data key, which keeps
+// the ciphertext the same size as the plaintext and can be decrypted from any offset.
+// The data key is sealed by a KMS master key (SSE-S3 and SSE-KMS) or by the key
+// provided by the customer (SSE-C).
+type ObjectEncryption struct {
+	Algorithm      string    `json:"alg"`
+	KeyID          string    `json:"kid,omitempty"`
+	SealedKey      []byte    `json:"key"`
+	IV             []byte    `json:"iv"`
+	CustomerKeyMD5 string    `json:"ckmd5,omitempty"`
+	Parts          []SSEPart `json:"parts,omitempty"`
+
+	block cipher.Block // available after the data key is unsealed
+}
+
+func ParseObjectEncryption(raw []byte) (*ObjectEncryption, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	e := &ObjectEncryption{}
+	if err := json.Unmarshal(raw, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *ObjectEncryption) Encode() string {
+	data, _ := json.Marshal(e)
+	return string(data)
+}
+
+// IsCustomer returns true if the object is encrypted with a customer provided key.
+func (e *ObjectEncryption) IsCustomer() bool {
+	return e != nil && e.CustomerKeyMD5 != ""
+}
+
+func newKMSEncryption(kms KMS, algorithm, keyID string) (*ObjectEncryption, error) {
+	dataKey, err := kms.GenerateKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	e := &ObjectEncryption{
+		Algorithm: algorithm,
+		KeyID:     dataKey.KeyID,
+		SealedKey: dataKey.Sealed,
+	}
+	if err = e.init(dataKey.Plaintext); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func newCustomerEncryption(customerKey []byte, customerKeyMD5 string) (*ObjectEncryption, error) {
+	key := make([]byte, sseKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	sealed, err := sealKey(customerKey, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	e := &ObjectEncryption{
+		Algorithm:      SSEAlgorithmAES256,
+		SealedKey:      sealed,
+		CustomerKeyMD5: customerKeyMD5,
+	}
+	if err = e.init(key); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *ObjectEncryption) init(key []byte) (err error) {
+	e.IV = make([]byte, sseIVSize)
+	if _, err = io.ReadFull(rand.Reader, e.IV); err != nil {
+		return
+	}
+	e.block, err = aes.NewCipher(key)
+	return
+}
+
+// Unseal decrypts the data key of the object, the customer key is required for SSE-C.
+func (e *ObjectEncryption) Unseal(kms KMS, customerKey []byte) (err error) {
+	var key []byte
+	if e.IsCustomer() {
+		if customerKeyMD5(customerKey) != e.CustomerKeyMD5 {
+			return SSECustomerKeyMismatch
+		}
+		if key, err = unsealKey(customerKey, e.SealedKey, nil); err != nil {
+			return SSECustomerKeyMismatch
+		}
+	} else {
+		if kms == nil {
+			return KMSNotConfigured
+		}
+		if key, err = kms.DecryptKey(e.KeyID, e.SealedKey); err != nil {
+			return
+		}
+	}
+	if len(e.IV) != sseIVSize {
+		return ErrKMSSealedKey
+	}
+	e.block, err = aes.NewCipher(key)
+	return
+}
+
+// EncryptReader returns a reader which encrypts the data of r. The partInode is the
+// data inode of the part for multipart upload, and is zero for a whole object.
+func (e *ObjectEncryption) EncryptReader(r io.Reader, partInode uint64) io.Reader {
+	return &cipher.StreamReader{S: e.newStream(partInode, 0), R: r}
+}
+
+// DecryptWriter returns a writer which decrypts the object data started at offset.
+func (e *ObjectEncryption) DecryptWriter(w io.Writer, offset uint64) io.Writer {
+	return &sseWriter{w: w, c: e.NewCipher(offset)}
+}
+
+// NewCipher returns a cipher which transforms the object data started at offset.
+func (e *ObjectEncryption) NewCipher(offset uint64) *SSECipher {
+	return &SSECipher{enc: e, offset: offset}
+}
+
+func (e *ObjectEncryption) partIV(partInode uint64) []byte {
+	if partInode == 0 {
+		return e.IV
+	}
+	var ino [8]byte
+	binary.BigEndian.PutUint64(ino[:], partInode)
+	h := sha256.New()
+	h.Write(e.IV)
+	h.Write(ino[:])
+	return h.Sum(nil)[:sseIVSize]
+}
+
+func (e *ObjectEncryption) newStream(partInode uint64, offset uint64) cipher.Stream {
+	iv := make([]byte, sseIVSize)
+	copy(iv, e.partIV(partInode))
+	// add the block index to the 128 bits big-endian counter
+	counter := offset / sseIVSize
+	low := binary.BigEndian.Uint64(iv[8:])
+	high := binary.BigEndian.Uint64(iv[:8])
+	if low+counter < low {
+		high++
+	}
+	binary.BigEndian.PutUint64(iv[8:], low+counter)
+	binary.BigEndian.PutUint64(iv[:8], high)
+
+	stream := cipher.NewCTR(e.block, iv)
+	if skip := offset % sseIVSize; skip > 0 {
+		var discard [sseIVSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+	return stream
+}
+
+// locatePart returns the part inode and the range [start, end) of the part which contains the offset.
+func (e *ObjectEncryption) locatePart(offset uint64) (partInode, start, end uint64) {
+	for _, part := range e.Parts {
+		if offset < start+part.Size {
+			return part.Inode, start, start + part.Size
+		}
+		start += part.Size
+	}
+	return 0, start, math.MaxUint64
+}
+
+// SSECipher encrypts or decrypts the object data sequentially from an offset,
+// and switches the key stream at the part boundaries of a multipart object.
+type SSECipher struct {
+	enc    *ObjectEncryption
+	offset uint64
+	end    uint64
+	stream cipher.Stream
+}
+
+func (c *SSECipher) XORKeyStream(dst, src []byte) {
+	for len(src) > 0 {
+		if c.stream == nil || c.offset >= c.end {
+			var partInode, start uint64
+			partInode, start, c.end = c.enc.locatePart(c.offset)
+			c.stream = c.enc.newStream(partInode, c.offset-start)
+		}
+		n := uint64(len(src))
+		if rest := c.end - c.offset; n > rest {
+			n = rest
+		}
+		c.stream.XORKeyStream(dst[:n], src[:n])
+		dst, src = dst[n:], src[n:]
+		c.offset += n
+	}
+}
+
+type sseWriter struct {
+	w   io.Writer
+	c   *SSECipher
+	buf []byte
+}
+
+func (w *sseWriter) Write(p []byte) (int, error) {
+	if cap(w.buf) < len(p) {
+		w.buf = make([]byte, len(p))
+	}
+	buf := w.buf[:len(p)]
+	w.c.XORKeyStream(buf, p)
+	return w.w.Write(buf)
+}
+
+func customerKeyMD5(key []byte) string {
+	sum := md5.Sum(key)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// parseCustomerKey parses the SSE-C headers of the request, which returns nil if
+// no SSE-C header is specified.
+func parseCustomerKey(header http.Header, copySource bool) (key []byte, keyMD5 string, errorCode *ErrorCode) {
+	algorithmHeader, keyHeader, md5Header := XAmzSSECustomerAlgorithm, XAmzSSECustomerKey, XAmzSSECustomerKeyMD5
+	if copySource {
+		algorithmHeader, keyHeader, md5Header = XAmzCopySourceSSECustomerAlgorithm, XAmzCopySourceSSECustomerKey,
+			XAmzCopySourceSSECustomerKeyMD5
+	}
+	algorithm, rawKey, keyMD5 := header.Get(algorithmHeader), header.Get(keyHeader), header.Get(md5Header)
+	if algorithm == "" && rawKey == "" && keyMD5 == "" {
+		return
+	}
+	if algorithm != SSEAlgorithmAES256 {
+		errorCode = InvalidSSECustomerAlgorithm
+		return
+	}
+	var err error
+	if key, err = base64.StdEncoding.DecodeString(rawKey); err != nil || len(key) != sseKeySize {
+		errorCode = InvalidSSECustomerKey
+		return
+	}
+	if keyMD5 != customerKeyMD5(key) {
+		errorCode = SSECustomerKeyMD5Mismatch
+		return
+	}
+	return
+}
+
+// setEncryptionHeaders sets the server-side encryption headers of the response.
+func setEncryptionHeaders(header http.Header, e *ObjectEncryption) {
+	if e == nil {
+		return
+	}
+	if e.IsCustomer() {
+		header.Set(XAmzSSECustomerAlgorithm, e.Algorithm)
+		header.Set(XAmzSSECustomerKeyMD5, e.CustomerKeyMD5)
+		return
+	}
+	header.Set(XAmzServerSideEncryption, e.Algorithm)
+	if e.Algorithm == SSEAlgorithmKMS {
+		header.Set(XAmzServerSideEncryptionKMSKeyId, e.KeyID)
+	}
+}
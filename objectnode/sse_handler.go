@@ -0,0 +1,253 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Get bucket encryption
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketEncryption.html
+func (o *ObjectNode) getBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketEncryptionHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *ServerSideEncryptionConfiguration
+	if config, err = vol.metaLoader.loadEncryption(); err != nil {
+		log.LogErrorf("getBucketEncryptionHandler: load encryption fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config == nil {
+		errorCode = NoSuchEncryptionConfiguration
+		return
+	}
+	output := &ServerSideEncryptionConfiguration{XMLNS: S3Namespace, Rules: config.Rules}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		log.LogErrorf("getBucketEncryptionHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), output, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket encryption
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketEncryption.html
+func (o *ObjectNode) putBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxEncryptionConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxEncryptionConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var config *ServerSideEncryptionConfiguration
+	if config, errorCode = ParseEncryptionConfig(body); errorCode != nil {
+		log.LogErrorf("putBucketEncryptionHandler: parse encryption config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+	// the objects can not be encrypted by default without a KMS
+	if o.kms == nil {
+		errorCode = KMSNotConfigured
+		return
+	}
+	if body, err = json.Marshal(config); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: json marshal encryption config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketEncryption(body, vol); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: store encryption config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeEncryption(config)
+	log.LogInfof("Audit: put bucket encryption: requestID(%v) remote(%v) volume(%v) config(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), string(body))
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Delete bucket encryption
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketEncryption.html
+func (o *ObjectNode) deleteBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketEncryptionHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if err = deleteBucketEncryption(vol); err != nil {
+		log.LogErrorf("deleteBucketEncryptionHandler: delete encryption config fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	vol.metaLoader.storeEncryption(nil)
+	log.LogInfof("Audit: delete bucket encryption: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newObjectEncryption creates the encryption of a new object according to the SSE headers of
+// the request, and the bucket default encryption is applied if no SSE header is specified.
+// A nil encryption is returned if the object is not to be encrypted.
+func (o *ObjectNode) newObjectEncryption(header http.Header, vol *Volume) (*ObjectEncryption, error) {
+	customerKey, keyMD5, errorCode := parseCustomerKey(header, false)
+	if errorCode != nil {
+		return nil, errorCode
+	}
+	algorithm, keyID := header.Get(XAmzServerSideEncryption), header.Get(XAmzServerSideEncryptionKMSKeyId)
+	if customerKey != nil {
+		if algorithm != "" || keyID != "" {
+			return nil, SSEMethodConflict
+		}
+		return newCustomerEncryption(customerKey, keyMD5)
+	}
+	if algorithm == "" {
+		if keyID != "" {
+			return nil, InvalidArgument
+		}
+		config, err := vol.metaLoader.loadEncryption()
+		if err != nil {
+			return nil, err
+		}
+		def := config.Default()
+		if def == nil {
+			return nil, nil
+		}
+		algorithm, keyID = def.SSEAlgorithm, def.KMSMasterKeyID
+	}
+	switch algorithm {
+	case SSEAlgorithmAES256:
+		if keyID != "" {
+			return nil, InvalidArgument
+		}
+	case SSEAlgorithmKMS:
+	default:
+		return nil, InvalidEncryptionAlgorithm
+	}
+	if o.kms == nil {
+		return nil, KMSNotConfigured
+	}
+	sse, err := newKMSEncryption(o.kms, algorithm, keyID)
+	if errors.Is(err, ErrKMSKeyNotFound) {
+		return nil, KMSKeyNotFound
+	}
+	return sse, err
+}
+
+// openObjectEncryption unseals the data key of an encrypted object, the customer key must
+// be provided by the SSE-C headers (or the copy source SSE-C headers) for SSE-C objects.
+func (o *ObjectNode) openObjectEncryption(header http.Header, sse *ObjectEncryption, copySource bool) error {
+	customerKey, _, errorCode := parseCustomerKey(header, copySource)
+	if errorCode != nil {
+		return errorCode
+	}
+	if sse == nil {
+		if customerKey != nil {
+			return SSEParametersNotApplicable
+		}
+		return nil
+	}
+	if sse.IsCustomer() != (customerKey != nil) {
+		if customerKey == nil {
+			return MissingSSECustomerKey
+		}
+		return SSEParametersNotApplicable
+	}
+	err := sse.Unseal(o.kms, customerKey)
+	if errors.Is(err, ErrKMSKeyNotFound) {
+		return KMSKeyNotFound
+	}
+	return err
+}
+
+// openUploadEncryption returns the unsealed encryption of a multipart upload, which is nil
+// if the upload is not encrypted.
+func (o *ObjectNode) openUploadEncryption(header http.Header, multipartInfo *proto.MultipartInfo) (*ObjectEncryption, error) {
+	var (
+		sse *ObjectEncryption
+		err error
+	)
+	if sse, err = ParseObjectEncryption([]byte(multipartInfo.Extend[XAttrKeyOSSSSE])); err != nil {
+		return nil, err
+	}
+	if err = o.openObjectEncryption(header, sse, false); err != nil {
+		return nil, err
+	}
+	return sse, nil
+}
@@ -0,0 +1,312 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKMS() KMS {
+	return &LocalKMS{
+		defaultKeyID: "key-1",
+		keys: map[string][]byte{
+			"key-1": bytes.Repeat([]byte{1}, sseKeySize),
+			"key-2": bytes.Repeat([]byte{2}, sseKeySize),
+		},
+	}
+}
+
+func randomData(t *testing.T, size int) []byte {
+	data := make([]byte, size)
+	_, err := io.ReadFull(rand.Reader, data)
+	require.NoError(t, err)
+	return data
+}
+
+func TestParseEncryptionConfig(t *testing.T) {
+	tests := []struct {
+		value       string
+		algorithm   string
+		keyID       string
+		expectedErr *ErrorCode
+	}{
+		{
+			value: `<ServerSideEncryptionConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Rule>
+							<ApplyServerSideEncryptionByDefault>
+								<SSEAlgorithm>AES256</SSEAlgorithm>
+							</ApplyServerSideEncryptionByDefault>
+						</Rule>
+					</ServerSideEncryptionConfiguration>`,
+			algorithm: SSEAlgorithmAES256,
+		},
+		{
+			value: `<ServerSideEncryptionConfiguration>
+						<Rule>
+							<ApplyServerSideEncryptionByDefault>
+								<SSEAlgorithm>aws:kms</SSEAlgorithm>
+								<KMSMasterKeyID>key-2</KMSMasterKeyID>
+							</ApplyServerSideEncryptionByDefault>
+							<BucketKeyEnabled>true</BucketKeyEnabled>
+						</Rule>
+					</ServerSideEncryptionConfiguration>`,
+			algorithm: SSEAlgorithmKMS,
+			keyID:     "key-2",
+		},
+		{
+			value: `<ServerSideEncryptionConfiguration>
+						<Rule>
+							<ApplyServerSideEncryptionByDefault>
+								<SSEAlgorithm>AES256</SSEAlgorithm>
+								<KMSMasterKeyID>key-2</KMSMasterKeyID>
+							</ApplyServerSideEncryptionByDefault>
+						</Rule>
+					</ServerSideEncryptionConfiguration>`,
+			expectedErr: InvalidArgument,
+		},
+		{
+			value: `<ServerSideEncryptionConfiguration>
+						<Rule>
+							<ApplyServerSideEncryptionByDefault>
+								<SSEAlgorithm>DES</SSEAlgorithm>
+							</ApplyServerSideEncryptionByDefault>
+						</Rule>
+					</ServerSideEncryptionConfiguration>`,
+			expectedErr: InvalidEncryptionAlgorithm,
+		},
+		{
+			value:       `<ServerSideEncryptionConfiguration></ServerSideEncryptionConfiguration>`,
+			expectedErr: MalformedXML,
+		},
+		{
+			value:       `<ServerSideEncryptionConfiguration><Rule></Rule></ServerSideEncryptionConfiguration>`,
+			expectedErr: MalformedXML,
+		},
+		{
+			value:       `<ServerSideEncryptionConfiguration><Rule>`,
+			expectedErr: MalformedXML,
+		},
+	}
+	for _, tt := range tests {
+		config, errCode := ParseEncryptionConfig([]byte(tt.value))
+		require.Equal(t, tt.expectedErr, errCode)
+		if errCode != nil {
+			continue
+		}
+		require.Equal(t, tt.algorithm, config.Default().SSEAlgorithm)
+		require.Equal(t, tt.keyID, config.Default().KMSMasterKeyID)
+	}
+
+	var config *ServerSideEncryptionConfiguration
+	require.Nil(t, config.Default())
+}
+
+func TestParseCustomerKey(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, sseKeySize)
+	rawKey := base64.StdEncoding.EncodeToString(key)
+	keyMD5 := customerKeyMD5(key)
+
+	tests := []struct {
+		algorithm   string
+		key         string
+		keyMD5      string
+		copySource  bool
+		expectedErr *ErrorCode
+	}{
+		{algorithm: SSEAlgorithmAES256, key: rawKey, keyMD5: keyMD5},
+		{algorithm: SSEAlgorithmAES256, key: rawKey, keyMD5: keyMD5, copySource: true},
+		{algorithm: SSEAlgorithmKMS, key: rawKey, keyMD5: keyMD5, expectedErr: InvalidSSECustomerAlgorithm},
+		{algorithm: SSEAlgorithmAES256, key: "invalid", keyMD5: keyMD5, expectedErr: InvalidSSECustomerKey},
+		{algorithm: SSEAlgorithmAES256, key: base64.StdEncoding.EncodeToString(key[1:]), keyMD5: keyMD5, expectedErr: InvalidSSECustomerKey},
+		{algorithm: SSEAlgorithmAES256, key: rawKey, expectedErr: SSECustomerKeyMD5Mismatch},
+		{key: rawKey, keyMD5: keyMD5, expectedErr: InvalidSSECustomerAlgorithm},
+	}
+	for _, tt := range tests {
+		header := make(http.Header)
+		if tt.copySource {
+			header.Set(XAmzCopySourceSSECustomerAlgorithm, tt.algorithm)
+			header.Set(XAmzCopySourceSSECustomerKey, tt.key)
+			header.Set(XAmzCopySourceSSECustomerKeyMD5, tt.keyMD5)
+		} else {
+			header.Set(XAmzSSECustomerAlgorithm, tt.algorithm)
+			header.Set(XAmzSSECustomerKey, tt.key)
+			header.Set(XAmzSSECustomerKeyMD5, tt.keyMD5)
+		}
+		k, md5, errCode := parseCustomerKey(header, tt.copySource)
+		require.Equal(t, tt.expectedErr, errCode)
+		if errCode == nil {
+			require.Equal(t, key, k)
+			require.Equal(t, keyMD5, md5)
+		}
+		// the headers of the other side are not taken
+		k, _, errCode = parseCustomerKey(header, !tt.copySource)
+		require.Nil(t, errCode)
+		require.Nil(t, k)
+	}
+}
+
+func TestObjectEncryption(t *testing.T) {
+	kms := newTestKMS()
+	data := randomData(t, 100*1024+13)
+
+	sse, err := newKMSEncryption(kms, SSEAlgorithmKMS, "key-2")
+	require.NoError(t, err)
+	require.Equal(t, "key-2", sse.KeyID)
+	ciphertext, err := io.ReadAll(sse.EncryptReader(bytes.NewReader(data), 0))
+	require.NoError(t, err)
+	require.Len(t, ciphertext, len(data))
+	require.NotEqual(t, data, ciphertext)
+
+	// decrypt with the metadata loaded from the xattr
+	loaded, err := ParseObjectEncryption([]byte(sse.Encode()))
+	require.NoError(t, err)
+	require.NoError(t, loaded.Unseal(kms, nil))
+	for _, offset := range []int{0, 1, 15, 16, 17, 4095, 65536, len(data) - 1} {
+		buf := new(bytes.Buffer)
+		w := loaded.DecryptWriter(buf, uint64(offset))
+		// write in small pieces to cover the stream state across writes
+		for rest := ciphertext[offset:]; len(rest) > 0; {
+			n := 1000
+			if n > len(rest) {
+				n = len(rest)
+			}
+			_, err = w.Write(rest[:n])
+			require.NoError(t, err)
+			rest = rest[n:]
+		}
+		require.Equal(t, data[offset:], buf.Bytes(), "offset %d", offset)
+	}
+
+	// a new object never shares the data key or IV
+	other, err := newKMSEncryption(kms, SSEAlgorithmAES256, "")
+	require.NoError(t, err)
+	require.Equal(t, "key-1", other.KeyID)
+	require.NotEqual(t, sse.IV, other.IV)
+	require.NotEqual(t, sse.SealedKey, other.SealedKey)
+
+	_, err = newKMSEncryption(kms, SSEAlgorithmKMS, "key-3")
+	require.ErrorIs(t, err, ErrKMSKeyNotFound)
+
+	empty, err := ParseObjectEncryption(nil)
+	require.NoError(t, err)
+	require.Nil(t, empty)
+	require.False(t, empty.IsCustomer())
+}
+
+func TestObjectEncryptionMultipart(t *testing.T) {
+	kms := newTestKMS()
+	sse, err := newKMSEncryption(kms, SSEAlgorithmAES256, "")
+	require.NoError(t, err)
+
+	// parts are encrypted independently with the IV derived from the part inode
+	parts := [][]byte{randomData(t, 5000), randomData(t, 4099), randomData(t, 17)}
+	var data, ciphertext []byte
+	for i, part := range parts {
+		inode := uint64(100 + i)
+		encrypted, err := io.ReadAll(sse.EncryptReader(bytes.NewReader(part), inode))
+		require.NoError(t, err)
+		data = append(data, part...)
+		ciphertext = append(ciphertext, encrypted...)
+		sse.Parts = append(sse.Parts, SSEPart{Inode: inode, Size: uint64(len(part))})
+	}
+
+	loaded, err := ParseObjectEncryption([]byte(sse.Encode()))
+	require.NoError(t, err)
+	require.Equal(t, sse.Parts, loaded.Parts)
+	require.NoError(t, loaded.Unseal(kms, nil))
+	for _, offset := range []int{0, 4999, 5000, 5001, 9098, 9099, len(data) - 1} {
+		buf := new(bytes.Buffer)
+		_, err = loaded.DecryptWriter(buf, uint64(offset)).Write(ciphertext[offset:])
+		require.NoError(t, err)
+		require.Equal(t, data[offset:], buf.Bytes(), "offset %d", offset)
+	}
+
+	// the same data in different part inodes is encrypted differently
+	a, _ := io.ReadAll(sse.EncryptReader(bytes.NewReader(parts[0]), 1))
+	b, _ := io.ReadAll(sse.EncryptReader(bytes.NewReader(parts[0]), 2))
+	require.NotEqual(t, a, b)
+}
+
+func TestObjectEncryptionCounter(t *testing.T) {
+	sse, err := newKMSEncryption(newTestKMS(), SSEAlgorithmAES256, "")
+	require.NoError(t, err)
+	// the low 64 bits of the counter overflow in the middle of the data
+	copy(sse.IV, bytes.Repeat([]byte{0xff}, sseIVSize))
+	sse.IV[0] = 0
+
+	data := randomData(t, 100)
+	ciphertext, err := io.ReadAll(sse.EncryptReader(bytes.NewReader(data), 0))
+	require.NoError(t, err)
+	for offset := 0; offset < len(data); offset++ {
+		plaintext := make([]byte, len(data)-offset)
+		sse.NewCipher(uint64(offset)).XORKeyStream(plaintext, ciphertext[offset:])
+		require.Equal(t, data[offset:], plaintext, "offset %d", offset)
+	}
+}
+
+func TestObjectEncryptionUnseal(t *testing.T) {
+	kms := newTestKMS()
+	customerKey := bytes.Repeat([]byte{7}, sseKeySize)
+	sse, err := newCustomerEncryption(customerKey, customerKeyMD5(customerKey))
+	require.NoError(t, err)
+	require.True(t, sse.IsCustomer())
+	require.Empty(t, sse.KeyID)
+
+	data := randomData(t, 1024)
+	ciphertext, err := io.ReadAll(sse.EncryptReader(bytes.NewReader(data), 0))
+	require.NoError(t, err)
+
+	loaded, err := ParseObjectEncryption([]byte(sse.Encode()))
+	require.NoError(t, err)
+	require.Equal(t, SSECustomerKeyMismatch, loaded.Unseal(kms, nil))
+	require.Equal(t, SSECustomerKeyMismatch, loaded.Unseal(kms, bytes.Repeat([]byte{8}, sseKeySize)))
+	require.NoError(t, loaded.Unseal(nil, customerKey))
+	buf := new(bytes.Buffer)
+	_, err = loaded.DecryptWriter(buf, 0).Write(ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, data, buf.Bytes())
+
+	// the master key is required for the objects encrypted by the KMS
+	sse, err = newKMSEncryption(kms, SSEAlgorithmKMS, "key-2")
+	require.NoError(t, err)
+	loaded, err = ParseObjectEncryption([]byte(sse.Encode()))
+	require.NoError(t, err)
+	require.Equal(t, KMSNotConfigured, loaded.Unseal(nil, nil))
+	loaded.KeyID = "key-1"
+	require.ErrorIs(t, loaded.Unseal(kms, nil), ErrKMSSealedKey)
+}
+
+func TestSetEncryptionHeaders(t *testing.T) {
+	header := make(http.Header)
+	setEncryptionHeaders(header, nil)
+	require.Empty(t, header)
+
+	setEncryptionHeaders(header, &ObjectEncryption{Algorithm: SSEAlgorithmKMS, KeyID: "key-2"})
+	require.Equal(t, SSEAlgorithmKMS, header.Get(XAmzServerSideEncryption))
+	require.Equal(t, "key-2", header.Get(XAmzServerSideEncryptionKMSKeyId))
+
+	header = make(http.Header)
+	setEncryptionHeaders(header, &ObjectEncryption{Algorithm: SSEAlgorithmAES256, CustomerKeyMD5: "md5"})
+	require.Empty(t, header.Get(XAmzServerSideEncryption))
+	require.Equal(t, SSEAlgorithmAES256, header.Get(XAmzSSECustomerAlgorithm))
+	require.Equal(t, "md5", header.Get(XAmzSSECustomerKeyMD5))
+}
@@ -30,6 +30,8 @@ import (
 const (
 	stsAkPrefix = "STS"
 	stsSep      = ";;sts;;"
+	// the separator of the name and the version of the role in the session token, which is never in the role name
+	stsRoleVersionSep = "/"
 
 	stsActionKey          = "Action"
 	stsActionValue        = "GetFederationToken"
@@ -65,17 +67,23 @@ type FederatedCredentials struct {
 }
 
 func EncodeFedSessionToken(ownerAk, ownerSk, fedAk, fedSk, name, policy, expireUnix string) (token string, err error) {
+	return encodeSessionToken(ownerAk, ownerSk, fedAk, fedSk, name, policy, expireUnix)
+}
+
+// EncodeRoleSessionToken encodes the session token of the assumed role, the session policy and the
+// version of the role are appended to the fields of the federation token so that the federation
+// token is still valid. The session is invalid once the role is deleted or updated.
+func EncodeRoleSessionToken(ownerAk, ownerSk, fedAk, fedSk, name string, role *proto.RoleInfo, sessionPolicy, expireUnix string) (token string, err error) {
+	return encodeSessionToken(ownerAk, ownerSk, fedAk, fedSk, name, role.PermissionPolicy, expireUnix, sessionPolicy,
+		role.RoleName+stsRoleVersionSep+strconv.FormatUint(role.Version, 10))
+}
+
+func encodeSessionToken(ownerAk, ownerSk, fedAk string, fields ...string) (token string, err error) {
 	encoding, err := NewStsEncoding(fedAk, ownerSk)
 	if err != nil {
 		return
 	}
-	toEncrypt := strings.Join([]string{
-		fedAk,
-		fedSk,
-		name,
-		policy,
-		expireUnix,
-	}, stsSep)
+	toEncrypt := strings.Join(append([]string{fedAk}, fields...), stsSep)
 	token = base64.URLEncoding.EncodeToString([]byte(ownerAk + stsSep + encoding.Encrypt([]byte(toEncrypt))))
 	return
 }
@@ -84,6 +92,20 @@ type FedDecodeResult struct {
 	FedSK    string
 	Policy   *PolicyV2
 	UserInfo *proto.UserInfo
+	// SessionPolicy is the optional session policy of the assumed role, the effective
+	// permissions are the intersection of it and the role policy.
+	SessionPolicy *PolicyV2
+	// RoleName and RoleVersion are the role of the session assumed, which is owned by the user.
+	RoleName    string
+	RoleVersion uint64
+}
+
+// IsAllow checks whether the action on the resource is allowed by the policies of the session.
+func (f *FedDecodeResult) IsAllow(action, bucket, key string) bool {
+	if !f.Policy.IsAllow(action, bucket, key) {
+		return false
+	}
+	return f.SessionPolicy == nil || f.SessionPolicy.IsAllow(action, bucket, key)
 }
 
 func DecodeFedSessionToken(fedAk, session string, getUserInfo func(ak string) (*proto.UserInfo, error)) (*FedDecodeResult, error) {
@@ -114,7 +136,8 @@ func DecodeFedSessionToken(fedAk, session string, getUserInfo func(ak string) (*
 	}
 
 	parts := strings.Split(string(decryptInfo), stsSep)
-	if len(parts) != 5 {
+	// the sessions of the roles carry the version of the role, the ones without it are never trusted
+	if len(parts) != 5 && len(parts) != 7 {
 		return nil, InvalidToken
 	}
 	fedAk1, fedSk, policyStr, expireUnixStr := parts[0], parts[1], parts[3], parts[4]
@@ -134,8 +157,26 @@ func DecodeFedSessionToken(fedAk, session string, getUserInfo func(ak string) (*
 	if err = json.Unmarshal([]byte(policyStr), &policy); err != nil {
 		return nil, InvalidToken
 	}
+	result := &FedDecodeResult{UserInfo: userInfo, FedSK: fedSk, Policy: &policy}
+	if len(parts) == 7 {
+		if parts[5] != "" {
+			var sessionPolicy PolicyV2
+			if err = json.Unmarshal([]byte(parts[5]), &sessionPolicy); err != nil {
+				return nil, InvalidToken
+			}
+			result.SessionPolicy = &sessionPolicy
+		}
+		roleRef := strings.SplitN(parts[6], stsRoleVersionSep, 2)
+		if len(roleRef) != 2 || roleRef[0] == "" {
+			return nil, InvalidToken
+		}
+		if result.RoleVersion, err = strconv.ParseUint(roleRef[1], 10, 64); err != nil {
+			return nil, InvalidToken
+		}
+		result.RoleName = roleRef[0]
+	}
 
-	return &FedDecodeResult{UserInfo: userInfo, FedSK: fedSk, Policy: &policy}, nil
+	return result, nil
 }
 
 func NewStsEncoding(block, key string) (*StsEncoding, error) {
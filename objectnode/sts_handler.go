@@ -21,6 +21,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/log"
 )
@@ -112,3 +113,322 @@ func (o *ObjectNode) getFederationTokenHandler(w http.ResponseWriter, r *http.Re
 
 	writeSuccessResponseXML(w, response)
 }
+
+// https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRole.html
+func (o *ObjectNode) assumeRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err error
+		erc *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, erc)
+	}()
+	// request param check
+	if token := r.Header.Get(XAmzSecurityToken); token != "" {
+		erc = AccessDeniedBySTS
+		return
+	}
+	ownerID, roleName, err := ParseRoleArn(r.FormValue(stsRoleArnKey))
+	if err != nil {
+		log.LogErrorf("assumeRoleHandler: sts role arn invalid: requestID(%v) roleArn(%v)",
+			GetRequestID(r), r.FormValue(stsRoleArnKey))
+		return
+	}
+	sessionName := r.FormValue(stsRoleSessionNameKey)
+	matched, _ := regexp.MatchString(`^[\w+=,.@-]*$`, sessionName)
+	if len(sessionName) < 2 || len(sessionName) > 64 || !matched {
+		log.LogErrorf("assumeRoleHandler: sts session name invalid: requestID(%v) name(%v)",
+			GetRequestID(r), sessionName)
+		erc = InvalidArgument
+		return
+	}
+	sessionPolicy := r.FormValue(stsPolicyKey)
+	if sessionPolicy != "" {
+		if _, err = ParsePolicyV2Config(sessionPolicy); err != nil {
+			log.LogErrorf("assumeRoleHandler: sts session policy invalid: requestID(%v) policy(%v) err(%v)",
+				GetRequestID(r), sessionPolicy, err)
+			erc = &ErrorCode{
+				ErrorCode:    "MalformedPolicyDocument",
+				ErrorMessage: fmt.Sprintf("The policy document was malformed: %v.", err.Error()),
+				StatusCode:   http.StatusBadRequest,
+			}
+			return
+		}
+	}
+	param := ParseRequestParam(r)
+	caller, err := o.getUserInfoByAccessKeyV2(param.AccessKey())
+	if err != nil {
+		log.LogErrorf("assumeRoleHandler: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return
+	}
+
+	// the role which does not exist is not distinguished from the role which is not trusted
+	role, err := o.roleStore.GetRole(ownerID, roleName)
+	if err != nil {
+		log.LogErrorf("assumeRoleHandler: get role fail: requestID(%v) owner(%v) role(%v) err(%v)",
+			GetRequestID(r), ownerID, roleName, err)
+		if err == proto.ErrRoleNotExists {
+			err = AccessDenied
+		}
+		return
+	}
+	trustPolicy, err := ParseRoleTrustPolicy(role.TrustPolicy)
+	if err != nil {
+		log.LogErrorf("assumeRoleHandler: role trust policy invalid: requestID(%v) owner(%v) role(%v) err(%v)",
+			GetRequestID(r), ownerID, roleName, err)
+		err = AccessDenied
+		return
+	}
+	if !trustPolicy.IsAllowed(stsAssumeRolePermission, caller.UserID) {
+		log.LogErrorf("assumeRoleHandler: role not trusted: requestID(%v) caller(%v) owner(%v) role(%v)",
+			GetRequestID(r), caller.UserID, ownerID, roleName)
+		err = AccessDenied
+		return
+	}
+	if _, err = ParsePolicyV2Config(role.PermissionPolicy); err != nil {
+		log.LogErrorf("assumeRoleHandler: role permission policy invalid: requestID(%v) owner(%v) role(%v) err(%v)",
+			GetRequestID(r), ownerID, roleName, err)
+		err = AccessDenied
+		return
+	}
+
+	durationSeconds := proto.RoleDefaultSessionDuration
+	if seconds := r.FormValue(stsDurationSecondsKey); seconds != "" {
+		if durationSeconds, err = strconv.ParseInt(seconds, 10, 64); err != nil ||
+			durationSeconds < proto.RoleMinSessionDuration || durationSeconds > role.MaxSessionDuration {
+			log.LogErrorf("assumeRoleHandler: sts duration invalid: requestID(%v) duration(%v) max(%v)",
+				GetRequestID(r), seconds, role.MaxSessionDuration)
+			err = InvalidArgument
+			return
+		}
+	}
+	owner, err := o.mc.UserAPI().GetUserInfo(role.OwnerID)
+	if err != nil {
+		log.LogErrorf("assumeRoleHandler: get role owner fail: requestID(%v) owner(%v) err(%v)",
+			GetRequestID(r), role.OwnerID, err)
+		return
+	}
+	// role session ak/sk generation
+	now := time.Now().UTC()
+	expireUnixStr := fmt.Sprint(now.Unix() + durationSeconds)
+	fedAk := stsAkPrefix + util.RandomString(13, util.Numeric|util.LowerLetter|util.UpperLetter)
+	fedSk := util.RandomString(32, util.Numeric|util.LowerLetter|util.UpperLetter)
+	sessionToken, err := EncodeRoleSessionToken(owner.AccessKey, owner.SecretKey, fedAk, fedSk, sessionName,
+		role, sessionPolicy, expireUnixStr)
+	if err != nil {
+		log.LogErrorf("assumeRoleHandler: encode session token fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	log.LogInfof("Audit: assume role: requestID(%v) remote(%v) caller(%v) role(%v) session(%v) duration(%v)",
+		GetRequestID(r), getRequestIP(r), caller.UserID, roleArn(ownerID, roleName), sessionName, durationSeconds)
+
+	// response result return
+	assumed := AssumeRoleResponse{
+		AssumeRoleResult: &AssumeRoleResult{
+			AssumedRoleUser: &AssumedRoleUser{
+				Arn:           fmt.Sprintf("arn:aws:sts::%s:assumed-role/%s/%s", role.OwnerID, role.RoleName, sessionName),
+				AssumedRoleId: fmt.Sprintf("%s:%s:%s", role.OwnerID, role.RoleName, sessionName),
+			},
+			Credentials: &FederatedCredentials{
+				AccessKeyId:     fedAk,
+				SecretAccessKey: fedSk,
+				SessionToken:    sessionToken,
+				Expiration:      now.Add(time.Duration(durationSeconds) * time.Second).Format(time.RFC3339),
+			},
+		},
+	}
+	assumed.ResponseMetadata.RequestID = GetRequestID(r)
+	response, err := MarshalXMLEntity(&assumed)
+	if err != nil {
+		log.LogErrorf("assumeRoleHandler: xml marshal result fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+
+	writeSuccessResponseXML(w, response)
+}
+
+// https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+// The request is not signed, the caller is authenticated by the web identity token issued by
+// the trusted OIDC issuers, and mapped to the CubeFS user or role by the claims of the token.
+func (o *ObjectNode) assumeRoleWithWebIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err error
+		erc *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, erc)
+	}()
+	if o.oidc == nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: no oidc issuer configured: requestID(%v)", GetRequestID(r))
+		erc = InvalidIdentityToken
+		return
+	}
+	sessionName := r.FormValue(stsRoleSessionNameKey)
+	matched, _ := regexp.MatchString(`^[\w+=,.@-]*$`, sessionName)
+	if len(sessionName) < 2 || len(sessionName) > 64 || !matched {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: sts session name invalid: requestID(%v) name(%v)",
+			GetRequestID(r), sessionName)
+		erc = InvalidArgument
+		return
+	}
+	reqRoleArn := r.FormValue(stsRoleArnKey)
+	if reqRoleArn != "" {
+		if _, _, err = ParseRoleArn(reqRoleArn); err != nil {
+			log.LogErrorf("assumeRoleWithWebIdentityHandler: sts role arn invalid: requestID(%v) roleArn(%v)",
+				GetRequestID(r), reqRoleArn)
+			return
+		}
+	}
+	sessionPolicy := r.FormValue(stsPolicyKey)
+	if sessionPolicy != "" {
+		if _, err = ParsePolicyV2Config(sessionPolicy); err != nil {
+			log.LogErrorf("assumeRoleWithWebIdentityHandler: sts session policy invalid: requestID(%v) policy(%v) err(%v)",
+				GetRequestID(r), sessionPolicy, err)
+			erc = &ErrorCode{
+				ErrorCode:    "MalformedPolicyDocument",
+				ErrorMessage: fmt.Sprintf("The policy document was malformed: %v.", err.Error()),
+				StatusCode:   http.StatusBadRequest,
+			}
+			return
+		}
+	}
+
+	claims, issuer, err := o.oidc.Verify(r.FormValue(stsWebIdentityTokenKey))
+	if err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: verify web identity token fail: requestID(%v) remote(%v) err(%v)",
+			GetRequestID(r), getRequestIP(r), err)
+		erc = InvalidIdentityToken
+		if err == errJWTExpired {
+			erc = ExpiredToken
+		}
+		return
+	}
+	mapping := issuer.Mapping(claims, reqRoleArn)
+	if mapping == nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: claims not mapped: requestID(%v) issuer(%v) sub(%v) groups(%v) roleArn(%v)",
+			GetRequestID(r), claims.Issuer, claims.Subject, claims.Groups, reqRoleArn)
+		erc = IDPRejectedClaim
+		return
+	}
+
+	var (
+		ownerID            = mapping.User
+		role               *proto.RoleInfo
+		maxSessionDuration = proto.RoleMaxSessionDuration
+	)
+	if mapping.RoleArn != "" {
+		var roleName string
+		ownerID, roleName, _ = ParseRoleArn(mapping.RoleArn)
+		// the role which does not exist is not distinguished from the role which is not trusted
+		if role, err = o.roleStore.GetRole(ownerID, roleName); err != nil {
+			log.LogErrorf("assumeRoleWithWebIdentityHandler: get role fail: requestID(%v) owner(%v) role(%v) err(%v)",
+				GetRequestID(r), ownerID, roleName, err)
+			if err == proto.ErrRoleNotExists {
+				err = AccessDenied
+			}
+			return
+		}
+		trustPolicy, err1 := ParseRoleTrustPolicy(role.TrustPolicy)
+		if err1 != nil || !trustPolicy.IsFederatedAllowed(stsAssumeRoleWithWebIdentityPermission, claims.Issuer) {
+			log.LogErrorf("assumeRoleWithWebIdentityHandler: role not trusted: requestID(%v) issuer(%v) owner(%v) role(%v) err(%v)",
+				GetRequestID(r), claims.Issuer, ownerID, roleName, err1)
+			err = AccessDenied
+			return
+		}
+		if _, err = ParsePolicyV2Config(role.PermissionPolicy); err != nil {
+			log.LogErrorf("assumeRoleWithWebIdentityHandler: role permission policy invalid: requestID(%v) owner(%v) role(%v) err(%v)",
+				GetRequestID(r), ownerID, roleName, err)
+			err = AccessDenied
+			return
+		}
+		maxSessionDuration = role.MaxSessionDuration
+	}
+
+	durationSeconds := proto.RoleDefaultSessionDuration
+	if seconds := r.FormValue(stsDurationSecondsKey); seconds != "" {
+		if durationSeconds, err = strconv.ParseInt(seconds, 10, 64); err != nil ||
+			durationSeconds < proto.RoleMinSessionDuration || durationSeconds > maxSessionDuration {
+			log.LogErrorf("assumeRoleWithWebIdentityHandler: sts duration invalid: requestID(%v) duration(%v) max(%v)",
+				GetRequestID(r), seconds, maxSessionDuration)
+			err = InvalidArgument
+			return
+		}
+	}
+	owner, err := o.mc.UserAPI().GetUserInfo(ownerID)
+	if err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: get user info fail: requestID(%v) user(%v) err(%v)",
+			GetRequestID(r), ownerID, err)
+		if err == proto.ErrUserNotExists {
+			err = AccessDenied
+		}
+		return
+	}
+
+	// session ak/sk generation, the session of the mapped user is the federation session of the user
+	now := time.Now().UTC()
+	expireUnixStr := fmt.Sprint(now.Unix() + durationSeconds)
+	fedAk := stsAkPrefix + util.RandomString(13, util.Numeric|util.LowerLetter|util.UpperLetter)
+	fedSk := util.RandomString(32, util.Numeric|util.LowerLetter|util.UpperLetter)
+	var (
+		sessionToken string
+		assumedUser  *AssumedRoleUser
+	)
+	if role != nil {
+		sessionToken, err = EncodeRoleSessionToken(owner.AccessKey, owner.SecretKey, fedAk, fedSk, sessionName,
+			role, sessionPolicy, expireUnixStr)
+		assumedUser = &AssumedRoleUser{
+			Arn:           fmt.Sprintf("arn:aws:sts::%s:assumed-role/%s/%s", role.OwnerID, role.RoleName, sessionName),
+			AssumedRoleId: fmt.Sprintf("%s:%s:%s", role.OwnerID, role.RoleName, sessionName),
+		}
+	} else {
+		policy := sessionPolicy
+		if policy == "" {
+			policy = oidcUserSessionPolicy
+		}
+		sessionToken, err = EncodeFedSessionToken(owner.AccessKey, owner.SecretKey, fedAk, fedSk, sessionName,
+			policy, expireUnixStr)
+		assumedUser = &AssumedRoleUser{
+			Arn:           fmt.Sprintf("arn:aws:sts::%s:federated-user/%s", owner.UserID, sessionName),
+			AssumedRoleId: fmt.Sprintf("%s:%s", owner.UserID, sessionName),
+		}
+	}
+	if err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: encode session token fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	log.LogInfof("Audit: assume role with web identity: requestID(%v) remote(%v) issuer(%v) sub(%v) user(%v) role(%v) session(%v) duration(%v)",
+		GetRequestID(r), getRequestIP(r), claims.Issuer, claims.Subject, ownerID, mapping.RoleArn, sessionName, durationSeconds)
+
+	// response result return
+	var audience string
+	if len(claims.Audience) > 0 {
+		audience = claims.Audience[0]
+	}
+	assumed := AssumeRoleWithWebIdentityResponse{
+		AssumeRoleWithWebIdentityResult: &AssumeRoleWithWebIdentityResult{
+			AssumedRoleUser: assumedUser,
+			Credentials: &FederatedCredentials{
+				AccessKeyId:     fedAk,
+				SecretAccessKey: fedSk,
+				SessionToken:    sessionToken,
+				Expiration:      now.Add(time.Duration(durationSeconds) * time.Second).Format(time.RFC3339),
+			},
+			SubjectFromWebIdentityToken: claims.Subject,
+			Audience:                    audience,
+			Provider:                    claims.Issuer,
+		},
+	}
+	assumed.ResponseMetadata.RequestID = GetRequestID(r)
+	response, err := MarshalXMLEntity(&assumed)
+	if err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: xml marshal result fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+
+	writeSuccessResponseXML(w, response)
+}
@@ -0,0 +1,599 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	stsAssumeRoleWithWebIdentityAction = "AssumeRoleWithWebIdentity"
+	stsWebIdentityTokenKey             = "WebIdentityToken"
+
+	stsAssumeRoleWithWebIdentityPermission = "sts:AssumeRoleWithWebIdentity"
+
+	oidcClaimSub    = "sub"
+	oidcClaimGroups = "groups"
+
+	oidcDefaultRefreshInterval = 3600 // seconds
+	oidcMinRefreshInterval     = 60   // seconds, the minimum interval of reloading on unknown key
+	oidcClockSkew              = 60 * time.Second
+	oidcMaxJWKSSize            = 1 << 20
+	oidcMaxTokenSize           = 16 << 10
+)
+
+// The permission policy of the sessions mapped to the users without the session policy,
+// the permissions of the sessions are still limited by the users themselves.
+const oidcUserSessionPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:*"],"Resource":["*"]}]}`
+
+var (
+	errJWTMalformed   = errors.New("malformed jwt")
+	errJWTAlgorithm   = errors.New("unsupported jwt algorithm")
+	errJWTKeyNotFound = errors.New("jwt signing key not found")
+	errJWTSignature   = errors.New("jwt signature not match")
+	errJWTIssuer      = errors.New("jwt issuer not trusted")
+	errJWTAudience    = errors.New("jwt audience not match")
+	errJWTExpired     = errors.New("jwt expired or not yet valid")
+)
+
+type AssumeRoleWithWebIdentityResponse struct {
+	XMLName                         *xml.Name                        `xml:"AssumeRoleWithWebIdentityResponse"`
+	AssumeRoleWithWebIdentityResult *AssumeRoleWithWebIdentityResult `xml:"AssumeRoleWithWebIdentityResult"`
+	ResponseMetadata                struct {
+		RequestID string `xml:"RequestId,omitempty"`
+	} `xml:"ResponseMetadata,omitempty"`
+}
+
+type AssumeRoleWithWebIdentityResult struct {
+	Credentials                 *FederatedCredentials `xml:"Credentials"`
+	AssumedRoleUser             *AssumedRoleUser      `xml:"AssumedRoleUser"`
+	SubjectFromWebIdentityToken string                `xml:"SubjectFromWebIdentityToken"`
+	Audience                    string                `xml:"Audience"`
+	Provider                    string                `xml:"Provider"`
+	PackedPolicySize            int                   `xml:",omitempty"`
+}
+
+// OIDCConfig is the configuration of the OpenID Connect issuers trusted by the
+// AssumeRoleWithWebIdentity, the JWKS of the issuers are reloaded every refreshInterval seconds.
+type OIDCConfig struct {
+	Issuers         []*OIDCIssuerConfig `json:"issuers"`
+	RefreshInterval int64               `json:"refreshInterval"`
+}
+
+type OIDCIssuerConfig struct {
+	Issuer    string   `json:"issuer"`
+	Audiences []string `json:"audiences"`
+	JWKSFile  string   `json:"jwksFile"`
+	JWKSURL   string   `json:"jwksUrl"`
+	// Mappings are evaluated in order, the first one matching the claims of the token is used.
+	Mappings []*OIDCClaimMapping `json:"mappings"`
+}
+
+// OIDCClaimMapping maps the tokens whose claim matches the value to the CubeFS user or role,
+// the value supports the wildcards '*' and '?'.
+type OIDCClaimMapping struct {
+	Claim   string `json:"claim"`
+	Value   string `json:"value"`
+	User    string `json:"user,omitempty"`
+	RoleArn string `json:"roleArn,omitempty"`
+}
+
+func (m *OIDCClaimMapping) validate() error {
+	if m.Claim != oidcClaimSub && m.Claim != oidcClaimGroups {
+		return fmt.Errorf("unsupported claim %v", m.Claim)
+	}
+	if m.Value == "" {
+		return errors.New("empty claim value")
+	}
+	if (m.User == "") == (m.RoleArn == "") {
+		return errors.New("exactly one of user and roleArn is required")
+	}
+	if m.RoleArn != "" {
+		if _, _, err := ParseRoleArn(m.RoleArn); err != nil {
+			return fmt.Errorf("invalid roleArn %v", m.RoleArn)
+		}
+	}
+	return nil
+}
+
+func (m *OIDCClaimMapping) match(claims *JWTClaims) bool {
+	switch m.Claim {
+	case oidcClaimSub:
+		return Match(m.Value, claims.Subject)
+	case oidcClaimGroups:
+		for _, group := range claims.Groups {
+			if Match(m.Value, group) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// JWTClaims is the registered claims of the web identity token used by the STS.
+type JWTClaims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	Groups    []string
+	ExpiresAt int64
+	NotBefore int64
+}
+
+func (c *JWTClaims) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Iss    string      `json:"iss"`
+		Sub    string      `json:"sub"`
+		Aud    interface{} `json:"aud"`
+		Groups interface{} `json:"groups"`
+		Exp    json.Number `json:"exp"`
+		Nbf    json.Number `json:"nbf"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	c.Issuer, c.Subject = raw.Iss, raw.Sub
+	c.Audience, _ = stringOrSlice(raw.Aud)
+	c.Groups, _ = stringOrSlice(raw.Groups)
+	var err error
+	if c.ExpiresAt, err = numericDate(raw.Exp); err != nil {
+		return err
+	}
+	c.NotBefore, err = numericDate(raw.Nbf)
+	return err
+}
+
+func numericDate(n json.Number) (int64, error) {
+	if n == "" {
+		return 0, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return 0, err
+	}
+	return int64(f), nil
+}
+
+// JSONWebKey is the public key of the JWKS, the RSA and EC keys are supported.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *JSONWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil || len(n) == 0 {
+			return nil, fmt.Errorf("invalid rsa modulus of key %v", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid rsa exponent of key %v", k.Kid)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %v of key %v", k.Crv, k.Kid)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ec x of key %v", k.Kid)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ec y of key %v", k.Kid)
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("invalid ec point of key %v", k.Kid)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %v of key %v", k.Kty, k.Kid)
+	}
+}
+
+type jwkEntry struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// parseJWKS parses the JSON web key set, the keys which are not used for the signature or
+// not supported are skipped.
+func parseJWKS(data []byte) ([]*jwkEntry, error) {
+	var set struct {
+		Keys []*JSONWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	entries := make([]*jwkEntry, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			log.LogWarnf("parseJWKS: skip key: %v", err)
+			continue
+		}
+		entries = append(entries, &jwkEntry{kid: k.Kid, alg: k.Alg, key: key})
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no valid signing key")
+	}
+	return entries, nil
+}
+
+type jwtAlgorithm struct {
+	hash  crypto.Hash
+	isRSA bool
+	size  int // the size of the coordinate of ECDSA signatures
+}
+
+var jwtAlgorithms = map[string]jwtAlgorithm{
+	"RS256": {hash: crypto.SHA256, isRSA: true},
+	"RS384": {hash: crypto.SHA384, isRSA: true},
+	"RS512": {hash: crypto.SHA512, isRSA: true},
+	"ES256": {hash: crypto.SHA256, size: 32},
+	"ES384": {hash: crypto.SHA384, size: 48},
+	"ES512": {hash: crypto.SHA512, size: 66},
+}
+
+func (a jwtAlgorithm) accepts(key crypto.PublicKey) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return a.isRSA
+	case *ecdsa.PublicKey:
+		return !a.isRSA
+	default:
+		return false
+	}
+}
+
+func (a jwtAlgorithm) verify(key crypto.PublicKey, signed, signature []byte) bool {
+	h := a.hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	if a.isRSA {
+		rsaKey, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(rsaKey, a.hash, digest, signature) == nil
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok || len(signature) != 2*a.size || (ecKey.Curve.Params().BitSize+7)/8 != a.size {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:a.size])
+	s := new(big.Int).SetBytes(signature[a.size:])
+	return ecdsa.Verify(ecKey, digest, r, s)
+}
+
+// OIDCIssuer verifies the web identity tokens issued by the issuer.
+type OIDCIssuer struct {
+	conf   *OIDCIssuerConfig
+	client *http.Client
+
+	mutex    sync.RWMutex
+	keys     []*jwkEntry
+	loadTime time.Time
+}
+
+func NewOIDCIssuer(conf *OIDCIssuerConfig) (*OIDCIssuer, error) {
+	if conf.Issuer == "" {
+		return nil, errors.New("empty issuer")
+	}
+	if (conf.JWKSFile == "") == (conf.JWKSURL == "") {
+		return nil, fmt.Errorf("exactly one of jwksFile and jwksUrl is required by issuer %v", conf.Issuer)
+	}
+	// the tokens issued to the other clients of the issuer must be rejected
+	if len(conf.Audiences) == 0 {
+		return nil, fmt.Errorf("empty audiences of issuer %v", conf.Issuer)
+	}
+	if len(conf.Mappings) == 0 {
+		return nil, fmt.Errorf("empty mappings of issuer %v", conf.Issuer)
+	}
+	for _, mapping := range conf.Mappings {
+		if err := mapping.validate(); err != nil {
+			return nil, fmt.Errorf("invalid mapping of issuer %v: %v", conf.Issuer, err)
+		}
+	}
+	issuer := &OIDCIssuer{conf: conf, client: &http.Client{Timeout: 10 * time.Second}}
+	if err := issuer.loadKeys(); err != nil {
+		return nil, fmt.Errorf("load jwks of issuer %v fail: %v", conf.Issuer, err)
+	}
+	return issuer, nil
+}
+
+func (i *OIDCIssuer) readJWKS() ([]byte, error) {
+	if i.conf.JWKSFile != "" {
+		return os.ReadFile(i.conf.JWKSFile)
+	}
+	resp, err := i.client.Get(i.conf.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, oidcMaxJWKSSize))
+}
+
+func (i *OIDCIssuer) loadKeys() error {
+	data, err := i.readJWKS()
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+	i.mutex.Lock()
+	i.keys, i.loadTime = keys, time.Now()
+	i.mutex.Unlock()
+	return nil
+}
+
+func (i *OIDCIssuer) findKey(kid, alg string) crypto.PublicKey {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	var found crypto.PublicKey
+	for _, entry := range i.keys {
+		if (entry.alg != "" && entry.alg != alg) || !jwtAlgorithms[alg].accepts(entry.key) {
+			continue
+		}
+		if kid != "" && entry.kid == kid {
+			return entry.key
+		}
+		// the token without the key ID is accepted only if the key is unambiguous
+		if kid == "" {
+			if found != nil {
+				return nil
+			}
+			found = entry.key
+		}
+	}
+	return found
+}
+
+// lookupKey finds the key of the token, the JWKS is reloaded on the unknown key ID since
+// the keys may be rotated by the issuer, but not more than once per minute.
+func (i *OIDCIssuer) lookupKey(kid, alg string) crypto.PublicKey {
+	if key := i.findKey(kid, alg); key != nil {
+		return key
+	}
+	// the reloading time is taken before reloading, so that the concurrent requests with
+	// the unknown key ID do not reload the JWKS at the same time
+	i.mutex.Lock()
+	reloadable := time.Since(i.loadTime) > oidcMinRefreshInterval*time.Second
+	if reloadable {
+		i.loadTime = time.Now()
+	}
+	i.mutex.Unlock()
+	if !reloadable {
+		return nil
+	}
+	if err := i.loadKeys(); err != nil {
+		log.LogWarnf("OIDCIssuer: reload jwks fail: issuer(%v) err(%v)", i.conf.Issuer, err)
+		return nil
+	}
+	return i.findKey(kid, alg)
+}
+
+// Verify verifies the signature and the claims of the token.
+func (i *OIDCIssuer) Verify(header *jwtHeader, claims *JWTClaims, signed, signature []byte, now time.Time) error {
+	algorithm, ok := jwtAlgorithms[header.Alg]
+	if !ok {
+		return errJWTAlgorithm
+	}
+	key := i.lookupKey(header.Kid, header.Alg)
+	if key == nil {
+		return errJWTKeyNotFound
+	}
+	if !algorithm.verify(key, signed, signature) {
+		return errJWTSignature
+	}
+	if claims.Issuer != i.conf.Issuer {
+		return errJWTIssuer
+	}
+	if !audienceMatch(i.conf.Audiences, claims.Audience) {
+		return errJWTAudience
+	}
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(oidcClockSkew)) {
+		return errJWTExpired
+	}
+	if claims.NotBefore != 0 && now.Add(oidcClockSkew).Before(time.Unix(claims.NotBefore, 0)) {
+		return errJWTExpired
+	}
+	return nil
+}
+
+func audienceMatch(allowed, audiences []string) bool {
+	for _, aud := range audiences {
+		for _, a := range allowed {
+			if aud == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Mapping returns the first mapping matching the claims, only the mappings of the role are
+// matched if the role ARN is specified.
+func (i *OIDCIssuer) Mapping(claims *JWTClaims, roleArn string) *OIDCClaimMapping {
+	for _, mapping := range i.conf.Mappings {
+		if roleArn != "" && mapping.RoleArn != roleArn {
+			continue
+		}
+		if mapping.match(claims) {
+			return mapping
+		}
+	}
+	return nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// parseJWT splits the compact serialized JWT, the signature is not verified.
+func parseJWT(token string) (header *jwtHeader, claims *JWTClaims, signed, signature []byte, err error) {
+	if len(token) > oidcMaxTokenSize {
+		err = errJWTMalformed
+		return
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		err = errJWTMalformed
+		return
+	}
+	var headerData, claimsData []byte
+	if headerData, err = base64.RawURLEncoding.DecodeString(parts[0]); err != nil {
+		err = errJWTMalformed
+		return
+	}
+	if claimsData, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		err = errJWTMalformed
+		return
+	}
+	if signature, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		err = errJWTMalformed
+		return
+	}
+	header, claims = new(jwtHeader), new(JWTClaims)
+	if err = json.Unmarshal(headerData, header); err != nil {
+		err = errJWTMalformed
+		return
+	}
+	if err = json.Unmarshal(claimsData, claims); err != nil {
+		err = errJWTMalformed
+		return
+	}
+	signed = []byte(parts[0] + "." + parts[1])
+	return
+}
+
+// OIDCProvider holds the trusted issuers of the web identity tokens.
+type OIDCProvider struct {
+	issuers  map[string]*OIDCIssuer
+	stopC    chan struct{}
+	interval time.Duration
+}
+
+func NewOIDCProvider(conf OIDCConfig) (*OIDCProvider, error) {
+	if len(conf.Issuers) == 0 {
+		return nil, errors.New("empty issuers")
+	}
+	if conf.RefreshInterval == 0 {
+		conf.RefreshInterval = oidcDefaultRefreshInterval
+	}
+	if conf.RefreshInterval < oidcMinRefreshInterval {
+		return nil, fmt.Errorf("refreshInterval must be at least %v", oidcMinRefreshInterval)
+	}
+	p := &OIDCProvider{
+		issuers:  make(map[string]*OIDCIssuer),
+		stopC:    make(chan struct{}),
+		interval: time.Duration(conf.RefreshInterval) * time.Second,
+	}
+	for _, issuerConf := range conf.Issuers {
+		if _, exist := p.issuers[issuerConf.Issuer]; exist {
+			return nil, fmt.Errorf("duplicate issuer %v", issuerConf.Issuer)
+		}
+		issuer, err := NewOIDCIssuer(issuerConf)
+		if err != nil {
+			return nil, err
+		}
+		p.issuers[issuerConf.Issuer] = issuer
+	}
+	return p, nil
+}
+
+// Start reloads the JWKS of the issuers periodically, the keys loaded last time are kept
+// if the reloading fails.
+func (p *OIDCProvider) Start() {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stopC:
+				return
+			case <-ticker.C:
+				for _, issuer := range p.issuers {
+					if err := issuer.loadKeys(); err != nil {
+						log.LogWarnf("OIDCProvider: reload jwks fail: issuer(%v) err(%v)", issuer.conf.Issuer, err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+func (p *OIDCProvider) Close() {
+	close(p.stopC)
+}
+
+// Verify verifies the web identity token and returns the claims and the issuer of it.
+func (p *OIDCProvider) Verify(token string) (*JWTClaims, *OIDCIssuer, error) {
+	header, claims, signed, signature, err := parseJWT(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	issuer, ok := p.issuers[claims.Issuer]
+	if !ok {
+		return nil, nil, errJWTIssuer
+	}
+	if err = issuer.Verify(header, claims, signed, signature, time.Now()); err != nil {
+		return nil, nil, err
+	}
+	return claims, issuer, nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testOIDCIssuer = "https://sso.example.com"
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signTestJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := b64(header) + "." + b64(payload)
+	algorithm := jwtAlgorithms[alg]
+	h := algorithm.hash.New()
+	h.Write([]byte(signed))
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, algorithm.hash, h.Sum(nil))
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, h.Sum(nil))
+		require.NoError(t, err)
+		signature = make([]byte, 2*algorithm.size)
+		r.FillBytes(signature[:algorithm.size])
+		s.FillBytes(signature[algorithm.size:])
+	}
+	return signed + "." + b64(signature)
+}
+
+func testJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) []byte {
+	data, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes())},
+		{"kty": "RSA", "kid": "enc1", "use": "enc", "n": b64(rsaKey.N.Bytes()), "e": "AQAB"},
+		{"kty": "oct", "kid": "hmac1", "k": "c2VjcmV0"},
+	}})
+	require.NoError(t, err)
+	return data
+}
+
+func TestOIDCProviderVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, testJWKS(t, rsaKey, ecKey), 0o600))
+
+	provider, err := NewOIDCProvider(OIDCConfig{Issuers: []*OIDCIssuerConfig{{
+		Issuer:    testOIDCIssuer,
+		Audiences: []string{"cubefs"},
+		JWKSFile:  jwksFile,
+		Mappings:  []*OIDCClaimMapping{{Claim: oidcClaimSub, Value: "*", User: "ltptest"}},
+	}}})
+	require.NoError(t, err)
+	issuer := provider.issuers[testOIDCIssuer]
+	require.Len(t, issuer.keys, 2)
+
+	now := time.Now().Unix()
+	claims := func(modify func(c map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss": testOIDCIssuer, "sub": "alice", "aud": []string{"other", "cubefs"},
+			"groups": []string{"dev", "ops"}, "exp": now + 600, "nbf": now - 10,
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+
+	parsed, _, err := provider.Verify(signTestJWT(t, "RS256", "rsa1", rsaKey, claims(nil)))
+	require.NoError(t, err)
+	require.Equal(t, "alice", parsed.Subject)
+	require.Equal(t, []string{"dev", "ops"}, parsed.Groups)
+	_, _, err = provider.Verify(signTestJWT(t, "RS512", "rsa1", rsaKey, claims(func(c map[string]interface{}) {
+		c["aud"] = "cubefs"
+	})))
+	require.NoError(t, err)
+	_, _, err = provider.Verify(signTestJWT(t, "ES256", "ec1", ecKey, claims(nil)))
+	require.NoError(t, err)
+
+	tests := []struct {
+		token string
+		err   error
+	}{
+		{"", errJWTMalformed},
+		{"a.b", errJWTMalformed},
+		{signTestJWT(t, "RS256", "rsa1", rsaKey, claims(func(c map[string]interface{}) {
+			c["iss"] = "https://evil.example.com"
+		})), errJWTIssuer},
+		{signTestJWT(t, "RS256", "rsa1", rsaKey, claims(func(c map[string]interface{}) {
+			c["aud"] = "other"
+		})), errJWTAudience},
+		{signTestJWT(t, "RS256", "rsa1", rsaKey, claims(func(c map[string]interface{}) {
+			delete(c, "aud")
+		})), errJWTAudience},
+		{signTestJWT(t, "RS256", "rsa1", rsaKey, claims(func(c map[string]interface{}) {
+			c["exp"] = now - 3600
+		})), errJWTExpired},
+		{signTestJWT(t, "RS256", "rsa1", rsaKey, claims(func(c map[string]interface{}) {
+			delete(c, "exp")
+		})), errJWTExpired},
+		{signTestJWT(t, "RS256", "rsa1", rsaKey, claims(func(c map[string]interface{}) {
+			c["nbf"] = now + 3600
+		})), errJWTExpired},
+		// the key is selected by the key ID, and must match the algorithm
+		{signTestJWT(t, "RS256", "ec1", rsaKey, claims(nil)), errJWTKeyNotFound},
+		{signTestJWT(t, "ES256", "rsa1", ecKey, claims(nil)), errJWTKeyNotFound},
+		{signTestJWT(t, "ES256", "ec1", rsaKey, claims(nil)), errJWTSignature},
+		{signTestJWT(t, "RS256", "enc1", rsaKey, claims(nil)), errJWTKeyNotFound},
+		{signTestJWT(t, "RS256", "", rsaKey, claims(nil)), nil},
+	}
+	for i, tt := range tests {
+		_, _, err = provider.Verify(tt.token)
+		require.Equal(t, tt.err, err, i)
+	}
+
+	// the signature of the tampered token does not match
+	token := signTestJWT(t, "RS256", "rsa1", rsaKey, claims(nil))
+	parts := strings.Split(token, ".")
+	payload, _ := json.Marshal(claims(func(c map[string]interface{}) { c["sub"] = "root" }))
+	_, _, err = provider.Verify(parts[0] + "." + b64(payload) + "." + parts[2])
+	require.Equal(t, errJWTSignature, err)
+
+	// the unsigned token is never accepted
+	header, _ := json.Marshal(map[string]string{"alg": "none"})
+	_, _, err = provider.Verify(b64(header) + "." + parts[1] + ".")
+	require.Equal(t, errJWTAlgorithm, err)
+}
+
+func TestOIDCJWKSFromURL(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwks := testJWKS(t, rsaKey, ecKey)
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(jwks)
+	}))
+	defer server.Close()
+
+	conf := &OIDCIssuerConfig{
+		Issuer:   testOIDCIssuer,
+		JWKSURL:  server.URL,
+		Mappings: []*OIDCClaimMapping{{Claim: oidcClaimSub, Value: "*", User: "ltptest"}},
+	}
+	// the audiences are required
+	_, err = NewOIDCIssuer(conf)
+	require.Error(t, err)
+	require.Equal(t, 0, requests)
+
+	conf.Audiences = []string{"cubefs"}
+	issuer, err := NewOIDCIssuer(conf)
+	require.NoError(t, err)
+	require.Equal(t, 1, requests)
+	require.NotNil(t, issuer.lookupKey("rsa1", "RS256"))
+	// the unknown key is not reloaded again within the minimum interval
+	require.Nil(t, issuer.lookupKey("rsa2", "RS256"))
+	require.Equal(t, 1, requests)
+	issuer.loadTime = time.Now().Add(-time.Hour)
+	require.Nil(t, issuer.lookupKey("rsa2", "RS256"))
+	require.Equal(t, 2, requests)
+}
+
+func TestOIDCClaimMapping(t *testing.T) {
+	issuer := &OIDCIssuer{conf: &OIDCIssuerConfig{Mappings: []*OIDCClaimMapping{
+		{Claim: oidcClaimGroups, Value: "admins", RoleArn: "arn:aws:iam::ltptest:role/admin"},
+		{Claim: oidcClaimGroups, Value: "dev-*", RoleArn: "arn:aws:iam::ltptest:role/dev"},
+		{Claim: oidcClaimSub, Value: "svc-?", User: "ltptest"},
+	}}}
+	tests := []struct {
+		sub     string
+		groups  []string
+		roleArn string
+		mapped  string
+	}{
+		{"alice", []string{"admins", "dev-a"}, "", "arn:aws:iam::ltptest:role/admin"},
+		{"alice", []string{"admins", "dev-a"}, "arn:aws:iam::ltptest:role/dev", "arn:aws:iam::ltptest:role/dev"},
+		{"alice", []string{"dev-b"}, "arn:aws:iam::ltptest:role/admin", ""},
+		{"svc-1", nil, "", "ltptest"},
+		{"svc-10", nil, "", ""},
+		{"svc-1", nil, "arn:aws:iam::ltptest:role/admin", ""},
+		{"bob", []string{"ops"}, "", ""},
+	}
+	for i, tt := range tests {
+		mapping := issuer.Mapping(&JWTClaims{Subject: tt.sub, Groups: tt.groups}, tt.roleArn)
+		if tt.mapped == "" {
+			require.Nil(t, mapping, i)
+			continue
+		}
+		require.NotNil(t, mapping, i)
+		require.Equal(t, tt.mapped, mapping.User+mapping.RoleArn, i)
+	}
+}
+
+func TestOIDCConfigInvalid(t *testing.T) {
+	mappings := []*OIDCClaimMapping{{Claim: oidcClaimSub, Value: "*", User: "ltptest"}}
+	for i, conf := range []OIDCConfig{
+		{},
+		{Issuers: []*OIDCIssuerConfig{{JWKSFile: "jwks.json", Mappings: mappings}}},
+		{Issuers: []*OIDCIssuerConfig{{Issuer: testOIDCIssuer, Mappings: mappings}}},
+		{Issuers: []*OIDCIssuerConfig{{Issuer: testOIDCIssuer, JWKSFile: "jwks.json", JWKSURL: "http://jwks", Mappings: mappings}}},
+		{Issuers: []*OIDCIssuerConfig{{Issuer: testOIDCIssuer, JWKSFile: "jwks.json"}}},
+		{Issuers: []*OIDCIssuerConfig{{Issuer: testOIDCIssuer, JWKSFile: "jwks.json",
+			Mappings: []*OIDCClaimMapping{{Claim: "email", Value: "*", User: "ltptest"}}}}},
+		{Issuers: []*OIDCIssuerConfig{{Issuer: testOIDCIssuer, JWKSFile: "jwks.json",
+			Mappings: []*OIDCClaimMapping{{Claim: oidcClaimSub, Value: "*", User: "ltptest", RoleArn: "arn:aws:iam::ltptest:role/a"}}}}},
+		{Issuers: []*OIDCIssuerConfig{{Issuer: testOIDCIssuer, JWKSFile: "jwks.json",
+			Mappings: []*OIDCClaimMapping{{Claim: oidcClaimSub, Value: "*", RoleArn: "ltptest"}}}}},
+		{RefreshInterval: 10, Issuers: []*OIDCIssuerConfig{{Issuer: testOIDCIssuer, JWKSFile: "jwks.json", Mappings: mappings}}},
+		// the jwks file does not exist
+		{Issuers: []*OIDCIssuerConfig{{Issuer: testOIDCIssuer, JWKSFile: filepath.Join(t.TempDir(), "jwks.json"), Mappings: mappings}}},
+	} {
+		_, err := NewOIDCProvider(conf)
+		require.Error(t, err, i)
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/gorilla/mux"
+)
+
+const (
+	stsAssumeRoleAction   = "AssumeRole"
+	stsRoleArnKey         = "RoleArn"
+	stsRoleSessionNameKey = "RoleSessionName"
+
+	stsAssumeRolePermission = "sts:AssumeRole"
+	stsPrincipalAWS         = "AWS"
+	stsPrincipalFederated   = "Federated"
+	stsMaxFormSize          = 64 << 10
+
+	iamRoleArnPrefix = "arn:aws:iam::"
+
+	// the sessions of the role deleted or updated are rejected within the interval
+	roleCacheTTL = 10 * time.Second
+)
+
+type AssumeRoleResponse struct {
+	XMLName          *xml.Name         `xml:"AssumeRoleResponse"`
+	AssumeRoleResult *AssumeRoleResult `xml:"AssumeRoleResult"`
+	ResponseMetadata struct {
+		RequestID string `xml:"RequestId,omitempty"`
+	} `xml:"ResponseMetadata,omitempty"`
+}
+
+type AssumeRoleResult struct {
+	Credentials      *FederatedCredentials `xml:"Credentials"`
+	AssumedRoleUser  *AssumedRoleUser      `xml:"AssumedRoleUser"`
+	PackedPolicySize int                   `xml:",omitempty"`
+}
+
+type AssumedRoleUser struct {
+	Arn           string `xml:"Arn"`
+	AssumedRoleId string `xml:"AssumedRoleId"`
+}
+
+// ParseRoleArn parses the owner and the name of the role from the ARN,
+// which is in the format of "arn:aws:iam::<owner>:role/<name>".
+func ParseRoleArn(arn string) (ownerID, roleName string, err error) {
+	if !strings.HasPrefix(arn, iamRoleArnPrefix) {
+		return "", "", InvalidArgument
+	}
+	parts := strings.SplitN(strings.TrimPrefix(arn, iamRoleArnPrefix), ":role/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.Contains(parts[1], "/") {
+		return "", "", InvalidArgument
+	}
+	return parts[0], parts[1], nil
+}
+
+func roleArn(ownerID, roleName string) string {
+	return fmt.Sprintf("%s%s:role/%s", iamRoleArnPrefix, ownerID, roleName)
+}
+
+// RoleTrustPolicy is the trust policy of the role, which specifies the principals
+// who can assume the role.
+type RoleTrustPolicy struct {
+	Version    string           `json:"Version"`
+	Statements []TrustStatement `json:"Statement"`
+}
+
+type TrustStatement struct {
+	Sid       string      `json:"Sid,omitempty"`
+	Effect    string      `json:"Effect"`
+	Principal interface{} `json:"Principal"`
+	Action    interface{} `json:"Action"`
+}
+
+func ParseRoleTrustPolicy(data string) (*RoleTrustPolicy, error) {
+	policy := new(RoleTrustPolicy)
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(policy); err != nil {
+		return nil, err
+	}
+	if policy.Version != defaultPolicyVersion {
+		return nil, errors.New("invalid version expecting 2012-10-17")
+	}
+	if len(policy.Statements) == 0 {
+		return nil, errors.New("statement cannot be empty")
+	}
+	for _, stmt := range policy.Statements {
+		if stmt.Effect != Allow && stmt.Effect != Deny {
+			return nil, errors.New("invalid effect")
+		}
+		if _, ok := stmt.principals(stsPrincipalAWS); !ok {
+			return nil, errors.New("invalid principal")
+		}
+		actions, ok := stringOrSlice(stmt.Action)
+		if !ok {
+			return nil, errors.New("invalid action")
+		}
+		for _, action := range actions {
+			if action != "*" && !strings.HasPrefix(action, "sts:") {
+				return nil, errors.New("invalid action")
+			}
+		}
+	}
+	return policy, nil
+}
+
+// IsAllowed checks whether the principal is allowed to perform the action on the role,
+// the explicit deny takes precedence over the allow.
+func (p *RoleTrustPolicy) IsAllowed(action, uid string) bool {
+	return p.isAllowed(action, func(stmt *TrustStatement) bool {
+		return stmt.matchPrincipal(uid)
+	})
+}
+
+// IsFederatedAllowed checks whether the identities of the OIDC issuer are allowed to perform
+// the action on the role.
+func (p *RoleTrustPolicy) IsFederatedAllowed(action, issuer string) bool {
+	return p.isAllowed(action, func(stmt *TrustStatement) bool {
+		return stmt.matchFederated(issuer)
+	})
+}
+
+func (p *RoleTrustPolicy) isAllowed(action string, matchPrincipal func(stmt *TrustStatement) bool) bool {
+	var allow bool
+	for i := range p.Statements {
+		stmt := &p.Statements[i]
+		if stmt.matchAction(action) && matchPrincipal(stmt) {
+			if stmt.Effect != Allow {
+				return false
+			}
+			allow = true
+		}
+	}
+	return allow
+}
+
+func (s *TrustStatement) matchAction(action string) bool {
+	actions, _ := stringOrSlice(s.Action)
+	for _, act := range actions {
+		if act == "*" || act == "sts:*" || act == action {
+			return true
+		}
+	}
+	return false
+}
+
+// principals returns the principals of the kind ("AWS" or "Federated") of the statement,
+// the principal "*" is represented by the principal list of "*".
+func (s *TrustStatement) principals(kind string) ([]string, bool) {
+	switch principal := s.Principal.(type) {
+	case string:
+		return []string{principal}, principal == "*"
+	case map[string]interface{}:
+		if len(principal) == 0 {
+			return nil, false
+		}
+		var values []string
+		for k, v := range principal {
+			if k != stsPrincipalAWS && k != stsPrincipalFederated {
+				return nil, false
+			}
+			vs, ok := stringOrSlice(v)
+			if !ok {
+				return nil, false
+			}
+			if k == kind {
+				values = vs
+			}
+		}
+		return values, true
+	default:
+		return nil, false
+	}
+}
+
+func (s *TrustStatement) matchPrincipal(uid string) bool {
+	principals, _ := s.principals(stsPrincipalAWS)
+	for _, principal := range principals {
+		if principal == "*" || principal == uid || principal == iamRoleArnPrefix+uid+":root" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TrustStatement) matchFederated(issuer string) bool {
+	principals, _ := s.principals(stsPrincipalFederated)
+	for _, principal := range principals {
+		if principal == "*" || principal == issuer {
+			return true
+		}
+	}
+	return false
+}
+
+func stringOrSlice(v interface{}) ([]string, bool) {
+	switch value := v.(type) {
+	case string:
+		return []string{value}, value != ""
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v1 := range value {
+			v2, ok := v1.(string)
+			if !ok || v2 == "" {
+				return nil, false
+			}
+			values = append(values, v2)
+		}
+		return values, len(values) > 0
+	default:
+		return nil, false
+	}
+}
+
+// stsActionMatcher matches the STS request by the action, which is in the query or the form
+// body. The form body is restored after peeking so that it can be verified by the signature.
+func stsActionMatcher(action string) mux.MatcherFunc {
+	return func(r *http.Request, _ *mux.RouteMatch) bool {
+		if r.URL.Query().Get(stsActionKey) == action {
+			return true
+		}
+		if r.Body == nil || !strings.HasPrefix(r.Header.Get(ContentType), "application/x-www-form-urlencoded") {
+			return false
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, stsMaxFormSize))
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		if err != nil {
+			return false
+		}
+		values, err := url.ParseQuery(string(body))
+		return err == nil && values.Get(stsActionKey) == action
+	}
+}
+
+type cachedRole struct {
+	role   *proto.RoleInfo
+	err    error
+	expire time.Time
+}
+
+// RoleStore gets the roles from the master, the roles of the sessions are cached for a short
+// while so that the role is not got by every request of the sessions.
+type RoleStore struct {
+	mc          *master.MasterClient
+	clientIDKey string
+	roles       sync.Map // mapping: owner/role -> *cachedRole
+}
+
+func NewRoleStore(mc *master.MasterClient, clientIDKey string) *RoleStore {
+	return &RoleStore{mc: mc, clientIDKey: clientIDKey}
+}
+
+// GetRole gets the current role from the master, which refreshes the cached one.
+func (s *RoleStore) GetRole(ownerID, roleName string) (role *proto.RoleInfo, err error) {
+	role, err = s.mc.UserAPI().GetRole(ownerID, roleName, s.clientIDKey)
+	// the errors of the master are not cached, the role is checked again by the next request
+	if err == nil || err == proto.ErrRoleNotExists {
+		s.roles.Store(ownerID+"/"+roleName, &cachedRole{role: role, err: err, expire: time.Now().Add(roleCacheTTL)})
+	}
+	return
+}
+
+// CheckSession checks the role of the session is not deleted or updated since the session was issued.
+func (s *RoleStore) CheckSession(ownerID, roleName string, version uint64) (err error) {
+	var role *proto.RoleInfo
+	if value, ok := s.roles.Load(ownerID + "/" + roleName); ok && time.Now().Before(value.(*cachedRole).expire) {
+		role, err = value.(*cachedRole).role, value.(*cachedRole).err
+	} else {
+		role, err = s.GetRole(ownerID, roleName)
+	}
+	if err == proto.ErrRoleNotExists || (err == nil && role.Version != version) {
+		return InvalidToken
+	}
+	return
+}
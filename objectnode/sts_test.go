@@ -18,6 +18,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -55,6 +57,117 @@ func TestEncodeDecodeFedSessionToken(t *testing.T) {
 	require.Equal(t, &policy, fed.Policy)
 }
 
+func TestEncodeDecodeRoleSessionToken(t *testing.T) {
+	fedAk := stsAkPrefix + util.RandomString(13, util.Numeric|util.LowerLetter|util.UpperLetter)
+	fedSk := util.RandomString(32, util.Numeric|util.LowerLetter|util.UpperLetter)
+	expireUnixStr := fmt.Sprint(time.Now().UTC().Unix() + 3600)
+	rolePolicy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject","s3:PutObject"],"Resource":"arn:aws:s3:::bucket/*"}]}`
+	sessionPolicy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"arn:aws:s3:::bucket/public/*"}]}`
+
+	role := &proto.RoleInfo{RoleName: "reader", OwnerID: "test", PermissionPolicy: rolePolicy, Version: 3}
+
+	token, err := EncodeRoleSessionToken(testOwnerAK, testOwnerSK, fedAk, fedSk, "session", role, sessionPolicy, expireUnixStr)
+	require.NoError(t, err)
+	fed, err := DecodeFedSessionToken(fedAk, token, testGetUserInfo)
+	require.NoError(t, err)
+	require.Equal(t, fedSk, fed.FedSK)
+	require.Equal(t, "reader", fed.RoleName)
+	require.Equal(t, uint64(3), fed.RoleVersion)
+	require.NotNil(t, fed.SessionPolicy)
+	// the effective permissions are the intersection of the policies
+	require.True(t, fed.IsAllow("s3:GetObject", "bucket", "public/a.txt"))
+	require.True(t, fed.IsAllow("s3:PutObject", "bucket", "public/a.txt"))
+	require.False(t, fed.IsAllow("s3:GetObject", "bucket", "private/a.txt"))
+	require.False(t, fed.IsAllow("s3:DeleteObject", "bucket", "public/a.txt"))
+
+	// the role policy takes effect alone without the session policy
+	token, err = EncodeRoleSessionToken(testOwnerAK, testOwnerSK, fedAk, fedSk, "session", role, "", expireUnixStr)
+	require.NoError(t, err)
+	fed, err = DecodeFedSessionToken(fedAk, token, testGetUserInfo)
+	require.NoError(t, err)
+	require.Nil(t, fed.SessionPolicy)
+	require.True(t, fed.IsAllow("s3:GetObject", "bucket", "private/a.txt"))
+	require.False(t, fed.IsAllow("s3:DeleteObject", "bucket", "private/a.txt"))
+
+	// the session of the role without the version is never trusted
+	token, err = encodeSessionToken(testOwnerAK, testOwnerSK, fedAk, fedSk, "session", rolePolicy, expireUnixStr, sessionPolicy)
+	require.NoError(t, err)
+	_, err = DecodeFedSessionToken(fedAk, token, testGetUserInfo)
+	require.Equal(t, InvalidToken, err)
+}
+
+func TestParseRoleArn(t *testing.T) {
+	owner, name, err := ParseRoleArn("arn:aws:iam::test:role/reader")
+	require.NoError(t, err)
+	require.Equal(t, "test", owner)
+	require.Equal(t, "reader", name)
+	require.Equal(t, "arn:aws:iam::test:role/reader", roleArn(owner, name))
+
+	for _, arn := range []string{"", "reader", "arn:aws:iam::test:user/reader", "arn:aws:iam:::role/reader",
+		"arn:aws:iam::test:role/", "arn:aws:iam::test:role/path/reader", "arn:aws:s3:::test:role/reader"} {
+		_, _, err = ParseRoleArn(arn)
+		require.Equal(t, InvalidArgument, err, arn)
+	}
+}
+
+func TestRoleTrustPolicy(t *testing.T) {
+	policy, err := ParseRoleTrustPolicy(`{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":{"AWS":["alice","arn:aws:iam::bob:root"]},"Action":"sts:AssumeRole"},
+		{"Effect":"Deny","Principal":{"AWS":"bob"},"Action":"sts:*"}]}`)
+	require.NoError(t, err)
+	require.True(t, policy.IsAllowed(stsAssumeRolePermission, "alice"))
+	require.False(t, policy.IsAllowed(stsAssumeRolePermission, "bob"))
+	require.False(t, policy.IsAllowed(stsAssumeRolePermission, "carol"))
+
+	policy, err = ParseRoleTrustPolicy(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"*"}]}`)
+	require.NoError(t, err)
+	require.True(t, policy.IsAllowed(stsAssumeRolePermission, "carol"))
+
+	policy, err = ParseRoleTrustPolicy(`{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":{"Federated":"https://sso.example.com"},"Action":"sts:AssumeRoleWithWebIdentity"},
+		{"Effect":"Allow","Principal":{"AWS":"alice"},"Action":"sts:AssumeRole"}]}`)
+	require.NoError(t, err)
+	require.True(t, policy.IsFederatedAllowed(stsAssumeRoleWithWebIdentityPermission, "https://sso.example.com"))
+	require.False(t, policy.IsFederatedAllowed(stsAssumeRoleWithWebIdentityPermission, "https://evil.example.com"))
+	require.False(t, policy.IsFederatedAllowed(stsAssumeRolePermission, "https://sso.example.com"))
+	require.False(t, policy.IsAllowed(stsAssumeRoleWithWebIdentityPermission, "alice"))
+	require.True(t, policy.IsAllowed(stsAssumeRolePermission, "alice"))
+
+	for _, data := range []string{
+		`{"Version":"2012-10-17","Statement":[]}`,
+		`{"Version":"2008-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"sts:AssumeRole"}]}`,
+		`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"alice","Action":"sts:AssumeRole"}]}`,
+		`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"x"},"Action":"sts:AssumeRole"}]}`,
+		`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject"}]}`,
+		`{"Version":"2012-10-17","Statement":[{"Effect":"Maybe","Principal":"*","Action":"sts:AssumeRole"}]}`,
+		`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"sts:AssumeRole","Resource":"*"}]}`,
+	} {
+		_, err = ParseRoleTrustPolicy(data)
+		require.Error(t, err, data)
+	}
+}
+
+func TestStsActionMatcher(t *testing.T) {
+	matcher := stsActionMatcher(stsAssumeRoleAction)
+	body := "Action=AssumeRole&RoleArn=arn%3Aaws%3Aiam%3A%3Atest%3Arole%2Freader&RoleSessionName=session"
+	r, err := http.NewRequest(http.MethodPost, "http://s3.local/", strings.NewReader(body))
+	require.NoError(t, err)
+	r.Header.Set(ContentType, "application/x-www-form-urlencoded")
+	require.True(t, matcher(r, nil))
+	// the body is restored for the signature and the handler
+	require.Equal(t, "session", r.PostFormValue(stsRoleSessionNameKey))
+
+	r, err = http.NewRequest(http.MethodPost, "http://s3.local/", strings.NewReader("Action=GetFederationToken"))
+	require.NoError(t, err)
+	r.Header.Set(ContentType, "application/x-www-form-urlencoded")
+	require.False(t, matcher(r, nil))
+	require.Equal(t, stsActionValue, r.PostFormValue(stsActionKey))
+
+	r, err = http.NewRequest(http.MethodPost, "http://s3.local/?Action=AssumeRole", nil)
+	require.NoError(t, err)
+	require.True(t, matcher(r, nil))
+}
+
 func testGetUserInfo(ak string) (*proto.UserInfo, error) {
 	if ak != testOwnerAK {
 		return nil, errors.New("wrong access key")
@@ -0,0 +1,132 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/Versioning.html
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"sort"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/google/uuid"
+)
+
+const (
+	VersioningEnabled   = "Enabled"
+	VersioningSuspended = "Suspended"
+	MFADeleteDisabled   = "Disabled"
+
+	// NullVersionId is the version id of the objects put while versioning is not enabled.
+	NullVersionId = "null"
+
+	MaxVersioningSize = 1 << 10 // 1KB
+)
+
+type VersioningConfiguration struct {
+	XMLNS     string   `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName   xml.Name `xml:"VersioningConfiguration" json:"-"`
+	Status    string   `xml:"Status,omitempty" json:"status,omitempty"`
+	MFADelete string   `xml:"MfaDelete,omitempty" json:"mfa_delete,omitempty"`
+}
+
+func (c *VersioningConfiguration) Enabled() bool {
+	return c != nil && c.Status == VersioningEnabled
+}
+
+func (c *VersioningConfiguration) Suspended() bool {
+	return c != nil && c.Status == VersioningSuspended
+}
+
+// Configured returns true if versioning has ever been enabled on the bucket.
+// Once enabled, versioning can only be suspended and never returns to unversioned.
+func (c *VersioningConfiguration) Configured() bool {
+	return c.Enabled() || c.Suspended()
+}
+
+func ParseVersioningConfig(data []byte) (*VersioningConfiguration, *ErrorCode) {
+	config := &VersioningConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	if config.Status != VersioningEnabled && config.Status != VersioningSuspended {
+		return nil, IllegalVersioningConfiguration
+	}
+	// MFA delete needs the device serial of the root account, which is not available here.
+	if config.MFADelete != "" && config.MFADelete != MFADeleteDisabled {
+		return nil, IllegalVersioningConfiguration
+	}
+	return config, nil
+}
+
+func storeBucketVersioning(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSVersioning, bytes)
+}
+
+func newVersionId() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// ObjectVersion is a non-current version or a delete marker of an object key, which is
+// shared with the lcnode to remove the expired delete markers.
+type ObjectVersion = proto.ObjectVersion
+
+// ObjectVersions is the non-current version list of an object key, the newest first.
+type ObjectVersions []*ObjectVersion
+
+func ParseObjectVersions(raw []byte) (versions ObjectVersions, err error) {
+	if len(raw) == 0 {
+		return
+	}
+	err = json.Unmarshal(raw, &versions)
+	return
+}
+
+func (vs ObjectVersions) Encode() string {
+	data, _ := json.Marshal(vs)
+	return string(data)
+}
+
+func (vs ObjectVersions) Find(versionId string) (int, *ObjectVersion) {
+	for i, version := range vs {
+		if version.VersionId == versionId {
+			return i, version
+		}
+	}
+	return -1, nil
+}
+
+// Insert inserts the version in the order of the modify time, so that the versions archived
+// concurrently are kept newest first.
+func (vs ObjectVersions) Insert(version *ObjectVersion) ObjectVersions {
+	index := sort.Search(len(vs), func(i int) bool { return vs[i].ModifyTime <= version.ModifyTime })
+	vs = append(vs, nil)
+	copy(vs[index+1:], vs[index:])
+	vs[index] = version
+	return vs
+}
+
+func (vs ObjectVersions) Remove(index int) ObjectVersions {
+	if index < 0 || index >= len(vs) {
+		return vs
+	}
+	return append(vs[:index:index], vs[index+1:]...)
+}
+
+func versionsXAttrKey(name string) string {
+	return XAttrKeyOSSVersions + name
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersioningConfig(t *testing.T) {
+	tests := []struct {
+		value       string
+		status      string
+		expectedErr *ErrorCode
+	}{
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Enabled</Status>
+					</VersioningConfiguration>`,
+			status: VersioningEnabled,
+		},
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Suspended</Status>
+						<MfaDelete>Disabled</MfaDelete>
+					</VersioningConfiguration>`,
+			status: VersioningSuspended,
+		},
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>enabled</Status>
+					</VersioningConfiguration>`,
+			expectedErr: IllegalVersioningConfiguration,
+		},
+		{
+			value:       `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></VersioningConfiguration>`,
+			expectedErr: IllegalVersioningConfiguration,
+		},
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Enabled</Status>
+						<MfaDelete>Enabled</MfaDelete>
+					</VersioningConfiguration>`,
+			expectedErr: IllegalVersioningConfiguration,
+		},
+		{
+			value:       `<VersioningConfiguration><Status>Enabled</Status>`,
+			expectedErr: MalformedXML,
+		},
+	}
+	for _, tt := range tests {
+		config, errCode := ParseVersioningConfig([]byte(tt.value))
+		require.Equal(t, tt.expectedErr, errCode)
+		if errCode == nil {
+			require.Equal(t, tt.status, config.Status)
+			require.True(t, config.Configured())
+		}
+	}
+
+	var config *VersioningConfiguration
+	require.False(t, config.Configured())
+	require.False(t, config.Enabled())
+}
+
+func TestObjectVersions(t *testing.T) {
+	versions := ObjectVersions{
+		{VersionId: "v3", DeleteMarker: true, ModifyTime: 3},
+		{VersionId: "v2", Inode: 12, ETag: "etag2", Size: 2, ModifyTime: 2},
+		{VersionId: NullVersionId, Inode: 11, ETag: "etag1", Size: 1, ModifyTime: 1},
+	}
+
+	parsed, err := ParseObjectVersions([]byte(versions.Encode()))
+	require.NoError(t, err)
+	require.Equal(t, versions, parsed)
+
+	index, version := parsed.Find("v2")
+	require.Equal(t, 1, index)
+	require.Equal(t, uint64(12), version.Inode)
+	index, version = parsed.Find("v4")
+	require.Equal(t, -1, index)
+	require.Nil(t, version)
+
+	removed := parsed.Remove(1)
+	require.Len(t, removed, 2)
+	require.Equal(t, "v3", removed[0].VersionId)
+	require.Equal(t, NullVersionId, removed[1].VersionId)
+	// the origin list is not modified
+	require.Len(t, parsed, 3)
+	require.Equal(t, "v2", parsed[1].VersionId)
+	require.Len(t, parsed.Remove(3), 3)
+
+	// the versions archived concurrently are kept newest first
+	inserted := removed.Insert(&ObjectVersion{VersionId: "v2", ModifyTime: 2})
+	inserted = inserted.Insert(&ObjectVersion{VersionId: "v4", ModifyTime: 4})
+	inserted = inserted.Insert(&ObjectVersion{VersionId: "v0", ModifyTime: 0})
+	ids := make([]string, 0, len(inserted))
+	for _, version := range inserted {
+		ids = append(ids, version.VersionId)
+	}
+	require.Equal(t, []string{"v4", "v3", "v2", NullVersionId, "v0"}, ids)
+
+	parsed, err = ParseObjectVersions(nil)
+	require.NoError(t, err)
+	require.Len(t, parsed, 0)
+}
+
+func TestNewVersionId(t *testing.T) {
+	id1, id2 := newVersionId(), newVersionId()
+	require.Len(t, id1, 32)
+	require.NotEqual(t, id1, id2)
+	require.False(t, strings.Contains(id1, "-"))
+}
+
+func TestListVersionsResultMarshal(t *testing.T) {
+	result := &ListVersionsResult{
+		Name:    "bucket",
+		MaxKeys: 1000,
+		Versions: []*VersionContent{
+			{Key: "a", VersionId: "v2", IsLatest: false, ETag: "\"etag\"", Size: 1, StorageClass: StorageClassStandard},
+		},
+		DeleteMarkers: []*DeleteMarkerEntry{
+			{Key: "a", VersionId: "v3", IsLatest: true},
+		},
+	}
+	data, err := MarshalXMLEntity(result)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "<ListVersionsResult>")
+	require.Contains(t, string(data), "<Version><Key>a</Key><VersionId>v2</VersionId><IsLatest>false</IsLatest>")
+	require.Contains(t, string(data), "<DeleteMarker><Key>a</Key><VersionId>v3</VersionId><IsLatest>true</IsLatest>")
+}
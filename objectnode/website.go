@@ -0,0 +1,235 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/WebsiteHosting.html
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxWebsiteConfigSize   = 128 << 10 // 128KB
+	MaxWebsiteRoutingRules = 50
+
+	defaultWebsiteRedirectCode = http.StatusMovedPermanently
+)
+
+type WebsiteConfiguration struct {
+	XMLNS                 string                `xml:"xmlns,attr,omitempty"`
+	XMLName               xml.Name              `xml:"WebsiteConfiguration"`
+	ErrorDocument         *WebsiteErrorDocument `xml:"ErrorDocument,omitempty"`
+	IndexDocument         *WebsiteIndexDocument `xml:"IndexDocument,omitempty"`
+	RedirectAllRequestsTo *WebsiteRedirectAll   `xml:"RedirectAllRequestsTo,omitempty"`
+	RoutingRules          []*WebsiteRoutingRule `xml:"RoutingRules>RoutingRule,omitempty"`
+}
+
+type WebsiteErrorDocument struct {
+	Key string `xml:"Key"`
+}
+
+type WebsiteIndexDocument struct {
+	Suffix string `xml:"Suffix"`
+}
+
+type WebsiteRedirectAll struct {
+	HostName string `xml:"HostName"`
+	Protocol string `xml:"Protocol,omitempty"`
+}
+
+type WebsiteRoutingRule struct {
+	Condition *WebsiteCondition `xml:"Condition,omitempty"`
+	Redirect  *WebsiteRedirect  `xml:"Redirect"`
+}
+
+type WebsiteCondition struct {
+	HttpErrorCodeReturnedEquals string `xml:"HttpErrorCodeReturnedEquals,omitempty"`
+	KeyPrefixEquals             string `xml:"KeyPrefixEquals,omitempty"`
+}
+
+type WebsiteRedirect struct {
+	HostName             string  `xml:"HostName,omitempty"`
+	HttpRedirectCode     string  `xml:"HttpRedirectCode,omitempty"`
+	Protocol             string  `xml:"Protocol,omitempty"`
+	ReplaceKeyPrefixWith *string `xml:"ReplaceKeyPrefixWith,omitempty"`
+	ReplaceKeyWith       string  `xml:"ReplaceKeyWith,omitempty"`
+}
+
+func ParseWebsiteConfig(data []byte) (*WebsiteConfiguration, *ErrorCode) {
+	config := &WebsiteConfiguration{}
+	if err := xml.Unmarshal(data, config); err != nil {
+		return nil, MalformedXML
+	}
+	if errCode := config.validate(); errCode != nil {
+		return nil, errCode
+	}
+	return config, nil
+}
+
+func invalidWebsiteArgument(message string) *ErrorCode {
+	return NewError("InvalidArgument", message, http.StatusBadRequest)
+}
+
+func validWebsiteProtocol(protocol string) bool {
+	return protocol == "" || protocol == "http" || protocol == "https"
+}
+
+func (c *WebsiteConfiguration) validate() *ErrorCode {
+	if c.RedirectAllRequestsTo != nil {
+		if c.IndexDocument != nil || c.ErrorDocument != nil || len(c.RoutingRules) > 0 {
+			return invalidWebsiteArgument("RedirectAllRequestsTo cannot be provided in conjunction with other Routing/Redirection rules.")
+		}
+		if c.RedirectAllRequestsTo.HostName == "" {
+			return invalidWebsiteArgument("A host name must be provided in RedirectAllRequestsTo.")
+		}
+		if !validWebsiteProtocol(c.RedirectAllRequestsTo.Protocol) {
+			return invalidWebsiteArgument("Invalid protocol, protocol can be http or https.")
+		}
+		return nil
+	}
+	if c.IndexDocument == nil {
+		return invalidWebsiteArgument("A value for IndexDocument Suffix must be provided if RedirectAllRequestsTo is empty.")
+	}
+	if suffix := c.IndexDocument.Suffix; suffix == "" || strings.Contains(suffix, "/") {
+		return invalidWebsiteArgument("The IndexDocument Suffix is not well formed.")
+	}
+	if c.ErrorDocument != nil && c.ErrorDocument.Key == "" {
+		return invalidWebsiteArgument("The ErrorDocument Key is not well formed.")
+	}
+	if len(c.RoutingRules) > MaxWebsiteRoutingRules {
+		return invalidWebsiteArgument("The number of routing rules must not exceed the allowed limit of 50 rules.")
+	}
+	for _, rule := range c.RoutingRules {
+		if errCode := rule.validate(); errCode != nil {
+			return errCode
+		}
+	}
+	return nil
+}
+
+func (r *WebsiteRoutingRule) validate() *ErrorCode {
+	if r.Redirect == nil {
+		return invalidWebsiteArgument("A Redirect must be provided in the routing rule.")
+	}
+	if c := r.Condition; c != nil && c.HttpErrorCodeReturnedEquals != "" {
+		code, err := strconv.Atoi(c.HttpErrorCodeReturnedEquals)
+		if err != nil || code < 400 || code > 599 {
+			return invalidWebsiteArgument("The provided HTTP error code is not valid. Valid codes are 4XX or 5XX.")
+		}
+	}
+	redirect := r.Redirect
+	if redirect.ReplaceKeyWith != "" && redirect.ReplaceKeyPrefixWith != nil {
+		return invalidWebsiteArgument("You can only define ReplaceKeyPrefix or ReplaceKey but not both.")
+	}
+	if redirect.HttpRedirectCode != "" {
+		code, err := strconv.Atoi(redirect.HttpRedirectCode)
+		if err != nil || code < 300 || code > 399 {
+			return invalidWebsiteArgument("The provided HTTP redirect code is not valid. It should be a string containing a number in the 3XX range.")
+		}
+	}
+	if !validWebsiteProtocol(redirect.Protocol) {
+		return invalidWebsiteArgument("Invalid protocol, protocol can be http or https.")
+	}
+	return nil
+}
+
+// match checks the condition of the routing rule, the status code is 0 if the rule is
+// checked before the object is retrieved.
+func (r *WebsiteRoutingRule) match(key string, statusCode int) bool {
+	c := r.Condition
+	if c == nil {
+		return statusCode == 0
+	}
+	if c.HttpErrorCodeReturnedEquals == "" {
+		if statusCode != 0 {
+			return false
+		}
+	} else if c.HttpErrorCodeReturnedEquals != strconv.Itoa(statusCode) {
+		return false
+	}
+	return strings.HasPrefix(key, c.KeyPrefixEquals)
+}
+
+// location returns the location to redirect the request of the key to.
+func (r *WebsiteRoutingRule) location(req *http.Request, key string) (location string, code int) {
+	redirect := r.Redirect
+	switch {
+	case redirect.ReplaceKeyWith != "":
+		key = redirect.ReplaceKeyWith
+	case redirect.ReplaceKeyPrefixWith != nil:
+		var prefix string
+		if r.Condition != nil {
+			prefix = r.Condition.KeyPrefixEquals
+		}
+		key = *redirect.ReplaceKeyPrefixWith + strings.TrimPrefix(key, prefix)
+	}
+	code = defaultWebsiteRedirectCode
+	if redirect.HttpRedirectCode != "" {
+		code, _ = strconv.Atoi(redirect.HttpRedirectCode)
+	}
+	return websiteLocation(req, redirect.Protocol, redirect.HostName, key), code
+}
+
+// Redirect returns the location which the request of the key is redirected to by the
+// routing rules, the status code is 0 if the rules are checked before the object is
+// retrieved, otherwise it is the error status code of the request.
+func (c *WebsiteConfiguration) Redirect(req *http.Request, key string, statusCode int) (location string, code int) {
+	if c.RedirectAllRequestsTo != nil {
+		if statusCode != 0 {
+			return "", 0
+		}
+		redirect := c.RedirectAllRequestsTo
+		return websiteLocation(req, redirect.Protocol, redirect.HostName, key), defaultWebsiteRedirectCode
+	}
+	for _, rule := range c.RoutingRules {
+		if rule.match(key, statusCode) {
+			return rule.location(req, key)
+		}
+	}
+	return "", 0
+}
+
+// IndexKey returns the key of the index document if the key is a directory.
+func (c *WebsiteConfiguration) IndexKey(key string) (string, bool) {
+	if c.IndexDocument == nil || (key != "" && !strings.HasSuffix(key, "/")) {
+		return key, false
+	}
+	return key + c.IndexDocument.Suffix, true
+}
+
+func websiteLocation(req *http.Request, protocol, host, key string) string {
+	if protocol == "" {
+		protocol = "http"
+		if req.TLS != nil {
+			protocol = "https"
+		}
+	}
+	if host == "" {
+		host = req.Host
+	}
+	return (&url.URL{Scheme: protocol, Host: host, Path: "/" + key}).String()
+}
+
+func storeBucketWebsite(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSWebsite, bytes)
+}
+
+func deleteBucketWebsite(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSWebsite)
+}
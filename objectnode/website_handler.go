@@ -0,0 +1,300 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/cubefs/cubefs/util/log"
+
+	"github.com/gorilla/mux"
+)
+
+// Get bucket website
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketWebsite.html
+func (o *ObjectNode) getBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketWebsiteHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *WebsiteConfiguration
+	if config, err = vol.metaLoader.loadWebsite(); err != nil {
+		log.LogErrorf("getBucketWebsiteHandler: load website fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config == nil {
+		errorCode = NoSuchWebsiteConfiguration
+		return
+	}
+	output := *config
+	output.XMLNS = S3Namespace
+	var data []byte
+	if data, err = MarshalXMLEntity(&output); err != nil {
+		log.LogErrorf("getBucketWebsiteHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), output, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// Put bucket website
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketWebsite.html
+func (o *ObjectNode) putBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketWebsiteHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxWebsiteConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketWebsiteHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxWebsiteConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	if requestMD5 := r.Header.Get(ContentMD5); requestMD5 != "" && requestMD5 != GetMD5(body) {
+		errorCode = InvalidDigest
+		return
+	}
+	var config *WebsiteConfiguration
+	if config, errorCode = ParseWebsiteConfig(body); errorCode != nil {
+		log.LogErrorf("putBucketWebsiteHandler: parse website config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+	if err = storeBucketWebsite(body, vol); err != nil {
+		log.LogErrorf("putBucketWebsiteHandler: store website config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeWebsite(config)
+	log.LogInfof("Audit: put bucket website: requestID(%v) remote(%v) volume(%v) config(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), string(body))
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Delete bucket website
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketWebsite.html
+func (o *ObjectNode) deleteBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketWebsiteHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if err = deleteBucketWebsite(vol); err != nil {
+		log.LogErrorf("deleteBucketWebsiteHandler: delete website config fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	vol.metaLoader.storeWebsite(nil)
+	log.LogInfof("Audit: delete bucket website: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WebsiteMiddleware returns a middleware handler to serve the requests of the website endpoints.
+// The requested key is redirected by the routing rules or resolved to the index document before
+// the policy check, so that the bucket policy and ACL are checked against the object served.
+func (o *ObjectNode) websiteMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, is := o.websiteWildcards.Parse(r.Host); !is {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		param := ParseRequestParam(r)
+		vol, err := o.getVol(param.Bucket())
+		if err != nil {
+			log.LogErrorf("websiteMiddleware: load volume fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), param.Bucket(), err)
+			o.errorResponse(w, r, err, nil)
+			return
+		}
+		config, err := vol.metaLoader.loadWebsite()
+		if err != nil {
+			log.LogErrorf("websiteMiddleware: load website fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			o.errorResponse(w, r, err, nil)
+			return
+		}
+		if config == nil {
+			o.errorResponse(w, r, nil, NoSuchWebsiteConfiguration)
+			return
+		}
+
+		// the query parameters of the S3 api are not available on the website endpoints
+		r.URL.RawQuery = ""
+		key := param.Object()
+		SetWebsiteKey(r, key)
+		if location, code := config.Redirect(r, key, 0); location != "" {
+			websiteRedirect(w, r, location, code)
+			return
+		}
+		target, isIndex := config.IndexKey(key)
+		if !isIndex && isWebsiteDirectory(vol, key) {
+			// the directory without the trailing slash is redirected to its index document
+			websiteRedirect(w, r, websiteLocation(r, "", "", key+"/"), http.StatusFound)
+			return
+		}
+		mux.Vars(r)[ContextKeyObject] = target
+		log.LogDebugf("websiteMiddleware: resolve website key: requestID(%v) volume(%v) key(%v) target(%v)",
+			GetRequestID(r), vol.Name(), key, target)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isWebsiteDirectory checks whether the key is a directory of the volume, rather than an object.
+func isWebsiteDirectory(vol *Volume, key string) bool {
+	if key == "" {
+		return false
+	}
+	if _, _, _, _, err := vol.recursiveLookupTarget(key, false); err == nil {
+		return false
+	}
+	_, _, _, mode, err := vol.recursiveLookupTarget(key+"/", false)
+	return err == nil && mode.IsDir()
+}
+
+func websiteRedirect(w http.ResponseWriter, r *http.Request, location string, code int) {
+	SetResponseStatusCode(r, strconv.Itoa(code))
+	http.Redirect(w, r, location, code)
+}
+
+// websiteErrorResponse serves the error of the website request by the routing rules or the
+// error document of the bucket, false is returned if it is not a website request or the error
+// is not handled by the website configuration.
+func (o *ObjectNode) websiteErrorResponse(w http.ResponseWriter, r *http.Request, ec *ErrorCode) bool {
+	key, is := GetWebsiteKey(r)
+	if !is {
+		return false
+	}
+	param := ParseRequestParam(r)
+	vol, err := o.getVol(param.Bucket())
+	if err != nil {
+		return false
+	}
+	config, err := vol.metaLoader.loadWebsite()
+	if err != nil || config == nil {
+		return false
+	}
+	if location, code := config.Redirect(r, key, ec.StatusCode); location != "" {
+		websiteRedirect(w, r, location, code)
+		return true
+	}
+	if config.ErrorDocument == nil || ec.StatusCode < http.StatusBadRequest || ec.StatusCode >= http.StatusInternalServerError {
+		return false
+	}
+
+	// the error document is served only if the requester is allowed to read it
+	vars := mux.Vars(r)
+	object := vars[ContextKeyObject]
+	vars[ContextKeyObject] = config.ErrorDocument.Key
+	allowed, _, _ := o.checkPolicy(r)
+	vars[ContextKeyObject] = object
+	if !allowed {
+		return false
+	}
+	return o.serveWebsiteErrorDocument(w, r, vol, config.ErrorDocument.Key, ec)
+}
+
+func (o *ObjectNode) serveWebsiteErrorDocument(w http.ResponseWriter, r *http.Request, vol *Volume, key string, ec *ErrorCode) bool {
+	info, _, err := vol.ObjectMeta(key)
+	if err != nil || info.Mode.IsDir() {
+		log.LogWarnf("serveWebsiteErrorDocument: load error document fail: requestID(%v) volume(%v) key(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, err)
+		return false
+	}
+	if err = o.openObjectEncryption(make(http.Header), info.Encryption, false); err != nil {
+		log.LogWarnf("serveWebsiteErrorDocument: open error document encryption fail: requestID(%v) volume(%v) key(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, err)
+		return false
+	}
+
+	SetResponseStatusCode(r, strconv.Itoa(ec.StatusCode))
+	SetResponseErrorMessage(r, ec.ErrorMessage)
+	if info.MIMEType != "" {
+		w.Header().Set(ContentType, info.MIMEType)
+	}
+	w.Header().Set(ContentLength, strconv.FormatInt(info.Size, 10))
+	w.WriteHeader(ec.StatusCode)
+	if r.Method == http.MethodHead {
+		return true
+	}
+
+	var writer io.Writer = w
+	if info.Encryption != nil {
+		writer = info.Encryption.DecryptWriter(writer, 0)
+	}
+	size := uint64(info.Size)
+	if err = vol.readFile(info.Inode, size, key, writer, 0, size, info.StorageClass); err != nil {
+		log.LogErrorf("serveWebsiteErrorDocument: read error document fail: requestID(%v) volume(%v) key(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, err)
+	}
+	return true
+}
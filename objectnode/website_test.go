@@ -0,0 +1,164 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWebsiteConfig(t *testing.T) {
+	valid := `<WebsiteConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+	<IndexDocument><Suffix>index.html</Suffix></IndexDocument>
+	<ErrorDocument><Key>error.html</Key></ErrorDocument>
+	<RoutingRules>
+		<RoutingRule>
+			<Condition><KeyPrefixEquals>docs/</KeyPrefixEquals></Condition>
+			<Redirect><ReplaceKeyPrefixWith>documents/</ReplaceKeyPrefixWith></Redirect>
+		</RoutingRule>
+		<RoutingRule>
+			<Condition><HttpErrorCodeReturnedEquals>404</HttpErrorCodeReturnedEquals></Condition>
+			<Redirect><HostName>example.com</HostName><HttpRedirectCode>302</HttpRedirectCode></Redirect>
+		</RoutingRule>
+	</RoutingRules>
+</WebsiteConfiguration>`
+	config, errCode := ParseWebsiteConfig([]byte(valid))
+	require.Nil(t, errCode)
+	require.Equal(t, "index.html", config.IndexDocument.Suffix)
+	require.Equal(t, "error.html", config.ErrorDocument.Key)
+	require.Len(t, config.RoutingRules, 2)
+	require.Equal(t, "documents/", *config.RoutingRules[0].Redirect.ReplaceKeyPrefixWith)
+
+	redirectAll := `<WebsiteConfiguration><RedirectAllRequestsTo><HostName>example.com</HostName>
+		<Protocol>https</Protocol></RedirectAllRequestsTo></WebsiteConfiguration>`
+	_, errCode = ParseWebsiteConfig([]byte(redirectAll))
+	require.Nil(t, errCode)
+
+	index := "<IndexDocument><Suffix>index.html</Suffix></IndexDocument>"
+	rule := func(rule string) string {
+		return "<WebsiteConfiguration>" + index + "<RoutingRules><RoutingRule>" + rule +
+			"</RoutingRule></RoutingRules></WebsiteConfiguration>"
+	}
+	cases := []string{
+		"<WebsiteConfiguration>",
+		"<WebsiteConfiguration></WebsiteConfiguration>",
+		"<WebsiteConfiguration><IndexDocument><Suffix></Suffix></IndexDocument></WebsiteConfiguration>",
+		"<WebsiteConfiguration><IndexDocument><Suffix>a/index.html</Suffix></IndexDocument></WebsiteConfiguration>",
+		"<WebsiteConfiguration>" + index + "<ErrorDocument><Key></Key></ErrorDocument></WebsiteConfiguration>",
+		"<WebsiteConfiguration>" + index + "<RedirectAllRequestsTo><HostName>example.com</HostName></RedirectAllRequestsTo></WebsiteConfiguration>",
+		"<WebsiteConfiguration><RedirectAllRequestsTo><HostName></HostName></RedirectAllRequestsTo></WebsiteConfiguration>",
+		"<WebsiteConfiguration><RedirectAllRequestsTo><HostName>example.com</HostName><Protocol>ftp</Protocol></RedirectAllRequestsTo></WebsiteConfiguration>",
+		rule("<Condition><KeyPrefixEquals>a</KeyPrefixEquals></Condition>"),
+		rule("<Condition><HttpErrorCodeReturnedEquals>200</HttpErrorCodeReturnedEquals></Condition><Redirect></Redirect>"),
+		rule("<Redirect><HttpRedirectCode>404</HttpRedirectCode></Redirect>"),
+		rule("<Redirect><ReplaceKeyWith>a</ReplaceKeyWith><ReplaceKeyPrefixWith>b</ReplaceKeyPrefixWith></Redirect>"),
+		rule("<Redirect><Protocol>ftp</Protocol></Redirect>"),
+	}
+	for _, c := range cases {
+		_, errCode = ParseWebsiteConfig([]byte(c))
+		require.NotNil(t, errCode, c)
+	}
+}
+
+func TestWebsiteRedirect(t *testing.T) {
+	prefix := "documents/"
+	empty := ""
+	config := &WebsiteConfiguration{
+		IndexDocument: &WebsiteIndexDocument{Suffix: "index.html"},
+		RoutingRules: []*WebsiteRoutingRule{
+			{
+				Condition: &WebsiteCondition{KeyPrefixEquals: "docs/"},
+				Redirect:  &WebsiteRedirect{ReplaceKeyPrefixWith: &prefix},
+			},
+			{
+				Condition: &WebsiteCondition{KeyPrefixEquals: "old/", HttpErrorCodeReturnedEquals: "404"},
+				Redirect:  &WebsiteRedirect{ReplaceKeyPrefixWith: &empty, Protocol: "https", HttpRedirectCode: "302"},
+			},
+			{
+				Condition: &WebsiteCondition{HttpErrorCodeReturnedEquals: "403"},
+				Redirect:  &WebsiteRedirect{HostName: "example.com", ReplaceKeyWith: "denied.html"},
+			},
+		},
+	}
+	req := httptest.NewRequest(http.MethodGet, "http://docs.website.cube.io/", nil)
+
+	location, code := config.Redirect(req, "docs/a b.html", 0)
+	require.Equal(t, "http://docs.website.cube.io/documents/a%20b.html", location)
+	require.Equal(t, http.StatusMovedPermanently, code)
+
+	location, _ = config.Redirect(req, "old/a.html", 0)
+	require.Equal(t, "", location)
+	location, code = config.Redirect(req, "old/a.html", http.StatusNotFound)
+	require.Equal(t, "https://docs.website.cube.io/a.html", location)
+	require.Equal(t, http.StatusFound, code)
+
+	location, _ = config.Redirect(req, "docs/a.html", http.StatusNotFound)
+	require.Equal(t, "", location)
+	location, code = config.Redirect(req, "docs/a.html", http.StatusForbidden)
+	require.Equal(t, "http://example.com/denied.html", location)
+	require.Equal(t, http.StatusMovedPermanently, code)
+
+	key, isIndex := config.IndexKey("")
+	require.True(t, isIndex)
+	require.Equal(t, "index.html", key)
+	key, isIndex = config.IndexKey("a/b/")
+	require.True(t, isIndex)
+	require.Equal(t, "a/b/index.html", key)
+	key, isIndex = config.IndexKey("a/b")
+	require.False(t, isIndex)
+	require.Equal(t, "a/b", key)
+
+	all := &WebsiteConfiguration{RedirectAllRequestsTo: &WebsiteRedirectAll{HostName: "example.com", Protocol: "https"}}
+	location, code = all.Redirect(req, "a.html", 0)
+	require.Equal(t, "https://example.com/a.html", location)
+	require.Equal(t, http.StatusMovedPermanently, code)
+	location, _ = all.Redirect(req, "a.html", http.StatusNotFound)
+	require.Equal(t, "", location)
+}
+
+func TestWebsiteRouters(t *testing.T) {
+	o := &ObjectNode{domains: []string{"cube.io"}, websiteDomains: []string{"website.cube.io"}}
+	router := mux.NewRouter().SkipClean(true)
+	o.registerApiRouters(router)
+
+	cases := []struct {
+		method string
+		url    string
+		action proto.Action
+		bucket string
+		object string
+	}{
+		{http.MethodGet, "http://docs.website.cube.io/", proto.OSSGetObjectAction, "docs", ""},
+		{http.MethodGet, "http://docs.website.cube.io/a/b/?acl", proto.OSSGetObjectAction, "docs", "a/b/"},
+		{http.MethodHead, "http://docs.website.cube.io:8080/a", proto.OSSHeadObjectAction, "docs", "a"},
+		{http.MethodPut, "http://docs.website.cube.io/a", proto.NoneAction, "docs", ""},
+		{http.MethodGet, "http://docs.cube.io/a?acl", proto.OSSGetObjectAclAction, "docs", "a"},
+		{http.MethodGet, "http://docs.cube.io/?website", proto.OSSGetBucketWebsiteAction, "docs", ""},
+		{http.MethodPut, "http://cube.io/docs?website", proto.OSSPutBucketWebsiteAction, "docs", ""},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.url, nil)
+		var match mux.RouteMatch
+		require.True(t, router.Match(req, &match), c.url)
+		require.Equal(t, c.action, ActionFromRouteName(match.Route.GetName()), c.url)
+		require.Equal(t, c.bucket, match.Vars[ContextKeyBucket], c.url)
+		require.Equal(t, c.object, match.Vars[ContextKeyObject], c.url)
+	}
+}
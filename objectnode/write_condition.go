@@ -0,0 +1,132 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// WriteCondition is the precondition of the conditional write, which is evaluated
+// atomically in the metanode when the new object is applied. If-None-Match is evaluated
+// by creating the dentry of the object key. If-Match is evaluated by the compare-and-set of
+// the ETag xattr of the current object, which also stops the object from being appended,
+// and then the dentry is updated only if it still refers to the object.
+// refer: https://docs.aws.amazon.com/AmazonS3/latest/userguide/conditional-writes.html
+type WriteCondition struct {
+	// IfNoneMatch is set with "If-None-Match: *", the object is written only if the key is absent.
+	IfNoneMatch bool
+	// IfMatch is the ETag of "If-Match", the object is overwritten only if the current ETag matches.
+	IfMatch string
+}
+
+// ParseWriteCondition parses the precondition headers of PutObject, CopyObject and
+// CompleteMultipartUpload, it returns nil if no precondition is specified.
+func ParseWriteCondition(r *http.Request) (*WriteCondition, *ErrorCode) {
+	ifNoneMatch := strings.TrimSpace(r.Header.Get(IfNoneMatch))
+	ifMatch := strings.TrimSpace(r.Header.Get(IfMatch))
+	if ifNoneMatch == "" && ifMatch == "" {
+		return nil, nil
+	}
+	if ifNoneMatch != "" && ifMatch != "" {
+		return nil, InvalidArgument
+	}
+	if ifNoneMatch != "" {
+		// only the wildcard is supported for the writes
+		if ifNoneMatch != "*" {
+			return nil, UnsupportedOperation
+		}
+		return &WriteCondition{IfNoneMatch: true}, nil
+	}
+	if ifMatch = strings.Trim(ifMatch, "\""); ifMatch == "" {
+		return nil, InvalidArgument
+	}
+	return &WriteCondition{IfMatch: ifMatch}, nil
+}
+
+// checkWriteCondition evaluates the condition against the current object of the key, inode is
+// the current object or zero if the key is absent. For If-Match, the ETag xattr of the object is
+// set to the ETag without the append state if it is still the one matched, so the ETag is not
+// changed by any appending afterwards, and it returns the inode which the dentry must still refer
+// to when it is updated.
+func (v *Volume) checkWriteCondition(cond *WriteCondition, inode uint64) (expectedInode uint64, err error) {
+	if cond == nil {
+		return
+	}
+	if cond.IfNoneMatch {
+		if inode != 0 {
+			err = PreconditionFailed
+		}
+		return
+	}
+	if inode == 0 {
+		err = NoSuchKey
+		return
+	}
+	var xattr *proto.XAttrInfo
+	if xattr, err = v.mw.XAttrGet_ll(inode, XAttrKeyOSSETag); err != nil {
+		log.LogErrorf("checkWriteCondition: get xattr fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+		err = writeConditionErr(cond, err)
+		return
+	}
+	raw := string(xattr.Get(XAttrKeyOSSETag))
+	etag := ParseETagValue(raw).ETag()
+	if raw == "" {
+		// the ETag of the object may be kept in the deprecated xattr
+		var current *ObjectVersion
+		if current, err = v.loadObjectVersion(inode); err != nil {
+			return
+		}
+		etag = current.ETag
+	}
+	if etag != cond.IfMatch {
+		log.LogDebugf("checkWriteCondition: etag mismatch: volume(%v) inode(%v) etag(%v) expected(%v)",
+			v.name, inode, etag, cond.IfMatch)
+		err = PreconditionFailed
+		return
+	}
+	sealed, _, _ := strings.Cut(raw, appendETagSeparator)
+	if err = v.mw.XAttrCompareAndSet_ll(inode, XAttrKeyOSSETag, []byte(raw), []byte(sealed)); err != nil {
+		log.LogWarnf("checkWriteCondition: compare and set etag fail: volume(%v) inode(%v) etag(%v) err(%v)",
+			v.name, inode, etag, err)
+		err = writeConditionErr(cond, err)
+		return
+	}
+	if sealed != raw {
+		updateAttrCache(inode, XAttrKeyOSSETag, sealed, v.name)
+	}
+	return inode, nil
+}
+
+// writeConditionErr converts the error of applying the dentry under the condition, the dentry is
+// created or changed concurrently after the condition was evaluated.
+func writeConditionErr(cond *WriteCondition, err error) error {
+	if cond == nil {
+		return err
+	}
+	switch {
+	case cond.IfNoneMatch && err == syscall.EEXIST:
+		return PreconditionFailed
+	case cond.IfMatch != "" && err == syscall.ESTALE:
+		return ConditionalRequestConflict
+	case cond.IfMatch != "" && err == syscall.ENOENT:
+		return NoSuchKey
+	}
+	return err
+}
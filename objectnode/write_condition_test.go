@@ -0,0 +1,62 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"errors"
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWriteCondition(t *testing.T) {
+	tests := []struct {
+		ifMatch     string
+		ifNoneMatch string
+		cond        *WriteCondition
+		errCode     *ErrorCode
+	}{
+		{"", "", nil, nil},
+		{"", "*", &WriteCondition{IfNoneMatch: true}, nil},
+		{`"d41d8cd98f00b204e9800998ecf8427e"`, "", &WriteCondition{IfMatch: "d41d8cd98f00b204e9800998ecf8427e"}, nil},
+		{"d41d8cd98f00b204e9800998ecf8427e-2", "", &WriteCondition{IfMatch: "d41d8cd98f00b204e9800998ecf8427e-2"}, nil},
+		{`""`, "", nil, InvalidArgument},
+		{"", `"d41d8cd98f00b204e9800998ecf8427e"`, nil, UnsupportedOperation},
+		{"etag", "*", nil, InvalidArgument},
+	}
+	for _, tt := range tests {
+		r, _ := http.NewRequest(http.MethodPut, "/bucket/key", nil)
+		if tt.ifMatch != "" {
+			r.Header.Set(IfMatch, tt.ifMatch)
+		}
+		if tt.ifNoneMatch != "" {
+			r.Header.Set(IfNoneMatch, tt.ifNoneMatch)
+		}
+		cond, errCode := ParseWriteCondition(r)
+		require.Equal(t, tt.errCode, errCode)
+		require.Equal(t, tt.cond, cond)
+	}
+}
+
+func TestWriteConditionErr(t *testing.T) {
+	other := errors.New("other")
+	require.Equal(t, syscall.EEXIST, writeConditionErr(nil, syscall.EEXIST))
+	require.Equal(t, PreconditionFailed, writeConditionErr(&WriteCondition{IfNoneMatch: true}, syscall.EEXIST))
+	require.Equal(t, other, writeConditionErr(&WriteCondition{IfNoneMatch: true}, other))
+	require.Equal(t, ConditionalRequestConflict, writeConditionErr(&WriteCondition{IfMatch: "etag"}, syscall.ESTALE))
+	require.Equal(t, NoSuchKey, writeConditionErr(&WriteCondition{IfMatch: "etag"}, syscall.ENOENT))
+}
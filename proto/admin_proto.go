@@ -104,6 +104,7 @@ const (
 	AdminAbortDecommissionDisk                        = "/admin/abortDecommissionDisk"
 	AdminResetDataPartitionRestoreStatus              = "/admin/resetDataPartitionRestoreStatus"
 	AdminGetOpLog                                     = "/admin/getOpLog"
+	AdminGetSpreadViolations                          = "/admin/getSpreadViolations"
 
 	// #nosec G101
 	AdminQueryDecommissionToken            = "/admin/queryDecommissionToken"
@@ -215,6 +216,11 @@ const (
 	RunMetaNodeBalanceTask             = "/metaNode/runBalanceTask"
 	StopMetaNodeBalanceTask            = "/metaNode/stopBalanceTask"
 	DeleteMetaNodeBalanceTask          = "/metaNode/deleteBalanceTask"
+	CreateDataNodeBalanceTask          = "/dataNode/createBalanceTask"
+	GetDataNodeBalanceTask             = "/dataNode/getBalanceTask"
+	RunDataNodeBalanceTask             = "/dataNode/runBalanceTask"
+	StopDataNodeBalanceTask            = "/dataNode/stopBalanceTask"
+	DeleteDataNodeBalanceTask          = "/dataNode/deleteBalanceTask"
 	OfflineMetaNode                    = "/metaNode/offline"
 	AdminUpdateDataNode                = "/dataNode/update"
 	AdminGetInvalidNodes               = "/invalid/nodes"
@@ -228,6 +234,7 @@ const (
 	AdminMetaPartitionCleanEmpty       = "/metaPartition/cleanEmpty"
 	AdminMetaPartitionRemoveBackup     = "/metaPartition/removeBackup"
 	AdminMetaPartitionGetCleanTask     = "/metaPartition/getCleanTask"
+	AdminSplitMetaPartition            = "/metaPartition/split"
 	AdminAddMetaReplica                = "/metaReplica/add"
 	AdminDeleteMetaReplica             = "/metaReplica/delete"
 	AdminPutDataPartitions             = "/dataPartitions/set"
@@ -245,7 +252,19 @@ const (
 	GetBucketLifecycle    = "/s3/getLifecycle"
 	DeleteBucketLifecycle = "/s3/deleteLifecycle"
 
-	AddLcNode = "/lcNode/add"
+	// S3 inventory configuration APIS
+	SetBucketInventory    = "/s3/setInventory"
+	GetBucketInventory    = "/s3/getInventory"
+	DeleteBucketInventory = "/s3/deleteInventory"
+
+	AddLcNode     = "/lcNode/add"
+	RestoreObject = "/lcNode/restoreObject"
+
+	// S3 batch operations APIS
+	BatchJobCreate       = "/s3/batch/create"
+	BatchJobGet          = "/s3/batch/get"
+	BatchJobList         = "/s3/batch/list"
+	BatchJobUpdateStatus = "/s3/batch/updateStatus"
 
 	QueryDisableDisk             = "/dataNode/queryDisableDisk"
 	QueryDecommissionSuccessDisk = "/dataNode/queryDecommissionSuccessDisk"
@@ -277,6 +296,18 @@ const (
 	UserTransferVol     = "/user/transferVol"
 	UserList            = "/user/list"
 	UsersOfVol          = "/vol/users"
+	UserRoleCreate      = "/user/role/create"
+	UserRoleUpdate      = "/user/role/update"
+	UserRoleDelete      = "/user/role/delete"
+	UserRoleGetInfo     = "/user/role/info"
+	UserRoleList        = "/user/role/list"
+	// APIs for the tokens of the admin APIs
+	AdminAPITokenCreate = "/admin/apiToken/create"
+	AdminAPITokenDelete = "/admin/apiToken/delete"
+	AdminAPITokenList   = "/admin/apiToken/list"
+	// APIs for the trust of the TLS between the nodes
+	AdminSetTLSTrust = "/admin/tlsTrust/set"
+	AdminGetTLSTrust = "/admin/tlsTrust/get"
 	// graphql api for header
 	HeadAuthorized  = "Authorization"
 	ParamAuthorized = "_authorization"
@@ -298,6 +329,7 @@ const (
 	S3QoSSet                     = "/s3/qos/set"
 	S3QoSGet                     = "/s3/qos/get"
 	S3QoSDelete                  = "/s3/qos/delete"
+	S3QoSLease                   = "/s3/qos/lease"
 	AdminEnablePersistAccessTime = "/vol/enablePersistAccessTime"
 
 	AdminVolAddAllowedStorageClass = "/vol/addAllowedStorageClass"
@@ -434,6 +466,16 @@ var GApiInfo map[string]string = map[string]string{
 	"usertransfervol":                 UserTransferVol,
 	"userlist":                        UserList,
 	"usersofvol":                      UsersOfVol,
+	"userrolecreate":                  UserRoleCreate,
+	"userroleupdate":                  UserRoleUpdate,
+	"userroledelete":                  UserRoleDelete,
+	"userrolegetinfo":                 UserRoleGetInfo,
+	"userrolelist":                    UserRoleList,
+	"adminapitokencreate":             AdminAPITokenCreate,
+	"adminapitokendelete":             AdminAPITokenDelete,
+	"adminapitokenlist":               AdminAPITokenList,
+	"adminsettlstrust":                AdminSetTLSTrust,
+	"admingettlstrust":                AdminGetTLSTrust,
 }
 
 const (
@@ -862,6 +904,14 @@ type HeartBeatRequest struct {
 	MetaNodeGOGC                   int
 	DataNodeGOGC                   int
 	FlashNodeHeartBeatInfos
+	TLSTrustInfo
+}
+
+// TLSTrustInfo defines the TLS mode and the CA bundle distributed by the master to the nodes, the empty
+// mode keeps the local TLS config of them.
+type TLSTrustInfo struct {
+	TLSMode  string
+	TLSCAPem string
 }
 
 // DataPartitionReport defines the partition report.
@@ -925,6 +975,7 @@ type DataNodeHeartbeatResponse struct {
 	MaxCapacity                      uint64 // maximum capacity to create partition
 	StartTime                        int64
 	ZoneName                         string
+	Labels                           map[string]string `json:",omitempty"` // topology labels, such as rack
 	PartitionReports                 []*DataPartitionReport
 	Status                           uint8
 	Result                           string
@@ -975,6 +1026,7 @@ type MetaPartitionReport struct {
 // MetaNodeHeartbeatResponse defines the response to the meta node heartbeat request.
 type MetaNodeHeartbeatResponse struct {
 	ZoneName                         string
+	Labels                           map[string]string `json:",omitempty"` // topology labels, such as rack
 	Total                            uint64
 	Used                             uint64
 	NodeMemTotal                     uint64
@@ -993,6 +1045,7 @@ type LcNodeHeartbeatResponse struct {
 	LcTaskCountLimit      int
 	LcScanningTasks       map[string]*LcNodeRuleTaskResponse
 	SnapshotScanningTasks map[string]*SnapshotVerDelTaskResponse
+	BatchTasks            map[string]*LcNodeBatchTaskResponse
 }
 
 type FlashNodeDiskCacheStat struct {
@@ -1359,6 +1412,7 @@ type SimpleVolView struct {
 	TxConflictRetryInterval int64
 	TxOpLimit               int
 	Description             string
+	SpreadLabel             string `json:",omitempty"`
 	DpSelectorName          string
 	DpSelectorParm          string
 	DefaultZonePrior        bool
@@ -1741,6 +1795,30 @@ type IsRaftStatusOKRequest struct {
 	ReplicaNum  int
 }
 
+// Phases of splitting a meta partition at an inode boundary.
+const (
+	MetaPartitionSplitPrepare = "prepare"
+	MetaPartitionSplitCommit  = "commit"
+	MetaPartitionSplitAbort   = "abort"
+)
+
+// SplitMetaPartitionRequest defines the request of splitting a meta partition at an inode boundary.
+// The inodes not less than SplitKey move to a new meta partition, a zero SplitKey in the prepare
+// phase lets the meta node pick the median inode of the partition.
+type SplitMetaPartitionRequest struct {
+	PartitionID uint64
+	SplitKey    uint64
+	Phase       string
+}
+
+// SplitMetaPartitionResponse defines the response to the request of splitting a meta partition.
+type SplitMetaPartitionResponse struct {
+	PartitionID uint64
+	SplitKey    uint64
+	InodeCount  uint64
+	DentryCount uint64
+}
+
 type FlashNodeSetIOLimitsRequest struct {
 	Iocc   int
 	Flow   int
@@ -264,6 +264,11 @@ const (
 	MsgMasterUserRemovePolicyReq    MsgType = MsgMasterAPIAccessReq + 0x80500
 	MsgMasterUserDeleteVolPolicyReq MsgType = MsgMasterAPIAccessReq + 0x80600
 	MsgMasterUserTransferVolReq     MsgType = MsgMasterAPIAccessReq + 0x80700
+	MsgMasterUserRoleCreateReq      MsgType = MsgMasterAPIAccessReq + 0x80800
+	MsgMasterUserRoleUpdateReq      MsgType = MsgMasterAPIAccessReq + 0x80900
+	MsgMasterUserRoleDeleteReq      MsgType = MsgMasterAPIAccessReq + 0x80a00
+	MsgMasterUserRoleGetReq         MsgType = MsgMasterAPIAccessReq + 0x80b00
+	MsgMasterUserRoleListReq        MsgType = MsgMasterAPIAccessReq + 0x80c00
 
 	// Master API zone management
 	MsgMasterUpdateZoneReq MsgType = MsgMasterAPIAccessReq + 0x90100
@@ -353,6 +358,11 @@ var MsgType2ResourceMap = map[MsgType]string{
 	MsgMasterUserRemovePolicyReq:    "master:userremotepolicy",
 	MsgMasterUserDeleteVolPolicyReq: "master:userdeletevolpolicy",
 	MsgMasterUserTransferVolReq:     "master:usertransfervol",
+	MsgMasterUserRoleCreateReq:      "master:userrolecreate",
+	MsgMasterUserRoleUpdateReq:      "master:userroleupdate",
+	MsgMasterUserRoleDeleteReq:      "master:userroledelete",
+	MsgMasterUserRoleGetReq:         "master:userroleget",
+	MsgMasterUserRoleListReq:        "master:userrolelist",
 
 	// Master API zone management
 	MsgMasterUpdateZoneReq: "master:updatezone",
@@ -0,0 +1,305 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ----------------------------------------------
+// objectnode -> master -> lcnode
+// BatchJob
+
+const (
+	BatchOpPutObjectTagging = "S3PutObjectTagging"
+	BatchOpPutObjectCopy    = "S3PutObjectCopy"
+	BatchOpDeleteObject     = "S3DeleteObject"
+	BatchOpPutObjectAcl     = "S3PutObjectAcl"
+
+	BatchJobSuspended = "Suspended" // waits for the confirmation, or is suspended by the owner
+	BatchJobReady     = "Ready"     // waits to be dispatched to the lcnodes
+	BatchJobActive    = "Active"
+	BatchJobCancelled = "Cancelled"
+	BatchJobComplete  = "Complete"
+	BatchJobFailed    = "Failed"
+
+	BatchReportAllTasks        = "AllTasks"
+	BatchReportFailedTasksOnly = "FailedTasksOnly"
+
+	BatchManifestFieldBucket    = "Bucket"
+	BatchManifestFieldKey       = "Key"
+	BatchManifestFieldVersionId = "VersionId"
+
+	BatchJobMaxShards      = 32
+	BatchJobMaxPrefixes    = 100
+	BatchJobMaxDescription = 256
+	BatchJobMaxPriority    = 2147483647
+)
+
+var (
+	BatchJobErrInvalidOperation = errors.New("invalid batch job operation")
+	BatchJobErrInvalidManifest  = errors.New("invalid batch job manifest")
+	BatchJobErrInvalidReport    = errors.New("invalid batch job report")
+)
+
+type BatchTag struct {
+	Key   string
+	Value string
+}
+
+// BatchOperation is the operation applied to each object of the manifest.
+type BatchOperation struct {
+	Type         string
+	Tags         []BatchTag `json:",omitempty"` // S3PutObjectTagging, the tag set replaces the existing one
+	TargetBucket string     `json:",omitempty"` // S3PutObjectCopy, the same bucket if empty
+	TargetPrefix string     `json:",omitempty"` // S3PutObjectCopy, prepended to the key
+	StorageClass string     `json:",omitempty"` // S3PutObjectCopy
+	CannedACL    string     `json:",omitempty"` // S3PutObjectAcl
+}
+
+func (o *BatchOperation) Validate() error {
+	if o == nil {
+		return BatchJobErrInvalidOperation
+	}
+	switch o.Type {
+	case BatchOpPutObjectTagging:
+		for _, tag := range o.Tags {
+			if tag.Key == "" {
+				return BatchJobErrInvalidOperation
+			}
+		}
+	case BatchOpPutObjectCopy:
+		if o.TargetBucket == "" && o.TargetPrefix == "" && o.StorageClass == "" {
+			// copy the object to itself without any change
+			return BatchJobErrInvalidOperation
+		}
+	case BatchOpDeleteObject:
+	case BatchOpPutObjectAcl:
+		if o.CannedACL == "" {
+			return BatchJobErrInvalidOperation
+		}
+	default:
+		return BatchJobErrInvalidOperation
+	}
+	return nil
+}
+
+// BatchManifest is the list of the objects of the job, which is either a CSV object with the
+// Fields in each line, or generated by scanning the Prefixes of the bucket.
+type BatchManifest struct {
+	Bucket   string
+	Key      string   `json:",omitempty"`
+	ETag     string   `json:",omitempty"` // checked against the manifest object if set
+	Fields   []string `json:",omitempty"`
+	Prefixes []string `json:",omitempty"`
+}
+
+func (m *BatchManifest) Validate() error {
+	if m == nil || m.Bucket == "" {
+		return BatchJobErrInvalidManifest
+	}
+	if m.Key == "" {
+		if len(m.Prefixes) == 0 || len(m.Prefixes) > BatchJobMaxPrefixes || len(m.Fields) != 0 {
+			return BatchJobErrInvalidManifest
+		}
+		return nil
+	}
+	if len(m.Prefixes) != 0 {
+		return BatchJobErrInvalidManifest
+	}
+	switch strings.Join(m.Fields, ",") {
+	case "", "Bucket,Key", "Bucket,Key,VersionId":
+	default:
+		return BatchJobErrInvalidManifest
+	}
+	return nil
+}
+
+// IsPrefix checks whether the manifest is generated by scanning the prefixes.
+func (m *BatchManifest) IsPrefix() bool {
+	return m.Key == ""
+}
+
+// BatchReport is the completion report of the job, which is written as the CSV objects
+// {Prefix}/job-{JobId}/results/{Shard}-{Seq}.csv in the Bucket.
+type BatchReport struct {
+	Enabled bool
+	Bucket  string `json:",omitempty"`
+	Prefix  string `json:",omitempty"`
+	Scope   string `json:",omitempty"`
+}
+
+func (r *BatchReport) Validate() error {
+	if r == nil {
+		return BatchJobErrInvalidReport
+	}
+	if !r.Enabled {
+		return nil
+	}
+	if r.Bucket == "" || (r.Scope != BatchReportAllTasks && r.Scope != BatchReportFailedTasksOnly) {
+		return BatchJobErrInvalidReport
+	}
+	return nil
+}
+
+// BatchReportKey returns the key of the report object of the shard.
+func BatchReportKey(prefix, jobId string, shard, seq int) string {
+	key := fmt.Sprintf("job-%s/results/%d-%d.csv", jobId, shard, seq)
+	if prefix = strings.TrimSuffix(prefix, "/"); prefix != "" {
+		key = prefix + "/" + key
+	}
+	return key
+}
+
+type BatchJobProgress struct {
+	Total     int64
+	Succeeded int64
+	Failed    int64
+}
+
+func (p *BatchJobProgress) Add(o BatchJobProgress) {
+	p.Total += o.Total
+	p.Succeeded += o.Succeeded
+	p.Failed += o.Failed
+}
+
+// BatchJobTask is the shard of the job executed by one lcnode. The objects before the checkpoint
+// are done and reported, so that the task is resumed from the checkpoint by any lcnode.
+type BatchJobTask struct {
+	Shard      int
+	NextLine   int64  `json:",omitempty"` // checkpoint of the CSV manifest
+	Marker     string `json:",omitempty"` // checkpoint of the prefix manifest, the last key done
+	ReportSeq  int    `json:",omitempty"` // report objects written
+	Progress   BatchJobProgress
+	Done       bool
+	Result     string `json:",omitempty"` // the error which fails the task
+	LcNode     string `json:",omitempty"` // lcnode running the task
+	UpdateTime int64  `json:",omitempty"` // unix seconds
+}
+
+type BatchJob struct {
+	JobId              string
+	Owner              string // uid of the owner
+	AccessKey          string // the objects are operated with the key of the owner
+	ClientRequestToken string
+	Description        string `json:",omitempty"`
+	Priority           int
+	Operation          *BatchOperation
+	Manifest           *BatchManifest
+	Report             *BatchReport
+	Status             string
+	StatusUpdateReason string `json:",omitempty"`
+	Shards             int
+	Tasks              []*BatchJobTask
+	CreationTime       int64
+	TerminationTime    int64 `json:",omitempty"`
+}
+
+func (j *BatchJob) Validate() error {
+	if j.Owner == "" || j.AccessKey == "" || len(j.Description) > BatchJobMaxDescription ||
+		j.Priority < 0 || j.Priority > BatchJobMaxPriority {
+		return errors.New("invalid batch job")
+	}
+	if err := j.Operation.Validate(); err != nil {
+		return err
+	}
+	if err := j.Manifest.Validate(); err != nil {
+		return err
+	}
+	return j.Report.Validate()
+}
+
+// Terminated checks whether the job is finished and can't be resumed.
+func (j *BatchJob) Terminated() bool {
+	return j.Status == BatchJobCancelled || j.Status == BatchJobComplete
+}
+
+func (j *BatchJob) Progress() (p BatchJobProgress) {
+	for _, t := range j.Tasks {
+		p.Add(t.Progress)
+	}
+	return
+}
+
+// Copy returns the copy of the job with the copies of the tasks, the others are shared.
+func (j *BatchJob) Copy() *BatchJob {
+	c := *j
+	c.Tasks = make([]*BatchJobTask, 0, len(j.Tasks))
+	for _, t := range j.Tasks {
+		task := *t
+		c.Tasks = append(c.Tasks, &task)
+	}
+	return &c
+}
+
+// CanTransit checks whether the job can be updated to the status by the owner.
+func (j *BatchJob) CanTransit(status string) bool {
+	switch status {
+	case BatchJobCancelled:
+		return !j.Terminated()
+	case BatchJobSuspended:
+		return j.Status == BatchJobReady || j.Status == BatchJobActive
+	case BatchJobReady:
+		// the failed job is resumed from the checkpoints of the tasks
+		return j.Status == BatchJobSuspended || j.Status == BatchJobFailed
+	}
+	return false
+}
+
+func BatchTaskId(jobId string, shard int) string {
+	return jobId + ":" + strconv.Itoa(shard)
+}
+
+func ParseBatchTaskId(id string) (jobId string, shard int, err error) {
+	i := strings.LastIndex(id, ":")
+	if i < 0 {
+		return "", 0, fmt.Errorf("invalid batch task id %v", id)
+	}
+	if shard, err = strconv.Atoi(id[i+1:]); err != nil {
+		return "", 0, fmt.Errorf("invalid batch task id %v", id)
+	}
+	return id[:i], shard, nil
+}
+
+type BatchTask struct {
+	Id   string
+	Job  *BatchJob // without the tasks
+	Task *BatchJobTask
+}
+
+type LcNodeBatchTaskRequest struct {
+	MasterAddr string
+	LcNodeAddr string
+	Task       *BatchTask
+}
+
+type LcNodeBatchTaskResponse struct {
+	ID      string
+	LcNode  string
+	Task    *BatchJobTask // the progress and the checkpoint of the task
+	Stopped bool          // the task is stopped before done
+	Status  uint8
+	Result  string
+}
+
+type BatchJobStatusRequest struct {
+	JobId  string
+	Owner  string
+	Status string
+	Reason string
+}
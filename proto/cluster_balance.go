@@ -69,3 +69,48 @@ type ClusterPlan struct {
 	Type    string                       `json:"type" bson:"type"`
 	Msg     string                       `json:"msg" bson:"msg"`
 }
+
+type DiskBalanceInfo struct {
+	Path  string  `json:"path"`
+	Total uint64  `json:"total"`
+	Used  uint64  `json:"used"`
+	Ratio float64 `json:"ratio"`
+}
+
+type DataNodeBalanceInfo struct {
+	ID        uint64             `json:"id"`
+	Addr      string             `json:"address"`
+	ZoneName  string             `json:"zone"`
+	NodeSetID uint64             `json:"nodeSetId"`
+	Total     uint64             `json:"total"`
+	Used      uint64             `json:"used"`
+	Ratio     float64            `json:"ratio"`
+	Estimate  float64            `json:"estimate"` // the usage ratio after the plan is done
+	Disks     []*DiskBalanceInfo `json:"disks"`
+}
+
+type DataBalancePlan struct {
+	ID           uint64 `json:"id" bson:"id"`
+	VolName      string `json:"volName" bson:"volname"`
+	Size         uint64 `json:"size" bson:"size"`
+	Source       string `json:"source" bson:"source"`
+	SrcDisk      string `json:"srcDisk" bson:"srcdisk"`
+	SrcNodeSetId uint64 `json:"srcNodeSetId" bson:"srcnodesetid"`
+	Destination  string `json:"destination" bson:"destination"`
+	DstNodeSetId uint64 `json:"dstNodeSetId" bson:"dstnodesetid"`
+	ZoneName     string `json:"zone" bson:"zonename"`
+	Status       string `json:"status" bson:"status"`
+	Msg          string `json:"msg" bson:"msg"`
+}
+
+type DataClusterPlan struct {
+	Nodes    []*DataNodeBalanceInfo `json:"nodes" bson:"nodes"`
+	Plan     []*DataBalancePlan     `json:"plan" bson:"plan"`
+	DoneNum  int                    `json:"doneCount" bson:"donenum"`
+	Total    int                    `json:"total" bson:"total"`
+	Parallel int                    `json:"parallel" bson:"parallel"` // the data partitions migrated at the same time
+	Status   string                 `json:"status" bson:"status"`
+	Expire   time.Time              `json:"expire" bson:"expire"`
+	Type     string                 `json:"type" bson:"type"`
+	Msg      string                 `json:"msg" bson:"msg"`
+}
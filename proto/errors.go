@@ -115,7 +115,18 @@ var (
 	ErrDataNodeAdd                             = errors.New("DataNode mediaType not match")
 	ErrNeedForbidVer0                          = errors.New("Need set volume ForbidWriteOpOfProtoVer0 first")
 	ErrTmpfsNoSpace                            = errors.New("no space left on device")
+	ErrRoleNotExists                           = errors.New("role not exists")
+	ErrDuplicateRole                           = errors.New("duplicate role")
+	ErrInvalidRole                             = errors.New("invalid role")
+	ErrAPITokenNotExists                       = errors.New("api token not exists")
+	ErrDuplicateAPIToken                       = errors.New("duplicate api token")
+	ErrInvalidAPIToken                         = errors.New("invalid api token")
+	ErrAPITokenDenied                          = errors.New("api token not permitted")
+	ErrBatchJobNotExists                       = errors.New("batch job not exists")
+	ErrInvalidBatchJobStatus                   = errors.New("invalid batch job status")
+	ErrNoSuchInventoryConfiguration            = errors.New("The inventory configuration does not exist")
 	ErrNoMpMigratePlan                         = errors.New("no meta partition migrate plan")
+	ErrNoDpMigratePlan                         = errors.New("no data partition migrate plan")
 	ErrFlashNodeFlowLimited                    = errors.New("flow limited")
 	ErrFlashNodeRunLimited                     = errors.New("run limited")
 )
@@ -190,6 +201,16 @@ const (
 	ErrCodeNoSuchLifecycleConfiguration
 	ErrCodeNoSupportStorageClass
 	ErrCodeTmpfsNoSpace
+	ErrCodeRoleNotExists
+	ErrCodeDuplicateRole
+	ErrCodeInvalidRole
+	ErrCodeBatchJobNotExists
+	ErrCodeInvalidBatchJobStatus
+	ErrCodeNoSuchInventoryConfiguration
+	ErrCodeAPITokenNotExists
+	ErrCodeDuplicateAPIToken
+	ErrCodeInvalidAPIToken
+	ErrCodeAPITokenDenied
 )
 
 // Err2CodeMap error map to code
@@ -258,6 +279,16 @@ var Err2CodeMap = map[error]int32{
 	ErrNoSuchLifecycleConfiguration:    ErrCodeNoSuchLifecycleConfiguration,
 	ErrNoSupportStorageClass:           ErrCodeNoSupportStorageClass,
 	ErrTmpfsNoSpace:                    ErrCodeTmpfsNoSpace,
+	ErrRoleNotExists:                   ErrCodeRoleNotExists,
+	ErrDuplicateRole:                   ErrCodeDuplicateRole,
+	ErrInvalidRole:                     ErrCodeInvalidRole,
+	ErrBatchJobNotExists:               ErrCodeBatchJobNotExists,
+	ErrInvalidBatchJobStatus:           ErrCodeInvalidBatchJobStatus,
+	ErrNoSuchInventoryConfiguration:    ErrCodeNoSuchInventoryConfiguration,
+	ErrAPITokenNotExists:               ErrCodeAPITokenNotExists,
+	ErrDuplicateAPIToken:               ErrCodeDuplicateAPIToken,
+	ErrInvalidAPIToken:                 ErrCodeInvalidAPIToken,
+	ErrAPITokenDenied:                  ErrCodeAPITokenDenied,
 }
 
 func ParseErrorCode(code int32) error {
@@ -335,6 +366,16 @@ var code2ErrMap = map[int32]error{
 	ErrCodeNoSuchLifecycleConfiguration:    ErrNoSuchLifecycleConfiguration,
 	ErrCodeNoSupportStorageClass:           ErrNoSupportStorageClass,
 	ErrCodeTmpfsNoSpace:                    ErrTmpfsNoSpace,
+	ErrCodeRoleNotExists:                   ErrRoleNotExists,
+	ErrCodeDuplicateRole:                   ErrDuplicateRole,
+	ErrCodeInvalidRole:                     ErrInvalidRole,
+	ErrCodeBatchJobNotExists:               ErrBatchJobNotExists,
+	ErrCodeInvalidBatchJobStatus:           ErrInvalidBatchJobStatus,
+	ErrCodeNoSuchInventoryConfiguration:    ErrNoSuchInventoryConfiguration,
+	ErrCodeAPITokenNotExists:               ErrAPITokenNotExists,
+	ErrCodeDuplicateAPIToken:               ErrDuplicateAPIToken,
+	ErrCodeInvalidAPIToken:                 ErrInvalidAPIToken,
+	ErrCodeAPITokenDenied:                  ErrAPITokenDenied,
 }
 
 type GeneralResp struct {
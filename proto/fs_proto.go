@@ -474,6 +474,9 @@ type UpdateDentryRequest struct {
 	ParentID    uint64 `json:"pino"`
 	Name        string `json:"name"`
 	Inode       uint64 `json:"ino"` // new inode number
+	// OldInode is the inode the dentry is expected to refer to, the dentry is only
+	// updated if it matches. It is only checked for OpMetaCondUpdateDentry.
+	OldInode uint64 `json:"oldIno,omitempty"`
 	RequestExtend
 }
 
@@ -826,6 +829,21 @@ type SetXAttrRequest struct {
 	Value       string `json:"val"`
 }
 
+// CompareAndSetXAttrRequest sets the xattr only if its value is the expected one, the empty
+// expected value matches the xattr absent. The xattr is removed if the value is empty.
+type CompareAndSetXAttrRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+	Key         string `json:"key"`
+	Expected    string `json:"expected"`
+	Value       string `json:"val"`
+}
+
+type CompareAndSetXAttrResponse struct {
+	Status uint8 `json:"status"`
+}
+
 type BatchSetXAttrRequest struct {
 	VolName     string            `json:"vol"`
 	PartitionId uint64            `json:"pid"`
@@ -0,0 +1,217 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	InventoryFormatCSV   = "CSV"
+	InventoryFormatJSONL = "JSONL" // one JSON object per line
+
+	InventoryFrequencyDaily  = "Daily"
+	InventoryFrequencyWeekly = "Weekly" // on Sundays
+
+	InventoryVersionsCurrent = "Current"
+
+	InventoryFieldSize             = "Size"
+	InventoryFieldLastModifiedDate = "LastModifiedDate"
+	InventoryFieldETag             = "ETag"
+	InventoryFieldStorageClass     = "StorageClass"
+	InventoryFieldTags             = "Tags"
+
+	MaxInventoryConfigurations = 1000
+)
+
+// InventoryFields are the optional fields of the inventory in the order of the columns,
+// the bucket and the key of the objects are always listed first.
+var InventoryFields = []string{
+	InventoryFieldSize,
+	InventoryFieldLastModifiedDate,
+	InventoryFieldETag,
+	InventoryFieldStorageClass,
+	InventoryFieldTags,
+}
+
+var (
+	InventoryErrTooMany     = errors.New("Inventory configurations number should not exceed allowed limit of 1000")
+	InventoryErrInvalidId   = errors.New("Invalid inventory configuration ID")
+	InventoryErrDestination = errors.New("The destination bucket of the inventory must be specified")
+	InventoryErrFormat      = errors.New("The inventory format must be CSV or JSONL")
+	InventoryErrFrequency   = errors.New("The inventory frequency must be Daily or Weekly")
+	InventoryErrVersions    = errors.New("Only the current object versions can be included in the inventory")
+	InventoryErrField       = errors.New("Invalid or duplicated inventory optional field")
+	InventoryErrPrefix      = errors.New("Inventory prefix cannot start with '/'")
+)
+
+// BucketInventory keeps the inventory configurations of the volume in the master.
+type BucketInventory struct {
+	VolName        string
+	Configurations []*InventoryConfiguration
+}
+
+type InventoryConfiguration struct {
+	Id                     string
+	Enabled                bool
+	Prefix                 string
+	Destination            *InventoryDestination
+	Fields                 []string
+	Frequency              string
+	IncludedObjectVersions string
+}
+
+type InventoryDestination struct {
+	Bucket string
+	Prefix string
+	Format string
+}
+
+func (c *InventoryConfiguration) Validate() error {
+	if len(c.Id) == 0 || len(c.Id) > MaxIdLength || !regexRuleId.MatchString(c.Id) {
+		return InventoryErrInvalidId
+	}
+	if strings.HasPrefix(c.Prefix, "/") {
+		return InventoryErrPrefix
+	}
+	if c.Destination == nil || c.Destination.Bucket == "" {
+		return InventoryErrDestination
+	}
+	if c.Destination.Format != InventoryFormatCSV && c.Destination.Format != InventoryFormatJSONL {
+		return InventoryErrFormat
+	}
+	if c.Frequency != InventoryFrequencyDaily && c.Frequency != InventoryFrequencyWeekly {
+		return InventoryErrFrequency
+	}
+	if c.IncludedObjectVersions != InventoryVersionsCurrent {
+		return InventoryErrVersions
+	}
+	seen := make(map[string]bool, len(c.Fields))
+	for _, f := range c.Fields {
+		if seen[f] || !contains(InventoryFields, f) {
+			return InventoryErrField
+		}
+		seen[f] = true
+	}
+	return nil
+}
+
+func contains(arr []string, s string) bool {
+	for _, a := range arr {
+		if a == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *InventoryConfiguration) HasField(field string) bool {
+	return contains(c.Fields, field)
+}
+
+// Schema returns the columns of the inventory.
+func (c *InventoryConfiguration) Schema() []string {
+	schema := []string{"Bucket", "Key"}
+	for _, f := range InventoryFields {
+		if c.HasField(f) {
+			schema = append(schema, f)
+		}
+	}
+	return schema
+}
+
+func (b *BucketInventory) Get(id string) *InventoryConfiguration {
+	for _, c := range b.Configurations {
+		if c.Id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// Put adds the configuration, or replaces the one of the same id.
+func (b *BucketInventory) Put(conf *InventoryConfiguration) error {
+	for i, c := range b.Configurations {
+		if c.Id == conf.Id {
+			b.Configurations[i] = conf
+			return nil
+		}
+	}
+	if len(b.Configurations) >= MaxInventoryConfigurations {
+		return InventoryErrTooMany
+	}
+	b.Configurations = append(b.Configurations, conf)
+	return nil
+}
+
+func (b *BucketInventory) Delete(id string) bool {
+	for i, c := range b.Configurations {
+		if c.Id == id {
+			b.Configurations = append(b.Configurations[:i], b.Configurations[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func InventoryTaskId(vol, id string) string {
+	return fmt.Sprintf("%s:inventory:%s", vol, id)
+}
+
+// GenEnabledInventoryTasks generates the tasks of the inventories to be generated at the day.
+func (b *BucketInventory) GenEnabledInventoryTasks(now time.Time) []*RuleTask {
+	tasks := make([]*RuleTask, 0)
+	for _, c := range b.Configurations {
+		if !c.Enabled {
+			log.LogDebugf("GenEnabledInventoryTasks: skip disabled inventory(%v) in volume(%v)", c.Id, b.VolName)
+			continue
+		}
+		if c.Frequency == InventoryFrequencyWeekly && now.Weekday() != time.Sunday {
+			continue
+		}
+		tasks = append(tasks, b.genInventoryTask(c))
+	}
+	return tasks
+}
+
+// GenInventoryTask generates the task of the id regardless of the frequency, it's used to redo the task.
+func (b *BucketInventory) GenInventoryTask(taskId string) *RuleTask {
+	for _, c := range b.Configurations {
+		if c.Enabled && InventoryTaskId(b.VolName, c.Id) == taskId {
+			return b.genInventoryTask(c)
+		}
+	}
+	return nil
+}
+
+// genInventoryTask generates the task which scans the prefix like a rule without any action,
+// so that the inventory is scheduled and scanned as the lifecycle tasks.
+func (b *BucketInventory) genInventoryTask(c *InventoryConfiguration) *RuleTask {
+	return &RuleTask{
+		Id:      InventoryTaskId(b.VolName, c.Id),
+		VolName: b.VolName,
+		Rule: &Rule{
+			ID:     c.Id,
+			Status: RuleEnabled,
+			Filter: &Filter{Prefix: c.Prefix},
+		},
+		Inventory: c,
+	}
+}
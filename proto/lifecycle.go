@@ -33,6 +33,8 @@ const (
 	OpTypeDelete          = "DELETE"
 	OpTypeStorageClassHDD = "HDD"
 	OpTypeStorageClassEBS = "BLOBSTORE"
+	OpTypeDeleteMarker    = "DELETE_MARKER"
+	OpTypeAbortMultipart  = "ABORT_MULTIPART"
 )
 
 func OpTypeToStorageType(op string) uint32 {
@@ -52,21 +54,57 @@ type LcConfiguration struct {
 }
 
 type Rule struct {
-	ID          string        `json:"ID" xml:"ID" bson:"ID"`
-	Status      string        `json:"Status" xml:"Status" bson:"Status"`
-	Filter      *Filter       `json:"Filter,omitempty" xml:"Filter,omitempty" bson:"Filter,omitempty"`
-	Expiration  *Expiration   `json:"Expiration,omitempty" xml:"Expiration,omitempty" bson:"Expiration,omitempty"`
-	Transitions []*Transition `json:"Transition,omitempty" xml:"Transition,omitempty" bson:"Transition,omitempty"`
+	ID                             string                          `json:"ID" xml:"ID" bson:"ID"`
+	Status                         string                          `json:"Status" xml:"Status" bson:"Status"`
+	Filter                         *Filter                         `json:"Filter,omitempty" xml:"Filter,omitempty" bson:"Filter,omitempty"`
+	Expiration                     *Expiration                     `json:"Expiration,omitempty" xml:"Expiration,omitempty" bson:"Expiration,omitempty"`
+	Transitions                    []*Transition                   `json:"Transition,omitempty" xml:"Transition,omitempty" bson:"Transition,omitempty"`
+	AbortIncompleteMultipartUpload *AbortIncompleteMultipartUpload `json:"AbortIncompleteMultipartUpload,omitempty" xml:"AbortIncompleteMultipartUpload,omitempty" bson:"AbortIncompleteMultipartUpload,omitempty"`
 }
 
 type Expiration struct {
 	Date *time.Time `json:"Date,omitempty" xml:"Date,omitempty" bson:"Date,omitempty"`
 	Days *int       `json:"Days,omitempty" xml:"Days,omitempty" bson:"Days,omitempty"`
+	// ExpiredObjectDeleteMarker removes the delete marker which has no non-current version left,
+	// it is not specified with Date or Days.
+	ExpiredObjectDeleteMarker *bool `json:"ExpiredObjectDeleteMarker,omitempty" xml:"ExpiredObjectDeleteMarker,omitempty" bson:"ExpiredObjectDeleteMarker,omitempty"`
 }
 
+// ExpiresObjects checks whether the expiration deletes the objects, rather than only the delete markers.
+func (e *Expiration) ExpiresObjects() bool {
+	return e != nil && (e.Date != nil || e.Days != nil)
+}
+
+// ExpiresDeleteMarkers checks whether the expired delete markers are to be removed.
+func (e *Expiration) ExpiresDeleteMarkers() bool {
+	return e != nil && e.ExpiredObjectDeleteMarker != nil && *e.ExpiredObjectDeleteMarker
+}
+
+type AbortIncompleteMultipartUpload struct {
+	DaysAfterInitiation int `json:"DaysAfterInitiation" xml:"DaysAfterInitiation" bson:"DaysAfterInitiation"`
+}
+
+type LcTag struct {
+	Key   string `json:"Key" xml:"Key" bson:"Key"`
+	Value string `json:"Value" xml:"Value" bson:"Value"`
+}
+
+// Filter selects the objects of the rule. Prefix, Tag and the size conditions are exclusive
+// unless they are combined by And, MinSize is kept for compatibility.
 type Filter struct {
-	Prefix  string `json:"Prefix,omitempty" xml:"Prefix,omitempty" bson:"Prefix,omitempty"`
-	MinSize uint64 `json:"MinSize" xml:"MinSize" bson:"MinSize"`
+	Prefix                string     `json:"Prefix,omitempty" xml:"Prefix,omitempty" bson:"Prefix,omitempty"`
+	MinSize               uint64     `json:"MinSize" xml:"MinSize" bson:"MinSize"`
+	Tag                   *LcTag     `json:"Tag,omitempty" xml:"Tag,omitempty" bson:"Tag,omitempty"`
+	ObjectSizeGreaterThan *int64     `json:"ObjectSizeGreaterThan,omitempty" xml:"ObjectSizeGreaterThan,omitempty" bson:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    *int64     `json:"ObjectSizeLessThan,omitempty" xml:"ObjectSizeLessThan,omitempty" bson:"ObjectSizeLessThan,omitempty"`
+	And                   *FilterAnd `json:"And,omitempty" xml:"And,omitempty" bson:"And,omitempty"`
+}
+
+type FilterAnd struct {
+	Prefix                string  `json:"Prefix,omitempty" xml:"Prefix,omitempty" bson:"Prefix,omitempty"`
+	Tags                  []LcTag `json:"Tag,omitempty" xml:"Tag,omitempty" bson:"Tag,omitempty"`
+	ObjectSizeGreaterThan *int64  `json:"ObjectSizeGreaterThan,omitempty" xml:"ObjectSizeGreaterThan,omitempty" bson:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    *int64  `json:"ObjectSizeLessThan,omitempty" xml:"ObjectSizeLessThan,omitempty" bson:"ObjectSizeLessThan,omitempty"`
 }
 
 type Transition struct {
@@ -89,6 +127,11 @@ var (
 	LifeCycleErrMalformedXML   = errors.New("The XML you provided was not well-formed or did not validate against our published schema")
 	LifeCycleErrConflictRules  = errors.New("Conflicting rule prefix")
 	LifeCycleErrRulePrefix     = errors.New("Rule prefix cannot start with '/'")
+	LifeCycleErrFilter         = errors.New("Filter conditions other than MinSize must be combined by 'And'")
+	LifeCycleErrTag            = errors.New("Invalid filter tag, the keys must be unique and non-empty")
+	LifeCycleErrObjectSize     = errors.New("'ObjectSizeLessThan' must be greater than 'ObjectSizeGreaterThan'")
+	LifeCycleErrAbortMultipart = errors.New("'DaysAfterInitiation' must be a positive integer, and the rule filter can't have tags or object sizes")
+	LifeCycleErrDeleteMarker   = errors.New("'ExpiredObjectDeleteMarker' cannot be specified with 'Days', 'Date' or filter tags")
 )
 
 func ValidRules(Rules []*Rule) error {
@@ -164,6 +207,9 @@ func (r *Rule) GetPrefix() string {
 	var prefix string
 	if r.Filter != nil {
 		prefix = r.Filter.Prefix
+		if r.Filter.And != nil {
+			prefix = r.Filter.And.Prefix
+		}
 	}
 	return prefix
 }
@@ -175,6 +221,54 @@ func (r *Rule) MinSize() uint64 {
 	return 0
 }
 
+// Tags returns the tags which the objects must have all.
+func (r *Rule) Tags() []LcTag {
+	switch {
+	case r.Filter == nil:
+		return nil
+	case r.Filter.And != nil:
+		return r.Filter.And.Tags
+	case r.Filter.Tag != nil:
+		return []LcTag{*r.Filter.Tag}
+	}
+	return nil
+}
+
+func (r *Rule) objectSizeRange() (greaterThan, lessThan *int64) {
+	if r.Filter == nil {
+		return
+	}
+	if r.Filter.And != nil {
+		return r.Filter.And.ObjectSizeGreaterThan, r.Filter.And.ObjectSizeLessThan
+	}
+	return r.Filter.ObjectSizeGreaterThan, r.Filter.ObjectSizeLessThan
+}
+
+// MatchSize checks whether the size of the object matches the MinSize and the object size range.
+func (r *Rule) MatchSize(size uint64) bool {
+	if size < r.MinSize() {
+		return false
+	}
+	greaterThan, lessThan := r.objectSizeRange()
+	if greaterThan != nil && int64(size) <= *greaterThan {
+		return false
+	}
+	if lessThan != nil && int64(size) >= *lessThan {
+		return false
+	}
+	return true
+}
+
+// MatchTags checks whether the object has all the tags of the rule.
+func (r *Rule) MatchTags(tags map[string]string) bool {
+	for _, tag := range r.Tags() {
+		if value, ok := tags[tag.Key]; !ok || value != tag.Value {
+			return false
+		}
+	}
+	return true
+}
+
 var regexRuleId = regexp.MustCompile(`^[A-Za-z0-9.-]+$`)
 
 var ExpirationEnabled bool
@@ -194,12 +288,17 @@ func validRule(r *Rule) error {
 		return LifeCycleErrMalformedXML
 	}
 
-	if r.Expiration == nil && len(r.Transitions) == 0 {
+	if r.Expiration == nil && len(r.Transitions) == 0 && r.AbortIncompleteMultipartUpload == nil {
 		return LifeCycleErrMissingActions
 	}
 
-	// expiration is temporarily disabled, remove this code to enable expiration
-	if r.Expiration != nil && !ExpirationEnabled {
+	if err := validFilter(r.Filter); err != nil {
+		return err
+	}
+
+	// expiration is temporarily disabled, remove this code to enable expiration.
+	// The expired delete markers are removed without deleting any data.
+	if r.Expiration.ExpiresObjects() && !ExpirationEnabled {
 		return errors.New("expiration is temporarily disabled")
 	}
 
@@ -207,6 +306,16 @@ func validRule(r *Rule) error {
 		if err := validExpiration(r.Expiration); err != nil {
 			return err
 		}
+		if r.Expiration.ExpiredObjectDeleteMarker != nil && len(r.Tags()) > 0 {
+			return LifeCycleErrDeleteMarker
+		}
+	}
+
+	if a := r.AbortIncompleteMultipartUpload; a != nil {
+		greaterThan, lessThan := r.objectSizeRange()
+		if a.DaysAfterInitiation <= 0 || len(r.Tags()) > 0 || greaterThan != nil || lessThan != nil {
+			return LifeCycleErrAbortMultipart
+		}
 	}
 
 	if r.Transitions != nil {
@@ -231,7 +340,57 @@ func validRule(r *Rule) error {
 	return nil
 }
 
+func validFilter(f *Filter) error {
+	if f == nil {
+		return nil
+	}
+	var (
+		tags                  []LcTag
+		greaterThan, lessThan = f.ObjectSizeGreaterThan, f.ObjectSizeLessThan
+	)
+	if f.Tag != nil {
+		tags = append(tags, *f.Tag)
+	}
+	if f.And != nil {
+		if f.Prefix != "" || f.Tag != nil || greaterThan != nil || lessThan != nil {
+			return LifeCycleErrFilter
+		}
+		tags, greaterThan, lessThan = f.And.Tags, f.And.ObjectSizeGreaterThan, f.And.ObjectSizeLessThan
+	} else {
+		conditions := len(tags)
+		for _, ok := range []bool{f.Prefix != "", greaterThan != nil, lessThan != nil} {
+			if ok {
+				conditions++
+			}
+		}
+		if conditions > 1 {
+			return LifeCycleErrFilter
+		}
+	}
+
+	keys := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		if _, ok := keys[tag.Key]; ok || tag.Key == "" {
+			return LifeCycleErrTag
+		}
+		keys[tag.Key] = struct{}{}
+	}
+	if (greaterThan != nil && *greaterThan < 0) || (lessThan != nil && *lessThan <= 0) {
+		return LifeCycleErrObjectSize
+	}
+	if greaterThan != nil && lessThan != nil && *lessThan <= *greaterThan {
+		return LifeCycleErrObjectSize
+	}
+	return nil
+}
+
 func validExpiration(e *Expiration) error {
+	if e.ExpiredObjectDeleteMarker != nil {
+		if e.Date != nil || e.Days != nil {
+			return LifeCycleErrDeleteMarker
+		}
+		return nil
+	}
 	// Date and Days cannot be set at the same time
 	if e.Date != nil && e.Days != nil {
 		return LifeCycleErrMalformedXML
@@ -306,7 +465,7 @@ func validTransitions(dateMap map[string]*time.Time, daysMap map[string]int, exp
 				return LifeCycleErrMalformedXML
 			}
 		}
-		if expiration != nil {
+		if expiration.ExpiresObjects() {
 			if expiration.Days != nil || !expiration.Date.After(*s[len(s)-1]) {
 				return LifeCycleErrMalformedXML
 			}
@@ -326,7 +485,7 @@ func validTransitions(dateMap map[string]*time.Time, daysMap map[string]int, exp
 				return LifeCycleErrMalformedXML
 			}
 		}
-		if expiration != nil {
+		if expiration.ExpiresObjects() {
 			if expiration.Date != nil || *expiration.Days <= s[len(s)-1] {
 				return LifeCycleErrMalformedXML
 			}
@@ -341,7 +500,7 @@ func (lcConf *LcConfiguration) GenEnabledRuleTasks() []*RuleTask {
 	for _, r := range lcConf.Rules {
 
 		// expiration is temporarily disabled, remove this code to enable expiration
-		if r.Expiration != nil {
+		if r.Expiration.ExpiresObjects() {
 			log.LogWarnf("GenEnabledRuleTasks: expiration is temporarily disabled, skip ruleid: %v", r.ID)
 			continue
 		}
@@ -372,9 +531,10 @@ type LcNodeRuleTaskRequest struct {
 }
 
 type RuleTask struct {
-	Id      string
-	VolName string
-	Rule    *Rule
+	Id        string
+	VolName   string
+	Rule      *Rule
+	Inventory *InventoryConfiguration `json:",omitempty"` // the task generates the inventory
 }
 
 type LcNodeRuleTaskResponse struct {
@@ -389,6 +549,7 @@ type LcNodeRuleTaskResponse struct {
 	Volume     string
 	RcvStop    bool
 	Rule       *Rule
+	Inventory  *InventoryConfiguration `json:",omitempty"`
 	LcNodeRuleTaskStatistics
 }
 
@@ -403,11 +564,18 @@ type LcNodeRuleTaskStatistics struct {
 	ExpiredMToBlobstoreNum   int64
 	ExpiredMToBlobstoreBytes int64
 	ExpiredSkipNum           int64
-
-	ErrorDeleteNum       int64
-	ErrorMToHddNum       int64
-	ErrorMToBlobstoreNum int64
-	ErrorReadDirNum      int64
+	ExpiredDeleteMarkerNum   int64
+	AbortedMultipartNum      int64
+	InventoryObjectNum       int64
+	InventoryFileNum         int64
+
+	ErrorDeleteNum         int64
+	ErrorMToHddNum         int64
+	ErrorMToBlobstoreNum   int64
+	ErrorReadDirNum        int64
+	ErrorDeleteMarkerNum   int64
+	ErrorAbortMultipartNum int64
+	ErrorInventoryNum      int64
 }
 
 // ----------------------------------
@@ -425,3 +593,107 @@ type ScanDentry struct {
 	LeaseExpire  uint64 `json:"leaseExpire"` // for migrate: used to determine whether a file is modified
 	HasMek       bool   `json:"mek"`         // for migrate: if HasMek, call DeleteMigrationExtentKey instead of migrating
 }
+
+// ----------------------------------------------
+// lcnode <-> objectnode
+
+const (
+	// XAttrKeyETag is the xattr of the object which keeps its ETag and the modify time
+	// when the ETag is computed, in the format of "etag[-parts][:unix seconds]".
+	XAttrKeyETag = "oss:etag"
+
+	// XAttrKeyTagging is the xattr of the object which keeps its tags in the URL query encoding.
+	XAttrKeyTagging = "oss:tagging"
+
+	// XAttrKeyVersions is the prefix of the xattrs of the parent directory, each of which keeps
+	// the non-current versions and the delete markers of one object key, the newest first.
+	XAttrKeyVersions = "oss:versions:"
+
+	// XAttrKeyObjectLock is the xattr of the object which keeps its retain until date in
+	// unix nanoseconds, the object can not be removed before the date. It keeps the object
+	// lock config of the bucket if it is set on the root directory.
+	XAttrKeyObjectLock = "oss:lock"
+
+	// XAttrKeyObjectLegalHold is the xattr of the object which keeps its legal hold status,
+	// the object can not be removed while it is ObjectLegalHoldOn.
+	XAttrKeyObjectLegalHold = "oss:legal-hold"
+
+	ObjectLegalHoldOn = "ON"
+)
+
+// ObjectVersion is a non-current version or a delete marker of an object key.
+// The data of a non-current version is kept in a hidden inode which is not
+// linked by any dentry, so that it is invisible to POSIX clients and listings.
+type ObjectVersion struct {
+	VersionId    string `json:"vid"`
+	Inode        uint64 `json:"ino,omitempty"`
+	DeleteMarker bool   `json:"dm,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	Size         int64  `json:"size,omitempty"`
+	ModifyTime   int64  `json:"mt"`
+}
+
+// ----------------------------------------------
+// objectnode -> master -> lcnode
+// RestoreTask
+
+// XAttrKeyRestore is the xattr of the object in the blobstore storage class which keeps its
+// restore status, it is set by the objectnode on request and updated by the lcnode.
+const XAttrKeyRestore = "oss:restore"
+
+// RestoreStatus is the restore status of the object. The lcnode copies the data of the object
+// to a temporary inode in the replica tier, which is deleted once the expiry date is passed.
+type RestoreStatus struct {
+	Ongoing          bool   `json:"ongoing"`
+	RequestTime      int64  `json:"requestTime"`          // unix seconds
+	Days             int    `json:"days"`                 // lifetime of the restored copy
+	ExpiryDate       int64  `json:"expiryDate,omitempty"` // unix seconds, set once restored
+	CopyInode        uint64 `json:"copyInode,omitempty"`
+	CopyStorageClass uint32 `json:"copySc,omitempty"`
+}
+
+// Restored checks whether the temporary copy of the object is available.
+func (s *RestoreStatus) Restored() bool {
+	return s != nil && !s.Ongoing && s.CopyInode != 0
+}
+
+// Expired checks whether the temporary copy of the object is to be deleted.
+func (s *RestoreStatus) Expired(now time.Time) bool {
+	return s.Restored() && now.Unix() >= s.ExpiryDate
+}
+
+// RestoreExpiryDate returns the expiry date of the restored copy, which is rounded up to the
+// midnight UTC after the specified days.
+func RestoreExpiryDate(restoreTime time.Time, days int) time.Time {
+	return restoreTime.UTC().Truncate(24*time.Hour).AddDate(0, 0, days+1)
+}
+
+// RestoreTask restores the object, or deletes the restored copy of the object once it is
+// expired if Expire is set.
+type RestoreTask struct {
+	Id      string
+	VolName string
+	Path    string
+	Inode   uint64
+	Days    int
+	Expire  bool
+}
+
+type LcNodeRestoreTaskRequest struct {
+	MasterAddr string
+	LcNodeAddr string
+	Task       *RestoreTask
+}
+
+type LcNodeRestoreTaskResponse struct {
+	ID        string
+	LcNode    string
+	Volume    string
+	Inode     uint64
+	CopyInode uint64
+	Expire    bool
+	// ExpiryDate is the expiry date of the restored copy, zero if the object has no restored copy
+	ExpiryDate int64
+	Status     uint8
+	Result     string
+}
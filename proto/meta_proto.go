@@ -49,6 +49,7 @@ type CreateMetaPartitionRequest struct {
 	PartitionID uint64
 	Members     []Peer
 	VerSeq      uint64
+	SplitFrom   uint64 // the partition whose frozen upper range seeds the new one
 }
 
 // CreateMetaPartitionResponse defines the response to the request of creating a meta partition.
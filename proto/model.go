@@ -35,10 +35,11 @@ type MetaNodeInfo struct {
 	DomainAddr                string
 	IsActive                  bool
 	IsWriteAble               bool
-	ZoneName                  string `json:"Zone"`
-	MaxMemAvailWeight         uint64 `json:"MaxMemAvailWeight"`
-	Total                     uint64 `json:"TotalWeight"`
-	Used                      uint64 `json:"UsedWeight"`
+	ZoneName                  string            `json:"Zone"`
+	Labels                    map[string]string `json:",omitempty"`
+	MaxMemAvailWeight         uint64            `json:"MaxMemAvailWeight"`
+	Total                     uint64            `json:"TotalWeight"`
+	Used                      uint64            `json:"UsedWeight"`
 	Ratio                     float64
 	SelectCount               uint64
 	Threshold                 float32
@@ -58,7 +59,8 @@ type DataNodeInfo struct {
 	Used                                  uint64 `json:"UsedWeight"`
 	AvailableSpace                        uint64
 	ID                                    uint64
-	ZoneName                              string `json:"Zone"`
+	ZoneName                              string            `json:"Zone"`
+	Labels                                map[string]string `json:",omitempty"`
 	Addr                                  string
 	RaftHeartbeatPort                     string
 	RaftReplicaPort                       string
@@ -150,6 +152,7 @@ type ClusterView struct {
 	MarkDiskBrokenThreshold                   float64
 	EnableAutoDpMetaRepair                    bool
 	AutoDpMetaRepairParallelCnt               int
+	EnableSpreadRepair                        bool
 	EnableAutoDecommission                    bool
 	AutoDecommissionDiskInterval              string
 	DecommissionFirstHostDiskParallelLimit    uint64
@@ -94,6 +94,7 @@ const (
 // For server
 const (
 	MasterAddr       = "masterAddr"
+	MasterAPIToken   = "masterApiToken"
 	ListenPort       = "listen"
 	ObjectNodeDomain = "objectNodeDomain"
 	BindIpKey        = "bindIp"
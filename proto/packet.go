@@ -110,6 +110,8 @@ const (
 	OpMetaExtentAddWithCheck       uint8 = 0x3A // Append extent key with discard extents check
 	OpMetaReadDirLimit             uint8 = 0x3D
 	OpMetaLockDir                  uint8 = 0x3E
+	OpMetaCompareAndSetXAttr       uint8 = 0x3F // set the xattr only if its value is the expected one
+	OpMetaCondUpdateDentry         uint8 = 0x5E // update the dentry only if it points to the expected inode
 
 	// Operations: Master -> MetaNode
 	OpCreateMetaPartition           uint8 = 0x40
@@ -125,6 +127,7 @@ const (
 	OpBackupEmptyMetaPartition      uint8 = 0x4A
 	OpRemoveBackupMetaPartition     uint8 = 0x4B
 	OpIsRaftStatusOk                uint8 = 0x4C
+	OpSplitMetaPartition            uint8 = 0x4D
 
 	// Quota
 	OpMetaBatchSetInodeQuota    uint8 = 0x50
@@ -137,6 +140,8 @@ const (
 	OpLcNodeHeartbeat      uint8 = 0x55
 	OpLcNodeScan           uint8 = 0x56
 	OpLcNodeSnapshotVerDel uint8 = 0x5B
+	OpLcNodeRestore        uint8 = 0x5C
+	OpLcNodeBatch          uint8 = 0x5D
 
 	// backUp
 	OpBatchLockNormalExtent   uint8 = 0x57
@@ -294,6 +299,14 @@ const (
 	OpLeaseGenerationNotMatch           uint8 = 0x87
 	OpWriteOpOfProtoVerForbidden        uint8 = 0x88
 	OpMetaForbiddenMigration            uint8 = 0x89
+
+	// conditional dentry update, the dentry does not refer to the expected inode
+	OpDentryNotMatchErr uint8 = 0x8D
+	// the inode is moved out of the meta partition by a split, the view of the client is stale
+	OpInodeOutOfRangeErr uint8 = 0x8E
+	// conditional xattr update, the value of the xattr is not the expected one
+	OpXAttrNotMatchErr uint8 = 0x8F
+
 	// Distributed cache related OP codes.
 	OpFlashNodeHeartbeat        uint8 = 0xDA
 	OpFlashNodeCachePrepare     uint8 = 0xDB
@@ -545,6 +558,10 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaReadDirLimit"
 	case OpMetaLockDir:
 		m = "OpMetaLockDir"
+	case OpMetaCompareAndSetXAttr:
+		m = "OpMetaCompareAndSetXAttr"
+	case OpMetaCondUpdateDentry:
+		m = "OpMetaCondUpdateDentry"
 	case OpMetaInodeGet:
 		m = "OpMetaInodeGet"
 	case OpMetaBatchInodeGet:
@@ -713,6 +730,10 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpLcNodeScan"
 	case OpLcNodeSnapshotVerDel:
 		m = "OpLcNodeSnapshotVerDel"
+	case OpLcNodeRestore:
+		m = "OpLcNodeRestore"
+	case OpLcNodeBatch:
+		m = "OpLcNodeBatch"
 	case OpMetaReadDirOnly:
 		m = "OpMetaReadDirOnly"
 	case OpBackupRead:
@@ -751,6 +772,8 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpRemoveBackupMetaPartition"
 	case OpIsRaftStatusOk:
 		m = "OpIsRaftStatusOk"
+	case OpSplitMetaPartition:
+		m = "OpSplitMetaPartition"
 	case OpFlashSDKHeartbeat:
 		m = "OpFlashSDKHeartbeat"
 	default:
@@ -854,6 +877,12 @@ func (p *Packet) GetResultMsg() (m string) {
 		m = "OpLeaseGenerationNotMatch"
 	case OpWriteOpOfProtoVerForbidden:
 		m = "OpWriteOpOfProtoVerForbidden"
+	case OpDentryNotMatchErr:
+		m = "OpDentryNotMatchErr"
+	case OpXAttrNotMatchErr:
+		m = "OpXAttrNotMatchErr"
+	case OpInodeOutOfRangeErr:
+		m = "OpInodeOutOfRangeErr"
 	default:
 		return fmt.Sprintf("Unknown ResultCode(%v)", p.ResultCode)
 	}
@@ -122,49 +122,78 @@ const (
 	OSSGetBucketLifecycleConfigurationAction    Action = OSSActionPrefix + "GetBucketLifecycleConfiguration"
 	OSSPutBucketLifecycleConfigurationAction    Action = OSSActionPrefix + "PutBucketLifecycleConfiguration"
 	OSSDeleteBucketLifecycleConfigurationAction Action = OSSActionPrefix + "DeleteBucketLifecycleConfiguration"
+	OSSPutBucketInventoryConfigurationAction    Action = OSSActionPrefix + "PutBucketInventoryConfiguration"
+	OSSGetBucketInventoryConfigurationAction    Action = OSSActionPrefix + "GetBucketInventoryConfiguration"
+	OSSListBucketInventoryConfigurationsAction  Action = OSSActionPrefix + "ListBucketInventoryConfigurations"
+	OSSDeleteBucketInventoryConfigurationAction Action = OSSActionPrefix + "DeleteBucketInventoryConfiguration"
 
 	// Object storage version actions
-	OSSGetBucketVersioningAction Action = OSSActionPrefix + "GetBucketVersioning" // unsupported
-	OSSPutBucketVersioningAction Action = OSSActionPrefix + "PutBucketVersioning" // unsupported
-	OSSListObjectVersionsAction  Action = OSSActionPrefix + "ListObjectVersions"  // unsupported
+	OSSGetBucketVersioningAction Action = OSSActionPrefix + "GetBucketVersioning"
+	OSSPutBucketVersioningAction Action = OSSActionPrefix + "PutBucketVersioning"
+	OSSListObjectVersionsAction  Action = OSSActionPrefix + "ListObjectVersions"
 
 	// Object legal hold actions
-	OSSGetObjectLegalHoldAction Action = OSSActionPrefix + "GetObjectLegalHold" // unsupported
-	OSSPutObjectLegalHoldAction Action = OSSActionPrefix + "PutObjectLegalHold" // unsupported
+	OSSGetObjectLegalHoldAction Action = OSSActionPrefix + "GetObjectLegalHold"
+	OSSPutObjectLegalHoldAction Action = OSSActionPrefix + "PutObjectLegalHold"
 
 	// Object retention actions
 	OSSGetObjectRetentionAction Action = OSSActionPrefix + "GetObjectRetention" // unsupported
 	OSSPutObjectRetentionAction Action = OSSActionPrefix + "PutObjectRetention" // unsupported
 
 	// Bucket encryption actions
-	OSSGetBucketEncryptionAction    Action = OSSActionPrefix + "GetBucketEncryption"    // unsupported
-	OSSPutBucketEncryptionAction    Action = OSSActionPrefix + "PutBucketEncryption"    // unsupported
-	OSSDeleteBucketEncryptionAction Action = OSSActionPrefix + "DeleteBucketEncryption" // unsupported
+	OSSGetBucketEncryptionAction    Action = OSSActionPrefix + "GetBucketEncryption"
+	OSSPutBucketEncryptionAction    Action = OSSActionPrefix + "PutBucketEncryption"
+	OSSDeleteBucketEncryptionAction Action = OSSActionPrefix + "DeleteBucketEncryption"
 
 	// Bucket website actions
-	OSSGetBucketWebsiteAction    Action = OSSActionPrefix + "GetBucketWebsite"    // unsupported
-	OSSPutBucketWebsiteAction    Action = OSSActionPrefix + "PutBucketWebsite"    // unsupported
-	OSSDeleteBucketWebsiteAction Action = OSSActionPrefix + "DeleteBucketWebsite" // unsupported
+	OSSGetBucketWebsiteAction    Action = OSSActionPrefix + "GetBucketWebsite"
+	OSSPutBucketWebsiteAction    Action = OSSActionPrefix + "PutBucketWebsite"
+	OSSDeleteBucketWebsiteAction Action = OSSActionPrefix + "DeleteBucketWebsite"
 
 	// Object restore actions
-	OSSRestoreObjectAction Action = OSSActionPrefix + "RestoreObject" // unsupported
+	OSSRestoreObjectAction Action = OSSActionPrefix + "RestoreObject"
+
+	// Object select actions
+	OSSSelectObjectContentAction Action = OSSActionPrefix + "SelectObjectContent"
+
+	// Object append actions
+	OSSAppendObjectAction Action = OSSActionPrefix + "AppendObject"
+
+	// Object rename actions
+	OSSRenameObjectAction Action = OSSActionPrefix + "RenameObject"
 
 	// Public access block actions
-	OSSGetPublicAccessBlockAction    Action = OSSActionPrefix + "GetPublicAccessBlock"   // unsupported
-	OSSPutPublicAccessBlockAction    Action = OSSActionPrefix + "PutPublicAccessBlock"   // unsupported
-	OSSDeletePublicAccessBlockAction Action = OSSActionPrefix + "DeletePulicAccessBlock" // unuspported
+	OSSGetPublicAccessBlockAction    Action = OSSActionPrefix + "GetPublicAccessBlock"
+	OSSPutPublicAccessBlockAction    Action = OSSActionPrefix + "PutPublicAccessBlock"
+	OSSDeletePublicAccessBlockAction Action = OSSActionPrefix + "DeletePublicAccessBlock"
 
 	// Bucket request payment actions
 	OSSGetBucketRequestPaymentAction Action = OSSActionPrefix + "GetBucketRequestPayment" // unsupported
 	OSSPutBucketRequestPaymentAction Action = OSSActionPrefix + "PutBucketRequestPayment" // unsupported
 
 	// Bucket replication actions
-	OSSGetBucketReplicationAction    Action = OSSActionPrefix + "GetBucketReplicationAction"    // unsupported
-	OSSPutBucketReplicationAction    Action = OSSActionPrefix + "PutBucketReplicationAction"    // unsupported
-	OSSDeleteBucketReplicationAction Action = OSSActionPrefix + "DeleteBucketReplicationAction" // unsupported
+	OSSGetBucketReplicationAction    Action = OSSActionPrefix + "GetBucketReplicationAction"
+	OSSPutBucketReplicationAction    Action = OSSActionPrefix + "PutBucketReplicationAction"
+	OSSDeleteBucketReplicationAction Action = OSSActionPrefix + "DeleteBucketReplicationAction"
+
+	// Bucket notification actions
+	OSSGetBucketNotificationAction Action = OSSActionPrefix + "GetBucketNotification"
+	OSSPutBucketNotificationAction Action = OSSActionPrefix + "PutBucketNotification"
+
+	// Bucket logging actions
+	OSSGetBucketLoggingAction Action = OSSActionPrefix + "GetBucketLogging"
+	OSSPutBucketLoggingAction Action = OSSActionPrefix + "PutBucketLogging"
 
 	// STS actions
-	OSSGetFederationTokenAction Action = OSSActionPrefix + "GetFederationToken"
+	OSSGetFederationTokenAction        Action = OSSActionPrefix + "GetFederationToken"
+	OSSAssumeRoleAction                Action = OSSActionPrefix + "AssumeRole"
+	OSSAssumeRoleWithWebIdentityAction Action = OSSActionPrefix + "AssumeRoleWithWebIdentity"
+
+	// Batch operations actions
+	OSSCreateJobAction       Action = OSSActionPrefix + "CreateJob"
+	OSSDescribeJobAction     Action = OSSActionPrefix + "DescribeJob"
+	OSSListJobsAction        Action = OSSActionPrefix + "ListJobs"
+	OSSUpdateJobStatusAction Action = OSSActionPrefix + "UpdateJobStatus"
 
 	// constants for POSIX file system interface
 	POSIXReadAction  Action = POSIXActionPrefix + "Read"
@@ -224,6 +253,10 @@ var AllActions = []Action{
 	OSSGetBucketLifecycleConfigurationAction,
 	OSSPutBucketLifecycleConfigurationAction,
 	OSSDeleteBucketLifecycleConfigurationAction,
+	OSSPutBucketInventoryConfigurationAction,
+	OSSGetBucketInventoryConfigurationAction,
+	OSSListBucketInventoryConfigurationsAction,
+	OSSDeleteBucketInventoryConfigurationAction,
 	OSSGetBucketVersioningAction,
 	OSSPutBucketVersioningAction,
 	OSSListObjectVersionsAction,
@@ -241,6 +274,9 @@ var AllActions = []Action{
 	OSSPutBucketWebsiteAction,
 	OSSDeleteBucketWebsiteAction,
 	OSSRestoreObjectAction,
+	OSSSelectObjectContentAction,
+	OSSAppendObjectAction,
+	OSSRenameObjectAction,
 	OSSGetPublicAccessBlockAction,
 	OSSPutPublicAccessBlockAction,
 	OSSDeletePublicAccessBlockAction,
@@ -249,8 +285,18 @@ var AllActions = []Action{
 	OSSGetBucketReplicationAction,
 	OSSPutBucketReplicationAction,
 	OSSDeleteBucketReplicationAction,
+	OSSGetBucketNotificationAction,
+	OSSPutBucketNotificationAction,
+	OSSGetBucketLoggingAction,
+	OSSPutBucketLoggingAction,
 	OSSOptionsObjectAction,
 	OSSGetFederationTokenAction,
+	OSSAssumeRoleAction,
+	OSSAssumeRoleWithWebIdentityAction,
+	OSSCreateJobAction,
+	OSSDescribeJobAction,
+	OSSListJobsAction,
+	OSSUpdateJobStatusAction,
 
 	// POSIX file system interface actions
 	POSIXReadAction,
@@ -439,6 +485,8 @@ var builtinPermissionActionsMap = map[Permission]Actions{
 		OSSListPartsAction,
 		OSSCompleteMultipartUploadAction,
 		OSSAbortMultipartUploadAction,
+		OSSAppendObjectAction,
+		OSSRenameObjectAction,
 		OSSGetBucketLocationAction,
 		OSSGetObjectTaggingAction,
 		OSSPutObjectTaggingAction,
@@ -14,7 +14,10 @@
 
 package proto
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 const (
 	FlowLimit       = "f"
@@ -22,6 +25,14 @@ const (
 	ConcurrentLimit = "c"
 	S3Nodes         = "s3nodes"
 	DefaultUid      = "default"
+
+	// The scopes of the limits, the limits of the uid and the bucket are both checked.
+	S3QoSScopeUid    = "uid"
+	S3QoSScopeBucket = "bucket"
+
+	// S3QoSMinShareRatio is the ratio of the quota reserved for the ObjectNodes evenly, so that
+	// the requests to the idle nodes are not rejected before the quota is leased to them.
+	S3QoSMinShareRatio = 0.1
 )
 
 type UserLimitConf struct {
@@ -30,17 +41,118 @@ type UserLimitConf struct {
 	ConcurrentQuota map[string]uint64 `json:"concurrent_quota"` // uid --> concurrency
 }
 
+// BucketLimitConf is the quota shared by all users of the bucket, the concurrency of
+// buckets is not limited.
+type BucketLimitConf struct {
+	BandWidthQuota map[string]uint64 `json:"band_width_quota"` // bucket --> BytesPS
+	QPSQuota       map[string]uint64 `json:"qps_quota"`        // bucket --> QPS
+}
+
 type S3QosRequest struct {
-	Uid   string `json:"uid"`
-	Api   string `json:"api"`
-	Type  string `json:"type"`
-	Quota uint64 `json:"quota"`
-	Nodes uint64 `json:"nodes"`
+	Uid    string `json:"uid"`
+	Bucket string `json:"bucket"`
+	Api    string `json:"api"`
+	Type   string `json:"type"`
+	Quota  uint64 `json:"quota"`
+	Nodes  uint64 `json:"nodes"`
 }
 
 type S3QoSResponse struct {
-	ApiLimitConf map[string]*UserLimitConf `json:"user_limit_conf"` // api --> userLimitConf
-	Nodes        uint64                    `json:"nodes"`
+	ApiLimitConf    map[string]*UserLimitConf   `json:"user_limit_conf"`   // api --> userLimitConf
+	BucketLimitConf map[string]*BucketLimitConf `json:"bucket_limit_conf"` // api --> bucketLimitConf
+	Nodes           uint64                      `json:"nodes"`
+}
+
+// S3QoSLeaseRequest reports the demands of the limits observed by the ObjectNode in the last
+// interval, the demands are the tokens per second keyed by S3QoSLimitKey.
+type S3QoSLeaseRequest struct {
+	Node     string            `json:"node"`
+	Interval int64             `json:"interval"` // seconds
+	Demands  map[string]uint64 `json:"demands"`
+}
+
+// S3QoSLeaseResponse is the shares of the limits leased to the ObjectNode, the limits without
+// the share take the minimum share of the active nodes.
+type S3QoSLeaseResponse struct {
+	Shares map[string]uint64 `json:"shares"`
+	Nodes  uint64            `json:"nodes"`  // active nodes
+	Expire int64             `json:"expire"` // seconds
+}
+
+// S3QoSLimitKey returns the key of the limit, which is "<scope>/<api>/<uid or bucket>/<type>".
+func S3QoSLimitKey(scope, api, id, limitType string) string {
+	return scope + "/" + api + "/" + id + "/" + limitType
+}
+
+func ParseS3QoSLimitKey(key string) (scope, api, id, limitType string, ok bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 4 || (parts[0] != S3QoSScopeUid && parts[0] != S3QoSScopeBucket) ||
+		parts[1] == "" || parts[2] == "" || parts[3] == "" {
+		return
+	}
+	return parts[0], parts[1], parts[2], parts[3], true
+}
+
+// S3QoSMinShare returns the share of the quota reserved for each of the nodes, which is at least 1.
+func S3QoSMinShare(total, nodes uint64) uint64 {
+	if nodes == 0 {
+		nodes = 1
+	}
+	share := uint64(float64(total) * S3QoSMinShareRatio / float64(nodes))
+	if share == 0 {
+		share = 1
+	}
+	return share
+}
+
+// AllocateS3QoSShares divides the quota among the nodes by their demands. Each node takes the
+// minimum share first, the rest of the quota is allocated by max-min fairness of the demands
+// exceeding the minimum share, and the quota which is not demanded is divided evenly, so that
+// the sum of the shares is the quota.
+func AllocateS3QoSShares(total uint64, demands []uint64) []uint64 {
+	n := uint64(len(demands))
+	shares := make([]uint64, n)
+	if n == 0 {
+		return shares
+	}
+	minShare := S3QoSMinShare(total, n)
+	if minShare*n >= total {
+		// the quota is too small to be divided by the demands
+		for i := range shares {
+			shares[i] = total / n
+			if uint64(i) < total%n {
+				shares[i]++
+			}
+		}
+		return shares
+	}
+	rest := total - minShare*n
+	type excess struct {
+		index  int
+		demand uint64
+	}
+	excesses := make([]excess, 0, n)
+	for i, demand := range demands {
+		shares[i] = minShare
+		if demand > minShare {
+			excesses = append(excesses, excess{index: i, demand: demand - minShare})
+		}
+	}
+	sort.Slice(excesses, func(i, j int) bool { return excesses[i].demand < excesses[j].demand })
+	for i, e := range excesses {
+		fair := rest / uint64(len(excesses)-i)
+		if e.demand < fair {
+			fair = e.demand
+		}
+		shares[e.index] += fair
+		rest -= fair
+	}
+	for i := range shares {
+		spare := rest / (n - uint64(i))
+		shares[i] += spare
+		rest -= spare
+	}
+	return shares
 }
 
 func IsS3PutApi(api string) bool {
@@ -0,0 +1,51 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sumShares(shares []uint64) (sum uint64) {
+	for _, share := range shares {
+		sum += share
+	}
+	return
+}
+
+func TestAllocateS3QoSShares(t *testing.T) {
+	// the idle nodes only keep the minimum share and the spare quota
+	shares := AllocateS3QoSShares(1000, []uint64{5000, 0, 0, 0})
+	require.Equal(t, []uint64{925, 25, 25, 25}, shares)
+
+	// the demands below the fair share are satisfied, the spare is divided evenly
+	shares = AllocateS3QoSShares(1000, []uint64{100, 300, 0, 0})
+	require.Equal(t, uint64(1000), sumShares(shares))
+	require.GreaterOrEqual(t, shares[0], uint64(100))
+	require.GreaterOrEqual(t, shares[1], uint64(300))
+
+	// the competing demands are divided by max-min fairness
+	shares = AllocateS3QoSShares(1000, []uint64{200, 2000, 2000})
+	require.Equal(t, uint64(1000), sumShares(shares))
+	require.Equal(t, uint64(200), shares[0])
+	require.Equal(t, shares[1], shares[2])
+
+	// the quota smaller than the nodes
+	shares = AllocateS3QoSShares(2, []uint64{100, 100, 100})
+	require.Equal(t, []uint64{1, 1, 0}, shares)
+
+	require.Empty(t, AllocateS3QoSShares(100, nil))
+}
+
+func TestS3QoSLimitKey(t *testing.T) {
+	key := S3QoSLimitKey(S3QoSScopeBucket, "getobject", "photos", QPSLimit)
+	require.Equal(t, "bucket/getobject/photos/q", key)
+	scope, api, id, limitType, ok := ParseS3QoSLimitKey(key)
+	require.True(t, ok)
+	require.Equal(t, []string{S3QoSScopeBucket, "getobject", "photos", QPSLimit}, []string{scope, api, id, limitType})
+
+	for _, key := range []string{"", "uid/getobject/alice", "vol/getobject/alice/q", "uid//alice/q", "uid/a/b/c/d"} {
+		_, _, _, _, ok = ParseS3QoSLimitKey(key)
+		require.False(t, ok, key)
+	}
+}
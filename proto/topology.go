@@ -0,0 +1,62 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// the topology labels of the nodes, such as "rack", "row" and "pdu", are arbitrary,
+// and the volumes spread the replicas of the partitions by the values of one of them.
+var regexTopologyLabel = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,62}$`)
+
+// ValidTopologyLabel checks the key or the value of the topology label.
+func ValidTopologyLabel(s string) bool {
+	return regexTopologyLabel.MatchString(s)
+}
+
+// ParseTopologyLabels parses the topology labels in the config of the node,
+// which is a JSON object such as {"rack": "r1", "pdu": "p2"}.
+func ParseTopologyLabels(value interface{}) (labels map[string]string, err error) {
+	labels = make(map[string]string)
+	if value == nil {
+		return
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("topology labels should be an object of strings, got %v", value)
+	}
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok || !ValidTopologyLabel(k) || !ValidTopologyLabel(s) {
+			return nil, fmt.Errorf("invalid topology label %v: %v", k, v)
+		}
+		labels[k] = s
+	}
+	return
+}
+
+// SpreadViolation is a partition with more than one replica in the same failure domain,
+// which is the value of the spread label of the volume on the nodes.
+type SpreadViolation struct {
+	PartitionID   uint64
+	PartitionType string
+	VolName       string
+	SpreadLabel   string
+	Hosts         []string
+	Domains       []string // the failure domains of the hosts, empty if the node has no such label
+	Repairing     bool
+}
@@ -0,0 +1,24 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTopologyLabels(t *testing.T) {
+	labels, err := ParseTopologyLabels(nil)
+	require.NoError(t, err)
+	require.Empty(t, labels)
+
+	labels, err = ParseTopologyLabels(map[string]interface{}{"rack": "r1", "pdu": "p-2"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"rack": "r1", "pdu": "p-2"}, labels)
+
+	_, err = ParseTopologyLabels("rack=r1")
+	require.Error(t, err)
+	_, err = ParseTopologyLabels(map[string]interface{}{"rack": 1})
+	require.Error(t, err)
+	_, err = ParseTopologyLabels(map[string]interface{}{"rack": "r 1"})
+	require.Error(t, err)
+}
@@ -25,7 +25,8 @@ var (
 	SKRegexp   = regexp.MustCompile("^[a-zA-Z0-9]{32}$")
 	WriteS3Api = []string{
 		"PostObject", "PutObject", "CopyObject", "CreateMultipartUpload", "UploadPart", "UploadPartCopy",
-		"CompleteMultipartUpload", "AbortMultipartUpload", "DeleteObjects", "DeleteObject",
+		"CompleteMultipartUpload", "AbortMultipartUpload", "DeleteObjects", "DeleteObject", "AppendObject",
+		"RenameObject",
 	}
 )
 
@@ -375,3 +376,84 @@ type UserUpdateParam struct {
 	Password    string   `json:"password"`
 	Description string   `json:"description"`
 }
+
+const (
+	RoleMinSessionDuration     int64 = 900
+	RoleDefaultSessionDuration int64 = 3600
+	RoleMaxSessionDuration     int64 = 43200
+)
+
+// RoleInfo is the role which can be assumed by the principals allowed by the trust policy,
+// the temporary credentials of the role act on behalf of the owner with the permission policy.
+type RoleInfo struct {
+	RoleName           string `json:"role_name"`
+	OwnerID            string `json:"owner_id"`
+	TrustPolicy        string `json:"trust_policy"`
+	PermissionPolicy   string `json:"permission_policy"`
+	MaxSessionDuration int64  `json:"max_session_duration"`
+	Description        string `json:"description"`
+	CreateTime         string `json:"create_time"`
+	// Version changes whenever the role is created or updated, the sessions of the
+	// role issued before are invalid once it is changed.
+	Version uint64 `json:"version"`
+}
+
+func (i *RoleInfo) String() string {
+	if i == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%v_%v_%v_%v", i.OwnerID, i.RoleName, i.MaxSessionDuration, i.Version)
+}
+
+// RoleParam is the parameter to create or update the role, the policies are the JSON documents.
+type RoleParam struct {
+	RoleName           string `json:"role_name"`
+	OwnerID            string `json:"owner_id"`
+	TrustPolicy        string `json:"trust_policy"`
+	PermissionPolicy   string `json:"permission_policy"`
+	MaxSessionDuration int64  `json:"max_session_duration"`
+	Description        string `json:"description"`
+}
+
+// The roles of the API tokens, the cluster admin is granted all the admin APIs, the volume admin
+// and the node operator are granted the read-only APIs besides the APIs of their own.
+const (
+	APITokenRoleReadOnly     = "read-only"
+	APITokenRoleVolumeAdmin  = "volume-admin"
+	APITokenRoleNodeOperator = "node-operator"
+	APITokenRoleClusterAdmin = "cluster-admin"
+)
+
+func IsValidAPITokenRole(role string) bool {
+	switch role {
+	case APITokenRoleReadOnly, APITokenRoleVolumeAdmin, APITokenRoleNodeOperator, APITokenRoleClusterAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// APITokenRoleAllows returns if the role is granted the APIs which require the role required.
+func APITokenRoleAllows(role, required string) bool {
+	if !IsValidAPITokenRole(role) || !IsValidAPITokenRole(required) {
+		return false
+	}
+	switch role {
+	case APITokenRoleClusterAdmin:
+		return true
+	case APITokenRoleVolumeAdmin, APITokenRoleNodeOperator:
+		return required == role || required == APITokenRoleReadOnly
+	default:
+		return required == APITokenRoleReadOnly
+	}
+}
+
+// APITokenInfo is a named token to call the admin APIs of the master with the permissions of its role,
+// the token itself is only returned when it is created.
+type APITokenInfo struct {
+	Name        string `json:"name"`
+	Role        string `json:"role"`
+	Token       string `json:"token,omitempty"`
+	Description string `json:"description"`
+	CreateTime  string `json:"create_time"`
+}
@@ -0,0 +1,26 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPITokenRoleAllows(t *testing.T) {
+	roles := []string{APITokenRoleReadOnly, APITokenRoleVolumeAdmin, APITokenRoleNodeOperator, APITokenRoleClusterAdmin}
+	for _, required := range roles {
+		require.True(t, APITokenRoleAllows(APITokenRoleClusterAdmin, required))
+		require.Equal(t, required == APITokenRoleReadOnly, APITokenRoleAllows(APITokenRoleReadOnly, required))
+	}
+
+	require.True(t, APITokenRoleAllows(APITokenRoleVolumeAdmin, APITokenRoleReadOnly))
+	require.True(t, APITokenRoleAllows(APITokenRoleVolumeAdmin, APITokenRoleVolumeAdmin))
+	require.False(t, APITokenRoleAllows(APITokenRoleVolumeAdmin, APITokenRoleNodeOperator))
+	require.False(t, APITokenRoleAllows(APITokenRoleVolumeAdmin, APITokenRoleClusterAdmin))
+
+	require.True(t, APITokenRoleAllows(APITokenRoleNodeOperator, APITokenRoleNodeOperator))
+	require.False(t, APITokenRoleAllows(APITokenRoleNodeOperator, APITokenRoleVolumeAdmin))
+
+	require.False(t, APITokenRoleAllows("root", APITokenRoleReadOnly))
+	require.False(t, APITokenRoleAllows(APITokenRoleClusterAdmin, "root"))
+}
@@ -17,6 +17,7 @@ package raftstore
 import (
 	"fmt"
 	syslog "log"
+	"net"
 	"os"
 	"path"
 	"strconv"
@@ -26,10 +27,20 @@ import (
 	"github.com/cubefs/cubefs/depends/tiglabs/raft/logger"
 	"github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
 	"github.com/cubefs/cubefs/depends/tiglabs/raft/storage/wal"
+	raftutil "github.com/cubefs/cubefs/depends/tiglabs/raft/util"
 	raftlog "github.com/cubefs/cubefs/depends/tiglabs/raft/util/log"
 	utilConfig "github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
+func init() {
+	// the raft replication follows the TLS mode of the node
+	raftutil.Dial = tlsutil.DialTimeout
+	raftutil.Listen = func(addr string) (net.Listener, error) {
+		return tlsutil.Listen("tcp", addr)
+	}
+}
+
 // RaftStore defines the interface for the raft store.
 type RaftStore interface {
 	CreatePartition(cfg *PartitionConfig) (Partition, error)
@@ -287,7 +287,7 @@ func (rc *RemoteCache) Init(client *ExtentClient) (err error) {
 
 func (rc *RemoteCache) Read(ctx context.Context, fg *FlashGroup, inode uint64, req *CacheReadRequest) (read int, err error) {
 	var (
-		conn      *net.TCPConn
+		conn      net.Conn
 		moved     bool
 		addr      string
 		reqPacket *Packet
@@ -357,7 +357,7 @@ func (rc *RemoteCache) Read(ctx context.Context, fg *FlashGroup, inode uint64, r
 	return
 }
 
-func (rc *RemoteCache) getReadReply(conn *net.TCPConn, reqPacket *Packet, req *CacheReadRequest) (readBytes int, err error) {
+func (rc *RemoteCache) getReadReply(conn net.Conn, reqPacket *Packet, req *CacheReadRequest) (readBytes int, err error) {
 	for readBytes < int(req.Size_) {
 		replyPacket := NewFlashCacheReply()
 		start := time.Now()
@@ -388,7 +388,7 @@ func (rc *RemoteCache) getReadReply(conn *net.TCPConn, reqPacket *Packet, req *C
 
 func (rc *RemoteCache) Prepare(ctx context.Context, fg *FlashGroup, inode uint64, req *proto.CachePrepareRequest) (err error) {
 	var (
-		conn  *net.TCPConn
+		conn  net.Conn
 		moved bool
 	)
 	bg := stat.BeginStat()
@@ -731,7 +731,7 @@ func (rc *RemoteCache) GetFlashGroupBySlot(slot uint32) (*FlashGroup, uint32) {
 }
 
 func (rc *RemoteCache) HeartBeat(addr string) (duration time.Duration, err error) {
-	var conn *net.TCPConn
+	var conn net.Conn
 	packet := proto.NewPacket()
 	packet.Opcode = proto.OpFlashSDKHeartbeat
 
@@ -67,7 +67,7 @@ func (reader *ExtentReader) Read(req *ExtentRequest) (readBytes int, err error)
 
 	log.LogDebugf("ExtentReader Read enter: size(%v) req(%v) reqPacket(%v)", size, req, reqPacket)
 
-	err = sc.Send(&reader.retryRead, reqPacket, func(conn *net.TCPConn) (error, bool) {
+	err = sc.Send(&reader.retryRead, reqPacket, func(conn net.Conn) (error, bool) {
 		bgTime := stat.BeginStat()
 		defer func() {
 			addr := conn.RemoteAddr().String()
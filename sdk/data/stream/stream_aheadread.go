@@ -205,7 +205,7 @@ func (arw *AheadReadWindow) doTask(task *AheadReadTask) {
 	log.LogDebugf("aheadRead doTask key(%v) size(%v)", key, task.p.Size)
 
 	for _, host := range hosts {
-		err = sendToNode(host, task.p, func(conn *net.TCPConn) (error, bool) {
+		err = sendToNode(host, task.p, func(conn net.Conn) (error, bool) {
 			readBytes = 0
 			for readBytes < task.cacheSize {
 				rp := NewReply(task.p.ReqID, task.p.PartitionID, task.p.ExtentID)
@@ -421,7 +421,7 @@ func (s *Streamer) aheadRead(req *ExtentRequest) (readSize int, err error) {
 }
 
 func sendToNode(host string, p *Packet, getReply GetReplyFunc) (err error) {
-	var conn *net.TCPConn
+	var conn net.Conn
 	if conn, err = StreamConnPool.GetConnect(host); err != nil {
 		return
 	}
@@ -46,7 +46,7 @@ const (
 	RetryFactor             = 12 / 10
 )
 
-type GetReplyFunc func(conn *net.TCPConn) (err error, again bool)
+type GetReplyFunc func(conn net.Conn) (err error, again bool)
 
 // StreamConn defines the struct of the stream connection.
 type StreamConn struct {
@@ -292,7 +292,7 @@ func (sc *StreamConn) sendToDataPartitionByAddr(req *Packet, getReply GetReplyFu
 	}
 }
 
-func (sc *StreamConn) sendToConn(conn *net.TCPConn, req *Packet, getReply GetReplyFunc) (err error) {
+func (sc *StreamConn) sendToConn(conn net.Conn, req *Packet, getReply GetReplyFunc) (err error) {
 	for i := 0; i < StreamSendMaxRetry; i++ {
 		log.LogDebugf("sendToConn: send to addr(%v), reqPacket(%v)", sc.currAddr, req)
 		err = req.WriteToConn(conn)
@@ -463,7 +463,7 @@ func getDpHosts(dp *wrapper.DataPartition) (activeHosts, quorumHosts []string) {
 }
 
 func getAppliedID(partitionId uint64, addr string) (applyId uint64, err error) {
-	var conn *net.TCPConn
+	var conn net.Conn
 	if conn, err = StreamConnPool.GetConnect(addr); err != nil {
 		log.LogWarnf("getDpAppliedID: failed to create connection addr[%v] err[%v]", addr, err)
 		return
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
@@ -210,6 +211,16 @@ func (api *AdminAPI) DecommissionMetaPartition(metaPartitionID uint64, nodeAddr,
 	return
 }
 
+// SplitMetaPartition splits the meta partition at the inode boundary, a zero split key lets the
+// meta node pick the median inode.
+func (api *AdminAPI) SplitMetaPartition(metaPartitionID, splitKey uint64) (result string, err error) {
+	err = api.mc.requestWith(&result, newRequest(get, proto.AdminSplitMetaPartition).Header(api.h).Param(
+		anyParam{"id", metaPartitionID},
+		anyParam{"splitKey", splitKey},
+	))
+	return
+}
+
 func (api *AdminAPI) DeleteDataReplica(dataPartitionID uint64, nodeAddr, clientIDKey string, raftForce bool) (err error) {
 	request := newRequest(get, proto.AdminDeleteDataReplica).Header(api.h)
 	request.addParam("id", strconv.FormatUint(dataPartitionID, 10))
@@ -309,6 +320,7 @@ func (api *AdminAPI) UpdateVolume(
 	request := newRequest(get, proto.AdminUpdateVol).Header(api.h)
 	request.addParam("name", vv.Name)
 	request.addParam("description", vv.Description)
+	request.addParam("spreadLabel", vv.SpreadLabel)
 	request.addParam("crossZone", strconv.FormatBool(vv.CrossZone))
 	request.addParam("authKey", util.CalcAuthKey(vv.Owner))
 	request.addParam("zoneName", vv.ZoneName)
@@ -860,10 +872,66 @@ func (api *AdminAPI) DelBucketLifecycle(volume string) (err error) {
 	return
 }
 
+func (api *AdminAPI) SetBucketInventory(req *proto.BucketInventory) (err error) {
+	return api.mc.request(newRequest(post, proto.SetBucketInventory).Header(api.h).Body(req))
+}
+
+func (api *AdminAPI) GetBucketInventory(volume string) (inventory *proto.BucketInventory, err error) {
+	inventory = &proto.BucketInventory{}
+	err = api.mc.requestWith(inventory, newRequest(get, proto.GetBucketInventory).
+		Header(api.h).addParam("name", volume))
+	return
+}
+
+func (api *AdminAPI) DelBucketInventory(volume string) (err error) {
+	request := newRequest(get, proto.DeleteBucketInventory).Header(api.h)
+	request.addParam("name", volume)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) RestoreObject(req *proto.RestoreTask) (err error) {
+	return api.mc.request(newRequest(post, proto.RestoreObject).Header(api.h).Body(req))
+}
+
 func (api *AdminAPI) GetS3QoSInfo() (data []byte, err error) {
 	return api.mc.serveRequest(newRequest(get, proto.S3QoSGet).Header(api.h))
 }
 
+func (api *AdminAPI) LeaseS3QoS(req *proto.S3QoSLeaseRequest) (resp *proto.S3QoSLeaseResponse, err error) {
+	resp = &proto.S3QoSLeaseResponse{}
+	err = api.mc.requestWith(resp, newRequest(post, proto.S3QoSLease).Header(api.h).Body(req))
+	return
+}
+
+func (api *AdminAPI) CreateBatchJob(job *proto.BatchJob) (created *proto.BatchJob, err error) {
+	created = &proto.BatchJob{}
+	err = api.mc.requestWith(created, newRequest(post, proto.BatchJobCreate).Header(api.h).Body(job))
+	return
+}
+
+// GetBatchJob returns the batch job of the owner, or the job of any owner if the owner is empty.
+func (api *AdminAPI) GetBatchJob(id, owner string) (job *proto.BatchJob, err error) {
+	job = &proto.BatchJob{}
+	err = api.mc.requestWith(job, newRequest(get, proto.BatchJobGet).Header(api.h).
+		addParam("id", id).addParam("owner", owner))
+	return
+}
+
+// ListBatchJobs returns the batch jobs of the owner in the statuses, all the jobs if the statuses are empty.
+func (api *AdminAPI) ListBatchJobs(owner string, statuses []string) (jobs []*proto.BatchJob, err error) {
+	jobs = make([]*proto.BatchJob, 0)
+	err = api.mc.requestWith(&jobs, newRequest(get, proto.BatchJobList).Header(api.h).
+		addParam("owner", owner).addParam("status", strings.Join(statuses, ",")))
+	return
+}
+
+func (api *AdminAPI) UpdateBatchJobStatus(req *proto.BatchJobStatusRequest) (job *proto.BatchJob, err error) {
+	job = &proto.BatchJob{}
+	err = api.mc.requestWith(job, newRequest(post, proto.BatchJobUpdateStatus).Header(api.h).Body(req))
+	return
+}
+
 func (api *AdminAPI) SetAutoDecommissionDisk(enable bool) (err error) {
 	request := newRequest(post, proto.AdminEnableAutoDecommissionDisk)
 	request.addParam("enable", strconv.FormatBool(enable))
@@ -911,6 +979,19 @@ func (api *AdminAPI) QueryDecommissionToken() (status []proto.DecommissionTokenS
 	return
 }
 
+func (api *AdminAPI) GetSpreadViolations() (violations []*proto.SpreadViolation, err error) {
+	var buf []byte
+	request := newAPIRequest(http.MethodGet, proto.AdminGetSpreadViolations)
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	violations = make([]*proto.SpreadViolation, 0)
+	if err = json.Unmarshal(buf, &violations); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) SetVolTrashInterval(volName string, authKey string, interval time.Duration) (err error) {
 	request := newAPIRequest(http.MethodPost, proto.AdminSetTrashInterval)
 	request.addParam("name", volName)
@@ -1047,3 +1128,60 @@ func (api *AdminAPI) DeleteMetaNodeBalanceTask() (result string, err error) {
 	err = api.mc.requestWith(&result, newRequest(get, proto.DeleteMetaNodeBalanceTask).Header(api.h))
 	return
 }
+
+func (api *AdminAPI) CreateDataNodeBalanceTask(parallel int) (task *proto.DataClusterPlan, err error) {
+	task = &proto.DataClusterPlan{}
+	err = api.mc.requestWith(task, newRequest(get, proto.CreateDataNodeBalanceTask).Header(api.h).Param(
+		anyParam{"parallel", parallel},
+	))
+	return
+}
+
+func (api *AdminAPI) GetDataNodeBalanceTask() (task *proto.DataClusterPlan, err error) {
+	task = &proto.DataClusterPlan{}
+	err = api.mc.requestWith(task, newRequest(get, proto.GetDataNodeBalanceTask).Header(api.h))
+	return
+}
+
+func (api *AdminAPI) RunDataNodeBalanceTask() (result string, err error) {
+	err = api.mc.requestWith(&result, newRequest(get, proto.RunDataNodeBalanceTask).Header(api.h))
+	return
+}
+
+func (api *AdminAPI) StopDataNodeBalanceTask() (result string, err error) {
+	err = api.mc.requestWith(&result, newRequest(get, proto.StopDataNodeBalanceTask).Header(api.h))
+	return
+}
+
+func (api *AdminAPI) DeleteDataNodeBalanceTask() (result string, err error) {
+	err = api.mc.requestWith(&result, newRequest(get, proto.DeleteDataNodeBalanceTask).Header(api.h))
+	return
+}
+
+// CreateAPIToken creates a named API token of the role, the token is only returned once.
+func (api *AdminAPI) CreateAPIToken(name, role, description string) (info *proto.APITokenInfo, err error) {
+	info = &proto.APITokenInfo{}
+	err = api.mc.requestWith(info, newRequest(post, proto.AdminAPITokenCreate).Header(api.h).
+		addParam("name", name).addParam("role", role).addParam("description", description))
+	return
+}
+
+func (api *AdminAPI) DeleteAPIToken(name string) (err error) {
+	return api.mc.request(newRequest(post, proto.AdminAPITokenDelete).Header(api.h).addParam("name", name))
+}
+
+func (api *AdminAPI) ListAPITokens() (tokens []*proto.APITokenInfo, err error) {
+	tokens = make([]*proto.APITokenInfo, 0)
+	err = api.mc.requestWith(&tokens, newRequest(get, proto.AdminAPITokenList).Header(api.h))
+	return
+}
+
+func (api *AdminAPI) SetTLSTrust(info *proto.TLSTrustInfo) (err error) {
+	return api.mc.request(newRequest(post, proto.AdminSetTLSTrust).Header(api.h).Body(info))
+}
+
+func (api *AdminAPI) GetTLSTrust() (info *proto.TLSTrustInfo, err error) {
+	info = &proto.TLSTrustInfo{}
+	err = api.mc.requestWith(info, newRequest(get, proto.AdminGetTLSTrust).Header(api.h))
+	return
+}
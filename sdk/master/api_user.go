@@ -127,3 +127,36 @@ func (api *UserAPI) ListUsersOfVol(vol string) (users []string, err error) {
 	err = api.mc.requestWith(&users, newRequest(get, proto.UsersOfVol).Header(api.h).addParam("name", vol))
 	return
 }
+
+func (api *UserAPI) CreateRole(param *proto.RoleParam, clientIDKey string) (roleInfo *proto.RoleInfo, err error) {
+	roleInfo = &proto.RoleInfo{}
+	err = api.mc.requestWith(roleInfo, newRequest(post, proto.UserRoleCreate).
+		Header(api.h).Body(param).addParam("clientIDKey", clientIDKey))
+	return
+}
+
+func (api *UserAPI) UpdateRole(param *proto.RoleParam, clientIDKey string) (roleInfo *proto.RoleInfo, err error) {
+	roleInfo = &proto.RoleInfo{}
+	err = api.mc.requestWith(roleInfo, newRequest(post, proto.UserRoleUpdate).
+		Header(api.h).Body(param).addParam("clientIDKey", clientIDKey))
+	return
+}
+
+func (api *UserAPI) DeleteRole(userID, roleName, clientIDKey string) (err error) {
+	return api.mc.request(newRequest(post, proto.UserRoleDelete).Header(api.h).
+		addParam("user", userID).addParam("role", roleName).addParam("clientIDKey", clientIDKey))
+}
+
+func (api *UserAPI) GetRole(userID, roleName, clientIDKey string) (roleInfo *proto.RoleInfo, err error) {
+	roleInfo = &proto.RoleInfo{}
+	err = api.mc.requestWith(roleInfo, newRequest(get, proto.UserRoleGetInfo).Header(api.h).
+		addParam("user", userID).addParam("role", roleName).addParam("clientIDKey", clientIDKey))
+	return
+}
+
+func (api *UserAPI) ListRoles(userID, clientIDKey string) (roles []*proto.RoleInfo, err error) {
+	roles = make([]*proto.RoleInfo, 0)
+	err = api.mc.requestWith(&roles, newRequest(get, proto.UserRoleList).Header(api.h).
+		addParam("user", userID).addParam("clientIDKey", clientIDKey))
+	return
+}
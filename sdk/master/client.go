@@ -62,6 +62,7 @@ type MasterClient struct {
 	leaderAddr  string
 	timeout     time.Duration
 	clientIDKey string
+	apiToken    string
 	client      *http.Client
 
 	adminAPI  *AdminAPI
@@ -146,6 +147,13 @@ func (c *MasterClient) SetClientIDKey(clientIDKey string) {
 	c.Unlock()
 }
 
+// SetAPIToken sets the token sent with the requests to the admin APIs.
+func (c *MasterClient) SetAPIToken(apiToken string) {
+	c.Lock()
+	c.apiToken = apiToken
+	c.Unlock()
+}
+
 func (c *MasterClient) serveRequest(r *request) (repsData []byte, err error) {
 	leaderAddr, nodes := c.prepareRequest()
 	host := leaderAddr
@@ -331,6 +339,11 @@ func (c *MasterClient) httpRequest(method, url string, r *request) (resp *http.R
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Connection", "close")
+	c.RLock()
+	if c.apiToken != "" {
+		req.Header.Set(proto.HeaderAPIToken, c.apiToken)
+	}
+	c.RUnlock()
 	for k, v := range r.header {
 		req.Header.Set(k, v)
 	}
@@ -61,6 +61,9 @@ const (
 	ForceUpdateRWMP             = "ForceUpdateRWMP"
 )
 
+// ErrObjectLocked is returned if the object is retained or under legal hold.
+var ErrObjectLocked = errors.New("Access Denied")
+
 func (mw *MetaWrapper) GetRootIno(subdir string) (uint64, error) {
 	rootIno, err := mw.LookupPath(subdir)
 	if err != nil {
@@ -923,6 +926,11 @@ deleteDirectly:
 	}
 	log.LogDebugf("action[Delete_ll] parentID %v name %v verSeq %v", parentID, name, verSeq)
 	status, info, err = mw.iunlink(mp, inode, verSeq, denVer, fullPath)
+	if status == statusNotPerm {
+		// the file protected by the object lock can not be removed
+		mw.restoreLockedDentry(parentMP, parentID, name, mp, inode, fullPath)
+		return nil, syscall.EPERM
+	}
 	if err != nil || status != statusOK {
 		log.LogDebugf("action[Delete_ll] parentID %v inode %v name %v verSeq %v err %v", parentID, inode, name, verSeq, err)
 		return nil, nil
@@ -931,38 +939,27 @@ deleteDirectly:
 	return info, nil
 }
 
-func isObjectLocked(mw *MetaWrapper, inode uint64, name string) error {
-	xattrInfo, err := mw.XAttrGet_ll(inode, "oss:lock")
-	if err != nil {
-		log.LogErrorf("isObjectLocked: check ObjectLock err(%v) name(%v)", err, name)
-		return err
+// restoreLockedDentry re-creates the dentry of the inode whose unlink is rejected by the
+// metanode, since the inode is protected by the object lock.
+func (mw *MetaWrapper) restoreLockedDentry(parentMP *MetaPartition, parentID uint64, name string, mp *MetaPartition, inode uint64, fullPath string) {
+	status, info, err := mw.iget(mp, inode, 0)
+	if err == nil && status == statusOK {
+		status, err = mw.dcreate(parentMP, parentID, name, inode, info.Mode, fullPath, false)
 	}
-	retainUntilDate := xattrInfo.Get("oss:lock")
-	if len(retainUntilDate) > 0 {
-		retainUntilDateInt64, err := strconv.ParseInt(string(retainUntilDate), 10, 64)
-		if err != nil {
-			return err
-		}
-		if retainUntilDateInt64 > time.Now().UnixNano() {
-			log.LogWarnf("isObjectLocked: object is locked, retainUntilDate(%v) name(%v)", retainUntilDateInt64, name)
-			return errors.New("Access Denied")
-		}
+	if err != nil || status != statusOK {
+		log.LogErrorf("restoreLockedDentry: parentID(%v) name(%v) ino(%v) status(%v) err(%v)",
+			parentID, name, inode, status, err)
 	}
-	return nil
 }
 
 func (mw *MetaWrapper) deletewithcond_ll(parentID, cond uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error) {
-	err := isObjectLocked(mw, cond, name)
-	if err != nil {
-		return nil, err
-	}
-
 	var (
 		status int
 		inode  uint64
 		mode   uint32
 		info   *proto.InodeInfo
 		mp     *MetaPartition
+		err    error
 	)
 
 	parentMP := mw.getPartitionByInode(parentID)
@@ -1033,6 +1030,10 @@ func (mw *MetaWrapper) deletewithcond_ll(parentID, cond uint64, name string, isD
 	}
 
 	status, info, err = mw.iunlink(mp, resp.Items[0].Inode, 0, 0, fullPath)
+	if status == statusNotPerm {
+		mw.restoreLockedDentry(parentMP, parentID, name, mp, resp.Items[0].Inode, fullPath)
+		return nil, ErrObjectLocked
+	}
 	if err != nil || status != statusOK {
 		return nil, nil
 	}
@@ -1394,6 +1395,22 @@ func (mw *MetaWrapper) DentryUpdate_ll(parentID uint64, name string, inode uint6
 	return
 }
 
+// DentryCompareAndUpdate_ll updates the dentry to the inode only if it still refers to the
+// expected inode, it returns syscall.ESTALE if the dentry has been updated to another inode.
+func (mw *MetaWrapper) DentryCompareAndUpdate_ll(parentID uint64, name string, inode, expectedInode uint64,
+	fullPath string,
+) (err error) {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		return syscall.ENOENT
+	}
+	status, _, err := mw.dcondupdate(parentMP, parentID, name, inode, expectedInode, fullPath)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+	return nil
+}
+
 func (mw *MetaWrapper) SplitExtentKey(parentInode, inode uint64, ek proto.ExtentKey, storageClass uint32) error {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
@@ -2037,6 +2054,24 @@ func (mw *MetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
 	return nil
 }
 
+// XAttrCompareAndSet_ll sets the xattr only if its value is the expected one, the empty expected value
+// matches the xattr absent and the empty value removes the xattr. It returns syscall.ESTALE if the
+// value is not the expected one, the caller reloads the xattr and retries.
+func (mw *MetaWrapper) XAttrCompareAndSet_ll(inode uint64, name string, expected, value []byte) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("XAttrCompareAndSet_ll: no such partition, inode(%v)", inode)
+		return syscall.ENOENT
+	}
+	status, err := mw.compareAndSetXAttr(mp, inode, []byte(name), expected, value)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+	log.LogDebugf("XAttrCompareAndSet_ll: set xattr: volume(%v) inode(%v) name(%v) status(%v)",
+		mw.volname, inode, name, status)
+	return nil
+}
+
 func (mw *MetaWrapper) BatchSetXAttr_ll(inode uint64, attrs map[string]string) error {
 	var err error
 	mp := mw.getPartitionByInode(inode)
@@ -37,7 +37,7 @@ const (
 )
 
 type MetaConn struct {
-	conn *net.TCPConn
+	conn net.Conn
 	id   uint64 // PartitionID
 	addr string // MetaNode addr
 }
@@ -157,6 +157,14 @@ retry:
 
 out:
 	log.LogDebugf("sendToMetaPartition: succeed! req(%v) mc(%v) resp(%v)", req, mc, resp)
+	if resp != nil && resp.ResultCode == proto.OpInodeOutOfRangeErr {
+		// the partition is split, refresh the view for the retries of the caller
+		log.LogWarnf("sendToMetaPartition: mp(%v) range is stale, req(%v)", mp, req)
+		mw.singleflight.Do(ForceUpdateRWMP, func() (interface{}, error) {
+			mw.triggerAndWaitForceUpdate()
+			return nil, nil
+		})
+	}
 	if mw.Client != nil && resp != nil { // For compatibility with LcNode, the client checks whether it is nil
 		mw.checkVerFromMeta(resp)
 	}
@@ -61,6 +61,9 @@ const (
 	statusNotEmpty
 	statusLeaseOccupiedByOthers
 	statusLeaseGenerationNotMatch
+	statusDentryNotMatch
+	statusXAttrNotMatch
+	statusOutOfRange
 )
 
 const (
@@ -408,6 +411,12 @@ func parseStatus(result uint8) (status int) {
 		status = statusLeaseOccupiedByOthers
 	case proto.OpLeaseGenerationNotMatch:
 		status = statusLeaseGenerationNotMatch
+	case proto.OpDentryNotMatchErr:
+		status = statusDentryNotMatch
+	case proto.OpXAttrNotMatchErr:
+		status = statusXAttrNotMatch
+	case proto.OpInodeOutOfRangeErr:
+		status = statusOutOfRange
 	default:
 		status = statusError
 	}
@@ -463,6 +472,10 @@ func statusToErrno(status int) error {
 		return errors.New("lease occupied by others")
 	case statusLeaseGenerationNotMatch:
 		return errors.New("lease generation not match")
+	case statusDentryNotMatch, statusXAttrNotMatch:
+		return syscall.ESTALE
+	case statusOutOfRange:
+		return syscall.ESTALE
 	default:
 	}
 	return syscall.EIO
@@ -687,6 +687,14 @@ func (mw *MetaWrapper) txDupdate(tx *Transaction, mp *MetaPartition, parentID ui
 }
 
 func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string, newInode uint64, fullPath string) (status int, oldInode uint64, err error) {
+	return mw.dcondupdate(mp, parentID, name, newInode, 0, fullPath)
+}
+
+// dcondupdate updates the dentry only if it refers to the expected inode, or updates it
+// unconditionally if the expected inode is 0.
+func (mw *MetaWrapper) dcondupdate(mp *MetaPartition, parentID uint64, name string, newInode, expectedInode uint64,
+	fullPath string,
+) (status int, oldInode uint64, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
 		stat.EndStat("dupdate", err, bgTime, 1)
@@ -702,11 +710,15 @@ func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string,
 		ParentID:    parentID,
 		Name:        name,
 		Inode:       newInode,
+		OldInode:    expectedInode,
 	}
 	req.FullPaths = []string{fullPath}
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaUpdateDentry
+	if expectedInode != 0 {
+		packet.Opcode = proto.OpMetaCondUpdateDentry
+	}
 	packet.PartitionID = mp.PartitionID
 	err = packet.MarshalData(req)
 	if err != nil {
@@ -2087,6 +2099,55 @@ func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, va
 	return
 }
 
+// compareAndSetXAttr sets the xattr only if its value is the expected one, the xattr is removed if the value is empty.
+func (mw *MetaWrapper) compareAndSetXAttr(mp *MetaPartition, inode uint64, name, expected, value []byte) (status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("compareAndSetXAttr", err, bgTime, 1)
+	}()
+
+	req := &proto.CompareAndSetXAttrRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Key:         string(name),
+		Expected:    string(expected),
+		Value:       string(value),
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaCompareAndSetXAttr
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("compareAndSetXAttr: matshal packet fail, err(%v)", err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("compareAndSetXAttr: send to partition fail, packet(%v) mp(%v) inode(%v) key(%v) err(%v)",
+			packet, mp, inode, req.Key, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK && status != statusXAttrNotMatch {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("compareAndSetXAttr: received fail status, packet(%v) mp(%v) inode(%v) key(%v) result(%v)",
+			packet, mp, inode, req.Key, packet.GetResultMsg())
+		return
+	}
+
+	log.LogDebugf("compareAndSetXAttr: packet(%v) mp(%v) inode(%v) key(%v) result(%v)", packet, mp, inode, req.Key, packet.GetResultMsg())
+	return
+}
+
 func (mw *MetaWrapper) getAllXAttr(mp *MetaPartition, inode uint64) (attrs map[string]string, status int, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
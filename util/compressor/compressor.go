@@ -14,6 +14,8 @@
 
 package compressor
 
+import "io"
+
 const EncodingGzip = "gzip"
 
 // Compressor bytes compressor.
@@ -21,6 +23,8 @@ const EncodingGzip = "gzip"
 type Compressor interface {
 	Compress([]byte) ([]byte, error)
 	Decompress([]byte) ([]byte, error)
+	// NewReader returns a reader decompressing the stream.
+	NewReader(io.Reader) (io.ReadCloser, error)
 }
 
 type none struct{}
@@ -28,6 +32,8 @@ type none struct{}
 func (none) Compress(pb []byte) ([]byte, error)   { return pb, nil }
 func (none) Decompress(cb []byte) ([]byte, error) { return cb, nil }
 
+func (none) NewReader(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(r), nil }
+
 var compressors = make(map[string]func() Compressor)
 
 func init() {
@@ -50,3 +50,7 @@ func (gzipCompressor) Decompress(cb []byte) ([]byte, error) {
 	}
 	return buffer.Bytes(), nil
 }
+
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
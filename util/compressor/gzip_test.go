@@ -15,7 +15,9 @@
 package compressor_test
 
 import (
+	"bytes"
 	"crypto/rand"
+	"io"
 	"testing"
 
 	"github.com/cubefs/cubefs/util/compressor"
@@ -36,6 +38,23 @@ func TestCompressor_Gzip(t *testing.T) {
 	}
 }
 
+func TestCompressor_GzipReader(t *testing.T) {
+	buf := make([]byte, 1024)
+	rand.Read(buf)
+	c := compressor.New(compressor.EncodingGzip)
+	cbuf, err := c.Compress(buf)
+	require.NoError(t, err)
+	r, err := c.NewReader(bytes.NewReader(cbuf))
+	require.NoError(t, err)
+	pbuf, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, buf, pbuf)
+
+	_, err = c.NewReader(bytes.NewReader(buf))
+	require.Error(t, err)
+}
+
 func Benchmark_Gzip(b *testing.B) {
 	buf := make([]byte, 1024)
 	rand.Read(buf)
@@ -19,10 +19,12 @@ import (
 	"net"
 	"sync"
 	"time"
+
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 type Object struct {
-	conn *net.TCPConn
+	conn net.Conn
 	idle int64
 }
 
@@ -73,19 +75,11 @@ func NewConnectPoolWithTimeoutAndCap(minCap, maxCap int, idleConnTimeout time.Du
 	return cp
 }
 
-func DailTimeOut(target string, timeout time.Duration) (c *net.TCPConn, err error) {
-	var connect net.Conn
-	connect, err = net.DialTimeout("tcp", target, timeout)
-	if err == nil {
-		conn := connect.(*net.TCPConn)
-		conn.SetKeepAlive(true)
-		conn.SetNoDelay(true)
-		c = conn
-	}
-	return
+func DailTimeOut(target string, timeout time.Duration) (c net.Conn, err error) {
+	return tlsutil.DialTimeout(target, timeout)
 }
 
-func (cp *ConnectPool) GetConnect(targetAddr string) (c *net.TCPConn, err error) {
+func (cp *ConnectPool) GetConnect(targetAddr string) (c net.Conn, err error) {
 	cp.RLock()
 	pool, ok := cp.pools[targetAddr]
 	cp.RUnlock()
@@ -117,11 +111,11 @@ func (cp *ConnectPool) ReleaseAll(addr net.Addr) {
 	pool.ReleaseAll()
 }
 
-func (cp *ConnectPool) PutConnect(c *net.TCPConn, forceClose bool) {
+func (cp *ConnectPool) PutConnect(c net.Conn, forceClose bool) {
 	cp.PutConnectV2(c, forceClose, "")
 }
 
-func (cp *ConnectPool) PutConnectV2(c *net.TCPConn, forceClose bool, addr string) {
+func (cp *ConnectPool) PutConnectV2(c net.Conn, forceClose bool, addr string) {
 	if c == nil {
 		return
 	}
@@ -149,7 +143,7 @@ func (cp *ConnectPool) PutConnectV2(c *net.TCPConn, forceClose bool, addr string
 	pool.PutConnectObjectToPool(object)
 }
 
-func (cp *ConnectPool) PutConnectEx(c *net.TCPConn, err error) {
+func (cp *ConnectPool) PutConnectEx(c net.Conn, err error) {
 	if c == nil {
 		return
 	}
@@ -225,11 +219,8 @@ func NewPool(min, max int, timeout, connectTimeout int64, target string) (p *Poo
 
 func (p *Pool) initAllConnect() {
 	for i := 0; i < p.mincap; i++ {
-		c, err := net.Dial("tcp", p.target)
+		conn, err := tlsutil.DialTimeout(p.target, time.Duration(p.connectTimeout)*time.Second)
 		if err == nil {
-			conn := c.(*net.TCPConn)
-			conn.SetKeepAlive(true)
-			conn.SetNoDelay(true)
 			o := &Object{conn: conn, idle: time.Now().UnixNano()}
 			p.PutConnectObjectToPool(o)
 		}
@@ -276,19 +267,11 @@ func (p *Pool) ReleaseAll() {
 	}
 }
 
-func (p *Pool) NewConnect(target string) (c *net.TCPConn, err error) {
-	var connect net.Conn
-	connect, err = net.DialTimeout("tcp", p.target, time.Duration(p.connectTimeout)*time.Second)
-	if err == nil {
-		conn := connect.(*net.TCPConn)
-		conn.SetKeepAlive(true)
-		conn.SetNoDelay(true)
-		c = conn
-	}
-	return
+func (p *Pool) NewConnect(target string) (c net.Conn, err error) {
+	return tlsutil.DialTimeout(p.target, time.Duration(p.connectTimeout)*time.Second)
 }
 
-func (p *Pool) GetConnectFromPool() (c *net.TCPConn, err error) {
+func (p *Pool) GetConnectFromPool() (c net.Conn, err error) {
 	var o *Object
 	for {
 		select {
@@ -28,6 +28,7 @@ import (
 
 	"github.com/cubefs/cubefs/depends/xtaci/smux"
 	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/tlsutil"
 )
 
 const (
@@ -351,7 +352,7 @@ func (p *SmuxPool) initSessions() {
 	p.sessionsLock.Lock()
 	defer p.sessionsLock.Unlock()
 	for i := 0; i < connPreAlloc; i++ {
-		conn, err := net.DialTimeout("tcp", p.target, p.cfg.DialTimeout)
+		conn, err := tlsutil.DialTimeout(p.target, p.cfg.DialTimeout)
 		if err != nil {
 			continue
 		}
@@ -588,16 +589,13 @@ func (p *SmuxPool) casCreateCall(prev *createSessCall, new *createSessCall) bool
 func (p *SmuxPool) handleCreateCall(call *createSessCall) {
 	var conn net.Conn
 	defer close(call.notify)
-	conn, call.err = net.DialTimeout("tcp", p.target, p.cfg.DialTimeout)
+	conn, call.err = tlsutil.DialTimeout(p.target, p.cfg.DialTimeout)
 	if call.err != nil {
 		return
 	}
-	c := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
 	call.sess, call.err = smux.Client(conn, p.cfg.Config)
 	if call.err != nil {
-		c.Close()
+		conn.Close()
 		return
 	}
 	p.insertSession(call.sess)
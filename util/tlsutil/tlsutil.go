@@ -0,0 +1,658 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package tlsutil secures the TCP traffic between the nodes and the clients with TLS 1.3
+// and the mutual authentication by the certificates.
+//
+// The mode of a node is one of:
+//
+//	disable    plaintext only
+//	permissive accept both TLS and plaintext, dial plaintext
+//	prefer     accept both TLS and plaintext, dial TLS and fall back to plaintext if the handshake fails
+//	strict     accept and dial TLS only
+//
+// A cluster is upgraded node by node from disable through permissive and prefer to strict.
+// The certificates are reloaded when the files change. The mode distributed by the master only takes
+// effect if it is stricter than the local mode. The mode and the CA bundle distributed by the master are
+// only accepted over the TLS connections whose peer is verified by the local CA file, so the node without
+// the local CA file only takes the mode of the local config.
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ModeDisable    = "disable"
+	ModePermissive = "permissive"
+	ModePrefer     = "prefer"
+	ModeStrict     = "strict"
+)
+
+const (
+	CfgTLSMode           = "tlsMode"
+	CfgTLSCertFile       = "tlsCertFile"
+	CfgTLSKeyFile        = "tlsKeyFile"
+	CfgTLSCAFile         = "tlsCAFile"
+	CfgTLSReloadInterval = "tlsReloadInterval" // in seconds
+)
+
+const (
+	defaultReloadInterval = 60 * time.Second
+	defaultHandshakeTime  = 5 * time.Second
+	recordTypeHandshake   = 0x16
+)
+
+var (
+	ErrInvalidMode       = errors.New("invalid tls mode")
+	ErrNoCertificate     = errors.New("no tls certificate loaded")
+	ErrNoTrustedCA       = errors.New("no trusted tls CA loaded")
+	ErrPlaintextRejected = errors.New("plaintext connection rejected by tls strict mode")
+)
+
+// Config is the local TLS config of a node.
+type Config struct {
+	Mode           string
+	CertFile       string
+	KeyFile        string
+	CAFile         string
+	ReloadInterval time.Duration
+}
+
+// IsValidMode returns whether the mode is a valid TLS mode, the empty mode is valid as disable.
+func IsValidMode(mode string) bool {
+	switch mode {
+	case "", ModeDisable, ModePermissive, ModePrefer, ModeStrict:
+		return true
+	}
+	return false
+}
+
+type state struct {
+	localMode  string
+	trustMode  string
+	trustCAPem string
+	cert       *tls.Certificate
+	localCAPem []byte
+	pool       *x509.CertPool
+}
+
+var modeLevels = map[string]int{
+	"":             0,
+	ModeDisable:    0,
+	ModePermissive: 1,
+	ModePrefer:     2,
+	ModeStrict:     3,
+}
+
+// mode returns the stricter one of the local mode and the mode distributed by the master, the mode
+// distributed never weakens the local one.
+func (s *state) mode() string {
+	mode := s.localMode
+	if modeLevels[s.trustMode] > modeLevels[mode] {
+		mode = s.trustMode
+	}
+	if mode == "" {
+		return ModeDisable
+	}
+	return mode
+}
+
+type manager struct {
+	sync.Mutex
+	cfg     Config
+	modTime map[string]time.Time
+	stopC   chan struct{}
+	state   atomic.Value // *state
+}
+
+var mgr = newManager()
+
+func newManager() *manager {
+	m := &manager{modTime: make(map[string]time.Time)}
+	m.state.Store(&state{})
+	return m
+}
+
+func (m *manager) load() *state {
+	return m.state.Load().(*state)
+}
+
+// Init loads the TLS config of the node from the config file.
+func Init(cfg *config.Config) (err error) {
+	c := &Config{
+		Mode:     strings.ToLower(cfg.GetString(CfgTLSMode)),
+		CertFile: cfg.GetString(CfgTLSCertFile),
+		KeyFile:  cfg.GetString(CfgTLSKeyFile),
+		CAFile:   cfg.GetString(CfgTLSCAFile),
+	}
+	if interval := cfg.GetInt64(CfgTLSReloadInterval); interval > 0 {
+		c.ReloadInterval = time.Duration(interval) * time.Second
+	}
+	return Setup(c)
+}
+
+// Setup applies the TLS config and starts to watch the files of the certificates.
+func Setup(c *Config) (err error) {
+	if !IsValidMode(c.Mode) {
+		return fmt.Errorf("%w: %v", ErrInvalidMode, c.Mode)
+	}
+	mgr.Lock()
+	defer mgr.Unlock()
+	mgr.cfg = *c
+	if mgr.cfg.ReloadInterval <= 0 {
+		mgr.cfg.ReloadInterval = defaultReloadInterval
+	}
+	if err = mgr.reload(); err != nil {
+		return
+	}
+	if mgr.stopC != nil {
+		close(mgr.stopC)
+		mgr.stopC = nil
+	}
+	if mgr.cfg.CertFile != "" || mgr.cfg.CAFile != "" {
+		mgr.stopC = make(chan struct{})
+		go mgr.watch(mgr.stopC, mgr.cfg.ReloadInterval)
+	}
+	log.LogInfof("action[tlsSetup] mode(%v) cert(%v) ca(%v)", c.Mode, c.CertFile, c.CAFile)
+	return
+}
+
+// Stop stops watching the files of the certificates.
+func Stop() {
+	mgr.Lock()
+	defer mgr.Unlock()
+	if mgr.stopC != nil {
+		close(mgr.stopC)
+		mgr.stopC = nil
+	}
+}
+
+// Reload reloads the certificate and the CA from the files.
+func Reload() (err error) {
+	mgr.Lock()
+	defer mgr.Unlock()
+	return mgr.reload()
+}
+
+// SetTrust applies the mode and the CA bundle of the cluster, the empty mode keeps the local mode and the
+// empty CA bundle trusts the local CA file only. It is used by the master which owns them.
+func SetTrust(mode, caPem string) (err error) {
+	if !IsValidMode(mode) {
+		return fmt.Errorf("%w: %v", ErrInvalidMode, mode)
+	}
+	mgr.Lock()
+	defer mgr.Unlock()
+	return mgr.setTrust(mode, caPem)
+}
+
+// SetTrustFrom applies the mode and the CA bundle distributed by the master over the connection. They are
+// ignored unless the connection is secured by TLS and the peer is verified by the local CA file, since the
+// plaintext one and the CA bundle distributed before can be forged.
+func SetTrustFrom(conn net.Conn, mode, caPem string) (err error) {
+	if !IsValidMode(mode) {
+		return fmt.Errorf("%w: %v", ErrInvalidMode, mode)
+	}
+	mgr.Lock()
+	defer mgr.Unlock()
+	old := mgr.load()
+	if old.trustMode == mode && old.trustCAPem == caPem {
+		return
+	}
+	if err = verifyByLocalCA(conn, old.localCAPem); err != nil {
+		log.LogWarnf("action[tlsSetTrust] ignore tls trust from connection(%v), err(%v)", conn.RemoteAddr(), err)
+		return nil
+	}
+	return mgr.setTrust(mode, caPem)
+}
+
+// verifyByLocalCA verifies the peer of the TLS connection by the local CA file only.
+func verifyByLocalCA(conn net.Conn, localCAPem []byte) (err error) {
+	if len(localCAPem) == 0 {
+		return ErrNoTrustedCA
+	}
+	tlsConn, ok := tlsConnOf(conn)
+	if !ok {
+		return errors.New("connection not secured by tls")
+	}
+	cs := tlsConn.ConnectionState()
+	if !cs.HandshakeComplete || len(cs.PeerCertificates) == 0 {
+		return errors.New("no tls certificate from peer")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if !opts.Roots.AppendCertsFromPEM(localCAPem) {
+		return fmt.Errorf("invalid tls CA file")
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err = cs.PeerCertificates[0].Verify(opts)
+	return
+}
+
+func (m *manager) setTrust(mode, caPem string) (err error) {
+	old := m.load()
+	if old.trustMode == mode && old.trustCAPem == caPem {
+		return
+	}
+	s := *old
+	s.trustMode, s.trustCAPem = mode, caPem
+	if s.pool, err = buildPool(s.localCAPem, caPem); err != nil {
+		return
+	}
+	m.state.Store(&s)
+	log.LogWarnf("action[tlsSetTrust] mode changed from(%v) to(%v)", old.mode(), s.mode())
+	return
+}
+
+// Mode returns the TLS mode in effect.
+func Mode() string {
+	return mgr.load().mode()
+}
+
+func buildPool(localCAPem []byte, caPem string) (pool *x509.CertPool, err error) {
+	if len(localCAPem) == 0 && caPem == "" {
+		return
+	}
+	pool = x509.NewCertPool()
+	if len(localCAPem) > 0 && !pool.AppendCertsFromPEM(localCAPem) {
+		return nil, fmt.Errorf("invalid tls CA file")
+	}
+	if caPem != "" && !pool.AppendCertsFromPEM([]byte(caPem)) {
+		return nil, fmt.Errorf("invalid tls CA bundle from master")
+	}
+	return
+}
+
+func (m *manager) reload() (err error) {
+	s := *m.load()
+	s.localMode = m.cfg.Mode
+	s.cert, s.localCAPem = nil, nil
+	if m.cfg.CertFile != "" || m.cfg.KeyFile != "" {
+		var cert tls.Certificate
+		if cert, err = tls.LoadX509KeyPair(m.cfg.CertFile, m.cfg.KeyFile); err != nil {
+			return
+		}
+		s.cert = &cert
+	}
+	if m.cfg.CAFile != "" {
+		if s.localCAPem, err = os.ReadFile(m.cfg.CAFile); err != nil {
+			return
+		}
+	}
+	if s.pool, err = buildPool(s.localCAPem, s.trustCAPem); err != nil {
+		return
+	}
+	for _, name := range []string{m.cfg.CertFile, m.cfg.KeyFile, m.cfg.CAFile} {
+		if info, e := os.Stat(name); e == nil {
+			m.modTime[name] = info.ModTime()
+		}
+	}
+	m.state.Store(&s)
+	return
+}
+
+func (m *manager) changed() bool {
+	for _, name := range []string{m.cfg.CertFile, m.cfg.KeyFile, m.cfg.CAFile} {
+		if name == "" {
+			continue
+		}
+		if info, err := os.Stat(name); err == nil && !info.ModTime().Equal(m.modTime[name]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *manager) watch(stopC chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopC:
+			return
+		case <-ticker.C:
+		}
+		m.Lock()
+		if m.changed() {
+			if err := m.reload(); err != nil {
+				log.LogErrorf("action[tlsWatch] reload certificates failed, keep the old ones, err(%v)", err)
+			} else {
+				log.LogWarnf("action[tlsWatch] certificates reloaded")
+			}
+		}
+		m.Unlock()
+	}
+}
+
+// verifyPeer verifies the certificate chain of the peer against the trusted CAs. The nodes are
+// addressed by IP, so the chain is verified without the host name.
+func verifyPeer(rawCerts [][]byte) (err error) {
+	s := mgr.load()
+	if s.pool == nil {
+		return ErrNoTrustedCA
+	}
+	if len(rawCerts) == 0 {
+		return errors.New("no tls certificate from peer")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(raw); err != nil {
+			return
+		}
+		certs = append(certs, cert)
+	}
+	opts := x509.VerifyOptions{
+		Roots:         s.pool,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err = certs[0].Verify(opts)
+	return
+}
+
+func getCertificate() (*tls.Certificate, error) {
+	if cert := mgr.load().cert; cert != nil {
+		return cert, nil
+	}
+	return nil, ErrNoCertificate
+}
+
+func clientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		// the chain is verified by VerifyPeerCertificate without the host name
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return getCertificate()
+		},
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyPeer(rawCerts)
+		},
+	}
+}
+
+func serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		ClientAuth: tls.RequireAnyClientCert,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return getCertificate()
+		},
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyPeer(rawCerts)
+		},
+	}
+}
+
+// DialTimeout dials the TCP address and secures the connection by the TLS mode in effect.
+func DialTimeout(addr string, timeout time.Duration) (conn net.Conn, err error) {
+	if conn, err = dialTCP(addr, timeout); err != nil {
+		return
+	}
+	mode := Mode()
+	if mode == ModeDisable || mode == ModePermissive {
+		return
+	}
+	var tlsConn net.Conn
+	if tlsConn, err = Client(conn, timeout); err == nil {
+		return tlsConn, nil
+	}
+	if mode == ModeStrict {
+		return nil, err
+	}
+	log.LogWarnf("action[tlsDial] handshake with(%v) failed, fall back to plaintext, err(%v)", addr, err)
+	return dialTCP(addr, timeout)
+}
+
+func dialTCP(addr string, timeout time.Duration) (conn net.Conn, err error) {
+	if conn, err = net.DialTimeout("tcp", addr, timeout); err != nil {
+		return
+	}
+	if c, ok := conn.(*net.TCPConn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
+	return
+}
+
+// Client runs the TLS handshake on the connection dialed, the connection is closed if it fails.
+func Client(conn net.Conn, timeout time.Duration) (net.Conn, error) {
+	if timeout <= 0 {
+		timeout = defaultHandshakeTime
+	}
+	tlsConn := tls.Client(conn, clientConfig())
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+// Listener accepts both the TLS and the plaintext connections by the first byte sent by the client.
+type Listener struct {
+	net.Listener
+}
+
+// NewListener wraps the TCP listener to serve the connections by the TLS mode in effect.
+func NewListener(ln net.Listener) net.Listener {
+	return &Listener{Listener: ln}
+}
+
+// Listen listens on the TCP address and serves the connections by the TLS mode in effect.
+func Listen(network, addr string) (ln net.Listener, err error) {
+	if ln, err = net.Listen(network, addr); err != nil {
+		return
+	}
+	return NewListener(ln), nil
+}
+
+func (l *Listener) Accept() (conn net.Conn, err error) {
+	if conn, err = l.Listener.Accept(); err != nil {
+		return
+	}
+	if Mode() == ModeDisable {
+		return
+	}
+	return &serverConn{Conn: conn}, nil
+}
+
+// serverConn decides the connection is TLS or plaintext on the first read or write, the handshake
+// runs in the goroutine serving the connection rather than the accepting one.
+type serverConn struct {
+	net.Conn
+	once sync.Once
+	conn net.Conn
+	err  error
+
+	// the deadlines set by the caller, restored after the handshake
+	deadlineLock  sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func (c *serverConn) SetDeadline(t time.Time) error {
+	c.deadlineLock.Lock()
+	c.readDeadline, c.writeDeadline = t, t
+	c.deadlineLock.Unlock()
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *serverConn) SetReadDeadline(t time.Time) error {
+	c.deadlineLock.Lock()
+	c.readDeadline = t
+	c.deadlineLock.Unlock()
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *serverConn) SetWriteDeadline(t time.Time) error {
+	c.deadlineLock.Lock()
+	c.writeDeadline = t
+	c.deadlineLock.Unlock()
+	return c.Conn.SetWriteDeadline(t)
+}
+
+// setHandshakeDeadline bounds the handshake by the default handshake time, or the deadline of the caller
+// if it is earlier.
+func (c *serverConn) setHandshakeDeadline() {
+	c.deadlineLock.Lock()
+	defer c.deadlineLock.Unlock()
+	deadline := time.Now().Add(defaultHandshakeTime)
+	read, write := deadline, deadline
+	if !c.readDeadline.IsZero() && c.readDeadline.Before(deadline) {
+		read = c.readDeadline
+	}
+	if !c.writeDeadline.IsZero() && c.writeDeadline.Before(deadline) {
+		write = c.writeDeadline
+	}
+	c.Conn.SetReadDeadline(read)
+	c.Conn.SetWriteDeadline(write)
+}
+
+func (c *serverConn) restoreDeadline() {
+	c.deadlineLock.Lock()
+	defer c.deadlineLock.Unlock()
+	c.Conn.SetReadDeadline(c.readDeadline)
+	c.Conn.SetWriteDeadline(c.writeDeadline)
+}
+
+func (c *serverConn) init() error {
+	c.once.Do(func() {
+		var first [1]byte
+		c.setHandshakeDeadline()
+		defer c.restoreDeadline()
+		if _, c.err = c.Conn.Read(first[:]); c.err != nil {
+			return
+		}
+		conn := &prefixConn{Conn: c.Conn, prefix: first[:]}
+		if first[0] != recordTypeHandshake {
+			if Mode() == ModeStrict {
+				c.err = ErrPlaintextRejected
+				log.LogWarnf("action[tlsAccept] reject plaintext connection from(%v)", c.Conn.RemoteAddr())
+				return
+			}
+			c.conn = conn
+			return
+		}
+		tlsConn := tls.Server(conn, serverConfig())
+		if c.err = tlsConn.Handshake(); c.err != nil {
+			log.LogWarnf("action[tlsAccept] handshake with(%v) failed, err(%v)", c.Conn.RemoteAddr(), c.err)
+			return
+		}
+		c.conn = tlsConn
+	})
+	return c.err
+}
+
+func (c *serverConn) Read(b []byte) (n int, err error) {
+	if err = c.init(); err != nil {
+		return
+	}
+	return c.conn.Read(b)
+}
+
+func (c *serverConn) Write(b []byte) (n int, err error) {
+	if err = c.init(); err != nil {
+		return
+	}
+	return c.conn.Write(b)
+}
+
+func (c *serverConn) Close() error {
+	return c.Conn.Close()
+}
+
+// NetConn returns the connection accepted by the listener.
+func (c *serverConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// IsTLS returns whether the connection accepted or dialed is secured by TLS.
+func IsTLS(conn net.Conn) bool {
+	_, ok := tlsConnOf(conn)
+	return ok
+}
+
+func tlsConnOf(conn net.Conn) (*tls.Conn, bool) {
+	for {
+		switch c := conn.(type) {
+		case *tls.Conn:
+			return c, true
+		case *serverConn:
+			if c.init() != nil {
+				return nil, false
+			}
+			tlsConn, ok := c.conn.(*tls.Conn)
+			return tlsConn, ok
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// TCPConn returns the TCP connection under the connection accepted or dialed.
+func TCPConn(conn net.Conn) (*net.TCPConn, bool) {
+	for {
+		switch c := conn.(type) {
+		case *net.TCPConn:
+			return c, true
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return nil, false
+		}
+	}
+}
+
+type prefixConn struct {
+	net.Conn
+	prefix []byte
+}
+
+func (c *prefixConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *prefixConn) Read(b []byte) (n int, err error) {
+	if len(c.prefix) > 0 {
+		n = copy(b, c.prefix)
+		c.prefix = c.prefix[n:]
+		return
+	}
+	return c.Conn.Read(b)
+}
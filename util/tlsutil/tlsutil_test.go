@@ -0,0 +1,341 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue writes the certificate and the key signed by the CA into the dir.
+func (ca *testCA) issue(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "node"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile, keyFile = path.Join(dir, "node.crt"), path.Join(dir, "node.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return
+}
+
+func setupTLS(t *testing.T, mode string, ca *testCA) {
+	dir := t.TempDir()
+	certFile, keyFile := ca.issue(t, dir)
+	caFile := path.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0o600))
+	require.NoError(t, SetTrust("", ""))
+	require.NoError(t, Setup(&Config{Mode: mode, CertFile: certFile, KeyFile: keyFile, CAFile: caFile}))
+	t.Cleanup(func() {
+		Stop()
+		SetTrust("", "")
+	})
+}
+
+// startEchoServer echoes the data of the connections accepted, and reports whether they are TLS.
+func startEchoServer(t *testing.T) (addr string, tlsC chan bool) {
+	ln, err := Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	tlsC = make(chan bool, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				tlsC <- IsTLS(conn)
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return ln.Addr().String(), tlsC
+}
+
+func echo(conn net.Conn) (err error) {
+	defer conn.Close()
+	if _, err = conn.Write([]byte("cubefs")); err != nil {
+		return
+	}
+	buf := make([]byte, 6)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = io.ReadFull(conn, buf)
+	return
+}
+
+func TestIsValidMode(t *testing.T) {
+	for _, mode := range []string{"", ModeDisable, ModePermissive, ModePrefer, ModeStrict} {
+		require.True(t, IsValidMode(mode))
+	}
+	require.False(t, IsValidMode("tls"))
+	require.ErrorIs(t, Setup(&Config{Mode: "tls"}), ErrInvalidMode)
+	require.ErrorIs(t, SetTrust("tls", ""), ErrInvalidMode)
+}
+
+func TestDialByMode(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	setupTLS(t, ModePermissive, ca)
+	addr, tlsC := startEchoServer(t)
+
+	cases := []struct {
+		mode  string
+		isTLS bool
+	}{
+		{ModeDisable, false},
+		{ModePermissive, false},
+		{ModePrefer, true},
+		{ModeStrict, true},
+	}
+	for _, c := range cases {
+		require.NoError(t, SetTrust(c.mode, ""))
+		conn, err := DialTimeout(addr, time.Second)
+		require.NoError(t, err, c.mode)
+		require.Equal(t, c.isTLS, IsTLS(conn), c.mode)
+		require.NoError(t, echo(conn), c.mode)
+		require.Equal(t, c.isTLS, <-tlsC, c.mode)
+	}
+
+	_, ok := TCPConn(&serverConn{Conn: &prefixConn{Conn: &net.TCPConn{}}})
+	require.True(t, ok)
+}
+
+func TestStrictRejectPlaintext(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	setupTLS(t, ModeStrict, ca)
+	addr, tlsC := startEchoServer(t)
+
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	require.NoError(t, err)
+	require.Error(t, echo(conn))
+	require.False(t, <-tlsC)
+
+	conn, err = DialTimeout(addr, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, echo(conn))
+	require.True(t, <-tlsC)
+}
+
+func TestUntrustedPeer(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	setupTLS(t, ModeStrict, ca)
+	addr, _ := startEchoServer(t)
+
+	// the CA distributed by the master is trusted besides the local CA file
+	other := newTestCA(t, "other")
+	dir := t.TempDir()
+	certFile, keyFile := other.issue(t, dir)
+	local := &Config{Mode: ModeStrict, CertFile: certFile, KeyFile: keyFile, CAFile: mgr.cfg.CAFile}
+	require.NoError(t, Setup(local))
+	_, err := DialTimeout(addr, time.Second)
+	require.Error(t, err)
+
+	require.NoError(t, SetTrust(ModeStrict, string(other.pem)))
+	conn, err := DialTimeout(addr, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, echo(conn))
+
+	// prefer falls back to plaintext if the handshake fails
+	local.Mode = ModePrefer
+	require.NoError(t, Setup(local))
+	require.NoError(t, SetTrust("", ""))
+	conn, err = DialTimeout(addr, time.Second)
+	require.NoError(t, err)
+	require.False(t, IsTLS(conn))
+	conn.Close()
+}
+
+func TestStricterMode(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	setupTLS(t, ModePrefer, ca)
+
+	// the mode distributed never weakens the local one
+	require.NoError(t, SetTrust(ModeDisable, ""))
+	require.Equal(t, ModePrefer, Mode())
+	require.NoError(t, SetTrust(ModeStrict, ""))
+	require.Equal(t, ModeStrict, Mode())
+	require.NoError(t, SetTrust("", ""))
+	require.Equal(t, ModePrefer, Mode())
+}
+
+// handshake runs the TLS handshake over the pipe, and returns the server side of it.
+func handshake(t *testing.T, client *tls.Config) net.Conn {
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+	server := tls.Server(serverConn, serverConfig())
+	errC := make(chan error, 1)
+	go func() {
+		errC <- tls.Client(clientConn, client).Handshake()
+	}()
+	require.NoError(t, server.Handshake())
+	require.NoError(t, <-errC)
+	return server
+}
+
+func TestTrustFrom(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	setupTLS(t, ModePermissive, ca)
+	other := newTestCA(t, "other")
+
+	// the trust over the plaintext connection or before the handshake is ignored
+	plain, peer := net.Pipe()
+	defer plain.Close()
+	defer peer.Close()
+	require.NoError(t, SetTrustFrom(plain, ModeStrict, string(other.pem)))
+	require.NoError(t, SetTrustFrom(tls.Client(plain, clientConfig()), ModeStrict, string(other.pem)))
+	require.Equal(t, ModePermissive, Mode())
+	require.Empty(t, mgr.load().trustCAPem)
+	require.ErrorIs(t, SetTrustFrom(plain, "tls", ""), ErrInvalidMode)
+
+	// the trust over the connection verified by the local CA file is accepted
+	conn := handshake(t, clientConfig())
+	require.NoError(t, SetTrustFrom(conn, ModeStrict, string(other.pem)))
+	require.Equal(t, ModeStrict, Mode())
+	require.Equal(t, string(other.pem), mgr.load().trustCAPem)
+
+	// the peer only verified by the CA bundle distributed can not change the trust
+	certFile, keyFile := other.issue(t, t.TempDir())
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	forged := clientConfig()
+	forged.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return &cert, nil
+	}
+	conn = handshake(t, forged)
+	require.NoError(t, SetTrustFrom(conn, ModePermissive, ""))
+	require.Equal(t, ModeStrict, Mode())
+	require.Equal(t, string(other.pem), mgr.load().trustCAPem)
+}
+
+func TestTrustFromWithoutLocalCA(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	setupTLS(t, ModePermissive, ca)
+	conn := handshake(t, clientConfig())
+
+	// the node without the local CA file only takes the mode of the local config
+	local := mgr.cfg
+	local.CAFile = ""
+	require.NoError(t, SetTrust("", string(ca.pem)))
+	require.NoError(t, Setup(&local))
+	require.NoError(t, SetTrustFrom(conn, ModeStrict, ""))
+	require.Equal(t, ModePermissive, Mode())
+}
+
+func TestKeepDeadline(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	setupTLS(t, ModePrefer, ca)
+	ln, err := Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	errC := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			errC <- err
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+		_, err = conn.Read(make([]byte, 1))
+		errC <- err
+	}()
+	conn, err := DialTimeout(ln.Addr().String(), time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.True(t, IsTLS(conn))
+
+	select {
+	case err = <-errC:
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		require.True(t, netErr.Timeout())
+	case <-time.After(3 * time.Second):
+		t.Fatal("the read deadline is lost after the handshake")
+	}
+}
+
+func TestReload(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	setupTLS(t, ModeStrict, ca)
+	old := mgr.load().cert
+
+	ca.issue(t, path.Dir(mgr.cfg.CertFile))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(mgr.cfg.CertFile, future, future))
+	mgr.Lock()
+	require.True(t, mgr.changed())
+	require.NoError(t, mgr.reload())
+	require.False(t, mgr.changed())
+	mgr.Unlock()
+	require.NotEqual(t, old, mgr.load().cert)
+
+	require.NoError(t, os.WriteFile(mgr.cfg.CertFile, []byte("invalid"), 0o600))
+	require.Error(t, Reload())
+	require.NotNil(t, mgr.load().cert)
+}